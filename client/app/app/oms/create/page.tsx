@@ -35,8 +35,10 @@ export default function CreateOrderPage() {
   const [quantity, setQuantity] = useState<string>("");
   const [orderType, setOrderType] = useState<OrderType>("MARKET");
   const [limitPrice, setLimitPrice] = useState<string>("");
+  const [limitYield, setLimitYield] = useState<string>("");
   const [curveSpreadBp, setCurveSpreadBp] = useState<string>("");
   const [timeInForce, setTimeInForce] = useState<TimeInForce>("DAY");
+  const [expireAt, setExpireAt] = useState<string>("");
   const [notes, setNotes] = useState<string>("");
   const [isSubmitting, setIsSubmitting] = useState(false);
   const [accounts, setAccounts] = useState<
@@ -92,7 +94,9 @@ export default function CreateOrderPage() {
     quantity &&
     parseFloat(quantity) > 0 &&
     (orderType !== "LIMIT" || (limitPrice && parseFloat(limitPrice) > 0)) &&
-    (orderType !== "CURVE_RELATIVE" || curveSpreadBp);
+    (orderType !== "CURVE_RELATIVE" || curveSpreadBp) &&
+    (orderType !== "YIELD_LIMIT" || limitYield) &&
+    (timeInForce !== "GTD" || (expireAt && new Date(expireAt) > new Date()));
 
   const buildRequest = () => {
     const request: {
@@ -102,8 +106,10 @@ export default function CreateOrderPage() {
       quantity: number;
       orderType: OrderType;
       limitPrice?: number;
+      limitYield?: number;
       curveSpreadBp?: number;
       timeInForce: TimeInForce;
+      expireAt?: string;
       createdBy: string;
     } = {
       accountId,
@@ -118,9 +124,15 @@ export default function CreateOrderPage() {
     if (orderType === "LIMIT" && limitPrice) {
       request.limitPrice = Number.parseFloat(limitPrice);
     }
+    if (orderType === "YIELD_LIMIT" && limitYield) {
+      request.limitYield = Number.parseFloat(limitYield);
+    }
     if (orderType === "CURVE_RELATIVE" && curveSpreadBp) {
       request.curveSpreadBp = Number.parseFloat(curveSpreadBp);
     }
+    if (timeInForce === "GTD" && expireAt) {
+      request.expireAt = new Date(expireAt).toISOString();
+    }
 
     return request;
   };
@@ -365,6 +377,12 @@ export default function CreateOrderPage() {
                       Limit
                     </Label>
                   </div>
+                  <div className="flex items-center space-x-2">
+                    <RadioGroupItem value="YIELD_LIMIT" id="yield-limit" />
+                    <Label htmlFor="yield-limit" className="cursor-pointer">
+                      Yield Limit
+                    </Label>
+                  </div>
                   <div className="flex items-center space-x-2">
                     <RadioGroupItem value="CURVE_RELATIVE" id="curve" />
                     <Label htmlFor="curve" className="cursor-pointer">
@@ -389,6 +407,24 @@ export default function CreateOrderPage() {
                 </div>
               )}
 
+              {/* Limit Yield (conditional) */}
+              {orderType === "YIELD_LIMIT" && (
+                <div className="space-y-2">
+                  <Label htmlFor="limitYield">Limit Yield (%)</Label>
+                  <Input
+                    id="limitYield"
+                    type="number"
+                    step="0.001"
+                    placeholder="Enter limit yield..."
+                    value={limitYield}
+                    onChange={(e) => setLimitYield(e.target.value)}
+                  />
+                  <p className="text-xs text-muted-foreground">
+                    Converted to a clean price limit on the latest curve
+                  </p>
+                </div>
+              )}
+
               {/* Curve Spread (conditional) */}
               {orderType === "CURVE_RELATIVE" && (
                 <div className="space-y-2">
@@ -418,8 +454,18 @@ export default function CreateOrderPage() {
                   <SelectContent>
                     <SelectItem value="DAY">DAY</SelectItem>
                     <SelectItem value="IOC">IOC (Immediate or Cancel)</SelectItem>
+                    <SelectItem value="GTC">GTC (Good Till Cancelled)</SelectItem>
+                    <SelectItem value="GTD">GTD (Good Till Date)</SelectItem>
                   </SelectContent>
                 </Select>
+                {timeInForce === "GTD" && (
+                  <Input
+                    type="datetime-local"
+                    className="w-64"
+                    value={expireAt}
+                    onChange={(e) => setExpireAt(e.target.value)}
+                  />
+                )}
               </div>
 
               {/* Notes */}
@@ -504,6 +550,13 @@ export default function CreateOrderPage() {
                   </div>
                 )}
 
+                {orderType === "YIELD_LIMIT" && limitYield && (
+                  <div className="flex justify-between">
+                    <span className="text-muted-foreground">Limit Yield</span>
+                    <span className="font-medium">{parseFloat(limitYield).toFixed(3)}%</span>
+                  </div>
+                )}
+
                 {orderType === "CURVE_RELATIVE" && curveSpreadBp && (
                   <div className="flex justify-between">
                     <span className="text-muted-foreground">Curve Spread</span>
@@ -513,7 +566,10 @@ export default function CreateOrderPage() {
 
                 <div className="flex justify-between">
                   <span className="text-muted-foreground">Time in Force</span>
-                  <span className="font-medium">{timeInForce}</span>
+                  <span className="font-medium">
+                    {timeInForce}
+                    {timeInForce === "GTD" && expireAt && ` until ${new Date(expireAt).toLocaleString()}`}
+                  </span>
                 </div>
               </div>
             </CardContent>
@@ -536,6 +592,9 @@ export default function CreateOrderPage() {
                   {orderType === "LIMIT" && !limitPrice && (
                     <li>- Enter limit price</li>
                   )}
+                  {orderType === "YIELD_LIMIT" && !limitYield && (
+                    <li>- Enter limit yield</li>
+                  )}
                   {orderType === "CURVE_RELATIVE" && !curveSpreadBp && (
                     <li>- Enter curve spread</li>
                   )}
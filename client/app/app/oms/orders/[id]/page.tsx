@@ -68,8 +68,9 @@ export default function OrderDetailPage({
   }
 
   const canApprove =
-    order.state === "APPROVAL_PENDING" && order.complianceResult?.status !== "BLOCK";
-  const canCancel = !["FILLED", "SETTLED", "CANCELLED", "REJECTED"].includes(order.state);
+    (order.state === "DRAFT" || order.state === "APPROVAL_PENDING") &&
+    order.complianceResult?.status !== "BLOCK";
+  const canCancel = !["FILLED", "SETTLED", "CANCELLED", "REJECTED", "EXPIRED", "REPLACED"].includes(order.state);
   const canSendToEms = order.state === "APPROVED";
 
   const handleApprove = async () => {
@@ -222,12 +223,21 @@ export default function OrderDetailPage({
                       {order.orderType}
                     </Badge>
                   </div>
-                  {order.orderType === "LIMIT" && order.limitPrice && (
+                  {(order.orderType === "LIMIT" || order.orderType === "YIELD_LIMIT") && order.limitPrice && (
                     <div>
                       <p className="text-sm text-muted-foreground">Limit Price</p>
                       <p className="text-xl font-bold">{formatPrice(order.limitPrice)}</p>
                     </div>
                   )}
+                  {(order.orderType === "LIMIT" || order.orderType === "YIELD_LIMIT") && order.limitYield != null && (
+                    <div>
+                      <p className="text-sm text-muted-foreground">Limit Yield</p>
+                      <p className="text-xl font-bold">{order.limitYield.toFixed(3)}%</p>
+                      {order.limitPricedAsOf && (
+                        <p className="text-xs text-muted-foreground">priced on {order.limitPricedAsOf} curve</p>
+                      )}
+                    </div>
+                  )}
                   {order.orderType === "CURVE_RELATIVE" && order.curveSpreadBp && (
                     <div>
                       <p className="text-sm text-muted-foreground">Curve Spread</p>
@@ -244,6 +254,44 @@ export default function OrderDetailPage({
             </CardContent>
           </Card>
 
+          {/* Cross Trades */}
+          {order.crossTrades && order.crossTrades.length > 0 && (
+            <Card>
+              <CardHeader>
+                <CardTitle>Cross Trades</CardTitle>
+                <CardDescription>
+                  Crossed internally against other accounts at the evaluated price
+                </CardDescription>
+              </CardHeader>
+              <CardContent>
+                <Table>
+                  <TableHeader>
+                    <TableRow>
+                      <TableHead>Counterparty</TableHead>
+                      <TableHead className="text-right">Quantity</TableHead>
+                      <TableHead className="text-right">Price</TableHead>
+                      <TableHead>Priced On</TableHead>
+                    </TableRow>
+                  </TableHeader>
+                  <TableBody>
+                    {order.crossTrades.map((leg) => (
+                      <TableRow key={leg.crossId}>
+                        <TableCell>
+                          <Link href={`/app/oms/orders/${leg.counterpartyOrderId}`} className="hover:underline">
+                            {leg.counterpartyAccountId}
+                          </Link>
+                        </TableCell>
+                        <TableCell className="text-right">{formatOrderQuantity(leg.quantity)}</TableCell>
+                        <TableCell className="text-right">{formatPrice(leg.price)}</TableCell>
+                        <TableCell>{leg.priceAsOf}</TableCell>
+                      </TableRow>
+                    ))}
+                  </TableBody>
+                </Table>
+              </CardContent>
+            </Card>
+          )}
+
           {/* Compliance Results */}
           {order.complianceResult && (
             <Card>
@@ -430,6 +478,18 @@ export default function OrderDetailPage({
                     <span className="font-medium">{formatDate(order.settledAt)}</span>
                   </div>
                 )}
+                {order.expireAt && !order.expiredAt && (
+                  <div className="flex justify-between">
+                    <span className="text-muted-foreground">Expires</span>
+                    <span className="font-medium">{formatDate(order.expireAt)}</span>
+                  </div>
+                )}
+                {order.expiredAt && (
+                  <div className="flex justify-between">
+                    <span className="text-muted-foreground">Expired</span>
+                    <span className="font-medium">{formatDate(order.expiredAt)}</span>
+                  </div>
+                )}
               </div>
             </CardContent>
           </Card>
@@ -98,7 +98,10 @@ export default function OrderBlotterPage() {
 
   const canApprove = Array.from(selectedOrders).every((id) => {
     const order = orders.find((o) => o.orderId === id);
-    return order?.state === "APPROVAL_PENDING" && order.complianceResult?.status !== "BLOCK";
+    return (
+      (order?.state === "DRAFT" || order?.state === "APPROVAL_PENDING") &&
+      order.complianceResult?.status !== "BLOCK"
+    );
   });
 
   const canCancel = Array.from(selectedOrders).every((id) => {
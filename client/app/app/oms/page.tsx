@@ -36,6 +36,8 @@ export default function OMSDashboardPage() {
       CANCELLED: 0,
       REJECTED: 0,
       SETTLED: 0,
+      EXPIRED: 0,
+      REPLACED: 0,
     };
 
     orders.forEach((order) => {
@@ -29,7 +29,7 @@ import { RadioGroup, RadioGroupItem } from "@/components/ui/radio-group";
 import { Label } from "@/components/ui/label";
 import { getAccounts } from "@/lib/api/pms";
 import { fetchInstruments } from "@/lib/marketdata/api";
-import { useBulkCreateOrders } from "@/lib/hooks/use-oms";
+import { confirmUpload, uploadOrderFile, type UploadRowResult } from "@/lib/api/oms";
 import { formatOrderQuantity, formatPrice } from "@/lib/oms/ui";
 import type { BulkOrderRow, BulkOrderValidationResult } from "@/lib/oms/types";
 import type { InstrumentWithPricing } from "@/lib/marketdata/types";
@@ -44,13 +44,13 @@ export default function BulkUploadPage() {
   const [submitOption, setSubmitOption] = useState<"draft" | "approval">("draft");
   const [progress, setProgress] = useState(0);
   const [results, setResults] = useState<{ success: number; errors: number }>({ success: 0, errors: 0 });
+  const [batchId, setBatchId] = useState<string | null>(null);
+  const [uploadError, setUploadError] = useState<string | null>(null);
   const [accounts, setAccounts] = useState<
     Array<{ accountId: string; householdId: string; name: string; householdName?: string }>
   >([]);
   const [instruments, setInstruments] = useState<InstrumentWithPricing[]>([]);
 
-  const bulkCreate = useBulkCreateOrders();
-
   useEffect(() => {
     let active = true;
     const loadData = async () => {
@@ -75,97 +75,32 @@ export default function BulkUploadPage() {
     };
   }, []);
 
-  const parseNumber = (value: string | undefined): number | undefined => {
-    if (!value) return undefined;
-    const parsed = Number(value);
-    return Number.isFinite(parsed) ? parsed : undefined;
-  };
-
-  const parseCsvText = (text: string): BulkOrderRow[] => {
-    const lines = text
-      .split(/\r?\n/)
-      .map((line) => line.trim())
-      .filter(Boolean);
-
-    if (lines.length < 2) return [];
-
-    const headers = lines[0].split(",").map((header) => header.trim());
-    return lines.slice(1).map((line) => {
-      const values = line.split(",").map((value) => value.trim());
-      const row: Record<string, string> = {};
-      headers.forEach((header, idx) => {
-        row[header] = values[idx] ?? "";
-      });
-
-      const side = (row.side || "").toUpperCase();
-      const orderType = (row.orderType || "MARKET").toUpperCase();
-      const timeInForce = (row.timeInForce || "DAY").toUpperCase();
-
-      return {
-        accountId: row.accountId || row.account || "",
-        cusip: row.cusip || row.instrumentId || "",
-        side: (side === "SELL" ? "SELL" : "BUY"),
-        quantity: Number(row.quantity ?? 0),
-        orderType: orderType === "LIMIT"
-          ? "LIMIT"
-          : orderType === "CURVE_RELATIVE"
-          ? "CURVE_RELATIVE"
-          : "MARKET",
-        limitPrice: parseNumber(row.limitPrice),
-        curveSpreadBp: parseNumber(row.curveSpreadBp),
-        timeInForce: timeInForce === "IOC" ? "IOC" : "DAY",
-        notes: row.notes || undefined,
-      };
-    });
-  };
-
-  // Validate a parsed order
-  const validateOrder = (row: BulkOrderRow, index: number): BulkOrderValidationResult => {
-    const errors: string[] = [];
-
-    // Validate account
-    if (!row.accountId) {
-      errors.push("Account ID required");
-    } else {
-      const account = accounts.find((a) => a.accountId === row.accountId);
-      if (!account) {
-        errors.push("Account not found");
-      }
-    }
-
-    // Validate instrument
-    if (!row.cusip) {
-      errors.push("CUSIP required");
-    } else {
-      const instrument = instruments.find((i) => i.cusip === row.cusip);
-      if (!instrument) {
-        errors.push("Instrument not found");
-      }
-    }
-
-    // Validate quantity
-    if (!row.quantity || row.quantity <= 0) {
-      errors.push("Invalid quantity");
-    }
-
-    // Validate side
-    if (!["BUY", "SELL"].includes(row.side)) {
-      errors.push("Invalid side (must be BUY or SELL)");
-    }
-
-    // Validate order type specific fields
-    if (row.orderType === "LIMIT" && (!row.limitPrice || row.limitPrice <= 0)) {
-      errors.push("Limit price required for LIMIT orders");
-    }
-    if (row.orderType === "CURVE_RELATIVE" && row.curveSpreadBp === undefined) {
-      errors.push("Curve spread required for CURVE_RELATIVE orders");
-    }
+  // Map a server-validated row to the preview shape
+  const toValidationResult = (row: UploadRowResult): BulkOrderValidationResult => {
+    const fields = row.fields || {};
+    const order = row.order;
+    const parseNumber = (value: string | undefined): number | undefined => {
+      if (!value) return undefined;
+      const parsed = Number(value);
+      return Number.isFinite(parsed) ? parsed : undefined;
+    };
 
     return {
-      row: index + 1,
-      data: row,
-      isValid: errors.length === 0,
-      errors,
+      row: row.rowNumber,
+      data: {
+        accountId: order?.accountId || fields.accountid || undefined,
+        accountName: fields.accountname || undefined,
+        cusip: order?.instrumentId || fields.cusip || fields.instrumentid || "",
+        side: (order?.side || (fields.side || "").toUpperCase()) as BulkOrderRow["side"],
+        quantity: order?.quantity ?? Number(fields.quantity ?? 0),
+        orderType: (order?.orderType || (fields.ordertype || "").toUpperCase()) as BulkOrderRow["orderType"],
+        limitPrice: order?.limitPrice ?? parseNumber(fields.limitprice),
+        curveSpreadBp: order?.curveSpreadBp ?? parseNumber(fields.curvespreadbp),
+        timeInForce: order?.timeInForce,
+        notes: fields.notes || undefined,
+      },
+      isValid: row.valid,
+      errors: row.errors || [],
     };
   };
 
@@ -184,7 +119,7 @@ export default function BulkUploadPage() {
     setIsDragging(false);
 
     const file = e.dataTransfer.files[0];
-    if (file && file.name.endsWith(".csv")) {
+    if (file && /\.(csv|xlsx)$/i.test(file.name)) {
       void processFile(file);
     }
   }, []);
@@ -198,58 +133,42 @@ export default function BulkUploadPage() {
 
   const processFile = async (file: File) => {
     setFileName(file.name);
+    setUploadError(null);
     try {
-      const contents = await file.text();
-      const rows = parseCsvText(contents);
-      const validated = rows.map((order, idx) => validateOrder(order, idx));
-      setParsedOrders(validated);
+      const response = await uploadOrderFile(file, "advisor@instant.com");
+      setBatchId(response.batchId);
+      setParsedOrders(response.batch.rows.map(toValidationResult));
       setStep("preview");
     } catch (err) {
-      console.error("Failed to parse CSV", err);
+      console.error("Failed to upload order file", err);
+      setUploadError(err instanceof Error ? err.message : "Failed to upload order file");
       setParsedOrders([]);
-      setStep("preview");
+      setBatchId(null);
     }
   };
 
   const handleSubmit = async () => {
+    if (!batchId) return;
     setStep("processing");
-    setProgress(10);
+    setProgress(40);
 
-    const validOrders = parsedOrders.filter((o) => o.isValid);
     const invalidOrders = parsedOrders.filter((o) => !o.isValid);
 
     try {
-      setProgress(40);
-      const response = await bulkCreate.mutateAsync({
-        orders: validOrders.map((order) => ({
-          accountId: order.data.accountId || "",
-          instrumentId: order.data.cusip,
-          side: order.data.side,
-          quantity: order.data.quantity,
-          orderType: order.data.orderType,
-          limitPrice: order.data.limitPrice,
-          curveSpreadBp: order.data.curveSpreadBp,
-          timeInForce: order.data.timeInForce || "DAY",
-          createdBy: "advisor@instant.com",
-        })),
-        createdBy: "advisor@instant.com",
-      });
-
-      const success = response.results.filter((result) => result.status === "created").length;
-      const failed = response.results.length - success;
+      const response = await confirmUpload(batchId, "advisor@instant.com");
 
       setProgress(100);
       setResults({
-        success,
-        errors: failed + invalidOrders.length,
+        success: response.createdCount,
+        errors: response.failedCount + invalidOrders.length,
       });
       setStep("complete");
     } catch (err) {
-      console.error("Failed to submit bulk orders", err);
+      console.error("Failed to confirm upload batch", err);
       setProgress(100);
       setResults({
         success: 0,
-        errors: validOrders.length + invalidOrders.length,
+        errors: parsedOrders.length,
       });
       setStep("complete");
     }
@@ -271,7 +190,7 @@ export default function BulkUploadPage() {
           <div>
             <h1 className="text-2xl font-bold tracking-tight">Bulk Order Upload</h1>
             <p className="text-sm text-muted-foreground">
-              Upload multiple orders from a CSV file
+              Upload multiple orders from a CSV or Excel (.xlsx) file
             </p>
           </div>
         </div>
@@ -282,9 +201,9 @@ export default function BulkUploadPage() {
         <div className="grid gap-6 lg:grid-cols-2">
           <Card>
             <CardHeader>
-              <CardTitle>Upload CSV File</CardTitle>
+              <CardTitle>Upload Order File</CardTitle>
               <CardDescription>
-                Drag and drop your CSV file or click to browse
+                Drag and drop a CSV or XLSX file or click to browse
               </CardDescription>
             </CardHeader>
             <CardContent>
@@ -299,12 +218,12 @@ export default function BulkUploadPage() {
                 onDrop={handleDrop}
               >
                 <Upload className="h-12 w-12 mx-auto mb-4 text-muted-foreground" />
-                <p className="text-lg font-medium mb-2">Drop your CSV file here</p>
+                <p className="text-lg font-medium mb-2">Drop your CSV or XLSX file here</p>
                 <p className="text-muted-foreground mb-4">or</p>
                 <label>
                   <input
                     type="file"
-                    accept=".csv"
+                    accept=".csv,.xlsx"
                     className="hidden"
                     onChange={handleFileSelect}
                   />
@@ -313,7 +232,9 @@ export default function BulkUploadPage() {
                   </Button>
                 </label>
               </div>
-
+              {uploadError && (
+                <p className="mt-4 text-sm text-red-600">{uploadError}</p>
+              )}
             </CardContent>
           </Card>
 
@@ -334,7 +255,8 @@ export default function BulkUploadPage() {
                 <h4 className="font-medium">Required Columns</h4>
                 <ul className="text-sm space-y-1 text-muted-foreground">
                   <li>
-                    <code className="bg-muted px-1 rounded">accountId</code> - Account identifier
+                    <code className="bg-muted px-1 rounded">accountId</code> or{" "}
+                    <code className="bg-muted px-1 rounded">accountName</code> - Account identifier
                   </li>
                   <li>
                     <code className="bg-muted px-1 rounded">cusip</code> - Instrument CUSIP
@@ -370,8 +292,12 @@ export default function BulkUploadPage() {
                 <h4 className="font-medium">Optional Columns</h4>
                 <ul className="text-sm space-y-1 text-muted-foreground">
                   <li>
-                    <code className="bg-muted px-1 rounded">timeInForce</code> - DAY (default) or
-                    IOC
+                    <code className="bg-muted px-1 rounded">timeInForce</code> - DAY (default), IOC,
+                    GTC or GTD
+                  </li>
+                  <li>
+                    <code className="bg-muted px-1 rounded">expireAt</code> - Required for GTD
+                    orders
                   </li>
                   <li>
                     <code className="bg-muted px-1 rounded">notes</code> - Order notes
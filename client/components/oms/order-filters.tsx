@@ -104,6 +104,20 @@ const STATE_OPTIONS: StateOption[] = [
     color: "text-red-600",
     bgColor: "bg-red-500/10 hover:bg-red-500/20 border-red-500/20",
   },
+  {
+    value: "EXPIRED",
+    label: "Expired",
+    icon: Clock,
+    color: "text-gray-500",
+    bgColor: "bg-gray-500/10 hover:bg-gray-500/20 border-gray-500/20",
+  },
+  {
+    value: "REPLACED",
+    label: "Replaced",
+    icon: FileEdit,
+    color: "text-violet-600",
+    bgColor: "bg-violet-500/10 hover:bg-violet-500/20 border-violet-500/20",
+  },
 ];
 
 interface SideOption {
@@ -173,6 +187,7 @@ const ORDER_TYPE_OPTIONS: OrderTypeOption[] = [
   { value: "MARKET", label: "Market", description: "Execute at market price" },
   { value: "LIMIT", label: "Limit", description: "Execute at specified price" },
   { value: "CURVE_RELATIVE", label: "Curve Relative", description: "Spread to curve" },
+  { value: "YIELD_LIMIT", label: "Yield Limit", description: "Execute at specified yield" },
 ];
 
 interface OrderFiltersProps {
@@ -108,9 +108,21 @@ export function OrderRow({
         <Badge variant="secondary">{order.orderType}</Badge>
       </TableCell>
       <TableCell className="text-right">
-        {order.orderType === "LIMIT" && order.limitPrice
-          ? formatPrice(order.limitPrice)
-          : order.orderType === "CURVE_RELATIVE" && order.curveSpreadBp
+        {order.orderType === "YIELD_LIMIT" && order.limitYield != null ? (
+          <div>
+            <div>{order.limitYield.toFixed(3)}%</div>
+            {order.limitPrice != null && (
+              <div className="text-xs text-muted-foreground">{formatPrice(order.limitPrice)}</div>
+            )}
+          </div>
+        ) : order.orderType === "LIMIT" && order.limitPrice ? (
+          <div>
+            <div>{formatPrice(order.limitPrice)}</div>
+            {order.limitYield != null && (
+              <div className="text-xs text-muted-foreground">{order.limitYield.toFixed(3)}%</div>
+            )}
+          </div>
+        ) : order.orderType === "CURVE_RELATIVE" && order.curveSpreadBp
           ? `+${order.curveSpreadBp}bp`
           : "-"}
       </TableCell>
@@ -8,8 +8,8 @@
 const API_BASE_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080';
 
 export type OrderSide = 'BUY' | 'SELL';
-export type OrderType = 'MARKET' | 'LIMIT' | 'CURVE_RELATIVE';
-export type TimeInForce = 'DAY' | 'IOC';
+export type OrderType = 'MARKET' | 'LIMIT' | 'CURVE_RELATIVE' | 'YIELD_LIMIT';
+export type TimeInForce = 'DAY' | 'IOC' | 'GTC' | 'GTD';
 export type OrderState =
   | 'DRAFT'
   | 'APPROVAL_PENDING'
@@ -19,7 +19,9 @@ export type OrderState =
   | 'FILLED'
   | 'CANCELLED'
   | 'REJECTED'
-  | 'SETTLED';
+  | 'SETTLED'
+  | 'EXPIRED'
+  | 'REPLACED';
 
 export interface CreateOrderRequest {
   accountId: string;
@@ -28,8 +30,10 @@ export interface CreateOrderRequest {
   quantity: number;
   orderType: OrderType;
   limitPrice?: number;
+  limitYield?: number; // percent; required for YIELD_LIMIT
   curveSpreadBp?: number;
   timeInForce: TimeInForce;
+  expireAt?: string; // required for GTD
   batchId?: string;
   createdBy: string;
 }
@@ -38,10 +42,29 @@ export interface AmendOrderRequest {
   quantity?: number;
   orderType?: OrderType;
   limitPrice?: number;
+  limitYield?: number;
   curveSpreadBp?: number;
   updatedBy: string;
 }
 
+export interface ReplaceOrderRequest {
+  quantity?: number;
+  orderType?: OrderType;
+  limitPrice?: number;
+  limitYield?: number;
+  curveSpreadBp?: number;
+  timeInForce?: TimeInForce;
+  expireAt?: string;
+  reason?: string;
+  replacedBy: string;
+}
+
+export interface FieldChange {
+  field: string;
+  from: string | number | null;
+  to: string | number | null;
+}
+
 export interface Order {
   orderId: string;
   accountId: string;
@@ -55,10 +78,13 @@ export interface Order {
   quantity: number;
   orderType: OrderType;
   limitPrice?: number;
+  limitYield?: number;
+  limitPricedAsOf?: string;
   curveSpreadBp?: number;
   timeInForce: TimeInForce;
   state: OrderState;
   batchId?: string;
+  blockId?: string;
   complianceResult?: ComplianceResult;
   createdAt: string;
   createdBy: string;
@@ -67,9 +93,50 @@ export interface Order {
   sentToEmsAt?: string;
   fullyFilledAt?: string;
   settledAt?: string;
+  expireAt?: string;
+  expiredAt?: string;
+  chainId?: string;
+  chainVersion?: number;
+  replacesOrderId?: string;
+  replacedByOrderId?: string;
+  nettingId?: string;
+  crossTrades?: CrossTradeLeg[];
   events?: any[];
 }
 
+export interface CrossTradeLeg {
+  crossId: string;
+  nettingId: string;
+  orderId: string;
+  accountId: string;
+  instrumentId: string;
+  side: OrderSide;
+  counterpartyOrderId: string;
+  counterpartyAccountId: string;
+  quantity: number;
+  price: number;
+  priceAsOf: string;
+  executedAt: string;
+}
+
+export interface NetAndSendResponse {
+  nettingId: string;
+  crosses: Array<{
+    crossId: string;
+    instrumentId: string;
+    buyOrderId: string;
+    buyAccountId: string;
+    sellOrderId: string;
+    sellAccountId: string;
+    quantity: number;
+    price: number;
+    priceAsOf: string;
+  }>;
+  residuals: Array<{ orderId: string; quantity: number }>;
+  correlationId: string;
+  status: string;
+}
+
 export interface ComplianceResult {
   status: 'PASS' | 'WARN' | 'BLOCK';
   rulesPassed?: string[];
@@ -91,6 +158,7 @@ export interface BlotterFilters {
   state?: OrderState;
   fromDate?: string;
   toDate?: string;
+  latestOnly?: boolean; // only the newest version of each cancel/replace chain
   limit?: number;
   offset?: number;
 }
@@ -106,6 +174,58 @@ export interface OrderResponse {
   status: string;
 }
 
+export interface AmendOrderResponse extends OrderResponse {
+  complianceStatus: 'PASS' | 'WARN' | 'BLOCK';
+  warnings?: ComplianceViolation[];
+  approvalRequested: boolean;
+  requiredApprovals?: number;
+}
+
+export interface ReplaceOrderResponse extends OrderResponse {
+  replacedOrderId: string;
+  chainId: string;
+  version: number;
+  changes: FieldChange[];
+  complianceStatus: 'PASS' | 'WARN' | 'BLOCK';
+  warnings?: ComplianceViolation[];
+  approvalRequested: boolean;
+  requiredApprovals?: number;
+}
+
+export interface OrderVersion {
+  orderId: string;
+  version: number;
+  state: OrderState;
+  quantity: number;
+  orderType: OrderType;
+  limitPrice?: number;
+  limitYield?: number;
+  curveSpreadBp?: number;
+  timeInForce: TimeInForce;
+  expireAt?: string;
+  createdAt: string;
+  createdBy: string;
+  replacesOrderId?: string;
+  replacedByOrderId?: string;
+  replacedAt?: string;
+  replacedBy?: string;
+  reason?: string;
+  changes: FieldChange[];
+  amendments: Array<{
+    changes: FieldChange[];
+    amendedBy: string;
+    amendedAt: string;
+  }>;
+}
+
+export interface OrderHistoryResponse {
+  orderId: string;
+  chainId: string;
+  latestOrderId: string;
+  versions: OrderVersion[];
+  count: number;
+}
+
 export interface BulkCreateResponse {
   batchId: string;
   correlationId: string;
@@ -116,6 +236,126 @@ export interface BulkCreateResponse {
   }>;
 }
 
+export interface CreateBlockOrderRequest {
+  instrumentId: string; // CUSIP
+  side: OrderSide;
+  orderType: OrderType;
+  limitPrice?: number;
+  limitYield?: number;
+  curveSpreadBp?: number;
+  timeInForce: TimeInForce;
+  expireAt?: string; // required for GTD
+  lotSize?: number; // defaults to 1000
+  allocations: Array<{ accountId: string; quantity: number }>;
+  createdBy: string;
+}
+
+export interface CreateBlockOrderResponse {
+  blockId: string;
+  correlationId: string;
+  status: string;
+  orders: Array<{
+    accountId: string;
+    orderId: string;
+    quantity: number;
+    status: 'created' | 'blocked';
+    error?: string;
+  }>;
+}
+
+export interface BlockAllocation {
+  allocationId: string;
+  executionId: string;
+  orderId: string;
+  accountId: string;
+  quantity: number;
+  targetQuantity: number;
+  price: number;
+  settlementDate: string;
+  createdAt: string;
+}
+
+export interface BlockOrder {
+  blockId: string;
+  instrumentId: string;
+  instrumentName?: string;
+  side: OrderSide;
+  quantity: number;
+  orderType: OrderType;
+  limitPrice?: number;
+  limitYield?: number;
+  curveSpreadBp?: number;
+  timeInForce: TimeInForce;
+  lotSize: number;
+  state: OrderState;
+  filledQuantity: number;
+  avgFillPrice?: number;
+  createdAt: string;
+  createdBy: string;
+  updatedAt: string;
+  sentToEmsAt?: string;
+  settledAt?: string;
+  orders?: Array<{
+    orderId: string;
+    accountId: string;
+    accountName?: string;
+    quantity: number;
+    state: OrderState;
+  }>;
+  allocations?: BlockAllocation[];
+}
+
+export type UploadBatchStatus = 'RECEIVED' | 'VALIDATED' | 'CONFIRMED';
+
+export interface UploadRowResult {
+  rowNumber: number;
+  valid: boolean;
+  errors: string[];
+  fields: Record<string, string>;
+  order?: CreateOrderRequest;
+}
+
+export interface UploadCreatedOrder {
+  rowNumber: number;
+  orderId?: string;
+  status: string;
+  error?: string;
+}
+
+export interface UploadBatch {
+  batchId: string;
+  fileName: string;
+  format: 'CSV' | 'XLSX';
+  status: UploadBatchStatus;
+  rowCount: number;
+  validCount: number;
+  errorCount: number;
+  rows: UploadRowResult[];
+  orders?: UploadCreatedOrder[];
+  createdCount?: number;
+  failedCount?: number;
+  uploadedBy: string;
+  confirmedBy?: string;
+  confirmedAt?: string;
+  createdAt?: string;
+}
+
+export interface UploadOrdersResponse {
+  batchId: string;
+  batch: UploadBatch;
+  correlationId: string;
+  status: string;
+}
+
+export interface ConfirmUploadResponse {
+  batchId: string;
+  orders: UploadCreatedOrder[];
+  createdCount: number;
+  failedCount: number;
+  correlationId: string;
+  status: string;
+}
+
 /**
  * Create a new order
  */
@@ -139,7 +379,7 @@ export async function createOrder(request: CreateOrderRequest): Promise<OrderRes
 /**
  * Amend an existing order
  */
-export async function amendOrder(orderId: string, request: AmendOrderRequest): Promise<OrderResponse> {
+export async function amendOrder(orderId: string, request: AmendOrderRequest): Promise<AmendOrderResponse> {
   const response = await fetch(`${API_BASE_URL}/api/oms/orders/${orderId}/amend`, {
     method: 'PATCH',
     headers: {
@@ -150,12 +390,36 @@ export async function amendOrder(orderId: string, request: AmendOrderRequest): P
 
   if (!response.ok) {
     const error = await response.json();
+    const violations: ComplianceViolation[] = error.violations || [];
+    if (violations.length > 0) {
+      throw new Error(`Amendment blocked by compliance: ${violations.map((v) => v.ruleName).join(', ')}`);
+    }
     throw new Error(error.error || 'Failed to amend order');
   }
 
   return response.json();
 }
 
+/**
+ * Cancel an order and replace it with a linked new version
+ */
+export async function replaceOrder(orderId: string, request: ReplaceOrderRequest): Promise<ReplaceOrderResponse> {
+  const response = await fetch(`${API_BASE_URL}/api/oms/orders/${orderId}/replace`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    body: JSON.stringify(request),
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to replace order');
+  }
+
+  return response.json();
+}
+
 /**
  * Approve an order
  */
@@ -176,6 +440,30 @@ export async function approveOrder(orderId: string, approvedBy: string): Promise
   return response.json();
 }
 
+/**
+ * Reject an order pending approval
+ */
+export async function rejectOrder(
+  orderId: string,
+  rejectedBy: string,
+  reason: string
+): Promise<OrderResponse> {
+  const response = await fetch(`${API_BASE_URL}/api/oms/orders/${orderId}/reject`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    body: JSON.stringify({ rejectedBy, reason }),
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to reject order');
+  }
+
+  return response.json();
+}
+
 /**
  * Cancel an order
  */
@@ -216,6 +504,26 @@ export async function sendToEMS(orderId: string, sentBy: string): Promise<OrderR
   return response.json();
 }
 
+/**
+ * Cross offsetting approved orders internally and send the residuals to EMS
+ */
+export async function netAndSend(orderIds: string[], sentBy: string): Promise<NetAndSendResponse> {
+  const response = await fetch(`${API_BASE_URL}/api/oms/orders/net-and-send`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    body: JSON.stringify({ orderIds, sentBy }),
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to net orders');
+  }
+
+  return response.json();
+}
+
 /**
  * Get blotter (list of orders)
  */
@@ -228,6 +536,7 @@ export async function getBlotter(filters?: BlotterFilters): Promise<BlotterRespo
     if (filters.state) params.append('state', filters.state);
     if (filters.fromDate) params.append('fromDate', filters.fromDate);
     if (filters.toDate) params.append('toDate', filters.toDate);
+    if (filters.latestOnly) params.append('latestOnly', 'true');
     if (filters.limit) params.append('limit', filters.limit.toString());
     if (filters.offset) params.append('offset', filters.offset.toString());
   }
@@ -258,6 +567,20 @@ export async function getOrderById(orderId: string): Promise<Order> {
   return response.json();
 }
 
+/**
+ * Get every version of an order's cancel/replace chain
+ */
+export async function getOrderHistory(orderId: string): Promise<OrderHistoryResponse> {
+  const response = await fetch(`${API_BASE_URL}/api/views/orders/${orderId}/history`);
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to fetch order history');
+  }
+
+  return response.json();
+}
+
 /**
  * Get orders by batch ID
  */
@@ -295,3 +618,127 @@ export async function bulkCreateOrders(orders: CreateOrderRequest[], createdBy:
 
   return response.json();
 }
+
+/**
+ * Create a block order with one child order per account allocation
+ */
+export async function createBlockOrder(request: CreateBlockOrderRequest): Promise<CreateBlockOrderResponse> {
+  const response = await fetch(`${API_BASE_URL}/api/oms/blocks`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    body: JSON.stringify(request),
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to create block order');
+  }
+
+  return response.json();
+}
+
+/**
+ * Send a block order to EMS as a single order
+ */
+export async function sendBlockToEMS(blockId: string, sentBy: string): Promise<{ blockId: string; correlationId: string; status: string }> {
+  const response = await fetch(`${API_BASE_URL}/api/oms/blocks/${blockId}/send-to-ems`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    body: JSON.stringify({ sentBy }),
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to send block order to EMS');
+  }
+
+  return response.json();
+}
+
+/**
+ * Get block orders
+ */
+export async function getBlockOrders(state?: OrderState): Promise<{ blocks: BlockOrder[]; count: number }> {
+  const params = state ? `?state=${state}` : '';
+  const response = await fetch(`${API_BASE_URL}/api/views/blocks${params}`);
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to fetch block orders');
+  }
+
+  return response.json();
+}
+
+/**
+ * Get a block order with its child orders and allocations
+ */
+export async function getBlockOrderById(blockId: string): Promise<BlockOrder> {
+  const response = await fetch(`${API_BASE_URL}/api/views/blocks/${blockId}`);
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to fetch block order');
+  }
+
+  return response.json();
+}
+
+/**
+ * Upload a CSV or XLSX order file for server-side validation
+ */
+export async function uploadOrderFile(file: File, uploadedBy: string): Promise<UploadOrdersResponse> {
+  const body = new FormData();
+  body.append('file', file);
+  body.append('uploadedBy', uploadedBy);
+
+  const response = await fetch(`${API_BASE_URL}/api/oms/uploads`, {
+    method: 'POST',
+    body,
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to upload order file');
+  }
+
+  return response.json();
+}
+
+/**
+ * Create orders for the valid rows of an uploaded batch
+ */
+export async function confirmUpload(batchId: string, confirmedBy: string): Promise<ConfirmUploadResponse> {
+  const response = await fetch(`${API_BASE_URL}/api/oms/uploads/${batchId}/confirm`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    body: JSON.stringify({ confirmedBy }),
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to confirm upload');
+  }
+
+  return response.json();
+}
+
+/**
+ * Get an upload batch with each row's validation result
+ */
+export async function getUploadBatch(batchId: string): Promise<UploadBatch> {
+  const response = await fetch(`${API_BASE_URL}/api/views/uploads/${batchId}`);
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to fetch upload batch');
+  }
+
+  return response.json();
+}
@@ -76,6 +76,8 @@ export interface AccountView {
     modelId?: string;
     createdAt: string;
     createdBy: string;
+    cashBalance?: number;
+    allowShortSales: boolean;
   };
   positions: Array<{
     accountId: string;
@@ -275,6 +277,36 @@ export async function sendProposalToOms(proposalId: string, sentBy: string) {
   return response.json();
 }
 
+export async function adjustAccountCash(accountId: string, amount: number, adjustedBy: string, reason?: string) {
+  const response = await fetch(`${API_BASE_URL}/api/pms/accounts/${accountId}/cash`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ amount, adjustedBy, reason }),
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || "Failed to adjust cash");
+  }
+
+  return response.json();
+}
+
+export async function setAccountShortSales(accountId: string, allowShortSales: boolean, updatedBy: string) {
+  const response = await fetch(`${API_BASE_URL}/api/pms/accounts/${accountId}/short-sales`, {
+    method: "PUT",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ allowShortSales, updatedBy }),
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || "Failed to update short sales");
+  }
+
+  return response.json();
+}
+
 export async function getAccountView(accountId: string): Promise<AccountView> {
   const response = await fetch(`${API_BASE_URL}/api/views/accounts/${accountId}`);
   if (!response.ok) {
@@ -2,9 +2,9 @@
 
 export type OrderSide = "BUY" | "SELL";
 
-export type OrderType = "MARKET" | "LIMIT" | "CURVE_RELATIVE";
+export type OrderType = "MARKET" | "LIMIT" | "CURVE_RELATIVE" | "YIELD_LIMIT";
 
-export type TimeInForce = "DAY" | "IOC";
+export type TimeInForce = "DAY" | "IOC" | "GTC" | "GTD";
 
 export type OrderState =
   | "DRAFT"
@@ -16,7 +16,9 @@ export type OrderState =
   | "FILLED"
   | "CANCELLED"
   | "REJECTED"
-  | "SETTLED";
+  | "SETTLED"
+  | "EXPIRED"
+  | "REPLACED";
 
 export type ComplianceStatus = "PASS" | "WARN" | "BLOCK" | "PENDING";
 
@@ -49,6 +51,8 @@ export interface Order {
   quantity: number;
   orderType: OrderType;
   limitPrice?: number;
+  limitYield?: number; // percent; the yield a LIMIT order's price implies, or a YIELD_LIMIT order's limit
+  limitPricedAsOf?: string; // curve date the limit was converted on
   curveSpreadBp?: number;
   timeInForce: TimeInForce;
   state: OrderState;
@@ -61,6 +65,14 @@ export interface Order {
   sentToEmsAt?: Date;
   fullyFilledAt?: Date;
   settledAt?: Date;
+  expireAt?: Date;
+  expiredAt?: Date;
+  approvedBy?: string;
+  approvedAt?: Date;
+  rejectedBy?: string;
+  rejectedAt?: Date;
+  rejectionReason?: string;
+  nettingId?: string; // netting run the order was sent to the EMS through
   notes?: string;
 }
 
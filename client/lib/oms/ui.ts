@@ -36,6 +36,10 @@ export function getStateColor(state: OrderState): string {
       return "bg-gray-100 text-gray-800";
     case "REJECTED":
       return "bg-red-100 text-red-800";
+    case "EXPIRED":
+      return "bg-slate-100 text-slate-600";
+    case "REPLACED":
+      return "bg-violet-100 text-violet-800";
     default:
       return "bg-gray-100 text-gray-800";
   }
@@ -0,0 +1,5 @@
+-- AlterTable
+ALTER TABLE "events" ADD COLUMN "position" BIGSERIAL NOT NULL;
+
+-- CreateIndex
+CREATE UNIQUE INDEX "events_position_key" ON "events"("position");
@@ -0,0 +1,12 @@
+-- CreateTable
+CREATE TABLE "worker_leases" (
+    "role" TEXT NOT NULL,
+    "instanceId" TEXT NOT NULL,
+    "acquiredAt" TIMESTAMP(3) NOT NULL,
+    "heartbeatAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "worker_leases_pkey" PRIMARY KEY ("role")
+);
+
+-- CreateIndex
+CREATE INDEX "worker_leases_instanceId_idx" ON "worker_leases"("instanceId");
@@ -0,0 +1,36 @@
+-- CreateEnum
+CREATE TYPE "dead_letter_status" AS ENUM (
+  'PENDING',
+  'FAILED',
+  'RESOLVED',
+  'SKIPPED'
+);
+
+-- CreateTable
+CREATE TABLE "projection_dead_letters" (
+    "id" TEXT NOT NULL,
+    "projection" TEXT NOT NULL,
+    "handler" TEXT NOT NULL,
+    "eventId" TEXT NOT NULL,
+    "eventType" TEXT NOT NULL,
+    "position" BIGINT NOT NULL,
+    "event" JSONB NOT NULL,
+    "error" TEXT NOT NULL,
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "status" "dead_letter_status" NOT NULL DEFAULT 'PENDING',
+    "nextRetryAt" TIMESTAMP(3),
+    "firstFailedAt" TIMESTAMP(3) NOT NULL,
+    "lastFailedAt" TIMESTAMP(3) NOT NULL,
+    "resolvedAt" TIMESTAMP(3),
+    "resolvedBy" TEXT,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "projection_dead_letters_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "projection_dead_letters_projection_eventId_key" ON "projection_dead_letters"("projection", "eventId");
+
+-- CreateIndex
+CREATE INDEX "projection_dead_letters_projection_status_nextRetryAt_idx" ON "projection_dead_letters"("projection", "status", "nextRetryAt");
@@ -0,0 +1,8 @@
+-- CreateTable
+CREATE TABLE "projection_checkpoints" (
+    "projection" TEXT NOT NULL,
+    "position" BIGINT NOT NULL DEFAULT 0,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "projection_checkpoints_pkey" PRIMARY KEY ("projection")
+);
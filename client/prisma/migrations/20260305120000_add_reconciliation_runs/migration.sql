@@ -0,0 +1,16 @@
+-- CreateTable
+CREATE TABLE "reconciliation_runs" (
+    "runId" TEXT NOT NULL,
+    "startedAt" TIMESTAMP(3) NOT NULL,
+    "completedAt" TIMESTAMP(3) NOT NULL,
+    "triggeredBy" TEXT NOT NULL,
+    "repair" BOOLEAN NOT NULL DEFAULT false,
+    "breakCount" INTEGER NOT NULL DEFAULT 0,
+    "repairedCount" INTEGER NOT NULL DEFAULT 0,
+    "report" JSONB NOT NULL,
+
+    CONSTRAINT "reconciliation_runs_pkey" PRIMARY KEY ("runId")
+);
+
+-- CreateIndex
+CREATE INDEX "reconciliation_runs_startedAt_idx" ON "reconciliation_runs"("startedAt");
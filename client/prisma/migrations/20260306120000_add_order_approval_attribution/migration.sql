@@ -0,0 +1,6 @@
+-- AlterTable
+ALTER TABLE "orders" ADD COLUMN     "approvedBy" TEXT,
+ADD COLUMN     "approvedAt" TIMESTAMP(3),
+ADD COLUMN     "rejectedBy" TEXT,
+ADD COLUMN     "rejectedAt" TIMESTAMP(3),
+ADD COLUMN     "rejectionReason" TEXT;
@@ -0,0 +1,42 @@
+-- AlterTable
+ALTER TABLE "orders" ADD COLUMN     "requiredApprovals" INTEGER NOT NULL DEFAULT 1,
+ADD COLUMN     "approvals" JSONB NOT NULL DEFAULT '[]';
+
+-- CreateTable
+CREATE TABLE "approval_policies" (
+    "policyId" TEXT NOT NULL,
+    "name" TEXT NOT NULL,
+    "description" TEXT,
+    "status" TEXT NOT NULL DEFAULT 'ACTIVE',
+    "scope" TEXT NOT NULL DEFAULT 'GLOBAL',
+    "scopeId" TEXT,
+    "criteria" JSONB NOT NULL DEFAULT '{}',
+    "requiredApprovals" INTEGER NOT NULL DEFAULT 1,
+    "version" INTEGER NOT NULL DEFAULT 1,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "createdBy" TEXT NOT NULL,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+    "updatedBy" TEXT NOT NULL,
+
+    CONSTRAINT "approval_policies_pkey" PRIMARY KEY ("policyId")
+);
+
+-- CreateIndex
+CREATE INDEX "approval_policies_status_idx" ON "approval_policies"("status");
+
+-- CreateIndex
+CREATE INDEX "approval_policies_scope_scopeId_idx" ON "approval_policies"("scope", "scopeId");
+
+-- Seed the policy that replaces the old hard-coded rule: orders above
+-- 1,000,000 quantity need one approval
+INSERT INTO "approval_policies" ("policyId", "name", "description", "criteria", "requiredApprovals", "createdBy", "updatedAt", "updatedBy")
+VALUES (
+    'default-large-quantity',
+    'Large quantity',
+    'Orders above 1,000,000 quantity need one approval',
+    '{"minQuantity": 1000000}',
+    1,
+    'system',
+    CURRENT_TIMESTAMP,
+    'system'
+);
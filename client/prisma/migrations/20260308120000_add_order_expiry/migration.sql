@@ -0,0 +1,13 @@
+-- AlterEnum
+ALTER TYPE "order_state" ADD VALUE 'EXPIRED';
+
+-- AlterEnum
+ALTER TYPE "time_in_force" ADD VALUE 'GTC';
+ALTER TYPE "time_in_force" ADD VALUE 'GTD';
+
+-- AlterTable
+ALTER TABLE "orders" ADD COLUMN     "expireAt" TIMESTAMP(3),
+ADD COLUMN     "expiredAt" TIMESTAMP(3);
+
+-- CreateIndex
+CREATE INDEX "orders_timeInForce_state_idx" ON "orders"("timeInForce", "state");
@@ -0,0 +1,68 @@
+-- CreateTable
+CREATE TABLE "block_orders" (
+    "blockId" TEXT NOT NULL,
+    "instrumentId" TEXT NOT NULL,
+    "side" "order_side" NOT NULL,
+    "quantity" DECIMAL(18,2) NOT NULL,
+    "orderType" "order_type" NOT NULL,
+    "limitPrice" DECIMAL(10,4),
+    "curveSpreadBp" DECIMAL(10,4),
+    "timeInForce" "time_in_force" NOT NULL,
+    "expireAt" TIMESTAMP(3),
+    "lotSize" DECIMAL(18,2) NOT NULL DEFAULT 1000,
+    "allocations" JSONB NOT NULL DEFAULT '[]',
+    "state" "order_state" NOT NULL DEFAULT 'DRAFT',
+    "filledQuantity" DECIMAL(18,2) NOT NULL DEFAULT 0,
+    "avgFillPrice" DECIMAL(10,4),
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "createdBy" TEXT NOT NULL,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+    "sentToEmsAt" TIMESTAMP(3),
+    "settledAt" TIMESTAMP(3),
+
+    CONSTRAINT "block_orders_pkey" PRIMARY KEY ("blockId")
+);
+
+-- CreateTable
+CREATE TABLE "allocations" (
+    "allocationId" TEXT NOT NULL,
+    "blockId" TEXT NOT NULL,
+    "executionId" TEXT NOT NULL,
+    "orderId" TEXT NOT NULL,
+    "accountId" TEXT NOT NULL,
+    "instrumentId" TEXT NOT NULL,
+    "side" "order_side" NOT NULL,
+    "quantity" DECIMAL(18,2) NOT NULL,
+    "targetQuantity" DECIMAL(18,2) NOT NULL,
+    "price" DECIMAL(10,4) NOT NULL,
+    "settlementDate" TIMESTAMP(3) NOT NULL,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "allocations_pkey" PRIMARY KEY ("allocationId")
+);
+
+-- AlterTable
+ALTER TABLE "orders" ADD COLUMN     "blockId" TEXT;
+
+-- AlterTable
+ALTER TABLE "executions" ADD COLUMN     "blockId" TEXT,
+ALTER COLUMN "orderId" DROP NOT NULL,
+ALTER COLUMN "accountId" DROP NOT NULL;
+
+-- CreateIndex
+CREATE INDEX "block_orders_state_idx" ON "block_orders"("state");
+
+-- CreateIndex
+CREATE INDEX "block_orders_createdAt_idx" ON "block_orders"("createdAt");
+
+-- CreateIndex
+CREATE INDEX "allocations_blockId_idx" ON "allocations"("blockId");
+
+-- CreateIndex
+CREATE INDEX "allocations_accountId_instrumentId_idx" ON "allocations"("accountId", "instrumentId");
+
+-- CreateIndex
+CREATE INDEX "orders_blockId_idx" ON "orders"("blockId");
+
+-- CreateIndex
+CREATE INDEX "executions_blockId_idx" ON "executions"("blockId");
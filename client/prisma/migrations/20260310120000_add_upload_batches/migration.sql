@@ -0,0 +1,24 @@
+-- CreateTable
+CREATE TABLE "upload_batches" (
+    "batchId" TEXT NOT NULL,
+    "fileName" TEXT NOT NULL,
+    "format" TEXT NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'RECEIVED',
+    "rowCount" INTEGER NOT NULL DEFAULT 0,
+    "validCount" INTEGER NOT NULL DEFAULT 0,
+    "errorCount" INTEGER NOT NULL DEFAULT 0,
+    "rows" JSONB NOT NULL DEFAULT '[]',
+    "orders" JSONB NOT NULL DEFAULT '[]',
+    "createdCount" INTEGER NOT NULL DEFAULT 0,
+    "failedCount" INTEGER NOT NULL DEFAULT 0,
+    "uploadedBy" TEXT NOT NULL,
+    "confirmedBy" TEXT,
+    "confirmedAt" TIMESTAMP(3),
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "upload_batches_pkey" PRIMARY KEY ("batchId")
+);
+
+-- CreateIndex
+CREATE INDEX "upload_batches_status_idx" ON "upload_batches"("status");
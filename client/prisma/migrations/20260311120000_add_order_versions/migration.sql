@@ -0,0 +1,40 @@
+-- AlterEnum
+ALTER TYPE "order_state" ADD VALUE 'REPLACED';
+
+-- AlterTable
+ALTER TABLE "orders" ADD COLUMN     "chainId" TEXT,
+ADD COLUMN     "chainVersion" INTEGER NOT NULL DEFAULT 1,
+ADD COLUMN     "replacesOrderId" TEXT,
+ADD COLUMN     "replacedByOrderId" TEXT,
+ADD COLUMN     "replacedAt" TIMESTAMP(3);
+
+-- Every existing order starts its own chain
+UPDATE "orders" SET "chainId" = "orderId" WHERE "chainId" IS NULL;
+
+-- CreateTable
+CREATE TABLE "order_history" (
+    "entryId" TEXT NOT NULL,
+    "orderId" TEXT NOT NULL,
+    "chainId" TEXT NOT NULL,
+    "chainVersion" INTEGER NOT NULL DEFAULT 1,
+    "changeType" TEXT NOT NULL,
+    "changes" JSONB NOT NULL DEFAULT '[]',
+    "relatedOrderId" TEXT,
+    "reason" TEXT,
+    "actor" TEXT NOT NULL,
+    "occurredAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "order_history_pkey" PRIMARY KEY ("entryId")
+);
+
+-- CreateIndex
+CREATE INDEX "orders_chainId_idx" ON "orders"("chainId");
+
+-- CreateIndex
+CREATE INDEX "orders_replacedByOrderId_idx" ON "orders"("replacedByOrderId");
+
+-- CreateIndex
+CREATE INDEX "order_history_chainId_occurredAt_idx" ON "order_history"("chainId", "occurredAt");
+
+-- CreateIndex
+CREATE INDEX "order_history_orderId_idx" ON "order_history"("orderId");
@@ -0,0 +1,4 @@
+-- AlterTable
+-- A NULL cash balance means the account does not track cash and skips the pre-trade cash check
+ALTER TABLE "accounts" ADD COLUMN     "cashBalance" DECIMAL(18,2),
+ADD COLUMN     "allowShortSales" BOOLEAN NOT NULL DEFAULT false;
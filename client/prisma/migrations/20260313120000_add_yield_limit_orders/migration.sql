@@ -0,0 +1,9 @@
+-- AlterEnum
+ALTER TYPE "order_type" ADD VALUE 'YIELD_LIMIT';
+
+-- AlterTable
+ALTER TABLE "orders" ADD COLUMN     "limitYield" DECIMAL(10,6),
+ADD COLUMN     "limitPricedAsOf" TIMESTAMP(3);
+
+-- AlterTable
+ALTER TABLE "block_orders" ADD COLUMN     "limitYield" DECIMAL(10,6);
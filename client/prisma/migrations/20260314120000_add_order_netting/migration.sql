@@ -0,0 +1,32 @@
+-- CreateTable
+CREATE TABLE "cross_trades" (
+    "crossId" TEXT NOT NULL,
+    "nettingId" TEXT NOT NULL,
+    "orderId" TEXT NOT NULL,
+    "accountId" TEXT NOT NULL,
+    "instrumentId" TEXT NOT NULL,
+    "side" "order_side" NOT NULL,
+    "counterpartyOrderId" TEXT NOT NULL,
+    "counterpartyAccountId" TEXT NOT NULL,
+    "quantity" DECIMAL(18,2) NOT NULL,
+    "price" DECIMAL(10,4) NOT NULL,
+    "priceAsOf" TIMESTAMP(3) NOT NULL,
+    "executedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "cross_trades_pkey" PRIMARY KEY ("crossId","orderId")
+);
+
+-- AlterTable
+ALTER TABLE "orders" ADD COLUMN     "nettingId" TEXT;
+
+-- CreateIndex
+CREATE INDEX "cross_trades_nettingId_idx" ON "cross_trades"("nettingId");
+
+-- CreateIndex
+CREATE INDEX "cross_trades_orderId_idx" ON "cross_trades"("orderId");
+
+-- CreateIndex
+CREATE INDEX "cross_trades_accountId_instrumentId_idx" ON "cross_trades"("accountId", "instrumentId");
+
+-- CreateIndex
+CREATE INDEX "orders_nettingId_idx" ON "orders"("nettingId");
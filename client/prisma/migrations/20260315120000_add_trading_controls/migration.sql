@@ -0,0 +1,54 @@
+-- CreateTable
+CREATE TABLE "trading_halts" (
+    "haltId" TEXT NOT NULL,
+    "scope" TEXT NOT NULL DEFAULT 'GLOBAL',
+    "scopeId" TEXT,
+    "reason" TEXT NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'ACTIVE',
+    "activatedBy" TEXT NOT NULL,
+    "activatedAt" TIMESTAMP(3) NOT NULL,
+    "releasedBy" TEXT,
+    "releasedAt" TIMESTAMP(3),
+    "releaseReason" TEXT,
+
+    CONSTRAINT "trading_halts_pkey" PRIMARY KEY ("haltId")
+);
+
+-- CreateTable
+CREATE TABLE "order_rate_limits" (
+    "actorId" TEXT NOT NULL,
+    "maxOrders" INTEGER NOT NULL,
+    "windowSeconds" INTEGER NOT NULL,
+    "setBy" TEXT NOT NULL,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "order_rate_limits_pkey" PRIMARY KEY ("actorId")
+);
+
+-- CreateTable
+CREATE TABLE "order_submission_refusals" (
+    "refusalId" TEXT NOT NULL,
+    "actorId" TEXT NOT NULL,
+    "accountIds" JSONB NOT NULL DEFAULT '[]',
+    "reason" TEXT NOT NULL,
+    "haltId" TEXT,
+    "detail" TEXT NOT NULL,
+    "refusedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "order_submission_refusals_pkey" PRIMARY KEY ("refusalId")
+);
+
+-- CreateIndex
+CREATE INDEX "trading_halts_status_idx" ON "trading_halts"("status");
+
+-- CreateIndex
+CREATE INDEX "trading_halts_scope_scopeId_idx" ON "trading_halts"("scope", "scopeId");
+
+-- CreateIndex
+CREATE INDEX "order_submission_refusals_refusedAt_idx" ON "order_submission_refusals"("refusedAt");
+
+-- CreateIndex
+CREATE INDEX "order_submission_refusals_actorId_idx" ON "order_submission_refusals"("actorId");
+
+-- CreateIndex
+CREATE INDEX "events_eventType_actorId_occurredAt_idx" ON "events"("eventType", "actorId", "occurredAt");
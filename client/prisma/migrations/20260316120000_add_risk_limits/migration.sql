@@ -0,0 +1,42 @@
+-- CreateTable
+CREATE TABLE "risk_limits" (
+    "limitId" TEXT NOT NULL,
+    "limitType" TEXT NOT NULL,
+    "scope" TEXT NOT NULL DEFAULT 'GLOBAL',
+    "scopeId" TEXT,
+    "maxValue" DOUBLE PRECISION NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'ACTIVE',
+    "version" INTEGER NOT NULL DEFAULT 1,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "createdBy" TEXT NOT NULL,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+    "updatedBy" TEXT NOT NULL,
+
+    CONSTRAINT "risk_limits_pkey" PRIMARY KEY ("limitId")
+);
+
+-- CreateTable
+CREATE TABLE "risk_order_exposures" (
+    "orderId" TEXT NOT NULL,
+    "actorId" TEXT NOT NULL,
+    "tradeDate" TEXT NOT NULL,
+    "notional" DOUBLE PRECISION NOT NULL,
+    "dv01" DOUBLE PRECISION NOT NULL,
+    "open" BOOLEAN NOT NULL DEFAULT true,
+    "createdAt" TIMESTAMP(3) NOT NULL,
+    "closedAt" TIMESTAMP(3),
+
+    CONSTRAINT "risk_order_exposures_pkey" PRIMARY KEY ("orderId")
+);
+
+-- CreateIndex
+CREATE INDEX "risk_limits_status_idx" ON "risk_limits"("status");
+
+-- CreateIndex
+CREATE INDEX "risk_limits_scope_scopeId_idx" ON "risk_limits"("scope", "scopeId");
+
+-- CreateIndex
+CREATE INDEX "risk_order_exposures_actorId_tradeDate_idx" ON "risk_order_exposures"("actorId", "tradeDate");
+
+-- CreateIndex
+CREATE INDEX "risk_order_exposures_actorId_open_idx" ON "risk_order_exposures"("actorId", "open");
@@ -0,0 +1,43 @@
+-- CreateTable
+CREATE TABLE "fee_schedules" (
+    "scheduleId" TEXT NOT NULL,
+    "name" TEXT NOT NULL,
+    "scope" TEXT NOT NULL DEFAULT 'GLOBAL',
+    "scopeId" TEXT,
+    "instrumentType" TEXT,
+    "commissionPerBond" DOUBLE PRECISION NOT NULL DEFAULT 0,
+    "commissionBps" DOUBLE PRECISION NOT NULL DEFAULT 0,
+    "minCommission" DOUBLE PRECISION NOT NULL DEFAULT 0,
+    "feeBps" DOUBLE PRECISION NOT NULL DEFAULT 0,
+    "markupBps" DOUBLE PRECISION NOT NULL DEFAULT 0,
+    "status" TEXT NOT NULL DEFAULT 'ACTIVE',
+    "version" INTEGER NOT NULL DEFAULT 1,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "createdBy" TEXT NOT NULL,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+    "updatedBy" TEXT NOT NULL,
+
+    CONSTRAINT "fee_schedules_pkey" PRIMARY KEY ("scheduleId")
+);
+
+-- AlterTable
+-- Charges booked on each fill, and their totals on the execution and each block allocation
+ALTER TABLE "fills" ADD COLUMN     "commission" DECIMAL(18,2) NOT NULL DEFAULT 0,
+ADD COLUMN     "fees" DECIMAL(18,2) NOT NULL DEFAULT 0,
+ADD COLUMN     "markup" DECIMAL(18,2) NOT NULL DEFAULT 0;
+
+-- AlterTable
+ALTER TABLE "executions" ADD COLUMN     "commission" DECIMAL(18,2) NOT NULL DEFAULT 0,
+ADD COLUMN     "fees" DECIMAL(18,2) NOT NULL DEFAULT 0,
+ADD COLUMN     "markup" DECIMAL(18,2) NOT NULL DEFAULT 0;
+
+-- AlterTable
+ALTER TABLE "allocations" ADD COLUMN     "commission" DECIMAL(18,2) NOT NULL DEFAULT 0,
+ADD COLUMN     "fees" DECIMAL(18,2) NOT NULL DEFAULT 0,
+ADD COLUMN     "markup" DECIMAL(18,2) NOT NULL DEFAULT 0;
+
+-- CreateIndex
+CREATE INDEX "fee_schedules_status_idx" ON "fee_schedules"("status");
+
+-- CreateIndex
+CREATE INDEX "fee_schedules_scope_scopeId_idx" ON "fee_schedules"("scope", "scopeId");
@@ -0,0 +1,6 @@
+-- AlterTable
+-- What an execution settles for: principal, accrued interest at the settlement date and the net amount with charges.
+-- NULL until the execution is settled, and for executions settled before amounts were recorded
+ALTER TABLE "executions" ADD COLUMN     "principal" DECIMAL(18,2),
+ADD COLUMN     "accruedInterest" DECIMAL(18,2),
+ADD COLUMN     "netSettlementAmount" DECIMAL(18,2);
@@ -0,0 +1,45 @@
+-- CreateTable
+-- One row per account settlement: an execution, a cross leg or a block allocation
+CREATE TABLE "settlements" (
+    "settlementId" TEXT NOT NULL,
+    "executionId" TEXT NOT NULL,
+    "orderId" TEXT,
+    "blockId" TEXT,
+    "crossId" TEXT,
+    "accountId" TEXT NOT NULL,
+    "instrumentId" TEXT NOT NULL,
+    "side" "order_side" NOT NULL,
+    "quantity" DECIMAL(18,2) NOT NULL,
+    "settledQuantity" DECIMAL(18,2) NOT NULL DEFAULT 0,
+    "price" DECIMAL(10,4) NOT NULL,
+    "netSettlementAmount" DECIMAL(18,2),
+    "tradeDate" TIMESTAMP(3) NOT NULL,
+    "settlementDate" TIMESTAMP(3) NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'PENDING',
+    "reason" TEXT,
+    "settledAt" TIMESTAMP(3),
+    "failedAt" TIMESTAMP(3),
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "settlements_pkey" PRIMARY KEY ("settlementId")
+);
+
+-- AlterTable
+-- Settled holdings; quantity less settledQuantity is traded but not yet settled.
+-- Positions booked before settlements were tracked settled on booking.
+ALTER TABLE "positions" ADD COLUMN     "settledQuantity" DECIMAL(18,6) NOT NULL DEFAULT 0;
+
+UPDATE "positions" SET "settledQuantity" = "quantity";
+
+-- CreateIndex
+CREATE INDEX "settlements_status_settlementDate_idx" ON "settlements"("status", "settlementDate");
+
+-- CreateIndex
+CREATE INDEX "settlements_accountId_idx" ON "settlements"("accountId");
+
+-- CreateIndex
+CREATE INDEX "settlements_executionId_idx" ON "settlements"("executionId");
+
+-- CreateIndex
+CREATE INDEX "settlements_orderId_idx" ON "settlements"("orderId");
@@ -0,0 +1,34 @@
+-- CreateTable
+CREATE TABLE "routing_policies" (
+    "policyId" TEXT NOT NULL,
+    "name" TEXT NOT NULL,
+    "priority" INTEGER NOT NULL DEFAULT 0,
+    "venue" TEXT NOT NULL,
+    "accountId" TEXT,
+    "instrumentType" TEXT,
+    "orderType" TEXT,
+    "minQuantity" DOUBLE PRECISION,
+    "maxQuantity" DOUBLE PRECISION,
+    "status" TEXT NOT NULL DEFAULT 'ACTIVE',
+    "version" INTEGER NOT NULL DEFAULT 1,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "createdBy" TEXT NOT NULL,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+    "updatedBy" TEXT NOT NULL,
+
+    CONSTRAINT "routing_policies_pkey" PRIMARY KEY ("policyId")
+);
+
+-- AlterTable
+-- The venue that worked each execution and the routing policy that chose it.
+-- Executions from before venues existed were all simulated.
+ALTER TABLE "executions" ADD COLUMN     "venue" TEXT,
+ADD COLUMN     "routingPolicyId" TEXT;
+
+UPDATE "executions" SET "venue" = 'simulator';
+
+-- CreateIndex
+CREATE INDEX "routing_policies_status_priority_idx" ON "routing_policies"("status", "priority");
+
+-- CreateIndex
+CREATE INDEX "executions_venue_idx" ON "executions"("venue");
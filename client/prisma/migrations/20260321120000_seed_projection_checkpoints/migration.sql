@@ -0,0 +1,7 @@
+-- Projections replay from their checkpoint when they start. A projection with
+-- no checkpoint yet starts from the current end of the log rather than
+-- replaying events its read model already holds.
+INSERT INTO "projection_checkpoints" ("projection", "position", "updatedAt")
+SELECT name, (SELECT COALESCE(MAX("position"), 0) FROM "events"), CURRENT_TIMESTAMP
+FROM (VALUES ('oms'), ('ems'), ('pms'), ('compliance')) AS projections(name)
+ON CONFLICT ("projection") DO NOTHING;
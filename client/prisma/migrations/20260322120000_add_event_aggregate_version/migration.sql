@@ -0,0 +1,16 @@
+-- Number each aggregate's events so a command can append only if the
+-- aggregate has not changed since it was loaded
+ALTER TABLE "events" ADD COLUMN "aggregateVersion" INTEGER;
+
+UPDATE "events" e
+SET "aggregateVersion" = v.version
+FROM (
+    SELECT "eventId", ROW_NUMBER() OVER (PARTITION BY "aggregateType", "aggregateId" ORDER BY "position") AS version
+    FROM "events"
+) v
+WHERE e."eventId" = v."eventId";
+
+ALTER TABLE "events" ALTER COLUMN "aggregateVersion" SET NOT NULL;
+
+-- CreateIndex
+CREATE UNIQUE INDEX "events_aggregateType_aggregateId_aggregateVersion_key" ON "events"("aggregateType", "aggregateId", "aggregateVersion");
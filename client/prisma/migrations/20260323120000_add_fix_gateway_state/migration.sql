@@ -0,0 +1,53 @@
+-- The FIX acceptor's state, so the instance that takes over the acceptor
+-- carries on each session's sequence numbers and knows its ClOrdIDs
+
+-- CreateTable
+CREATE TABLE "fix_sessions" (
+    "compId" TEXT NOT NULL,
+    "nextOutSeqNum" INTEGER NOT NULL DEFAULT 1,
+    "nextInSeqNum" INTEGER NOT NULL DEFAULT 1,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "fix_sessions_pkey" PRIMARY KEY ("compId")
+);
+
+-- CreateTable
+CREATE TABLE "fix_messages" (
+    "compId" TEXT NOT NULL,
+    "seqNum" INTEGER NOT NULL,
+    "message" TEXT NOT NULL,
+    "sentAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "fix_messages_pkey" PRIMARY KEY ("compId", "seqNum")
+);
+
+-- CreateTable
+CREATE TABLE "fix_orders" (
+    "compId" TEXT NOT NULL,
+    "clOrdId" TEXT NOT NULL,
+    "origClOrdId" TEXT,
+    "cancelClOrdId" TEXT,
+    "orderId" TEXT NOT NULL,
+    "account" TEXT NOT NULL,
+    "securityId" TEXT NOT NULL,
+    "side" TEXT NOT NULL,
+    "quantity" DECIMAL(18,2) NOT NULL,
+    "cumQty" DECIMAL(18,2) NOT NULL DEFAULT 0,
+    "notional" DOUBLE PRECISION NOT NULL DEFAULT 0,
+    "status" TEXT NOT NULL,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "fix_orders_pkey" PRIMARY KEY ("compId", "clOrdId")
+);
+
+-- CreateTable
+CREATE TABLE "fix_executions" (
+    "executionId" TEXT NOT NULL,
+    "orderId" TEXT NOT NULL,
+    "createdAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "fix_executions_pkey" PRIMARY KEY ("executionId")
+);
+
+-- CreateIndex
+CREATE INDEX "fix_orders_orderId_idx" ON "fix_orders"("orderId");
@@ -0,0 +1,16 @@
+-- Orders admitted against each actor's rate limit. The check and the
+-- admission happen under one per-actor lock, so concurrent submissions are
+-- counted against each other before any of their orders are created.
+
+-- CreateTable
+CREATE TABLE "order_rate_admissions" (
+    "admissionId" TEXT NOT NULL,
+    "actorId" TEXT NOT NULL,
+    "orders" INTEGER NOT NULL,
+    "admittedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "order_rate_admissions_pkey" PRIMARY KEY ("admissionId")
+);
+
+-- CreateIndex
+CREATE INDEX "order_rate_admissions_actorId_admittedAt_idx" ON "order_rate_admissions"("actorId", "admittedAt");
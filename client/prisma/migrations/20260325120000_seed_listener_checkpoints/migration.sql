@@ -0,0 +1,7 @@
+-- The EMS and compliance listeners resume from a checkpoint when they start,
+-- like the projections. They start from the current end of the log rather
+-- than executing and evaluating every order already in it again.
+INSERT INTO "projection_checkpoints" ("projection", "position", "updatedAt")
+SELECT name, (SELECT COALESCE(MAX("position"), 0) FROM "events"), CURRENT_TIMESTAMP
+FROM (VALUES ('ems-listener'), ('compliance-listener')) AS listeners(name)
+ON CONFLICT ("projection") DO NOTHING;
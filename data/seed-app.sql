@@ -287,7 +287,7 @@ ON CONFLICT ("fillId") DO NOTHING;
 -- Events
 INSERT INTO events (
   "eventId", "occurredAt", "eventType", "aggregateType", "aggregateId",
-  "correlationId", "causationId", "actorId", "actorRole", payload, explanation, "schemaVersion"
+  "correlationId", "causationId", "actorId", "actorRole", payload, explanation, "schemaVersion", "aggregateVersion"
 )
 VALUES
   (
@@ -302,6 +302,7 @@ VALUES
     'user',
     '{"orderId":"seed-order-001","accountId":"seed-account-001","instrumentId":"912810TM6","side":"BUY","quantity":100000,"orderType":"LIMIT"}',
     'Order created for Cedar Ridge Core.',
+    1,
     1
   ),
   (
@@ -316,6 +317,7 @@ VALUES
     'system',
     '{"ruleId":"seed-compliance-rule-001","result":"PASS"}',
     'Compliance check passed.',
+    1,
     1
   ),
   (
@@ -330,6 +332,7 @@ VALUES
     'system',
     '{"executionId":"seed-execution-001","orderId":"seed-order-001"}',
     NULL,
+    1,
     1
   ),
   (
@@ -344,7 +347,8 @@ VALUES
     'system',
     '{"executionId":"seed-execution-001","clipIndex":2,"quantity":50000}',
     'Second clip executed.',
-    1
+    1,
+    2
   ),
   (
     'seed-event-005',
@@ -358,6 +362,7 @@ VALUES
     'user',
     '{"proposalId":"seed-proposal-001","accountId":"seed-account-001"}',
     'Optimization proposal generated.',
+    1,
     1
   )
 ON CONFLICT ("eventId") DO NOTHING;
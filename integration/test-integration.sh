@@ -139,12 +139,12 @@ wait_for_match "Compliance violation projected" \
   "${COMPLIANCE_RULE_ID}"
 echo ""
 
-# Test 5: Approve Order (if in APPROVAL_PENDING state)
-if [ "$STATE" == "APPROVAL_PENDING" ]; then
+# Test 5: Approve Order (only approved orders can be sent to EMS)
+if [ "$STATE" == "APPROVAL_PENDING" ] || [ "$STATE" == "DRAFT" ]; then
     echo "6. Approving order..."
     APPROVE_RESPONSE=$(curl -s -X POST "${API_URL}/api/oms/orders/${ORDER_ID}/approve" \
       -H "Content-Type: application/json" \
-      -d "{\"approvedBy\": \"integration-test\"}")
+      -d "{\"approvedBy\": \"integration-approver\"}")
 
     if echo "$APPROVE_RESPONSE" | grep -q "approved"; then
         echo -e "${GREEN}✓ Order approved${NC}"
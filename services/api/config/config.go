@@ -1,8 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,6 +17,40 @@ type Config struct {
 	DirectURL       string
 	TemporalAddress string
 	Environment     string
+	EventBusBackend string
+	InstanceID      string
+	LeaderInterval  time.Duration
+
+	ReadConsistencyTimeout time.Duration
+
+	ReconciliationInterval   time.Duration
+	ReconciliationAutoRepair bool
+
+	OrderDayCutoff      string
+	OrderCutoffTimezone string
+	OrderExpiryInterval time.Duration
+	LimitPriceBandPct   float64
+
+	SettlementCycleDefault int
+	SettlementCycles       []string
+	SettlementInterval     time.Duration
+
+	ExecutionVenueDefault string
+	BrokerURL             string
+	BrokerTimeout         time.Duration
+	BrokerPollInterval    time.Duration
+	BrokerWorkingTime     time.Duration
+	SimulatorSeed         *int64
+
+	PretradeHoldingsCheck string
+	PretradeCashCheck     string
+
+	OrderRateLimitMax    int
+	OrderRateLimitWindow time.Duration
+
+	FixAddress       string
+	FixSenderCompID  string
+	FixTargetCompIDs []string
 }
 
 func Load() *Config {
@@ -37,6 +75,40 @@ func Load() *Config {
 		DirectURL:       getEnv("DIRECT_URL", ""),
 		TemporalAddress: getEnv("TEMPORAL_ADDRESS", "localhost:7233"),
 		Environment:     getEnv("ENV", "development"),
+		EventBusBackend: getEnv("EVENT_BUS_BACKEND", "memory"),
+		InstanceID:      getEnv("INSTANCE_ID", defaultInstanceID()),
+		LeaderInterval:  getDuration("LEADER_ELECTION_INTERVAL", 5*time.Second),
+
+		ReadConsistencyTimeout: getDuration("READ_CONSISTENCY_TIMEOUT", 5*time.Second),
+
+		ReconciliationInterval:   getDuration("RECONCILIATION_INTERVAL", time.Hour),
+		ReconciliationAutoRepair: getEnv("RECONCILIATION_AUTO_REPAIR", "false") == "true",
+
+		OrderDayCutoff:      getEnv("ORDER_DAY_CUTOFF", "17:00"),
+		OrderCutoffTimezone: getEnv("ORDER_CUTOFF_TIMEZONE", "America/New_York"),
+		OrderExpiryInterval: getDuration("ORDER_EXPIRY_INTERVAL", time.Minute),
+		LimitPriceBandPct:   getFloat("LIMIT_PRICE_BAND_PCT", 5),
+
+		SettlementCycleDefault: getInt("SETTLEMENT_CYCLE_DEFAULT", 1),
+		SettlementCycles:       getList("SETTLEMENT_CYCLES"),
+		SettlementInterval:     getDuration("SETTLEMENT_INTERVAL", time.Minute),
+
+		ExecutionVenueDefault: getEnv("EXECUTION_VENUE_DEFAULT", "simulator"),
+		BrokerURL:             getEnv("BROKER_URL", ""),
+		BrokerTimeout:         getDuration("BROKER_TIMEOUT", 10*time.Second),
+		BrokerPollInterval:    getDuration("BROKER_POLL_INTERVAL", 500*time.Millisecond),
+		BrokerWorkingTime:     getDuration("BROKER_WORKING_TIME", 10*time.Second),
+		SimulatorSeed:         getOptionalInt64("SIMULATOR_SEED"),
+
+		PretradeHoldingsCheck: getEnv("PRETRADE_HOLDINGS_CHECK", "BLOCK"),
+		PretradeCashCheck:     getEnv("PRETRADE_CASH_CHECK", "BLOCK"),
+
+		OrderRateLimitMax:    getInt("ORDER_RATE_LIMIT_MAX", 0),
+		OrderRateLimitWindow: getDuration("ORDER_RATE_LIMIT_WINDOW", time.Minute),
+
+		FixAddress:       getEnv("FIX_ADDRESS", ""),
+		FixSenderCompID:  getEnv("FIX_SENDER_COMP_ID", "INSTANT"),
+		FixTargetCompIDs: getList("FIX_TARGET_COMP_IDS"),
 	}
 }
 
@@ -46,3 +118,77 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration for %s (%q), using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func getFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid number for %s (%q), using %g", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func getInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid integer for %s (%q), using %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getOptionalInt64 reads an integer that has no default, returning nil when
+// it is unset or invalid
+func getOptionalInt64(key string) *int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Printf("Invalid integer for %s (%q), ignoring it", key, value)
+		return nil
+	}
+	return &parsed
+}
+
+// getList reads a comma-separated list, ignoring blank entries
+func getList(key string) []string {
+	values := []string{}
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// defaultInstanceID identifies this process in worker leases
+func defaultInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
@@ -0,0 +1,252 @@
+package ems
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"instant/services/api/events"
+	"instant/services/api/services/allocation"
+	"instant/services/api/services/fees"
+	"instant/services/api/services/settlement"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// blockOrderFromPayload builds the order the EMS executes for a block from its
+// BlockOrderSentToEMS payload
+func blockOrderFromPayload(payload map[string]interface{}) (*orderRecord, error) {
+	blockID := payloadString(payload["blockId"])
+	if blockID == "" {
+		return nil, errors.New("blockId missing in BlockOrderSentToEMS payload")
+	}
+
+	order := &orderRecord{
+		blockID:      blockID,
+		instrumentID: payloadString(payload["instrumentId"]),
+		side:         payloadString(payload["side"]),
+		orderType:    payloadString(payload["orderType"]),
+		timeInForce:  payloadString(payload["timeInForce"]),
+		lotSize:      allocation.DefaultLotSize,
+	}
+	order.quantity, _ = payload["quantity"].(float64)
+	if lotSize, ok := payload["lotSize"].(float64); ok && lotSize > 0 {
+		order.lotSize = lotSize
+	}
+	if limitPrice, ok := payload["limitPrice"].(float64); ok {
+		order.limitPrice = sql.NullFloat64{Float64: limitPrice, Valid: true}
+	}
+	if curveSpreadBp, ok := payload["curveSpreadBp"].(float64); ok {
+		order.curveSpreadBp = sql.NullFloat64{Float64: curveSpreadBp, Valid: true}
+	}
+
+	var entries []map[string]interface{}
+	switch value := payload["allocations"].(type) {
+	case []map[string]interface{}:
+		entries = value
+	case []interface{}:
+		for _, item := range value {
+			if entry, ok := item.(map[string]interface{}); ok {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	for _, entry := range entries {
+		target := allocation.Target{
+			AccountID: payloadString(entry["accountId"]),
+			OrderID:   payloadString(entry["orderId"]),
+		}
+		target.Quantity, _ = entry["quantity"].(float64)
+		order.allocations = append(order.allocations, target)
+	}
+	if len(order.allocations) == 0 {
+		return nil, fmt.Errorf("block %s has no allocations", blockID)
+	}
+
+	return order, nil
+}
+
+// allocateBlock splits a block execution's fills across the block's child
+// orders pro-rata, books an allocation for each account charged under that
+// account's fee schedule and fills the child orders. When the block's
+// remainder was cancelled, for the given reason, children that were not
+// filled in full have the rest cancelled.
+func (s *Service) allocateBlock(order *orderRecord, instrument *instrumentRecord, executionID, actorID, correlationID string, filledQuantity, avgFillPrice float64, asOfDate time.Time, cancelReason string, causation *events.Event) error {
+	results, err := allocation.ProRata(filledQuantity, order.allocations, order.lotSize)
+	if err != nil {
+		return fmt.Errorf("failed to allocate block %s: %w", order.blockID, err)
+	}
+
+	tradeDate := s.tradeDate(asOfDate)
+	settlementDate := s.settlementDate(asOfDate, instrument.instrumentType)
+	allocatedOrderIDs := []string{}
+	settlementIDs := []string{}
+	totalCharges := fees.Charges{}
+	totalAmounts := settlement.Amounts{}
+	publish := func(event *events.Event) error {
+		if causation != nil {
+			event.WithCausation(causation.EventID)
+		}
+		return s.appendAndPublish(event)
+	}
+
+	for _, result := range results {
+		if result.Quantity > 0 {
+			allocatedOrderIDs = append(allocatedOrderIDs, result.OrderID)
+
+			schedule, err := s.scheduleFor(result.AccountID, instrument.instrumentType)
+			if err != nil {
+				return err
+			}
+			charges := fees.Compute(schedule, result.Quantity, avgFillPrice)
+			totalCharges = totalCharges.Add(charges)
+			amounts := s.settlementAmounts(instrument, order.side, result.Quantity, avgFillPrice, charges, settlementDate)
+			totalAmounts = totalAmounts.Add(amounts)
+
+			// Each allocation settles on its own, under the allocation's ID
+			allocationID := uuid.New().String()
+			settlementIDs = append(settlementIDs, allocationID)
+			allocationPayload := map[string]interface{}{
+				"allocationId":   allocationID,
+				"settlementId":   allocationID,
+				"blockId":        order.blockID,
+				"executionId":    executionID,
+				"orderId":        result.OrderID,
+				"accountId":      result.AccountID,
+				"instrumentId":   order.instrumentID,
+				"side":           order.side,
+				"quantity":       result.Quantity,
+				"targetQuantity": result.Target,
+				"price":          avgFillPrice,
+				"tradeDate":      tradeDate,
+				"settlementDate": settlementDate,
+				"status":         settlement.StatusPending,
+			}
+			charges.Payload(allocationPayload)
+			amounts.Payload(allocationPayload)
+
+			if err := publish(events.NewEvent(
+				events.EventAllocationBooked,
+				events.AggregateExecution,
+				executionID,
+				actorID,
+				"user",
+				correlationID,
+				allocationPayload,
+			)); err != nil {
+				return err
+			}
+
+			fillType := events.EventOrderPartiallyFilled
+			if result.Quantity >= result.Target {
+				fillType = events.EventOrderFullyFilled
+			}
+			if err := publish(events.NewEvent(
+				fillType,
+				events.AggregateOrder,
+				result.OrderID,
+				actorID,
+				"user",
+				correlationID,
+				map[string]interface{}{
+					"orderId":        result.OrderID,
+					"blockId":        order.blockID,
+					"executionId":    executionID,
+					"filledQuantity": result.Quantity,
+					"avgFillPrice":   avgFillPrice,
+				},
+			)); err != nil {
+				return err
+			}
+		}
+
+		if result.Quantity < result.Target && cancelReason != "" {
+			if err := publish(events.NewEvent(
+				events.EventOrderCancelled,
+				events.AggregateOrder,
+				result.OrderID,
+				"system:ems",
+				"system",
+				correlationID,
+				map[string]interface{}{
+					"orderId":           result.OrderID,
+					"blockId":           order.blockID,
+					"executionId":       executionID,
+					"cancelledBy":       "system:ems",
+					"cancelledAt":       time.Now().UTC(),
+					"reason":            cancelReason,
+					"filledQuantity":    result.Quantity,
+					"cancelledQuantity": result.Target - result.Quantity,
+				},
+			)); err != nil {
+				return err
+			}
+		}
+	}
+
+	state := "FILLED"
+	if filledQuantity < order.quantity {
+		state = "PARTIALLY_FILLED"
+	}
+	if err := publish(events.NewEvent(
+		events.EventBlockOrderAllocated,
+		events.AggregateBlockOrder,
+		order.blockID,
+		actorID,
+		"user",
+		correlationID,
+		map[string]interface{}{
+			"blockId":        order.blockID,
+			"executionId":    executionID,
+			"state":          state,
+			"filledQuantity": filledQuantity,
+			"avgFillPrice":   avgFillPrice,
+			"allocations":    results,
+		},
+	)); err != nil {
+		return err
+	}
+
+	if filledQuantity == 0 {
+		return nil
+	}
+
+	// Positions are booked and settled per account from AllocationBooked; the
+	// block's settlement lists the allocations' settlements and carries the
+	// sum of the accounts' charges and amounts
+	payload := map[string]interface{}{
+		"executionId":    executionID,
+		"blockId":        order.blockID,
+		"orderIds":       allocatedOrderIDs,
+		"settlementIds":  settlementIDs,
+		"instrumentId":   order.instrumentID,
+		"side":           order.side,
+		"filledQuantity": filledQuantity,
+		"avgFillPrice":   avgFillPrice,
+		"tradeDate":      tradeDate,
+		"settlementDate": settlementDate,
+		"status":         settlement.StatusPending,
+	}
+	totalCharges.ScheduleID = ""
+	totalCharges.Payload(payload)
+	totalAmounts.Payload(payload)
+
+	return publish(events.NewEvent(
+		events.EventSettlementBooked,
+		events.AggregateExecution,
+		executionID,
+		actorID,
+		"user",
+		correlationID,
+		payload,
+	))
+}
+
+// payloadString reads a string payload value, which may be a named string
+// type when the event was published in-process
+func payloadString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,280 @@
+package ems
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// BrokerVenueName is the venue the HTTP broker is registered as
+const BrokerVenueName = "mock-broker"
+
+// BrokerVenue routes orders to a broker over HTTP. The broker answers a
+// submitted order with the fills it got so far; an order it is still working
+// is polled for more, off the EMS listener, until it is done or has been
+// working for workingTime, when what is left is cancelled. An order whose submission gets no answer is
+// looked up, and failing that cancelled, so it is never left working unseen.
+type BrokerVenue struct {
+	baseURL      string
+	client       *http.Client
+	pollInterval time.Duration
+	workingTime  time.Duration
+}
+
+// brokerRejection is the broker turning down a request it received, as
+// opposed to a request that may or may not have reached it
+type brokerRejection struct {
+	path    string
+	status  int
+	message string
+}
+
+func (r *brokerRejection) Error() string {
+	return fmt.Sprintf("broker rejected %s: %s", r.path, r.message)
+}
+
+type brokerOrderRequest struct {
+	ClientOrderID  string   `json:"clientOrderId"`
+	InstrumentID   string   `json:"instrumentId"`
+	Side           string   `json:"side"`
+	Quantity       float64  `json:"quantity"`
+	OrderType      string   `json:"orderType"`
+	LimitPrice     *float64 `json:"limitPrice,omitempty"`
+	TimeInForce    string   `json:"timeInForce"`
+	ReferencePrice float64  `json:"referencePrice"`
+}
+
+type brokerOrderResponse struct {
+	OrderID           string  `json:"orderId"`
+	Status            string  `json:"status"`
+	FilledQuantity    float64 `json:"filledQuantity"`
+	LeavesQuantity    float64 `json:"leavesQuantity"`
+	CancelledQuantity float64 `json:"cancelledQuantity"`
+	Fills             []struct {
+		FillID    string    `json:"fillId"`
+		Quantity  float64   `json:"quantity"`
+		Price     float64   `json:"price"`
+		Timestamp time.Time `json:"timestamp"`
+	} `json:"fills"`
+}
+
+// NewBrokerVenue creates a venue for the broker at baseURL. Orders the broker
+// leaves open are polled every pollInterval for up to workingTime.
+func NewBrokerVenue(baseURL string, timeout, pollInterval, workingTime time.Duration) *BrokerVenue {
+	return &BrokerVenue{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		client:       &http.Client{Timeout: timeout},
+		pollInterval: pollInterval,
+		workingTime:  workingTime,
+	}
+}
+
+// Name returns the broker's venue name
+func (v *BrokerVenue) Name() string {
+	return BrokerVenueName
+}
+
+// Submit sends the order to the broker, keyed by execution ID, and reports
+// the fills it got straight away. An order the broker is still working comes
+// back with Working set to poll it for the rest. Slippage is all put down to
+// the broker's spread.
+func (v *BrokerVenue) Submit(order VenueOrder, onFill FillCallback) (*VenueReport, error) {
+	orderType := order.OrderType
+	var limitPrice *float64
+	if orderType == "LIMIT" || orderType == "YIELD_LIMIT" {
+		// Brokers take clean price limits
+		orderType = "LIMIT"
+		limitPrice = order.LimitPrice
+	}
+
+	response, remainderCancelled, err := v.place(brokerOrderRequest{
+		ClientOrderID:  order.ExecutionID,
+		InstrumentID:   order.InstrumentID,
+		Side:           order.Side,
+		Quantity:       order.Quantity,
+		OrderType:      orderType,
+		LimitPrice:     limitPrice,
+		TimeInForce:    order.TimeInForce,
+		ReferencePrice: order.BaselinePrice,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sideMultiplier := 1.0
+	if order.Side == "SELL" {
+		sideMultiplier = -1.0
+	}
+	reported := map[string]bool{}
+	reportFills := func(response *brokerOrderResponse) error {
+		for _, fill := range response.Fills {
+			if reported[fill.FillID] {
+				continue
+			}
+			reported[fill.FillID] = true
+			if err := onFill(VenueFill{
+				Quantity:  fill.Quantity,
+				Price:     fill.Price,
+				Timestamp: fill.Timestamp,
+				SlippageBps: map[string]float64{
+					"brokerSpread": ((fill.Price - order.BaselinePrice) / order.BaselinePrice) * 10000 * sideMultiplier,
+				},
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := reportFills(response); err != nil {
+		return nil, err
+	}
+
+	report := v.report(order, response, remainderCancelled)
+	if response.open() && !order.immediateOrCancel() && v.pollInterval > 0 {
+		report.Working = func() (*VenueReport, error) {
+			return v.work(order, response, reportFills)
+		}
+	}
+	return report, nil
+}
+
+// work polls an order the broker is still working and reports its fills
+// until it is done or has been working for workingTime, then cancels what is
+// left
+func (v *BrokerVenue) work(order VenueOrder, response *brokerOrderResponse, reportFills func(*brokerOrderResponse) error) (*VenueReport, error) {
+	orderPath := "/orders/" + url.PathEscape(order.ExecutionID)
+	deadline := time.Now().Add(v.workingTime)
+	for response.open() && time.Now().Before(deadline) {
+		time.Sleep(v.pollInterval)
+		polled := &brokerOrderResponse{}
+		if err := v.do(http.MethodGet, orderPath, nil, polled); err != nil {
+			var rejection *brokerRejection
+			if errors.As(err, &rejection) {
+				return nil, err
+			}
+			// The broker may answer the next poll
+			continue
+		}
+		response = polled
+		if err := reportFills(response); err != nil {
+			return nil, err
+		}
+	}
+
+	if !response.open() {
+		return v.report(order, response, false), nil
+	}
+	cancelled := &brokerOrderResponse{}
+	if err := v.do(http.MethodPost, orderPath+"/cancel", nil, cancelled); err != nil {
+		return nil, fmt.Errorf("broker order %s still working after %s: %w", response.OrderID, v.workingTime, err)
+	}
+	if err := reportFills(cancelled); err != nil {
+		return nil, err
+	}
+	return v.report(order, cancelled, true), nil
+}
+
+// report describes the broker order as it last stood
+func (v *BrokerVenue) report(order VenueOrder, response *brokerOrderResponse, remainderCancelled bool) *VenueReport {
+	explanation := fmt.Sprintf("Executed at broker %s as order %s.", v.baseURL, response.OrderID)
+	if remainderCancelled {
+		explanation += fmt.Sprintf(" The unfilled %.2f was cancelled at the broker.", response.CancelledQuantity)
+	}
+	return &VenueReport{
+		Inputs: map[string]interface{}{
+			"baselinePrice":     order.BaselinePrice,
+			"brokerOrderId":     response.OrderID,
+			"brokerStatus":      response.Status,
+			"leavesQuantity":    response.LeavesQuantity,
+			"cancelledQuantity": response.CancelledQuantity,
+		},
+		Explanation:        explanation,
+		RemainderCancelled: remainderCancelled,
+	}
+}
+
+// place submits an order. When the submission gets no answer the broker may
+// still have the order, so it is looked up and, failing that, cancelled;
+// cancelled reports whether place cancelled it. Only a broker that has never
+// seen the order lets the submission fail outright.
+func (v *BrokerVenue) place(request brokerOrderRequest) (response *brokerOrderResponse, cancelled bool, err error) {
+	response = &brokerOrderResponse{}
+	err = v.do(http.MethodPost, "/orders", request, response)
+	var rejection *brokerRejection
+	if err == nil || errors.As(err, &rejection) {
+		return response, false, err
+	}
+
+	orderPath := "/orders/" + url.PathEscape(request.ClientOrderID)
+	lookupErr := v.do(http.MethodGet, orderPath, nil, response)
+	if lookupErr == nil {
+		return response, false, nil
+	}
+	if errors.As(lookupErr, &rejection) && rejection.status == http.StatusNotFound {
+		return nil, false, err
+	}
+	cancelErr := v.do(http.MethodPost, orderPath+"/cancel", nil, response)
+	if cancelErr == nil {
+		return response, true, nil
+	}
+	if errors.As(cancelErr, &rejection) && rejection.status == http.StatusNotFound {
+		return nil, false, err
+	}
+	return nil, false, fmt.Errorf("%w; order %s may be working at the broker: %v", err, request.ClientOrderID, cancelErr)
+}
+
+// Cancel cancels whatever the broker has left of an execution
+func (v *BrokerVenue) Cancel(executionID string) error {
+	var response brokerOrderResponse
+	return v.do(http.MethodPost, "/orders/"+url.PathEscape(executionID)+"/cancel", nil, &response)
+}
+
+// open reports whether the broker is still working the order
+func (r *brokerOrderResponse) open() bool {
+	return r.Status == "PARTIALLY_FILLED" && r.LeavesQuantity > 0
+}
+
+func (v *BrokerVenue) do(method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode broker request: %w", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, v.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to build broker request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("broker request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&failure)
+		if failure.Error == "" {
+			failure.Error = resp.Status
+		}
+		return &brokerRejection{path: path, status: resp.StatusCode, message: failure.Error}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode broker response: %w", err)
+	}
+	return nil
+}
@@ -7,7 +7,14 @@ import (
 	"instant/services/api/eventbus"
 	"instant/services/api/events"
 	"instant/services/api/eventstore"
-	"math"
+	"instant/services/api/projections"
+	"instant/services/api/services/allocation"
+	"instant/services/api/services/calendar"
+	"instant/services/api/services/fees"
+	"instant/services/api/services/pricing"
+	"instant/services/api/services/routing"
+	"instant/services/api/services/settlement"
+	"instant/services/api/services/tradingcontrol"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,18 +22,20 @@ import (
 )
 
 type Service struct {
-	eventStore *eventstore.EventStore
-	eventBus   *eventbus.EventBus
-	db         *sql.DB
-	stopChan   chan struct{}
+	eventStore      *eventstore.EventStore
+	eventBus        *eventbus.EventBus
+	db              *sql.DB
+	tradingControls *tradingcontrol.Service
+	fees            *fees.Service
+	pricing         *pricing.Service
+	calendar        *calendar.Calendar
+	router          *VenueRouter
+	checkpoints     *projections.CheckpointStore
+	stopChan        chan struct{}
 }
 
-type liquidityProfile struct {
-	maxClip       float64
-	spreadBps     float64
-	sizeImpactBps float64
-	sideImpactBps float64
-}
+// listenerCheckpoint is the checkpoint the EMS listener resumes from
+const listenerCheckpoint = "ems-listener"
 
 type orderRecord struct {
 	orderID       string
@@ -37,56 +46,108 @@ type orderRecord struct {
 	orderType     string
 	limitPrice    sql.NullFloat64
 	curveSpreadBp sql.NullFloat64
+	timeInForce   string
+
+	// blockID, allocations and lotSize are set when a block order is executed
+	// for several accounts at once
+	blockID     string
+	allocations []allocation.Target
+	lotSize     float64
 }
 
 type instrumentRecord struct {
-	maturityDate time.Time
-	askPrice     sql.NullFloat64
+	cusip           string
+	instrumentType  string
+	coupon          float64
+	issueDate       time.Time
+	maturityDate    time.Time
+	couponFrequency int
+	askPrice        sql.NullFloat64
 }
 
 var (
 	ErrOrderNotFound      = errors.New("order not found")
 	ErrInstrumentNotFound = errors.New("instrument not found")
+	ErrInvalidSeed        = errors.New("seed must be between 0 and 2^53")
 )
 
-var bucketProfiles = map[string]liquidityProfile{
-	"0-2Y":   {maxClip: 100000, spreadBps: 0.6, sizeImpactBps: 0.2, sideImpactBps: 0.1},
-	"2-5Y":   {maxClip: 75000, spreadBps: 0.9, sizeImpactBps: 0.35, sideImpactBps: 0.12},
-	"5-10Y":  {maxClip: 50000, spreadBps: 1.3, sizeImpactBps: 0.5, sideImpactBps: 0.15},
-	"10-30Y": {maxClip: 35000, spreadBps: 1.8, sizeImpactBps: 0.7, sideImpactBps: 0.2},
-	"30Y+":   {maxClip: 30000, spreadBps: 2.2, sizeImpactBps: 0.9, sideImpactBps: 0.25},
-}
-
-// NewService creates a new EMS service.
-func NewService(db *sql.DB, es *eventstore.EventStore, eb *eventbus.EventBus) (*Service, error) {
+// MaxSeed is the largest simulation seed accepted. Seeds are recorded in event
+// payloads, which round-trip numbers through float64.
+const MaxSeed = 1<<53 - 1
+
+// NewService creates a new EMS service. Orders a trading kill switch covers
+// are held instead of executed, the rest are worked at the venue their routing
+// policy names, fills are charged under the account's fee schedule, and trades
+// settle on the bond market calendar. Without a router every order goes to the
+// simulator.
+func NewService(db *sql.DB, es *eventstore.EventStore, eb *eventbus.EventBus, tradingControls *tradingcontrol.Service, feeSchedules *fees.Service, marketCalendar *calendar.Calendar, router *VenueRouter) (*Service, error) {
+	if router == nil {
+		var err error
+		if router, err = NewVenueRouter(nil, SimulatorVenueName, NewSimulatorVenue()); err != nil {
+			return nil, err
+		}
+	}
 	return &Service{
-		eventStore: es,
-		eventBus:   eb,
-		db:         db,
-		stopChan:   make(chan struct{}),
+		eventStore:      es,
+		eventBus:        eb,
+		db:              db,
+		tradingControls: tradingControls,
+		fees:            feeSchedules,
+		pricing:         pricing.NewService(),
+		calendar:        marketCalendar,
+		router:          router,
+		checkpoints:     projections.NewCheckpointStore(db),
+		stopChan:        make(chan struct{}),
 	}, nil
 }
 
-// Start listens for OrderSentToEMS events and runs execution simulations.
+// Start listens for OrderSentToEMS, BlockOrderSentToEMS and OrdersNetted events and runs execution simulations.
+// It first catches up on those appended since its checkpoint, so orders sent
+// while no instance ran the listener are still executed.
 func (s *Service) Start() {
-	subscriber, cleanup := s.eventBus.Subscribe(events.EventOrderSentToEMS, 1000)
+	subscriber, cleanup := s.eventBus.Subscribe("*", 1000)
 	defer cleanup()
 
+	if !projections.CatchUp(listenerCheckpoint, subscriber, s.eventStore, s.checkpoints, s.apply, s.stopChan) {
+		return
+	}
+
 	for {
 		select {
-		case event := <-subscriber:
-			if event == nil {
-				continue
-			}
-			if err := s.handleOrderSent(event); err != nil {
-				fmt.Printf("EMS simulation error for order event %s: %v\n", event.EventID, err)
+		case event, ok := <-subscriber:
+			if !ok {
+				// The bus has closed the subscription
+				return
 			}
+			s.apply(event)
 		case <-s.stopChan:
 			return
 		}
 	}
 }
 
+// apply executes what an event sends to the EMS and advances the listener's
+// checkpoint past it
+func (s *Service) apply(event *events.Event) {
+	var err error
+	switch event.EventType {
+	case events.EventOrderSentToEMS:
+		err = s.handleOrderSent(event)
+	case events.EventBlockOrderSentToEMS:
+		err = s.handleBlockOrderSent(event)
+	case events.EventOrdersNetted:
+		err = s.handleOrdersNetted(event)
+	default:
+		return
+	}
+	if err != nil && !errors.Is(err, tradingcontrol.ErrTradingHalted) {
+		fmt.Printf("EMS simulation error for %s event %s: %v\n", event.EventType, event.EventID, err)
+	}
+	if err := s.checkpoints.Advance(listenerCheckpoint, event.Position); err != nil {
+		fmt.Printf("%s checkpoint error: %v\n", listenerCheckpoint, err)
+	}
+}
+
 // Stop stops the service listener.
 func (s *Service) Stop() {
 	close(s.stopChan)
@@ -100,8 +161,11 @@ func (s *Service) RequestExecution(req RequestExecutionRequest, correlationID st
 	if req.RequestedBy == "" {
 		return "", errors.New("requestedBy is required")
 	}
+	if req.Seed != nil && (*req.Seed < 0 || *req.Seed > MaxSeed) {
+		return "", ErrInvalidSeed
+	}
 
-	return s.runSimulation(req.OrderID, req.RequestedBy, correlationID, req.AsOfDate, nil)
+	return s.runSimulation(req.OrderID, req.RequestedBy, correlationID, req.AsOfDate, req.Seed, nil)
 }
 
 func (s *Service) handleOrderSent(event *events.Event) error {
@@ -109,17 +173,118 @@ func (s *Service) handleOrderSent(event *events.Event) error {
 	if !ok || orderID == "" {
 		return errors.New("orderId missing in OrderSentToEMS payload")
 	}
+	if blockID, _ := event.Payload["blockId"].(string); blockID != "" {
+		// Child of a block: executed when BlockOrderSentToEMS arrives
+		return nil
+	}
+	if nettingID, _ := event.Payload["nettingId"].(string); nettingID != "" {
+		// Netted order: its residual is executed when OrdersNetted arrives
+		return nil
+	}
 
-	_, err := s.runSimulation(orderID, event.Actor.ActorID, event.CorrelationID, nil, event)
+	_, err := s.runSimulation(orderID, event.Actor.ActorID, event.CorrelationID, nil, nil, event)
 	return err
 }
 
-func (s *Service) runSimulation(orderID, actorID, correlationID string, asOfOverride *time.Time, causation *events.Event) (string, error) {
+func (s *Service) handleBlockOrderSent(event *events.Event) error {
+	order, err := blockOrderFromPayload(event.Payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.simulate(order, event.Actor.ActorID, event.CorrelationID, nil, nil, event)
+	return err
+}
+
+// handleOrdersNetted executes what is left of each order after internal crossing
+func (s *Service) handleOrdersNetted(event *events.Event) error {
+	var residuals []map[string]interface{}
+	switch value := event.Payload["residuals"].(type) {
+	case []map[string]interface{}:
+		residuals = value
+	case []interface{}:
+		for _, item := range value {
+			if residual, ok := item.(map[string]interface{}); ok {
+				residuals = append(residuals, residual)
+			}
+		}
+	}
+
+	var errs []error
+	for _, residual := range residuals {
+		orderID := payloadString(residual["orderId"])
+		quantity, _ := residual["quantity"].(float64)
+		if orderID == "" || quantity <= 0 {
+			continue
+		}
+
+		order, err := s.fetchOrder(orderID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("order %s: %w", orderID, err))
+			continue
+		}
+		order.quantity = quantity
+		if _, err := s.simulate(order, event.Actor.ActorID, event.CorrelationID, nil, nil, event); err != nil {
+			errs = append(errs, fmt.Errorf("order %s: %w", orderID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// remainderCancelReason returns why an execution's unfilled remainder is
+// cancelled, or "" when it is left open: IOC orders never rest, and a venue
+// may give up on an order and cancel what is left itself.
+func remainderCancelReason(immediateOrCancel bool, report *VenueReport) string {
+	switch {
+	case immediateOrCancel:
+		return "IOC remainder cancelled"
+	case report != nil && report.RemainderCancelled:
+		return "unfilled remainder cancelled at venue"
+	default:
+		return ""
+	}
+}
+
+func (s *Service) runSimulation(orderID, actorID, correlationID string, asOfOverride *time.Time, seed *int64, causation *events.Event) (string, error) {
 	order, err := s.fetchOrder(orderID)
 	if err != nil {
 		return "", err
 	}
 
+	return s.simulate(order, actorID, correlationID, asOfOverride, seed, causation)
+}
+
+// executionIDFor is the execution an event sends an order, or a block order,
+// to the EMS for. It is the same each time the event is handled, so an event
+// handled again after a failover finds the execution already requested.
+func executionIDFor(causationID string, order *orderRecord) string {
+	key := order.orderID
+	if order.blockID != "" {
+		key = "block:" + order.blockID
+	}
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(causationID+"/"+key)).String()
+}
+
+// simulate routes an order, or a block order, to an execution venue and books
+// the fills the venue reports. seed, when set, seeds a stochastic simulation.
+// An order an event has already sent to execution is not executed again.
+func (s *Service) simulate(order *orderRecord, actorID, correlationID string, asOfOverride *time.Time, seed *int64, causation *events.Event) (string, error) {
+	executionID := uuid.New().String()
+	if causation != nil {
+		executionID = executionIDFor(causation.EventID, order)
+		existing, err := s.eventStore.GetByAggregate(events.AggregateExecution, executionID)
+		if err != nil {
+			return "", err
+		}
+		if len(existing) > 0 {
+			return executionID, nil
+		}
+	}
+
+	if err := s.holdIfHalted(order, actorID, correlationID, causation); err != nil {
+		return "", err
+	}
+
 	instrument, err := s.fetchInstrument(order.instrumentID)
 	if err != nil {
 		return "", err
@@ -129,9 +294,6 @@ func (s *Service) runSimulation(orderID, actorID, correlationID string, asOfOver
 	if asOfOverride != nil {
 		asOfDate = asOfOverride.UTC()
 	}
-	yearsToMaturity := instrument.maturityDate.Sub(asOfDate).Hours() / (24 * 365.25)
-	bucket := maturityBucket(yearsToMaturity)
-	profile := bucketProfiles[bucket]
 
 	baselinePrice := 100.0
 	if instrument.askPrice.Valid {
@@ -142,23 +304,42 @@ func (s *Service) runSimulation(orderID, actorID, correlationID string, asOfOver
 		baselinePrice = baselinePrice * (1 + order.curveSpreadBp.Float64/10000)
 	}
 
-	totalQuantity := order.quantity
-	maxClip := profile.maxClip
-	clipCount := int(math.Ceil(totalQuantity / maxClip))
-	if clipCount < 1 {
-		clipCount = 1
+	// Block fills are charged per account when they are allocated
+	var schedule *fees.Schedule
+	if order.blockID == "" {
+		if schedule, err = s.scheduleFor(order.accountID, instrument.instrumentType); err != nil {
+			return "", err
+		}
 	}
 
-	executionID := uuid.New().String()
+	venue, policy, err := s.route(order, instrument.instrumentType)
+	if err != nil {
+		return "", err
+	}
+
+	totalQuantity := order.quantity
+	immediateOrCancel := order.timeInForce == "IOC"
+
 	executionStart := time.Now().UTC()
 
-	deterministicInputs := map[string]interface{}{
-		"baselinePrice":  baselinePrice,
-		"maturityBucket": bucket,
-		"maxClip":        maxClip,
-		"spreadBps":      profile.spreadBps,
-		"sizeImpactBps":  profile.sizeImpactBps,
-		"sideImpactBps":  profile.sideImpactBps,
+	requestPayload := map[string]interface{}{
+		"executionId":    executionID,
+		"instrumentId":   order.instrumentID,
+		"side":           order.side,
+		"totalQuantity":  totalQuantity,
+		"filledQuantity": 0.0,
+		"status":         ExecutionStatusPending,
+		"asOfDate":       asOfDate,
+		"venue":          venue.Name(),
+	}
+	if policy != nil {
+		requestPayload["routingPolicyId"] = policy.PolicyID
+	}
+	if order.blockID != "" {
+		requestPayload["blockId"] = order.blockID
+	} else {
+		requestPayload["orderId"] = order.orderID
+		requestPayload["accountId"] = order.accountID
 	}
 
 	execRequested := events.NewEvent(
@@ -168,65 +349,86 @@ func (s *Service) runSimulation(orderID, actorID, correlationID string, asOfOver
 		actorID,
 		"user",
 		correlationID,
-		map[string]interface{}{
-			"executionId":    executionID,
-			"orderId":        order.orderID,
-			"accountId":      order.accountID,
-			"instrumentId":   order.instrumentID,
-			"side":           order.side,
-			"totalQuantity":  totalQuantity,
-			"filledQuantity": 0.0,
-			"status":         ExecutionStatusPending,
-			"asOfDate":       asOfDate,
-		},
+		requestPayload,
 	)
 	if causation != nil {
 		execRequested.WithCausation(causation.EventID)
 	}
-	if err := s.appendAndPublish(execRequested); err != nil {
+	// Only the first to request the execution works it
+	if err := s.eventStore.AppendExpected(execRequested, 0); err != nil {
+		if errors.Is(err, eventstore.ErrConcurrencyConflict) {
+			return executionID, nil
+		}
 		return "", err
 	}
+	s.eventBus.Publish(execRequested)
+
+	venueOrder := VenueOrder{
+		ExecutionID:     executionID,
+		OrderID:         order.orderID,
+		BlockID:         order.blockID,
+		AccountID:       order.accountID,
+		InstrumentID:    order.instrumentID,
+		InstrumentType:  instrument.instrumentType,
+		Side:            order.side,
+		Quantity:        totalQuantity,
+		OrderType:       order.orderType,
+		TimeInForce:     order.timeInForce,
+		BaselinePrice:   baselinePrice,
+		YearsToMaturity: instrument.maturityDate.Sub(asOfDate).Hours() / (24 * 365.25),
+		AsOfDate:        asOfDate,
+		Seed:            seed,
+	}
+	// Yield limits reach the EMS already converted to a clean price
+	if order.limitPrice.Valid {
+		limitPrice := order.limitPrice.Float64
+		venueOrder.LimitPrice = &limitPrice
+	}
 
 	totalFilled := 0.0
 	totalNotional := 0.0
+	totalCharges := fees.Charges{}
 	totalSlippageWeighted := 0.0
-	slippageComponentsWeighted := map[string]float64{
-		"bucketSpread": 0,
-		"sizeImpact":   0,
-		"sideImpact":   0,
-	}
+	slippageComponentsWeighted := map[string]float64{}
+	fillCount := 0
+	lastFillTime := time.Time{}
 
 	sideMultiplier := 1.0
 	if order.side == "SELL" {
 		sideMultiplier = -1.0
 	}
 
-	for clipIndex := 0; clipIndex < clipCount; clipIndex++ {
-		remaining := totalQuantity - totalFilled
-		clipQty := math.Min(maxClip, remaining)
-		sizeFactor := clipQty / maxClip
-
-		spreadBps := profile.spreadBps
-		sizeImpactBps := profile.sizeImpactBps * sizeFactor
-		sideImpactBps := profile.sideImpactBps
-
-		totalBps := (spreadBps + sizeImpactBps + sideImpactBps) * sideMultiplier
-		price := baselinePrice * (1 + totalBps/10000)
+	report, err := venue.Submit(venueOrder, func(fill VenueFill) error {
+		if fill.Quantity <= 0 {
+			return nil
+		}
+		fillCount++
 
-		if order.orderType == "LIMIT" && order.limitPrice.Valid {
-			if order.side == "BUY" && price > order.limitPrice.Float64 {
-				price = order.limitPrice.Float64
-			}
-			if order.side == "SELL" && price < order.limitPrice.Float64 {
-				price = order.limitPrice.Float64
-			}
+		slippageBps := ((fill.Price - baselinePrice) / baselinePrice) * 10000 * sideMultiplier
+		totalSlippageWeighted += slippageBps * fill.Quantity
+		for component, bps := range fill.SlippageBps {
+			slippageComponentsWeighted[component] += bps * fill.Quantity
 		}
 
-		slippageBps := ((price - baselinePrice) / baselinePrice) * 10000 * sideMultiplier
-		totalSlippageWeighted += slippageBps * clipQty
-		slippageComponentsWeighted["bucketSpread"] += spreadBps * sideMultiplier * clipQty
-		slippageComponentsWeighted["sizeImpact"] += sizeImpactBps * sideMultiplier * clipQty
-		slippageComponentsWeighted["sideImpact"] += sideImpactBps * sideMultiplier * clipQty
+		timestamp := fill.Timestamp
+		if timestamp.IsZero() {
+			timestamp = time.Now().UTC()
+		}
+		if timestamp.After(lastFillTime) {
+			lastFillTime = timestamp
+		}
+		fillPayload := map[string]interface{}{
+			"fillId":      uuid.New().String(),
+			"executionId": executionID,
+			"clipIndex":   fillCount,
+			"quantity":    fill.Quantity,
+			"price":       fill.Price,
+			"timestamp":   timestamp,
+			"slippage":    slippageBps,
+		}
+		charges := fees.Compute(schedule, fill.Quantity, fill.Price)
+		charges.Payload(fillPayload)
+		totalCharges = totalCharges.Add(charges)
 
 		fillEvent := events.NewEvent(
 			events.EventFillGenerated,
@@ -235,27 +437,19 @@ func (s *Service) runSimulation(orderID, actorID, correlationID string, asOfOver
 			actorID,
 			"user",
 			correlationID,
-			map[string]interface{}{
-				"fillId":      uuid.New().String(),
-				"executionId": executionID,
-				"clipIndex":   clipIndex + 1,
-				"quantity":    clipQty,
-				"price":       price,
-				"timestamp":   time.Now().UTC(),
-				"slippage":    slippageBps,
-			},
+			fillPayload,
 		)
 		if causation != nil {
 			fillEvent.WithCausation(causation.EventID)
 		}
 		if err := s.appendAndPublish(fillEvent); err != nil {
-			return "", err
+			return err
 		}
 
-		totalFilled += clipQty
-		totalNotional += clipQty * price
+		totalFilled += fill.Quantity
+		totalNotional += fill.Quantity * fill.Price
 
-		if totalFilled < totalQuantity {
+		if totalFilled < totalQuantity && order.blockID == "" {
 			partiallyFilled := events.NewEvent(
 				events.EventOrderPartiallyFilled,
 				events.AggregateOrder,
@@ -273,105 +467,341 @@ func (s *Service) runSimulation(orderID, actorID, correlationID string, asOfOver
 				partiallyFilled.WithCausation(causation.EventID)
 			}
 			if err := s.appendAndPublish(partiallyFilled); err != nil {
-				return "", err
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return executionID, fmt.Errorf("venue %s: %w", venue.Name(), err)
+	}
+
+	// complete books the execution once the venue has finished with the order
+	complete := func(report *VenueReport) error {
+		avgFillPrice := 0.0
+		if totalFilled > 0 {
+			avgFillPrice = totalNotional / totalFilled
+		}
+
+		// Simulated fills may be stamped after the simulation itself returns
+		executionEnd := time.Now().UTC()
+		if lastFillTime.After(executionEnd) {
+			executionEnd = lastFillTime
+		}
+		averageSlippage := 0.0
+		slippageComponents := map[string]float64{}
+		for component := range slippageComponentsWeighted {
+			slippageComponents[component] = 0
+		}
+		if totalFilled > 0 {
+			averageSlippage = totalSlippageWeighted / totalFilled
+			for component, weighted := range slippageComponentsWeighted {
+				slippageComponents[component] = weighted / totalFilled
+			}
+		}
+
+		deterministicInputs := report.Inputs
+		if deterministicInputs == nil {
+			deterministicInputs = map[string]interface{}{}
+		}
+		deterministicInputs["venue"] = venue.Name()
+
+		execSimulated := events.NewEvent(
+			events.EventExecutionSimulated,
+			events.AggregateExecution,
+			executionID,
+			actorID,
+			"user",
+			correlationID,
+			map[string]interface{}{
+				"executionId":         executionID,
+				"filledQuantity":      totalFilled,
+				"avgFillPrice":        avgFillPrice,
+				"slippageTotal":       averageSlippage,
+				"slippageBreakdown":   slippageComponents,
+				"deterministicInputs": deterministicInputs,
+				"status":              ExecutionStatusSimulating,
+				"executionStartTime":  executionStart,
+				"executionEndTime":    executionEnd,
+				"explanation":         report.Explanation,
+			},
+		)
+		if causation != nil {
+			execSimulated.WithCausation(causation.EventID)
+		}
+		if err := s.appendAndPublish(execSimulated); err != nil {
+			return err
+		}
+
+		if order.blockID != "" {
+			return s.allocateBlock(order, instrument, executionID, actorID, correlationID, totalFilled, avgFillPrice, asOfDate, remainderCancelReason(immediateOrCancel, report), causation)
+		}
+
+		if totalFilled < totalQuantity {
+			if reason := remainderCancelReason(immediateOrCancel, report); reason != "" {
+				// Pull the unfilled remainder before telling OMS it is cancelled
+				if immediateOrCancel {
+					if err := venue.Cancel(executionID); err != nil {
+						return fmt.Errorf("venue %s: %w", venue.Name(), err)
+					}
+				}
+				remainderCancelled := events.NewEvent(
+					events.EventOrderCancelled,
+					events.AggregateOrder,
+					order.orderID,
+					"system:ems",
+					"system",
+					correlationID,
+					map[string]interface{}{
+						"orderId":           order.orderID,
+						"executionId":       executionID,
+						"cancelledBy":       "system:ems",
+						"cancelledAt":       time.Now().UTC(),
+						"reason":            reason,
+						"filledQuantity":    totalFilled,
+						"cancelledQuantity": totalQuantity - totalFilled,
+					},
+				)
+				if causation != nil {
+					remainderCancelled.WithCausation(causation.EventID)
+				}
+				if err := s.appendAndPublish(remainderCancelled); err != nil {
+					return err
+				}
+			}
+			if totalFilled == 0 {
+				return nil
 			}
+			return s.bookSettlement(order, instrument, executionID, actorID, correlationID, totalFilled, avgFillPrice, totalCharges, asOfDate, causation)
+		}
+
+		fullyFilled := events.NewEvent(
+			events.EventOrderFullyFilled,
+			events.AggregateOrder,
+			order.orderID,
+			actorID,
+			"user",
+			correlationID,
+			map[string]interface{}{
+				"orderId":        order.orderID,
+				"executionId":    executionID,
+				"filledQuantity": totalFilled,
+				"avgFillPrice":   avgFillPrice,
+			},
+		)
+		if causation != nil {
+			fullyFilled.WithCausation(causation.EventID)
 		}
+		if err := s.appendAndPublish(fullyFilled); err != nil {
+			return err
+		}
+
+		return s.bookSettlement(order, instrument, executionID, actorID, correlationID, totalFilled, avgFillPrice, totalCharges, asOfDate, causation)
 	}
 
-	avgFillPrice := 0.0
-	if totalFilled > 0 {
-		avgFillPrice = totalNotional / totalFilled
+	if report.Working != nil {
+		// The venue keeps working the order; the listener moves on
+		go func() {
+			final, err := report.Working()
+			if err != nil {
+				fmt.Printf("EMS venue %s error working execution %s: %v\n", venue.Name(), executionID, err)
+				return
+			}
+			if err := complete(final); err != nil {
+				fmt.Printf("EMS error completing execution %s: %v\n", executionID, err)
+			}
+		}()
+		return executionID, nil
 	}
+	return executionID, complete(report)
+}
+
+// route picks the venue that works an order
+func (s *Service) route(order *orderRecord, instrumentType string) (Venue, *routing.Policy, error) {
+	return s.router.Route(routing.Order{
+		AccountID:      order.accountID,
+		InstrumentType: instrumentType,
+		OrderType:      order.orderType,
+		Quantity:       order.quantity,
+	})
+}
 
-	executionEnd := time.Now().UTC()
-	averageSlippage := 0.0
-	slippageComponents := map[string]float64{
-		"bucketSpread": 0,
-		"sizeImpact":   0,
-		"sideImpact":   0,
+// CancelWorking cancels an order's open executions at the venues working
+// them, so nothing more fills once OMS expires the order
+func (s *Service) CancelWorking(orderID string) error {
+	rows, err := s.db.Query(`
+		SELECT "executionId", COALESCE(venue, $2)
+		FROM executions
+		WHERE "orderId" = $1
+		  AND status IN ('PENDING', 'SIMULATING', 'PARTIALLY_FILLED')
+	`, orderID, SimulatorVenueName)
+	if err != nil {
+		return fmt.Errorf("failed to load working executions: %w", err)
 	}
-	if totalFilled > 0 {
-		averageSlippage = totalSlippageWeighted / totalFilled
-		slippageComponents["bucketSpread"] = slippageComponentsWeighted["bucketSpread"] / totalFilled
-		slippageComponents["sizeImpact"] = slippageComponentsWeighted["sizeImpact"] / totalFilled
-		slippageComponents["sideImpact"] = slippageComponentsWeighted["sideImpact"] / totalFilled
+	defer rows.Close()
+
+	type working struct{ executionID, venue string }
+	var executions []working
+	for rows.Next() {
+		var execution working
+		if err := rows.Scan(&execution.executionID, &execution.venue); err != nil {
+			return fmt.Errorf("failed to scan working execution: %w", err)
+		}
+		executions = append(executions, execution)
+	}
+	if err := rows.Err(); err != nil {
+		return err
 	}
 
-	execSimulated := events.NewEvent(
-		events.EventExecutionSimulated,
+	for _, execution := range executions {
+		venue, err := s.router.Venue(execution.venue)
+		if err != nil {
+			return err
+		}
+		if err := venue.Cancel(execution.executionID); err != nil {
+			return fmt.Errorf("venue %s: %w", venue.Name(), err)
+		}
+	}
+	return nil
+}
+
+// bookSettlement emits SettlementBooked for the quantity an execution filled,
+// the charges on its fills and the cash it settles for. The settlement is
+// pending until the settlement job settles it on the settlement date.
+func (s *Service) bookSettlement(order *orderRecord, instrument *instrumentRecord, executionID, actorID, correlationID string, filledQuantity, avgFillPrice float64, charges fees.Charges, asOfDate time.Time, causation *events.Event) error {
+	settlementDate := s.settlementDate(asOfDate, instrument.instrumentType)
+	amounts := s.settlementAmounts(instrument, order.side, filledQuantity, avgFillPrice, charges, settlementDate)
+	payload := map[string]interface{}{
+		"settlementId":   uuid.New().String(),
+		"executionId":    executionID,
+		"orderId":        order.orderID,
+		"accountId":      order.accountID,
+		"instrumentId":   order.instrumentID,
+		"side":           order.side,
+		"filledQuantity": filledQuantity,
+		"avgFillPrice":   avgFillPrice,
+		"tradeDate":      s.tradeDate(asOfDate),
+		"settlementDate": settlementDate,
+		"status":         settlement.StatusPending,
+	}
+	charges.Payload(payload)
+	amounts.Payload(payload)
+
+	settlementBooked := events.NewEvent(
+		events.EventSettlementBooked,
 		events.AggregateExecution,
 		executionID,
 		actorID,
 		"user",
 		correlationID,
-		map[string]interface{}{
-			"executionId":         executionID,
-			"filledQuantity":      totalFilled,
-			"avgFillPrice":        avgFillPrice,
-			"slippageTotal":       averageSlippage,
-			"slippageBreakdown":   slippageComponents,
-			"deterministicInputs": deterministicInputs,
-			"status":              ExecutionStatusSimulating,
-			"executionStartTime":  executionStart,
-			"executionEndTime":    executionEnd,
-			"explanation":         "Deterministic execution simulation using bucketed liquidity profile.",
-		},
+		payload,
 	)
 	if causation != nil {
-		execSimulated.WithCausation(causation.EventID)
+		settlementBooked.WithCausation(causation.EventID)
 	}
-	if err := s.appendAndPublish(execSimulated); err != nil {
-		return "", err
+	return s.appendAndPublish(settlementBooked)
+}
+
+// tradeDate is the business day a trade on asOfDate is booked on
+func (s *Service) tradeDate(asOfDate time.Time) time.Time {
+	if s.calendar == nil {
+		return asOfDate.UTC().Truncate(24 * time.Hour)
 	}
+	return s.calendar.TradeDate(asOfDate)
+}
 
-	fullyFilled := events.NewEvent(
-		events.EventOrderFullyFilled,
-		events.AggregateOrder,
-		order.orderID,
-		actorID,
-		"user",
-		correlationID,
-		map[string]interface{}{
-			"orderId":        order.orderID,
-			"executionId":    executionID,
-			"filledQuantity": totalFilled,
-			"avgFillPrice":   avgFillPrice,
-		},
-	)
-	if causation != nil {
-		fullyFilled.WithCausation(causation.EventID)
+// settlementDate is when a trade on asOfDate settles: the instrument type's
+// settlement cycle in bond market business days, or the next day without a
+// calendar
+func (s *Service) settlementDate(asOfDate time.Time, instrumentType string) time.Time {
+	if s.calendar == nil {
+		return asOfDate.Add(24 * time.Hour)
 	}
-	if err := s.appendAndPublish(fullyFilled); err != nil {
-		return "", err
+	return s.calendar.SettlementDate(asOfDate, instrumentType)
+}
+
+// holdIfHalted records ExecutionHaltedByKillSwitch and returns an error
+// wrapping tradingcontrol.ErrTradingHalted when a kill switch covers the
+// order's accounts or the actor. The order stays SENT and can be re-requested
+// once the halt is released.
+func (s *Service) holdIfHalted(order *orderRecord, actorID, correlationID string, causation *events.Event) error {
+	if s.tradingControls == nil {
+		return nil
 	}
 
-	settlementDate := asOfDate.Add(24 * time.Hour)
-	settlementBooked := events.NewEvent(
-		events.EventSettlementBooked,
-		events.AggregateExecution,
-		executionID,
+	accountIDs := []string{}
+	if order.blockID != "" {
+		for _, target := range order.allocations {
+			accountIDs = append(accountIDs, target.AccountID)
+		}
+	} else {
+		accountIDs = append(accountIDs, order.accountID)
+	}
+
+	halt, err := s.tradingControls.ActiveHalt(accountIDs, actorID)
+	if err != nil {
+		return err
+	}
+	if halt == nil {
+		return nil
+	}
+
+	aggregateType, aggregateID := events.AggregateOrder, order.orderID
+	payload := map[string]interface{}{
+		"haltId":     halt.HaltID,
+		"scope":      halt.Scope,
+		"reason":     halt.Reason,
+		"accountIds": accountIDs,
+		"quantity":   order.quantity,
+		"heldAt":     time.Now().UTC(),
+	}
+	if order.blockID != "" {
+		aggregateType, aggregateID = events.AggregateBlockOrder, order.blockID
+		payload["blockId"] = order.blockID
+	} else {
+		payload["orderId"] = order.orderID
+	}
+	if halt.ScopeID != nil {
+		payload["scopeId"] = *halt.ScopeID
+	}
+
+	held := events.NewEvent(
+		events.EventExecutionHaltedByKillSwitch,
+		aggregateType,
+		aggregateID,
 		actorID,
-		"user",
+		"system",
 		correlationID,
-		map[string]interface{}{
-			"executionId":    executionID,
-			"orderId":        order.orderID,
-			"accountId":      order.accountID,
-			"instrumentId":   order.instrumentID,
-			"side":           order.side,
-			"filledQuantity": totalFilled,
-			"avgFillPrice":   avgFillPrice,
-			"settlementDate": settlementDate,
-		},
+		payload,
 	)
 	if causation != nil {
-		settlementBooked.WithCausation(causation.EventID)
+		held.WithCausation(causation.EventID)
 	}
-	if err := s.appendAndPublish(settlementBooked); err != nil {
-		return "", err
+	if err := s.appendAndPublish(held); err != nil {
+		return err
 	}
 
-	return executionID, nil
+	return fmt.Errorf("%w: execution held by halt %s (%s)", tradingcontrol.ErrTradingHalted, halt.HaltID, halt.Reason)
+}
+
+// settlementAmounts prices a fill at the settlement date: its principal,
+// the accrued interest the buyer pays the seller and the net amount with charges
+func (s *Service) settlementAmounts(instrument *instrumentRecord, side string, quantity, cleanPrice float64, charges fees.Charges, settlementDate time.Time) settlement.Amounts {
+	accrued := s.pricing.AccruedInterest(instrument.pricingInput(), settlementDate)
+	return settlement.Compute(side, quantity, cleanPrice, accrued, charges.Total())
+}
+
+// scheduleFor returns the fee schedule for an account's fills, or nil when
+// fees are not configured
+func (s *Service) scheduleFor(accountID, instrumentType string) (*fees.Schedule, error) {
+	if s.fees == nil {
+		return nil, nil
+	}
+	schedule, err := s.fees.ScheduleFor(accountID, instrumentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve fee schedule: %w", err)
+	}
+	return schedule, nil
 }
 
 func (s *Service) appendAndPublish(event *events.Event) error {
@@ -385,7 +815,7 @@ func (s *Service) appendAndPublish(event *events.Event) error {
 func (s *Service) fetchOrder(orderID string) (*orderRecord, error) {
 	query := `
 		SELECT "orderId", "accountId", "instrumentId", side, quantity, "orderType",
-		       "limitPrice", "curveSpreadBp"
+		       "limitPrice", "curveSpreadBp", "timeInForce"
 		FROM orders
 		WHERE "orderId" = $1
 	`
@@ -400,6 +830,7 @@ func (s *Service) fetchOrder(orderID string) (*orderRecord, error) {
 		&record.orderType,
 		&record.limitPrice,
 		&record.curveSpreadBp,
+		&record.timeInForce,
 	); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrOrderNotFound
@@ -412,14 +843,18 @@ func (s *Service) fetchOrder(orderID string) (*orderRecord, error) {
 
 func (s *Service) fetchInstrument(cusip string) (*instrumentRecord, error) {
 	query := `
-		SELECT "maturityDate", "askPrice"
+		SELECT type::text, coupon, "issueDate", "maturityDate", "couponFrequency", "askPrice"
 		FROM instruments
 		WHERE cusip = $1
 	`
 
-	var record instrumentRecord
+	record := instrumentRecord{cusip: cusip}
 	if err := s.db.QueryRow(query, cusip).Scan(
+		&record.instrumentType,
+		&record.coupon,
+		&record.issueDate,
 		&record.maturityDate,
+		&record.couponFrequency,
 		&record.askPrice,
 	); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -431,18 +866,15 @@ func (s *Service) fetchInstrument(cusip string) (*instrumentRecord, error) {
 	return &record, nil
 }
 
-func maturityBucket(yearsToMaturity float64) string {
-	if yearsToMaturity <= 2 {
-		return "0-2Y"
-	}
-	if yearsToMaturity <= 5 {
-		return "2-5Y"
-	}
-	if yearsToMaturity <= 10 {
-		return "5-10Y"
-	}
-	if yearsToMaturity <= 30 {
-		return "10-30Y"
+// pricingInput describes the instrument to the pricing service
+func (r *instrumentRecord) pricingInput() pricing.InstrumentInput {
+	return pricing.InstrumentInput{
+		Cusip:           r.cusip,
+		Type:            r.instrumentType,
+		Coupon:          r.coupon,
+		IssueDate:       r.issueDate,
+		MaturityDate:    r.maturityDate,
+		CouponFrequency: r.couponFrequency,
+		DayCount:        pricing.DefaultDayCount(r.instrumentType, r.coupon, r.couponFrequency),
 	}
-	return "30Y+"
 }
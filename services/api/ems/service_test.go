@@ -0,0 +1,22 @@
+package ems
+
+import "testing"
+
+func TestExecutionIDForIsTheSameEachTimeAnEventIsHandled(t *testing.T) {
+	order := &orderRecord{orderID: "order-1"}
+	first := executionIDFor("event-1", order)
+	if again := executionIDFor("event-1", order); again != first {
+		t.Fatalf("expected the same execution when the event is handled again, got %s and %s", first, again)
+	}
+
+	// A netting event sends several orders to execution
+	if other := executionIDFor("event-1", &orderRecord{orderID: "order-2"}); other == first {
+		t.Fatalf("expected each order its own execution")
+	}
+	if later := executionIDFor("event-2", order); later == first {
+		t.Fatalf("expected a later event to start a new execution")
+	}
+	if block := executionIDFor("event-1", &orderRecord{blockID: "order-1"}); block == first {
+		t.Fatalf("expected a block order keyed apart from an order")
+	}
+}
@@ -0,0 +1,280 @@
+package ems
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// liquidityProfile describes a maturity bucket's liquidity. fillProbability
+// and delayMs only drive the stochastic simulator: the chance that a clip
+// finds liquidity and the mean time between clips.
+type liquidityProfile struct {
+	maxClip         float64
+	spreadBps       float64
+	sizeImpactBps   float64
+	sideImpactBps   float64
+	fillProbability float64
+	delayMs         float64
+}
+
+var bucketProfiles = map[string]liquidityProfile{
+	"0-2Y":   {maxClip: 100000, spreadBps: 0.6, sizeImpactBps: 0.2, sideImpactBps: 0.1, fillProbability: 0.95, delayMs: 200},
+	"2-5Y":   {maxClip: 75000, spreadBps: 0.9, sizeImpactBps: 0.35, sideImpactBps: 0.12, fillProbability: 0.9, delayMs: 300},
+	"5-10Y":  {maxClip: 50000, spreadBps: 1.3, sizeImpactBps: 0.5, sideImpactBps: 0.15, fillProbability: 0.85, delayMs: 450},
+	"10-30Y": {maxClip: 35000, spreadBps: 1.8, sizeImpactBps: 0.7, sideImpactBps: 0.2, fillProbability: 0.8, delayMs: 650},
+	"30Y+":   {maxClip: 30000, spreadBps: 2.2, sizeImpactBps: 0.9, sideImpactBps: 0.25, fillProbability: 0.75, delayMs: 900},
+}
+
+const (
+	// stochasticClipLot is the par amount stochastic clips are rounded down to
+	stochasticClipLot = 1000
+	// stochasticSpreadNoise is the standard deviation of a stochastic clip's
+	// spread, as a fraction of the bucket spread
+	stochasticSpreadNoise = 0.5
+)
+
+// SimulatorVenue fills orders deterministically against the bucketed
+// liquidity profile: in clips of the bucket's maximum size, each priced at the
+// bucket spread plus size and side impact from the baseline price. Limit
+// orders fill at their limit when the model price breaches it; IOC orders
+// take a single clip, or nothing when that clip breaches the limit.
+//
+// In stochastic mode clip sizes, whether each clip finds liquidity, the spread
+// it pays and the simulated time between clips are drawn from a seeded random
+// source, so the same seed replays the same fills. An order that runs out of
+// attempts is left partially filled.
+type SimulatorVenue struct {
+	stochastic bool
+	seed       *int64
+}
+
+// NewSimulatorVenue creates the deterministic simulator venue
+func NewSimulatorVenue() *SimulatorVenue {
+	return &SimulatorVenue{}
+}
+
+// NewStochasticSimulatorVenue creates the stochastic simulator venue. Orders
+// without a seed of their own use seed, or a fresh one when it is nil; either
+// way the seed used is recorded in the report's inputs.
+func NewStochasticSimulatorVenue(seed *int64) *SimulatorVenue {
+	return &SimulatorVenue{stochastic: true, seed: seed}
+}
+
+// Name returns the simulator's venue name
+func (v *SimulatorVenue) Name() string {
+	if v.stochastic {
+		return StochasticSimulatorVenueName
+	}
+	return SimulatorVenueName
+}
+
+// Submit simulates the order's fills
+func (v *SimulatorVenue) Submit(order VenueOrder, onFill FillCallback) (*VenueReport, error) {
+	if v.stochastic {
+		return v.submitStochastic(order, onFill)
+	}
+
+	bucket := maturityBucket(order.YearsToMaturity)
+	profile := bucketProfiles[bucket]
+
+	maxClip := profile.maxClip
+	clipCount := int(math.Ceil(order.Quantity / maxClip))
+	if clipCount < 1 {
+		clipCount = 1
+	}
+
+	// IOC orders only take the liquidity available immediately: a single clip
+	// at the touch. The EMS cancels whatever that clip does not fill.
+	if order.immediateOrCancel() {
+		clipCount = 1
+	}
+
+	sideMultiplier := 1.0
+	if order.Side == "SELL" {
+		sideMultiplier = -1.0
+	}
+
+	filled := 0.0
+	for clipIndex := 0; clipIndex < clipCount; clipIndex++ {
+		clipQty := math.Min(maxClip, order.Quantity-filled)
+		sizeFactor := clipQty / maxClip
+
+		spreadBps := profile.spreadBps
+		sizeImpactBps := profile.sizeImpactBps * sizeFactor
+		sideImpactBps := profile.sideImpactBps
+
+		totalBps := (spreadBps + sizeImpactBps + sideImpactBps) * sideMultiplier
+		price, ok := applyLimit(order, order.BaselinePrice*(1+totalBps/10000))
+		if !ok {
+			break
+		}
+
+		if err := onFill(VenueFill{
+			Quantity:  clipQty,
+			Price:     price,
+			Timestamp: time.Now().UTC(),
+			SlippageBps: map[string]float64{
+				"bucketSpread": spreadBps * sideMultiplier,
+				"sizeImpact":   sizeImpactBps * sideMultiplier,
+				"sideImpact":   sideImpactBps * sideMultiplier,
+			},
+		}); err != nil {
+			return nil, err
+		}
+		filled += clipQty
+	}
+
+	return &VenueReport{
+		Inputs: map[string]interface{}{
+			"baselinePrice":  order.BaselinePrice,
+			"maturityBucket": bucket,
+			"maxClip":        maxClip,
+			"spreadBps":      profile.spreadBps,
+			"sizeImpactBps":  profile.sizeImpactBps,
+			"sideImpactBps":  profile.sideImpactBps,
+		},
+		Explanation: "Deterministic execution simulation using bucketed liquidity profile.",
+	}, nil
+}
+
+// submitStochastic simulates the order's fills from a seeded random source.
+// Each attempt waits an exponentially distributed time, then draws a clip of
+// half to all of the bucket's maximum size that finds liquidity with the
+// bucket's fill probability, at the bucket spread plus normally distributed
+// noise. IOC orders get a single attempt; any other order that runs out of
+// attempts has its remainder cancelled, as nothing goes on working it.
+func (v *SimulatorVenue) submitStochastic(order VenueOrder, onFill FillCallback) (*VenueReport, error) {
+	bucket := maturityBucket(order.YearsToMaturity)
+	profile := bucketProfiles[bucket]
+
+	seed := v.resolveSeed(order)
+	rng := rand.New(rand.NewSource(seed))
+
+	maxClip := profile.maxClip
+	// As many attempts as the deterministic simulator takes clips, and two
+	// more: smaller clips and misses can leave part of a large order unfilled
+	maxAttempts := int(math.Ceil(order.Quantity/maxClip)) + 2
+	if order.immediateOrCancel() {
+		maxAttempts = 1
+	}
+
+	sideMultiplier := 1.0
+	if order.Side == "SELL" {
+		sideMultiplier = -1.0
+	}
+
+	start := time.Now().UTC()
+	elapsedMs := 0.0
+	filled := 0.0
+	attempts := 0
+	for attempts < maxAttempts && filled < order.Quantity {
+		attempts++
+
+		// Every attempt draws the same random numbers, filled or not, so a
+		// seed replays the same sequence of attempts
+		elapsedMs += rng.ExpFloat64() * profile.delayMs
+		clipQty := math.Floor(maxClip*(0.5+0.5*rng.Float64())/stochasticClipLot) * stochasticClipLot
+		liquidityFound := rng.Float64() < profile.fillProbability
+		spreadBps := profile.spreadBps + rng.NormFloat64()*profile.spreadBps*stochasticSpreadNoise
+
+		if !liquidityFound {
+			continue
+		}
+		clipQty = math.Min(math.Max(clipQty, stochasticClipLot), order.Quantity-filled)
+		sizeImpactBps := profile.sizeImpactBps * clipQty / maxClip
+		sideImpactBps := profile.sideImpactBps
+
+		totalBps := (spreadBps + sizeImpactBps + sideImpactBps) * sideMultiplier
+		price, ok := applyLimit(order, order.BaselinePrice*(1+totalBps/10000))
+		if !ok {
+			break
+		}
+
+		if err := onFill(VenueFill{
+			Quantity:  clipQty,
+			Price:     price,
+			Timestamp: start.Add(time.Duration(elapsedMs * float64(time.Millisecond))),
+			SlippageBps: map[string]float64{
+				"bucketSpread": spreadBps * sideMultiplier,
+				"sizeImpact":   sizeImpactBps * sideMultiplier,
+				"sideImpact":   sideImpactBps * sideMultiplier,
+			},
+		}); err != nil {
+			return nil, err
+		}
+		filled += clipQty
+	}
+
+	return &VenueReport{
+		Inputs: map[string]interface{}{
+			"baselinePrice":       order.BaselinePrice,
+			"maturityBucket":      bucket,
+			"maxClip":             maxClip,
+			"spreadBps":           profile.spreadBps,
+			"sizeImpactBps":       profile.sizeImpactBps,
+			"sideImpactBps":       profile.sideImpactBps,
+			"mode":                "stochastic",
+			"seed":                seed,
+			"fillProbability":     profile.fillProbability,
+			"delayMs":             profile.delayMs,
+			"noiseBps":            profile.spreadBps * stochasticSpreadNoise,
+			"maxAttempts":         maxAttempts,
+			"attempts":            attempts,
+			"simulatedDurationMs": elapsedMs,
+		},
+		Explanation:        "Stochastic execution simulation using bucketed liquidity profile; replay with the recorded seed.",
+		RemainderCancelled: filled < order.Quantity && !order.immediateOrCancel(),
+	}, nil
+}
+
+// resolveSeed picks the seed for an order: its own, the venue's, or a fresh
+// one small enough to survive being recorded in an event payload
+func (v *SimulatorVenue) resolveSeed(order VenueOrder) int64 {
+	if order.Seed != nil {
+		return *order.Seed
+	}
+	if v.seed != nil {
+		return *v.seed
+	}
+	return time.Now().UnixNano() & MaxSeed
+}
+
+// applyLimit holds a limit order's model price to its limit. It reports false
+// when an IOC order's price breaches the limit: there is no liquidity inside
+// the limit right now, so nothing more fills.
+func applyLimit(order VenueOrder, price float64) (float64, bool) {
+	if (order.OrderType != "LIMIT" && order.OrderType != "YIELD_LIMIT") || order.LimitPrice == nil {
+		return price, true
+	}
+	breachesLimit := (order.Side == "BUY" && price > *order.LimitPrice) ||
+		(order.Side == "SELL" && price < *order.LimitPrice)
+	if breachesLimit && order.immediateOrCancel() {
+		return 0, false
+	}
+	if breachesLimit {
+		return *order.LimitPrice, true
+	}
+	return price, true
+}
+
+// Cancel has nothing to do: simulated orders never rest at the venue
+func (v *SimulatorVenue) Cancel(executionID string) error {
+	return nil
+}
+
+func maturityBucket(yearsToMaturity float64) string {
+	if yearsToMaturity <= 2 {
+		return "0-2Y"
+	}
+	if yearsToMaturity <= 5 {
+		return "2-5Y"
+	}
+	if yearsToMaturity <= 10 {
+		return "5-10Y"
+	}
+	if yearsToMaturity <= 30 {
+		return "10-30Y"
+	}
+	return "30Y+"
+}
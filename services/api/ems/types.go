@@ -14,9 +14,11 @@ const (
 	ExecutionStatusCancelled       ExecutionStatus = "CANCELLED"
 )
 
-// RequestExecutionRequest represents a manual execution request.
+// RequestExecutionRequest represents a manual execution request. Seed
+// replays a stochastic simulation; deterministic venues ignore it.
 type RequestExecutionRequest struct {
 	OrderID     string     `json:"orderId"`
 	RequestedBy string     `json:"requestedBy"`
 	AsOfDate    *time.Time `json:"asOfDate,omitempty"`
+	Seed        *int64     `json:"seed,omitempty"`
 }
@@ -0,0 +1,153 @@
+package ems
+
+import (
+	"errors"
+	"fmt"
+	"instant/services/api/services/routing"
+	"sort"
+	"time"
+)
+
+// SimulatorVenueName is the venue the deterministic simulator is registered as
+const SimulatorVenueName = "simulator"
+
+// StochasticSimulatorVenueName is the venue the seeded stochastic simulator is
+// registered as
+const StochasticSimulatorVenueName = "simulator-stochastic"
+
+var ErrUnknownVenue = errors.New("unknown execution venue")
+
+// Venue executes orders the EMS routes to it. Submit works an order and
+// reports each fill through onFill as it happens, returning once the venue has
+// finished with the order or, for a venue that works orders over time, once it
+// has taken the order; whatever did not fill may still be resting there.
+// Cancel pulls an execution's unfilled remainder from the venue.
+type Venue interface {
+	Name() string
+	Submit(order VenueOrder, onFill FillCallback) (*VenueReport, error)
+	Cancel(executionID string) error
+}
+
+// FillCallback receives a venue's fills in the order they happen. An error
+// stops the venue from reporting further fills.
+type FillCallback func(fill VenueFill) error
+
+// VenueOrder is an order, or a block order, as a venue is asked to work it.
+// Limit prices are clean prices; yield limits are converted before routing.
+// Seed, when set, seeds venues that simulate fills randomly.
+type VenueOrder struct {
+	ExecutionID     string
+	OrderID         string
+	BlockID         string
+	AccountID       string
+	InstrumentID    string
+	InstrumentType  string
+	Side            string
+	Quantity        float64
+	OrderType       string
+	LimitPrice      *float64
+	TimeInForce     string
+	BaselinePrice   float64
+	YearsToMaturity float64
+	AsOfDate        time.Time
+	Seed            *int64
+}
+
+// immediateOrCancel reports whether the order only takes liquidity available
+// right away
+func (o VenueOrder) immediateOrCancel() bool {
+	return o.TimeInForce == "IOC"
+}
+
+// VenueFill is one fill a venue reports. SlippageBps breaks the fill's price
+// away from the baseline down into the components the venue models, in basis
+// points; the EMS averages them over the execution by quantity.
+type VenueFill struct {
+	Quantity    float64
+	Price       float64
+	Timestamp   time.Time
+	SlippageBps map[string]float64
+}
+
+// VenueReport describes how a venue worked an order: the inputs that
+// determined its fills and an explanation for the execution record.
+// RemainderCancelled is set when the venue stopped working the order and
+// cancelled whatever did not fill. Working is set when the venue is still
+// working the order: it reports the later fills through the same onFill and
+// returns the final report once the venue has finished, and the EMS calls it
+// on a goroutine of its own.
+type VenueReport struct {
+	Inputs             map[string]interface{}
+	Explanation        string
+	RemainderCancelled bool
+	Working            func() (*VenueReport, error)
+}
+
+// VenueRouter picks the venue for each execution from the routing policies,
+// falling back to the default venue when no policy matches
+type VenueRouter struct {
+	venues       map[string]Venue
+	defaultVenue string
+	policies     *routing.Service
+}
+
+// NewVenueRouter creates a router over the given venues. policies may be nil
+// to send everything to the default venue.
+func NewVenueRouter(policies *routing.Service, defaultVenue string, venues ...Venue) (*VenueRouter, error) {
+	router := &VenueRouter{
+		venues:       make(map[string]Venue, len(venues)),
+		defaultVenue: defaultVenue,
+		policies:     policies,
+	}
+	for _, venue := range venues {
+		router.venues[venue.Name()] = venue
+	}
+	if _, ok := router.venues[defaultVenue]; !ok {
+		return nil, fmt.Errorf("%w: default venue %q", ErrUnknownVenue, defaultVenue)
+	}
+	return router, nil
+}
+
+// VenueNames lists the configured venues
+func (r *VenueRouter) VenueNames() []string {
+	names := make([]string, 0, len(r.venues))
+	for name := range r.venues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultVenue is the venue orders go to when no policy matches
+func (r *VenueRouter) DefaultVenue() string {
+	return r.defaultVenue
+}
+
+// Venue looks up a configured venue by name
+func (r *VenueRouter) Venue(name string) (Venue, error) {
+	venue, ok := r.venues[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownVenue, name)
+	}
+	return venue, nil
+}
+
+// Route returns the venue for an order and the policy that chose it, nil for
+// the default venue
+func (r *VenueRouter) Route(order routing.Order) (Venue, *routing.Policy, error) {
+	if r.policies == nil {
+		return r.venues[r.defaultVenue], nil, nil
+	}
+	policy, err := r.policies.Route(order)
+	if err != nil {
+		return nil, nil, err
+	}
+	if policy == nil {
+		return r.venues[r.defaultVenue], nil, nil
+	}
+	venue, ok := r.venues[policy.Venue]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: routing policy %s names %q", ErrUnknownVenue, policy.PolicyID, policy.Venue)
+	}
+	return venue, policy, nil
+}
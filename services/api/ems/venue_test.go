@@ -0,0 +1,255 @@
+package ems
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"instant/services/mockbroker"
+)
+
+func collectFills(t *testing.T, venue Venue, order VenueOrder) ([]VenueFill, *VenueReport) {
+	t.Helper()
+	var fills []VenueFill
+	report, err := venue.Submit(order, func(fill VenueFill) error {
+		fills = append(fills, fill)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Working != nil {
+		if report, err = report.Working(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return fills, report
+}
+
+func TestSimulatorFillsInBucketClips(t *testing.T) {
+	// 7 years to maturity: the 5-10Y bucket, in clips of 50,000
+	fills, report := collectFills(t, NewSimulatorVenue(), VenueOrder{
+		Side: "BUY", Quantity: 120000, OrderType: "MARKET", BaselinePrice: 100, YearsToMaturity: 7,
+	})
+
+	if len(fills) != 3 || fills[0].Quantity != 50000 || fills[2].Quantity != 20000 {
+		t.Fatalf("expected clips of 50000, 50000 and 20000, got %+v", fills)
+	}
+	// Spread 1.3bp, full size impact 0.5bp and side impact 0.15bp
+	if math.Abs(fills[0].Price-100.0195) > 1e-9 {
+		t.Fatalf("expected 100.0195, got %v", fills[0].Price)
+	}
+	if report.Inputs["maturityBucket"] != "5-10Y" {
+		t.Fatalf("expected the 5-10Y bucket, got %v", report.Inputs["maturityBucket"])
+	}
+}
+
+func TestSimulatorIOCTakesOneClipInsideTheLimit(t *testing.T) {
+	limit := 100.01
+	order := VenueOrder{
+		Side: "BUY", Quantity: 120000, OrderType: "LIMIT", LimitPrice: &limit, TimeInForce: "IOC",
+		BaselinePrice: 100, YearsToMaturity: 1,
+	}
+	fills, _ := collectFills(t, NewSimulatorVenue(), order)
+	if len(fills) != 1 || fills[0].Quantity != 100000 {
+		t.Fatalf("expected a single 100000 clip, got %+v", fills)
+	}
+
+	limit = 100
+	if fills, _ := collectFills(t, NewSimulatorVenue(), order); len(fills) != 0 {
+		t.Fatalf("expected nothing to fill through the limit, got %+v", fills)
+	}
+}
+
+func TestBrokerVenueReportsBrokerFills(t *testing.T) {
+	broker := mockbroker.New(2, 40000)
+	server := httptest.NewServer(broker.Handler())
+	defer server.Close()
+	venue := NewBrokerVenue(server.URL, 0, 0, 0)
+
+	fills, report := collectFills(t, venue, VenueOrder{
+		ExecutionID: "exec-1", InstrumentID: "912828XX1", Side: "SELL", Quantity: 100000,
+		OrderType: "MARKET", BaselinePrice: 100,
+	})
+	if len(fills) != 3 || fills[2].Quantity != 20000 {
+		t.Fatalf("expected clips of 40000, 40000 and 20000, got %+v", fills)
+	}
+	if math.Abs(fills[0].Price-99.98) > 1e-9 || math.Abs(fills[0].SlippageBps["brokerSpread"]-2) > 1e-6 {
+		t.Fatalf("expected 99.98 at 2bp of broker spread, got %+v", fills[0])
+	}
+	if report.Inputs["brokerStatus"] != mockbroker.StatusFilled {
+		t.Fatalf("expected a filled broker order, got %v", report.Inputs["brokerStatus"])
+	}
+
+	if err := venue.Cancel("exec-1"); err != nil {
+		t.Fatalf("unexpected error cancelling a filled order: %v", err)
+	}
+	if err := venue.Cancel("exec-unknown"); err == nil {
+		t.Fatalf("expected an error cancelling an order the broker does not know")
+	}
+}
+
+func TestBrokerVenueIOCRemainderIsCancelled(t *testing.T) {
+	broker := mockbroker.New(1, 40000)
+	server := httptest.NewServer(broker.Handler())
+	defer server.Close()
+
+	fills, _ := collectFills(t, NewBrokerVenue(server.URL, 0, 0, 0), VenueOrder{
+		ExecutionID: "exec-2", Side: "BUY", Quantity: 100000, OrderType: "MARKET", TimeInForce: "IOC", BaselinePrice: 100,
+	})
+	if len(fills) != 1 || fills[0].Quantity != 40000 {
+		t.Fatalf("expected a single 40000 clip, got %+v", fills)
+	}
+	order, ok := broker.Order("exec-2")
+	if !ok || order.Status != mockbroker.StatusCancelled || order.CancelledQuantity != 60000 {
+		t.Fatalf("expected the 60000 remainder cancelled, got %+v", order)
+	}
+}
+
+func TestBrokerVenuePollsForLaterFills(t *testing.T) {
+	broker := mockbroker.New(1, 40000)
+	broker.ClipInterval = 5 * time.Millisecond
+	server := httptest.NewServer(broker.Handler())
+	defer server.Close()
+
+	var fills []VenueFill
+	report, err := NewBrokerVenue(server.URL, 0, time.Millisecond, time.Second).Submit(VenueOrder{
+		ExecutionID: "exec-3", Side: "BUY", Quantity: 100000, OrderType: "MARKET", BaselinePrice: 100,
+	}, func(fill VenueFill) error {
+		fills = append(fills, fill)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Submit returns once the broker has taken the order
+	if len(fills) != 1 || report.Working == nil {
+		t.Fatalf("expected the first clip and the order still working, got %+v and %+v", fills, report)
+	}
+
+	if report, err = report.Working(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fills) != 3 || fills[2].Quantity != 20000 {
+		t.Fatalf("expected each clip reported once, got %+v", fills)
+	}
+	if report.RemainderCancelled || report.Inputs["brokerStatus"] != mockbroker.StatusFilled {
+		t.Fatalf("expected the order worked to a fill, got %+v", report)
+	}
+}
+
+func TestBrokerVenueCancelsWhatIsLeftAfterTheWorkingTime(t *testing.T) {
+	broker := mockbroker.New(1, 40000)
+	broker.ClipInterval = time.Hour
+	server := httptest.NewServer(broker.Handler())
+	defer server.Close()
+
+	fills, report := collectFills(t, NewBrokerVenue(server.URL, 0, time.Millisecond, 10*time.Millisecond), VenueOrder{
+		ExecutionID: "exec-4", Side: "BUY", Quantity: 100000, OrderType: "MARKET", BaselinePrice: 100,
+	})
+	if len(fills) != 1 || !report.RemainderCancelled {
+		t.Fatalf("expected one clip and the remainder cancelled, got %+v and %+v", fills, report)
+	}
+	order, ok := broker.Order("exec-4")
+	if !ok || order.Status != mockbroker.StatusCancelled || order.CancelledQuantity != 60000 {
+		t.Fatalf("expected the 60000 remainder cancelled at the broker, got %+v", order)
+	}
+	if remainderCancelReason(false, report) == "" {
+		t.Fatalf("expected the EMS to cancel the order's remainder")
+	}
+}
+
+func TestBrokerVenueReconcilesASubmissionThatTimesOut(t *testing.T) {
+	broker := mockbroker.New(1, 40000)
+	handler := broker.Handler()
+	// The broker takes the order but its answer arrives after the venue gives up
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/orders" {
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+			time.Sleep(100 * time.Millisecond)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	venue := NewBrokerVenue(server.URL, 50*time.Millisecond, 0, 0)
+
+	fills, report := collectFills(t, venue, VenueOrder{
+		ExecutionID: "exec-5", Side: "BUY", Quantity: 100000, OrderType: "MARKET", BaselinePrice: 100,
+	})
+	if len(fills) != 3 || report.Inputs["brokerStatus"] != mockbroker.StatusFilled {
+		t.Fatalf("expected the fills found at the broker, got %+v and %+v", fills, report)
+	}
+}
+
+func TestStochasticSimulatorReplaysASeed(t *testing.T) {
+	seed := int64(42)
+	order := VenueOrder{
+		Side: "SELL", Quantity: 500000, OrderType: "MARKET", BaselinePrice: 100, YearsToMaturity: 7, Seed: &seed,
+	}
+	venue := NewStochasticSimulatorVenue(nil)
+	fills, report := collectFills(t, venue, order)
+	replayed, _ := collectFills(t, venue, order)
+
+	if len(fills) == 0 || len(fills) != len(replayed) {
+		t.Fatalf("expected the same number of fills, got %d and %d", len(fills), len(replayed))
+	}
+	for i := range fills {
+		if fills[i].Quantity != replayed[i].Quantity || fills[i].Price != replayed[i].Price ||
+			fills[i].Timestamp.Sub(fills[0].Timestamp) != replayed[i].Timestamp.Sub(replayed[0].Timestamp) {
+			t.Fatalf("fill %d differs on replay: %+v and %+v", i, fills[i], replayed[i])
+		}
+	}
+	if report.Inputs["seed"] != seed || report.Inputs["mode"] != "stochastic" {
+		t.Fatalf("expected seed 42 recorded in stochastic mode, got %v", report.Inputs)
+	}
+
+	other := int64(7)
+	order.Seed = &other
+	different, _ := collectFills(t, venue, order)
+	same := len(different) == len(fills)
+	for i := 0; same && i < len(fills); i++ {
+		same = fills[i].Quantity == different[i].Quantity && fills[i].Price == different[i].Price
+	}
+	if same {
+		t.Fatalf("expected another seed to give different fills")
+	}
+}
+
+func TestStochasticSimulatorSpreadsClipsOverTime(t *testing.T) {
+	order := VenueOrder{Side: "BUY", Quantity: 300000, OrderType: "MARKET", BaselinePrice: 100, YearsToMaturity: 20}
+	partial := false
+	for seed := int64(0); seed < 50; seed++ {
+		order.Seed = &seed
+		fills, report := collectFills(t, NewStochasticSimulatorVenue(nil), order)
+
+		filled := 0.0
+		for i, fill := range fills {
+			// 10-30Y clips are half to all of 35,000, rounded to 1,000
+			if fill.Quantity < 1000 || fill.Quantity > 35000 || math.Mod(fill.Quantity, 1000) != 0 {
+				t.Fatalf("seed %d: unexpected clip size %v", seed, fill.Quantity)
+			}
+			if i > 0 && fill.Timestamp.Before(fills[i-1].Timestamp) {
+				t.Fatalf("seed %d: fills out of time order", seed)
+			}
+			filled += fill.Quantity
+		}
+		if filled > order.Quantity {
+			t.Fatalf("seed %d: overfilled %v", seed, filled)
+		}
+		if filled < order.Quantity {
+			partial = true
+		}
+		if report.RemainderCancelled != (filled < order.Quantity) {
+			t.Fatalf("seed %d: filled %v of %v but remainder cancelled is %v", seed, filled, order.Quantity, report.RemainderCancelled)
+		}
+		if report.Inputs["attempts"].(int) > report.Inputs["maxAttempts"].(int) {
+			t.Fatalf("seed %d: too many attempts %v", seed, report.Inputs["attempts"])
+		}
+	}
+	if !partial {
+		t.Fatalf("expected some seeds to leave the order partially filled")
+	}
+}
@@ -8,21 +8,65 @@ import (
 // Subscriber is a channel that receives events
 type Subscriber chan *events.Event
 
-// EventBus handles in-process event publishing and subscription
+// EventBus handles event publishing and subscription. By default events are
+// delivered in-process; NewPostgres returns a bus that fans events out across
+// instances via Postgres LISTEN/NOTIFY.
 type EventBus struct {
 	subscribers map[string][]Subscriber
 	mu          sync.RWMutex
+	postgres    *postgresBackend
+
+	// unsubscribing is closed for a subscriber as it unsubscribes, releasing
+	// a delivery waiting on it
+	unsubscribing map[Subscriber]chan struct{}
+	unsubscribeMu sync.Mutex
 }
 
 // New creates a new EventBus instance
 func New() *EventBus {
 	return &EventBus{
-		subscribers: make(map[string][]Subscriber),
+		subscribers:   make(map[string][]Subscriber),
+		unsubscribing: make(map[Subscriber]chan struct{}),
 	}
 }
 
 // Publish sends an event to all subscribers of that event type
 func (eb *EventBus) Publish(event *events.Event) {
+	if eb.postgres != nil && eb.postgres.publish(event) {
+		// Delivery happens when the notification comes back on the listener
+		return
+	}
+	eb.dispatch(event)
+}
+
+// deliver hands an event to local subscribers, waiting for each one to take
+// it. It gives up on a subscriber that unsubscribes, and on all of them once
+// stop is closed.
+func (eb *EventBus) deliver(event *events.Event, stop <-chan struct{}) {
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+
+	recipients := append(append([]Subscriber{}, eb.subscribers[event.EventType]...), eb.subscribers["*"]...)
+	for _, sub := range recipients {
+		select {
+		case sub <- event:
+		case <-eb.unsubscribed(sub):
+		case <-stop:
+			return
+		}
+	}
+}
+
+// unsubscribed returns a channel closed once the subscriber starts to unsubscribe
+func (eb *EventBus) unsubscribed(sub Subscriber) <-chan struct{} {
+	eb.unsubscribeMu.Lock()
+	defer eb.unsubscribeMu.Unlock()
+	return eb.unsubscribing[sub]
+}
+
+// dispatch delivers an event to local subscribers without waiting for them:
+// a subscriber whose buffer is full misses the event
+func (eb *EventBus) dispatch(event *events.Event) {
 	eb.mu.RLock()
 	defer eb.mu.RUnlock()
 
@@ -65,6 +109,9 @@ func (eb *EventBus) Subscribe(eventType string, bufferSize int) (Subscriber, fun
 
 	// Add to subscribers list
 	eb.subscribers[eventType] = append(eb.subscribers[eventType], sub)
+	eb.unsubscribeMu.Lock()
+	eb.unsubscribing[sub] = make(chan struct{})
+	eb.unsubscribeMu.Unlock()
 
 	// Return cleanup function
 	cleanup := func() {
@@ -76,6 +123,18 @@ func (eb *EventBus) Subscribe(eventType string, bufferSize int) (Subscriber, fun
 
 // Unsubscribe removes a subscriber from an event type
 func (eb *EventBus) Unsubscribe(eventType string, sub Subscriber) {
+	// A delivery waiting on this subscriber holds the read lock, so it is
+	// released before the write lock is taken
+	eb.unsubscribeMu.Lock()
+	if unsubscribing, ok := eb.unsubscribing[sub]; ok {
+		select {
+		case <-unsubscribing:
+		default:
+			close(unsubscribing)
+		}
+	}
+	eb.unsubscribeMu.Unlock()
+
 	eb.mu.Lock()
 	defer eb.mu.Unlock()
 
@@ -85,6 +144,9 @@ func (eb *EventBus) Unsubscribe(eventType string, sub Subscriber) {
 			// Remove subscriber from slice
 			eb.subscribers[eventType] = append(subs[:i], subs[i+1:]...)
 			close(sub)
+			eb.unsubscribeMu.Lock()
+			delete(eb.unsubscribing, sub)
+			eb.unsubscribeMu.Unlock()
 			break
 		}
 	}
@@ -99,6 +161,21 @@ func (eb *EventBus) SubscriberCount(eventType string) int {
 
 // Close closes all subscriber channels
 func (eb *EventBus) Close() {
+	if eb.postgres != nil {
+		eb.postgres.close()
+	}
+
+	// Release any delivery still waiting on a subscriber before locking
+	eb.unsubscribeMu.Lock()
+	for _, unsubscribing := range eb.unsubscribing {
+		select {
+		case <-unsubscribing:
+		default:
+			close(unsubscribing)
+		}
+	}
+	eb.unsubscribeMu.Unlock()
+
 	eb.mu.Lock()
 	defer eb.mu.Unlock()
 
@@ -108,4 +185,7 @@ func (eb *EventBus) Close() {
 		}
 		delete(eb.subscribers, eventType)
 	}
+	eb.unsubscribeMu.Lock()
+	eb.unsubscribing = make(map[Subscriber]chan struct{})
+	eb.unsubscribeMu.Unlock()
 }
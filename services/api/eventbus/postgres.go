@@ -0,0 +1,263 @@
+package eventbus
+
+import (
+	"database/sql"
+	"fmt"
+	"instant/services/api/events"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// NotifyChannel is the Postgres channel used to announce appended events
+const NotifyChannel = "instant_events"
+
+const (
+	listenerMinReconnect = 1 * time.Second
+	listenerMaxReconnect = 30 * time.Second
+	listenerPingInterval = 60 * time.Second
+	catchUpBatchSize     = 500
+	// catchUpRetryInterval is how soon an unfinished catch-up is retried
+	catchUpRetryInterval = 100 * time.Millisecond
+	// positionGapTimeout is how long catch-up waits for a missing position to
+	// commit before moving past it
+	positionGapTimeout = time.Second
+	// skippedRecheckInterval is how often positions catch-up moved past are
+	// looked for again, and skippedRecheckWindow how long for, in case a slow
+	// transaction commits them after all
+	skippedRecheckInterval = time.Second
+	skippedRecheckWindow   = 5 * time.Minute
+	// maxSkippedPositions caps how many skipped positions are tracked, so a
+	// large jump in the sequence is not rechecked position by position
+	maxSkippedPositions = 1000
+)
+
+// EventLoader reads persisted events by their store position
+type EventLoader interface {
+	GetByPosition(position int64) (*events.Event, error)
+	GetAfterPosition(position int64, limit int) ([]*events.Event, error)
+}
+
+// postgresBackend publishes event positions with pg_notify. A notification
+// only wakes the instances up: each one delivers events to its local
+// subscribers by reading the event store in position order from the last
+// position it delivered, so an event is delivered once and none is skipped
+// because a load failed. Delivery waits for each subscriber to take the
+// event. A position still missing after positionGapTimeout is moved past but
+// looked for again for skippedRecheckWindow, and delivered out of order if
+// its transaction commits late; one that commits later still is only seen by
+// consumers that catch up from a checkpoint.
+type postgresBackend struct {
+	bus      *EventBus
+	db       *sql.DB
+	listener *pq.Listener
+	loader   EventLoader
+	wake     chan struct{}
+	stopChan chan struct{}
+	done     chan struct{}
+	now      func() time.Time
+
+	// lastPosition, gapSince and skipped are only touched by the run goroutine
+	lastPosition int64
+	gapSince     time.Time
+	// skipped holds the positions catch-up moved past and when
+	skipped map[int64]time.Time
+}
+
+// NewPostgres creates an EventBus backed by Postgres LISTEN/NOTIFY.
+// Notifications carry only the event position; the event itself is loaded
+// from the event store, so every instance sees the same payload.
+func NewPostgres(databaseURL string, loader EventLoader) (*EventBus, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	var lastPosition int64
+	if err := db.QueryRow(`SELECT COALESCE(MAX(position), 0) FROM events`).Scan(&lastPosition); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to read latest event position: %w", err)
+	}
+
+	eb := New()
+	backend := &postgresBackend{
+		bus:          eb,
+		db:           db,
+		loader:       loader,
+		wake:         make(chan struct{}, 1),
+		stopChan:     make(chan struct{}),
+		done:         make(chan struct{}),
+		now:          time.Now,
+		lastPosition: lastPosition,
+	}
+
+	backend.listener = pq.NewListener(databaseURL, listenerMinReconnect, listenerMaxReconnect, backend.listenerEvent)
+	if err := backend.listener.Listen(NotifyChannel); err != nil {
+		backend.listener.Close()
+		db.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", NotifyChannel, err)
+	}
+
+	eb.postgres = backend
+	go backend.run()
+
+	return eb, nil
+}
+
+// publish announces a persisted event to all instances. It returns false when
+// the event cannot be routed through Postgres and must be delivered locally.
+func (b *postgresBackend) publish(event *events.Event) bool {
+	if event.Position == 0 {
+		// Not appended to the store, so other instances could not load it
+		return false
+	}
+
+	if _, err := b.db.Exec(`SELECT pg_notify($1, $2)`, NotifyChannel, strconv.FormatInt(event.Position, 10)); err != nil {
+		// The event is in the store, so local catch-up still delivers it here
+		log.Printf("EventBus: failed to notify position %d, catching up locally: %v", event.Position, err)
+		b.wakeUp()
+	}
+
+	return true
+}
+
+// wakeUp asks the run goroutine to catch up without waiting for a notification
+func (b *postgresBackend) wakeUp() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run catches up on every notification until the bus is closed
+func (b *postgresBackend) run() {
+	defer close(b.done)
+
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-b.stopChan:
+			return
+		case <-b.listener.Notify:
+			// A notification's position is not needed: everything after the
+			// last delivered position is read from the store. A nil
+			// notification means the connection was re-established and
+			// notifications sent while it was down are lost.
+		case <-b.wake:
+		case <-retry:
+		case <-time.After(listenerPingInterval):
+			go b.listener.Ping()
+			continue
+		}
+
+		retry = nil
+		if !b.catchUp() {
+			retry = time.After(catchUpRetryInterval)
+		} else if len(b.skipped) > 0 {
+			retry = time.After(skippedRecheckInterval)
+		}
+	}
+}
+
+// catchUp delivers every event appended after the last one delivered, in
+// position order, then any skipped position that has since committed. It
+// returns false when it stopped early and must be retried: the store could
+// not be read, or a position is missing that a transaction still in flight
+// may yet commit.
+func (b *postgresBackend) catchUp() bool {
+	b.recheckSkipped()
+	for {
+		batch, err := b.loader.GetAfterPosition(b.lastPosition, catchUpBatchSize)
+		if err != nil {
+			log.Printf("EventBus: failed to catch up after position %d: %v", b.lastPosition, err)
+			return false
+		}
+
+		for _, event := range batch {
+			if event.Position <= b.lastPosition {
+				continue
+			}
+			if event.Position > b.lastPosition+1 {
+				if b.gapSince.IsZero() {
+					b.gapSince = b.now()
+				}
+				if b.now().Sub(b.gapSince) < positionGapTimeout {
+					return false
+				}
+				log.Printf("EventBus: positions %d-%d not committed, moving past them", b.lastPosition+1, event.Position-1)
+				b.skip(b.lastPosition+1, event.Position-1)
+			}
+			b.gapSince = time.Time{}
+			b.lastPosition = event.Position
+			b.bus.deliver(event, b.stopChan)
+		}
+
+		if len(batch) < catchUpBatchSize {
+			return true
+		}
+	}
+}
+
+// skip records positions catch-up moved past, to be looked for again
+func (b *postgresBackend) skip(from, to int64) {
+	if b.skipped == nil {
+		b.skipped = map[int64]time.Time{}
+	}
+	now := b.now()
+	for position := from; position <= to; position++ {
+		if len(b.skipped) >= maxSkippedPositions {
+			log.Printf("EventBus: not rechecking positions %d-%d, %d skipped positions already tracked", position, to, len(b.skipped))
+			return
+		}
+		b.skipped[position] = now
+	}
+}
+
+// recheckSkipped delivers skipped positions that have committed since, and
+// stops looking for those skipped longer than skippedRecheckWindow ago
+func (b *postgresBackend) recheckSkipped() {
+	positions := make([]int64, 0, len(b.skipped))
+	for position := range b.skipped {
+		positions = append(positions, position)
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+
+	for _, position := range positions {
+		event, err := b.loader.GetByPosition(position)
+		if err == nil {
+			log.Printf("EventBus: position %d committed late, delivering it out of order", position)
+			delete(b.skipped, position)
+			b.bus.deliver(event, b.stopChan)
+			continue
+		}
+		if b.now().Sub(b.skipped[position]) >= skippedRecheckWindow {
+			log.Printf("EventBus: position %d never committed", position)
+			delete(b.skipped, position)
+		}
+	}
+}
+
+func (b *postgresBackend) listenerEvent(ev pq.ListenerEventType, err error) {
+	switch ev {
+	case pq.ListenerEventDisconnected:
+		log.Printf("EventBus: Postgres listener disconnected: %v", err)
+	case pq.ListenerEventReconnected:
+		log.Println("EventBus: Postgres listener reconnected")
+	case pq.ListenerEventConnectionAttemptFailed:
+		log.Printf("EventBus: Postgres listener reconnect failed: %v", err)
+	}
+}
+
+func (b *postgresBackend) close() {
+	close(b.stopChan)
+	<-b.done
+	b.listener.Close()
+	b.db.Close()
+}
@@ -0,0 +1,236 @@
+package eventbus
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"instant/services/api/events"
+)
+
+// fakeLoader serves events from memory in position order
+type fakeLoader struct {
+	events []*events.Event
+	err    error
+}
+
+func (l *fakeLoader) GetByPosition(position int64) (*events.Event, error) {
+	for _, event := range l.events {
+		if event.Position == position {
+			return event, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (l *fakeLoader) GetAfterPosition(position int64, limit int) ([]*events.Event, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	batch := []*events.Event{}
+	for _, event := range l.events {
+		if event.Position > position && len(batch) < limit {
+			batch = append(batch, event)
+		}
+	}
+	return batch, nil
+}
+
+func (l *fakeLoader) append(positions ...int64) {
+	for _, position := range positions {
+		l.events = append(l.events, &events.Event{EventType: "Test", Position: position})
+	}
+}
+
+func newTestBackend(loader EventLoader) (*postgresBackend, Subscriber) {
+	bus := New()
+	sub, _ := bus.Subscribe("*", 2000)
+	return &postgresBackend{bus: bus, loader: loader, now: time.Now}, sub
+}
+
+func drain(sub Subscriber) []int64 {
+	positions := []int64{}
+	for {
+		select {
+		case event := <-sub:
+			positions = append(positions, event.Position)
+		default:
+			return positions
+		}
+	}
+}
+
+func TestCatchUpDeliversEachPositionOnceInOrder(t *testing.T) {
+	loader := &fakeLoader{}
+	loader.append(1, 2, 3)
+	backend, sub := newTestBackend(loader)
+
+	if !backend.catchUp() {
+		t.Fatalf("expected catch-up to finish")
+	}
+	// A notification for an event already delivered is only a wake-up
+	loader.append(4)
+	backend.catchUp()
+	backend.catchUp()
+
+	got := drain(sub)
+	if len(got) != 4 || got[0] != 1 || got[3] != 4 {
+		t.Fatalf("expected positions 1-4 once each, got %v", got)
+	}
+}
+
+func TestCatchUpRetriesAfterALoadFailure(t *testing.T) {
+	loader := &fakeLoader{err: errors.New("connection reset")}
+	loader.append(1, 2)
+	backend, sub := newTestBackend(loader)
+
+	if backend.catchUp() {
+		t.Fatalf("expected catch-up to report the failure")
+	}
+	if got := drain(sub); len(got) != 0 {
+		t.Fatalf("expected nothing delivered, got %v", got)
+	}
+
+	loader.err = nil
+	backend.catchUp()
+	if got := drain(sub); len(got) != 2 {
+		t.Fatalf("expected the events to be delivered once the store recovers, got %v", got)
+	}
+}
+
+func TestCatchUpWaitsForAGapToCommit(t *testing.T) {
+	loader := &fakeLoader{}
+	loader.append(1, 2, 4)
+	backend, sub := newTestBackend(loader)
+	now := time.Now()
+	backend.now = func() time.Time { return now }
+
+	if backend.catchUp() {
+		t.Fatalf("expected catch-up to stop at the gap")
+	}
+	if got := drain(sub); len(got) != 2 {
+		t.Fatalf("expected positions 1 and 2, got %v", got)
+	}
+
+	// Position 3 commits late and is delivered before 4
+	loader.events = append(loader.events[:2], &events.Event{EventType: "Test", Position: 3}, loader.events[2])
+	backend.catchUp()
+	if got := drain(sub); len(got) != 2 || got[0] != 3 || got[1] != 4 {
+		t.Fatalf("expected positions 3 and 4, got %v", got)
+	}
+
+	// Position 5 never commits
+	loader.append(6)
+	backend.catchUp()
+	if got := drain(sub); len(got) != 0 {
+		t.Fatalf("expected position 6 to wait, got %v", got)
+	}
+	now = now.Add(positionGapTimeout)
+	if !backend.catchUp() {
+		t.Fatalf("expected catch-up to move past the gap")
+	}
+	if got := drain(sub); len(got) != 1 || got[0] != 6 {
+		t.Fatalf("expected position 6, got %v", got)
+	}
+}
+
+func TestCatchUpReadsInBatches(t *testing.T) {
+	loader := &fakeLoader{}
+	for position := int64(1); position <= catchUpBatchSize+10; position++ {
+		loader.append(position)
+	}
+	backend, sub := newTestBackend(loader)
+
+	backend.catchUp()
+	if got := drain(sub); len(got) != catchUpBatchSize+10 {
+		t.Fatalf("expected every event across batches, got %d", len(got))
+	}
+}
+
+func TestCatchUpDeliversASkippedPositionThatCommitsLate(t *testing.T) {
+	loader := &fakeLoader{}
+	loader.append(1, 3)
+	backend, sub := newTestBackend(loader)
+	now := time.Now()
+	backend.now = func() time.Time { return now }
+
+	backend.catchUp()
+	now = now.Add(positionGapTimeout)
+	backend.catchUp()
+	if got := drain(sub); len(got) != 2 || got[1] != 3 {
+		t.Fatalf("expected positions 1 and 3, got %v", got)
+	}
+
+	// Position 2 commits after catch-up moved past it
+	loader.append(2)
+	backend.catchUp()
+	if got := drain(sub); len(got) != 1 || got[0] != 2 {
+		t.Fatalf("expected position 2 delivered late, got %v", got)
+	}
+	if len(backend.skipped) != 0 {
+		t.Fatalf("expected position 2 to no longer be rechecked, got %v", backend.skipped)
+	}
+}
+
+func TestCatchUpStopsRecheckingAfterTheWindow(t *testing.T) {
+	loader := &fakeLoader{}
+	loader.append(1, 3)
+	backend, sub := newTestBackend(loader)
+	now := time.Now()
+	backend.now = func() time.Time { return now }
+
+	backend.catchUp()
+	now = now.Add(positionGapTimeout)
+	backend.catchUp()
+	drain(sub)
+
+	now = now.Add(skippedRecheckWindow)
+	backend.catchUp()
+	if len(backend.skipped) != 0 {
+		t.Fatalf("expected position 2 to be given up on, got %v", backend.skipped)
+	}
+	loader.append(2)
+	backend.catchUp()
+	if got := drain(sub); len(got) != 0 {
+		t.Fatalf("expected nothing delivered, got %v", got)
+	}
+}
+
+func TestDeliverWaitsForASubscriberWithAFullBuffer(t *testing.T) {
+	bus := New()
+	sub, unsubscribe := bus.Subscribe("Test", 1)
+	bus.deliver(&events.Event{EventType: "Test", Position: 1}, nil)
+
+	delivered := make(chan struct{})
+	go func() {
+		bus.deliver(&events.Event{EventType: "Test", Position: 2}, nil)
+		close(delivered)
+	}()
+	select {
+	case <-delivered:
+		t.Fatalf("expected delivery to wait for the subscriber")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if event := <-sub; event.Position != 1 {
+		t.Fatalf("expected position 1 first, got %d", event.Position)
+	}
+	<-delivered
+	if event := <-sub; event.Position != 2 {
+		t.Fatalf("expected position 2, got %d", event.Position)
+	}
+
+	// A subscriber that goes away releases a waiting delivery
+	bus.deliver(&events.Event{EventType: "Test", Position: 3}, nil)
+	released := make(chan struct{})
+	go func() {
+		bus.deliver(&events.Event{EventType: "Test", Position: 4}, nil)
+		close(released)
+	}()
+	unsubscribe()
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Fatalf("expected unsubscribing to release the delivery")
+	}
+}
@@ -20,6 +20,13 @@ const (
 	AggregateRoutingPolicy    = "RoutingPolicy"
 	AggregateUploadBatch      = "UploadBatch"
 	AggregateAIDraft          = "AIDraft"
+	AggregateApprovalPolicy   = "ApprovalPolicy"
+	AggregateBlockOrder       = "BlockOrder"
+	AggregateNetting          = "Netting"
+	AggregateTradingControl   = "TradingControl"
+	AggregateRiskLimit        = "RiskLimit"
+	AggregateFeeSchedule      = "FeeSchedule"
+	AggregateSettlement       = "Settlement"
 )
 
 // EventType constants - Market Data
@@ -41,15 +48,72 @@ const (
 const (
 	EventUploadBatchReceived    = "UploadBatchReceived"
 	EventUploadBatchValidated   = "UploadBatchValidated"
+	EventUploadBatchConfirming  = "UploadBatchConfirming"
+	EventUploadBatchConfirmed   = "UploadBatchConfirmed"
 	EventOrderCreated           = "OrderCreated"
 	EventOrderAmended           = "OrderAmended"
+	EventOrderReplaced          = "OrderReplaced"
 	EventOrderCancelled         = "OrderCancelled"
+	EventOrderExpired           = "OrderExpired"
 	EventOrderApprovalRequested = "OrderApprovalRequested"
+	EventOrderApprovalRecorded  = "OrderApprovalRecorded"
 	EventOrderApproved          = "OrderApproved"
 	EventOrderRejected          = "OrderRejected"
 	EventOrderSentToEMS         = "OrderSentToEMS"
 )
 
+// EventType constants - Block Orders
+const (
+	EventBlockOrderCreated   = "BlockOrderCreated"
+	EventBlockOrderSentToEMS = "BlockOrderSentToEMS"
+	EventBlockOrderAllocated = "BlockOrderAllocated"
+	EventAllocationBooked    = "AllocationBooked"
+)
+
+// EventType constants - Netting
+const (
+	EventCrossTradeExecuted = "CrossTradeExecuted"
+	EventOrdersNetted       = "OrdersNetted"
+)
+
+// EventType constants - Trading Controls
+const (
+	EventTradingHaltActivated        = "TradingHaltActivated"
+	EventTradingHaltReleased         = "TradingHaltReleased"
+	EventOrderRateLimitSet           = "OrderRateLimitSet"
+	EventOrderSubmissionRefused      = "OrderSubmissionRefused"
+	EventExecutionHaltedByKillSwitch = "ExecutionHaltedByKillSwitch"
+)
+
+// EventType constants - Risk Limits
+const (
+	EventRiskLimitCreated  = "RiskLimitCreated"
+	EventRiskLimitUpdated  = "RiskLimitUpdated"
+	EventRiskLimitDeleted  = "RiskLimitDeleted"
+	EventRiskLimitBreached = "RiskLimitBreached"
+)
+
+// EventType constants - Fee Schedules
+const (
+	EventFeeScheduleCreated = "FeeScheduleCreated"
+	EventFeeScheduleUpdated = "FeeScheduleUpdated"
+	EventFeeScheduleDeleted = "FeeScheduleDeleted"
+)
+
+// EventType constants - Routing Policies
+const (
+	EventRoutingPolicyCreated = "RoutingPolicyCreated"
+	EventRoutingPolicyUpdated = "RoutingPolicyUpdated"
+	EventRoutingPolicyDeleted = "RoutingPolicyDeleted"
+)
+
+// EventType constants - Approval Policies
+const (
+	EventApprovalPolicyCreated = "ApprovalPolicyCreated"
+	EventApprovalPolicyUpdated = "ApprovalPolicyUpdated"
+	EventApprovalPolicyDeleted = "ApprovalPolicyDeleted"
+)
+
 // EventType constants - Compliance
 const (
 	EventRuleSetPublished           = "RuleSetPublished"
@@ -75,6 +139,13 @@ const (
 	EventSettlementBooked    = "SettlementBooked"
 )
 
+// EventType constants - Settlement lifecycle
+const (
+	EventSettlementSettled          = "SettlementSettled"
+	EventSettlementPartiallySettled = "SettlementPartiallySettled"
+	EventSettlementFailed           = "SettlementFailed"
+)
+
 // EventType constants - PMS
 const (
 	EventAccountCreated       = "AccountCreated"
@@ -85,6 +156,8 @@ const (
 	EventProposalGenerated    = "ProposalGenerated"
 	EventProposalApproved     = "ProposalApproved"
 	EventProposalSentToOMS    = "ProposalSentToOMS"
+	EventAccountCashAdjusted  = "AccountCashAdjusted"
+	EventAccountShortSalesUpdated = "AccountShortSalesUpdated"
 )
 
 // EventType constants - Copilot
@@ -118,6 +191,9 @@ type Event struct {
 	Payload       map[string]interface{} `json:"payload"`
 	Explanation   *string                `json:"explanation,omitempty"`
 	SchemaVersion int                    `json:"schemaVersion"`
+	Position      int64                  `json:"position,omitempty"`
+	// AggregateVersion numbers the event within its aggregate, from 1
+	AggregateVersion int `json:"aggregateVersion,omitempty"`
 }
 
 // NewEvent creates a new event with required fields
@@ -3,14 +3,23 @@ package eventstore
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"instant/services/api/events"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+// ErrConcurrencyConflict is returned when an aggregate has been written since
+// the version a command loaded it at
+var ErrConcurrencyConflict = errors.New("aggregate was changed by another command")
+
+// uniqueViolation is the Postgres error code for a unique constraint violation
+const uniqueViolation = "23505"
+
 // EventStore handles event persistence and retrieval
 type EventStore struct {
 	db *sql.DB
@@ -35,8 +44,91 @@ func (es *EventStore) Close() error {
 	return es.db.Close()
 }
 
-// Append atomically writes an event to the event store
+// Append atomically writes an event to the event store, whatever version its
+// aggregate is at
 func (es *EventStore) Append(event *events.Event) error {
+	return es.AppendAll([]*events.Event{event}, nil)
+}
+
+// AppendExpected writes an event only if its aggregate is still at
+// expectedVersion, the number of events it had when the command loaded it.
+// Otherwise it returns ErrConcurrencyConflict and writes nothing.
+func (es *EventStore) AppendExpected(event *events.Event, expectedVersion int) error {
+	return es.AppendAll([]*events.Event{event}, map[events.Aggregate]int{event.Aggregate: expectedVersion})
+}
+
+// AppendAll writes a batch of events in one transaction: either every event is
+// written or none is. Aggregates listed in expected must still be at the given
+// version. Each aggregate is locked for the transaction so its events are
+// numbered without gaps; aggregates are locked in a fixed order so batches
+// that share aggregates cannot deadlock.
+func (es *EventStore) AppendAll(batch []*events.Event, expected map[events.Aggregate]int) error {
+	tx, err := es.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin append: %w", err)
+	}
+	defer tx.Rollback()
+
+	versions := map[events.Aggregate]int{}
+	for _, aggregate := range batchAggregates(batch) {
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1), hashtext($2))`, aggregate.Type, aggregate.ID); err != nil {
+			return fmt.Errorf("failed to lock aggregate: %w", err)
+		}
+		var version int
+		err := tx.QueryRow(
+			`SELECT COALESCE(MAX("aggregateVersion"), 0) FROM events WHERE "aggregateType" = $1 AND "aggregateId" = $2`,
+			aggregate.Type, aggregate.ID,
+		).Scan(&version)
+		if err != nil {
+			return fmt.Errorf("failed to read aggregate version: %w", err)
+		}
+		if want, ok := expected[aggregate]; ok && want != version {
+			return fmt.Errorf("%w: %s %s is at version %d, expected %d", ErrConcurrencyConflict, aggregate.Type, aggregate.ID, version, want)
+		}
+		versions[aggregate] = version
+	}
+
+	for _, event := range batch {
+		versions[event.Aggregate]++
+		if err := insertEvent(tx, event, versions[event.Aggregate]); err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation && pqErr.Constraint == aggregateVersionKey {
+				return fmt.Errorf("%w: %s %s", ErrConcurrencyConflict, event.Aggregate.Type, event.Aggregate.ID)
+			}
+			return fmt.Errorf("failed to insert event: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit events: %w", err)
+	}
+	return nil
+}
+
+// aggregateVersionKey is the unique index that numbers each aggregate's events
+const aggregateVersionKey = "events_aggregateType_aggregateId_aggregateVersion_key"
+
+// batchAggregates returns the distinct aggregates written by a batch, sorted
+func batchAggregates(batch []*events.Event) []events.Aggregate {
+	seen := map[events.Aggregate]bool{}
+	aggregates := []events.Aggregate{}
+	for _, event := range batch {
+		if !seen[event.Aggregate] {
+			seen[event.Aggregate] = true
+			aggregates = append(aggregates, event.Aggregate)
+		}
+	}
+	sort.Slice(aggregates, func(i, j int) bool {
+		if aggregates[i].Type != aggregates[j].Type {
+			return aggregates[i].Type < aggregates[j].Type
+		}
+		return aggregates[i].ID < aggregates[j].ID
+	})
+	return aggregates
+}
+
+// insertEvent writes one event as the given version of its aggregate,
+// filling in its ID, time and position
+func insertEvent(tx *sql.Tx, event *events.Event, version int) error {
 	// Generate event ID if not set
 	if event.EventID == "" {
 		event.EventID = uuid.New().String()
@@ -57,11 +149,12 @@ func (es *EventStore) Append(event *events.Event) error {
 		INSERT INTO events (
 			"eventId", "occurredAt", "eventType", "aggregateType", "aggregateId",
 			"correlationId", "causationId", "actorId", "actorRole",
-			payload, explanation, "schemaVersion"
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			payload, explanation, "schemaVersion", "aggregateVersion"
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING position
 	`
 
-	_, err = es.db.Exec(
+	err = tx.QueryRow(
 		query,
 		event.EventID,
 		event.OccurredAt,
@@ -75,12 +168,13 @@ func (es *EventStore) Append(event *events.Event) error {
 		payloadJSON,
 		event.Explanation,
 		event.SchemaVersion,
-	)
-
+		version,
+	).Scan(&event.Position)
 	if err != nil {
-		return fmt.Errorf("failed to insert event: %w", err)
+		return err
 	}
 
+	event.AggregateVersion = version
 	return nil
 }
 
@@ -89,10 +183,10 @@ func (es *EventStore) GetByAggregate(aggregateType, aggregateID string) ([]*even
 	query := `
 		SELECT "eventId", "occurredAt", "eventType", "aggregateType", "aggregateId",
 		       "correlationId", "causationId", "actorId", "actorRole",
-		       payload, explanation, "schemaVersion"
+		       payload, explanation, "schemaVersion", position, "aggregateVersion"
 		FROM events
 		WHERE "aggregateType" = $1 AND "aggregateId" = $2
-		ORDER BY "occurredAt" ASC
+		ORDER BY position ASC
 	`
 
 	return es.queryEvents(query, aggregateType, aggregateID)
@@ -103,10 +197,10 @@ func (es *EventStore) GetByCorrelation(correlationID string) ([]*events.Event, e
 	query := `
 		SELECT "eventId", "occurredAt", "eventType", "aggregateType", "aggregateId",
 		       "correlationId", "causationId", "actorId", "actorRole",
-		       payload, explanation, "schemaVersion"
+		       payload, explanation, "schemaVersion", position, "aggregateVersion"
 		FROM events
 		WHERE "correlationId" = $1
-		ORDER BY "occurredAt" ASC
+		ORDER BY position ASC
 	`
 
 	return es.queryEvents(query, correlationID)
@@ -117,10 +211,10 @@ func (es *EventStore) GetByTimeRange(from, to time.Time) ([]*events.Event, error
 	query := `
 		SELECT "eventId", "occurredAt", "eventType", "aggregateType", "aggregateId",
 		       "correlationId", "causationId", "actorId", "actorRole",
-		       payload, explanation, "schemaVersion"
+		       payload, explanation, "schemaVersion", position, "aggregateVersion"
 		FROM events
 		WHERE "occurredAt" BETWEEN $1 AND $2
-		ORDER BY "occurredAt" ASC
+		ORDER BY position ASC
 	`
 
 	return es.queryEvents(query, from, to)
@@ -131,28 +225,101 @@ func (es *EventStore) GetByEventType(eventType string) ([]*events.Event, error)
 	query := `
 		SELECT "eventId", "occurredAt", "eventType", "aggregateType", "aggregateId",
 		       "correlationId", "causationId", "actorId", "actorRole",
-		       payload, explanation, "schemaVersion"
+		       payload, explanation, "schemaVersion", position, "aggregateVersion"
 		FROM events
 		WHERE "eventType" = $1
-		ORDER BY "occurredAt" ASC
+		ORDER BY position ASC
 	`
 
 	return es.queryEvents(query, eventType)
 }
 
+// GetByEventTypesAfterPosition retrieves the events of the given types with a
+// position greater than the given one
+func (es *EventStore) GetByEventTypesAfterPosition(position int64, eventTypes ...string) ([]*events.Event, error) {
+	query := `
+		SELECT "eventId", "occurredAt", "eventType", "aggregateType", "aggregateId",
+		       "correlationId", "causationId", "actorId", "actorRole",
+		       payload, explanation, "schemaVersion", position, "aggregateVersion"
+		FROM events
+		WHERE position > $1 AND "eventType" = ANY($2)
+		ORDER BY position ASC
+	`
+
+	return es.queryEvents(query, position, pq.Array(eventTypes))
+}
+
 // GetAll retrieves all events (use with caution)
 func (es *EventStore) GetAll() ([]*events.Event, error) {
 	query := `
 		SELECT "eventId", "occurredAt", "eventType", "aggregateType", "aggregateId",
 		       "correlationId", "causationId", "actorId", "actorRole",
-		       payload, explanation, "schemaVersion"
+		       payload, explanation, "schemaVersion", position, "aggregateVersion"
 		FROM events
-		ORDER BY "occurredAt" ASC
+		ORDER BY position ASC
 	`
 
 	return es.queryEvents(query)
 }
 
+// GetByPosition retrieves a single event by its global store position
+func (es *EventStore) GetByPosition(position int64) (*events.Event, error) {
+	query := `
+		SELECT "eventId", "occurredAt", "eventType", "aggregateType", "aggregateId",
+		       "correlationId", "causationId", "actorId", "actorRole",
+		       payload, explanation, "schemaVersion", position, "aggregateVersion"
+		FROM events
+		WHERE position = $1
+	`
+
+	result, err := es.queryEvents(query, position)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("event at position %d not found", position)
+	}
+	return result[0], nil
+}
+
+// GetAfterPosition retrieves up to limit events with a position greater than the given one
+func (es *EventStore) GetAfterPosition(position int64, limit int) ([]*events.Event, error) {
+	query := `
+		SELECT "eventId", "occurredAt", "eventType", "aggregateType", "aggregateId",
+		       "correlationId", "causationId", "actorId", "actorRole",
+		       payload, explanation, "schemaVersion", position, "aggregateVersion"
+		FROM events
+		WHERE position > $1
+		ORDER BY position ASC
+		LIMIT $2
+	`
+
+	return es.queryEvents(query, position, limit)
+}
+
+// LastPositionByCorrelation returns the highest position written under a correlation ID,
+// or 0 when no event has been written yet
+func (es *EventStore) LastPositionByCorrelation(correlationID string) (int64, error) {
+	var position int64
+	err := es.db.QueryRow(
+		`SELECT COALESCE(MAX(position), 0) FROM events WHERE "correlationId" = $1`,
+		correlationID,
+	).Scan(&position)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query last position: %w", err)
+	}
+	return position, nil
+}
+
+// LastPosition returns the highest position in the store, or 0 when it is empty
+func (es *EventStore) LastPosition() (int64, error) {
+	var position int64
+	if err := es.db.QueryRow(`SELECT COALESCE(MAX(position), 0) FROM events`).Scan(&position); err != nil {
+		return 0, fmt.Errorf("failed to query last position: %w", err)
+	}
+	return position, nil
+}
+
 // queryEvents is a helper function to execute queries and scan results
 func (es *EventStore) queryEvents(query string, args ...interface{}) ([]*events.Event, error) {
 	rows, err := es.db.Query(query, args...)
@@ -177,6 +344,8 @@ func (es *EventStore) queryEvents(query string, args ...interface{}) ([]*events.
 			payloadJSON   []byte
 			explanation   sql.NullString
 			schemaVersion int
+			position      int64
+			version       int
 		)
 
 		err := rows.Scan(
@@ -192,6 +361,8 @@ func (es *EventStore) queryEvents(query string, args ...interface{}) ([]*events.
 			&payloadJSON,
 			&explanation,
 			&schemaVersion,
+			&position,
+			&version,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan event row: %w", err)
@@ -215,9 +386,11 @@ func (es *EventStore) queryEvents(query string, args ...interface{}) ([]*events.
 				Type: aggregateType,
 				ID:   aggregateID,
 			},
-			CorrelationID: correlationID,
-			Payload:       payload,
-			SchemaVersion: schemaVersion,
+			CorrelationID:    correlationID,
+			Payload:          payload,
+			SchemaVersion:    schemaVersion,
+			Position:         position,
+			AggregateVersion: version,
 		}
 
 		if causationID.Valid {
@@ -0,0 +1,34 @@
+package eventstore
+
+import (
+	"testing"
+
+	"instant/services/api/events"
+)
+
+func TestBatchAggregatesLocksEachAggregateOnceInOrder(t *testing.T) {
+	order := func(id string) *events.Event {
+		return &events.Event{Aggregate: events.Aggregate{Type: events.AggregateOrder, ID: id}}
+	}
+	batch := []*events.Event{
+		order("o2"),
+		{Aggregate: events.Aggregate{Type: events.AggregateNetting, ID: "n1"}},
+		order("o1"),
+		order("o2"),
+	}
+
+	got := batchAggregates(batch)
+	want := []events.Aggregate{
+		{Type: events.AggregateNetting, ID: "n1"},
+		{Type: events.AggregateOrder, ID: "o1"},
+		{Type: events.AggregateOrder, ID: "o2"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
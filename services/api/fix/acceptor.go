@@ -0,0 +1,327 @@
+package fix
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"instant/services/api/eventbus"
+)
+
+// logonTimeout bounds how long a new connection may take to log on
+const logonTimeout = 10 * time.Second
+
+// Config configures the FIX acceptor
+type Config struct {
+	Address       string   // host:port to listen on
+	SenderCompID  string   // the gateway's own CompID
+	TargetCompIDs []string // counterparties allowed to log on; empty allows any
+}
+
+// counterparty is the gateway's state for one counterparty CompID: the
+// sequence numbers and sent messages that survive reconnects, and the
+// current session if it is logged on
+type counterparty struct {
+	compID  string
+	store   *sessionStore
+	session *Session
+}
+
+// Acceptor listens for FIX initiators and runs a session for each. Orders
+// entered on a session are placed through the OMS and reported back with
+// ExecutionReports as the order's events arrive. Sequence numbers, sent
+// messages and the ClOrdID map are kept in the store, so a restarted
+// acceptor carries on where the last one stopped.
+type Acceptor struct {
+	config   Config
+	orders   OrderService
+	eventBus *eventbus.EventBus
+	store    Store
+
+	listener net.Listener
+
+	mu             sync.Mutex
+	counterparties map[string]*counterparty
+	byClOrdID      map[string]*fixOrder
+	byOrderID      map[string]*fixOrder
+	executions     map[string]string // executionId -> orderId
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewAcceptor creates a FIX acceptor that places orders through the given
+// service and keeps its state in the given store
+func NewAcceptor(cfg Config, orders OrderService, eb *eventbus.EventBus, store Store) *Acceptor {
+	return &Acceptor{
+		config:         cfg,
+		orders:         orders,
+		eventBus:       eb,
+		store:          store,
+		counterparties: map[string]*counterparty{},
+		byClOrdID:      map[string]*fixOrder{},
+		byOrderID:      map[string]*fixOrder{},
+		executions:     map[string]string{},
+		stopChan:       make(chan struct{}),
+	}
+}
+
+// Listen restores the acceptor's orders from its store and binds its address
+func (a *Acceptor) Listen() error {
+	if a.config.SenderCompID == "" {
+		return errors.New("FIX SenderCompID is required")
+	}
+	if err := a.restore(); err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", a.config.Address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", a.config.Address, err)
+	}
+	a.listener = listener
+	return nil
+}
+
+// restore reloads the orders entered over FIX and the executions working them
+func (a *Acceptor) restore() error {
+	orders, executions, err := a.store.loadOrders()
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, order := range orders {
+		a.byClOrdID[clOrdIDKey(order.compID, order.clOrdID)] = order
+		if order.cancelClOrdID != "" {
+			a.byClOrdID[clOrdIDKey(order.compID, order.cancelClOrdID)] = order
+		}
+		a.byOrderID[order.orderID] = order
+	}
+	for executionID, orderID := range executions {
+		a.executions[executionID] = orderID
+	}
+	return nil
+}
+
+// Addr returns the address the acceptor is listening on
+func (a *Acceptor) Addr() net.Addr {
+	return a.listener.Addr()
+}
+
+// Start accepts connections and reports order events until Stop is called.
+// Listen must have succeeded first.
+func (a *Acceptor) Start() {
+	subscriber, cleanup := a.eventBus.Subscribe("*", 1000)
+	defer cleanup()
+
+	go a.acceptLoop()
+
+	log.Printf("FIX acceptor %s listening on %s", a.config.SenderCompID, a.listener.Addr())
+
+	for {
+		select {
+		case event, ok := <-subscriber:
+			if !ok {
+				// The bus has closed the subscription
+				return
+			}
+			a.handleEvent(event)
+		case <-a.stopChan:
+			log.Println("FIX acceptor stopped")
+			return
+		}
+	}
+}
+
+// Stop closes the listener and every session
+func (a *Acceptor) Stop() {
+	a.stopOnce.Do(func() {
+		close(a.stopChan)
+		a.listener.Close()
+
+		a.mu.Lock()
+		sessions := []*Session{}
+		for _, cp := range a.counterparties {
+			if cp.session != nil {
+				sessions = append(sessions, cp.session)
+			}
+		}
+		a.mu.Unlock()
+
+		for _, session := range sessions {
+			session.Close()
+		}
+	})
+}
+
+func (a *Acceptor) acceptLoop() {
+	for {
+		conn, err := a.listener.Accept()
+		if err != nil {
+			select {
+			case <-a.stopChan:
+				return
+			default:
+			}
+			log.Printf("FIX accept error: %v", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		go a.handleConnection(conn)
+	}
+}
+
+// handleConnection logs a new connection on and serves it
+func (a *Acceptor) handleConnection(conn net.Conn) {
+	session, gap, err := a.logon(conn)
+	if err != nil {
+		log.Printf("FIX logon from %s refused: %v", conn.RemoteAddr(), err)
+		conn.Close()
+		return
+	}
+
+	log.Printf("FIX session %s->%s logged on from %s", session.TargetCompID, session.SenderCompID, conn.RemoteAddr())
+	if gap > 0 {
+		session.requestResend(session.store.expectedIn(), gap)
+	}
+
+	if err := session.serve(); err != nil {
+		log.Printf("FIX session %s->%s ended: %v", session.TargetCompID, session.SenderCompID, err)
+		return
+	}
+	log.Printf("FIX session %s->%s logged out", session.TargetCompID, session.SenderCompID)
+}
+
+// logon reads and answers the counterparty's Logon. It returns the session
+// and, when the Logon was ahead of the expected sequence number, the
+// sequence number it carried so the gap can be requested.
+func (a *Acceptor) logon(conn net.Conn) (*Session, int, error) {
+	reader := bufio.NewReader(conn)
+
+	conn.SetReadDeadline(time.Now().Add(logonTimeout))
+	data, err := readMessage(reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	logon, err := ParseMessage(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	if logon.Type() != MsgTypeLogon {
+		return nil, 0, ErrLogonRequired
+	}
+
+	compID := logon.Get(TagSenderCompID)
+	if logon.Get(TagTargetCompID) != a.config.SenderCompID || !a.allowed(compID) {
+		return nil, 0, fmt.Errorf("%w: %s->%s", ErrCompIDMismatch, compID, logon.Get(TagTargetCompID))
+	}
+	heartBtInt, err := heartBtIntFromLogon(logon)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	a.mu.Lock()
+	cp, err := a.counterparty(compID)
+	if err != nil {
+		a.mu.Unlock()
+		return nil, 0, err
+	}
+	if cp.loggedOn() {
+		a.mu.Unlock()
+		return nil, 0, fmt.Errorf("%s is already logged on", compID)
+	}
+
+	resetSeqNum := logon.Get(TagResetSeqNumFlag) == "Y"
+	if resetSeqNum {
+		cp.store.reset()
+	}
+
+	session := newSession(conn, reader, a.config.SenderCompID, compID, heartBtInt, cp.store, a.handleApplication)
+	gapDetected, err := acceptLogonSequence(logon, cp.store)
+	if err != nil {
+		a.mu.Unlock()
+		session.Send(NewMessage(MsgTypeLogout).Set(TagText, err.Error()))
+		return nil, 0, err
+	}
+
+	// The Logon goes out before any report can be sent on the session
+	if err := session.Send(logonMessage(heartBtInt, resetSeqNum)); err != nil {
+		a.mu.Unlock()
+		return nil, 0, err
+	}
+	cp.session = session
+	a.mu.Unlock()
+
+	gap := 0
+	if gapDetected {
+		gap, _ = logon.Int(TagMsgSeqNum)
+	}
+	return session, gap, nil
+}
+
+// counterparty returns a counterparty's state, loading its session from the
+// store the first time it is seen. Callers hold a.mu.
+func (a *Acceptor) counterparty(compID string) (*counterparty, error) {
+	if cp, ok := a.counterparties[compID]; ok {
+		return cp, nil
+	}
+	store, err := openSessionStore(a.store, compID)
+	if err != nil {
+		return nil, err
+	}
+	cp := &counterparty{compID: compID, store: store}
+	a.counterparties[compID] = cp
+	return cp, nil
+}
+
+func (a *Acceptor) allowed(compID string) bool {
+	if compID == "" {
+		return false
+	}
+	if len(a.config.TargetCompIDs) == 0 {
+		return true
+	}
+	for _, allowed := range a.config.TargetCompIDs {
+		if allowed == compID {
+			return true
+		}
+	}
+	return false
+}
+
+// sendTo sends a message to a counterparty. If it is not logged on, the
+// message is still given a sequence number and stored, so it is delivered
+// when the counterparty reconnects and requests the gap. Callers hold a.mu.
+func (a *Acceptor) sendTo(compID string, msg *Message) {
+	cp, err := a.counterparty(compID)
+	if err != nil {
+		log.Printf("FIX: failed to send %s to %s: %v", msg.Type(), compID, err)
+		return
+	}
+	if cp.loggedOn() {
+		// A failed write has already stored the message for resending
+		if err := cp.session.Send(msg); !errors.Is(err, ErrSessionClosed) {
+			return
+		}
+	}
+	cp.store.record(msg, a.config.SenderCompID, compID)
+}
+
+func (cp *counterparty) loggedOn() bool {
+	if cp.session == nil {
+		return false
+	}
+	select {
+	case <-cp.session.Done():
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,356 @@
+package fix
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"instant/services/api/eventbus"
+	"instant/services/api/events"
+	"instant/services/api/oms"
+
+	"github.com/google/uuid"
+)
+
+// fakeOrders stands in for the OMS, publishing the events it would
+type fakeOrders struct {
+	eventBus *eventbus.EventBus
+
+	mu       sync.Mutex
+	created  []oms.CreateOrderRequest
+	replaced []oms.ReplaceOrderRequest
+}
+
+func (f *fakeOrders) publish(eventType, orderID string, payload map[string]interface{}) {
+	payload["orderId"] = orderID
+	f.eventBus.Publish(events.NewEvent(eventType, events.AggregateOrder, orderID, "test", "user", "corr", payload))
+}
+
+func (f *fakeOrders) CreateOrder(req oms.CreateOrderRequest, correlationID string) (string, error) {
+	f.mu.Lock()
+	f.created = append(f.created, req)
+	f.mu.Unlock()
+
+	orderID := uuid.New().String()
+	f.publish(events.EventOrderCreated, orderID, map[string]interface{}{"quantity": req.Quantity})
+	return orderID, nil
+}
+
+func (f *fakeOrders) CancelOrder(req oms.CancelOrderRequest, correlationID string) error {
+	f.publish(events.EventOrderCancelled, req.OrderID, map[string]interface{}{"cancelledBy": req.CancelledBy})
+	return nil
+}
+
+func (f *fakeOrders) ReplaceOrder(req oms.ReplaceOrderRequest, correlationID string) (*oms.ReplaceResult, error) {
+	f.mu.Lock()
+	f.replaced = append(f.replaced, req)
+	f.mu.Unlock()
+
+	newOrderID := uuid.New().String()
+	f.publish(events.EventOrderReplaced, req.OrderID, map[string]interface{}{"replacedByOrderId": newOrderID})
+	f.publish(events.EventOrderCreated, newOrderID, map[string]interface{}{"replacesOrderId": req.OrderID})
+	return &oms.ReplaceResult{OrderID: newOrderID, ReplacedOrderID: req.OrderID}, nil
+}
+
+func startAcceptor(t *testing.T) (*Acceptor, *fakeOrders) {
+	t.Helper()
+	orders := &fakeOrders{eventBus: eventbus.New()}
+	return startAcceptorOn(t, orders, NewMemoryStore()), orders
+}
+
+// startAcceptorOn starts an acceptor on existing state, as a restart would
+func startAcceptorOn(t *testing.T, orders *fakeOrders, store Store) *Acceptor {
+	t.Helper()
+	acceptor := NewAcceptor(Config{Address: "127.0.0.1:0", SenderCompID: "INSTANT"}, orders, orders.eventBus, store)
+	if err := acceptor.Listen(); err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go acceptor.Start()
+	t.Cleanup(acceptor.Stop)
+
+	// Start subscribes to the bus on its own goroutine
+	time.Sleep(20 * time.Millisecond)
+	return acceptor
+}
+
+func initiatorConfig(acceptor *Acceptor) InitiatorConfig {
+	return InitiatorConfig{
+		Address:      acceptor.Addr().String(),
+		SenderCompID: "DESK",
+		TargetCompID: "INSTANT",
+		ResetSeqNum:  true,
+	}
+}
+
+func expectMessage(t *testing.T, messages <-chan *Message, msgType string) *Message {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		if msg.Type() != msgType {
+			t.Fatalf("expected MsgType %s, got %s", msgType, msg)
+		}
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for MsgType %s", msgType)
+	}
+	return nil
+}
+
+func expectFields(t *testing.T, msg *Message, want map[int]string) {
+	t.Helper()
+	for tag, value := range want {
+		if msg.Get(tag) != value {
+			t.Fatalf("tag %d: expected %q, got %q in %s", tag, value, msg.Get(tag), msg)
+		}
+	}
+}
+
+func newOrderSingle(clOrdID string) *Message {
+	return NewMessage(MsgTypeNewOrderSingle).
+		Set(TagClOrdID, clOrdID).
+		Set(TagAccount, "acct-1").
+		Set(TagSecurityID, "912828YK0").
+		Set(TagSecurityIDSource, "1").
+		Set(TagSide, "1").
+		Set(TagOrderQty, "1000000").
+		Set(TagOrdType, "2").
+		Set(TagPrice, "99.5").
+		Set(TagTimeInForce, "0")
+}
+
+func TestGatewayReportsOrderLifecycle(t *testing.T) {
+	acceptor, orders := startAcceptor(t)
+	client, err := Dial(initiatorConfig(acceptor))
+	if err != nil {
+		t.Fatalf("logon failed: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.Send(newOrderSingle("c1")); err != nil {
+		t.Fatalf("failed to send order: %v", err)
+	}
+	ack := expectMessage(t, client.Messages, MsgTypeExecutionReport)
+	expectFields(t, ack, map[int]string{TagClOrdID: "c1", TagExecType: execTypeNew, TagOrdStatus: ordStatusNew, TagLeavesQty: "1000000"})
+	orderID := ack.Get(TagOrderID)
+
+	orders.mu.Lock()
+	req := orders.created[0]
+	orders.mu.Unlock()
+	if req.Side != oms.OrderSideBuy || req.OrderType != oms.OrderTypeLimit || req.LimitPrice == nil || *req.LimitPrice != 99.5 ||
+		req.TimeInForce != oms.TimeInForceDay || req.InstrumentID != "912828YK0" || req.CreatedBy != "fix:DESK" {
+		t.Fatalf("unexpected order request: %+v", req)
+	}
+
+	eb := orders.eventBus
+	eb.Publish(events.NewEvent(events.EventExecutionRequested, events.AggregateExecution, "exec-1", "test", "user", "corr",
+		map[string]interface{}{"executionId": "exec-1", "orderId": orderID}))
+	eb.Publish(events.NewEvent(events.EventFillGenerated, events.AggregateExecution, "exec-1", "test", "user", "corr",
+		map[string]interface{}{"executionId": "exec-1", "quantity": 400000.0, "price": 99.5}))
+	eb.Publish(events.NewEvent(events.EventFillGenerated, events.AggregateExecution, "exec-1", "test", "user", "corr",
+		map[string]interface{}{"executionId": "exec-1", "quantity": 600000.0, "price": 99.6}))
+	orders.publish(events.EventOrderFullyFilled, orderID, map[string]interface{}{"filledQuantity": 1000000.0})
+
+	first := expectMessage(t, client.Messages, MsgTypeExecutionReport)
+	expectFields(t, first, map[int]string{TagExecType: execTypeTrade, TagOrdStatus: ordStatusPartiallyFilled, TagLastQty: "400000", TagCumQty: "400000", TagLeavesQty: "600000"})
+	second := expectMessage(t, client.Messages, MsgTypeExecutionReport)
+	expectFields(t, second, map[int]string{TagExecType: execTypeTrade, TagOrdStatus: ordStatusFilled, TagLastPx: "99.6", TagCumQty: "1000000", TagLeavesQty: "0"})
+	if avgPx, _ := second.Float(TagAvgPx); avgPx < 99.5599 || avgPx > 99.5601 {
+		t.Fatalf("expected AvgPx 99.56, got %s", second.Get(TagAvgPx))
+	}
+	filled := expectMessage(t, client.Messages, MsgTypeExecutionReport)
+	expectFields(t, filled, map[int]string{TagExecType: execTypeOrderStatus, TagOrdStatus: ordStatusFilled, TagOrderID: orderID})
+}
+
+func TestGatewayCancelReplace(t *testing.T) {
+	acceptor, orders := startAcceptor(t)
+	client, err := Dial(initiatorConfig(acceptor))
+	if err != nil {
+		t.Fatalf("logon failed: %v", err)
+	}
+	defer client.Close()
+
+	client.Send(newOrderSingle("c1"))
+	expectMessage(t, client.Messages, MsgTypeExecutionReport)
+
+	client.Send(NewMessage(MsgTypeOrderCancelReplaceRequest).
+		Set(TagClOrdID, "c2").
+		Set(TagOrigClOrdID, "c1").
+		Set(TagSide, "1").
+		Set(TagOrderQty, "500000").
+		Set(TagOrdType, "2").
+		Set(TagPrice, "99.25"))
+	replaced := expectMessage(t, client.Messages, MsgTypeExecutionReport)
+	expectFields(t, replaced, map[int]string{TagExecType: execTypeReplaced, TagClOrdID: "c2", TagOrigClOrdID: "c1", TagOrderQty: "500000"})
+
+	orders.mu.Lock()
+	replaceReq := orders.replaced[0]
+	orders.mu.Unlock()
+	if replaceReq.Quantity == nil || *replaceReq.Quantity != 500000 || replaceReq.LimitPrice == nil || *replaceReq.LimitPrice != 99.25 {
+		t.Fatalf("unexpected replace request: %+v", replaceReq)
+	}
+
+	client.Send(NewMessage(MsgTypeOrderCancelRequest).Set(TagClOrdID, "c3").Set(TagOrigClOrdID, "c2").Set(TagSide, "1"))
+	cancelled := expectMessage(t, client.Messages, MsgTypeExecutionReport)
+	expectFields(t, cancelled, map[int]string{TagExecType: execTypeCanceled, TagOrdStatus: ordStatusCanceled, TagClOrdID: "c3", TagOrigClOrdID: "c2", TagLeavesQty: "0"})
+
+	client.Send(NewMessage(MsgTypeOrderCancelRequest).Set(TagClOrdID, "c4").Set(TagOrigClOrdID, "missing").Set(TagSide, "1"))
+	reject := expectMessage(t, client.Messages, MsgTypeOrderCancelReject)
+	expectFields(t, reject, map[int]string{TagCxlRejResponseTo: cxlRejResponseToCancel, TagCxlRejReason: "1", TagClOrdID: "c4"})
+
+	client.Send(newOrderSingle("c1"))
+	duplicate := expectMessage(t, client.Messages, MsgTypeExecutionReport)
+	expectFields(t, duplicate, map[int]string{TagExecType: execTypeRejected, TagOrdRejReason: "6"})
+}
+
+// rawClient speaks FIX byte by byte so tests control sequence numbers
+type rawClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialRaw(t *testing.T, acceptor *Acceptor) *rawClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", acceptor.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &rawClient{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+func (c *rawClient) send(msg *Message, seq int) {
+	c.t.Helper()
+	stampHeader(msg, "DESK", "INSTANT", seq, time.Now())
+	if _, err := c.conn.Write(msg.Bytes()); err != nil {
+		c.t.Fatalf("failed to write: %v", err)
+	}
+}
+
+func (c *rawClient) read(msgType string) *Message {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	data, err := readMessage(c.reader)
+	if err != nil {
+		c.t.Fatalf("failed to read MsgType %s: %v", msgType, err)
+	}
+	msg, err := ParseMessage(data)
+	if err != nil {
+		c.t.Fatalf("failed to parse: %v", err)
+	}
+	if msg.Type() != msgType {
+		c.t.Fatalf("expected MsgType %s, got %s", msgType, msg)
+	}
+	return msg
+}
+
+func TestSessionSequenceNumbersAndResend(t *testing.T) {
+	acceptor, _ := startAcceptor(t)
+	client := dialRaw(t, acceptor)
+
+	client.send(logonMessage(30*time.Second, true), 1)
+	logon := client.read(MsgTypeLogon)
+	expectFields(t, logon, map[int]string{TagMsgSeqNum: "1", TagHeartBtInt: "30", TagResetSeqNumFlag: "Y"})
+
+	client.send(newOrderSingle("c1"), 2)
+	ack := client.read(MsgTypeExecutionReport)
+	expectFields(t, ack, map[int]string{TagMsgSeqNum: "2"})
+
+	// Resend everything: the Logon is gap filled, the report resent as a possible duplicate
+	client.send(NewMessage(MsgTypeResendRequest).SetInt(TagBeginSeqNo, 1).SetInt(TagEndSeqNo, 0), 3)
+	gapFill := client.read(MsgTypeSequenceReset)
+	expectFields(t, gapFill, map[int]string{TagMsgSeqNum: "1", TagGapFillFlag: "Y", TagPossDupFlag: "Y", TagNewSeqNo: "2"})
+	resent := client.read(MsgTypeExecutionReport)
+	expectFields(t, resent, map[int]string{TagMsgSeqNum: "2", TagPossDupFlag: "Y", TagClOrdID: "c1", TagExecID: ack.Get(TagExecID)})
+	if resent.Get(TagOrigSendingTime) != ack.Get(TagSendingTime) {
+		t.Fatalf("expected OrigSendingTime %s, got %s", ack.Get(TagSendingTime), resent.Get(TagOrigSendingTime))
+	}
+
+	// Skipping 4 makes the acceptor ask for it instead of answering the test request
+	client.send(NewMessage(MsgTypeTestRequest).Set(TagTestReqID, "early"), 5)
+	resendRequest := client.read(MsgTypeResendRequest)
+	expectFields(t, resendRequest, map[int]string{TagBeginSeqNo: "4", TagEndSeqNo: "0"})
+
+	client.send(NewMessage(MsgTypeSequenceReset).Set(TagPossDupFlag, "Y").Set(TagGapFillFlag, "Y").SetInt(TagNewSeqNo, 5), 4)
+	client.send(NewMessage(MsgTypeTestRequest).Set(TagTestReqID, "ping"), 5)
+	heartbeat := client.read(MsgTypeHeartbeat)
+	expectFields(t, heartbeat, map[int]string{TagTestReqID: "ping"})
+
+	// A repeated sequence number that is not a possible duplicate ends the session
+	client.send(NewMessage(MsgTypeHeartbeat), 3)
+	logout := client.read(MsgTypeLogout)
+	if !strings.Contains(logout.Get(TagText), "MsgSeqNum too low, expecting 6 but received 3") {
+		t.Fatalf("unexpected logout text: %q", logout.Get(TagText))
+	}
+}
+
+func TestAcceptorDeliversReportsMissedWhileDisconnected(t *testing.T) {
+	acceptor, orders := startAcceptor(t)
+	cfg := initiatorConfig(acceptor)
+	client, err := Dial(cfg)
+	if err != nil {
+		t.Fatalf("logon failed: %v", err)
+	}
+
+	client.Send(newOrderSingle("c1"))
+	orderID := expectMessage(t, client.Messages, MsgTypeExecutionReport).Get(TagOrderID)
+
+	client.Close()
+	orders.publish(events.EventOrderCancelled, orderID, map[string]interface{}{"reason": "desk closed"})
+
+	var reconnected *Initiator
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		reconnected, err = client.Reconnect(cfg)
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("reconnect failed: %v", err)
+	}
+	defer reconnected.Close()
+
+	cancelled := expectMessage(t, reconnected.Messages, MsgTypeExecutionReport)
+	expectFields(t, cancelled, map[int]string{TagExecType: execTypeCanceled, TagOrderID: orderID, TagText: "desk closed"})
+}
+
+func TestAcceptorRestartKeepsSequenceNumbersAndOrders(t *testing.T) {
+	store := NewMemoryStore()
+	orders := &fakeOrders{eventBus: eventbus.New()}
+	first := startAcceptorOn(t, orders, store)
+
+	client := dialRaw(t, first)
+	client.send(logonMessage(30*time.Second, true), 1)
+	client.read(MsgTypeLogon)
+	client.send(newOrderSingle("c1"), 2)
+	orderID := client.read(MsgTypeExecutionReport).Get(TagOrderID)
+	first.Stop()
+
+	// Another acceptor on the same store carries on from the same sequence numbers
+	second := startAcceptorOn(t, orders, store)
+	client = dialRaw(t, second)
+	client.send(logonMessage(30*time.Second, false), 3)
+	logon := client.read(MsgTypeLogon)
+	expectFields(t, logon, map[int]string{TagMsgSeqNum: "3"})
+
+	// Netting crosses and block allocations fill the order as well as EMS fills do
+	orders.eventBus.Publish(events.NewEvent(events.EventCrossTradeExecuted, events.AggregateNetting, "net-1", "test", "user", "corr",
+		map[string]interface{}{"crossId": "cross-1", "buyOrderId": orderID, "sellOrderId": "other", "quantity": 400000.0, "price": 99.5}))
+	crossed := client.read(MsgTypeExecutionReport)
+	expectFields(t, crossed, map[int]string{TagMsgSeqNum: "4", TagExecType: execTypeTrade, TagClOrdID: "c1", TagLastQty: "400000", TagCumQty: "400000", TagLeavesQty: "600000"})
+
+	orders.eventBus.Publish(events.NewEvent(events.EventAllocationBooked, events.AggregateExecution, "exec-1", "test", "user", "corr",
+		map[string]interface{}{"executionId": "exec-1", "orderId": orderID, "quantity": 600000.0, "price": 99.6}))
+	allocated := client.read(MsgTypeExecutionReport)
+	expectFields(t, allocated, map[int]string{TagExecType: execTypeTrade, TagOrdStatus: ordStatusFilled, TagCumQty: "1000000", TagLeavesQty: "0"})
+
+	// The ClOrdID is still known, so reusing it is rejected
+	client.send(newOrderSingle("c1"), 4)
+	duplicate := client.read(MsgTypeExecutionReport)
+	expectFields(t, duplicate, map[int]string{TagExecType: execTypeRejected, TagOrdRejReason: "6"})
+}
@@ -0,0 +1,580 @@
+package fix
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"instant/services/api/events"
+	"instant/services/api/oms"
+
+	"github.com/google/uuid"
+)
+
+// OrderService is the part of the OMS the gateway places orders through
+type OrderService interface {
+	CreateOrder(req oms.CreateOrderRequest, correlationID string) (string, error)
+	CancelOrder(req oms.CancelOrderRequest, correlationID string) error
+	ReplaceOrder(req oms.ReplaceOrderRequest, correlationID string) (*oms.ReplaceResult, error)
+}
+
+// ExecType and OrdStatus values
+const (
+	execTypeNew         = "0"
+	execTypeCanceled    = "4"
+	execTypeReplaced    = "5"
+	execTypeRejected    = "8"
+	execTypeExpired     = "C"
+	execTypeTrade       = "F"
+	execTypeOrderStatus = "I"
+
+	ordStatusNew             = "0"
+	ordStatusPartiallyFilled = "1"
+	ordStatusFilled          = "2"
+	ordStatusCanceled        = "4"
+	ordStatusRejected        = "8"
+	ordStatusExpired         = "C"
+)
+
+// Reject reason codes
+const (
+	ordRejReasonOther         = 99
+	ordRejReasonDuplicate     = 6
+	cxlRejReasonTooLate       = 0
+	cxlRejReasonUnknown       = 1
+	cxlRejReasonOther         = 99
+	cxlRejResponseToCancel    = "1"
+	cxlRejResponseToReplace   = "2"
+	businessRejectUnsupported = 3
+)
+
+// fixOrder is an order entered over FIX. Quantities follow FIX and cover the
+// whole cancel/replace chain: a replacement keeps the fills of the versions
+// before it.
+type fixOrder struct {
+	compID        string
+	clOrdID       string
+	origClOrdID   string // set on replacements
+	cancelClOrdID string // set once a cancel has been accepted
+	orderID       string
+	account       string
+	securityID    string
+	side          string
+	quantity      float64
+	cumQty        float64
+	notional      float64
+	status        string
+}
+
+func (o *fixOrder) avgPx() float64 {
+	if o.cumQty == 0 {
+		return 0
+	}
+	return o.notional / o.cumQty
+}
+
+func (o *fixOrder) done() bool {
+	switch o.status {
+	case ordStatusFilled, ordStatusCanceled, ordStatusRejected, ordStatusExpired:
+		return true
+	}
+	return false
+}
+
+func clOrdIDKey(compID, clOrdID string) string {
+	return compID + "\x00" + clOrdID
+}
+
+// save writes an order through to the store. Callers hold a.mu.
+func (a *Acceptor) save(order *fixOrder) {
+	if err := a.store.saveOrder(order); err != nil {
+		log.Printf("FIX: %v", err)
+	}
+}
+
+// handleApplication runs on the session's goroutine for each application
+// message received in sequence
+func (a *Acceptor) handleApplication(session *Session, msg *Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch msg.Type() {
+	case MsgTypeNewOrderSingle:
+		a.newOrderSingle(session.TargetCompID, msg)
+	case MsgTypeOrderCancelRequest:
+		a.cancelOrder(session.TargetCompID, msg)
+	case MsgTypeOrderCancelReplaceRequest:
+		a.replaceOrder(session.TargetCompID, msg)
+	default:
+		a.sendTo(session.TargetCompID, NewMessage(MsgTypeBusinessMessageReject).
+			Set(TagRefSeqNum, msg.Get(TagMsgSeqNum)).
+			Set(TagRefMsgType, msg.Type()).
+			SetInt(TagBusinessRejectReason, businessRejectUnsupported).
+			Set(TagText, fmt.Sprintf("message type %s is not supported", msg.Type())))
+	}
+}
+
+// newOrderSingle places a NewOrderSingle as an OMS order. The order is
+// acknowledged when its OrderCreated event arrives; requests the OMS refuses
+// are rejected straight away.
+func (a *Acceptor) newOrderSingle(compID string, msg *Message) {
+	order := &fixOrder{
+		compID:     compID,
+		clOrdID:    msg.Get(TagClOrdID),
+		account:    msg.Get(TagAccount),
+		securityID: securityID(msg),
+		side:       msg.Get(TagSide),
+		status:     ordStatusNew,
+	}
+	order.quantity, _ = msg.Float(TagOrderQty)
+
+	if order.clOrdID == "" {
+		a.rejectOrder(order, ordRejReasonOther, "ClOrdID is required")
+		return
+	}
+	if _, exists := a.byClOrdID[clOrdIDKey(compID, order.clOrdID)]; exists {
+		a.rejectOrder(order, ordRejReasonDuplicate, "duplicate ClOrdID")
+		return
+	}
+
+	req, err := createOrderRequest(msg, "fix:"+compID)
+	if err != nil {
+		a.rejectOrder(order, ordRejReasonOther, err.Error())
+		return
+	}
+
+	orderID, err := a.orders.CreateOrder(req, uuid.New().String())
+	if orderID == "" {
+		a.rejectOrder(order, ordRejReasonOther, err.Error())
+		return
+	}
+
+	// A compliance block still records the order; its OrderBlockedByCompliance
+	// event rejects it
+	order.orderID = orderID
+	a.byClOrdID[clOrdIDKey(compID, order.clOrdID)] = order
+	a.byOrderID[orderID] = order
+	a.save(order)
+}
+
+// cancelOrder cancels the order an OrderCancelRequest names by OrigClOrdID
+func (a *Acceptor) cancelOrder(compID string, msg *Message) {
+	clOrdID := msg.Get(TagClOrdID)
+	order, ok := a.byClOrdID[clOrdIDKey(compID, msg.Get(TagOrigClOrdID))]
+	if !ok {
+		a.rejectCancel(compID, msg, nil, cxlRejResponseToCancel, cxlRejReasonUnknown, "unknown OrigClOrdID")
+		return
+	}
+	if clOrdID == "" {
+		a.rejectCancel(compID, msg, order, cxlRejResponseToCancel, cxlRejReasonOther, "ClOrdID is required")
+		return
+	}
+
+	err := a.orders.CancelOrder(oms.CancelOrderRequest{
+		OrderID:     order.orderID,
+		CancelledBy: "fix:" + compID,
+		Reason:      msg.Get(TagText),
+	}, uuid.New().String())
+	if err != nil {
+		a.rejectCancel(compID, msg, order, cxlRejResponseToCancel, cancelRejectReason(err), err.Error())
+		return
+	}
+
+	order.cancelClOrdID = clOrdID
+	a.byClOrdID[clOrdIDKey(compID, clOrdID)] = order
+	a.save(order)
+}
+
+// replaceOrder replaces the order an OrderCancelReplaceRequest names by
+// OrigClOrdID. OrderQty is the new total for the chain, so the replacement
+// works OrderQty less what has already filled.
+func (a *Acceptor) replaceOrder(compID string, msg *Message) {
+	clOrdID := msg.Get(TagClOrdID)
+	original, ok := a.byClOrdID[clOrdIDKey(compID, msg.Get(TagOrigClOrdID))]
+	if !ok {
+		a.rejectCancel(compID, msg, nil, cxlRejResponseToReplace, cxlRejReasonUnknown, "unknown OrigClOrdID")
+		return
+	}
+	if clOrdID == "" {
+		a.rejectCancel(compID, msg, original, cxlRejResponseToReplace, cxlRejReasonOther, "ClOrdID is required")
+		return
+	}
+	if _, exists := a.byClOrdID[clOrdIDKey(compID, clOrdID)]; exists {
+		a.rejectCancel(compID, msg, original, cxlRejResponseToReplace, cxlRejReasonOther, "duplicate ClOrdID")
+		return
+	}
+
+	req, quantity, err := replaceOrderRequest(msg, original, "fix:"+compID)
+	if err != nil {
+		a.rejectCancel(compID, msg, original, cxlRejResponseToReplace, cxlRejReasonOther, err.Error())
+		return
+	}
+
+	result, err := a.orders.ReplaceOrder(req, uuid.New().String())
+	if err != nil {
+		a.rejectCancel(compID, msg, original, cxlRejResponseToReplace, cancelRejectReason(err), err.Error())
+		return
+	}
+
+	replacement := &fixOrder{
+		compID:      compID,
+		clOrdID:     clOrdID,
+		origClOrdID: original.clOrdID,
+		orderID:     result.OrderID,
+		account:     original.account,
+		securityID:  original.securityID,
+		side:        original.side,
+		quantity:    quantity,
+		cumQty:      original.cumQty,
+		notional:    original.notional,
+		status:      ordStatusNew,
+	}
+	if replacement.cumQty > 0 {
+		replacement.status = ordStatusPartiallyFilled
+	}
+	original.status = ordStatusCanceled
+	a.byClOrdID[clOrdIDKey(compID, clOrdID)] = replacement
+	a.byOrderID[result.OrderID] = replacement
+	a.save(original)
+	a.save(replacement)
+}
+
+// handleEvent reports an order event to the counterparty that entered the order
+func (a *Acceptor) handleEvent(event *events.Event) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	payload := event.Payload
+	switch event.EventType {
+	case events.EventExecutionRequested:
+		orderID := stringValue(payload["orderId"])
+		if _, ok := a.byOrderID[orderID]; ok {
+			executionID := stringValue(payload["executionId"])
+			a.executions[executionID] = orderID
+			if err := a.store.saveExecution(executionID, orderID); err != nil {
+				log.Printf("FIX: %v", err)
+			}
+		}
+		return
+
+	case events.EventCrossTradeExecuted:
+		// A netting cross fills both of its legs without going to the EMS
+		for _, orderID := range []string{stringValue(payload["buyOrderId"]), stringValue(payload["sellOrderId"])} {
+			if order, ok := a.byOrderID[orderID]; ok {
+				a.reportFill(order, floatValue(payload["quantity"]), floatValue(payload["price"]))
+			}
+		}
+		return
+	}
+
+	orderID := stringValue(payload["orderId"])
+	if event.EventType == events.EventFillGenerated {
+		orderID = a.executions[stringValue(payload["executionId"])]
+	}
+	order, ok := a.byOrderID[orderID]
+	if !ok {
+		return
+	}
+
+	switch event.EventType {
+	case events.EventOrderCreated:
+		if order.origClOrdID != "" {
+			a.sendTo(order.compID, a.executionReport(order, execTypeReplaced))
+			return
+		}
+		a.sendTo(order.compID, a.executionReport(order, execTypeNew))
+
+	case events.EventFillGenerated, events.EventAllocationBooked:
+		// A block execution's fills reach the order as its allocation
+		a.reportFill(order, floatValue(payload["quantity"]), floatValue(payload["price"]))
+
+	case events.EventOrderFullyFilled:
+		order.status = ordStatusFilled
+		a.save(order)
+		a.sendTo(order.compID, a.executionReport(order, execTypeOrderStatus))
+
+	case events.EventOrderCancelled:
+		order.status = ordStatusCanceled
+		a.save(order)
+		report := a.executionReport(order, execTypeCanceled)
+		if order.cancelClOrdID != "" {
+			report.Set(TagClOrdID, order.cancelClOrdID).Set(TagOrigClOrdID, order.clOrdID)
+		}
+		if reason := stringValue(payload["reason"]); reason != "" {
+			report.Set(TagText, reason)
+		}
+		a.sendTo(order.compID, report)
+
+	case events.EventOrderExpired:
+		order.status = ordStatusExpired
+		a.save(order)
+		a.sendTo(order.compID, a.executionReport(order, execTypeExpired))
+
+	case events.EventOrderRejected, events.EventOrderBlockedByCompliance:
+		order.status = ordStatusRejected
+		a.save(order)
+		report := a.executionReport(order, execTypeRejected).SetInt(TagOrdRejReason, ordRejReasonOther)
+		if event.EventType == events.EventOrderBlockedByCompliance {
+			report.Set(TagText, "blocked by compliance")
+		} else if reason := stringValue(payload["rejectionReason"]); reason != "" {
+			report.Set(TagText, reason)
+		}
+		a.sendTo(order.compID, report)
+	}
+}
+
+// reportFill adds a fill to the order and sends it as a trade report
+func (a *Acceptor) reportFill(order *fixOrder, lastQty, lastPx float64) {
+	order.cumQty += lastQty
+	order.notional += lastQty * lastPx
+	order.status = ordStatusPartiallyFilled
+	if order.cumQty >= order.quantity {
+		order.status = ordStatusFilled
+	}
+	a.save(order)
+
+	report := a.executionReport(order, execTypeTrade).
+		SetFloat(TagLastQty, lastQty).
+		SetFloat(TagLastPx, lastPx)
+	a.sendTo(order.compID, report)
+}
+
+// executionReport reports an order's current status
+func (a *Acceptor) executionReport(order *fixOrder, execType string) *Message {
+	leavesQty := order.quantity - order.cumQty
+	if order.done() || leavesQty < 0 {
+		leavesQty = 0
+	}
+
+	orderID := order.orderID
+	if orderID == "" {
+		orderID = "NONE"
+	}
+
+	report := NewMessage(MsgTypeExecutionReport).
+		Set(TagOrderID, orderID).
+		Set(TagClOrdID, order.clOrdID).
+		Set(TagExecID, uuid.New().String()).
+		Set(TagExecType, execType).
+		Set(TagOrdStatus, order.status).
+		Set(TagAccount, order.account).
+		Set(TagSymbol, order.securityID).
+		Set(TagSecurityID, order.securityID).
+		Set(TagSecurityIDSource, "1").
+		Set(TagSide, order.side).
+		SetFloat(TagOrderQty, order.quantity).
+		SetFloat(TagLeavesQty, leavesQty).
+		SetFloat(TagCumQty, order.cumQty).
+		SetFloat(TagAvgPx, order.avgPx()).
+		SetTime(TagTransactTime, time.Now())
+	if order.origClOrdID != "" {
+		report.Set(TagOrigClOrdID, order.origClOrdID)
+	}
+	return report
+}
+
+func (a *Acceptor) rejectOrder(order *fixOrder, reason int, text string) {
+	order.status = ordStatusRejected
+	a.sendTo(order.compID, a.executionReport(order, execTypeRejected).
+		SetInt(TagOrdRejReason, reason).
+		Set(TagText, text))
+}
+
+func (a *Acceptor) rejectCancel(compID string, msg *Message, order *fixOrder, responseTo string, reason int, text string) {
+	reject := NewMessage(MsgTypeOrderCancelReject).
+		Set(TagOrderID, "NONE").
+		Set(TagClOrdID, msg.Get(TagClOrdID)).
+		Set(TagOrigClOrdID, msg.Get(TagOrigClOrdID)).
+		Set(TagOrdStatus, ordStatusRejected).
+		Set(TagCxlRejResponseTo, responseTo).
+		SetInt(TagCxlRejReason, reason).
+		Set(TagText, text)
+	if order != nil {
+		reject.Set(TagOrderID, order.orderID).Set(TagOrdStatus, order.status)
+	}
+	a.sendTo(compID, reject)
+}
+
+func cancelRejectReason(err error) int {
+	if errors.Is(err, oms.ErrInvalidState) {
+		return cxlRejReasonTooLate
+	}
+	if errors.Is(err, oms.ErrOrderNotFound) {
+		return cxlRejReasonUnknown
+	}
+	return cxlRejReasonOther
+}
+
+// createOrderRequest maps a NewOrderSingle onto an OMS order
+func createOrderRequest(msg *Message, createdBy string) (oms.CreateOrderRequest, error) {
+	req := oms.CreateOrderRequest{
+		AccountID:    msg.Get(TagAccount),
+		InstrumentID: securityID(msg),
+		CreatedBy:    createdBy,
+	}
+	if req.AccountID == "" {
+		return req, errors.New("Account is required")
+	}
+	if req.InstrumentID == "" {
+		return req, errors.New("SecurityID (CUSIP) or Symbol is required")
+	}
+
+	side, err := orderSide(msg.Get(TagSide))
+	if err != nil {
+		return req, err
+	}
+	req.Side = side
+
+	if req.Quantity, err = msg.Float(TagOrderQty); err != nil {
+		return req, errors.New("OrderQty is required")
+	}
+	if req.OrderType, err = orderType(msg.Get(TagOrdType)); err != nil {
+		return req, err
+	}
+	if req.OrderType == oms.OrderTypeLimit {
+		price, err := msg.Float(TagPrice)
+		if err != nil {
+			return req, errors.New("Price is required on limit orders")
+		}
+		req.LimitPrice = &price
+	}
+	if req.TimeInForce, req.ExpireAt, err = timeInForce(msg); err != nil {
+		return req, err
+	}
+	return req, nil
+}
+
+// replaceOrderRequest maps an OrderCancelReplaceRequest onto a replace of
+// the original order. It returns the FIX total quantity of the replacement.
+func replaceOrderRequest(msg *Message, original *fixOrder, replacedBy string) (oms.ReplaceOrderRequest, float64, error) {
+	req := oms.ReplaceOrderRequest{
+		OrderID:    original.orderID,
+		Reason:     msg.Get(TagText),
+		ReplacedBy: replacedBy,
+	}
+	if side := msg.Get(TagSide); side != "" && side != original.side {
+		return req, 0, errors.New("Side cannot be changed on replace")
+	}
+
+	quantity := original.quantity
+	if msg.Has(TagOrderQty) {
+		orderQty, err := msg.Float(TagOrderQty)
+		if err != nil {
+			return req, 0, err
+		}
+		if orderQty <= original.cumQty {
+			return req, 0, fmt.Errorf("OrderQty %s does not exceed the %s already filled", msg.Get(TagOrderQty), strconv.FormatFloat(original.cumQty, 'f', -1, 64))
+		}
+		quantity = orderQty
+		working := orderQty - original.cumQty
+		req.Quantity = &working
+	}
+
+	if msg.Has(TagOrdType) {
+		ordType, err := orderType(msg.Get(TagOrdType))
+		if err != nil {
+			return req, 0, err
+		}
+		req.OrderType = &ordType
+	}
+	if msg.Has(TagPrice) {
+		price, err := msg.Float(TagPrice)
+		if err != nil {
+			return req, 0, err
+		}
+		req.LimitPrice = &price
+	}
+	if msg.Has(TagTimeInForce) {
+		tif, expireAt, err := timeInForce(msg)
+		if err != nil {
+			return req, 0, err
+		}
+		req.TimeInForce = &tif
+		req.ExpireAt = expireAt
+	}
+	return req, quantity, nil
+}
+
+// securityID prefers SecurityID when it is a CUSIP (SecurityIDSource 1) and
+// falls back to Symbol
+func securityID(msg *Message) string {
+	if id := msg.Get(TagSecurityID); id != "" && (msg.Get(TagSecurityIDSource) == "" || msg.Get(TagSecurityIDSource) == "1") {
+		return id
+	}
+	return msg.Get(TagSymbol)
+}
+
+func orderSide(value string) (oms.OrderSide, error) {
+	switch value {
+	case "1":
+		return oms.OrderSideBuy, nil
+	case "2", "5":
+		// Sell short (5) is a sell; whether the account may go short is a compliance decision
+		return oms.OrderSideSell, nil
+	}
+	return "", fmt.Errorf("unsupported Side %q", value)
+}
+
+func orderType(value string) (oms.OrderType, error) {
+	switch value {
+	case "1":
+		return oms.OrderTypeMarket, nil
+	case "2":
+		return oms.OrderTypeLimit, nil
+	}
+	return "", fmt.Errorf("unsupported OrdType %q", value)
+}
+
+func timeInForce(msg *Message) (oms.TimeInForce, *time.Time, error) {
+	switch msg.Get(TagTimeInForce) {
+	case "", "0":
+		return oms.TimeInForceDay, nil, nil
+	case "1":
+		return oms.TimeInForceGTC, nil, nil
+	case "3":
+		return oms.TimeInForceIOC, nil, nil
+	case "6":
+		expireAt, err := parseUTCTimestamp(msg.Get(TagExpireTime))
+		if err != nil {
+			return "", nil, errors.New("ExpireTime is required on GTD orders")
+		}
+		return oms.TimeInForceGTD, &expireAt, nil
+	}
+	return "", nil, fmt.Errorf("unsupported TimeInForce %q", msg.Get(TagTimeInForce))
+}
+
+func parseUTCTimestamp(value string) (time.Time, error) {
+	for _, layout := range []string{sendingTimeFormat, "20060102-15:04:05"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid UTCTimestamp %q", value)
+}
+
+func stringValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+}
+
+func floatValue(value interface{}) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case string:
+		parsed, _ := strconv.ParseFloat(v, 64)
+		return parsed
+	}
+	return 0
+}
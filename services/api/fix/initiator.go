@@ -0,0 +1,96 @@
+package fix
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"time"
+)
+
+// InitiatorConfig configures a FIX initiator
+type InitiatorConfig struct {
+	Address      string
+	SenderCompID string
+	TargetCompID string
+	HeartBtInt   time.Duration // defaults to DefaultHeartBtInt
+	ResetSeqNum  bool          // start both sides' sequence numbers at 1
+}
+
+// Initiator is a client-side FIX session, enough to drive the acceptor from
+// tests and local tools. Application messages it receives are delivered on
+// Messages.
+type Initiator struct {
+	*Session
+	Messages <-chan *Message
+}
+
+// Dial connects to an acceptor and logs on
+func Dial(cfg InitiatorConfig) (*Initiator, error) {
+	return dial(cfg, newSessionStore())
+}
+
+func dial(cfg InitiatorConfig, store *sessionStore) (*Initiator, error) {
+	if cfg.HeartBtInt <= 0 {
+		cfg.HeartBtInt = DefaultHeartBtInt
+	}
+
+	conn, err := net.Dial("tcp", cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", cfg.Address, err)
+	}
+
+	messages := make(chan *Message, 1000)
+	handler := func(_ *Session, msg *Message) {
+		select {
+		case messages <- msg:
+		default:
+		}
+	}
+
+	if cfg.ResetSeqNum {
+		store.reset()
+	}
+	reader := bufio.NewReader(conn)
+	session := newSession(conn, reader, cfg.SenderCompID, cfg.TargetCompID, cfg.HeartBtInt, store, handler)
+	if err := session.Send(logonMessage(cfg.HeartBtInt, cfg.ResetSeqNum)); err != nil {
+		return nil, err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(logonTimeout))
+	data, err := readMessage(reader)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("no Logon response: %w", err)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	logon, err := ParseMessage(data)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if logon.Type() != MsgTypeLogon {
+		conn.Close()
+		return nil, fmt.Errorf("%w: got MsgType %s: %s", ErrLogonRequired, logon.Type(), logon.Get(TagText))
+	}
+
+	gap, err := acceptLogonSequence(logon, store)
+	if err != nil {
+		session.sendLogout(err.Error())
+		session.Close()
+		return nil, err
+	}
+	if gap {
+		seq, _ := logon.Int(TagMsgSeqNum)
+		session.requestResend(store.expectedIn(), seq)
+	}
+
+	go session.serve()
+	return &Initiator{Session: session, Messages: messages}, nil
+}
+
+// Reconnect logs on again after a disconnect, continuing the same sequence numbers
+func (i *Initiator) Reconnect(cfg InitiatorConfig) (*Initiator, error) {
+	cfg.ResetSeqNum = false
+	return dial(cfg, i.store)
+}
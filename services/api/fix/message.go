@@ -0,0 +1,356 @@
+package fix
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BeginString is the protocol version the gateway speaks
+const BeginString = "FIX.4.4"
+
+const soh = '\x01'
+
+// sendingTimeFormat is the UTCTimestamp format with milliseconds
+const sendingTimeFormat = "20060102-15:04:05.000"
+
+// Session-level tags
+const (
+	TagBeginSeqNo           = 7
+	TagBeginString          = 8
+	TagBodyLength           = 9
+	TagCheckSum             = 10
+	TagEndSeqNo             = 16
+	TagMsgSeqNum            = 34
+	TagMsgType              = 35
+	TagNewSeqNo             = 36
+	TagPossDupFlag          = 43
+	TagRefSeqNum            = 45
+	TagSenderCompID         = 49
+	TagSendingTime          = 52
+	TagTargetCompID         = 56
+	TagText                 = 58
+	TagEncryptMethod        = 98
+	TagHeartBtInt           = 108
+	TagTestReqID            = 112
+	TagOrigSendingTime      = 122
+	TagGapFillFlag          = 123
+	TagResetSeqNumFlag      = 141
+	TagRefTagID             = 371
+	TagRefMsgType           = 372
+	TagSessionRejectReason  = 373
+	TagBusinessRejectReason = 380
+)
+
+// Application tags
+const (
+	TagAccount          = 1
+	TagAvgPx            = 6
+	TagClOrdID          = 11
+	TagCumQty           = 14
+	TagExecID           = 17
+	TagSecurityIDSource = 22
+	TagLastPx           = 31
+	TagLastQty          = 32
+	TagOrderID          = 37
+	TagOrderQty         = 38
+	TagOrdStatus        = 39
+	TagOrdType          = 40
+	TagOrigClOrdID      = 41
+	TagPrice            = 44
+	TagSecurityID       = 48
+	TagSide             = 54
+	TagSymbol           = 55
+	TagTimeInForce      = 59
+	TagTransactTime     = 60
+	TagCxlRejReason     = 102
+	TagOrdRejReason     = 103
+	TagExpireTime       = 126
+	TagExecType         = 150
+	TagLeavesQty        = 151
+	TagCxlRejResponseTo = 434
+)
+
+// Message types
+const (
+	MsgTypeHeartbeat                 = "0"
+	MsgTypeTestRequest               = "1"
+	MsgTypeResendRequest             = "2"
+	MsgTypeReject                    = "3"
+	MsgTypeSequenceReset             = "4"
+	MsgTypeLogout                    = "5"
+	MsgTypeExecutionReport           = "8"
+	MsgTypeOrderCancelReject         = "9"
+	MsgTypeLogon                     = "A"
+	MsgTypeNewOrderSingle            = "D"
+	MsgTypeOrderCancelRequest        = "F"
+	MsgTypeOrderCancelReplaceRequest = "G"
+	MsgTypeBusinessMessageReject     = "j"
+)
+
+var (
+	// ErrGarbled is returned for data that is not a well-formed FIX message
+	ErrGarbled = errors.New("garbled FIX message")
+	// ErrBadChecksum is returned when a message's CheckSum does not match its bytes
+	ErrBadChecksum = errors.New("FIX message checksum mismatch")
+)
+
+// headerTags are written, when present, right after MsgType in this order
+var headerTags = []int{TagSenderCompID, TagTargetCompID, TagMsgSeqNum, TagPossDupFlag, TagSendingTime, TagOrigSendingTime}
+
+// Field is one tag=value pair
+type Field struct {
+	Tag   int
+	Value string
+}
+
+// Message is a FIX message without its BeginString, BodyLength and CheckSum,
+// which are computed when it is written
+type Message struct {
+	Fields []Field
+}
+
+// NewMessage starts a message of the given type
+func NewMessage(msgType string) *Message {
+	return &Message{Fields: []Field{{Tag: TagMsgType, Value: msgType}}}
+}
+
+// Type returns the message's MsgType
+func (m *Message) Type() string {
+	return m.Get(TagMsgType)
+}
+
+// Get returns the value of a tag, or "" if it is not set
+func (m *Message) Get(tag int) string {
+	for _, field := range m.Fields {
+		if field.Tag == tag {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// Has reports whether a tag is set
+func (m *Message) Has(tag int) bool {
+	for _, field := range m.Fields {
+		if field.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Int returns a tag's value as an integer
+func (m *Message) Int(tag int) (int, error) {
+	value, err := strconv.Atoi(m.Get(tag))
+	if err != nil {
+		return 0, fmt.Errorf("tag %d: %q is not an integer", tag, m.Get(tag))
+	}
+	return value, nil
+}
+
+// Float returns a tag's value as a number
+func (m *Message) Float(tag int) (float64, error) {
+	value, err := strconv.ParseFloat(m.Get(tag), 64)
+	if err != nil {
+		return 0, fmt.Errorf("tag %d: %q is not a number", tag, m.Get(tag))
+	}
+	return value, nil
+}
+
+// Set sets a tag, replacing an existing value
+func (m *Message) Set(tag int, value string) *Message {
+	for i, field := range m.Fields {
+		if field.Tag == tag {
+			m.Fields[i].Value = value
+			return m
+		}
+	}
+	m.Fields = append(m.Fields, Field{Tag: tag, Value: value})
+	return m
+}
+
+// SetInt sets a tag to an integer
+func (m *Message) SetInt(tag int, value int) *Message {
+	return m.Set(tag, strconv.Itoa(value))
+}
+
+// SetFloat sets a tag to a number without trailing zeros
+func (m *Message) SetFloat(tag int, value float64) *Message {
+	return m.Set(tag, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// SetTime sets a tag to a UTCTimestamp
+func (m *Message) SetTime(tag int, value time.Time) *Message {
+	return m.Set(tag, value.UTC().Format(sendingTimeFormat))
+}
+
+// Remove clears a tag
+func (m *Message) Remove(tag int) *Message {
+	fields := m.Fields[:0]
+	for _, field := range m.Fields {
+		if field.Tag != tag {
+			fields = append(fields, field)
+		}
+	}
+	m.Fields = fields
+	return m
+}
+
+// Clone returns a copy that can be changed without affecting m
+func (m *Message) Clone() *Message {
+	return &Message{Fields: append([]Field(nil), m.Fields...)}
+}
+
+// Bytes encodes the message with BeginString, BodyLength and CheckSum.
+// MsgType and the standard header fields come first, then the body in the
+// order it was set.
+func (m *Message) Bytes() []byte {
+	var body bytes.Buffer
+	writeField := func(tag int, value string) {
+		body.WriteString(strconv.Itoa(tag))
+		body.WriteByte('=')
+		body.WriteString(value)
+		body.WriteByte(soh)
+	}
+
+	writeField(TagMsgType, m.Type())
+	for _, tag := range headerTags {
+		if m.Has(tag) {
+			writeField(tag, m.Get(tag))
+		}
+	}
+	for _, field := range m.Fields {
+		if field.Tag == TagMsgType || isHeaderTag(field.Tag) {
+			continue
+		}
+		writeField(field.Tag, field.Value)
+	}
+
+	var out bytes.Buffer
+	out.WriteString(fmt.Sprintf("8=%s\x019=%d\x01", BeginString, body.Len()))
+	out.Write(body.Bytes())
+	out.WriteString(fmt.Sprintf("10=%03d\x01", checksum(out.Bytes())))
+	return out.Bytes()
+}
+
+// String renders the message with | in place of SOH, for logs
+func (m *Message) String() string {
+	return strings.ReplaceAll(string(m.Bytes()), string(soh), "|")
+}
+
+func isHeaderTag(tag int) bool {
+	for _, header := range headerTags {
+		if header == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func checksum(data []byte) int {
+	sum := 0
+	for _, b := range data {
+		sum += int(b)
+	}
+	return sum % 256
+}
+
+// ParseMessage decodes one complete message, checking BeginString,
+// BodyLength and CheckSum
+func ParseMessage(data []byte) (*Message, error) {
+	if !bytes.HasPrefix(data, []byte("8="+BeginString+"\x01")) {
+		return nil, fmt.Errorf("%w: expected BeginString %s", ErrGarbled, BeginString)
+	}
+	if len(data) < 7 || data[len(data)-1] != soh {
+		return nil, fmt.Errorf("%w: message is not terminated", ErrGarbled)
+	}
+
+	trailerStart := bytes.LastIndex(data[:len(data)-1], []byte{soh}) + 1
+	trailer := string(data[trailerStart : len(data)-1])
+	if !strings.HasPrefix(trailer, "10=") {
+		return nil, fmt.Errorf("%w: missing CheckSum", ErrGarbled)
+	}
+	want, err := strconv.Atoi(strings.TrimPrefix(trailer, "10="))
+	if err != nil || want != checksum(data[:trailerStart]) {
+		return nil, ErrBadChecksum
+	}
+
+	message := &Message{}
+	bodyStart := 0
+	for i, raw := range strings.Split(string(data[:trailerStart-1]), string(soh)) {
+		separator := strings.IndexByte(raw, '=')
+		if separator <= 0 {
+			return nil, fmt.Errorf("%w: field %q", ErrGarbled, raw)
+		}
+		tag, err := strconv.Atoi(raw[:separator])
+		if err != nil {
+			return nil, fmt.Errorf("%w: tag %q", ErrGarbled, raw[:separator])
+		}
+		value := raw[separator+1:]
+
+		switch {
+		case i == 0 && tag == TagBeginString:
+			bodyStart += len(raw) + 1
+		case i == 1 && tag == TagBodyLength:
+			bodyStart += len(raw) + 1
+			length, err := strconv.Atoi(value)
+			if err != nil || length != trailerStart-bodyStart {
+				return nil, fmt.Errorf("%w: BodyLength %s does not match", ErrGarbled, value)
+			}
+		case i < 2:
+			return nil, fmt.Errorf("%w: header must start with BeginString and BodyLength", ErrGarbled)
+		case i == 2 && tag != TagMsgType:
+			return nil, fmt.Errorf("%w: MsgType must be the third field", ErrGarbled)
+		default:
+			message.Fields = append(message.Fields, Field{Tag: tag, Value: value})
+		}
+	}
+	if message.Type() == "" {
+		return nil, fmt.Errorf("%w: missing MsgType", ErrGarbled)
+	}
+	return message, nil
+}
+
+// readMessage reads the bytes of the next message from a stream, using
+// BodyLength to find its end
+func readMessage(reader *bufio.Reader) ([]byte, error) {
+	begin, err := reader.ReadBytes(soh)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(begin, []byte("8=")) {
+		return nil, fmt.Errorf("%w: expected BeginString, got %q", ErrGarbled, begin)
+	}
+
+	lengthField, err := reader.ReadBytes(soh)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(lengthField, []byte("9=")) {
+		return nil, fmt.Errorf("%w: expected BodyLength, got %q", ErrGarbled, lengthField)
+	}
+	length, err := strconv.Atoi(string(lengthField[2 : len(lengthField)-1]))
+	if err != nil || length < 0 || length > maxBodyLength {
+		return nil, fmt.Errorf("%w: BodyLength %q", ErrGarbled, lengthField)
+	}
+
+	// Body plus the 7-byte trailer "10=nnn<SOH>"
+	rest := make([]byte, length+7)
+	if _, err := io.ReadFull(reader, rest); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 0, len(begin)+len(lengthField)+len(rest))
+	data = append(data, begin...)
+	data = append(data, lengthField...)
+	return append(data, rest...), nil
+}
+
+// maxBodyLength bounds what a counterparty can make the gateway allocate
+const maxBodyLength = 64 * 1024
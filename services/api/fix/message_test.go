@@ -0,0 +1,40 @@
+package fix
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	msg := NewMessage(MsgTypeNewOrderSingle).
+		Set(TagClOrdID, "c1").
+		Set(TagSenderCompID, "DESK").
+		Set(TagTargetCompID, "INSTANT").
+		SetInt(TagMsgSeqNum, 7).
+		SetFloat(TagOrderQty, 250000)
+	data := msg.Bytes()
+
+	if !strings.HasPrefix(string(data), "8=FIX.4.4\x019=") || !strings.Contains(string(data), "\x0135=D\x0149=DESK\x0156=INSTANT\x0134=7\x01") {
+		t.Fatalf("unexpected header order: %s", msg)
+	}
+
+	read, err := readMessage(bufio.NewReader(bytes.NewReader(append(data, data...))))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	parsed, err := ParseMessage(read)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if parsed.Type() != MsgTypeNewOrderSingle || parsed.Get(TagClOrdID) != "c1" || parsed.Get(TagOrderQty) != "250000" {
+		t.Fatalf("unexpected message: %s", parsed)
+	}
+
+	corrupted := bytes.Replace(data, []byte("11=c1"), []byte("11=c2"), 1)
+	if _, err := ParseMessage(corrupted); !errors.Is(err, ErrBadChecksum) {
+		t.Fatalf("expected ErrBadChecksum, got %v", err)
+	}
+}
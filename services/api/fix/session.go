@@ -0,0 +1,548 @@
+package fix
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrLogonRequired is returned when a connection opens with anything but a Logon
+	ErrLogonRequired = errors.New("first message must be a Logon")
+	// ErrCompIDMismatch is returned when a message is not addressed from and to the session's CompIDs
+	ErrCompIDMismatch = errors.New("incorrect SenderCompID or TargetCompID")
+	// ErrSeqNumTooLow is returned when a message that is not a possible duplicate repeats a sequence number
+	ErrSeqNumTooLow = errors.New("MsgSeqNum too low")
+	// ErrHeartbeatTimeout is returned when the counterparty stops answering test requests
+	ErrHeartbeatTimeout = errors.New("counterparty stopped responding to test requests")
+	// ErrSessionClosed is returned when sending on a session that has disconnected
+	ErrSessionClosed = errors.New("FIX session is closed")
+
+	errLoggedOut = errors.New("logged out")
+)
+
+// SessionRejectReason values used by the gateway
+const (
+	rejectRequiredTagMissing = 1
+	rejectValueIncorrect     = 5
+	rejectCompIDProblem      = 9
+)
+
+// DefaultHeartBtInt is the heartbeat interval the initiator proposes
+const DefaultHeartBtInt = 30 * time.Second
+
+// sessionStore holds a session's sequence numbers and the messages it has
+// sent. It outlives a connection, so a counterparty that reconnects carries on
+// from the same sequence numbers and can ask for what it missed. When it has a
+// backing Store it writes every change through, so the session also outlives
+// the process.
+type sessionStore struct {
+	mu      sync.Mutex
+	nextOut int
+	nextIn  int
+	sent    map[int]*Message
+
+	compID  string
+	backing Store
+}
+
+func newSessionStore() *sessionStore {
+	return &sessionStore{nextOut: 1, nextIn: 1, sent: map[int]*Message{}}
+}
+
+// openSessionStore loads a counterparty's session from the backing store
+func openSessionStore(backing Store, compID string) (*sessionStore, error) {
+	state, err := backing.loadSession(compID)
+	if err != nil {
+		return nil, err
+	}
+	return &sessionStore{
+		nextOut: state.nextOut,
+		nextIn:  state.nextIn,
+		sent:    state.sent,
+		compID:  compID,
+		backing: backing,
+	}, nil
+}
+
+func (st *sessionStore) reset() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.nextOut = 1
+	st.nextIn = 1
+	st.sent = map[int]*Message{}
+	if st.backing != nil {
+		if err := st.backing.resetSession(st.compID); err != nil {
+			log.Printf("FIX: %v", err)
+		}
+	}
+}
+
+func (st *sessionStore) expectedIn() int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.nextIn
+}
+
+func (st *sessionStore) setNextIn(seq int) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.nextIn = seq
+	if st.backing != nil {
+		if err := st.backing.saveNextIn(st.compID, seq); err != nil {
+			log.Printf("FIX: %v", err)
+		}
+	}
+}
+
+// record stamps a message with the next outgoing sequence number and keeps
+// it for resends
+func (st *sessionStore) record(msg *Message, senderCompID, targetCompID string) *Message {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	out := msg.Clone()
+	stampHeader(out, senderCompID, targetCompID, st.nextOut, time.Now())
+	st.sent[st.nextOut] = out
+	if st.backing != nil {
+		if err := st.backing.saveSent(st.compID, st.nextOut, out); err != nil {
+			log.Printf("FIX: %v", err)
+		}
+	}
+	st.nextOut++
+	return out
+}
+
+// sentRange returns the stored messages from begin through end (0 means the
+// last one sent) and the last sequence number covered
+func (st *sessionStore) sentRange(begin, end int) (map[int]*Message, int) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	last := st.nextOut - 1
+	if end == 0 || end > last {
+		end = last
+	}
+	messages := map[int]*Message{}
+	for seq := begin; seq <= end; seq++ {
+		if msg, ok := st.sent[seq]; ok {
+			messages[seq] = msg
+		}
+	}
+	return messages, end
+}
+
+func stampHeader(msg *Message, senderCompID, targetCompID string, seq int, sendingTime time.Time) {
+	msg.Set(TagSenderCompID, senderCompID)
+	msg.Set(TagTargetCompID, targetCompID)
+	msg.SetInt(TagMsgSeqNum, seq)
+	msg.SetTime(TagSendingTime, sendingTime)
+}
+
+// isAdminMessage reports whether a message type belongs to the session layer.
+// Admin messages are never resent; a gap fill covers them instead.
+func isAdminMessage(msgType string) bool {
+	switch msgType {
+	case MsgTypeHeartbeat, MsgTypeTestRequest, MsgTypeResendRequest, MsgTypeReject, MsgTypeSequenceReset, MsgTypeLogout, MsgTypeLogon:
+		return true
+	}
+	return false
+}
+
+// Session is one logged-on FIX connection. It answers heartbeats and test
+// requests, checks incoming sequence numbers, asks for resends when it sees a
+// gap and answers the counterparty's resend requests. Application messages
+// are passed to the handler in sequence.
+type Session struct {
+	SenderCompID string
+	TargetCompID string
+	HeartBtInt   time.Duration
+
+	conn    net.Conn
+	reader  *bufio.Reader
+	store   *sessionStore
+	handler func(*Session, *Message)
+
+	writeMu sync.Mutex
+
+	activityMu        sync.Mutex
+	lastSent          time.Time
+	lastReceived      time.Time
+	testRequestSentAt time.Time
+	resendUntil       int
+	logoutSent        bool
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func newSession(conn net.Conn, reader *bufio.Reader, senderCompID, targetCompID string, heartBtInt time.Duration, store *sessionStore, handler func(*Session, *Message)) *Session {
+	now := time.Now()
+	return &Session{
+		SenderCompID: senderCompID,
+		TargetCompID: targetCompID,
+		HeartBtInt:   heartBtInt,
+		conn:         conn,
+		reader:       reader,
+		store:        store,
+		handler:      handler,
+		lastSent:     now,
+		lastReceived: now,
+		done:         make(chan struct{}),
+	}
+}
+
+// Send assigns the next sequence number to a message and writes it
+func (s *Session) Send(msg *Message) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	select {
+	case <-s.done:
+		return ErrSessionClosed
+	default:
+	}
+	return s.write(s.store.record(msg, s.SenderCompID, s.TargetCompID))
+}
+
+// Logout ends the session, waiting briefly for the counterparty to confirm
+func (s *Session) Logout(text string) {
+	s.sendLogout(text)
+	select {
+	case <-s.done:
+	case <-time.After(s.HeartBtInt):
+		s.Close()
+	}
+}
+
+// Close drops the connection without a Logout
+func (s *Session) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.conn.Close()
+	})
+}
+
+// Done is closed when the session ends
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// write sends an already stamped message; callers hold writeMu
+func (s *Session) write(msg *Message) error {
+	if _, err := s.conn.Write(msg.Bytes()); err != nil {
+		s.Close()
+		return fmt.Errorf("failed to write %s message: %w", msg.Type(), err)
+	}
+	s.activityMu.Lock()
+	s.lastSent = time.Now()
+	s.activityMu.Unlock()
+	return nil
+}
+
+func (s *Session) sendLogout(text string) {
+	s.activityMu.Lock()
+	alreadySent := s.logoutSent
+	s.logoutSent = true
+	s.activityMu.Unlock()
+	if alreadySent {
+		return
+	}
+
+	logout := NewMessage(MsgTypeLogout)
+	if text != "" {
+		logout.Set(TagText, text)
+	}
+	s.Send(logout)
+}
+
+func (s *Session) sendReject(ref *Message, reason int, refTag int, text string) {
+	reject := NewMessage(MsgTypeReject).
+		Set(TagRefSeqNum, ref.Get(TagMsgSeqNum)).
+		Set(TagRefMsgType, ref.Type()).
+		SetInt(TagSessionRejectReason, reason).
+		Set(TagText, text)
+	if refTag > 0 {
+		reject.SetInt(TagRefTagID, refTag)
+	}
+	s.Send(reject)
+}
+
+// serve reads messages until the connection drops or either side logs out
+func (s *Session) serve() error {
+	defer s.Close()
+	go s.monitor()
+
+	for {
+		msg, err := s.read()
+		if err != nil {
+			select {
+			case <-s.done:
+				return nil
+			default:
+				return err
+			}
+		}
+		if err := s.receive(msg); err != nil {
+			if errors.Is(err, errLoggedOut) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// read returns the next well-formed message. Garbled messages are dropped
+// without consuming a sequence number, so the gap is detected and resent.
+func (s *Session) read() (*Message, error) {
+	for {
+		data, err := readMessage(s.reader)
+		if err != nil {
+			return nil, err
+		}
+		msg, err := ParseMessage(data)
+		if err != nil {
+			log.Printf("FIX %s->%s: dropping message: %v", s.TargetCompID, s.SenderCompID, err)
+			continue
+		}
+
+		s.activityMu.Lock()
+		s.lastReceived = time.Now()
+		s.testRequestSentAt = time.Time{}
+		s.activityMu.Unlock()
+		return msg, nil
+	}
+}
+
+// receive checks a message's CompIDs and sequence number before handling it
+func (s *Session) receive(msg *Message) error {
+	if msg.Get(TagSenderCompID) != s.TargetCompID || msg.Get(TagTargetCompID) != s.SenderCompID {
+		s.sendReject(msg, rejectCompIDProblem, 0, ErrCompIDMismatch.Error())
+		s.sendLogout(ErrCompIDMismatch.Error())
+		return ErrCompIDMismatch
+	}
+
+	seq, err := msg.Int(TagMsgSeqNum)
+	if err != nil {
+		s.sendLogout("MsgSeqNum missing or invalid")
+		return err
+	}
+
+	// A SequenceReset in reset mode applies whatever its own sequence number
+	if msg.Type() == MsgTypeSequenceReset && msg.Get(TagGapFillFlag) != "Y" {
+		return s.applySequenceReset(msg)
+	}
+
+	expected := s.store.expectedIn()
+	switch {
+	case seq > expected:
+		// Honour the counterparty's own resend request before filling our gap
+		if msg.Type() == MsgTypeResendRequest {
+			s.handleResendRequest(msg)
+		}
+		s.requestResend(expected, seq)
+		return nil
+	case seq < expected:
+		if msg.Get(TagPossDupFlag) == "Y" {
+			return nil
+		}
+		text := fmt.Sprintf("MsgSeqNum too low, expecting %d but received %d", expected, seq)
+		s.sendLogout(text)
+		return fmt.Errorf("%w: %s", ErrSeqNumTooLow, text)
+	}
+
+	s.store.setNextIn(seq + 1)
+	return s.dispatch(msg)
+}
+
+func (s *Session) dispatch(msg *Message) error {
+	switch msg.Type() {
+	case MsgTypeHeartbeat, MsgTypeReject:
+		if msg.Type() == MsgTypeReject {
+			log.Printf("FIX %s->%s: session reject of seq %s: %s", s.TargetCompID, s.SenderCompID, msg.Get(TagRefSeqNum), msg.Get(TagText))
+		}
+	case MsgTypeTestRequest:
+		s.Send(NewMessage(MsgTypeHeartbeat).Set(TagTestReqID, msg.Get(TagTestReqID)))
+	case MsgTypeResendRequest:
+		s.handleResendRequest(msg)
+	case MsgTypeSequenceReset:
+		return s.applySequenceReset(msg)
+	case MsgTypeLogout:
+		s.sendLogout("")
+		return errLoggedOut
+	case MsgTypeLogon:
+		s.sendReject(msg, rejectValueIncorrect, TagMsgType, "session is already logged on")
+	default:
+		if s.handler != nil {
+			s.handler(s, msg)
+		}
+	}
+	return nil
+}
+
+// applySequenceReset moves the expected incoming sequence number forward.
+// Moving it back is refused.
+func (s *Session) applySequenceReset(msg *Message) error {
+	newSeq, err := msg.Int(TagNewSeqNo)
+	if err != nil {
+		s.sendReject(msg, rejectRequiredTagMissing, TagNewSeqNo, "NewSeqNo is required")
+		return nil
+	}
+	if newSeq < s.store.expectedIn() {
+		s.sendReject(msg, rejectValueIncorrect, TagNewSeqNo, "NewSeqNo may not decrease the expected sequence number")
+		return nil
+	}
+	s.store.setNextIn(newSeq)
+	return nil
+}
+
+// requestResend asks for everything from the first missing sequence number,
+// unless a resend covering it is already outstanding
+func (s *Session) requestResend(expected, received int) {
+	s.activityMu.Lock()
+	outstanding := s.resendUntil >= expected
+	if received > s.resendUntil {
+		s.resendUntil = received
+	}
+	s.activityMu.Unlock()
+	if outstanding {
+		return
+	}
+
+	s.Send(NewMessage(MsgTypeResendRequest).
+		SetInt(TagBeginSeqNo, expected).
+		SetInt(TagEndSeqNo, 0))
+}
+
+// handleResendRequest resends stored application messages as possible
+// duplicates and replaces runs of admin messages with gap fills
+func (s *Session) handleResendRequest(msg *Message) {
+	begin, err := msg.Int(TagBeginSeqNo)
+	if err != nil || begin < 1 {
+		s.sendReject(msg, rejectRequiredTagMissing, TagBeginSeqNo, "BeginSeqNo is required")
+		return
+	}
+	end, err := msg.Int(TagEndSeqNo)
+	if err != nil {
+		end = 0
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	messages, last := s.store.sentRange(begin, end)
+	now := time.Now()
+	gapStart := 0
+	flushGap := func(next int) {
+		if gapStart == 0 {
+			return
+		}
+		gapFill := NewMessage(MsgTypeSequenceReset).
+			Set(TagPossDupFlag, "Y").
+			Set(TagGapFillFlag, "Y").
+			SetInt(TagNewSeqNo, next)
+		stampHeader(gapFill, s.SenderCompID, s.TargetCompID, gapStart, now)
+		s.write(gapFill)
+		gapStart = 0
+	}
+
+	for seq := begin; seq <= last; seq++ {
+		original, ok := messages[seq]
+		if !ok || isAdminMessage(original.Type()) {
+			if gapStart == 0 {
+				gapStart = seq
+			}
+			continue
+		}
+		flushGap(seq)
+
+		duplicate := original.Clone()
+		duplicate.Set(TagPossDupFlag, "Y")
+		duplicate.Set(TagOrigSendingTime, original.Get(TagSendingTime))
+		duplicate.SetTime(TagSendingTime, now)
+		s.write(duplicate)
+	}
+	flushGap(last + 1)
+}
+
+// monitor sends heartbeats when the session is quiet, a test request when
+// the counterparty is, and disconnects if the test request goes unanswered
+func (s *Session) monitor() {
+	tick := s.HeartBtInt / 4
+	if tick < 10*time.Millisecond {
+		tick = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.activityMu.Lock()
+			sinceSent := now.Sub(s.lastSent)
+			sinceReceived := now.Sub(s.lastReceived)
+			testRequestSentAt := s.testRequestSentAt
+			s.activityMu.Unlock()
+
+			switch {
+			case !testRequestSentAt.IsZero() && now.Sub(testRequestSentAt) >= s.HeartBtInt:
+				log.Printf("FIX %s->%s: %v", s.SenderCompID, s.TargetCompID, ErrHeartbeatTimeout)
+				s.Close()
+				return
+			case testRequestSentAt.IsZero() && sinceReceived >= s.HeartBtInt+s.HeartBtInt/5:
+				s.activityMu.Lock()
+				s.testRequestSentAt = now
+				s.activityMu.Unlock()
+				s.Send(NewMessage(MsgTypeTestRequest).Set(TagTestReqID, strconv.FormatInt(now.UnixNano(), 10)))
+			case sinceSent >= s.HeartBtInt:
+				s.Send(NewMessage(MsgTypeHeartbeat))
+			}
+		}
+	}
+}
+
+// logonMessage builds the Logon that opens or acknowledges a session
+func logonMessage(heartBtInt time.Duration, resetSeqNum bool) *Message {
+	logon := NewMessage(MsgTypeLogon).
+		SetInt(TagEncryptMethod, 0).
+		SetInt(TagHeartBtInt, int(heartBtInt/time.Second))
+	if resetSeqNum {
+		logon.Set(TagResetSeqNumFlag, "Y")
+	}
+	return logon
+}
+
+// acceptLogonSequence checks the sequence number of a counterparty's Logon.
+// A Logon ahead of the expected number is accepted and the gap requested
+// once the session is running.
+func acceptLogonSequence(logon *Message, store *sessionStore) (gap bool, err error) {
+	seq, err := logon.Int(TagMsgSeqNum)
+	if err != nil {
+		return false, err
+	}
+	expected := store.expectedIn()
+	switch {
+	case seq < expected:
+		return false, fmt.Errorf("%w: expecting %d but received %d", ErrSeqNumTooLow, expected, seq)
+	case seq > expected:
+		return true, nil
+	}
+	store.setNextIn(seq + 1)
+	return false, nil
+}
+
+// heartBtIntFromLogon reads HeartBtInt (in seconds) from a Logon
+func heartBtIntFromLogon(logon *Message) (time.Duration, error) {
+	seconds, err := logon.Int(TagHeartBtInt)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("HeartBtInt must be a positive number of seconds, got %q", logon.Get(TagHeartBtInt))
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
@@ -0,0 +1,317 @@
+package fix
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Store keeps the acceptor's state where a restarted acceptor, or the one
+// that takes over on another instance, finds it: each counterparty's sequence
+// numbers and sent messages, the orders entered over FIX by ClOrdID, and the
+// executions working them.
+type Store interface {
+	loadSession(compID string) (*sessionState, error)
+	saveSent(compID string, seq int, msg *Message) error
+	saveNextIn(compID string, nextIn int) error
+	resetSession(compID string) error
+
+	loadOrders() ([]*fixOrder, map[string]string, error)
+	saveOrder(order *fixOrder) error
+	saveExecution(executionID, orderID string) error
+}
+
+// sessionState is a counterparty's stored sequence numbers and sent messages
+type sessionState struct {
+	nextOut int
+	nextIn  int
+	sent    map[int]*Message
+}
+
+func newSessionState() *sessionState {
+	return &sessionState{nextOut: 1, nextIn: 1, sent: map[int]*Message{}}
+}
+
+// memoryStore keeps the acceptor's state for the life of the process
+type memoryStore struct {
+	mu         sync.Mutex
+	sessions   map[string]*sessionState
+	orders     map[string]fixOrder
+	executions map[string]string
+}
+
+// NewMemoryStore returns a store that keeps state in memory, for tests and
+// single-process tools
+func NewMemoryStore() Store {
+	return &memoryStore{
+		sessions:   map[string]*sessionState{},
+		orders:     map[string]fixOrder{},
+		executions: map[string]string{},
+	}
+}
+
+func (m *memoryStore) session(compID string) *sessionState {
+	state, ok := m.sessions[compID]
+	if !ok {
+		state = newSessionState()
+		m.sessions[compID] = state
+	}
+	return state
+}
+
+func (m *memoryStore) loadSession(compID string) (*sessionState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := m.session(compID)
+	loaded := &sessionState{nextOut: state.nextOut, nextIn: state.nextIn, sent: make(map[int]*Message, len(state.sent))}
+	for seq, msg := range state.sent {
+		loaded.sent[seq] = msg
+	}
+	return loaded, nil
+}
+
+func (m *memoryStore) saveSent(compID string, seq int, msg *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := m.session(compID)
+	state.sent[seq] = msg
+	if seq >= state.nextOut {
+		state.nextOut = seq + 1
+	}
+	return nil
+}
+
+func (m *memoryStore) saveNextIn(compID string, nextIn int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.session(compID).nextIn = nextIn
+	return nil
+}
+
+func (m *memoryStore) resetSession(compID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[compID] = newSessionState()
+	return nil
+}
+
+func (m *memoryStore) loadOrders() ([]*fixOrder, map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	orders := make([]*fixOrder, 0, len(m.orders))
+	for _, order := range m.orders {
+		order := order
+		orders = append(orders, &order)
+	}
+	executions := make(map[string]string, len(m.executions))
+	for executionID, orderID := range m.executions {
+		executions[executionID] = orderID
+	}
+	return orders, executions, nil
+}
+
+func (m *memoryStore) saveOrder(order *fixOrder) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orders[clOrdIDKey(order.compID, order.clOrdID)] = *order
+	return nil
+}
+
+func (m *memoryStore) saveExecution(executionID, orderID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.executions[executionID] = orderID
+	return nil
+}
+
+// postgresStore keeps the acceptor's state in the fix_sessions, fix_messages,
+// fix_orders and fix_executions tables
+type postgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore returns a store backed by the database
+func NewPostgresStore(db *sql.DB) Store {
+	return &postgresStore{db: db}
+}
+
+func (p *postgresStore) loadSession(compID string) (*sessionState, error) {
+	state := newSessionState()
+	err := p.db.QueryRow(`
+		SELECT "nextOutSeqNum", "nextInSeqNum" FROM fix_sessions WHERE "compId" = $1
+	`, compID).Scan(&state.nextOut, &state.nextIn)
+	if errors.Is(err, sql.ErrNoRows) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load FIX session %s: %w", compID, err)
+	}
+
+	rows, err := p.db.Query(`
+		SELECT "seqNum", message FROM fix_messages WHERE "compId" = $1
+	`, compID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load FIX messages for %s: %w", compID, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var seq int
+		var raw string
+		if err := rows.Scan(&seq, &raw); err != nil {
+			return nil, fmt.Errorf("failed to scan FIX message: %w", err)
+		}
+		msg, err := ParseMessage([]byte(raw))
+		if err != nil {
+			return nil, fmt.Errorf("stored FIX message %s/%d: %w", compID, seq, err)
+		}
+		state.sent[seq] = msg
+	}
+	return state, rows.Err()
+}
+
+// saveSent stores a sent message and moves the outgoing sequence number past it
+func (p *postgresStore) saveSent(compID string, seq int, msg *Message) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	if _, err := tx.Exec(`
+		INSERT INTO fix_messages ("compId", "seqNum", message, "sentAt")
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT ("compId", "seqNum") DO UPDATE SET message = EXCLUDED.message, "sentAt" = EXCLUDED."sentAt"
+	`, compID, seq, string(msg.Bytes()), now); err != nil {
+		return fmt.Errorf("failed to store FIX message: %w", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO fix_sessions ("compId", "nextOutSeqNum", "nextInSeqNum", "updatedAt")
+		VALUES ($1, $2, 1, $3)
+		ON CONFLICT ("compId") DO UPDATE SET
+			"nextOutSeqNum" = GREATEST(fix_sessions."nextOutSeqNum", EXCLUDED."nextOutSeqNum"),
+			"updatedAt" = EXCLUDED."updatedAt"
+	`, compID, seq+1, now); err != nil {
+		return fmt.Errorf("failed to store FIX sequence number: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (p *postgresStore) saveNextIn(compID string, nextIn int) error {
+	_, err := p.db.Exec(`
+		INSERT INTO fix_sessions ("compId", "nextOutSeqNum", "nextInSeqNum", "updatedAt")
+		VALUES ($1, 1, $2, $3)
+		ON CONFLICT ("compId") DO UPDATE SET
+			"nextInSeqNum" = EXCLUDED."nextInSeqNum",
+			"updatedAt" = EXCLUDED."updatedAt"
+	`, compID, nextIn, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to store FIX sequence number: %w", err)
+	}
+	return nil
+}
+
+func (p *postgresStore) resetSession(compID string) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM fix_messages WHERE "compId" = $1`, compID); err != nil {
+		return fmt.Errorf("failed to clear FIX messages: %w", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO fix_sessions ("compId", "nextOutSeqNum", "nextInSeqNum", "updatedAt")
+		VALUES ($1, 1, 1, $2)
+		ON CONFLICT ("compId") DO UPDATE SET
+			"nextOutSeqNum" = 1, "nextInSeqNum" = 1, "updatedAt" = EXCLUDED."updatedAt"
+	`, compID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to reset FIX session: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (p *postgresStore) loadOrders() ([]*fixOrder, map[string]string, error) {
+	rows, err := p.db.Query(`
+		SELECT "compId", "clOrdId", COALESCE("origClOrdId", ''), COALESCE("cancelClOrdId", ''), "orderId",
+		       account, "securityId", side, quantity, "cumQty", notional, status
+		FROM fix_orders
+	`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load FIX orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []*fixOrder{}
+	for rows.Next() {
+		order := &fixOrder{}
+		if err := rows.Scan(
+			&order.compID, &order.clOrdID, &order.origClOrdID, &order.cancelClOrdID, &order.orderID,
+			&order.account, &order.securityID, &order.side, &order.quantity, &order.cumQty, &order.notional, &order.status,
+		); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan FIX order: %w", err)
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	executionRows, err := p.db.Query(`SELECT "executionId", "orderId" FROM fix_executions`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load FIX executions: %w", err)
+	}
+	defer executionRows.Close()
+
+	executions := map[string]string{}
+	for executionRows.Next() {
+		var executionID, orderID string
+		if err := executionRows.Scan(&executionID, &orderID); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan FIX execution: %w", err)
+		}
+		executions[executionID] = orderID
+	}
+	return orders, executions, executionRows.Err()
+}
+
+func (p *postgresStore) saveOrder(order *fixOrder) error {
+	_, err := p.db.Exec(`
+		INSERT INTO fix_orders (
+			"compId", "clOrdId", "origClOrdId", "cancelClOrdId", "orderId",
+			account, "securityId", side, quantity, "cumQty", notional, status, "updatedAt"
+		) VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT ("compId", "clOrdId") DO UPDATE SET
+			"cancelClOrdId" = EXCLUDED."cancelClOrdId",
+			"cumQty" = EXCLUDED."cumQty",
+			notional = EXCLUDED.notional,
+			status = EXCLUDED.status,
+			"updatedAt" = EXCLUDED."updatedAt"
+	`,
+		order.compID, order.clOrdID, order.origClOrdID, order.cancelClOrdID, order.orderID,
+		order.account, order.securityID, order.side, order.quantity, order.cumQty, order.notional, order.status,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store FIX order %s: %w", order.clOrdID, err)
+	}
+	return nil
+}
+
+func (p *postgresStore) saveExecution(executionID, orderID string) error {
+	_, err := p.db.Exec(`
+		INSERT INTO fix_executions ("executionId", "orderId", "createdAt")
+		VALUES ($1, $2, $3)
+		ON CONFLICT ("executionId") DO NOTHING
+	`, executionID, orderID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to store FIX execution %s: %w", executionID, err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"instant/services/api/eventstore"
+	"instant/services/api/services/approval"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ApprovalCommandHandler handles approval policy commands
+type ApprovalCommandHandler struct {
+	service    *approval.Service
+	eventStore *eventstore.EventStore
+}
+
+// NewApprovalCommandHandler creates a new approval policy command handler
+func NewApprovalCommandHandler(service *approval.Service, eventStore *eventstore.EventStore) *ApprovalCommandHandler {
+	return &ApprovalCommandHandler{service: service, eventStore: eventStore}
+}
+
+type approvalPolicyRequest struct {
+	Name              string            `json:"name"`
+	Description       *string           `json:"description"`
+	Status            string            `json:"status"`
+	Scope             string            `json:"scope"`
+	ScopeID           *string           `json:"scopeId"`
+	Criteria          approval.Criteria `json:"criteria"`
+	RequiredApprovals int               `json:"requiredApprovals"`
+}
+
+func (r approvalPolicyRequest) input(actorID string) approval.PolicyInput {
+	return approval.PolicyInput{
+		Name:              r.Name,
+		Description:       r.Description,
+		Status:            r.Status,
+		Scope:             r.Scope,
+		ScopeID:           r.ScopeID,
+		Criteria:          r.Criteria,
+		RequiredApprovals: r.RequiredApprovals,
+		ActorID:           actorID,
+	}
+}
+
+// CreatePolicy handles creating an approval policy
+func (h *ApprovalCommandHandler) CreatePolicy(c *gin.Context) {
+	var req struct {
+		approvalPolicyRequest
+		CreatedBy string `json:"createdBy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := correlationIDFromHeader(c)
+
+	policyID, err := h.service.CreatePolicy(req.input(req.CreatedBy), correlationID)
+	if err != nil {
+		c.JSON(approvalPolicyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"policyId":      policyID,
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "created",
+	})
+}
+
+// UpdatePolicy handles replacing an approval policy's definition
+func (h *ApprovalCommandHandler) UpdatePolicy(c *gin.Context) {
+	policyID := c.Param("id")
+	var req struct {
+		approvalPolicyRequest
+		UpdatedBy string `json:"updatedBy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := correlationIDFromHeader(c)
+
+	version, err := h.service.UpdatePolicy(policyID, req.input(req.UpdatedBy), correlationID)
+	if err != nil {
+		c.JSON(approvalPolicyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policyId":      policyID,
+		"version":       version,
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "updated",
+	})
+}
+
+// DeletePolicy handles retiring an approval policy
+func (h *ApprovalCommandHandler) DeletePolicy(c *gin.Context) {
+	actorID := actorIDFromBody(c)
+	if actorID == "" {
+		return
+	}
+	correlationID := correlationIDFromHeader(c)
+
+	if err := h.service.DeletePolicy(c.Param("id"), actorID, correlationID); err != nil {
+		c.JSON(approvalPolicyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "deleted",
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+	})
+}
+
+func approvalPolicyErrorStatus(err error) int {
+	if errors.Is(err, approval.ErrPolicyNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
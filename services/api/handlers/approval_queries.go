@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"instant/services/api/services/approval"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ApprovalQueryHandler serves approval policies
+type ApprovalQueryHandler struct {
+	service *approval.Service
+}
+
+// NewApprovalQueryHandler creates a new approval policy query handler
+func NewApprovalQueryHandler(service *approval.Service) (*ApprovalQueryHandler, error) {
+	return &ApprovalQueryHandler{service: service}, nil
+}
+
+// GetPolicies lists approval policies that have not been deleted
+func (h *ApprovalQueryHandler) GetPolicies(c *gin.Context) {
+	policies, err := h.service.ListPolicies()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policies": policies,
+		"count":    len(policies),
+	})
+}
+
+// GetPolicyByID returns one approval policy
+func (h *ApprovalQueryHandler) GetPolicyByID(c *gin.Context) {
+	policy, err := h.service.GetPolicy(c.Param("id"))
+	if errors.Is(err, approval.ErrPolicyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"instant/services/api/services/calendar"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CalendarQueryHandler serves the bond market calendar
+type CalendarQueryHandler struct {
+	calendar *calendar.Calendar
+}
+
+// NewCalendarQueryHandler creates a new market calendar query handler
+func NewCalendarQueryHandler(marketCalendar *calendar.Calendar) (*CalendarQueryHandler, error) {
+	return &CalendarQueryHandler{calendar: marketCalendar}, nil
+}
+
+// GetCalendar lists a year's holidays and early closes, the current year by
+// default, with the settlement cycles per instrument type
+func (h *CalendarQueryHandler) GetCalendar(c *gin.Context) {
+	now := time.Now().In(h.calendar.Location())
+	year := now.Year()
+	if value := c.Query("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1900 || parsed > 2200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid year"})
+			return
+		}
+		year = parsed
+	}
+
+	days := h.calendar.Year(year)
+	c.JSON(http.StatusOK, gin.H{
+		"year":             year,
+		"timezone":         h.calendar.Location().String(),
+		"tradeDate":        h.calendar.TradeDate(now).Format("2006-01-02"),
+		"specialDays":      days,
+		"count":            len(days),
+		"settlementCycles": h.calendar.SettlementCycles(),
+	})
+}
+
+// GetSettlementDate returns when a trade on tradeDate, today by default, in
+// an instrument type settles
+func (h *CalendarQueryHandler) GetSettlementDate(c *gin.Context) {
+	tradedAt := time.Now()
+	if value := c.Query("tradeDate"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, h.calendar.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tradeDate"})
+			return
+		}
+		tradedAt = parsed
+	}
+	instrumentType := c.Query("instrumentType")
+
+	c.JSON(http.StatusOK, gin.H{
+		"tradeDate":       h.calendar.TradeDate(tradedAt).Format("2006-01-02"),
+		"instrumentType":  instrumentType,
+		"settlementCycle": h.calendar.SettlementCycle(instrumentType),
+		"settlementDate":  h.calendar.SettlementDate(tradedAt, instrumentType).Format("2006-01-02"),
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"instant/services/api/eventstore"
 	"instant/services/api/services/compliance"
 
 	"github.com/gin-gonic/gin"
@@ -11,11 +12,12 @@ import (
 )
 
 type ComplianceCommandHandler struct {
-	service *compliance.Service
+	service    *compliance.Service
+	eventStore *eventstore.EventStore
 }
 
-func NewComplianceCommandHandler(service *compliance.Service) *ComplianceCommandHandler {
-	return &ComplianceCommandHandler{service: service}
+func NewComplianceCommandHandler(service *compliance.Service, eventStore *eventstore.EventStore) *ComplianceCommandHandler {
+	return &ComplianceCommandHandler{service: service, eventStore: eventStore}
 }
 
 type ruleRequest struct {
@@ -72,6 +74,7 @@ func (h *ComplianceCommandHandler) CreateRule(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{
 		"ruleId":        ruleID,
 		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
 		"status":        "created",
 	})
 }
@@ -115,6 +118,7 @@ func (h *ComplianceCommandHandler) UpdateRule(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"ruleId":        ruleID,
 		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
 		"status":        "updated",
 	})
 }
@@ -131,7 +135,11 @@ func (h *ComplianceCommandHandler) EnableRule(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "enabled"})
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "enabled",
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+	})
 }
 
 func (h *ComplianceCommandHandler) DisableRule(c *gin.Context) {
@@ -146,7 +154,11 @@ func (h *ComplianceCommandHandler) DisableRule(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "disabled"})
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "disabled",
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+	})
 }
 
 func (h *ComplianceCommandHandler) DeleteRule(c *gin.Context) {
@@ -161,7 +173,11 @@ func (h *ComplianceCommandHandler) DeleteRule(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "deleted",
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+	})
 }
 
 func (h *ComplianceCommandHandler) PublishRuleSet(c *gin.Context) {
@@ -202,6 +218,7 @@ func (h *ComplianceCommandHandler) PublishRuleSet(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"ruleSetId":     id,
 		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
 		"status":        "published",
 	})
 }
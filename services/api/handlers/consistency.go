@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"instant/services/api/eventstore"
+	"instant/services/api/projections"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MinPositionHeader lets clients request read-your-writes on view endpoints
+const MinPositionHeader = "X-Min-Position"
+
+// ProjectionPositionHeader reports the checkpoint a view was served at
+const ProjectionPositionHeader = "X-Projection-Position"
+
+// ConsistencyWaiter holds view requests until the backing projection has
+// applied the event position the client last wrote.
+type ConsistencyWaiter struct {
+	checkpoints *projections.CheckpointStore
+	timeout     time.Duration
+}
+
+// NewConsistencyWaiter creates a new consistency waiter.
+func NewConsistencyWaiter(checkpoints *projections.CheckpointStore, timeout time.Duration) *ConsistencyWaiter {
+	return &ConsistencyWaiter{checkpoints: checkpoints, timeout: timeout}
+}
+
+// Require returns middleware that waits for the projection to reach the
+// position given by the minPosition query parameter or X-Min-Position header.
+// Requests without either are served immediately.
+func (w *ConsistencyWaiter) Require(projection string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := c.Query("minPosition")
+		if raw == "" {
+			raw = c.GetHeader(MinPositionHeader)
+		}
+		if raw == "" {
+			c.Next()
+			return
+		}
+
+		minPosition, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || minPosition < 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "minPosition must be a non-negative integer"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), w.timeout)
+		defer cancel()
+
+		position, err := w.checkpoints.WaitFor(ctx, projection, minPosition)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{
+					"error":       "projection has not caught up to the requested position",
+					"projection":  projection,
+					"position":    position,
+					"minPosition": minPosition,
+				})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Header(ProjectionPositionHeader, strconv.FormatInt(position, 10))
+		c.Next()
+	}
+}
+
+// writtenPosition returns the position of the last event written under the
+// command's correlation ID so clients can pass it back as minPosition.
+func writtenPosition(eventStore *eventstore.EventStore, correlationID string) int64 {
+	if eventStore == nil {
+		return 0
+	}
+	position, err := eventStore.LastPositionByCorrelation(correlationID)
+	if err != nil {
+		return 0
+	}
+	return position
+}
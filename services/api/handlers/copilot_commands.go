@@ -122,6 +122,7 @@ func (h *CopilotCommandHandler) HandleCreateDraft(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{
 		"planId":        req.PlanID,
 		"correlationId": correlationID,
+		"position":      event.Position,
 		"status":        "proposed",
 	})
 }
@@ -172,6 +173,7 @@ func (h *CopilotCommandHandler) HandleApproveDraft(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"planId":        planID,
 		"correlationId": correlationID,
+		"position":      event.Position,
 		"status":        "approved",
 	})
 }
@@ -223,6 +225,7 @@ func (h *CopilotCommandHandler) HandleRejectDraft(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"planId":        planID,
 		"correlationId": correlationID,
+		"position":      event.Position,
 		"status":        "rejected",
 	})
 }
@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"instant/services/api/ems"
+	"instant/services/api/eventstore"
+	"instant/services/api/services/tradingcontrol"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -11,12 +14,14 @@ import (
 // EMSCommandHandler handles EMS-specific commands.
 type EMSCommandHandler struct {
 	emsService *ems.Service
+	eventStore *eventstore.EventStore
 }
 
 // NewEMSCommandHandler creates a new EMS command handler.
-func NewEMSCommandHandler(emsService *ems.Service) *EMSCommandHandler {
+func NewEMSCommandHandler(emsService *ems.Service, eventStore *eventstore.EventStore) *EMSCommandHandler {
 	return &EMSCommandHandler{
 		emsService: emsService,
+		eventStore: eventStore,
 	}
 }
 
@@ -34,6 +39,18 @@ func (h *EMSCommandHandler) HandleRequestExecution(c *gin.Context) {
 	}
 
 	executionID, err := h.emsService.RequestExecution(req, correlationID)
+	if errors.Is(err, tradingcontrol.ErrTradingHalted) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":         err.Error(),
+			"correlationId": correlationID,
+			"position":      writtenPosition(h.eventStore, correlationID),
+		})
+		return
+	}
+	if errors.Is(err, ems.ErrInvalidSeed) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -42,6 +59,7 @@ func (h *EMSCommandHandler) HandleRequestExecution(c *gin.Context) {
 	c.JSON(http.StatusAccepted, gin.H{
 		"executionId":   executionID,
 		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
 		"status":        "requested",
 	})
 }
@@ -32,12 +32,13 @@ func (h *EMSQueryHandler) GetExecutions(c *gin.Context) {
 
 	query := `
 		SELECT
-			e."executionId", e."orderId", e."accountId", e."instrumentId", e.side,
+			e."executionId", COALESCE(e."orderId", ''), COALESCE(e."accountId", ''), e."instrumentId", e.side,
 			e."totalQuantity", e."filledQuantity", e."avgFillPrice", e.status,
 			e."asOfDate", e."executionStartTime", e."executionEndTime",
 			e."settlementDate", e."settledDate",
 			e."slippageTotal", e."slippageBreakdown", e."deterministicInputs",
-			e.explanation, e."createdAt", e."updatedAt",
+			e.explanation, e."createdAt", e."updatedAt", e."blockId", e.venue, e."routingPolicyId",
+			e.commission + e.fees + e.markup, e.principal, e."accruedInterest", e."netSettlementAmount",
 			i.name as "instrumentName", i.cusip, a.name as "accountName",
 			o."orderType", o."limitPrice", o."curveSpreadBp"
 		FROM executions e
@@ -109,6 +110,13 @@ func (h *EMSQueryHandler) GetExecutions(c *gin.Context) {
 			explanation       sql.NullString
 			createdAt         time.Time
 			updatedAt         time.Time
+			blockID           sql.NullString
+			venue             sql.NullString
+			routingPolicyID   sql.NullString
+			totalCharges      float64
+			principal         sql.NullFloat64
+			accruedInterest   sql.NullFloat64
+			netAmount         sql.NullFloat64
 			instrumentName    sql.NullString
 			cusip             sql.NullString
 			accountName       sql.NullString
@@ -123,7 +131,8 @@ func (h *EMSQueryHandler) GetExecutions(c *gin.Context) {
 			&asOfDate, &executionStart, &executionEnd,
 			&settlementDate, &settledDate,
 			&slippageTotal, &slippageBreakdown, &deterministic,
-			&explanation, &createdAt, &updatedAt,
+			&explanation, &createdAt, &updatedAt, &blockID, &venue, &routingPolicyID,
+			&totalCharges, &principal, &accruedInterest, &netAmount,
 			&instrumentName, &cusip, &accountName,
 			&orderType, &limitPrice, &curveSpreadBp,
 		); err != nil {
@@ -141,6 +150,7 @@ func (h *EMSQueryHandler) GetExecutions(c *gin.Context) {
 			"filledQuantity": filledQuantity,
 			"status":         statusVal,
 			"asOfDate":       asOfDate,
+			"totalCharges":   totalCharges,
 			"createdAt":      createdAt,
 			"updatedAt":      updatedAt,
 		}
@@ -148,6 +158,11 @@ func (h *EMSQueryHandler) GetExecutions(c *gin.Context) {
 		if avgFillPrice.Valid {
 			execution["avgFillPrice"] = avgFillPrice.Float64
 		}
+		addSettlementAmounts(execution, principal, accruedInterest, netAmount)
+		addRouting(execution, venue, routingPolicyID)
+		if blockID.Valid {
+			execution["blockId"] = blockID.String
+		}
 		if executionStart.Valid {
 			execution["executionStartTime"] = executionStart.Time
 		}
@@ -216,12 +231,13 @@ func (h *EMSQueryHandler) GetExecutionByID(c *gin.Context) {
 
 	query := `
 		SELECT
-			e."executionId", e."orderId", e."accountId", e."instrumentId", e.side,
+			e."executionId", COALESCE(e."orderId", ''), COALESCE(e."accountId", ''), e."instrumentId", e.side,
 			e."totalQuantity", e."filledQuantity", e."avgFillPrice", e.status,
 			e."asOfDate", e."executionStartTime", e."executionEndTime",
 			e."settlementDate", e."settledDate",
 			e."slippageTotal", e."slippageBreakdown", e."deterministicInputs",
-			e.explanation, e."createdAt", e."updatedAt",
+			e.explanation, e."createdAt", e."updatedAt", e."blockId", e.venue, e."routingPolicyId",
+			e.commission, e.fees, e.markup, e.principal, e."accruedInterest", e."netSettlementAmount",
 			o."orderType", o."limitPrice", o."curveSpreadBp"
 		FROM executions e
 		LEFT JOIN orders o ON e."orderId" = o."orderId"
@@ -248,6 +264,15 @@ func (h *EMSQueryHandler) GetExecutionByID(c *gin.Context) {
 		explanation       sql.NullString
 		createdAt         time.Time
 		updatedAt         time.Time
+		blockID           sql.NullString
+		venue             sql.NullString
+		routingPolicyID   sql.NullString
+		commission        float64
+		fees              float64
+		markup            float64
+		principal         sql.NullFloat64
+		accruedInterest   sql.NullFloat64
+		netAmount         sql.NullFloat64
 		orderType         sql.NullString
 		limitPrice        sql.NullFloat64
 		curveSpreadBp     sql.NullFloat64
@@ -259,7 +284,8 @@ func (h *EMSQueryHandler) GetExecutionByID(c *gin.Context) {
 		&asOfDate, &executionStart, &executionEnd,
 		&settlementDate, &settledDate,
 		&slippageTotal, &slippageBreakdown, &deterministic,
-		&explanation, &createdAt, &updatedAt,
+		&explanation, &createdAt, &updatedAt, &blockID, &venue, &routingPolicyID,
+		&commission, &fees, &markup, &principal, &accruedInterest, &netAmount,
 		&orderType, &limitPrice, &curveSpreadBp,
 	)
 	if err != nil {
@@ -281,6 +307,10 @@ func (h *EMSQueryHandler) GetExecutionByID(c *gin.Context) {
 		"filledQuantity": filledQuantity,
 		"status":         statusVal,
 		"asOfDate":       asOfDate,
+		"commission":     commission,
+		"fees":           fees,
+		"markup":         markup,
+		"totalCharges":   commission + fees + markup,
 		"createdAt":      createdAt,
 		"updatedAt":      updatedAt,
 	}
@@ -288,6 +318,11 @@ func (h *EMSQueryHandler) GetExecutionByID(c *gin.Context) {
 	if avgFillPrice.Valid {
 		execution["avgFillPrice"] = avgFillPrice.Float64
 	}
+	addSettlementAmounts(execution, principal, accruedInterest, netAmount)
+	addRouting(execution, venue, routingPolicyID)
+	if blockID.Valid {
+		execution["blockId"] = blockID.String
+	}
 	if executionStart.Valid {
 		execution["executionStartTime"] = executionStart.Time
 	}
@@ -329,7 +364,7 @@ func (h *EMSQueryHandler) GetExecutionByID(c *gin.Context) {
 	}
 
 	fillsQuery := `
-		SELECT "fillId", "clipIndex", quantity, price, timestamp, slippage
+		SELECT "fillId", "clipIndex", quantity, price, timestamp, slippage, commission, fees, markup
 		FROM fills
 		WHERE "executionId" = $1
 		ORDER BY "clipIndex" ASC
@@ -345,26 +380,32 @@ func (h *EMSQueryHandler) GetExecutionByID(c *gin.Context) {
 	fills := []map[string]interface{}{}
 	for fillRows.Next() {
 		var (
-			fillID    string
-			clipIndex int
-			quantity  float64
-			price     float64
-			timestamp time.Time
-			slippage  float64
+			fillID     string
+			clipIndex  int
+			quantity   float64
+			price      float64
+			timestamp  time.Time
+			slippage   float64
+			commission float64
+			fees       float64
+			markup     float64
 		)
 
-		if err := fillRows.Scan(&fillID, &clipIndex, &quantity, &price, &timestamp, &slippage); err != nil {
+		if err := fillRows.Scan(&fillID, &clipIndex, &quantity, &price, &timestamp, &slippage, &commission, &fees, &markup); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
 		fills = append(fills, map[string]interface{}{
-			"fillId":    fillID,
-			"clipIndex": clipIndex,
-			"quantity":  quantity,
-			"price":     price,
-			"timestamp": timestamp,
-			"slippage":  slippage,
+			"fillId":     fillID,
+			"clipIndex":  clipIndex,
+			"quantity":   quantity,
+			"price":      price,
+			"timestamp":  timestamp,
+			"slippage":   slippage,
+			"commission": commission,
+			"fees":       fees,
+			"markup":     markup,
 		})
 	}
 
@@ -372,3 +413,28 @@ func (h *EMSQueryHandler) GetExecutionByID(c *gin.Context) {
 
 	c.JSON(http.StatusOK, execution)
 }
+
+// addSettlementAmounts adds the cash an execution settled for once it has
+// been booked
+func addSettlementAmounts(execution map[string]interface{}, principal, accruedInterest, netAmount sql.NullFloat64) {
+	if principal.Valid {
+		execution["principal"] = principal.Float64
+	}
+	if accruedInterest.Valid {
+		execution["accruedInterest"] = accruedInterest.Float64
+	}
+	if netAmount.Valid {
+		execution["netSettlementAmount"] = netAmount.Float64
+	}
+}
+
+// addRouting adds the venue an execution was routed to and the routing policy
+// that chose it, when one did
+func addRouting(execution map[string]interface{}, venue, routingPolicyID sql.NullString) {
+	if venue.Valid {
+		execution["venue"] = venue.String
+	}
+	if routingPolicyID.Valid {
+		execution["routingPolicyId"] = routingPolicyID.String
+	}
+}
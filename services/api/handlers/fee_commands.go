@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"instant/services/api/eventstore"
+	"instant/services/api/services/fees"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeeCommandHandler handles fee schedule commands
+type FeeCommandHandler struct {
+	service    *fees.Service
+	eventStore *eventstore.EventStore
+}
+
+// NewFeeCommandHandler creates a new fee schedule command handler
+func NewFeeCommandHandler(service *fees.Service, eventStore *eventstore.EventStore) *FeeCommandHandler {
+	return &FeeCommandHandler{service: service, eventStore: eventStore}
+}
+
+type feeScheduleRequest struct {
+	Name              string  `json:"name" binding:"required"`
+	Scope             string  `json:"scope"`
+	ScopeID           *string `json:"scopeId"`
+	InstrumentType    *string `json:"instrumentType"`
+	CommissionPerBond float64 `json:"commissionPerBond"`
+	CommissionBps     float64 `json:"commissionBps"`
+	MinCommission     float64 `json:"minCommission"`
+	FeeBps            float64 `json:"feeBps"`
+	MarkupBps         float64 `json:"markupBps"`
+}
+
+func (r feeScheduleRequest) input(actorID string) fees.ScheduleInput {
+	return fees.ScheduleInput{
+		Name:              r.Name,
+		Scope:             r.Scope,
+		ScopeID:           r.ScopeID,
+		InstrumentType:    r.InstrumentType,
+		CommissionPerBond: r.CommissionPerBond,
+		CommissionBps:     r.CommissionBps,
+		MinCommission:     r.MinCommission,
+		FeeBps:            r.FeeBps,
+		MarkupBps:         r.MarkupBps,
+		ActorID:           actorID,
+	}
+}
+
+// CreateSchedule handles creating a fee schedule
+func (h *FeeCommandHandler) CreateSchedule(c *gin.Context) {
+	var req struct {
+		feeScheduleRequest
+		CreatedBy string `json:"createdBy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := correlationIDFromHeader(c)
+
+	scheduleID, err := h.service.CreateSchedule(req.input(req.CreatedBy), correlationID)
+	if err != nil {
+		c.JSON(feeScheduleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"scheduleId":    scheduleID,
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "created",
+	})
+}
+
+// UpdateSchedule handles replacing a fee schedule's name and rates
+func (h *FeeCommandHandler) UpdateSchedule(c *gin.Context) {
+	scheduleID := c.Param("id")
+	var req struct {
+		feeScheduleRequest
+		UpdatedBy string `json:"updatedBy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := correlationIDFromHeader(c)
+
+	version, err := h.service.UpdateSchedule(scheduleID, req.input(req.UpdatedBy), correlationID)
+	if err != nil {
+		c.JSON(feeScheduleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"scheduleId":    scheduleID,
+		"version":       version,
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "updated",
+	})
+}
+
+// DeleteSchedule handles retiring a fee schedule
+func (h *FeeCommandHandler) DeleteSchedule(c *gin.Context) {
+	actorID := actorIDFromBody(c)
+	if actorID == "" {
+		return
+	}
+	correlationID := correlationIDFromHeader(c)
+
+	if err := h.service.DeleteSchedule(c.Param("id"), actorID, correlationID); err != nil {
+		c.JSON(feeScheduleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "deleted",
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+	})
+}
+
+func feeScheduleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, fees.ErrScheduleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, fees.ErrDuplicateSchedule):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"instant/services/api/services/fees"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeeQueryHandler serves fee schedules
+type FeeQueryHandler struct {
+	service *fees.Service
+}
+
+// NewFeeQueryHandler creates a new fee schedule query handler
+func NewFeeQueryHandler(service *fees.Service) (*FeeQueryHandler, error) {
+	return &FeeQueryHandler{service: service}, nil
+}
+
+// GetSchedules lists fee schedules that have not been deleted
+func (h *FeeQueryHandler) GetSchedules(c *gin.Context) {
+	schedules, err := h.service.ListSchedules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"schedules": schedules,
+		"count":     len(schedules),
+	})
+}
+
+// GetScheduleByID returns one fee schedule
+func (h *FeeQueryHandler) GetScheduleByID(c *gin.Context) {
+	schedule, err := h.service.GetSchedule(c.Param("id"))
+	if errors.Is(err, fees.ErrScheduleNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
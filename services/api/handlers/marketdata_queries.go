@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"instant/services/api/services/calendar"
 	"instant/services/api/services/pricing"
 
 	"github.com/gin-gonic/gin"
@@ -15,14 +16,16 @@ import (
 )
 
 type MarketDataQueryHandler struct {
-	db      *sql.DB
-	pricing *pricing.Service
+	db       *sql.DB
+	pricing  *pricing.Service
+	calendar *calendar.Calendar
 }
 
-func NewMarketDataQueryHandler(db *sql.DB) (*MarketDataQueryHandler, error) {
+func NewMarketDataQueryHandler(db *sql.DB, marketCalendar *calendar.Calendar) (*MarketDataQueryHandler, error) {
 	return &MarketDataQueryHandler{
-		db:      db,
-		pricing: pricing.NewService(),
+		db:       db,
+		pricing:  pricing.NewService(),
+		calendar: marketCalendar,
 	}, nil
 }
 
@@ -366,11 +369,6 @@ type instrumentRow struct {
 }
 
 func (row instrumentRow) pricingInput() pricing.InstrumentInput {
-	dayCount := "ACT/ACT"
-	if row.Type == "bill" || row.CouponFrequency == 0 || row.Coupon == 0 {
-		dayCount = "ACT/360"
-	}
-
 	return pricing.InstrumentInput{
 		Cusip:           row.Cusip,
 		Type:            row.Type,
@@ -378,7 +376,7 @@ func (row instrumentRow) pricingInput() pricing.InstrumentInput {
 		IssueDate:       row.IssueDate,
 		MaturityDate:    row.MaturityDate,
 		CouponFrequency: row.CouponFrequency,
-		DayCount:        dayCount,
+		DayCount:        pricing.DefaultDayCount(row.Type, row.Coupon, row.CouponFrequency),
 	}
 }
 
@@ -581,15 +579,21 @@ func (h *MarketDataQueryHandler) resolveAsOfDate(value string) (time.Time, error
 		return time.Time{}, fmt.Errorf("invalid asOfDate")
 	}
 
-	var latest time.Time
-	err := h.db.QueryRow(`SELECT MAX("asOfDate") FROM yield_curves`).Scan(&latest)
+	// Without an asOfDate, price off the latest curve on or before the
+	// market's latest business day
+	asOfDate := time.Now().UTC()
+	if h.calendar != nil {
+		asOfDate = h.calendar.LatestBusinessDay(asOfDate)
+	}
+	var latest sql.NullTime
+	err := h.db.QueryRow(`SELECT MAX("asOfDate") FROM yield_curves WHERE "asOfDate" <= $1`, asOfDate).Scan(&latest)
 	if err != nil {
 		return time.Time{}, err
 	}
-	if latest.IsZero() {
+	if !latest.Valid {
 		return time.Time{}, fmt.Errorf("no curve dates available")
 	}
-	return latest, nil
+	return latest.Time, nil
 }
 
 func parseDate(value string) (time.Time, error) {
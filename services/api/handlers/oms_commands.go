@@ -2,7 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"instant/services/api/eventstore"
 	"instant/services/api/oms"
+	"instant/services/api/services/allocation"
+	"instant/services/api/services/risk"
+	"instant/services/api/services/tradingcontrol"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -12,12 +17,14 @@ import (
 // OMSCommandHandler handles OMS-specific commands
 type OMSCommandHandler struct {
 	omsService *oms.Service
+	eventStore *eventstore.EventStore
 }
 
 // NewOMSCommandHandler creates a new OMS command handler
-func NewOMSCommandHandler(omsService *oms.Service) *OMSCommandHandler {
+func NewOMSCommandHandler(omsService *oms.Service, eventStore *eventstore.EventStore) *OMSCommandHandler {
 	return &OMSCommandHandler{
 		omsService: omsService,
+		eventStore: eventStore,
 	}
 }
 
@@ -42,20 +49,18 @@ func (h *OMSCommandHandler) HandleCreateOrder(c *gin.Context) {
 				"error":         "Order blocked by compliance",
 				"orderId":       orderID,
 				"correlationId": correlationID,
+				"position":      writtenPosition(h.eventStore, correlationID),
 			})
 			return
 		}
-		if isOMSCreationValidationError(err) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondOMSCommandError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"orderId":       orderID,
 		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
 		"status":        "created",
 	})
 }
@@ -77,15 +82,62 @@ func (h *OMSCommandHandler) HandleAmendOrder(c *gin.Context) {
 		correlationID = uuid.New().String()
 	}
 
-	if err := h.omsService.AmendOrder(req, correlationID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	result, err := h.omsService.AmendOrder(req, correlationID)
+	if err != nil {
+		respondOMSCommandError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"orderId":       orderID,
-		"correlationId": correlationID,
-		"status":        "amended",
+		"orderId":           orderID,
+		"correlationId":     correlationID,
+		"position":          writtenPosition(h.eventStore, correlationID),
+		"status":            "amended",
+		"complianceStatus":  result.ComplianceStatus,
+		"warnings":          result.Warnings,
+		"approvalRequested": result.ApprovalRequested,
+		"requiredApprovals": result.RequiredApprovals,
+	})
+}
+
+// HandleReplaceOrder handles ReplaceOrder command: the order is retired and a
+// linked new version with the changed terms takes its place
+func (h *OMSCommandHandler) HandleReplaceOrder(c *gin.Context) {
+	orderID := c.Param("id")
+
+	var req oms.ReplaceOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ReplacedBy == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "replacedBy is required"})
+		return
+	}
+
+	req.OrderID = orderID
+
+	correlationID := correlationIDFromHeader(c)
+
+	result, err := h.omsService.ReplaceOrder(req, correlationID)
+	if err != nil {
+		respondOMSCommandError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"orderId":           result.OrderID,
+		"replacedOrderId":   result.ReplacedOrderID,
+		"chainId":           result.ChainID,
+		"version":           result.Version,
+		"changes":           result.Changes,
+		"correlationId":     correlationID,
+		"position":          writtenPosition(h.eventStore, correlationID),
+		"status":            "replaced",
+		"complianceStatus":  result.ComplianceStatus,
+		"warnings":          result.Warnings,
+		"approvalRequested": result.ApprovalRequested,
+		"requiredApprovals": result.RequiredApprovals,
 	})
 }
 
@@ -111,15 +163,56 @@ func (h *OMSCommandHandler) HandleApproveOrder(c *gin.Context) {
 		ApprovedBy: req.ApprovedBy,
 	}
 
-	if err := h.omsService.ApproveOrder(approveReq, correlationID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	result, err := h.omsService.ApproveOrder(approveReq, correlationID)
+	if err != nil {
+		respondOMSCommandError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orderId":           orderID,
+		"correlationId":     correlationID,
+		"position":          writtenPosition(h.eventStore, correlationID),
+		"status":            approvalStatus(result),
+		"approvals":         result.Approvals,
+		"requiredApprovals": result.RequiredApprovals,
+	})
+}
+
+// HandleRejectOrder handles RejectOrder command
+func (h *OMSCommandHandler) HandleRejectOrder(c *gin.Context) {
+	orderID := c.Param("id")
+
+	var req struct {
+		RejectedBy string `json:"rejectedBy" binding:"required"`
+		Reason     string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := c.GetHeader("X-Correlation-ID")
+	if correlationID == "" {
+		correlationID = uuid.New().String()
+	}
+
+	rejectReq := oms.RejectOrderRequest{
+		OrderID:    orderID,
+		RejectedBy: req.RejectedBy,
+		Reason:     req.Reason,
+	}
+
+	if err := h.omsService.RejectOrder(rejectReq, correlationID); err != nil {
+		respondOMSCommandError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"orderId":       orderID,
 		"correlationId": correlationID,
-		"status":        "approved",
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "rejected",
 	})
 }
 
@@ -148,13 +241,14 @@ func (h *OMSCommandHandler) HandleCancelOrder(c *gin.Context) {
 	}
 
 	if err := h.omsService.CancelOrder(cancelReq, correlationID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondOMSCommandError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"orderId":       orderID,
 		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
 		"status":        "cancelled",
 	})
 }
@@ -182,13 +276,52 @@ func (h *OMSCommandHandler) HandleSendToEMS(c *gin.Context) {
 	}
 
 	if err := h.omsService.SendToEMS(sendReq, correlationID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondOMSCommandError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"orderId":       orderID,
 		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "sent_to_ems",
+	})
+}
+
+// HandleNetAndSend crosses offsetting approved orders internally and sends the
+// residuals to the EMS
+func (h *OMSCommandHandler) HandleNetAndSend(c *gin.Context) {
+	var req struct {
+		OrderIDs []string `json:"orderIds" binding:"required"`
+		SentBy   string   `json:"sentBy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := c.GetHeader("X-Correlation-ID")
+	if correlationID == "" {
+		correlationID = uuid.New().String()
+	}
+
+	netReq := oms.NetAndSendRequest{
+		OrderIDs: req.OrderIDs,
+		SentBy:   req.SentBy,
+	}
+
+	result, err := h.omsService.NetAndSend(netReq, correlationID)
+	if err != nil {
+		respondOMSCommandError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"nettingId":     result.NettingID,
+		"crosses":       result.Crosses,
+		"residuals":     result.Residuals,
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
 		"status":        "sent_to_ems",
 	})
 }
@@ -234,10 +367,74 @@ func (h *OMSCommandHandler) HandleBulkCreateOrders(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{
 		"batchId":       batchID,
 		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
 		"results":       results,
 	})
 }
 
+// HandleCreateBlockOrder handles CreateBlockOrder command
+func (h *OMSCommandHandler) HandleCreateBlockOrder(c *gin.Context) {
+	var req oms.CreateBlockOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := c.GetHeader("X-Correlation-ID")
+	if correlationID == "" {
+		correlationID = uuid.New().String()
+	}
+
+	result, err := h.omsService.CreateBlockOrder(req, correlationID)
+	if err != nil {
+		respondOMSCommandError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"blockId":       result.BlockID,
+		"orders":        result.Orders,
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "created",
+	})
+}
+
+// HandleSendBlockToEMS handles SendBlockToEMS command
+func (h *OMSCommandHandler) HandleSendBlockToEMS(c *gin.Context) {
+	blockID := c.Param("id")
+
+	var req struct {
+		SentBy string `json:"sentBy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := c.GetHeader("X-Correlation-ID")
+	if correlationID == "" {
+		correlationID = uuid.New().String()
+	}
+
+	sendReq := oms.SendBlockToEMSRequest{
+		BlockID: blockID,
+		SentBy:  req.SentBy,
+	}
+
+	if err := h.omsService.SendBlockToEMS(sendReq, correlationID); err != nil {
+		respondOMSCommandError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"blockId":       blockID,
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "sent_to_ems",
+	})
+}
+
 // HandleCommandRouter routes generic command requests to specific handlers
 func (h *OMSCommandHandler) HandleCommandRouter(c *gin.Context) {
 	var req struct {
@@ -266,17 +463,14 @@ func (h *OMSCommandHandler) HandleCommandRouter(c *gin.Context) {
 
 		orderID, err := h.omsService.CreateOrder(createReq, correlationID)
 		if err != nil {
-			if isOMSCreationValidationError(err) {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-				return
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondOMSCommandError(c, err)
 			return
 		}
 
 		c.JSON(http.StatusCreated, gin.H{
 			"orderId":       orderID,
 			"correlationId": correlationID,
+			"position":      writtenPosition(h.eventStore, correlationID),
 			"status":        "created",
 		})
 
@@ -287,14 +481,43 @@ func (h *OMSCommandHandler) HandleCommandRouter(c *gin.Context) {
 			return
 		}
 
-		if err := h.omsService.AmendOrder(amendReq, correlationID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		result, err := h.omsService.AmendOrder(amendReq, correlationID)
+		if err != nil {
+			respondOMSCommandError(c, err)
 			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"correlationId": correlationID,
-			"status":        "amended",
+			"orderId":           amendReq.OrderID,
+			"correlationId":     correlationID,
+			"position":          writtenPosition(h.eventStore, correlationID),
+			"status":            "amended",
+			"complianceStatus":  result.ComplianceStatus,
+			"warnings":          result.Warnings,
+			"approvalRequested": result.ApprovalRequested,
+			"requiredApprovals": result.RequiredApprovals,
+		})
+
+	case "ReplaceOrder":
+		var replaceReq oms.ReplaceOrderRequest
+		if err := json.Unmarshal(req.Payload, &replaceReq); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := h.omsService.ReplaceOrder(replaceReq, correlationID)
+		if err != nil {
+			respondOMSCommandError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"orderId":          result.OrderID,
+			"replacedOrderId":  result.ReplacedOrderID,
+			"correlationId":    correlationID,
+			"position":         writtenPosition(h.eventStore, correlationID),
+			"status":           "replaced",
+			"complianceStatus": result.ComplianceStatus,
 		})
 
 	case "ApproveOrder":
@@ -304,14 +527,36 @@ func (h *OMSCommandHandler) HandleCommandRouter(c *gin.Context) {
 			return
 		}
 
-		if err := h.omsService.ApproveOrder(approveReq, correlationID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		result, err := h.omsService.ApproveOrder(approveReq, correlationID)
+		if err != nil {
+			respondOMSCommandError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"correlationId":     correlationID,
+			"position":          writtenPosition(h.eventStore, correlationID),
+			"status":            approvalStatus(result),
+			"approvals":         result.Approvals,
+			"requiredApprovals": result.RequiredApprovals,
+		})
+
+	case "RejectOrder":
+		var rejectReq oms.RejectOrderRequest
+		if err := json.Unmarshal(req.Payload, &rejectReq); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := h.omsService.RejectOrder(rejectReq, correlationID); err != nil {
+			respondOMSCommandError(c, err)
 			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{
 			"correlationId": correlationID,
-			"status":        "approved",
+			"position":      writtenPosition(h.eventStore, correlationID),
+			"status":        "rejected",
 		})
 
 	case "CancelOrder":
@@ -322,12 +567,13 @@ func (h *OMSCommandHandler) HandleCommandRouter(c *gin.Context) {
 		}
 
 		if err := h.omsService.CancelOrder(cancelReq, correlationID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondOMSCommandError(c, err)
 			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{
 			"correlationId": correlationID,
+			"position":      writtenPosition(h.eventStore, correlationID),
 			"status":        "cancelled",
 		})
 
@@ -339,12 +585,52 @@ func (h *OMSCommandHandler) HandleCommandRouter(c *gin.Context) {
 		}
 
 		if err := h.omsService.SendToEMS(sendReq, correlationID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondOMSCommandError(c, err)
 			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{
 			"correlationId": correlationID,
+			"position":      writtenPosition(h.eventStore, correlationID),
+			"status":        "sent_to_ems",
+		})
+
+	case "CreateBlockOrder":
+		var blockReq oms.CreateBlockOrderRequest
+		if err := json.Unmarshal(req.Payload, &blockReq); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := h.omsService.CreateBlockOrder(blockReq, correlationID)
+		if err != nil {
+			respondOMSCommandError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"blockId":       result.BlockID,
+			"orders":        result.Orders,
+			"correlationId": correlationID,
+			"position":      writtenPosition(h.eventStore, correlationID),
+			"status":        "created",
+		})
+
+	case "SendBlockToEMS":
+		var sendReq oms.SendBlockToEMSRequest
+		if err := json.Unmarshal(req.Payload, &sendReq); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := h.omsService.SendBlockToEMS(sendReq, correlationID); err != nil {
+			respondOMSCommandError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"correlationId": correlationID,
+			"position":      writtenPosition(h.eventStore, correlationID),
 			"status":        "sent_to_ems",
 		})
 
@@ -357,5 +643,70 @@ func isOMSCreationValidationError(err error) bool {
 	return err == oms.ErrInvalidQuantity ||
 		err == oms.ErrInvalidOrderType ||
 		err == oms.ErrMissingLimitPrice ||
-		err == oms.ErrMissingCurveSpread
+		err == oms.ErrMissingCurveSpread ||
+		err == oms.ErrMissingLimitYield ||
+		err == oms.ErrInvalidTimeInForce ||
+		err == oms.ErrInvalidExpireAt ||
+		errors.Is(err, oms.ErrLimitOutsideBand) ||
+		errors.Is(err, oms.ErrLimitPricingUnavailable)
+}
+
+// respondOMSCommandError maps order command errors to HTTP statuses
+func respondOMSCommandError(c *gin.Context, err error) {
+	var stateErr *oms.StateError
+	var blockErr *oms.ComplianceBlockError
+	var refused *tradingcontrol.RefusedError
+	var breach *risk.BreachError
+	switch {
+	case errors.As(err, &breach):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":    err.Error(),
+			"breaches": breach.Breaches,
+		})
+	case errors.As(err, &refused) && refused.Reason == tradingcontrol.ReasonThrottled:
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.As(err, &refused):
+		response := gin.H{"error": err.Error()}
+		if refused.Halt != nil {
+			response["haltId"] = refused.Halt.HaltID
+		}
+		c.JSON(http.StatusForbidden, response)
+	case errors.As(err, &stateErr):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":        err.Error(),
+			"currentState": stateErr.CurrentState,
+		})
+	case errors.As(err, &blockErr):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":      err.Error(),
+			"violations": blockErr.Blocks,
+		})
+	case errors.Is(err, oms.ErrSelfApproval):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, oms.ErrDuplicateApprover), errors.Is(err, eventstore.ErrConcurrencyConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, oms.ErrOrderNotFound), errors.Is(err, oms.ErrBlockNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, oms.ErrEmptyBlock), errors.Is(err, oms.ErrBlockChildOrder),
+		errors.Is(err, oms.ErrReplaceBlockChild), errors.Is(err, oms.ErrNothingToReplace):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, oms.ErrAmendBelowFilled), errors.Is(err, oms.ErrInvalidQuantity),
+		errors.Is(err, oms.ErrMissingRejectReason), errors.Is(err, oms.ErrMissingRejectedBy),
+		isOMSCreationValidationError(err),
+		errors.Is(err, oms.ErrNothingToNet),
+		errors.Is(err, oms.ErrDuplicateAllocation), errors.Is(err, allocation.ErrNoTargets),
+		errors.Is(err, allocation.ErrInvalidLot):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// approvalStatus tells callers whether an approval released the order or is
+// waiting on further approvers
+func approvalStatus(result *oms.ApprovalResult) string {
+	if result.Approved {
+		return "approved"
+	}
+	return "approval_recorded"
 }
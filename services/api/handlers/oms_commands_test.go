@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"instant/services/api/eventstore"
+	"instant/services/api/oms"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRespondOMSCommandError_ConcurrencyConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	err := fmt.Errorf("failed to append OrderCancelled event: %w", eventstore.ErrConcurrencyConflict)
+	respondOMSCommandError(c, err)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestRespondOMSCommandError_MissingRejecter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	respondOMSCommandError(c, oms.ErrMissingRejectedBy)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -35,6 +35,7 @@ func (h *OMSQueryHandler) GetBlotter(c *gin.Context) {
 	state := c.Query("state")
 	fromDate := c.Query("fromDate")
 	toDate := c.Query("toDate")
+	latestOnly := c.Query("latestOnly") == "true"
 	limit := c.DefaultQuery("limit", "100")
 	offset := c.DefaultQuery("offset", "0")
 
@@ -42,10 +43,12 @@ func (h *OMSQueryHandler) GetBlotter(c *gin.Context) {
 	query := `
 		SELECT
 			o."orderId", o."accountId", o."instrumentId", o.side, o.quantity,
-			o."orderType", o."limitPrice", o."curveSpreadBp", o."timeInForce",
-			o.state, o."batchId", o."complianceResult",
+			o."orderType", o."limitPrice", o."limitYield", o."limitPricedAsOf", o."curveSpreadBp", o."timeInForce",
+			o.state, o."batchId", o."blockId", o."complianceResult",
 			o."createdAt", o."createdBy", o."updatedAt", o."lastStateChangeAt",
-			o."sentToEmsAt", o."fullyFilledAt", o."settledAt",
+			o."sentToEmsAt", o."fullyFilledAt", o."settledAt", o."expireAt", o."expiredAt",
+			o."approvedBy", o."approvedAt", o."rejectedBy", o."rejectedAt", o."rejectionReason",
+			o."chainId", o."chainVersion", o."replacesOrderId", o."replacedByOrderId",
 			i.name as "instrumentName", i.cusip, i.type as "instrumentType",
 			a.name as "accountName", a."householdId"
 		FROM orders o
@@ -87,6 +90,11 @@ func (h *OMSQueryHandler) GetBlotter(c *gin.Context) {
 		argPos++
 	}
 
+	// Only the newest version of each cancel/replace chain
+	if latestOnly {
+		query += ` AND o."replacedByOrderId" IS NULL`
+	}
+
 	query += ` ORDER BY o."createdAt" DESC`
 	query += fmt.Sprintf(` LIMIT $%d OFFSET $%d`, argPos, argPos+1)
 	args = append(args, limit, offset)
@@ -109,10 +117,13 @@ func (h *OMSQueryHandler) GetBlotter(c *gin.Context) {
 			quantity         float64
 			orderType        string
 			limitPrice       sql.NullFloat64
+			limitYield       sql.NullFloat64
+			limitPricedAsOf  sql.NullTime
 			curveSpreadBp    sql.NullFloat64
 			timeInForce      string
 			state            string
 			batchID          sql.NullString
+			blockID          sql.NullString
 			complianceResult []byte
 			createdAt        time.Time
 			createdBy        string
@@ -121,6 +132,17 @@ func (h *OMSQueryHandler) GetBlotter(c *gin.Context) {
 			sentToEmsAt      sql.NullTime
 			fullyFilledAt    sql.NullTime
 			settledAt        sql.NullTime
+			expireAt         sql.NullTime
+			expiredAt        sql.NullTime
+			approvedBy       sql.NullString
+			approvedAt       sql.NullTime
+			rejectedBy       sql.NullString
+			rejectedAt       sql.NullTime
+			rejectionReason  sql.NullString
+			chainID          sql.NullString
+			chainVersion     sql.NullInt64
+			replacesOrderID  sql.NullString
+			replacedByOrderID sql.NullString
 			instrumentName   string
 			cusip            string
 			instrumentType   string
@@ -130,10 +152,12 @@ func (h *OMSQueryHandler) GetBlotter(c *gin.Context) {
 
 		err := rows.Scan(
 			&orderID, &accountID, &instrumentID, &side, &quantity,
-			&orderType, &limitPrice, &curveSpreadBp, &timeInForce,
-			&state, &batchID, &complianceResult,
+			&orderType, &limitPrice, &limitYield, &limitPricedAsOf, &curveSpreadBp, &timeInForce,
+			&state, &batchID, &blockID, &complianceResult,
 			&createdAt, &createdBy, &updatedAt, &lastStateChangeAt,
-			&sentToEmsAt, &fullyFilledAt, &settledAt,
+			&sentToEmsAt, &fullyFilledAt, &settledAt, &expireAt, &expiredAt,
+			&approvedBy, &approvedAt, &rejectedBy, &rejectedAt, &rejectionReason,
+			&chainID, &chainVersion, &replacesOrderID, &replacedByOrderID,
 			&instrumentName, &cusip, &instrumentType,
 			&accountName, &householdIDVal,
 		)
@@ -165,12 +189,21 @@ func (h *OMSQueryHandler) GetBlotter(c *gin.Context) {
 		if limitPrice.Valid {
 			order["limitPrice"] = limitPrice.Float64
 		}
+		if limitYield.Valid {
+			order["limitYield"] = limitYield.Float64
+		}
+		if limitPricedAsOf.Valid {
+			order["limitPricedAsOf"] = limitPricedAsOf.Time.Format("2006-01-02")
+		}
 		if curveSpreadBp.Valid {
 			order["curveSpreadBp"] = curveSpreadBp.Float64
 		}
 		if batchID.Valid {
 			order["batchId"] = batchID.String
 		}
+		if blockID.Valid {
+			order["blockId"] = blockID.String
+		}
 		if sentToEmsAt.Valid {
 			order["sentToEmsAt"] = sentToEmsAt.Time
 		}
@@ -180,6 +213,14 @@ func (h *OMSQueryHandler) GetBlotter(c *gin.Context) {
 		if settledAt.Valid {
 			order["settledAt"] = settledAt.Time
 		}
+		if expireAt.Valid {
+			order["expireAt"] = expireAt.Time
+		}
+		if expiredAt.Valid {
+			order["expiredAt"] = expiredAt.Time
+		}
+		addApprovalAttribution(order, approvedBy, approvedAt, rejectedBy, rejectedAt, rejectionReason)
+		addChainLinks(order, chainID, chainVersion, replacesOrderID, replacedByOrderID)
 		if complianceResult != nil {
 			var cr interface{}
 			if err := json.Unmarshal(complianceResult, &cr); err == nil {
@@ -204,10 +245,12 @@ func (h *OMSQueryHandler) GetOrderByID(c *gin.Context) {
 	query := `
 		SELECT
 			o."orderId", o."accountId", o."instrumentId", o.side, o.quantity,
-			o."orderType", o."limitPrice", o."curveSpreadBp", o."timeInForce",
-			o.state, o."batchId", o."complianceResult",
+			o."orderType", o."limitPrice", o."limitYield", o."limitPricedAsOf", o."curveSpreadBp", o."timeInForce",
+			o.state, o."batchId", o."blockId", o."complianceResult",
 			o."createdAt", o."createdBy", o."updatedAt", o."lastStateChangeAt",
-			o."sentToEmsAt", o."fullyFilledAt", o."settledAt",
+			o."sentToEmsAt", o."fullyFilledAt", o."settledAt", o."expireAt", o."expiredAt",
+			o."approvedBy", o."approvedAt", o."rejectedBy", o."rejectedAt", o."rejectionReason",
+			o."chainId", o."chainVersion", o."replacesOrderId", o."replacedByOrderId", o."nettingId",
 			i.name as "instrumentName", i.cusip, i.type as "instrumentType",
 			a.name as "accountName", a."householdId"
 		FROM orders o
@@ -224,10 +267,13 @@ func (h *OMSQueryHandler) GetOrderByID(c *gin.Context) {
 		quantity         float64
 		orderType        string
 		limitPrice       sql.NullFloat64
+		limitYield       sql.NullFloat64
+		limitPricedAsOf  sql.NullTime
 		curveSpreadBp    sql.NullFloat64
 		timeInForce      string
 		state            string
 		batchID          sql.NullString
+		blockID          sql.NullString
 		complianceResult []byte
 		createdAt        time.Time
 		createdBy        string
@@ -236,6 +282,18 @@ func (h *OMSQueryHandler) GetOrderByID(c *gin.Context) {
 		sentToEmsAt      sql.NullTime
 		fullyFilledAt    sql.NullTime
 		settledAt        sql.NullTime
+		expireAt         sql.NullTime
+		expiredAt        sql.NullTime
+		approvedBy       sql.NullString
+		approvedAt       sql.NullTime
+		rejectedBy       sql.NullString
+		rejectedAt       sql.NullTime
+		rejectionReason  sql.NullString
+		chainID          sql.NullString
+		chainVersion     sql.NullInt64
+		replacesOrderID  sql.NullString
+		replacedByOrderID sql.NullString
+		nettingID        sql.NullString
 		instrumentName   string
 		cusip            string
 		instrumentType   string
@@ -245,10 +303,12 @@ func (h *OMSQueryHandler) GetOrderByID(c *gin.Context) {
 
 	err := h.db.QueryRow(query, orderID).Scan(
 		&orderIDVal, &accountID, &instrumentID, &side, &quantity,
-		&orderType, &limitPrice, &curveSpreadBp, &timeInForce,
-		&state, &batchID, &complianceResult,
+		&orderType, &limitPrice, &limitYield, &limitPricedAsOf, &curveSpreadBp, &timeInForce,
+		&state, &batchID, &blockID, &complianceResult,
 		&createdAt, &createdBy, &updatedAt, &lastStateChangeAt,
-		&sentToEmsAt, &fullyFilledAt, &settledAt,
+		&sentToEmsAt, &fullyFilledAt, &settledAt, &expireAt, &expiredAt,
+		&approvedBy, &approvedAt, &rejectedBy, &rejectedAt, &rejectionReason,
+		&chainID, &chainVersion, &replacesOrderID, &replacedByOrderID, &nettingID,
 		&instrumentName, &cusip, &instrumentType,
 		&accountName, &householdIDVal,
 	)
@@ -285,12 +345,21 @@ func (h *OMSQueryHandler) GetOrderByID(c *gin.Context) {
 	if limitPrice.Valid {
 		order["limitPrice"] = limitPrice.Float64
 	}
+	if limitYield.Valid {
+		order["limitYield"] = limitYield.Float64
+	}
+	if limitPricedAsOf.Valid {
+		order["limitPricedAsOf"] = limitPricedAsOf.Time.Format("2006-01-02")
+	}
 	if curveSpreadBp.Valid {
 		order["curveSpreadBp"] = curveSpreadBp.Float64
 	}
 	if batchID.Valid {
 		order["batchId"] = batchID.String
 	}
+	if blockID.Valid {
+		order["blockId"] = blockID.String
+	}
 	if sentToEmsAt.Valid {
 		order["sentToEmsAt"] = sentToEmsAt.Time
 	}
@@ -300,12 +369,29 @@ func (h *OMSQueryHandler) GetOrderByID(c *gin.Context) {
 	if settledAt.Valid {
 		order["settledAt"] = settledAt.Time
 	}
+	if expireAt.Valid {
+		order["expireAt"] = expireAt.Time
+	}
+	if expiredAt.Valid {
+		order["expiredAt"] = expiredAt.Time
+	}
+	addApprovalAttribution(order, approvedBy, approvedAt, rejectedBy, rejectedAt, rejectionReason)
+	addChainLinks(order, chainID, chainVersion, replacesOrderID, replacedByOrderID)
 	if complianceResult != nil {
 		var cr interface{}
 		if err := json.Unmarshal(complianceResult, &cr); err == nil {
 			order["complianceResult"] = cr
 		}
 	}
+	if nettingID.Valid {
+		order["nettingId"] = nettingID.String
+		crossTrades, err := h.crossTrades(`"orderId" = $1`, orderIDVal)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		order["crossTrades"] = crossTrades
+	}
 
 	// Get event timeline for this order
 	events, err := h.eventStore.GetByAggregate("Order", orderID)
@@ -318,6 +404,187 @@ func (h *OMSQueryHandler) GetOrderByID(c *gin.Context) {
 	c.JSON(http.StatusOK, order)
 }
 
+// GetOrderHistory returns every version of an order's cancel/replace chain,
+// oldest first. Each version lists the field-level changes that produced it
+// and the amendments made to it, with who made them and when.
+func (h *OMSQueryHandler) GetOrderHistory(c *gin.Context) {
+	orderID := c.Param("id")
+
+	var chainID string
+	err := h.db.QueryRow(`
+		SELECT COALESCE("chainId", "orderId") FROM orders WHERE "orderId" = $1
+	`, orderID).Scan(&chainID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT "orderId", "chainVersion", state, quantity, "orderType", "limitPrice", "limitYield", "curveSpreadBp",
+			"timeInForce", "expireAt", "replacesOrderId", "replacedByOrderId", "replacedAt",
+			"createdAt", "createdBy"
+		FROM orders
+		WHERE COALESCE("chainId", "orderId") = $1
+		ORDER BY "chainVersion" ASC, "createdAt" ASC
+	`, chainID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	versions := []map[string]interface{}{}
+	versionByOrder := map[string]map[string]interface{}{}
+	latestOrderID := ""
+	for rows.Next() {
+		var (
+			versionOrderID    string
+			chainVersion      int
+			state             string
+			quantity          float64
+			orderType         string
+			limitPrice        sql.NullFloat64
+			limitYield        sql.NullFloat64
+			curveSpreadBp     sql.NullFloat64
+			timeInForce       string
+			expireAt          sql.NullTime
+			replacesOrderID   sql.NullString
+			replacedByOrderID sql.NullString
+			replacedAt        sql.NullTime
+			createdAt         time.Time
+			createdBy         string
+		)
+		if err := rows.Scan(
+			&versionOrderID, &chainVersion, &state, &quantity, &orderType, &limitPrice, &limitYield, &curveSpreadBp,
+			&timeInForce, &expireAt, &replacesOrderID, &replacedByOrderID, &replacedAt,
+			&createdAt, &createdBy,
+		); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		version := map[string]interface{}{
+			"orderId":        versionOrderID,
+			"version":        chainVersion,
+			"state":          state,
+			"quantity":       quantity,
+			"orderType":      orderType,
+			"timeInForce":    timeInForce,
+			"createdAt":      createdAt,
+			"createdBy":      createdBy,
+			"changes":        []interface{}{},
+			"amendments":     []interface{}{},
+		}
+		if limitPrice.Valid {
+			version["limitPrice"] = limitPrice.Float64
+		}
+		if limitYield.Valid {
+			version["limitYield"] = limitYield.Float64
+		}
+		if curveSpreadBp.Valid {
+			version["curveSpreadBp"] = curveSpreadBp.Float64
+		}
+		if expireAt.Valid {
+			version["expireAt"] = expireAt.Time
+		}
+		if replacesOrderID.Valid {
+			version["replacesOrderId"] = replacesOrderID.String
+		}
+		if replacedByOrderID.Valid {
+			version["replacedByOrderId"] = replacedByOrderID.String
+		} else {
+			latestOrderID = versionOrderID
+		}
+		if replacedAt.Valid {
+			version["replacedAt"] = replacedAt.Time
+		}
+
+		versions = append(versions, version)
+		versionByOrder[versionOrderID] = version
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	historyRows, err := h.db.Query(`
+		SELECT "orderId", "changeType", changes, "relatedOrderId", reason, actor, "occurredAt"
+		FROM order_history
+		WHERE "chainId" = $1
+		ORDER BY "occurredAt" ASC
+	`, chainID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer historyRows.Close()
+
+	for historyRows.Next() {
+		var (
+			entryOrderID   string
+			changeType     string
+			changesJSON    []byte
+			relatedOrderID sql.NullString
+			reason         sql.NullString
+			actor          string
+			occurredAt     time.Time
+		)
+		if err := historyRows.Scan(&entryOrderID, &changeType, &changesJSON, &relatedOrderID, &reason, &actor, &occurredAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var changes []interface{}
+		if err := json.Unmarshal(changesJSON, &changes); err != nil || changes == nil {
+			changes = []interface{}{}
+		}
+
+		switch changeType {
+		case "AMENDED":
+			version, ok := versionByOrder[entryOrderID]
+			if !ok {
+				continue
+			}
+			version["amendments"] = append(version["amendments"].([]interface{}), map[string]interface{}{
+				"changes":   changes,
+				"amendedBy": actor,
+				"amendedAt": occurredAt,
+			})
+		case "REPLACED":
+			// The diff recorded when a version is replaced belongs to the
+			// version that replaced it
+			if !relatedOrderID.Valid {
+				continue
+			}
+			version, ok := versionByOrder[relatedOrderID.String]
+			if !ok {
+				continue
+			}
+			version["changes"] = changes
+			version["replacedBy"] = actor
+			if reason.Valid {
+				version["reason"] = reason.String
+			}
+		}
+	}
+	if err := historyRows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orderId":       orderID,
+		"chainId":       chainID,
+		"latestOrderId": latestOrderID,
+		"versions":      versions,
+		"count":         len(versions),
+	})
+}
+
 // GetOrdersByBatchID returns all orders in a batch
 func (h *OMSQueryHandler) GetOrdersByBatchID(c *gin.Context) {
 	batchID := c.Param("batchId")
@@ -325,10 +592,11 @@ func (h *OMSQueryHandler) GetOrdersByBatchID(c *gin.Context) {
 	query := `
 		SELECT
 			o."orderId", o."accountId", o."instrumentId", o.side, o.quantity,
-			o."orderType", o."limitPrice", o."curveSpreadBp", o."timeInForce",
-			o.state, o."batchId", o."complianceResult",
+			o."orderType", o."limitPrice", o."limitYield", o."limitPricedAsOf", o."curveSpreadBp", o."timeInForce",
+			o.state, o."batchId", o."blockId", o."complianceResult",
 			o."createdAt", o."createdBy", o."updatedAt", o."lastStateChangeAt",
-			o."sentToEmsAt", o."fullyFilledAt", o."settledAt"
+			o."sentToEmsAt", o."fullyFilledAt", o."settledAt", o."expireAt", o."expiredAt",
+			o."approvedBy", o."approvedAt", o."rejectedBy", o."rejectedAt", o."rejectionReason"
 		FROM orders o
 		WHERE o."batchId" = $1
 		ORDER BY o."createdAt" ASC
@@ -346,19 +614,30 @@ func (h *OMSQueryHandler) GetOrdersByBatchID(c *gin.Context) {
 	for rows.Next() {
 		var order oms.Order
 		var limitPrice sql.NullFloat64
+		var limitYield sql.NullFloat64
+		var limitPricedAsOf sql.NullTime
 		var curveSpreadBp sql.NullFloat64
 		var batchIDVal sql.NullString
+		var blockIDVal sql.NullString
 		var complianceResult []byte
 		var sentToEmsAt sql.NullTime
 		var fullyFilledAt sql.NullTime
 		var settledAt sql.NullTime
+		var expireAt sql.NullTime
+		var expiredAt sql.NullTime
+		var approvedBy sql.NullString
+		var approvedAt sql.NullTime
+		var rejectedBy sql.NullString
+		var rejectedAt sql.NullTime
+		var rejectionReason sql.NullString
 
 		err := rows.Scan(
 			&order.OrderID, &order.AccountID, &order.InstrumentID, &order.Side, &order.Quantity,
-			&order.OrderType, &limitPrice, &curveSpreadBp, &order.TimeInForce,
-			&order.State, &batchIDVal, &complianceResult,
+			&order.OrderType, &limitPrice, &limitYield, &limitPricedAsOf, &curveSpreadBp, &order.TimeInForce,
+			&order.State, &batchIDVal, &blockIDVal, &complianceResult,
 			&order.CreatedAt, &order.CreatedBy, &order.UpdatedAt, &order.LastStateChangeAt,
-			&sentToEmsAt, &fullyFilledAt, &settledAt,
+			&sentToEmsAt, &fullyFilledAt, &settledAt, &expireAt, &expiredAt,
+			&approvedBy, &approvedAt, &rejectedBy, &rejectedAt, &rejectionReason,
 		)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -369,6 +648,10 @@ func (h *OMSQueryHandler) GetOrdersByBatchID(c *gin.Context) {
 			val := limitPrice.Float64
 			order.LimitPrice = &val
 		}
+		if limitYield.Valid {
+			val := limitYield.Float64
+			order.LimitYield = &val
+		}
 		if curveSpreadBp.Valid {
 			val := curveSpreadBp.Float64
 			order.CurveSpreadBp = &val
@@ -376,6 +659,9 @@ func (h *OMSQueryHandler) GetOrdersByBatchID(c *gin.Context) {
 		if batchIDVal.Valid {
 			order.BatchID = &batchIDVal.String
 		}
+		if blockIDVal.Valid {
+			order.BlockID = &blockIDVal.String
+		}
 		if sentToEmsAt.Valid {
 			order.SentToEmsAt = &sentToEmsAt.Time
 		}
@@ -385,6 +671,27 @@ func (h *OMSQueryHandler) GetOrdersByBatchID(c *gin.Context) {
 		if settledAt.Valid {
 			order.SettledAt = &settledAt.Time
 		}
+		if expireAt.Valid {
+			order.ExpireAt = &expireAt.Time
+		}
+		if expiredAt.Valid {
+			order.ExpiredAt = &expiredAt.Time
+		}
+		if approvedBy.Valid {
+			order.ApprovedBy = &approvedBy.String
+		}
+		if approvedAt.Valid {
+			order.ApprovedAt = &approvedAt.Time
+		}
+		if rejectedBy.Valid {
+			order.RejectedBy = &rejectedBy.String
+		}
+		if rejectedAt.Valid {
+			order.RejectedAt = &rejectedAt.Time
+		}
+		if rejectionReason.Valid {
+			order.RejectionReason = &rejectionReason.String
+		}
 		if complianceResult != nil {
 			var cr oms.ComplianceResult
 			if err := json.Unmarshal(complianceResult, &cr); err == nil {
@@ -401,3 +708,551 @@ func (h *OMSQueryHandler) GetOrdersByBatchID(c *gin.Context) {
 		"count":   len(orders),
 	})
 }
+
+// GetApprovalQueue returns orders awaiting approval, oldest first, with how
+// long each has been waiting and any compliance warnings behind the request
+func (h *OMSQueryHandler) GetApprovalQueue(c *gin.Context) {
+	accountID := c.Query("accountId")
+
+	query := `
+		SELECT
+			o."orderId", o."accountId", o."instrumentId", o.side, o.quantity,
+			o."orderType", o."limitPrice", o."timeInForce", o."complianceResult",
+			o."createdAt", o."createdBy", o."lastStateChangeAt",
+			o."requiredApprovals", o.approvals,
+			COALESCE(i.name, ''), COALESCE(a.name, '')
+		FROM orders o
+		LEFT JOIN instruments i ON o."instrumentId" = i.cusip
+		LEFT JOIN accounts a ON o."accountId" = a."accountId"
+		WHERE o.state = 'APPROVAL_PENDING'
+	`
+	args := []interface{}{}
+	if accountID != "" {
+		query += ` AND o."accountId" = $1`
+		args = append(args, accountID)
+	}
+	query += ` ORDER BY o."lastStateChangeAt" ASC`
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	now := time.Now().UTC()
+	approvals := []map[string]interface{}{}
+
+	for rows.Next() {
+		var (
+			orderID          string
+			accountIDVal     string
+			instrumentID     string
+			side             string
+			quantity         float64
+			orderType        string
+			limitPrice       sql.NullFloat64
+			timeInForce      string
+			complianceResult []byte
+			createdAt        time.Time
+			createdBy        string
+			pendingSince     time.Time
+			required         int
+			approvalsJSON    []byte
+			instrumentName   string
+			accountName      string
+		)
+
+		if err := rows.Scan(
+			&orderID, &accountIDVal, &instrumentID, &side, &quantity,
+			&orderType, &limitPrice, &timeInForce, &complianceResult,
+			&createdAt, &createdBy, &pendingSince,
+			&required, &approvalsJSON,
+			&instrumentName, &accountName,
+		); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		approval := map[string]interface{}{
+			"orderId":           orderID,
+			"accountId":         accountIDVal,
+			"accountName":       accountName,
+			"instrumentId":      instrumentID,
+			"instrumentName":    instrumentName,
+			"side":              side,
+			"quantity":          quantity,
+			"orderType":         orderType,
+			"timeInForce":       timeInForce,
+			"createdAt":         createdAt,
+			"createdBy":         createdBy,
+			"pendingSince":      pendingSince,
+			"ageSeconds":        int64(now.Sub(pendingSince).Seconds()),
+			"requiredApprovals": required,
+			"approvals":         []map[string]interface{}{},
+			"warnings":          []oms.ComplianceViolation{},
+		}
+		var approvalsGiven []map[string]interface{}
+		if err := json.Unmarshal(approvalsJSON, &approvalsGiven); err == nil && approvalsGiven != nil {
+			approval["approvals"] = approvalsGiven
+		}
+		if limitPrice.Valid {
+			approval["limitPrice"] = limitPrice.Float64
+		}
+		if complianceResult != nil {
+			var cr oms.ComplianceResult
+			if err := json.Unmarshal(complianceResult, &cr); err == nil {
+				approval["complianceStatus"] = cr.Status
+				if cr.Warnings != nil {
+					approval["warnings"] = cr.Warnings
+				}
+			}
+		}
+
+		approvals = append(approvals, approval)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"approvals": approvals,
+		"count":     len(approvals),
+	})
+}
+
+// blockOrderColumns are the block_orders columns read by scanBlockOrder
+const blockOrderColumns = `
+	b."blockId", b."instrumentId", b.side, b.quantity, b."orderType", b."limitPrice",
+	b."curveSpreadBp", b."timeInForce", b."lotSize", b.state, b."filledQuantity",
+	b."avgFillPrice", b."createdAt", b."createdBy", b."updatedAt", b."sentToEmsAt",
+	b."settledAt", COALESCE(i.name, ''), b."limitYield"
+`
+
+// scanBlockOrder reads one block order row selected with blockOrderColumns
+func scanBlockOrder(row interface{ Scan(...interface{}) error }) (map[string]interface{}, error) {
+	var (
+		blockID        string
+		instrumentID   string
+		side           string
+		quantity       float64
+		orderType      string
+		limitPrice     sql.NullFloat64
+		curveSpreadBp  sql.NullFloat64
+		timeInForce    string
+		lotSize        float64
+		state          string
+		filledQuantity float64
+		avgFillPrice   sql.NullFloat64
+		createdAt      time.Time
+		createdBy      string
+		updatedAt      time.Time
+		sentToEmsAt    sql.NullTime
+		settledAt      sql.NullTime
+		instrumentName string
+		limitYield     sql.NullFloat64
+	)
+
+	if err := row.Scan(
+		&blockID, &instrumentID, &side, &quantity, &orderType, &limitPrice,
+		&curveSpreadBp, &timeInForce, &lotSize, &state, &filledQuantity,
+		&avgFillPrice, &createdAt, &createdBy, &updatedAt, &sentToEmsAt,
+		&settledAt, &instrumentName, &limitYield,
+	); err != nil {
+		return nil, err
+	}
+
+	block := map[string]interface{}{
+		"blockId":        blockID,
+		"instrumentId":   instrumentID,
+		"instrumentName": instrumentName,
+		"side":           side,
+		"quantity":       quantity,
+		"orderType":      orderType,
+		"timeInForce":    timeInForce,
+		"lotSize":        lotSize,
+		"state":          state,
+		"filledQuantity": filledQuantity,
+		"createdAt":      createdAt,
+		"createdBy":      createdBy,
+		"updatedAt":      updatedAt,
+	}
+	if limitPrice.Valid {
+		block["limitPrice"] = limitPrice.Float64
+	}
+	if limitYield.Valid {
+		block["limitYield"] = limitYield.Float64
+	}
+	if curveSpreadBp.Valid {
+		block["curveSpreadBp"] = curveSpreadBp.Float64
+	}
+	if avgFillPrice.Valid {
+		block["avgFillPrice"] = avgFillPrice.Float64
+	}
+	if sentToEmsAt.Valid {
+		block["sentToEmsAt"] = sentToEmsAt.Time
+	}
+	if settledAt.Valid {
+		block["settledAt"] = settledAt.Time
+	}
+
+	return block, nil
+}
+
+// GetBlockOrders returns block orders, newest first
+func (h *OMSQueryHandler) GetBlockOrders(c *gin.Context) {
+	state := c.Query("state")
+	limit := c.DefaultQuery("limit", "100")
+	offset := c.DefaultQuery("offset", "0")
+
+	query := `SELECT ` + blockOrderColumns + `
+		FROM block_orders b
+		LEFT JOIN instruments i ON b."instrumentId" = i.cusip
+		WHERE 1=1
+	`
+
+	args := []interface{}{}
+	argPos := 1
+
+	if state != "" {
+		query += fmt.Sprintf(` AND b.state = $%d`, argPos)
+		args = append(args, state)
+		argPos++
+	}
+
+	query += ` ORDER BY b."createdAt" DESC`
+	query += fmt.Sprintf(` LIMIT $%d OFFSET $%d`, argPos, argPos+1)
+	args = append(args, limit, offset)
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	blocks := []map[string]interface{}{}
+	for rows.Next() {
+		block, err := scanBlockOrder(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		blocks = append(blocks, block)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"blocks": blocks,
+		"count":  len(blocks),
+	})
+}
+
+// GetBlockOrderByID returns a block order with its child orders and the
+// allocations booked to each account
+func (h *OMSQueryHandler) GetBlockOrderByID(c *gin.Context) {
+	blockID := c.Param("id")
+
+	query := `SELECT ` + blockOrderColumns + `
+		FROM block_orders b
+		LEFT JOIN instruments i ON b."instrumentId" = i.cusip
+		WHERE b."blockId" = $1
+	`
+
+	block, err := scanBlockOrder(h.db.QueryRow(query, blockID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "block order not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	childRows, err := h.db.Query(`
+		SELECT o."orderId", o."accountId", COALESCE(a.name, ''), o.quantity, o.state
+		FROM orders o
+		LEFT JOIN accounts a ON o."accountId" = a."accountId"
+		WHERE o."blockId" = $1
+		ORDER BY o."createdAt" ASC
+	`, blockID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer childRows.Close()
+
+	orders := []map[string]interface{}{}
+	for childRows.Next() {
+		var (
+			orderID     string
+			accountID   string
+			accountName string
+			quantity    float64
+			state       string
+		)
+		if err := childRows.Scan(&orderID, &accountID, &accountName, &quantity, &state); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		orders = append(orders, map[string]interface{}{
+			"orderId":     orderID,
+			"accountId":   accountID,
+			"accountName": accountName,
+			"quantity":    quantity,
+			"state":       state,
+		})
+	}
+	block["orders"] = orders
+
+	allocationRows, err := h.db.Query(`
+		SELECT "allocationId", "executionId", "orderId", "accountId", quantity,
+			"targetQuantity", price, "settlementDate", "createdAt"
+		FROM allocations
+		WHERE "blockId" = $1
+		ORDER BY "createdAt" ASC
+	`, blockID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer allocationRows.Close()
+
+	allocations := []map[string]interface{}{}
+	for allocationRows.Next() {
+		var (
+			allocationID   string
+			executionID    string
+			orderID        string
+			accountID      string
+			quantity       float64
+			targetQuantity float64
+			price          float64
+			settlementDate time.Time
+			createdAt      time.Time
+		)
+		if err := allocationRows.Scan(
+			&allocationID, &executionID, &orderID, &accountID, &quantity,
+			&targetQuantity, &price, &settlementDate, &createdAt,
+		); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		allocations = append(allocations, map[string]interface{}{
+			"allocationId":   allocationID,
+			"executionId":    executionID,
+			"orderId":        orderID,
+			"accountId":      accountID,
+			"quantity":       quantity,
+			"targetQuantity": targetQuantity,
+			"price":          price,
+			"settlementDate": settlementDate,
+			"createdAt":      createdAt,
+		})
+	}
+	block["allocations"] = allocations
+
+	c.JSON(http.StatusOK, block)
+}
+
+// GetUploadBatch returns an uploaded order file with each row's validation
+// result and, once confirmed, the orders created from it
+func (h *OMSQueryHandler) GetUploadBatch(c *gin.Context) {
+	batchID := c.Param("id")
+
+	var (
+		fileName     string
+		format       string
+		status       string
+		rowCount     int
+		validCount   int
+		errorCount   int
+		rowsJSON     []byte
+		ordersJSON   []byte
+		createdCount int
+		failedCount  int
+		uploadedBy   string
+		confirmedBy  sql.NullString
+		confirmedAt  sql.NullTime
+		createdAt    time.Time
+	)
+
+	err := h.db.QueryRow(`
+		SELECT "fileName", format, status, "rowCount", "validCount", "errorCount", rows, orders,
+			"createdCount", "failedCount", "uploadedBy", "confirmedBy", "confirmedAt", "createdAt"
+		FROM upload_batches
+		WHERE "batchId" = $1
+	`, batchID).Scan(
+		&fileName, &format, &status, &rowCount, &validCount, &errorCount, &rowsJSON, &ordersJSON,
+		&createdCount, &failedCount, &uploadedBy, &confirmedBy, &confirmedAt, &createdAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "upload batch not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	batch := map[string]interface{}{
+		"batchId":      batchID,
+		"fileName":     fileName,
+		"format":       format,
+		"status":       status,
+		"rowCount":     rowCount,
+		"validCount":   validCount,
+		"errorCount":   errorCount,
+		"rows":         []interface{}{},
+		"orders":       []interface{}{},
+		"createdCount": createdCount,
+		"failedCount":  failedCount,
+		"uploadedBy":   uploadedBy,
+		"createdAt":    createdAt,
+	}
+	var rows []interface{}
+	if err := json.Unmarshal(rowsJSON, &rows); err == nil && rows != nil {
+		batch["rows"] = rows
+	}
+	var orders []interface{}
+	if err := json.Unmarshal(ordersJSON, &orders); err == nil && orders != nil {
+		batch["orders"] = orders
+	}
+	if confirmedBy.Valid {
+		batch["confirmedBy"] = confirmedBy.String
+	}
+	if confirmedAt.Valid {
+		batch["confirmedAt"] = confirmedAt.Time
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
+
+// addApprovalAttribution adds who approved or rejected an order to its view
+// addChainLinks adds an order's place in its cancel/replace chain to a view
+func addChainLinks(order map[string]interface{}, chainID sql.NullString, chainVersion sql.NullInt64, replacesOrderID, replacedByOrderID sql.NullString) {
+	order["chainId"] = order["orderId"]
+	if chainID.Valid {
+		order["chainId"] = chainID.String
+	}
+	order["chainVersion"] = int64(1)
+	if chainVersion.Valid {
+		order["chainVersion"] = chainVersion.Int64
+	}
+	if replacesOrderID.Valid {
+		order["replacesOrderId"] = replacesOrderID.String
+	}
+	if replacedByOrderID.Valid {
+		order["replacedByOrderId"] = replacedByOrderID.String
+	}
+}
+
+func addApprovalAttribution(order map[string]interface{}, approvedBy sql.NullString, approvedAt sql.NullTime, rejectedBy sql.NullString, rejectedAt sql.NullTime, rejectionReason sql.NullString) {
+	if approvedBy.Valid {
+		order["approvedBy"] = approvedBy.String
+	}
+	if approvedAt.Valid {
+		order["approvedAt"] = approvedAt.Time
+	}
+	if rejectedBy.Valid {
+		order["rejectedBy"] = rejectedBy.String
+	}
+	if rejectedAt.Valid {
+		order["rejectedAt"] = rejectedAt.Time
+	}
+	if rejectionReason.Valid {
+		order["rejectionReason"] = rejectionReason.String
+	}
+}
+
+// GetNettingRun returns the orders sent through a netting run and the cross
+// trades it made between them
+func (h *OMSQueryHandler) GetNettingRun(c *gin.Context) {
+	nettingID := c.Param("id")
+
+	rows, err := h.db.Query(`
+		SELECT "orderId", "accountId", "instrumentId", side, quantity, state
+		FROM orders
+		WHERE "nettingId" = $1
+		ORDER BY "createdAt"
+	`, nettingID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	orders := []map[string]interface{}{}
+	for rows.Next() {
+		var orderID, accountID, instrumentID, side, state string
+		var quantity float64
+		if err := rows.Scan(&orderID, &accountID, &instrumentID, &side, &quantity, &state); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		orders = append(orders, map[string]interface{}{
+			"orderId":      orderID,
+			"accountId":    accountID,
+			"instrumentId": instrumentID,
+			"side":         side,
+			"quantity":     quantity,
+			"state":        state,
+		})
+	}
+	if len(orders) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "netting run not found"})
+		return
+	}
+
+	crossTrades, err := h.crossTrades(`"nettingId" = $1`, nettingID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"nettingId":   nettingID,
+		"orders":      orders,
+		"crossTrades": crossTrades,
+	})
+}
+
+// crossTrades lists cross trade legs matching the filter, oldest first
+func (h *OMSQueryHandler) crossTrades(filter string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := h.db.Query(`
+		SELECT "crossId", "nettingId", "orderId", "accountId", "instrumentId", side,
+			"counterpartyOrderId", "counterpartyAccountId", quantity, price, "priceAsOf", "executedAt"
+		FROM cross_trades
+		WHERE `+filter+`
+		ORDER BY "executedAt", "crossId", side
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	legs := []map[string]interface{}{}
+	for rows.Next() {
+		var (
+			crossID, nettingID, orderID, accountID, instrumentID, side string
+			counterpartyOrderID, counterpartyAccountID                 string
+			quantity, price                                            float64
+			priceAsOf, executedAt                                      time.Time
+		)
+		if err := rows.Scan(&crossID, &nettingID, &orderID, &accountID, &instrumentID, &side,
+			&counterpartyOrderID, &counterpartyAccountID, &quantity, &price, &priceAsOf, &executedAt); err != nil {
+			return nil, err
+		}
+		legs = append(legs, map[string]interface{}{
+			"crossId":               crossID,
+			"nettingId":             nettingID,
+			"orderId":               orderID,
+			"accountId":             accountID,
+			"instrumentId":          instrumentID,
+			"side":                  side,
+			"counterpartyOrderId":   counterpartyOrderID,
+			"counterpartyAccountId": counterpartyAccountID,
+			"quantity":              quantity,
+			"price":                 price,
+			"priceAsOf":             priceAsOf.Format("2006-01-02"),
+			"executedAt":            executedAt,
+		})
+	}
+	return legs, rows.Err()
+}
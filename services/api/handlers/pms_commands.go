@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
+	"instant/services/api/eventstore"
 	"instant/services/api/pms"
 
 	"github.com/gin-gonic/gin"
@@ -13,12 +15,14 @@ import (
 // PMSCommandHandler handles PMS-specific commands.
 type PMSCommandHandler struct {
 	pmsService *pms.Service
+	eventStore *eventstore.EventStore
 }
 
 // NewPMSCommandHandler creates a new PMS command handler.
-func NewPMSCommandHandler(pmsService *pms.Service) *PMSCommandHandler {
+func NewPMSCommandHandler(pmsService *pms.Service, eventStore *eventstore.EventStore) *PMSCommandHandler {
 	return &PMSCommandHandler{
 		pmsService: pmsService,
+		eventStore: eventStore,
 	}
 }
 
@@ -44,6 +48,7 @@ func (h *PMSCommandHandler) HandleCreateHousehold(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{
 		"householdId":  householdID,
 		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
 		"status":       "created",
 	})
 }
@@ -74,6 +79,7 @@ func (h *PMSCommandHandler) HandleSetTarget(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{
 		"targetId":      targetID,
 		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
 		"status":        "set",
 	})
 }
@@ -100,6 +106,7 @@ func (h *PMSCommandHandler) HandleRunOptimization(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{
 		"proposalId":    proposalID,
 		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
 		"status":        "generated",
 	})
 }
@@ -134,6 +141,7 @@ func (h *PMSCommandHandler) HandleApproveProposal(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"proposalId":   proposalID,
 		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
 		"status":       "approved",
 	})
 }
@@ -168,6 +176,70 @@ func (h *PMSCommandHandler) HandleSendProposalToOMS(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"proposalId":   proposalID,
 		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
 		"status":       "sent_to_oms",
 	})
 }
+
+// HandleAdjustCash handles AdjustCash command.
+func (h *PMSCommandHandler) HandleAdjustCash(c *gin.Context) {
+	var req pms.AdjustCashRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.AccountID = c.Param("id")
+
+	correlationID := c.GetHeader("X-Correlation-ID")
+	if correlationID == "" {
+		correlationID = uuid.New().String()
+	}
+
+	if err := h.pmsService.AdjustCash(req, correlationID); err != nil {
+		respondAccountCommandError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"accountId":     req.AccountID,
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "cash_adjusted",
+	})
+}
+
+// HandleSetShortSales handles SetShortSales command.
+func (h *PMSCommandHandler) HandleSetShortSales(c *gin.Context) {
+	var req pms.SetShortSalesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.AccountID = c.Param("id")
+
+	correlationID := c.GetHeader("X-Correlation-ID")
+	if correlationID == "" {
+		correlationID = uuid.New().String()
+	}
+
+	if err := h.pmsService.SetShortSales(req, correlationID); err != nil {
+		respondAccountCommandError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"accountId":       req.AccountID,
+		"allowShortSales": req.AllowShortSales,
+		"correlationId":   correlationID,
+		"position":        writtenPosition(h.eventStore, correlationID),
+		"status":          "updated",
+	})
+}
+
+func respondAccountCommandError(c *gin.Context, err error) {
+	if errors.Is(err, pms.ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
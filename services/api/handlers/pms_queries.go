@@ -51,6 +51,9 @@ func (h *PMSQueryHandler) GetAccountView(c *gin.Context) {
 	}
 
 	analytics := computeAnalyticsWithBuckets(positions, asOfDate)
+	if cash, ok := account["cashBalance"].(float64); ok {
+		analytics.withCash(cash)
+	}
 	viewAsOf := latestUpdate
 	if !asOfDate.IsZero() {
 		viewAsOf = asOfDate
@@ -520,17 +523,19 @@ func (h *PMSQueryHandler) GetDriftView(c *gin.Context) {
 }
 
 type positionRow struct {
-	AccountID    string       `json:"accountId"`
-	InstrumentID string       `json:"instrumentId"`
-	Cusip        string       `json:"cusip"`
-	Description  string       `json:"description"`
-	Quantity     float64      `json:"quantity"`
-	AvgCost      float64      `json:"avgCost"`
-	MarketValue  float64      `json:"marketValue"`
-	Duration     float64      `json:"duration"`
-	Dv01         float64      `json:"dv01"`
-	MaturityDate sql.NullTime `json:"maturityDate"`
-	UpdatedAt    time.Time    `json:"updatedAt"`
+	AccountID         string       `json:"accountId"`
+	InstrumentID      string       `json:"instrumentId"`
+	Cusip             string       `json:"cusip"`
+	Description       string       `json:"description"`
+	Quantity          float64      `json:"quantity"`
+	SettledQuantity   float64      `json:"settledQuantity"`
+	UnsettledQuantity float64      `json:"unsettledQuantity"`
+	AvgCost           float64      `json:"avgCost"`
+	MarketValue       float64      `json:"marketValue"`
+	Duration          float64      `json:"duration"`
+	Dv01              float64      `json:"dv01"`
+	MaturityDate      sql.NullTime `json:"maturityDate"`
+	UpdatedAt         time.Time    `json:"updatedAt"`
 }
 
 type targetRow struct {
@@ -541,18 +546,21 @@ type targetRow struct {
 
 func (h *PMSQueryHandler) fetchAccount(accountID string) (map[string]interface{}, error) {
 	query := `
-		SELECT "accountId", "householdId", name, "accountType", "modelId", "createdAt", "createdBy"
+		SELECT "accountId", "householdId", name, "accountType", "modelId", "createdAt", "createdBy",
+		       "cashBalance", "allowShortSales"
 		FROM accounts
 		WHERE "accountId" = $1
 	`
 
 	var (
-		householdID string
-		name        string
-		accountType string
-		modelID     sql.NullString
-		createdAt   time.Time
-		createdBy   string
+		householdID     string
+		name            string
+		accountType     string
+		modelID         sql.NullString
+		createdAt       time.Time
+		createdBy       string
+		cashBalance     sql.NullFloat64
+		allowShortSales bool
 	)
 
 	if err := h.db.QueryRow(query, accountID).Scan(
@@ -563,21 +571,27 @@ func (h *PMSQueryHandler) fetchAccount(accountID string) (map[string]interface{}
 		&modelID,
 		&createdAt,
 		&createdBy,
+		&cashBalance,
+		&allowShortSales,
 	); err != nil {
 		return nil, fmt.Errorf("account not found")
 	}
 
 	account := map[string]interface{}{
-		"accountId":   accountID,
-		"householdId": householdID,
-		"name":        name,
-		"accountType": accountType,
-		"createdAt":   createdAt,
-		"createdBy":   createdBy,
+		"accountId":       accountID,
+		"householdId":     householdID,
+		"name":            name,
+		"accountType":     accountType,
+		"createdAt":       createdAt,
+		"createdBy":       createdBy,
+		"allowShortSales": allowShortSales,
 	}
 	if modelID.Valid {
 		account["modelId"] = modelID.String
 	}
+	if cashBalance.Valid {
+		account["cashBalance"] = cashBalance.Float64
+	}
 
 	return account, nil
 }
@@ -704,7 +718,7 @@ func (h *PMSQueryHandler) fetchAllAccounts() ([]map[string]interface{}, error) {
 
 func (h *PMSQueryHandler) fetchPositionsByAccount(accountID string) ([]positionRow, time.Time, error) {
 	query := `
-		SELECT p."accountId", p."instrumentId", p.quantity, p."avgCost", p."marketValue", p.duration, p.dv01,
+		SELECT p."accountId", p."instrumentId", p.quantity, p."settledQuantity", p."avgCost", p."marketValue", p.duration, p.dv01,
 		       i.name, i.cusip, i."maturityDate", p."updatedAt"
 		FROM positions p
 		LEFT JOIN instruments i ON p."instrumentId" = i.cusip
@@ -724,6 +738,7 @@ func (h *PMSQueryHandler) positionsFromExecutions(accountID string) ([]positionR
 	query := `
 		SELECT e."instrumentId",
 		       SUM(CASE WHEN e.side = 'SELL' THEN -e."filledQuantity" ELSE e."filledQuantity" END) AS "netQuantity",
+		       SUM(CASE WHEN e.status <> 'SETTLED' THEN 0 WHEN e.side = 'SELL' THEN -e."filledQuantity" ELSE e."filledQuantity" END) AS "settledQuantity",
 		       SUM(e."filledQuantity" * COALESCE(e."avgFillPrice", 0)) AS "weightedCost",
 		       SUM(e."filledQuantity") AS "totalQty",
 		       i.name, i.cusip, i."maturityDate",
@@ -748,6 +763,7 @@ func (h *PMSQueryHandler) positionsFromExecutions(accountID string) ([]positionR
 		var (
 			instrumentID string
 			netQuantity  float64
+			settledQty   float64
 			weightedCost float64
 			totalQty     float64
 			description  sql.NullString
@@ -760,6 +776,7 @@ func (h *PMSQueryHandler) positionsFromExecutions(accountID string) ([]positionR
 		if err := rows.Scan(
 			&instrumentID,
 			&netQuantity,
+			&settledQty,
 			&weightedCost,
 			&totalQty,
 			&description,
@@ -792,17 +809,19 @@ func (h *PMSQueryHandler) positionsFromExecutions(accountID string) ([]positionR
 		dv01 := marketValue * duration * 0.0001
 
 		rebuilt = append(rebuilt, positionRow{
-			AccountID:    accountID,
-			InstrumentID: instrumentID,
-			Cusip:        cusip.String,
-			Description:  description.String,
-			Quantity:     netQuantity,
-			AvgCost:      avgCost,
-			MarketValue:  marketValue,
-			Duration:     duration,
-			Dv01:         dv01,
-			MaturityDate: maturityDate,
-			UpdatedAt:    latestUpdate,
+			AccountID:         accountID,
+			InstrumentID:      instrumentID,
+			Cusip:             cusip.String,
+			Description:       description.String,
+			Quantity:          netQuantity,
+			SettledQuantity:   settledQty,
+			UnsettledQuantity: netQuantity - settledQty,
+			AvgCost:           avgCost,
+			MarketValue:       marketValue,
+			Duration:          duration,
+			Dv01:              dv01,
+			MaturityDate:      maturityDate,
+			UpdatedAt:         latestUpdate,
 		})
 	}
 
@@ -811,7 +830,7 @@ func (h *PMSQueryHandler) positionsFromExecutions(accountID string) ([]positionR
 
 func (h *PMSQueryHandler) fetchPositionsByHousehold(householdID string) ([]positionRow, time.Time, error) {
 	query := `
-		SELECT p."accountId", p."instrumentId", p.quantity, p."avgCost", p."marketValue", p.duration, p.dv01,
+		SELECT p."accountId", p."instrumentId", p.quantity, p."settledQuantity", p."avgCost", p."marketValue", p.duration, p.dv01,
 		       i.name, i.cusip, i."maturityDate", p."updatedAt"
 		FROM positions p
 		LEFT JOIN instruments i ON p."instrumentId" = i.cusip
@@ -837,6 +856,7 @@ func scanPositions(rows *sql.Rows) ([]positionRow, time.Time, error) {
 			&row.AccountID,
 			&row.InstrumentID,
 			&row.Quantity,
+			&row.SettledQuantity,
 			&row.AvgCost,
 			&row.MarketValue,
 			&row.Duration,
@@ -851,6 +871,7 @@ func scanPositions(rows *sql.Rows) ([]positionRow, time.Time, error) {
 		if row.UpdatedAt.After(latestUpdate) {
 			latestUpdate = row.UpdatedAt
 		}
+		row.UnsettledQuantity = row.Quantity - row.SettledQuantity
 		positions = append(positions, row)
 	}
 
@@ -874,6 +895,8 @@ func aggregatePositions(positions []positionRow) []positionRow {
 			avgCost = (entry.AvgCost*entry.Quantity + pos.AvgCost*pos.Quantity) / totalQty
 		}
 		entry.Quantity = totalQty
+		entry.SettledQuantity += pos.SettledQuantity
+		entry.UnsettledQuantity += pos.UnsettledQuantity
 		entry.MarketValue = totalValue
 		entry.Dv01 += pos.Dv01
 		entry.AvgCost = avgCost
@@ -942,6 +965,14 @@ func computeAnalyticsWithBuckets(positions []positionRow, asOfDate time.Time) an
 	}
 }
 
+// withCash sets the cash balance and its share of positions plus cash
+func (a *analyticsWithBuckets) withCash(cash float64) {
+	a.CashBalance = cash
+	if total := a.TotalMarketValue + cash; total > 0 {
+		a.CashPercentage = math.Round((cash/total)*100*100) / 100
+	}
+}
+
 func maturityBucket(maturity time.Time, asOfDate time.Time) string {
 	ref := time.Now().UTC()
 	if !asOfDate.IsZero() {
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"instant/services/api/projections"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProjectionCommandHandler handles operator actions on projection dead letters.
+type ProjectionCommandHandler struct {
+	deadLetters *projections.DeadLetterQueue
+}
+
+// NewProjectionCommandHandler creates a new projection command handler.
+func NewProjectionCommandHandler(deadLetters *projections.DeadLetterQueue) *ProjectionCommandHandler {
+	return &ProjectionCommandHandler{deadLetters: deadLetters}
+}
+
+// HandleRetryDeadLetter queues a dead letter for the running projection to retry.
+func (h *ProjectionCommandHandler) HandleRetryDeadLetter(c *gin.Context) {
+	projection := c.Param("name")
+	id := c.Param("id")
+
+	if err := h.deadLetters.Requeue(projection, id); err != nil {
+		c.JSON(deadLetterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"id":         id,
+		"projection": projection,
+		"status":     "retry_queued",
+	})
+}
+
+// HandleSkipDeadLetter marks a dead letter as intentionally not applied.
+func (h *ProjectionCommandHandler) HandleSkipDeadLetter(c *gin.Context) {
+	var req struct {
+		SkippedBy string `json:"skippedBy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	projection := c.Param("name")
+	id := c.Param("id")
+
+	if err := h.deadLetters.Skip(projection, id, req.SkippedBy); err != nil {
+		c.JSON(deadLetterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":         id,
+		"projection": projection,
+		"status":     "skipped",
+	})
+}
+
+func deadLetterErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, projections.ErrUnknownProjection), errors.Is(err, projections.ErrDeadLetterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, projections.ErrDeadLetterClosed):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"instant/services/api/projections"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProjectionQueryHandler exposes projection health such as dead letters.
+type ProjectionQueryHandler struct {
+	deadLetters *projections.DeadLetterQueue
+}
+
+// NewProjectionQueryHandler creates a new projection query handler.
+func NewProjectionQueryHandler(deadLetters *projections.DeadLetterQueue) (*ProjectionQueryHandler, error) {
+	return &ProjectionQueryHandler{deadLetters: deadLetters}, nil
+}
+
+// GetDeadLetters lists dead-lettered events for a projection.
+func (h *ProjectionQueryHandler) GetDeadLetters(c *gin.Context) {
+	projection := c.Param("name")
+	status := c.Query("status")
+	limit := parseIntWithDefault(c.Query("limit"), 100)
+	offset := parseIntWithDefault(c.Query("offset"), 0)
+
+	letters, err := h.deadLetters.List(projection, status, limit, offset)
+	if err != nil {
+		c.JSON(deadLetterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"projection":  projection,
+		"deadLetters": letters,
+		"count":       len(letters),
+	})
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"instant/services/api/reconciliation"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReconciliationCommandHandler triggers reconciliation runs.
+type ReconciliationCommandHandler struct {
+	service *reconciliation.Service
+}
+
+// NewReconciliationCommandHandler creates a new reconciliation command handler.
+func NewReconciliationCommandHandler(service *reconciliation.Service) *ReconciliationCommandHandler {
+	return &ReconciliationCommandHandler{service: service}
+}
+
+// HandleRunReconciliation rebuilds projections from events, diffs them and
+// optionally repairs breaks.
+func (h *ReconciliationCommandHandler) HandleRunReconciliation(c *gin.Context) {
+	var req struct {
+		RequestedBy string `json:"requestedBy" binding:"required"`
+		Repair      bool   `json:"repair"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.service.Run(req.Repair, req.RequestedBy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
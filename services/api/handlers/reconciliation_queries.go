@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"instant/services/api/reconciliation"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReconciliationQueryHandler serves stored reconciliation reports.
+type ReconciliationQueryHandler struct {
+	service *reconciliation.Service
+}
+
+// NewReconciliationQueryHandler creates a new reconciliation query handler.
+func NewReconciliationQueryHandler(service *reconciliation.Service) (*ReconciliationQueryHandler, error) {
+	return &ReconciliationQueryHandler{service: service}, nil
+}
+
+// GetRuns lists recent reconciliation runs.
+func (h *ReconciliationQueryHandler) GetRuns(c *gin.Context) {
+	limit := parseIntWithDefault(c.Query("limit"), 20)
+
+	runs, err := h.service.ListRuns(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"runs":  runs,
+		"count": len(runs),
+	})
+}
+
+// GetRunByID returns one reconciliation run with its breaks.
+func (h *ReconciliationQueryHandler) GetRunByID(c *gin.Context) {
+	report, err := h.service.GetRun(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, reconciliation.ErrRunNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
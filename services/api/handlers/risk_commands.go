@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"instant/services/api/eventstore"
+	"instant/services/api/services/risk"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RiskCommandHandler handles risk limit commands
+type RiskCommandHandler struct {
+	service    *risk.Service
+	eventStore *eventstore.EventStore
+}
+
+// NewRiskCommandHandler creates a new risk limit command handler
+func NewRiskCommandHandler(service *risk.Service, eventStore *eventstore.EventStore) *RiskCommandHandler {
+	return &RiskCommandHandler{service: service, eventStore: eventStore}
+}
+
+// CreateLimit handles creating a risk limit
+func (h *RiskCommandHandler) CreateLimit(c *gin.Context) {
+	var req struct {
+		LimitType string  `json:"limitType" binding:"required"`
+		Scope     string  `json:"scope"`
+		ScopeID   *string `json:"scopeId"`
+		MaxValue  float64 `json:"maxValue"`
+		CreatedBy string  `json:"createdBy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := correlationIDFromHeader(c)
+
+	limitID, err := h.service.CreateLimit(risk.LimitInput{
+		LimitType: req.LimitType,
+		Scope:     req.Scope,
+		ScopeID:   req.ScopeID,
+		MaxValue:  req.MaxValue,
+		ActorID:   req.CreatedBy,
+	}, correlationID)
+	if err != nil {
+		c.JSON(riskLimitErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"limitId":       limitID,
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "created",
+	})
+}
+
+// UpdateLimit handles changing a risk limit's maximum
+func (h *RiskCommandHandler) UpdateLimit(c *gin.Context) {
+	limitID := c.Param("id")
+	var req struct {
+		MaxValue  float64 `json:"maxValue"`
+		UpdatedBy string  `json:"updatedBy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := correlationIDFromHeader(c)
+
+	version, err := h.service.UpdateLimit(limitID, req.MaxValue, req.UpdatedBy, correlationID)
+	if err != nil {
+		c.JSON(riskLimitErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"limitId":       limitID,
+		"version":       version,
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "updated",
+	})
+}
+
+// DeleteLimit handles retiring a risk limit
+func (h *RiskCommandHandler) DeleteLimit(c *gin.Context) {
+	actorID := actorIDFromBody(c)
+	if actorID == "" {
+		return
+	}
+	correlationID := correlationIDFromHeader(c)
+
+	if err := h.service.DeleteLimit(c.Param("id"), actorID, correlationID); err != nil {
+		c.JSON(riskLimitErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "deleted",
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+	})
+}
+
+func riskLimitErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, risk.ErrLimitNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, risk.ErrDuplicateLimit):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
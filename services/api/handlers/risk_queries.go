@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"instant/services/api/services/risk"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RiskQueryHandler serves risk limits and utilization
+type RiskQueryHandler struct {
+	service *risk.Service
+}
+
+// NewRiskQueryHandler creates a new risk limit query handler
+func NewRiskQueryHandler(service *risk.Service) (*RiskQueryHandler, error) {
+	return &RiskQueryHandler{service: service}, nil
+}
+
+// GetLimits lists risk limits that have not been deleted
+func (h *RiskQueryHandler) GetLimits(c *gin.Context) {
+	limits, err := h.service.ListLimits()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"limits": limits,
+		"count":  len(limits),
+	})
+}
+
+// GetLimitByID returns one risk limit
+func (h *RiskQueryHandler) GetLimitByID(c *gin.Context) {
+	limit, err := h.service.GetLimit(c.Param("id"))
+	if errors.Is(err, risk.ErrLimitNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, limit)
+}
+
+// GetUtilization returns each trader's usage against their limits for a
+// trade date (default today), optionally for one actorId
+func (h *RiskQueryHandler) GetUtilization(c *gin.Context) {
+	utilization, err := h.service.GetUtilization(c.Query("tradeDate"), c.Query("actorId"))
+	if errors.Is(err, risk.ErrInvalidTradeDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, utilization)
+}
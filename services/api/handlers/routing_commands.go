@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"instant/services/api/eventstore"
+	"instant/services/api/services/routing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoutingCommandHandler handles routing policy commands
+type RoutingCommandHandler struct {
+	service    *routing.Service
+	eventStore *eventstore.EventStore
+}
+
+// NewRoutingCommandHandler creates a new routing policy command handler
+func NewRoutingCommandHandler(service *routing.Service, eventStore *eventstore.EventStore) *RoutingCommandHandler {
+	return &RoutingCommandHandler{service: service, eventStore: eventStore}
+}
+
+type routingPolicyRequest struct {
+	Name           string   `json:"name" binding:"required"`
+	Priority       int      `json:"priority"`
+	Venue          string   `json:"venue" binding:"required"`
+	AccountID      *string  `json:"accountId"`
+	InstrumentType *string  `json:"instrumentType"`
+	OrderType      *string  `json:"orderType"`
+	MinQuantity    *float64 `json:"minQuantity"`
+	MaxQuantity    *float64 `json:"maxQuantity"`
+}
+
+func (r routingPolicyRequest) input(actorID string) routing.PolicyInput {
+	return routing.PolicyInput{
+		Name:           r.Name,
+		Priority:       r.Priority,
+		Venue:          r.Venue,
+		AccountID:      r.AccountID,
+		InstrumentType: r.InstrumentType,
+		OrderType:      r.OrderType,
+		MinQuantity:    r.MinQuantity,
+		MaxQuantity:    r.MaxQuantity,
+		ActorID:        actorID,
+	}
+}
+
+// CreatePolicy handles creating a routing policy
+func (h *RoutingCommandHandler) CreatePolicy(c *gin.Context) {
+	var req struct {
+		routingPolicyRequest
+		CreatedBy string `json:"createdBy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := correlationIDFromHeader(c)
+
+	policyID, err := h.service.CreatePolicy(req.input(req.CreatedBy), correlationID)
+	if err != nil {
+		c.JSON(routingPolicyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"policyId":      policyID,
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "created",
+	})
+}
+
+// UpdatePolicy handles replacing a routing policy's criteria and venue
+func (h *RoutingCommandHandler) UpdatePolicy(c *gin.Context) {
+	policyID := c.Param("id")
+	var req struct {
+		routingPolicyRequest
+		UpdatedBy string `json:"updatedBy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := correlationIDFromHeader(c)
+
+	version, err := h.service.UpdatePolicy(policyID, req.input(req.UpdatedBy), correlationID)
+	if err != nil {
+		c.JSON(routingPolicyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policyId":      policyID,
+		"version":       version,
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "updated",
+	})
+}
+
+// DeletePolicy handles retiring a routing policy
+func (h *RoutingCommandHandler) DeletePolicy(c *gin.Context) {
+	actorID := actorIDFromBody(c)
+	if actorID == "" {
+		return
+	}
+	correlationID := correlationIDFromHeader(c)
+
+	if err := h.service.DeletePolicy(c.Param("id"), actorID, correlationID); err != nil {
+		c.JSON(routingPolicyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "deleted",
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+	})
+}
+
+func routingPolicyErrorStatus(err error) int {
+	if errors.Is(err, routing.ErrPolicyNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"instant/services/api/ems"
+	"instant/services/api/services/routing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoutingQueryHandler serves routing policies and the venues they route to
+type RoutingQueryHandler struct {
+	service *routing.Service
+	router  *ems.VenueRouter
+}
+
+// NewRoutingQueryHandler creates a new routing policy query handler
+func NewRoutingQueryHandler(service *routing.Service, router *ems.VenueRouter) (*RoutingQueryHandler, error) {
+	return &RoutingQueryHandler{service: service, router: router}, nil
+}
+
+// GetPolicies lists routing policies that have not been deleted, in the order
+// they are tried, with the configured venues
+func (h *RoutingQueryHandler) GetPolicies(c *gin.Context) {
+	policies, err := h.service.ListPolicies()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policies":     policies,
+		"count":        len(policies),
+		"venues":       h.router.VenueNames(),
+		"defaultVenue": h.router.DefaultVenue(),
+	})
+}
+
+// GetPolicyByID returns one routing policy
+func (h *RoutingQueryHandler) GetPolicyByID(c *gin.Context) {
+	policy, err := h.service.GetPolicy(c.Param("id"))
+	if errors.Is(err, routing.ErrPolicyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
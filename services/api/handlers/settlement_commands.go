@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"instant/services/api/eventstore"
+	"instant/services/api/services/settlement"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SettlementCommandHandler handles settlement lifecycle commands
+type SettlementCommandHandler struct {
+	service    *settlement.Service
+	eventStore *eventstore.EventStore
+}
+
+// NewSettlementCommandHandler creates a new settlement command handler
+func NewSettlementCommandHandler(service *settlement.Service, eventStore *eventstore.EventStore) *SettlementCommandHandler {
+	return &SettlementCommandHandler{service: service, eventStore: eventStore}
+}
+
+// SettleSettlement handles settling a settlement by hand, in full or, with a
+// quantity and a reason, in part
+func (h *SettlementCommandHandler) SettleSettlement(c *gin.Context) {
+	var req struct {
+		Quantity  float64 `json:"quantity"`
+		Reason    string  `json:"reason"`
+		SettledBy string  `json:"settledBy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := correlationIDFromHeader(c)
+
+	settled, err := h.service.Settle(c.Param("id"), req.Quantity, req.Reason, req.SettledBy, correlationID)
+	if err != nil {
+		c.JSON(settlementErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settlementId":      settled.SettlementID,
+		"settledQuantity":   settled.SettledQuantity,
+		"remainingQuantity": settled.RemainingQuantity,
+		"correlationId":     correlationID,
+		"position":          writtenPosition(h.eventStore, correlationID),
+		"status":            settled.Status,
+	})
+}
+
+// FailSettlement handles recording that a settlement failed
+func (h *SettlementCommandHandler) FailSettlement(c *gin.Context) {
+	var req struct {
+		Reason   string `json:"reason" binding:"required"`
+		FailedBy string `json:"failedBy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := correlationIDFromHeader(c)
+
+	failed, err := h.service.Fail(c.Param("id"), req.Reason, req.FailedBy, correlationID)
+	if err != nil {
+		c.JSON(settlementErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settlementId":  failed.SettlementID,
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        failed.Status,
+	})
+}
+
+func settlementErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, settlement.ErrSettlementNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, settlement.ErrSettlementClosed), errors.Is(err, settlement.ErrSettlementNotPending),
+		errors.Is(err, settlement.ErrSettlementChanged):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"instant/services/api/services/settlement"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SettlementQueryHandler serves settlements
+type SettlementQueryHandler struct {
+	service *settlement.Service
+}
+
+// NewSettlementQueryHandler creates a new settlement query handler
+func NewSettlementQueryHandler(service *settlement.Service) (*SettlementQueryHandler, error) {
+	return &SettlementQueryHandler{service: service}, nil
+}
+
+// GetSettlements lists settlements, optionally by status and account
+func (h *SettlementQueryHandler) GetSettlements(c *gin.Context) {
+	limit := 100
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	settlements, err := h.service.ListSettlements(c.Query("status"), c.Query("accountId"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settlements": settlements,
+		"count":       len(settlements),
+	})
+}
+
+// GetSettlementByID returns one settlement
+func (h *SettlementQueryHandler) GetSettlementByID(c *gin.Context) {
+	found, err := h.service.GetSettlement(c.Param("id"))
+	if errors.Is(err, settlement.ErrSettlementNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, found)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"instant/services/api/eventstore"
+	"instant/services/api/services/tradingcontrol"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TradingControlCommandHandler handles kill switch and rate limit commands
+type TradingControlCommandHandler struct {
+	service    *tradingcontrol.Service
+	eventStore *eventstore.EventStore
+}
+
+// NewTradingControlCommandHandler creates a new trading control command handler
+func NewTradingControlCommandHandler(service *tradingcontrol.Service, eventStore *eventstore.EventStore) *TradingControlCommandHandler {
+	return &TradingControlCommandHandler{service: service, eventStore: eventStore}
+}
+
+// ActivateHalt handles activating a kill switch
+func (h *TradingControlCommandHandler) ActivateHalt(c *gin.Context) {
+	var req struct {
+		Scope       string  `json:"scope"`
+		ScopeID     *string `json:"scopeId"`
+		Reason      string  `json:"reason"`
+		ActivatedBy string  `json:"activatedBy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := correlationIDFromHeader(c)
+
+	haltID, err := h.service.ActivateHalt(tradingcontrol.HaltInput{
+		Scope:   req.Scope,
+		ScopeID: req.ScopeID,
+		Reason:  req.Reason,
+		ActorID: req.ActivatedBy,
+	}, correlationID)
+	if err != nil {
+		c.JSON(tradingControlErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"haltId":        haltID,
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "activated",
+	})
+}
+
+// ReleaseHalt handles lifting a kill switch
+func (h *TradingControlCommandHandler) ReleaseHalt(c *gin.Context) {
+	var req struct {
+		Reason     string `json:"reason"`
+		ReleasedBy string `json:"releasedBy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := correlationIDFromHeader(c)
+
+	if err := h.service.ReleaseHalt(c.Param("id"), req.ReleasedBy, req.Reason, correlationID); err != nil {
+		c.JSON(tradingControlErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"haltId":        c.Param("id"),
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "released",
+	})
+}
+
+// SetRateLimit handles setting an actor's order rate limit
+func (h *TradingControlCommandHandler) SetRateLimit(c *gin.Context) {
+	var req struct {
+		MaxOrders     int    `json:"maxOrders"`
+		WindowSeconds int    `json:"windowSeconds"`
+		SetBy         string `json:"setBy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := correlationIDFromHeader(c)
+
+	limit := tradingcontrol.RateLimit{
+		ActorID:   c.Param("actorId"),
+		MaxOrders: req.MaxOrders,
+		Window:    time.Duration(req.WindowSeconds) * time.Second,
+		SetBy:     req.SetBy,
+	}
+	if err := h.service.SetRateLimit(limit, correlationID); err != nil {
+		c.JSON(tradingControlErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"actorId":       limit.ActorID,
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "set",
+	})
+}
+
+func tradingControlErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, tradingcontrol.ErrHaltNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, tradingcontrol.ErrHaltReleased):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
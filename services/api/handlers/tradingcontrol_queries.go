@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"instant/services/api/services/tradingcontrol"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TradingControlQueryHandler serves kill switch and rate limit status
+type TradingControlQueryHandler struct {
+	service *tradingcontrol.Service
+}
+
+// NewTradingControlQueryHandler creates a new trading control query handler
+func NewTradingControlQueryHandler(service *tradingcontrol.Service) (*TradingControlQueryHandler, error) {
+	return &TradingControlQueryHandler{service: service}, nil
+}
+
+// GetStatus returns active halts, rate limits and recent refusals
+func (h *TradingControlQueryHandler) GetStatus(c *gin.Context) {
+	status, err := h.service.GetStatus()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// GetHaltByID returns one halt
+func (h *TradingControlQueryHandler) GetHaltByID(c *gin.Context) {
+	halt, err := h.service.GetHalt(c.Param("id"))
+	if errors.Is(err, tradingcontrol.ErrHaltNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, halt)
+}
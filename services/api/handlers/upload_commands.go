@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"instant/services/api/eventstore"
+	"instant/services/api/upload"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxUploadBytes caps the size of an uploaded order file
+const maxUploadBytes = 10 << 20
+
+// UploadCommandHandler handles order file uploads
+type UploadCommandHandler struct {
+	service    *upload.Service
+	eventStore *eventstore.EventStore
+}
+
+// NewUploadCommandHandler creates a new upload command handler
+func NewUploadCommandHandler(service *upload.Service, eventStore *eventstore.EventStore) *UploadCommandHandler {
+	return &UploadCommandHandler{service: service, eventStore: eventStore}
+}
+
+// HandleUploadOrders accepts a CSV or XLSX file as multipart field "file" and
+// returns every row with its validation errors
+func (h *UploadCommandHandler) HandleUploadOrders(c *gin.Context) {
+	uploadedBy := c.PostForm("uploadedBy")
+	if uploadedBy == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "uploadedBy is required"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	if fileHeader.Size > maxUploadBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is larger than 10MB"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxUploadBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := correlationIDFromHeader(c)
+
+	batch, err := h.service.Receive(upload.ReceiveRequest{
+		FileName:   fileHeader.Filename,
+		Data:       data,
+		UploadedBy: uploadedBy,
+	}, correlationID)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"batchId":       batch.BatchID,
+		"batch":         batch,
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "validated",
+	})
+}
+
+// HandleConfirmUpload creates orders for the valid rows of an uploaded batch
+func (h *UploadCommandHandler) HandleConfirmUpload(c *gin.Context) {
+	var req struct {
+		ConfirmedBy string `json:"confirmedBy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correlationID := correlationIDFromHeader(c)
+
+	result, err := h.service.Confirm(upload.ConfirmRequest{
+		BatchID:     c.Param("id"),
+		ConfirmedBy: req.ConfirmedBy,
+	}, correlationID)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"batchId":       result.BatchID,
+		"orders":        result.Orders,
+		"createdCount":  result.CreatedCount,
+		"failedCount":   result.FailedCount,
+		"correlationId": correlationID,
+		"position":      writtenPosition(h.eventStore, correlationID),
+		"status":        "confirmed",
+	})
+}
+
+// respondUploadError maps upload errors to HTTP statuses
+func respondUploadError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, upload.ErrBatchNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, upload.ErrBatchNotConfirmable), errors.Is(err, upload.ErrNoValidRows):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, upload.ErrUnsupportedFormat), errors.Is(err, upload.ErrEmptyFile),
+		errors.Is(err, upload.ErrMissingColumns), errors.Is(err, upload.ErrUnreadableFile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
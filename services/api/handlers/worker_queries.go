@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"database/sql"
+	"instant/services/api/leader"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WorkerQueryHandler exposes background worker leadership status.
+type WorkerQueryHandler struct {
+	db      *sql.DB
+	elector *leader.Elector
+}
+
+// NewWorkerQueryHandler creates a new worker query handler.
+func NewWorkerQueryHandler(db *sql.DB, elector *leader.Elector) (*WorkerQueryHandler, error) {
+	return &WorkerQueryHandler{db: db, elector: elector}, nil
+}
+
+// GetWorkers returns which instance holds each singleton role.
+// A lease whose heartbeat is older than three election intervals is reported
+// as stale: its holder has likely died and another instance will take over.
+func (h *WorkerQueryHandler) GetWorkers(c *gin.Context) {
+	rows, err := h.db.Query(`
+		SELECT "role", "instanceId", "acquiredAt", "heartbeatAt"
+		FROM worker_leases
+		ORDER BY "role" ASC
+	`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	staleAfter := 3 * h.elector.Interval()
+	leases := []map[string]interface{}{}
+	for rows.Next() {
+		var (
+			role        string
+			instanceID  string
+			acquiredAt  time.Time
+			heartbeatAt time.Time
+		)
+		if err := rows.Scan(&role, &instanceID, &acquiredAt, &heartbeatAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		leases = append(leases, map[string]interface{}{
+			"role":        role,
+			"instanceId":  instanceID,
+			"acquiredAt":  acquiredAt,
+			"heartbeatAt": heartbeatAt,
+			"stale":       time.Since(heartbeatAt) > staleAfter,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"instanceId": h.elector.InstanceID(),
+		"leases":     leases,
+		"local":      h.elector.Status(),
+	})
+}
@@ -0,0 +1,340 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// lockNamespace is the first key of every advisory lock taken by the elector,
+// keeping role locks apart from any other advisory locks in the database.
+const lockNamespace int32 = 0x1a57
+
+// Worker is a background component that runs only while its role is held
+type Worker interface {
+	Start()
+	Stop()
+}
+
+// WorkerFactory builds a fresh worker each time leadership is acquired, since
+// workers cannot be restarted once stopped.
+type WorkerFactory func() (Worker, error)
+
+// RoleStatus describes this instance's view of a role
+type RoleStatus struct {
+	Role       string     `json:"role"`
+	IsLeader   bool       `json:"isLeader"`
+	AcquiredAt *time.Time `json:"acquiredAt,omitempty"`
+	LastError  string     `json:"lastError,omitempty"`
+}
+
+type role struct {
+	name       string
+	lockKey    int32
+	newWorker  WorkerFactory
+	worker     Worker
+	acquiredAt *time.Time
+	lastError  string
+}
+
+// lockSession holds session-level advisory locks. The locks are released
+// when the session ends.
+type lockSession interface {
+	TryLock(ctx context.Context, key int32) (bool, error)
+	Unlock(ctx context.Context, key int32) error
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// leaseStore records which instance holds each role, for the workers view
+type leaseStore interface {
+	Renew(ctx context.Context, role, instanceID string, acquiredAt time.Time) error
+	Clear(ctx context.Context, role, instanceID string) error
+}
+
+// Elector runs singleton workers on exactly one instance using Postgres
+// session-level advisory locks. All locks are held on one dedicated
+// connection: if the process dies or the connection drops, Postgres releases
+// them and another instance takes over on its next attempt.
+type Elector struct {
+	openSession func(ctx context.Context) (lockSession, error)
+	leases      leaseStore
+	instanceID  string
+	interval    time.Duration
+
+	mu       sync.Mutex
+	session  lockSession
+	roles    []*role
+	stopChan chan struct{}
+	done     chan struct{}
+}
+
+// NewElector creates a new Elector for this instance
+func NewElector(db *sql.DB, instanceID string, interval time.Duration) *Elector {
+	openSession := func(ctx context.Context) (lockSession, error) {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &postgresSession{conn: conn}, nil
+	}
+	return newElector(openSession, &postgresLeases{db: db}, instanceID, interval)
+}
+
+func newElector(openSession func(ctx context.Context) (lockSession, error), leases leaseStore, instanceID string, interval time.Duration) *Elector {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return &Elector{
+		openSession: openSession,
+		leases:      leases,
+		instanceID:  instanceID,
+		interval:    interval,
+		stopChan:    make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// InstanceID returns the identifier this instance records in worker leases
+func (e *Elector) InstanceID() string {
+	return e.instanceID
+}
+
+// Interval returns how often leadership is attempted and renewed
+func (e *Elector) Interval() time.Duration {
+	return e.interval
+}
+
+// Register adds a singleton role. Roles must be registered before Start.
+func (e *Elector) Register(name string, newWorker WorkerFactory) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.roles = append(e.roles, &role{
+		name:      name,
+		lockKey:   roleLockKey(name),
+		newWorker: newWorker,
+	})
+}
+
+// Start attempts to acquire every role and keeps renewing until stopped
+func (e *Elector) Start() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	e.tick()
+	for {
+		select {
+		case <-ticker.C:
+			e.tick()
+		case <-e.stopChan:
+			e.releaseAll()
+			return
+		}
+	}
+}
+
+// Stop stops all running workers and releases their locks
+func (e *Elector) Stop() {
+	close(e.stopChan)
+	<-e.done
+}
+
+// Status returns this instance's view of every registered role
+func (e *Elector) Status() []RoleStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	statuses := make([]RoleStatus, 0, len(e.roles))
+	for _, r := range e.roles {
+		statuses = append(statuses, RoleStatus{
+			Role:       r.name,
+			IsLeader:   r.worker != nil,
+			AcquiredAt: r.acquiredAt,
+			LastError:  r.lastError,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Role < statuses[j].Role })
+	return statuses
+}
+
+// tick verifies held locks, renews leases and tries to acquire free roles
+func (e *Elector) tick() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+	defer cancel()
+
+	if e.session != nil {
+		if err := e.session.Ping(ctx); err != nil {
+			log.Printf("Leader election: lock connection lost, stepping down: %v", err)
+			e.stepDownAll(fmt.Sprintf("lock connection lost: %v", err))
+		}
+	}
+
+	if e.session == nil {
+		session, err := e.openSession(ctx)
+		if err != nil {
+			log.Printf("Leader election: failed to open lock connection: %v", err)
+			return
+		}
+		e.session = session
+	}
+
+	for _, r := range e.roles {
+		if r.worker != nil {
+			e.renewLease(ctx, r)
+			continue
+		}
+		e.tryAcquire(ctx, r)
+	}
+}
+
+func (e *Elector) tryAcquire(ctx context.Context, r *role) {
+	acquired, err := e.session.TryLock(ctx, r.lockKey)
+	if err != nil {
+		r.lastError = err.Error()
+		log.Printf("Leader election: failed to try lock for %s: %v", r.name, err)
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	worker, err := r.newWorker()
+	if err != nil {
+		r.lastError = err.Error()
+		log.Printf("Leader election: failed to build worker for %s: %v", r.name, err)
+		e.unlock(ctx, r)
+		return
+	}
+
+	now := time.Now().UTC()
+	r.worker = worker
+	r.acquiredAt = &now
+	r.lastError = ""
+	go worker.Start()
+
+	e.renewLease(ctx, r)
+	log.Printf("Leader election: %s acquired %s", e.instanceID, r.name)
+}
+
+// renewLease records this instance as the holder of the role
+func (e *Elector) renewLease(ctx context.Context, r *role) {
+	if err := e.leases.Renew(ctx, r.name, e.instanceID, *r.acquiredAt); err != nil {
+		r.lastError = err.Error()
+		log.Printf("Leader election: failed to renew lease for %s: %v", r.name, err)
+	}
+}
+
+// stepDownAll stops every worker; the locks died with the connection
+func (e *Elector) stepDownAll(reason string) {
+	for _, r := range e.roles {
+		if r.worker == nil {
+			continue
+		}
+		r.worker.Stop()
+		r.worker = nil
+		r.acquiredAt = nil
+		r.lastError = reason
+	}
+	e.session.Close()
+	e.session = nil
+}
+
+// releaseAll stops workers and hands roles over on shutdown
+func (e *Elector) releaseAll() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+	defer cancel()
+
+	for _, r := range e.roles {
+		if r.worker == nil {
+			continue
+		}
+		r.worker.Stop()
+		r.worker = nil
+		r.acquiredAt = nil
+
+		if err := e.leases.Clear(ctx, r.name, e.instanceID); err != nil {
+			log.Printf("Leader election: failed to clear lease for %s: %v", r.name, err)
+		}
+		if e.session != nil {
+			e.unlock(ctx, r)
+		}
+	}
+
+	if e.session != nil {
+		e.session.Close()
+		e.session = nil
+	}
+}
+
+func (e *Elector) unlock(ctx context.Context, r *role) {
+	if err := e.session.Unlock(ctx, r.lockKey); err != nil {
+		log.Printf("Leader election: failed to unlock %s: %v", r.name, err)
+	}
+}
+
+// postgresSession holds advisory locks on one dedicated connection
+type postgresSession struct {
+	conn *sql.Conn
+}
+
+func (s *postgresSession) TryLock(ctx context.Context, key int32) (bool, error) {
+	var acquired bool
+	err := s.conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, $2)`, lockNamespace, key).Scan(&acquired)
+	return acquired, err
+}
+
+func (s *postgresSession) Unlock(ctx context.Context, key int32) error {
+	_, err := s.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1, $2)`, lockNamespace, key)
+	return err
+}
+
+func (s *postgresSession) Ping(ctx context.Context) error {
+	return s.conn.PingContext(ctx)
+}
+
+func (s *postgresSession) Close() error {
+	return s.conn.Close()
+}
+
+// postgresLeases stores leases in the worker_leases table
+type postgresLeases struct {
+	db *sql.DB
+}
+
+func (l *postgresLeases) Renew(ctx context.Context, role, instanceID string, acquiredAt time.Time) error {
+	_, err := l.db.ExecContext(ctx, `
+		INSERT INTO worker_leases ("role", "instanceId", "acquiredAt", "heartbeatAt")
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT ("role") DO UPDATE SET
+			"instanceId" = EXCLUDED."instanceId",
+			"acquiredAt" = EXCLUDED."acquiredAt",
+			"heartbeatAt" = EXCLUDED."heartbeatAt"
+	`, role, instanceID, acquiredAt, time.Now().UTC())
+	return err
+}
+
+func (l *postgresLeases) Clear(ctx context.Context, role, instanceID string) error {
+	_, err := l.db.ExecContext(ctx, `DELETE FROM worker_leases WHERE "role" = $1 AND "instanceId" = $2`, role, instanceID)
+	return err
+}
+
+// roleLockKey derives a stable advisory lock key from a role name
+func roleLockKey(name string) int32 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return int32(h.Sum32())
+}
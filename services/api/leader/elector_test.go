@@ -0,0 +1,187 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDatabase stands in for Postgres: advisory locks belong to the session
+// that took them and are released when it closes
+type fakeDatabase struct {
+	mu     sync.Mutex
+	locks  map[int32]*fakeSession
+	leases map[string]string
+	renews map[string]int
+}
+
+func newFakeDatabase() *fakeDatabase {
+	return &fakeDatabase{locks: map[int32]*fakeSession{}, leases: map[string]string{}, renews: map[string]int{}}
+}
+
+func (d *fakeDatabase) open(ctx context.Context) (lockSession, error) {
+	return &fakeSession{db: d}, nil
+}
+
+func (d *fakeDatabase) Renew(ctx context.Context, role, instanceID string, acquiredAt time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.leases[role] = instanceID
+	d.renews[role+"/"+instanceID]++
+	return nil
+}
+
+func (d *fakeDatabase) Clear(ctx context.Context, role, instanceID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.leases[role] == instanceID {
+		delete(d.leases, role)
+	}
+	return nil
+}
+
+type fakeSession struct {
+	db     *fakeDatabase
+	broken bool
+}
+
+func (s *fakeSession) TryLock(ctx context.Context, key int32) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	if holder, ok := s.db.locks[key]; ok && holder != s {
+		return false, nil
+	}
+	s.db.locks[key] = s
+	return true, nil
+}
+
+func (s *fakeSession) Unlock(ctx context.Context, key int32) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	if s.db.locks[key] == s {
+		delete(s.db.locks, key)
+	}
+	return nil
+}
+
+func (s *fakeSession) Ping(ctx context.Context) error {
+	if s.broken {
+		return errors.New("connection reset")
+	}
+	return nil
+}
+
+func (s *fakeSession) Close() error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	for key, holder := range s.db.locks {
+		if holder == s {
+			delete(s.db.locks, key)
+		}
+	}
+	return nil
+}
+
+type fakeWorker struct {
+	mu      sync.Mutex
+	started bool
+	stopped bool
+}
+
+func (w *fakeWorker) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.started = true
+}
+
+func (w *fakeWorker) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopped = true
+}
+
+func newTestElector(db *fakeDatabase, instanceID string, workers *[]*fakeWorker) *Elector {
+	e := newElector(db.open, db, instanceID, time.Second)
+	e.Register("projection", func() (Worker, error) {
+		worker := &fakeWorker{}
+		*workers = append(*workers, worker)
+		return worker, nil
+	})
+	return e
+}
+
+func isLeader(e *Elector) bool {
+	return e.Status()[0].IsLeader
+}
+
+func TestElectorAcquiresAndRenewsOnlyOnce(t *testing.T) {
+	db := newFakeDatabase()
+	var workersA, workersB []*fakeWorker
+	a := newTestElector(db, "instance-a", &workersA)
+	b := newTestElector(db, "instance-b", &workersB)
+
+	a.tick()
+	b.tick()
+	if !isLeader(a) || isLeader(b) {
+		t.Fatalf("expected only instance-a to lead")
+	}
+	if len(workersA) != 1 || len(workersB) != 0 {
+		t.Fatalf("expected one worker on instance-a, got %d and %d", len(workersA), len(workersB))
+	}
+
+	// Renewing keeps the same worker and refreshes the lease
+	a.tick()
+	b.tick()
+	if len(workersA) != 1 || db.renews["projection/instance-a"] != 2 {
+		t.Fatalf("expected the lease renewed without a new worker, got %d workers and %d renewals", len(workersA), db.renews["projection/instance-a"])
+	}
+	if db.leases["projection"] != "instance-a" {
+		t.Fatalf("expected instance-a to hold the lease, got %q", db.leases["projection"])
+	}
+}
+
+func TestElectorStepsDownWhenItsConnectionDrops(t *testing.T) {
+	db := newFakeDatabase()
+	var workersA, workersB []*fakeWorker
+	a := newTestElector(db, "instance-a", &workersA)
+	b := newTestElector(db, "instance-b", &workersB)
+
+	a.tick()
+	a.session.(*fakeSession).broken = true
+	a.tick()
+	if !workersA[0].stopped {
+		t.Fatalf("expected instance-a's worker to stop")
+	}
+	// instance-a reacquires on a fresh connection before instance-b tries
+	if !isLeader(a) || len(workersA) != 2 {
+		t.Fatalf("expected instance-a to lead again with a new worker")
+	}
+
+	a.releaseAll()
+	if _, held := db.leases["projection"]; held {
+		t.Fatalf("expected the lease cleared on shutdown")
+	}
+	b.tick()
+	if !isLeader(b) || len(workersB) != 1 {
+		t.Fatalf("expected instance-b to take over")
+	}
+}
+
+func TestElectorReleasesTheLockWhenTheWorkerCannotBeBuilt(t *testing.T) {
+	db := newFakeDatabase()
+	a := newElector(db.open, db, "instance-a", time.Second)
+	a.Register("projection", func() (Worker, error) {
+		return nil, errors.New("no database")
+	})
+
+	a.tick()
+	status := a.Status()[0]
+	if status.IsLeader || status.LastError != "no database" {
+		t.Fatalf("expected no leadership and the build error, got %+v", status)
+	}
+	if len(db.locks) != 0 {
+		t.Fatalf("expected the lock released for another instance")
+	}
+}
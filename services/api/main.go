@@ -2,16 +2,28 @@ package main
 
 import (
 	"database/sql"
+	"fmt"
 	"instant/services/api/config"
 	"instant/services/api/ems"
 	"instant/services/api/eventbus"
 	"instant/services/api/eventstore"
+	"instant/services/api/fix"
 	"instant/services/api/handlers"
+	"instant/services/api/leader"
 	"instant/services/api/oms"
 	"instant/services/api/pms"
 	"instant/services/api/projections"
+	"instant/services/api/reconciliation"
 	"instant/services/api/routes"
+	"instant/services/api/services/approval"
+	"instant/services/api/services/calendar"
 	"instant/services/api/services/compliance"
+	"instant/services/api/services/fees"
+	"instant/services/api/services/risk"
+	"instant/services/api/services/routing"
+	"instant/services/api/services/settlement"
+	"instant/services/api/services/tradingcontrol"
+	"instant/services/api/upload"
 	"log"
 	"os"
 	"os/signal"
@@ -52,8 +64,19 @@ func main() {
 	log.Println("DB pool initialized successfully")
 
 	// Initialize EventBus
-	log.Println("Initializing EventBus...")
-	eventBus := eventbus.New()
+	log.Printf("Initializing EventBus (%s backend)...", cfg.EventBusBackend)
+	var eventBus *eventbus.EventBus
+	switch cfg.EventBusBackend {
+	case "postgres":
+		eventBus, err = eventbus.NewPostgres(cfg.DirectURL, eventStore)
+		if err != nil {
+			log.Fatalf("Failed to initialize EventBus: %v", err)
+		}
+	case "memory":
+		eventBus = eventbus.New()
+	default:
+		log.Fatalf("Unknown EVENT_BUS_BACKEND %q (expected memory or postgres)", cfg.EventBusBackend)
+	}
 	defer eventBus.Close()
 	log.Println("EventBus initialized successfully")
 
@@ -63,25 +86,117 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to initialize Compliance Service: %v", err)
 	}
+	holdingsCheck, err := compliance.ParseAvailabilitySeverity(cfg.PretradeHoldingsCheck)
+	if err != nil {
+		log.Fatalf("Invalid PRETRADE_HOLDINGS_CHECK: %v", err)
+	}
+	cashCheck, err := compliance.ParseAvailabilitySeverity(cfg.PretradeCashCheck)
+	if err != nil {
+		log.Fatalf("Invalid PRETRADE_CASH_CHECK: %v", err)
+	}
+	complianceService.SetAvailabilityPolicy(compliance.AvailabilityPolicy{Holdings: holdingsCheck, Cash: cashCheck})
 	log.Println("Compliance Service initialized successfully")
 
+	// Initialize Approval Policy Service
+	log.Println("Initializing Approval Policy Service...")
+	approvalService, err := approval.NewService(db, eventStore, eventBus)
+	if err != nil {
+		log.Fatalf("Failed to initialize Approval Policy Service: %v", err)
+	}
+	log.Println("Approval Policy Service initialized successfully")
+
+	// Initialize Trading Control Service
+	log.Println("Initializing Trading Control Service...")
+	tradingControlService, err := tradingcontrol.NewService(db, eventStore, eventBus, tradingcontrol.RateLimit{
+		MaxOrders: cfg.OrderRateLimitMax,
+		Window:    cfg.OrderRateLimitWindow,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize Trading Control Service: %v", err)
+	}
+	log.Println("Trading Control Service initialized successfully")
+
+	// Initialize Risk Limit Service
+	log.Println("Initializing Risk Limit Service...")
+	riskLocation, err := time.LoadLocation(cfg.OrderCutoffTimezone)
+	if err != nil {
+		log.Fatalf("Invalid ORDER_CUTOFF_TIMEZONE: %v", err)
+	}
+	riskService, err := risk.NewService(db, eventStore, eventBus, riskLocation)
+	if err != nil {
+		log.Fatalf("Failed to initialize Risk Limit Service: %v", err)
+	}
+	log.Println("Risk Limit Service initialized successfully")
+
+	// Initialize the bond market calendar trades settle and orders expire on
+	settlementCycles, err := calendar.ParseSettlementCycles(cfg.SettlementCycles)
+	if err != nil {
+		log.Fatalf("Invalid SETTLEMENT_CYCLES: %v", err)
+	}
+	marketCalendar := calendar.New(riskLocation, cfg.SettlementCycleDefault, settlementCycles)
+
+	// Initialize Fee Schedule Service
+	log.Println("Initializing Fee Schedule Service...")
+	feeService, err := fees.NewService(db, eventStore, eventBus)
+	if err != nil {
+		log.Fatalf("Failed to initialize Fee Schedule Service: %v", err)
+	}
+	log.Println("Fee Schedule Service initialized successfully")
+
+	// Initialize Settlement Service
+	log.Println("Initializing Settlement Service...")
+	settlementService, err := settlement.NewService(db, eventStore, eventBus, marketCalendar)
+	if err != nil {
+		log.Fatalf("Failed to initialize Settlement Service: %v", err)
+	}
+	log.Println("Settlement Service initialized successfully")
+
 	// Initialize OMS Service
 	log.Println("Initializing OMS Service...")
-	omsService := oms.NewService(eventStore, eventBus, complianceService)
+	omsService := oms.NewService(eventStore, eventBus, complianceService, approvalService, oms.NewLimitPricer(db, cfg.LimitPriceBandPct, marketCalendar), tradingControlService, riskService)
 	log.Println("OMS Service initialized successfully")
 
 	// Initialize OMS Handlers
 	log.Println("Initializing OMS Handlers...")
-	omsCommandHandler := handlers.NewOMSCommandHandler(omsService)
+	omsCommandHandler := handlers.NewOMSCommandHandler(omsService, eventStore)
 	omsQueryHandler, err := handlers.NewOMSQueryHandler(db, eventStore)
 	if err != nil {
 		log.Fatalf("Failed to initialize OMS Query Handler: %v", err)
 	}
 	log.Println("OMS Handlers initialized successfully")
 
+	// Initialize Upload Service
+	log.Println("Initializing Upload Service...")
+	uploadService, err := upload.NewService(db, eventStore, eventBus, omsService)
+	if err != nil {
+		log.Fatalf("Failed to initialize Upload Service: %v", err)
+	}
+	uploadCommandHandler := handlers.NewUploadCommandHandler(uploadService, eventStore)
+	log.Println("Upload Service initialized successfully")
+
+	// Initialize Execution Venues and Routing Policies
+	log.Println("Initializing Execution Venues...")
+	venues := []ems.Venue{ems.NewSimulatorVenue(), ems.NewStochasticSimulatorVenue(cfg.SimulatorSeed)}
+	if cfg.BrokerURL != "" {
+		venues = append(venues, ems.NewBrokerVenue(cfg.BrokerURL, cfg.BrokerTimeout, cfg.BrokerPollInterval, cfg.BrokerWorkingTime))
+	}
+	venueNames := make([]string, 0, len(venues))
+	for _, venue := range venues {
+		venueNames = append(venueNames, venue.Name())
+	}
+	routingService, err := routing.NewService(db, eventStore, eventBus, venueNames)
+	if err != nil {
+		log.Fatalf("Failed to initialize Routing Service: %v", err)
+	}
+	venueRouter, err := ems.NewVenueRouter(routingService, cfg.ExecutionVenueDefault, venues...)
+	if err != nil {
+		log.Fatalf("Failed to initialize Execution Venues: %v", err)
+	}
+	log.Printf("Execution Venues initialized successfully: %v (default %s)", venueNames, cfg.ExecutionVenueDefault)
+
 	// Initialize EMS Service
 	log.Println("Initializing EMS Service...")
-	emsService, err := ems.NewService(db, eventStore, eventBus)
+	emsService, err := ems.NewService(db, eventStore, eventBus, tradingControlService, feeService, marketCalendar, venueRouter)
 	if err != nil {
 		log.Fatalf("Failed to initialize EMS Service: %v", err)
 	}
@@ -89,7 +204,7 @@ func main() {
 
 	// Initialize EMS Handlers
 	log.Println("Initializing EMS Handlers...")
-	emsCommandHandler := handlers.NewEMSCommandHandler(emsService)
+	emsCommandHandler := handlers.NewEMSCommandHandler(emsService, eventStore)
 	emsQueryHandler, err := handlers.NewEMSQueryHandler(db)
 	if err != nil {
 		log.Fatalf("Failed to initialize EMS Query Handler: %v", err)
@@ -106,7 +221,7 @@ func main() {
 
 	// Initialize PMS Handlers
 	log.Println("Initializing PMS Handlers...")
-	pmsCommandHandler := handlers.NewPMSCommandHandler(pmsService)
+	pmsCommandHandler := handlers.NewPMSCommandHandler(pmsService, eventStore)
 	pmsQueryHandler, err := handlers.NewPMSQueryHandler(db)
 	if err != nil {
 		log.Fatalf("Failed to initialize PMS Query Handler: %v", err)
@@ -115,19 +230,77 @@ func main() {
 
 	// Initialize Compliance Handlers
 	log.Println("Initializing Compliance Handlers...")
-	complianceCommandHandler := handlers.NewComplianceCommandHandler(complianceService)
+	complianceCommandHandler := handlers.NewComplianceCommandHandler(complianceService, eventStore)
 	complianceQueryHandler, err := handlers.NewComplianceQueryHandler(db, eventStore)
 	if err != nil {
 		log.Fatalf("Failed to initialize Compliance Query Handler: %v", err)
 	}
 	log.Println("Compliance Handlers initialized successfully")
 
+	// Initialize Approval Policy Handlers
+	log.Println("Initializing Approval Policy Handlers...")
+	approvalCommandHandler := handlers.NewApprovalCommandHandler(approvalService, eventStore)
+	approvalQueryHandler, err := handlers.NewApprovalQueryHandler(approvalService)
+	if err != nil {
+		log.Fatalf("Failed to initialize Approval Policy Query Handler: %v", err)
+	}
+	log.Println("Approval Policy Handlers initialized successfully")
+
+	// Initialize Trading Control Handlers
+	log.Println("Initializing Trading Control Handlers...")
+	tradingControlCommandHandler := handlers.NewTradingControlCommandHandler(tradingControlService, eventStore)
+	tradingControlQueryHandler, err := handlers.NewTradingControlQueryHandler(tradingControlService)
+	if err != nil {
+		log.Fatalf("Failed to initialize Trading Control Query Handler: %v", err)
+	}
+	log.Println("Trading Control Handlers initialized successfully")
+
+	// Initialize Risk Limit Handlers
+	log.Println("Initializing Risk Limit Handlers...")
+	riskCommandHandler := handlers.NewRiskCommandHandler(riskService, eventStore)
+	riskQueryHandler, err := handlers.NewRiskQueryHandler(riskService)
+	if err != nil {
+		log.Fatalf("Failed to initialize Risk Limit Query Handler: %v", err)
+	}
+	log.Println("Risk Limit Handlers initialized successfully")
+
+	// Initialize Fee Schedule Handlers
+	log.Println("Initializing Fee Schedule Handlers...")
+	feeCommandHandler := handlers.NewFeeCommandHandler(feeService, eventStore)
+	feeQueryHandler, err := handlers.NewFeeQueryHandler(feeService)
+	if err != nil {
+		log.Fatalf("Failed to initialize Fee Schedule Query Handler: %v", err)
+	}
+	log.Println("Fee Schedule Handlers initialized successfully")
+
+	// Initialize Settlement Handlers
+	log.Println("Initializing Settlement Handlers...")
+	settlementCommandHandler := handlers.NewSettlementCommandHandler(settlementService, eventStore)
+	settlementQueryHandler, err := handlers.NewSettlementQueryHandler(settlementService)
+	if err != nil {
+		log.Fatalf("Failed to initialize Settlement Query Handler: %v", err)
+	}
+	log.Println("Settlement Handlers initialized successfully")
+
+	// Initialize Routing Handlers
+	log.Println("Initializing Routing Handlers...")
+	routingCommandHandler := handlers.NewRoutingCommandHandler(routingService, eventStore)
+	routingQueryHandler, err := handlers.NewRoutingQueryHandler(routingService, venueRouter)
+	if err != nil {
+		log.Fatalf("Failed to initialize Routing Query Handler: %v", err)
+	}
+	log.Println("Routing Handlers initialized successfully")
+
 	// Initialize Market Data Handlers
 	log.Println("Initializing Market Data Handlers...")
-	marketDataQueryHandler, err := handlers.NewMarketDataQueryHandler(db)
+	marketDataQueryHandler, err := handlers.NewMarketDataQueryHandler(db, marketCalendar)
 	if err != nil {
 		log.Fatalf("Failed to initialize Market Data Query Handler: %v", err)
 	}
+	calendarQueryHandler, err := handlers.NewCalendarQueryHandler(marketCalendar)
+	if err != nil {
+		log.Fatalf("Failed to initialize Calendar Query Handler: %v", err)
+	}
 	log.Println("Market Data Handlers initialized successfully")
 
 	// Initialize Copilot Handlers
@@ -135,54 +308,108 @@ func main() {
 	copilotCommandHandler := handlers.NewCopilotCommandHandler(eventStore)
 	log.Println("Copilot Handlers initialized successfully")
 
-	// Initialize OMS Projection Worker
-	log.Println("Initializing OMS Projection Worker...")
-	omsProjection, err := projections.NewOMSProjection(db, eventBus)
+	// Initialize Leader Elector for singleton background workers
+	log.Printf("Initializing Leader Elector (instance %s)...", cfg.InstanceID)
+	elector := leader.NewElector(db, cfg.InstanceID, cfg.LeaderInterval)
+
+	elector.Register("oms-projection", func() (leader.Worker, error) {
+		return projections.NewOMSProjection(db, eventBus, eventStore)
+	})
+	elector.Register("ems-projection", func() (leader.Worker, error) {
+		return projections.NewEMSProjection(db, eventBus, eventStore)
+	})
+	elector.Register("pms-projection", func() (leader.Worker, error) {
+		return projections.NewPMSProjection(db, eventBus, eventStore)
+	})
+	elector.Register("compliance-projection", func() (leader.Worker, error) {
+		return projections.NewComplianceProjection(db, eventBus, eventStore)
+	})
+	elector.Register("ems-listener", func() (leader.Worker, error) {
+		return ems.NewService(db, eventStore, eventBus, tradingControlService, feeService, marketCalendar, venueRouter)
+	})
+	elector.Register("compliance-listener", func() (leader.Worker, error) {
+		return compliance.NewService(db, eventStore, eventBus)
+	})
+
+	// Order expiry runs on one instance so DAY and GTD orders expire exactly once
+	dayCutoff, err := oms.ParseDayCutoff(cfg.OrderDayCutoff, cfg.OrderCutoffTimezone)
 	if err != nil {
-		log.Fatalf("Failed to initialize OMS Projection: %v", err)
+		log.Fatalf("Failed to parse order day cutoff: %v", err)
+	}
+	dayCutoff.Calendar = marketCalendar
+	if cfg.OrderExpiryInterval > 0 {
+		elector.Register("order-expiry", func() (leader.Worker, error) {
+			return oms.NewExpiryJob(omsService, db, emsService, dayCutoff, cfg.OrderExpiryInterval), nil
+		})
+	}
+	if cfg.SettlementInterval > 0 {
+		elector.Register("settlement-job", func() (leader.Worker, error) {
+			return settlement.NewJob(settlementService, cfg.SettlementInterval), nil
+		})
 	}
 
-	// Start projection worker in background
-	go omsProjection.Start()
-	log.Println("OMS Projection Worker started")
-
-	// Initialize EMS Projection Worker
-	log.Println("Initializing EMS Projection Worker...")
-	emsProjection, err := projections.NewEMSProjection(db, eventBus)
+	// Initialize Reconciliation Service
+	log.Println("Initializing Reconciliation Service...")
+	reconciliationService, err := reconciliation.NewService(db, eventStore)
 	if err != nil {
-		log.Fatalf("Failed to initialize EMS Projection: %v", err)
+		log.Fatalf("Failed to initialize Reconciliation Service: %v", err)
+	}
+	if cfg.ReconciliationInterval > 0 {
+		elector.Register("reconciliation-job", func() (leader.Worker, error) {
+			return reconciliation.NewJob(reconciliationService, cfg.ReconciliationInterval, cfg.ReconciliationAutoRepair), nil
+		})
+	}
+	log.Println("Reconciliation Service initialized successfully")
+
+	// FIX order entry is optional. One instance accepts sessions at a time; its
+	// sequence numbers and ClOrdIDs are stored, so another instance can take over.
+	if cfg.FixAddress != "" {
+		elector.Register("fix-acceptor", func() (leader.Worker, error) {
+			fixAcceptor := fix.NewAcceptor(fix.Config{
+				Address:       cfg.FixAddress,
+				SenderCompID:  cfg.FixSenderCompID,
+				TargetCompIDs: cfg.FixTargetCompIDs,
+			}, omsService, eventBus, fix.NewPostgresStore(db))
+			if err := fixAcceptor.Listen(); err != nil {
+				return nil, fmt.Errorf("failed to start FIX acceptor: %w", err)
+			}
+			return fixAcceptor, nil
+		})
 	}
 
-	go emsProjection.Start()
-	log.Println("EMS Projection Worker started")
+	// Projections and listeners start on whichever instance wins each role
+	go elector.Start()
+	log.Println("Leader Elector started")
 
-	// Initialize PMS Projection Worker
-	log.Println("Initializing PMS Projection Worker...")
-	pmsProjection, err := projections.NewPMSProjection(db, eventBus)
+	// Initialize Worker Handlers
+	log.Println("Initializing Worker Handlers...")
+	workerQueryHandler, err := handlers.NewWorkerQueryHandler(db, elector)
 	if err != nil {
-		log.Fatalf("Failed to initialize PMS Projection: %v", err)
+		log.Fatalf("Failed to initialize Worker Query Handler: %v", err)
 	}
+	log.Println("Worker Handlers initialized successfully")
 
-	go pmsProjection.Start()
-	log.Println("PMS Projection Worker started")
-
-	// Initialize Compliance Projection Worker
-	log.Println("Initializing Compliance Projection Worker...")
-	complianceProjection, err := projections.NewComplianceProjection(db, eventBus)
+	// Initialize Projection Handlers
+	log.Println("Initializing Projection Handlers...")
+	deadLetters := projections.NewDeadLetterQueue(db)
+	projectionCommandHandler := handlers.NewProjectionCommandHandler(deadLetters)
+	projectionQueryHandler, err := handlers.NewProjectionQueryHandler(deadLetters)
 	if err != nil {
-		log.Fatalf("Failed to initialize Compliance Projection: %v", err)
+		log.Fatalf("Failed to initialize Projection Query Handler: %v", err)
 	}
+	log.Println("Projection Handlers initialized successfully")
 
-	go complianceProjection.Start()
-	log.Println("Compliance Projection Worker started")
-
-	// Start EMS simulation listener
-	go emsService.Start()
-	log.Println("EMS Service listener started")
+	// Initialize Reconciliation Handlers
+	log.Println("Initializing Reconciliation Handlers...")
+	reconciliationCommandHandler := handlers.NewReconciliationCommandHandler(reconciliationService)
+	reconciliationQueryHandler, err := handlers.NewReconciliationQueryHandler(reconciliationService)
+	if err != nil {
+		log.Fatalf("Failed to initialize Reconciliation Query Handler: %v", err)
+	}
+	log.Println("Reconciliation Handlers initialized successfully")
 
-	// Start Compliance service listener
-	go complianceService.Start()
-	log.Println("Compliance Service listener started")
+	// Initialize read-your-writes waiter for view endpoints
+	consistencyWaiter := handlers.NewConsistencyWaiter(projections.NewCheckpointStore(db), cfg.ReadConsistencyTimeout)
 
 	// Initialize Gin router
 	router := gin.Default()
@@ -201,8 +428,28 @@ func main() {
 		pmsQueryHandler,
 		complianceCommandHandler,
 		complianceQueryHandler,
+		approvalCommandHandler,
+		approvalQueryHandler,
+		tradingControlCommandHandler,
+		tradingControlQueryHandler,
+		riskCommandHandler,
+		riskQueryHandler,
+		feeCommandHandler,
+		feeQueryHandler,
+		settlementCommandHandler,
+		settlementQueryHandler,
+		routingCommandHandler,
+		routingQueryHandler,
+		uploadCommandHandler,
 		marketDataQueryHandler,
+		calendarQueryHandler,
 		copilotCommandHandler,
+		workerQueryHandler,
+		projectionCommandHandler,
+		projectionQueryHandler,
+		reconciliationCommandHandler,
+		reconciliationQueryHandler,
+		consistencyWaiter,
 		eventStore,
 	)
 
@@ -222,13 +469,8 @@ func main() {
 	<-quit
 	log.Println("Shutting down server...")
 
-	// Stop projection worker
-	omsProjection.Stop()
-	emsProjection.Stop()
-	pmsProjection.Stop()
-	complianceProjection.Stop()
-	emsService.Stop()
-	complianceService.Stop()
+	// Stop background workers and release their roles
+	elector.Stop()
 
 	log.Println("Server stopped gracefully")
 }
@@ -238,7 +480,8 @@ func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Correlation-ID")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Correlation-ID, X-Min-Position")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Projection-Position")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
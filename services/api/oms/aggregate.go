@@ -0,0 +1,333 @@
+package oms
+
+import (
+	"errors"
+	"fmt"
+	"instant/services/api/events"
+	"time"
+)
+
+var (
+	// ErrAmendBelowFilled is returned when an amendment would reduce quantity below what has already filled
+	ErrAmendBelowFilled = errors.New("amended quantity is below filled quantity")
+	// ErrSelfApproval is returned when the order's creator tries to approve it
+	ErrSelfApproval = errors.New("approver must differ from the order's creator")
+	// ErrDuplicateApprover is returned when the same person approves an order twice
+	ErrDuplicateApprover = errors.New("approver has already approved this order")
+)
+
+// orderTransitions is the OrderState transition table. States missing from
+// the table are terminal.
+var orderTransitions = map[OrderState][]OrderState{
+	OrderStateDraft:           {OrderStateApprovalPending, OrderStateApproved, OrderStateRejected, OrderStateCancelled, OrderStateExpired, OrderStateReplaced},
+	OrderStateApprovalPending: {OrderStateApprovalPending, OrderStateApproved, OrderStateRejected, OrderStateCancelled, OrderStateExpired, OrderStateReplaced},
+	OrderStateApproved:        {OrderStateApprovalPending, OrderStateSent, OrderStateCancelled, OrderStateExpired, OrderStateReplaced},
+	OrderStateSent:            {OrderStatePartiallyFilled, OrderStateFilled, OrderStateRejected, OrderStateCancelled, OrderStateExpired, OrderStateReplaced},
+	OrderStatePartiallyFilled: {OrderStatePartiallyFilled, OrderStateFilled, OrderStateCancelled, OrderStateExpired, OrderStateReplaced},
+	OrderStateFilled:          {OrderStateSettled},
+}
+
+// amendableStates lists the states in which an order may still be amended
+var amendableStates = map[OrderState]bool{
+	OrderStateDraft:           true,
+	OrderStateApprovalPending: true,
+	OrderStateApproved:        true,
+	OrderStateSent:            true,
+	OrderStatePartiallyFilled: true,
+}
+
+// StateError reports a command that is not allowed in the order's current state.
+// It wraps ErrInvalidState so callers can match it with errors.Is.
+type StateError struct {
+	OrderID      string
+	Action       string
+	CurrentState OrderState
+}
+
+func (e *StateError) Error() string {
+	return fmt.Sprintf("%s: cannot %s order %s in state %s", ErrInvalidState, e.Action, e.OrderID, e.CurrentState)
+}
+
+func (e *StateError) Unwrap() error {
+	return ErrInvalidState
+}
+
+// OrderAggregate is the write-side view of an order, rebuilt from its event stream
+type OrderAggregate struct {
+	OrderID        string
+	AccountID      string
+	InstrumentID   string
+	Side           OrderSide
+	Quantity       float64
+	OrderType      OrderType
+	LimitPrice     *float64
+	LimitYield     *float64
+	CurveSpreadBp  *float64
+	TimeInForce    TimeInForce
+	ExpireAt       *time.Time
+	CreatedAt      time.Time
+	State          OrderState
+	CreatedBy      string
+	BlockID        string
+	FilledQuantity float64
+	Version        int
+
+	// LimitPricedAsOf is the curve date the limit's price and yield were converted on
+	LimitPricedAsOf *time.Time
+
+	// ChainID is the ID of the first order in a cancel/replace chain and
+	// ChainVersion this order's place in it, starting at 1
+	ChainID           string
+	ChainVersion      int
+	ReplacesOrderID   string
+	ReplacedByOrderID string
+
+	// RequiredApprovals is how many distinct approvers the current approval request needs
+	RequiredApprovals int
+	// Approvers lists who has approved since approval was last requested
+	Approvers []string
+
+	// filledByExecution holds the cumulative fill reported by each execution
+	filledByExecution map[string]float64
+}
+
+// LoadOrderAggregate folds an order's events into its current state
+func LoadOrderAggregate(orderEvents []*events.Event) (*OrderAggregate, error) {
+	order := &OrderAggregate{filledByExecution: map[string]float64{}}
+	for _, event := range orderEvents {
+		order.Apply(event)
+	}
+	if order.OrderID == "" {
+		return nil, ErrOrderNotFound
+	}
+	return order, nil
+}
+
+// Apply folds a single event into the aggregate
+func (o *OrderAggregate) Apply(event *events.Event) {
+	payload := event.Payload
+	o.Version++
+
+	switch event.EventType {
+	case events.EventOrderCreated:
+		o.OrderID = event.Aggregate.ID
+		o.AccountID, _ = payload["accountId"].(string)
+		o.InstrumentID, _ = payload["instrumentId"].(string)
+		o.Side = OrderSide(stringField(payload, "side"))
+		o.Quantity, _ = payload["quantity"].(float64)
+		o.OrderType = OrderType(stringField(payload, "orderType"))
+		o.LimitPrice = floatField(payload, "limitPrice")
+		o.LimitYield = floatField(payload, "limitYield")
+		o.LimitPricedAsOf = timeField(payload, "limitPricedAsOf")
+		o.CurveSpreadBp = floatField(payload, "curveSpreadBp")
+		o.TimeInForce = TimeInForce(stringField(payload, "timeInForce"))
+		o.ExpireAt = timeField(payload, "expireAt")
+		o.CreatedAt = event.OccurredAt
+		o.State = OrderStateDraft
+		o.CreatedBy, _ = payload["createdBy"].(string)
+		o.BlockID, _ = payload["blockId"].(string)
+		o.ChainID = stringField(payload, "chainId")
+		if o.ChainID == "" {
+			o.ChainID = o.OrderID
+		}
+		o.ChainVersion = intField(payload, "chainVersion", 1)
+		o.ReplacesOrderID = stringField(payload, "replacesOrderId")
+	case events.EventOrderAmended:
+		if quantity, ok := payload["quantity"].(float64); ok {
+			o.Quantity = quantity
+		}
+		if orderType, ok := payload["orderType"].(string); ok {
+			o.OrderType = OrderType(orderType)
+		}
+		if limitPrice := floatField(payload, "limitPrice"); limitPrice != nil {
+			o.LimitPrice = limitPrice
+		}
+		if limitYield := floatField(payload, "limitYield"); limitYield != nil {
+			o.LimitYield = limitYield
+		}
+		if limitPricedAsOf := timeField(payload, "limitPricedAsOf"); limitPricedAsOf != nil {
+			o.LimitPricedAsOf = limitPricedAsOf
+		}
+		if curveSpreadBp := floatField(payload, "curveSpreadBp"); curveSpreadBp != nil {
+			o.CurveSpreadBp = curveSpreadBp
+		}
+	case events.EventOrderApprovalRequested:
+		o.State = OrderStateApprovalPending
+		o.RequiredApprovals = intField(payload, "requiredApprovals", 1)
+		o.Approvers = nil
+	case events.EventOrderApprovalRecorded:
+		o.Approvers = append(o.Approvers, stringField(payload, "approvedBy"))
+	case events.EventOrderApproved:
+		o.State = OrderStateApproved
+		o.Approvers = append(o.Approvers, stringField(payload, "approvedBy"))
+	case events.EventOrderRejected, events.EventOrderBlockedByCompliance:
+		o.State = OrderStateRejected
+	case events.EventOrderSentToEMS:
+		o.State = OrderStateSent
+	case events.EventOrderPartiallyFilled:
+		o.applyFill(payload)
+		o.State = OrderStatePartiallyFilled
+	case events.EventOrderFullyFilled:
+		o.applyFill(payload)
+		o.State = OrderStateFilled
+	case events.EventOrderCancelled:
+		o.State = OrderStateCancelled
+	case events.EventOrderExpired:
+		o.State = OrderStateExpired
+	case events.EventOrderReplaced:
+		o.State = OrderStateReplaced
+		o.ReplacedByOrderID = stringField(payload, "replacedByOrderId")
+	}
+}
+
+// applyFill records an execution's cumulative filled quantity
+func (o *OrderAggregate) applyFill(payload map[string]interface{}) {
+	filled, ok := payload["filledQuantity"].(float64)
+	if !ok {
+		return
+	}
+	executionID, _ := payload["executionId"].(string)
+	o.filledByExecution[executionID] = filled
+
+	o.FilledQuantity = 0
+	for _, quantity := range o.filledByExecution {
+		o.FilledQuantity += quantity
+	}
+}
+
+// CanTransitionTo reports whether the order may move to the given state
+func (o *OrderAggregate) CanTransitionTo(next OrderState) bool {
+	for _, allowed := range orderTransitions[o.State] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireTransition returns a StateError if the order cannot move to the given state
+func (o *OrderAggregate) RequireTransition(action string, next OrderState) error {
+	if !o.CanTransitionTo(next) {
+		return &StateError{OrderID: o.OrderID, Action: action, CurrentState: o.State}
+	}
+	return nil
+}
+
+// ValidateApprover enforces four-eyes on orders pending approval: the
+// approver may not be the order's creator or someone who already approved it
+func (o *OrderAggregate) ValidateApprover(approvedBy string) error {
+	if o.State != OrderStateApprovalPending {
+		return nil
+	}
+	if approvedBy == o.CreatedBy {
+		return ErrSelfApproval
+	}
+	for _, approver := range o.Approvers {
+		if approver == approvedBy {
+			return ErrDuplicateApprover
+		}
+	}
+	return nil
+}
+
+// ApprovalsNeeded returns how many approvals the order needs in total, including this one
+func (o *OrderAggregate) ApprovalsNeeded() int {
+	if o.State != OrderStateApprovalPending || o.RequiredApprovals < 1 {
+		return 1
+	}
+	return o.RequiredApprovals
+}
+
+// ValidateAmend checks that an amendment is allowed in the current state and
+// does not shrink the order below what has already filled
+func (o *OrderAggregate) ValidateAmend(req AmendOrderRequest) error {
+	if !amendableStates[o.State] {
+		return &StateError{OrderID: o.OrderID, Action: "amend", CurrentState: o.State}
+	}
+	if req.Quantity != nil {
+		if *req.Quantity <= 0 {
+			return ErrInvalidQuantity
+		}
+		if *req.Quantity < o.FilledQuantity {
+			return fmt.Errorf("%w: filled %.2f", ErrAmendBelowFilled, o.FilledQuantity)
+		}
+	}
+	return nil
+}
+
+// Terms returns the order's current terms in the shape compliance and
+// approval policies evaluate new orders in
+func (o *OrderAggregate) Terms() CreateOrderRequest {
+	return CreateOrderRequest{
+		AccountID:     o.AccountID,
+		InstrumentID:  o.InstrumentID,
+		Side:          o.Side,
+		Quantity:      o.Quantity,
+		OrderType:     o.OrderType,
+		LimitPrice:    o.LimitPrice,
+		LimitYield:    o.LimitYield,
+		CurveSpreadBp: o.CurveSpreadBp,
+		TimeInForce:   o.TimeInForce,
+		ExpireAt:      o.ExpireAt,
+		CreatedBy:     o.CreatedBy,
+		limitAsOf:     o.LimitPricedAsOf,
+	}
+}
+
+// AmendedTerms returns the order's terms with the amendment applied
+func (o *OrderAggregate) AmendedTerms(req AmendOrderRequest) CreateOrderRequest {
+	terms := o.Terms()
+	if req.Quantity != nil {
+		terms.Quantity = *req.Quantity
+	}
+	if req.OrderType != nil {
+		terms.OrderType = *req.OrderType
+	}
+	if req.LimitPrice != nil {
+		terms.LimitPrice = req.LimitPrice
+	}
+	if req.LimitYield != nil {
+		terms.LimitYield = req.LimitYield
+	}
+	if req.CurveSpreadBp != nil {
+		terms.CurveSpreadBp = req.CurveSpreadBp
+	}
+	return terms
+}
+
+func stringField(payload map[string]interface{}, key string) string {
+	value, _ := payload[key].(string)
+	return value
+}
+
+func intField(payload map[string]interface{}, key string, fallback int) int {
+	switch value := payload[key].(type) {
+	case int:
+		return value
+	case float64:
+		return int(value)
+	}
+	return fallback
+}
+
+// timeField reads a timestamp that is a time.Time when published in-process
+// and an RFC 3339 string once loaded from the event store
+func timeField(payload map[string]interface{}, key string) *time.Time {
+	switch value := payload[key].(type) {
+	case time.Time:
+		return &value
+	case string:
+		if parsed, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return &parsed
+		}
+	}
+	return nil
+}
+
+func floatField(payload map[string]interface{}, key string) *float64 {
+	value, ok := payload[key].(float64)
+	if !ok {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,239 @@
+package oms
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"instant/services/api/events"
+	"instant/services/api/services/calendar"
+)
+
+func orderEvent(eventType string, payload map[string]interface{}) *events.Event {
+	return events.NewEvent(eventType, events.AggregateOrder, "order-1", "trader", "user", "corr-1", payload)
+}
+
+func TestOrderAggregateRejectsIllegalTransitions(t *testing.T) {
+	order, err := LoadOrderAggregate([]*events.Event{
+		orderEvent(events.EventOrderCreated, map[string]interface{}{"orderId": "order-1", "quantity": 1000.0}),
+		orderEvent(events.EventOrderApproved, map[string]interface{}{"orderId": "order-1"}),
+		orderEvent(events.EventOrderSentToEMS, map[string]interface{}{"orderId": "order-1"}),
+		orderEvent(events.EventOrderFullyFilled, map[string]interface{}{"orderId": "order-1", "executionId": "exec-1", "filledQuantity": 1000.0}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.State != OrderStateFilled {
+		t.Fatalf("expected FILLED, got %s", order.State)
+	}
+
+	err = order.RequireTransition("cancel", OrderStateCancelled)
+	var stateErr *StateError
+	if !errors.As(err, &stateErr) || !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected state error, got %v", err)
+	}
+	if stateErr.CurrentState != OrderStateFilled {
+		t.Fatalf("expected current state FILLED, got %s", stateErr.CurrentState)
+	}
+}
+
+func TestOrderAggregateDraftCannotBeSent(t *testing.T) {
+	order, err := LoadOrderAggregate([]*events.Event{
+		orderEvent(events.EventOrderCreated, map[string]interface{}{"orderId": "order-1", "quantity": 1000.0}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := order.RequireTransition("send", OrderStateSent); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+	if err := order.RequireTransition("approve", OrderStateApproved); err != nil {
+		t.Fatalf("expected draft to be approvable, got %v", err)
+	}
+}
+
+func TestOrderAggregateRejectsAmendBelowFilled(t *testing.T) {
+	order, err := LoadOrderAggregate([]*events.Event{
+		orderEvent(events.EventOrderCreated, map[string]interface{}{"orderId": "order-1", "quantity": 1000.0}),
+		orderEvent(events.EventOrderApproved, map[string]interface{}{"orderId": "order-1"}),
+		orderEvent(events.EventOrderSentToEMS, map[string]interface{}{"orderId": "order-1"}),
+		orderEvent(events.EventOrderPartiallyFilled, map[string]interface{}{"orderId": "order-1", "executionId": "exec-1", "filledQuantity": 250.0}),
+		orderEvent(events.EventOrderPartiallyFilled, map[string]interface{}{"orderId": "order-1", "executionId": "exec-1", "filledQuantity": 400.0}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.FilledQuantity != 400 {
+		t.Fatalf("expected filled quantity 400, got %v", order.FilledQuantity)
+	}
+
+	below := 300.0
+	if err := order.ValidateAmend(AmendOrderRequest{OrderID: "order-1", Quantity: &below}); !errors.Is(err, ErrAmendBelowFilled) {
+		t.Fatalf("expected ErrAmendBelowFilled, got %v", err)
+	}
+	above := 500.0
+	if err := order.ValidateAmend(AmendOrderRequest{OrderID: "order-1", Quantity: &above}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestLoadOrderAggregateWithoutEvents(t *testing.T) {
+	if _, err := LoadOrderAggregate(nil); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("expected ErrOrderNotFound, got %v", err)
+	}
+}
+
+func TestOrderAggregateFourEyesApproval(t *testing.T) {
+	order, err := LoadOrderAggregate([]*events.Event{
+		orderEvent(events.EventOrderCreated, map[string]interface{}{"orderId": "order-1", "quantity": 1000.0, "createdBy": "trader"}),
+		orderEvent(events.EventOrderApprovalRequested, map[string]interface{}{"orderId": "order-1", "requiredApprovals": 2.0}),
+		orderEvent(events.EventOrderApprovalRecorded, map[string]interface{}{"orderId": "order-1", "approvedBy": "pm-1"}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.ApprovalsNeeded() != 2 || len(order.Approvers) != 1 {
+		t.Fatalf("expected 1 of 2 approvals, got %d of %d", len(order.Approvers), order.ApprovalsNeeded())
+	}
+
+	if err := order.ValidateApprover("trader"); !errors.Is(err, ErrSelfApproval) {
+		t.Fatalf("expected ErrSelfApproval, got %v", err)
+	}
+	if err := order.ValidateApprover("pm-1"); !errors.Is(err, ErrDuplicateApprover) {
+		t.Fatalf("expected ErrDuplicateApprover, got %v", err)
+	}
+	if err := order.ValidateApprover("pm-2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestOrderAggregateExpiresAtDayCutoff(t *testing.T) {
+	cutoff, err := ParseDayCutoff("17:00", "America/New_York")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	created := orderEvent(events.EventOrderCreated, map[string]interface{}{"orderId": "order-1", "quantity": 1000.0, "timeInForce": "DAY"})
+	// 18:30 New York, after the cutoff, so the order lives until the next day's cutoff
+	created.OccurredAt = time.Date(2026, 3, 9, 22, 30, 0, 0, time.UTC)
+	order, err := LoadOrderAggregate([]*events.Event{created})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expiresAt := order.ExpiresAt(cutoff)
+	want := time.Date(2026, 3, 10, 21, 0, 0, 0, time.UTC)
+	if expiresAt == nil || !expiresAt.Equal(want) {
+		t.Fatalf("expected expiry at %s, got %v", want, expiresAt)
+	}
+
+	order.Apply(orderEvent(events.EventOrderExpired, map[string]interface{}{"orderId": "order-1"}))
+	if err := order.RequireTransition("approve", OrderStateApproved); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected expired order to be terminal, got %v", err)
+	}
+
+	gtc, err := LoadOrderAggregate([]*events.Event{
+		orderEvent(events.EventOrderCreated, map[string]interface{}{"orderId": "order-1", "quantity": 1000.0, "timeInForce": "GTC"}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gtc.ExpiresAt(cutoff) != nil {
+		t.Fatalf("expected GTC order not to expire")
+	}
+}
+
+func TestDayCutoffFollowsMarketCalendar(t *testing.T) {
+	cutoff, err := ParseDayCutoff("17:00", "America/New_York")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cutoff.Calendar = calendar.New(cutoff.Location, calendar.DefaultSettlementCycle, nil)
+
+	// Thanksgiving is closed and the day after closes at 14:00 New York
+	got := cutoff.Next(time.Date(2026, 11, 26, 15, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 11, 27, 19, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("expected cutoff at %s, got %s", want, got)
+	}
+
+	// After the early close on Thursday 2 July 2026, Independence Day is
+	// observed on the Friday, so the next cutoff is Monday's
+	got = cutoff.Next(time.Date(2026, 7, 2, 19, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 7, 6, 21, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("expected cutoff at %s, got %s", want, got)
+	}
+}
+
+func TestDayCutoffPreviousIsTheLastCutoffPassed(t *testing.T) {
+	cutoff, err := ParseDayCutoff("17:00", "America/New_York")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cutoff.Calendar = calendar.New(cutoff.Location, calendar.DefaultSettlementCycle, nil)
+
+	// Over the weekend the last cutoff is Friday's 14:00 early close
+	got := cutoff.Previous(time.Date(2026, 11, 28, 15, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 11, 27, 19, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("expected cutoff at %s, got %s", want, got)
+	}
+
+	// On Monday morning it is still Thursday's early close, skipping the holiday
+	got = cutoff.Previous(time.Date(2026, 7, 6, 14, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 7, 2, 18, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("expected cutoff at %s, got %s", want, got)
+	}
+
+	// A cutoff is passed the instant it is reached, and an order created by
+	// it has expired
+	at := time.Date(2026, 7, 6, 21, 0, 0, 0, time.UTC)
+	if got := cutoff.Previous(at); !got.Equal(at) {
+		t.Fatalf("expected cutoff at %s, got %s", at, got)
+	}
+	created := time.Date(2026, 7, 6, 20, 0, 0, 0, time.UTC)
+	if next := cutoff.Next(created); next.After(at) {
+		t.Fatalf("expected an order created at %s to expire by %s, not %s", created, at, next)
+	}
+}
+
+func TestOrderAggregateReplacementWorksUnfilledQuantity(t *testing.T) {
+	order, err := LoadOrderAggregate([]*events.Event{
+		orderEvent(events.EventOrderCreated, map[string]interface{}{"orderId": "order-1", "quantity": 1000.0, "orderType": "LIMIT", "limitPrice": 99.5, "timeInForce": "DAY"}),
+		orderEvent(events.EventOrderApproved, map[string]interface{}{"orderId": "order-1"}),
+		orderEvent(events.EventOrderSentToEMS, map[string]interface{}{"orderId": "order-1"}),
+		orderEvent(events.EventOrderPartiallyFilled, map[string]interface{}{"orderId": "order-1", "executionId": "exec-1", "filledQuantity": 400.0}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.ChainID != "order-1" || order.ChainVersion != 1 {
+		t.Fatalf("expected order to start its own chain at version 1, got %s v%d", order.ChainID, order.ChainVersion)
+	}
+
+	limitPrice := 99.75
+	terms := order.ReplacementTerms(ReplaceOrderRequest{OrderID: "order-1", LimitPrice: &limitPrice, ReplacedBy: "trader-2"})
+	if terms.Quantity != 600 {
+		t.Fatalf("expected the replacement to work the unfilled 600, got %.2f", terms.Quantity)
+	}
+	if terms.CreatedBy != "trader-2" {
+		t.Fatalf("expected the replacement to be created by trader-2, got %s", terms.CreatedBy)
+	}
+
+	changes := termChanges(order.Terms(), terms)
+	if len(changes) != 2 {
+		t.Fatalf("expected quantity and limit price changes, got %+v", changes)
+	}
+	if changes[0].Field != "quantity" || changes[0].From != 1000.0 || changes[0].To != 600.0 {
+		t.Fatalf("unexpected quantity change: %+v", changes[0])
+	}
+	if changes[1].Field != "limitPrice" || changes[1].From != 99.5 || changes[1].To != 99.75 {
+		t.Fatalf("unexpected limit price change: %+v", changes[1])
+	}
+
+	order.Apply(orderEvent(events.EventOrderReplaced, map[string]interface{}{"orderId": "order-1", "replacedByOrderId": "order-2"}))
+	if order.State != OrderStateReplaced || order.ReplacedByOrderID != "order-2" {
+		t.Fatalf("expected REPLACED by order-2, got %s by %q", order.State, order.ReplacedByOrderID)
+	}
+	if err := order.RequireTransition("replace", OrderStateReplaced); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected a replaced order to be terminal, got %v", err)
+	}
+}
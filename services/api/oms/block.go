@@ -0,0 +1,390 @@
+package oms
+
+import (
+	"errors"
+	"fmt"
+	"instant/services/api/events"
+	"instant/services/api/services/allocation"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrBlockNotFound is returned when no block order exists with the given ID
+	ErrBlockNotFound = errors.New("block order not found")
+	// ErrDuplicateAllocation is returned when an account appears twice in one block
+	ErrDuplicateAllocation = errors.New("each account may appear only once in a block")
+	// ErrEmptyBlock is returned when a block has no child orders left to send
+	ErrEmptyBlock = errors.New("block has no working child orders")
+	// ErrBlockChildOrder is returned when a child order is sent to the EMS on its own
+	ErrBlockChildOrder = errors.New("child orders are sent to the EMS with their block")
+)
+
+// excludedChildStates are child order states that drop out of a block when it is sent
+var excludedChildStates = map[OrderState]bool{
+	OrderStateRejected:  true,
+	OrderStateCancelled: true,
+	OrderStateExpired:   true,
+}
+
+// BlockOrderAggregate is the write-side view of a block order, rebuilt from its event stream
+type BlockOrderAggregate struct {
+	BlockID       string
+	InstrumentID  string
+	Side          OrderSide
+	OrderType     OrderType
+	LimitPrice    *float64
+	CurveSpreadBp *float64
+	TimeInForce   TimeInForce
+	LotSize       float64
+	State         OrderState
+	CreatedBy     string
+	ChildOrderIDs []string
+	Version       int
+}
+
+// LoadBlockOrderAggregate folds a block order's events into its current state
+func LoadBlockOrderAggregate(blockEvents []*events.Event) (*BlockOrderAggregate, error) {
+	block := &BlockOrderAggregate{}
+	for _, event := range blockEvents {
+		block.Apply(event)
+	}
+	if block.BlockID == "" {
+		return nil, ErrBlockNotFound
+	}
+	return block, nil
+}
+
+// Apply folds a single event into the aggregate
+func (b *BlockOrderAggregate) Apply(event *events.Event) {
+	payload := event.Payload
+	b.Version++
+
+	switch event.EventType {
+	case events.EventBlockOrderCreated:
+		b.BlockID = event.Aggregate.ID
+		b.InstrumentID = stringField(payload, "instrumentId")
+		b.Side = OrderSide(stringField(payload, "side"))
+		b.OrderType = OrderType(stringField(payload, "orderType"))
+		b.LimitPrice = floatField(payload, "limitPrice")
+		b.CurveSpreadBp = floatField(payload, "curveSpreadBp")
+		b.TimeInForce = TimeInForce(stringField(payload, "timeInForce"))
+		if lotSize := floatField(payload, "lotSize"); lotSize != nil {
+			b.LotSize = *lotSize
+		}
+		b.State = OrderStateDraft
+		b.CreatedBy = stringField(payload, "createdBy")
+		b.ChildOrderIDs = nil
+		for _, child := range payloadList(payload, "allocations") {
+			b.ChildOrderIDs = append(b.ChildOrderIDs, stringField(child, "orderId"))
+		}
+	case events.EventBlockOrderSentToEMS:
+		b.State = OrderStateSent
+	case events.EventBlockOrderAllocated:
+		b.State = OrderState(stringField(payload, "state"))
+	}
+}
+
+// CreateBlockOrder records a block order and creates one child order per
+// allocation. Each child goes through compliance and approval for its own
+// account; children that compliance blocks are left out when the block is sent.
+func (s *Service) CreateBlockOrder(req CreateBlockOrderRequest, correlationID string) (*CreateBlockOrderResult, error) {
+	if req.TimeInForce == "" {
+		req.TimeInForce = TimeInForceDay
+	}
+	lotSize := allocation.DefaultLotSize
+	if req.LotSize != nil {
+		lotSize = *req.LotSize
+	}
+	if err := s.validateCreateBlockOrderRequest(req, lotSize); err != nil {
+		return nil, err
+	}
+
+	accountIDs := make([]string, 0, len(req.Allocations))
+	for _, alloc := range req.Allocations {
+		accountIDs = append(accountIDs, alloc.AccountID)
+	}
+	if err := s.checkTradingControls(req.CreatedBy, accountIDs, len(req.Allocations), correlationID); err != nil {
+		return nil, err
+	}
+
+	// The limit is priced once for the block and shared by every child
+	limit, err := s.priceLimit(req.orderTerms(req.Allocations[0].Quantity))
+	if err != nil {
+		return nil, err
+	}
+
+	children := make([]CreateOrderRequest, 0, len(req.Allocations))
+	for _, alloc := range req.Allocations {
+		child := limit
+		child.AccountID = alloc.AccountID
+		child.Quantity = alloc.Quantity
+		children = append(children, child)
+	}
+	exposures, err := s.checkRiskLimits(req.CreatedBy, children, false, correlationID)
+	if err != nil {
+		return nil, err
+	}
+
+	admission, err := s.admitOrders(req.CreatedBy, accountIDs, len(req.Allocations), correlationID)
+	if err != nil {
+		return nil, err
+	}
+
+	blockID := uuid.New().String()
+	total := 0.0
+	allocations := make([]map[string]interface{}, 0, len(req.Allocations))
+	childIDs := make([]string, 0, len(req.Allocations))
+	for _, alloc := range req.Allocations {
+		childID := uuid.New().String()
+		childIDs = append(childIDs, childID)
+		total += alloc.Quantity
+		allocations = append(allocations, map[string]interface{}{
+			"accountId": alloc.AccountID,
+			"orderId":   childID,
+			"quantity":  alloc.Quantity,
+		})
+	}
+
+	payload := map[string]interface{}{
+		"blockId":      blockID,
+		"instrumentId": req.InstrumentID,
+		"side":         req.Side,
+		"quantity":     total,
+		"orderType":    req.OrderType,
+		"timeInForce":  req.TimeInForce,
+		"lotSize":      lotSize,
+		"allocations":  allocations,
+		"state":        OrderStateDraft,
+		"createdBy":    req.CreatedBy,
+	}
+	if limit.LimitPrice != nil {
+		payload["limitPrice"] = *limit.LimitPrice
+	}
+	if limit.LimitYield != nil {
+		payload["limitYield"] = *limit.LimitYield
+	}
+	if req.CurveSpreadBp != nil {
+		payload["curveSpreadBp"] = *req.CurveSpreadBp
+	}
+	if req.ExpireAt != nil {
+		payload["expireAt"] = req.ExpireAt.UTC()
+	}
+
+	event := events.NewEvent(
+		events.EventBlockOrderCreated,
+		events.AggregateBlockOrder,
+		blockID,
+		req.CreatedBy,
+		"user",
+		correlationID,
+		payload,
+	)
+
+	if err := s.eventStore.Append(event); err != nil {
+		s.withdrawOrders(admission, len(req.Allocations))
+		return nil, fmt.Errorf("failed to append BlockOrderCreated event: %w", err)
+	}
+
+	s.eventBus.Publish(event)
+
+	result := &CreateBlockOrderResult{BlockID: blockID, Orders: []BlockChildResult{}}
+	// Only children created and not blocked by compliance count against the
+	// rate limit
+	accepted := 0
+	defer func() { s.withdrawOrders(admission, len(req.Allocations)-accepted) }()
+	for i, alloc := range req.Allocations {
+		child := children[i]
+		child.BlockID = &blockID
+		child.riskExposure = exposures[i]
+
+		childResult := BlockChildResult{AccountID: alloc.AccountID, OrderID: childIDs[i], Quantity: alloc.Quantity, Status: "created"}
+		if _, err := s.createOrder(childIDs[i], child, correlationID); err != nil {
+			if !errors.Is(err, ErrComplianceBlocked) {
+				return nil, err
+			}
+			childResult.Status = "blocked"
+			childResult.Error = err.Error()
+		} else {
+			accepted++
+		}
+		result.Orders = append(result.Orders, childResult)
+	}
+
+	return result, nil
+}
+
+// SendBlockToEMS sends a block order to the EMS as a single order. Every child
+// still working must be approved; rejected, cancelled and expired children are
+// left out and the block trades the sum of the rest.
+func (s *Service) SendBlockToEMS(req SendBlockToEMSRequest, correlationID string) error {
+	block, err := s.loadBlockOrder(req.BlockID)
+	if err != nil {
+		return err
+	}
+	if block.State != OrderStateDraft {
+		return &StateError{OrderID: block.BlockID, Action: "send", CurrentState: block.State}
+	}
+
+	children := []*OrderAggregate{}
+	for _, childID := range block.ChildOrderIDs {
+		child, err := s.loadOrder(childID)
+		if err != nil {
+			return err
+		}
+		if excludedChildStates[child.State] {
+			continue
+		}
+		if err := child.RequireTransition("send", OrderStateSent); err != nil {
+			return err
+		}
+		children = append(children, child)
+	}
+	if len(children) == 0 {
+		return ErrEmptyBlock
+	}
+
+	// The children and the block are written together, each at the version it
+	// was loaded at, so a block is never sent twice
+	sentAt := time.Now().UTC()
+	total := 0.0
+	batch := make([]*events.Event, 0, len(children)+1)
+	expected := map[events.Aggregate]int{}
+	targets := make([]map[string]interface{}, 0, len(children))
+	for _, child := range children {
+		total += child.Quantity
+		targets = append(targets, map[string]interface{}{
+			"accountId": child.AccountID,
+			"orderId":   child.OrderID,
+			"quantity":  child.Quantity,
+		})
+
+		// Children are marked sent so their own state follows the block; the EMS
+		// executes the block, not the children
+		childEvent := events.NewEvent(
+			events.EventOrderSentToEMS,
+			events.AggregateOrder,
+			child.OrderID,
+			req.SentBy,
+			"user",
+			correlationID,
+			map[string]interface{}{
+				"orderId":     child.OrderID,
+				"blockId":     block.BlockID,
+				"sentBy":      req.SentBy,
+				"sentToEmsAt": sentAt,
+			},
+		)
+		batch = append(batch, childEvent)
+		expected[childEvent.Aggregate] = child.Version
+	}
+
+	payload := map[string]interface{}{
+		"blockId":      block.BlockID,
+		"instrumentId": block.InstrumentID,
+		"side":         block.Side,
+		"quantity":     total,
+		"orderType":    block.OrderType,
+		"timeInForce":  block.TimeInForce,
+		"lotSize":      block.LotSize,
+		"allocations":  targets,
+		"sentBy":       req.SentBy,
+		"sentToEmsAt":  sentAt,
+	}
+	if block.LimitPrice != nil {
+		payload["limitPrice"] = *block.LimitPrice
+	}
+	if block.CurveSpreadBp != nil {
+		payload["curveSpreadBp"] = *block.CurveSpreadBp
+	}
+
+	event := events.NewEvent(
+		events.EventBlockOrderSentToEMS,
+		events.AggregateBlockOrder,
+		block.BlockID,
+		req.SentBy,
+		"user",
+		correlationID,
+		payload,
+	)
+
+	batch = append(batch, event)
+	expected[event.Aggregate] = block.Version
+
+	if err := s.eventStore.AppendAll(batch, expected); err != nil {
+		return fmt.Errorf("failed to append BlockOrderSentToEMS events: %w", err)
+	}
+
+	for _, sent := range batch {
+		s.eventBus.Publish(sent)
+	}
+
+	return nil
+}
+
+// loadBlockOrder rehydrates the block order aggregate from the event store
+func (s *Service) loadBlockOrder(blockID string) (*BlockOrderAggregate, error) {
+	blockEvents, err := s.eventStore.GetByAggregate(events.AggregateBlockOrder, blockID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load block order events: %w", err)
+	}
+	return LoadBlockOrderAggregate(blockEvents)
+}
+
+// validateCreateBlockOrderRequest validates the block terms and its allocations
+func (s *Service) validateCreateBlockOrderRequest(req CreateBlockOrderRequest, lotSize float64) error {
+	if len(req.Allocations) == 0 {
+		return allocation.ErrNoTargets
+	}
+	if lotSize <= 0 {
+		return allocation.ErrInvalidLot
+	}
+
+	seen := map[string]bool{}
+	for _, alloc := range req.Allocations {
+		if alloc.Quantity <= 0 {
+			return ErrInvalidQuantity
+		}
+		if seen[alloc.AccountID] {
+			return ErrDuplicateAllocation
+		}
+		seen[alloc.AccountID] = true
+	}
+
+	return s.validateCreateOrderRequest(req.orderTerms(req.Allocations[0].Quantity))
+}
+
+// orderTerms returns the block's terms as a child order of the given quantity
+func (req CreateBlockOrderRequest) orderTerms(quantity float64) CreateOrderRequest {
+	return CreateOrderRequest{
+		InstrumentID:  req.InstrumentID,
+		Side:          req.Side,
+		Quantity:      quantity,
+		OrderType:     req.OrderType,
+		LimitPrice:    req.LimitPrice,
+		LimitYield:    req.LimitYield,
+		CurveSpreadBp: req.CurveSpreadBp,
+		TimeInForce:   req.TimeInForce,
+		ExpireAt:      req.ExpireAt,
+		CreatedBy:     req.CreatedBy,
+	}
+}
+
+// payloadList reads a list of objects that is a []map when published
+// in-process and a []interface{} once loaded from the event store
+func payloadList(payload map[string]interface{}, key string) []map[string]interface{} {
+	switch value := payload[key].(type) {
+	case []map[string]interface{}:
+		return value
+	case []interface{}:
+		items := make([]map[string]interface{}, 0, len(value))
+		for _, item := range value {
+			if entry, ok := item.(map[string]interface{}); ok {
+				items = append(items, entry)
+			}
+		}
+		return items
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package oms
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"instant/services/api/services/calendar"
+
+	"github.com/google/uuid"
+)
+
+// expiryActorID is recorded as the actor on OrderExpired events
+const expiryActorID = "system:order-expiry"
+
+// DayCutoff is the wall-clock time at which DAY and unsent IOC orders expire.
+// With a calendar, orders expire on bond market business days only, and at the
+// early close when the market closes before the cutoff.
+type DayCutoff struct {
+	Hour     int
+	Minute   int
+	Location *time.Location
+	Calendar *calendar.Calendar
+}
+
+// ParseDayCutoff parses a cutoff such as "17:00" in the given IANA time zone
+func ParseDayCutoff(clock, timezone string) (DayCutoff, error) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return DayCutoff{}, fmt.Errorf("invalid cutoff %q (expected HH:MM): %w", clock, err)
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return DayCutoff{}, fmt.Errorf("invalid cutoff time zone %q: %w", timezone, err)
+	}
+	return DayCutoff{Hour: parsed.Hour(), Minute: parsed.Minute(), Location: location}, nil
+}
+
+// Next returns the first cutoff at or after t
+func (c DayCutoff) Next(t time.Time) time.Time {
+	local := t.In(c.Location)
+	for day := 0; ; day++ {
+		cutoff, ok := c.on(local.Year(), local.Month(), local.Day()+day)
+		if ok && !cutoff.Before(local) {
+			return cutoff
+		}
+	}
+}
+
+// Previous returns the last cutoff at or before t. An order created at or
+// before it has expired by t.
+func (c DayCutoff) Previous(t time.Time) time.Time {
+	local := t.In(c.Location)
+	for day := 0; ; day-- {
+		cutoff, ok := c.on(local.Year(), local.Month(), local.Day()+day)
+		if ok && !cutoff.After(local) {
+			return cutoff
+		}
+	}
+}
+
+// on is the cutoff on a date, and false if the market is closed that day
+func (c DayCutoff) on(year int, month time.Month, day int) (time.Time, bool) {
+	cutoff := time.Date(year, month, day, c.Hour, c.Minute, 0, 0, c.Location)
+	if c.Calendar == nil {
+		return cutoff, true
+	}
+	if !c.Calendar.IsBusinessDay(cutoff) {
+		return cutoff, false
+	}
+	if earlyClose, ok := c.Calendar.EarlyClose(cutoff); ok && earlyClose.Before(cutoff) {
+		cutoff = earlyClose.In(c.Location)
+	}
+	return cutoff, true
+}
+
+// ExpiresAt returns when the order's time in force runs out, or nil for GTC
+// orders. DAY orders expire at the first cutoff after they were created; IOC
+// orders are cancelled by the EMS once sent, so only unsent ones reach the cutoff.
+func (o *OrderAggregate) ExpiresAt(cutoff DayCutoff) *time.Time {
+	switch o.TimeInForce {
+	case TimeInForceDay, TimeInForceIOC:
+		expiresAt := cutoff.Next(o.CreatedAt)
+		return &expiresAt
+	case TimeInForceGTD:
+		return o.ExpireAt
+	}
+	return nil
+}
+
+// WorkingCanceller pulls an order's working executions from the venues
+// working them
+type WorkingCanceller interface {
+	CancelWorking(orderID string) error
+}
+
+// ExpiryJob expires working orders whose time in force has run out. It is a
+// singleton worker so an order is never expired twice.
+type ExpiryJob struct {
+	service  *Service
+	db       *sql.DB
+	venues   WorkingCanceller
+	cutoff   DayCutoff
+	interval time.Duration
+	stopChan chan struct{}
+}
+
+// NewExpiryJob creates a new order expiry job. Orders already sent are
+// cancelled at their venue through venues before they are expired.
+func NewExpiryJob(service *Service, db *sql.DB, venues WorkingCanceller, cutoff DayCutoff, interval time.Duration) *ExpiryJob {
+	return &ExpiryJob{
+		service:  service,
+		db:       db,
+		venues:   venues,
+		cutoff:   cutoff,
+		interval: interval,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start scans for expired orders every interval until stopped
+func (j *ExpiryJob) Start() {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	log.Printf("Order expiry job started (every %s, DAY cutoff %02d:%02d %s)", j.interval, j.cutoff.Hour, j.cutoff.Minute, j.cutoff.Location)
+
+	for {
+		select {
+		case <-ticker.C:
+			expired, err := j.ExpireDue(time.Now().UTC())
+			if err != nil {
+				log.Printf("Order expiry job failed: %v", err)
+				continue
+			}
+			if expired > 0 {
+				log.Printf("Order expiry job expired %d orders", expired)
+			}
+		case <-j.stopChan:
+			log.Println("Order expiry job stopped")
+			return
+		}
+	}
+}
+
+// Stop stops the job
+func (j *ExpiryJob) Stop() {
+	close(j.stopChan)
+}
+
+// ExpireDue expires every working order whose time in force ran out before now.
+// Candidates come from the orders projection: DAY and IOC orders created by
+// the last cutoff, and GTD orders past their expiry. Each one is re-checked
+// against its event stream, and pulled from its venue if it was sent, before
+// it is expired.
+func (j *ExpiryJob) ExpireDue(now time.Time) (int, error) {
+	rows, err := j.db.Query(`
+		SELECT "orderId"
+		FROM orders
+		WHERE state IN ('DRAFT', 'APPROVAL_PENDING', 'APPROVED', 'SENT', 'PARTIALLY_FILLED')
+		  AND (
+			("timeInForce" IN ('DAY', 'IOC') AND "createdAt" <= $1)
+			OR ("timeInForce" = 'GTD' AND "expireAt" <= $2)
+		  )
+		ORDER BY "createdAt" ASC
+	`, j.cutoff.Previous(now).UTC(), now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to query working orders: %w", err)
+	}
+
+	var orderIDs []string
+	for rows.Next() {
+		var orderID string
+		if err := rows.Scan(&orderID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	correlationID := uuid.New().String()
+	expired := 0
+	for _, orderID := range orderIDs {
+		order, err := j.service.loadOrder(orderID)
+		if err != nil {
+			log.Printf("Order expiry: failed to load order %s: %v", orderID, err)
+			continue
+		}
+		expiresAt := order.ExpiresAt(j.cutoff)
+		if expiresAt == nil || now.Before(*expiresAt) || !order.CanTransitionTo(OrderStateExpired) {
+			continue
+		}
+
+		if j.venues != nil && (order.State == OrderStateSent || order.State == OrderStatePartiallyFilled) {
+			// The remainder must stop working before the order is expired
+			if err := j.venues.CancelWorking(orderID); err != nil {
+				log.Printf("Order expiry: failed to cancel order %s at its venue: %v", orderID, err)
+				continue
+			}
+		}
+
+		reason := fmt.Sprintf("%s order expired at %s", order.TimeInForce, expiresAt.UTC().Format(time.RFC3339))
+		if err := j.service.ExpireOrder(orderID, reason, correlationID); err != nil {
+			log.Printf("Order expiry: failed to expire order %s: %v", orderID, err)
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,241 @@
+package oms
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"instant/services/api/services/calendar"
+	"instant/services/api/services/pricing"
+)
+
+// DefaultLimitBandPct is how far, as a percentage of the evaluated clean
+// price, a limit may sit from the evaluated price before it is refused
+const DefaultLimitBandPct = 5.0
+
+var (
+	// ErrLimitOutsideBand is returned for a limit too far from the evaluated price to be intended
+	ErrLimitOutsideBand = errors.New("limit is outside the allowed band around the evaluated price")
+	// ErrLimitPricingUnavailable is returned when a yield limit cannot be converted to a price
+	ErrLimitPricingUnavailable = errors.New("no evaluated price to convert the yield limit with")
+)
+
+// limitQuote is an order's limit as both a clean price and a yield, with the
+// evaluated price it was checked against
+type limitQuote struct {
+	Price          float64
+	Yield          float64
+	EvaluatedPrice float64
+	AsOfDate       time.Time
+}
+
+// LimitPricer prices limit orders off the latest yield curve. YIELD_LIMIT
+// orders are converted to an equivalent clean price, LIMIT orders get the
+// yield their price implies, and both are refused if the price is more than
+// the band away from the instrument's evaluated price.
+type LimitPricer struct {
+	db       *sql.DB
+	pricing  *pricing.Service
+	bandPct  float64
+	calendar *calendar.Calendar
+}
+
+// NewLimitPricer creates a limit pricer with the given fat-finger band, in
+// percent. Limits are priced off the latest curve on or before the bond
+// market's latest business day.
+func NewLimitPricer(db *sql.DB, bandPct float64, marketCalendar *calendar.Calendar) *LimitPricer {
+	if bandPct <= 0 {
+		bandPct = DefaultLimitBandPct
+	}
+	return &LimitPricer{db: db, pricing: pricing.NewService(), bandPct: bandPct, calendar: marketCalendar}
+}
+
+// quote converts and checks the limit on the given terms
+func (p *LimitPricer) quote(req CreateOrderRequest) (*limitQuote, error) {
+	asOfDate, err := p.latestCurveDate()
+	if err != nil {
+		return nil, err
+	}
+	instrument, err := p.fetchInstrument(req.InstrumentID)
+	if err != nil {
+		return nil, err
+	}
+	curve, err := p.fetchCurve(asOfDate)
+	if err != nil {
+		return nil, err
+	}
+	return quoteLimit(p.pricing, instrument, curve, req, p.bandPct)
+}
+
+// evaluate prices the instrument off the latest curve
+func (p *LimitPricer) evaluate(cusip string) (*pricing.EvaluatedPrice, error) {
+	asOfDate, err := p.latestCurveDate()
+	if err != nil {
+		return nil, err
+	}
+	instrument, err := p.fetchInstrument(cusip)
+	if err != nil {
+		return nil, err
+	}
+	curve, err := p.fetchCurve(asOfDate)
+	if err != nil {
+		return nil, err
+	}
+	return p.pricing.Evaluate(instrument, curve, asOfDate)
+}
+
+// settlementTerms returns the trade date and settlement date of a trade in
+// the instrument at tradedAt, on the instrument type's settlement cycle, and
+// its accrued interest per 100 par on the settlement date
+func (p *LimitPricer) settlementTerms(cusip string, tradedAt time.Time) (time.Time, time.Time, float64, error) {
+	instrument, err := p.fetchInstrument(cusip)
+	if err != nil {
+		return time.Time{}, time.Time{}, 0, err
+	}
+	tradeDate := tradedAt.UTC().Truncate(24 * time.Hour)
+	settlementDate := tradedAt.UTC().Add(24 * time.Hour)
+	if p.calendar != nil {
+		tradeDate = p.calendar.TradeDate(tradedAt)
+		settlementDate = p.calendar.SettlementDate(tradedAt, instrument.Type)
+	}
+	return tradeDate, settlementDate, p.pricing.AccruedInterest(instrument, settlementDate), nil
+}
+
+// quoteLimit prices the instrument off the curve, converts the order's limit
+// into its other form and applies the fat-finger band
+func quoteLimit(service *pricing.Service, instrument pricing.InstrumentInput, curve pricing.CurveData, req CreateOrderRequest, bandPct float64) (*limitQuote, error) {
+	evaluated, err := service.Evaluate(instrument, curve, curve.AsOfDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLimitPricingUnavailable, err)
+	}
+
+	quote := &limitQuote{EvaluatedPrice: evaluated.CleanPrice, AsOfDate: curve.AsOfDate}
+	switch req.OrderType {
+	case OrderTypeYieldLimit:
+		atYield, err := service.PriceAtYield(instrument, *req.LimitYield, curve.AsOfDate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrLimitPricingUnavailable, err)
+		}
+		quote.Price = atYield.CleanPrice
+		quote.Yield = *req.LimitYield
+	case OrderTypeLimit:
+		yield, err := service.YieldAtPrice(instrument, *req.LimitPrice, curve.AsOfDate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrLimitPricingUnavailable, err)
+		}
+		quote.Price = *req.LimitPrice
+		quote.Yield = yield
+	default:
+		return nil, ErrInvalidOrderType
+	}
+
+	deviationPct := math.Abs(quote.Price-quote.EvaluatedPrice) / quote.EvaluatedPrice * 100
+	if deviationPct > bandPct {
+		return nil, fmt.Errorf("%w: limit price %.4f (yield %.4f%%) is %.2f%% from the evaluated price %.4f on %s, more than the %.2f%% band",
+			ErrLimitOutsideBand, quote.Price, quote.Yield, deviationPct, quote.EvaluatedPrice, curve.AsOfDate.Format("2006-01-02"), bandPct)
+	}
+	return quote, nil
+}
+
+// priceLimit fills in both forms of a limit order's limit. A LIMIT order
+// that cannot be priced keeps its price without a yield; a YIELD_LIMIT
+// order cannot be placed without one.
+func (s *Service) priceLimit(req CreateOrderRequest) (CreateOrderRequest, error) {
+	if req.OrderType != OrderTypeLimit && req.OrderType != OrderTypeYieldLimit {
+		return req, nil
+	}
+
+	var quote *limitQuote
+	err := ErrLimitPricingUnavailable
+	if s.limitPricer != nil {
+		quote, err = s.limitPricer.quote(req)
+	}
+	if errors.Is(err, ErrLimitOutsideBand) {
+		return req, err
+	}
+	if err != nil {
+		if req.OrderType == OrderTypeYieldLimit {
+			return req, err
+		}
+		log.Printf("Limit price on %s left unchecked: %v", req.InstrumentID, err)
+		req.LimitYield = nil
+		req.limitAsOf = nil
+		return req, nil
+	}
+
+	req.LimitPrice = &quote.Price
+	req.LimitYield = &quote.Yield
+	req.limitAsOf = &quote.AsOfDate
+	return req, nil
+}
+
+// limitTermsChanged reports whether an amendment or replace touches the limit,
+// so an unchanged limit is not re-priced against a moved market
+func limitTermsChanged(before, after CreateOrderRequest) bool {
+	return before.OrderType != after.OrderType ||
+		optionalFloat(before.LimitPrice) != optionalFloat(after.LimitPrice) ||
+		optionalFloat(before.LimitYield) != optionalFloat(after.LimitYield)
+}
+
+func (p *LimitPricer) latestCurveDate() (time.Time, error) {
+	asOfDate := time.Now().UTC()
+	if p.calendar != nil {
+		asOfDate = p.calendar.LatestBusinessDay(asOfDate)
+	}
+	var latest sql.NullTime
+	err := p.db.QueryRow(`SELECT MAX("asOfDate") FROM yield_curves WHERE "asOfDate" <= $1`, asOfDate).Scan(&latest)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to fetch curve date: %w", err)
+	}
+	if !latest.Valid {
+		return time.Time{}, fmt.Errorf("%w: no yield curve loaded", ErrLimitPricingUnavailable)
+	}
+	return latest.Time, nil
+}
+
+func (p *LimitPricer) fetchInstrument(cusip string) (pricing.InstrumentInput, error) {
+	instrument := pricing.InstrumentInput{Cusip: cusip}
+	err := p.db.QueryRow(`
+		SELECT type::text, coupon, "issueDate", "maturityDate", "couponFrequency"
+		FROM instruments
+		WHERE cusip = $1
+	`, cusip).Scan(&instrument.Type, &instrument.Coupon, &instrument.IssueDate, &instrument.MaturityDate, &instrument.CouponFrequency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return instrument, fmt.Errorf("%w: unknown instrument %s", ErrLimitPricingUnavailable, cusip)
+	}
+	if err != nil {
+		return instrument, fmt.Errorf("failed to fetch instrument: %w", err)
+	}
+	instrument.DayCount = pricing.DefaultDayCount(instrument.Type, instrument.Coupon, instrument.CouponFrequency)
+	return instrument, nil
+}
+
+func (p *LimitPricer) fetchCurve(asOfDate time.Time) (pricing.CurveData, error) {
+	rows, err := p.db.Query(`SELECT tenor::text, "parYield" FROM yield_curves WHERE "asOfDate" = $1`, asOfDate)
+	if err != nil {
+		return pricing.CurveData{}, fmt.Errorf("failed to fetch curve: %w", err)
+	}
+	defer rows.Close()
+
+	curve := pricing.CurveData{AsOfDate: asOfDate}
+	for rows.Next() {
+		var tenor string
+		var parYield float64
+		if err := rows.Scan(&tenor, &parYield); err != nil {
+			return pricing.CurveData{}, err
+		}
+		if years, ok := curveTenorYears[tenor]; ok {
+			curve.Points = append(curve.Points, pricing.CurvePoint{TenorYears: years, ParYield: parYield})
+		}
+	}
+	return curve, rows.Err()
+}
+
+// curveTenorYears maps the yield_curves tenor enum to years
+var curveTenorYears = map[string]float64{
+	"M1": 1.0 / 12.0, "M3": 0.25, "M6": 0.5,
+	"Y1": 1, "Y2": 2, "Y3": 3, "Y5": 5, "Y7": 7, "Y10": 10, "Y20": 20, "Y30": 30,
+}
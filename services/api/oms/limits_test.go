@@ -0,0 +1,93 @@
+package oms
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"instant/services/api/services/pricing"
+)
+
+func TestQuoteLimit(t *testing.T) {
+	asOfDate := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	curve := pricing.CurveData{
+		AsOfDate: asOfDate,
+		Points: []pricing.CurvePoint{
+			{TenorYears: 2, ParYield: 3.9},
+			{TenorYears: 10, ParYield: 4.3},
+		},
+	}
+	instrument := pricing.InstrumentInput{
+		Cusip:           "91282CJL6",
+		Type:            "note",
+		Coupon:          4.5,
+		IssueDate:       time.Date(2025, 11, 15, 0, 0, 0, 0, time.UTC),
+		MaturityDate:    time.Date(2032, 11, 15, 0, 0, 0, 0, time.UTC),
+		CouponFrequency: 2,
+		DayCount:        pricing.DefaultDayCount("note", 4.5, 2),
+	}
+	service := pricing.NewService()
+	evaluated, err := service.Evaluate(instrument, curve, asOfDate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("yield limit converts to a clean price", func(t *testing.T) {
+		yield := evaluated.YieldToMaturity + 0.1
+		quote, err := quoteLimit(service, instrument, curve, CreateOrderRequest{OrderType: OrderTypeYieldLimit, LimitYield: &yield}, DefaultLimitBandPct)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if quote.Price >= evaluated.CleanPrice || evaluated.CleanPrice-quote.Price > 1 {
+			t.Fatalf("expected a price just under %.4f for 10bp more yield, got %.4f", evaluated.CleanPrice, quote.Price)
+		}
+		if quote.Yield != yield || !quote.AsOfDate.Equal(asOfDate) {
+			t.Fatalf("unexpected quote: %+v", quote)
+		}
+	})
+
+	t.Run("price limit gets its implied yield", func(t *testing.T) {
+		price := evaluated.CleanPrice
+		quote, err := quoteLimit(service, instrument, curve, CreateOrderRequest{OrderType: OrderTypeLimit, LimitPrice: &price}, DefaultLimitBandPct)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if quote.Price != price || math.Abs(quote.Yield-evaluated.YieldToMaturity) > 1e-6 {
+			t.Fatalf("expected yield %.6f at the evaluated price, got %+v", evaluated.YieldToMaturity, quote)
+		}
+	})
+
+	t.Run("limits outside the band are refused", func(t *testing.T) {
+		price := evaluated.CleanPrice * 1.1
+		_, err := quoteLimit(service, instrument, curve, CreateOrderRequest{OrderType: OrderTypeLimit, LimitPrice: &price}, DefaultLimitBandPct)
+		if !errors.Is(err, ErrLimitOutsideBand) {
+			t.Fatalf("expected ErrLimitOutsideBand, got %v", err)
+		}
+
+		// A yield typed in basis points instead of percent is far off the price
+		yield := evaluated.YieldToMaturity * 100
+		_, err = quoteLimit(service, instrument, curve, CreateOrderRequest{OrderType: OrderTypeYieldLimit, LimitYield: &yield}, DefaultLimitBandPct)
+		if !errors.Is(err, ErrLimitOutsideBand) {
+			t.Fatalf("expected ErrLimitOutsideBand, got %v", err)
+		}
+	})
+}
+
+func TestPriceLimitWithoutPricer(t *testing.T) {
+	service := &Service{}
+	price, yield := 99.5, 4.25
+
+	limit, err := service.priceLimit(CreateOrderRequest{OrderType: OrderTypeLimit, LimitPrice: &price, LimitYield: &yield})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if limit.LimitPrice == nil || *limit.LimitPrice != price || limit.LimitYield != nil {
+		t.Fatalf("expected the price kept without a yield, got %+v", limit)
+	}
+
+	_, err = service.priceLimit(CreateOrderRequest{OrderType: OrderTypeYieldLimit, LimitYield: &yield})
+	if !errors.Is(err, ErrLimitPricingUnavailable) {
+		t.Fatalf("expected ErrLimitPricingUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,355 @@
+package oms
+
+import (
+	"errors"
+	"fmt"
+	"instant/services/api/events"
+	"instant/services/api/services/settlement"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNothingToNet is returned when a netting request names no orders
+var ErrNothingToNet = errors.New("at least one order is required to net")
+
+// crossPrice is the evaluated price an instrument's orders are crossed at
+type crossPrice struct {
+	Price float64
+	AsOf  time.Time
+}
+
+// NetAndSend crosses approved orders that buy and sell the same instrument
+// for different accounts against each other at the evaluated price, and
+// sends only what is left of each order to the EMS. Orders in instruments
+// with no evaluated price, and orders whose limit the price would breach,
+// are sent to the EMS whole.
+//
+// Everything the netting books is worked out before anything is written, and
+// then written in one batch: the orders are marked sent, the crosses filled
+// and settled, and the residuals handed to the EMS together or not at all, so
+// a failure never leaves an order marked sent with nothing booked.
+func (s *Service) NetAndSend(req NetAndSendRequest, correlationID string) (*NetAndSendResult, error) {
+	orders, err := s.loadNettableOrders(req.OrderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	crosses := planCrosses(orders, s.crossPrices(orders))
+	sentAt := time.Now().UTC()
+	terms, err := s.crossSettlementTerms(crosses, sentAt)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, expected, result := nettingBatch(uuid.New().String(), orders, crosses, terms, req.SentBy, correlationID, sentAt)
+	if err := s.eventStore.AppendAll(batch, expected); err != nil {
+		return nil, fmt.Errorf("failed to append netting events: %w", err)
+	}
+	for _, written := range batch {
+		s.eventBus.Publish(written)
+	}
+	return result, nil
+}
+
+// settlementTerms are the dates and accrued interest a cross settles on
+type settlementTerms struct {
+	tradeDate      time.Time
+	settlementDate time.Time
+	accrued        float64
+}
+
+// crossSettlementTerms works out the settlement terms of each instrument
+// that crosses
+func (s *Service) crossSettlementTerms(crosses []CrossTrade, tradedAt time.Time) (map[string]settlementTerms, error) {
+	terms := map[string]settlementTerms{}
+	for _, cross := range crosses {
+		if _, ok := terms[cross.InstrumentID]; ok {
+			continue
+		}
+		tradeDate, settlementDate, accrued, err := s.limitPricer.settlementTerms(cross.InstrumentID, tradedAt)
+		if err != nil {
+			return nil, err
+		}
+		terms[cross.InstrumentID] = settlementTerms{tradeDate: tradeDate, settlementDate: settlementDate, accrued: accrued}
+	}
+	return terms, nil
+}
+
+// nettingBatch builds the events a netting writes and the versions its orders
+// must still be at. Each order is marked sent before its cross fills, so the
+// order reads SENT then filled; OrdersNetted comes last and hands the
+// residuals to the EMS.
+func nettingBatch(nettingID string, orders []*OrderAggregate, crosses []CrossTrade, terms map[string]settlementTerms, actorID, correlationID string, sentAt time.Time) ([]*events.Event, map[events.Aggregate]int, *NetAndSendResult) {
+	batch := []*events.Event{}
+	expected := map[events.Aggregate]int{}
+	byID := make(map[string]*OrderAggregate, len(orders))
+	orderIDs := make([]string, 0, len(orders))
+	for _, order := range orders {
+		sent := events.NewEvent(
+			events.EventOrderSentToEMS,
+			events.AggregateOrder,
+			order.OrderID,
+			actorID,
+			"user",
+			correlationID,
+			map[string]interface{}{
+				"orderId":     order.OrderID,
+				"nettingId":   nettingID,
+				"sentBy":      actorID,
+				"sentToEmsAt": sentAt,
+			},
+		)
+		batch = append(batch, sent)
+		expected[sent.Aggregate] = order.Version
+		byID[order.OrderID] = order
+		orderIDs = append(orderIDs, order.OrderID)
+	}
+	expected[events.Aggregate{Type: events.AggregateNetting, ID: nettingID}] = 0
+
+	result := &NetAndSendResult{NettingID: nettingID, Crosses: []CrossTrade{}, Residuals: []NetResidual{}}
+	crossed := map[string]float64{}
+	for _, cross := range crosses {
+		cross.CrossID = uuid.New().String()
+		executed := events.NewEvent(
+			events.EventCrossTradeExecuted,
+			events.AggregateNetting,
+			nettingID,
+			actorID,
+			"user",
+			correlationID,
+			map[string]interface{}{
+				"crossId":       cross.CrossID,
+				"nettingId":     nettingID,
+				"instrumentId":  cross.InstrumentID,
+				"buyOrderId":    cross.BuyOrderID,
+				"buyAccountId":  cross.BuyAccountID,
+				"sellOrderId":   cross.SellOrderID,
+				"sellAccountId": cross.SellAccountID,
+				"quantity":      cross.Quantity,
+				"price":         cross.Price,
+				"priceAsOf":     cross.PriceAsOf,
+				"executedAt":    sentAt,
+			},
+		)
+		batch = append(batch, executed)
+
+		for _, orderID := range []string{cross.BuyOrderID, cross.SellOrderID} {
+			crossed[orderID] += cross.Quantity
+			batch = append(batch, crossLegEvents(byID[orderID], nettingID, cross, crossed[orderID], terms[cross.InstrumentID], actorID, correlationID, executed)...)
+		}
+		result.Crosses = append(result.Crosses, cross)
+	}
+
+	residuals := []map[string]interface{}{}
+	for _, order := range orders {
+		remaining := order.Quantity - order.FilledQuantity - crossed[order.OrderID]
+		if remaining <= 0 {
+			continue
+		}
+		result.Residuals = append(result.Residuals, NetResidual{OrderID: order.OrderID, Quantity: remaining})
+		residuals = append(residuals, map[string]interface{}{
+			"orderId":  order.OrderID,
+			"quantity": remaining,
+		})
+	}
+
+	netted := events.NewEvent(
+		events.EventOrdersNetted,
+		events.AggregateNetting,
+		nettingID,
+		actorID,
+		"user",
+		correlationID,
+		map[string]interface{}{
+			"nettingId":   nettingID,
+			"orderIds":    orderIDs,
+			"crossCount":  len(result.Crosses),
+			"residuals":   residuals,
+			"sentBy":      actorID,
+			"sentToEmsAt": sentAt,
+		},
+	)
+	batch = append(batch, netted)
+	return batch, expected, result
+}
+
+// loadNettableOrders loads the orders named in a netting request. Every order
+// must be approved and not part of a block; duplicates are ignored.
+func (s *Service) loadNettableOrders(orderIDs []string) ([]*OrderAggregate, error) {
+	if len(orderIDs) == 0 {
+		return nil, ErrNothingToNet
+	}
+
+	seen := map[string]bool{}
+	orders := make([]*OrderAggregate, 0, len(orderIDs))
+	for _, orderID := range orderIDs {
+		if seen[orderID] {
+			continue
+		}
+		seen[orderID] = true
+
+		order, err := s.loadOrder(orderID)
+		if err != nil {
+			return nil, err
+		}
+		if err := order.RequireTransition("send", OrderStateSent); err != nil {
+			return nil, err
+		}
+		if order.BlockID != "" {
+			return nil, ErrBlockChildOrder
+		}
+		orders = append(orders, order)
+	}
+
+	// Earlier orders are crossed first
+	sort.SliceStable(orders, func(i, j int) bool {
+		return orders[i].CreatedAt.Before(orders[j].CreatedAt)
+	})
+	return orders, nil
+}
+
+// crossPrices evaluates each instrument that has orders on both sides
+func (s *Service) crossPrices(orders []*OrderAggregate) map[string]crossPrice {
+	sides := map[string]map[OrderSide]bool{}
+	for _, order := range orders {
+		if sides[order.InstrumentID] == nil {
+			sides[order.InstrumentID] = map[OrderSide]bool{}
+		}
+		sides[order.InstrumentID][order.Side] = true
+	}
+
+	prices := map[string]crossPrice{}
+	for instrumentID, sidesSeen := range sides {
+		if !sidesSeen[OrderSideBuy] || !sidesSeen[OrderSideSell] {
+			continue
+		}
+		if s.limitPricer == nil {
+			log.Printf("Orders in %s sent without crossing: no evaluated price", instrumentID)
+			continue
+		}
+		evaluated, err := s.limitPricer.evaluate(instrumentID)
+		if err != nil {
+			log.Printf("Orders in %s sent without crossing: %v", instrumentID, err)
+			continue
+		}
+		prices[instrumentID] = crossPrice{Price: evaluated.CleanPrice, AsOf: evaluated.AsOfDate}
+	}
+	return prices
+}
+
+// planCrosses pairs buys with sells in the same instrument, oldest first,
+// until one side runs out. An order never crosses with another order for the
+// same account, and only orders the cross price satisfies take part.
+func planCrosses(orders []*OrderAggregate, prices map[string]crossPrice) []CrossTrade {
+	remaining := make(map[string]float64, len(orders))
+	for _, order := range orders {
+		remaining[order.OrderID] = order.Quantity - order.FilledQuantity
+	}
+
+	crosses := []CrossTrade{}
+	for _, buy := range orders {
+		price, ok := prices[buy.InstrumentID]
+		if !ok || buy.Side != OrderSideBuy || !crossable(buy, price.Price) {
+			continue
+		}
+		for _, sell := range orders {
+			if remaining[buy.OrderID] <= 0 {
+				break
+			}
+			if sell.Side != OrderSideSell || sell.InstrumentID != buy.InstrumentID ||
+				sell.AccountID == buy.AccountID || remaining[sell.OrderID] <= 0 || !crossable(sell, price.Price) {
+				continue
+			}
+
+			quantity := remaining[buy.OrderID]
+			if remaining[sell.OrderID] < quantity {
+				quantity = remaining[sell.OrderID]
+			}
+			remaining[buy.OrderID] -= quantity
+			remaining[sell.OrderID] -= quantity
+
+			crosses = append(crosses, CrossTrade{
+				InstrumentID:  buy.InstrumentID,
+				BuyOrderID:    buy.OrderID,
+				BuyAccountID:  buy.AccountID,
+				SellOrderID:   sell.OrderID,
+				SellAccountID: sell.AccountID,
+				Quantity:      quantity,
+				Price:         price.Price,
+				PriceAsOf:     price.AsOf,
+			})
+		}
+	}
+	return crosses
+}
+
+// crossable reports whether an order may trade at the cross price. Curve
+// relative orders are priced by the EMS and always go to the market.
+func crossable(order *OrderAggregate, price float64) bool {
+	switch order.OrderType {
+	case OrderTypeMarket:
+		return true
+	case OrderTypeLimit, OrderTypeYieldLimit:
+		if order.LimitPrice == nil {
+			return false
+		}
+		if order.Side == OrderSideBuy {
+			return price <= *order.LimitPrice
+		}
+		return price >= *order.LimitPrice
+	default:
+		return false
+	}
+}
+
+// crossLegEvents fill one side of a cross trade and book its settlement. The
+// cross ID stands in for the execution ID, so the order's fills from the cross
+// and from the EMS add up.
+func crossLegEvents(order *OrderAggregate, nettingID string, cross CrossTrade, crossedSoFar float64, terms settlementTerms, actorID, correlationID string, causation *events.Event) []*events.Event {
+	fillType := events.EventOrderPartiallyFilled
+	fillPayload := map[string]interface{}{
+		"orderId":        order.OrderID,
+		"executionId":    cross.CrossID,
+		"crossId":        cross.CrossID,
+		"filledQuantity": cross.Quantity,
+	}
+	if order.FilledQuantity+crossedSoFar >= order.Quantity {
+		fillType = events.EventOrderFullyFilled
+		fillPayload["avgFillPrice"] = cross.Price
+	}
+
+	fill := events.NewEvent(fillType, events.AggregateOrder, order.OrderID, actorID, "user", correlationID, fillPayload)
+	fill.WithCausation(causation.EventID)
+
+	// Crosses are internal, so they settle without charges
+	payload := map[string]interface{}{
+		"settlementId":   uuid.New().String(),
+		"executionId":    cross.CrossID,
+		"crossId":        cross.CrossID,
+		"orderId":        order.OrderID,
+		"accountId":      order.AccountID,
+		"instrumentId":   order.InstrumentID,
+		"side":           string(order.Side),
+		"filledQuantity": cross.Quantity,
+		"avgFillPrice":   cross.Price,
+		"tradeDate":      terms.tradeDate,
+		"settlementDate": terms.settlementDate,
+		"status":         settlement.StatusPending,
+	}
+	settlement.Compute(string(order.Side), cross.Quantity, cross.Price, terms.accrued, 0).Payload(payload)
+
+	booked := events.NewEvent(
+		events.EventSettlementBooked,
+		events.AggregateNetting,
+		nettingID,
+		actorID,
+		"user",
+		correlationID,
+		payload,
+	)
+	booked.WithCausation(causation.EventID)
+	return []*events.Event{fill, booked}
+}
@@ -0,0 +1,133 @@
+package oms
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"instant/services/api/events"
+)
+
+func TestPlanCrosses(t *testing.T) {
+	asOfDate := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	prices := map[string]crossPrice{"91282CJL6": {Price: 99.5, AsOf: asOfDate}}
+	limit := func(price float64) *float64 { return &price }
+	order := func(id, account string, side OrderSide, quantity float64, orderType OrderType, limitPrice *float64) *OrderAggregate {
+		return &OrderAggregate{
+			OrderID:      id,
+			AccountID:    account,
+			InstrumentID: "91282CJL6",
+			Side:         side,
+			Quantity:     quantity,
+			OrderType:    orderType,
+			LimitPrice:   limitPrice,
+		}
+	}
+
+	t.Run("buys cross against sells oldest first", func(t *testing.T) {
+		crosses := planCrosses([]*OrderAggregate{
+			order("buy-1", "acct-a", OrderSideBuy, 300000, OrderTypeMarket, nil),
+			order("sell-1", "acct-b", OrderSideSell, 200000, OrderTypeMarket, nil),
+			order("sell-2", "acct-c", OrderSideSell, 250000, OrderTypeLimit, limit(99.25)),
+		}, prices)
+
+		if len(crosses) != 2 {
+			t.Fatalf("expected 2 crosses, got %+v", crosses)
+		}
+		if crosses[0].SellOrderID != "sell-1" || crosses[0].Quantity != 200000 {
+			t.Fatalf("expected sell-1 crossed in full first, got %+v", crosses[0])
+		}
+		if crosses[1].SellOrderID != "sell-2" || crosses[1].Quantity != 100000 {
+			t.Fatalf("expected the buy's remaining 100000 crossed with sell-2, got %+v", crosses[1])
+		}
+		for _, cross := range crosses {
+			if cross.Price != 99.5 || !cross.PriceAsOf.Equal(asOfDate) || cross.BuyAccountID != "acct-a" {
+				t.Fatalf("unexpected cross terms: %+v", cross)
+			}
+		}
+	})
+
+	t.Run("an account never crosses with itself", func(t *testing.T) {
+		crosses := planCrosses([]*OrderAggregate{
+			order("buy-1", "acct-a", OrderSideBuy, 100000, OrderTypeMarket, nil),
+			order("sell-1", "acct-a", OrderSideSell, 100000, OrderTypeMarket, nil),
+		}, prices)
+		if len(crosses) != 0 {
+			t.Fatalf("expected no crosses, got %+v", crosses)
+		}
+	})
+
+	t.Run("limits the cross price breaches and curve relative orders are left out", func(t *testing.T) {
+		crosses := planCrosses([]*OrderAggregate{
+			order("buy-1", "acct-a", OrderSideBuy, 100000, OrderTypeLimit, limit(99.25)),
+			order("buy-2", "acct-b", OrderSideBuy, 100000, OrderTypeCurveRelative, nil),
+			order("sell-1", "acct-c", OrderSideSell, 100000, OrderTypeYieldLimit, limit(99.75)),
+			order("sell-2", "acct-d", OrderSideSell, 100000, OrderTypeMarket, nil),
+		}, prices)
+		if len(crosses) != 0 {
+			t.Fatalf("expected no crosses, got %+v", crosses)
+		}
+	})
+
+	t.Run("instruments without a cross price are not crossed", func(t *testing.T) {
+		crosses := planCrosses([]*OrderAggregate{
+			order("buy-1", "acct-a", OrderSideBuy, 100000, OrderTypeMarket, nil),
+			order("sell-1", "acct-b", OrderSideSell, 100000, OrderTypeMarket, nil),
+		}, map[string]crossPrice{})
+		if len(crosses) != 0 {
+			t.Fatalf("expected no crosses, got %+v", crosses)
+		}
+	})
+}
+
+func TestNettingBatch(t *testing.T) {
+	asOfDate := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	buy := &OrderAggregate{OrderID: "buy-1", AccountID: "acct-a", InstrumentID: "91282CJL6", Side: OrderSideBuy, Quantity: 300000, OrderType: OrderTypeMarket, Version: 4}
+	sell := &OrderAggregate{OrderID: "sell-1", AccountID: "acct-b", InstrumentID: "91282CJL6", Side: OrderSideSell, Quantity: 200000, OrderType: OrderTypeMarket, Version: 3}
+	orders := []*OrderAggregate{buy, sell}
+	crosses := planCrosses(orders, map[string]crossPrice{"91282CJL6": {Price: 99.5, AsOf: asOfDate}})
+	terms := map[string]settlementTerms{"91282CJL6": {tradeDate: asOfDate, settlementDate: asOfDate.AddDate(0, 0, 1)}}
+
+	batch, expected, result := nettingBatch("net-1", orders, crosses, terms, "trader", "corr", asOfDate)
+
+	// Every order and the netting itself must be unchanged for the batch to be written
+	want := map[events.Aggregate]int{
+		{Type: events.AggregateOrder, ID: "buy-1"}:   4,
+		{Type: events.AggregateOrder, ID: "sell-1"}:  3,
+		{Type: events.AggregateNetting, ID: "net-1"}: 0,
+	}
+	if len(expected) != len(want) {
+		t.Fatalf("expected versions %v, got %v", want, expected)
+	}
+	for aggregate, version := range want {
+		if expected[aggregate] != version {
+			t.Fatalf("expected %v at version %d, got %v", aggregate, version, expected)
+		}
+	}
+
+	// Each order reads SENT before its cross fill, and the residuals go to the EMS last
+	types := []string{}
+	for _, event := range batch {
+		types = append(types, event.EventType+":"+event.Aggregate.ID)
+	}
+	wantTypes := []string{
+		events.EventOrderSentToEMS + ":buy-1",
+		events.EventOrderSentToEMS + ":sell-1",
+		events.EventCrossTradeExecuted + ":net-1",
+		events.EventOrderPartiallyFilled + ":buy-1",
+		events.EventSettlementBooked + ":net-1",
+		events.EventOrderFullyFilled + ":sell-1",
+		events.EventSettlementBooked + ":net-1",
+		events.EventOrdersNetted + ":net-1",
+	}
+	if strings.Join(types, ",") != strings.Join(wantTypes, ",") {
+		t.Fatalf("expected events %v, got %v", wantTypes, types)
+	}
+
+	if len(result.Residuals) != 1 || result.Residuals[0].OrderID != "buy-1" || result.Residuals[0].Quantity != 100000 {
+		t.Fatalf("unexpected residuals: %+v", result.Residuals)
+	}
+	if batch[4].Payload["settlementDate"] != asOfDate.AddDate(0, 0, 1) {
+		t.Fatalf("expected settlement terms on the booking, got %v", batch[4].Payload)
+	}
+}
@@ -0,0 +1,219 @@
+package oms
+
+import (
+	"errors"
+	"fmt"
+	"instant/services/api/events"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrReplaceBlockChild is returned when a child order of a block is replaced on its own
+	ErrReplaceBlockChild = errors.New("child orders of a block cannot be replaced")
+	// ErrNothingToReplace is returned when a replace leaves no quantity to work
+	ErrNothingToReplace = errors.New("order has no unfilled quantity to replace")
+)
+
+// chainLink ties a new order version to the order it replaces
+type chainLink struct {
+	OrderID string
+	ChainID string
+	Version int
+}
+
+// ReplacementTerms returns the terms of the order version that replaces this
+// one. Fills stay with this version, so the new version defaults to working
+// what is left unfilled.
+func (o *OrderAggregate) ReplacementTerms(req ReplaceOrderRequest) CreateOrderRequest {
+	terms := o.Terms()
+	terms.Quantity = o.Quantity - o.FilledQuantity
+	terms.CreatedBy = req.ReplacedBy
+
+	if req.Quantity != nil {
+		terms.Quantity = *req.Quantity
+	}
+	if req.OrderType != nil {
+		terms.OrderType = *req.OrderType
+	}
+	if req.LimitPrice != nil {
+		terms.LimitPrice = req.LimitPrice
+	}
+	if req.LimitYield != nil {
+		terms.LimitYield = req.LimitYield
+	}
+	if req.CurveSpreadBp != nil {
+		terms.CurveSpreadBp = req.CurveSpreadBp
+	}
+	if req.TimeInForce != nil {
+		terms.TimeInForce = *req.TimeInForce
+		if terms.TimeInForce != TimeInForceGTD {
+			terms.ExpireAt = nil
+		}
+	}
+	if req.ExpireAt != nil {
+		terms.ExpireAt = req.ExpireAt
+	}
+	return terms
+}
+
+// termChanges lists the order terms that differ between two versions
+func termChanges(before, after CreateOrderRequest) []FieldChange {
+	changes := []FieldChange{}
+	add := func(field string, from, to interface{}) {
+		if from != to {
+			changes = append(changes, FieldChange{Field: field, From: from, To: to})
+		}
+	}
+
+	add("quantity", before.Quantity, after.Quantity)
+	add("orderType", string(before.OrderType), string(after.OrderType))
+	add("limitPrice", optionalFloat(before.LimitPrice), optionalFloat(after.LimitPrice))
+	add("limitYield", optionalFloat(before.LimitYield), optionalFloat(after.LimitYield))
+	add("curveSpreadBp", optionalFloat(before.CurveSpreadBp), optionalFloat(after.CurveSpreadBp))
+	add("timeInForce", string(before.TimeInForce), string(after.TimeInForce))
+	add("expireAt", optionalTime(before.ExpireAt), optionalTime(after.ExpireAt))
+	return changes
+}
+
+func optionalFloat(value *float64) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+func optionalTime(value *time.Time) interface{} {
+	if value == nil {
+		return nil
+	}
+	return value.UTC().Format(time.RFC3339)
+}
+
+// ReplaceOrder cancels an order and replaces it with a new version carrying
+// the changed terms. The new version is linked to the one it replaces and
+// goes through pre-trade compliance and approval like any new order; a BLOCK
+// refuses the replace and leaves the original working. Compliance runs once,
+// and OrderReplaced and the new version's OrderCreated are only written, in
+// one batch, after every check has passed.
+func (s *Service) ReplaceOrder(req ReplaceOrderRequest, correlationID string) (*ReplaceResult, error) {
+	order, err := s.loadOrder(req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := order.RequireTransition("replace", OrderStateReplaced); err != nil {
+		return nil, err
+	}
+	if order.BlockID != "" {
+		return nil, ErrReplaceBlockChild
+	}
+	if req.Quantity == nil && order.Quantity-order.FilledQuantity <= 0 {
+		return nil, ErrNothingToReplace
+	}
+
+	if err := s.checkTradingControls(req.ReplacedBy, []string{order.AccountID}, 1, correlationID); err != nil {
+		return nil, err
+	}
+
+	terms := order.ReplacementTerms(req)
+	if terms.TimeInForce == "" {
+		terms.TimeInForce = TimeInForceDay
+	}
+	if err := s.validateCreateOrderRequest(terms); err != nil {
+		return nil, err
+	}
+	if limitTermsChanged(order.Terms(), terms) {
+		if terms, err = s.priceLimit(terms); err != nil {
+			return nil, err
+		}
+	}
+
+	exposures, err := s.checkRiskLimits(terms.CreatedBy, []CreateOrderRequest{terms}, true, correlationID)
+	if err != nil {
+		return nil, err
+	}
+	terms.riskExposure = exposures[0]
+
+	terms.replaces = &chainLink{
+		OrderID: order.OrderID,
+		ChainID: order.ChainID,
+		Version: order.ChainVersion + 1,
+	}
+
+	newOrderID := uuid.New().String()
+	complianceResult, recordCompliance, err := s.runAmendmentComplianceCheck(newOrderID, terms, correlationID, req.ReplacedBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run compliance check on replacement: %w", err)
+	}
+	if complianceResult.Status == ComplianceStatusBlock {
+		return nil, &ComplianceBlockError{OrderID: req.OrderID, Blocks: complianceResult.Blocks}
+	}
+
+	changes := termChanges(order.Terms(), terms)
+	payload := map[string]interface{}{
+		"orderId":           req.OrderID,
+		"replacedByOrderId": newOrderID,
+		"chainId":           order.ChainID,
+		"replacedBy":        req.ReplacedBy,
+		"replacedAt":        time.Now().UTC(),
+		"filledQuantity":    order.FilledQuantity,
+		"changes":           changes,
+	}
+	if req.Reason != "" {
+		payload["reason"] = req.Reason
+	}
+
+	event := events.NewEvent(
+		events.EventOrderReplaced,
+		events.AggregateOrder,
+		req.OrderID,
+		req.ReplacedBy,
+		"user",
+		correlationID,
+		payload,
+	)
+
+	created := newOrderCreatedEvent(newOrderID, terms, correlationID).WithCausation(event.EventID)
+
+	admission, err := s.admitOrders(req.ReplacedBy, []string{order.AccountID}, 1, correlationID)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := []*events.Event{event, created}
+	expected := map[events.Aggregate]int{
+		event.Aggregate:   order.Version,
+		created.Aggregate: 0,
+	}
+	if err := s.eventStore.AppendAll(batch, expected); err != nil {
+		s.withdrawOrders(admission, 1)
+		return nil, fmt.Errorf("failed to append replacement events: %w", err)
+	}
+	for _, written := range batch {
+		s.eventBus.Publish(written)
+	}
+
+	recordCompliance()
+	if err := s.storeComplianceResult(newOrderID, complianceResult, correlationID, terms.CreatedBy); err != nil {
+		fmt.Printf("Failed to store compliance result: %v\n", err)
+	}
+	requirement := s.requestApproval(newOrderID, terms, complianceResult, correlationID)
+
+	result := &ReplaceResult{
+		OrderID:          newOrderID,
+		ReplacedOrderID:  order.OrderID,
+		ChainID:          order.ChainID,
+		Version:          order.ChainVersion + 1,
+		Changes:          changes,
+		ComplianceStatus: complianceResult.Status,
+		Warnings:         complianceResult.Warnings,
+	}
+
+	if requirement != nil {
+		result.ApprovalRequested = true
+		result.RequiredApprovals = requirement.RequiredApprovals
+	}
+
+	return result, nil
+}
@@ -8,7 +8,11 @@ import (
 	"instant/services/api/eventbus"
 	"instant/services/api/events"
 	"instant/services/api/eventstore"
+	"instant/services/api/services/approval"
 	"instant/services/api/services/compliance"
+	"instant/services/api/services/risk"
+	"instant/services/api/services/tradingcontrol"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,33 +26,205 @@ var (
 	ErrComplianceBlocked   = errors.New("order blocked by compliance")
 	ErrMissingLimitPrice   = errors.New("limit price required for LIMIT orders")
 	ErrMissingCurveSpread  = errors.New("curve spread required for CURVE_RELATIVE orders")
+	ErrMissingLimitYield   = errors.New("limit yield required for YIELD_LIMIT orders")
+	ErrMissingRejectReason = errors.New("rejection reason is required")
+	ErrMissingRejectedBy   = errors.New("rejectedBy is required")
+	ErrInvalidTimeInForce  = errors.New("time in force must be DAY, IOC, GTC or GTD")
+	ErrInvalidExpireAt     = errors.New("expireAt must be a future time on GTD orders and omitted otherwise")
 )
 
+// ComplianceBlockError is returned when an amendment fails a BLOCK rule.
+// It wraps ErrComplianceBlocked and carries the violations that blocked it.
+type ComplianceBlockError struct {
+	OrderID string
+	Blocks  []ComplianceViolation
+}
+
+func (e *ComplianceBlockError) Error() string {
+	return fmt.Sprintf("%s: amendment to order %s fails %d rule(s)", ErrComplianceBlocked, e.OrderID, len(e.Blocks))
+}
+
+func (e *ComplianceBlockError) Unwrap() error {
+	return ErrComplianceBlocked
+}
+
 // Service handles order management operations
 type Service struct {
 	eventStore *eventstore.EventStore
 	eventBus   *eventbus.EventBus
 	complianceService *compliance.Service
+	approvalService   *approval.Service
+	limitPricer       *LimitPricer
+	tradingControls   *tradingcontrol.Service
+	riskLimits        *risk.Service
 }
 
 // NewService creates a new OMS service
-func NewService(es *eventstore.EventStore, eb *eventbus.EventBus, complianceService *compliance.Service) *Service {
+func NewService(es *eventstore.EventStore, eb *eventbus.EventBus, complianceService *compliance.Service, approvalService *approval.Service, limitPricer *LimitPricer, tradingControls *tradingcontrol.Service, riskLimits *risk.Service) *Service {
 	return &Service{
 		eventStore: es,
 		eventBus:   eb,
 		complianceService: complianceService,
+		approvalService:   approvalService,
+		limitPricer:       limitPricer,
+		tradingControls:   tradingControls,
+		riskLimits:        riskLimits,
 	}
 }
 
 // CreateOrder creates a new order and emits OrderCreated event
 func (s *Service) CreateOrder(req CreateOrderRequest, correlationID string) (string, error) {
 	// Validate inputs
+	if req.TimeInForce == "" {
+		req.TimeInForce = TimeInForceDay
+	}
 	if err := s.validateCreateOrderRequest(req); err != nil {
 		return "", err
 	}
+	if err := s.checkTradingControls(req.CreatedBy, []string{req.AccountID}, 1, correlationID); err != nil {
+		return "", err
+	}
+	req, err := s.priceLimit(req)
+	if err != nil {
+		return "", err
+	}
+	exposures, err := s.checkRiskLimits(req.CreatedBy, []CreateOrderRequest{req}, false, correlationID)
+	if err != nil {
+		return "", err
+	}
+	req.riskExposure = exposures[0]
+
+	admission, err := s.admitOrders(req.CreatedBy, []string{req.AccountID}, 1, correlationID)
+	if err != nil {
+		return "", err
+	}
+	orderID, err := s.createOrder(uuid.New().String(), req, correlationID)
+	if err != nil {
+		// A blocked or unrecorded order does not count against the rate limit
+		s.withdrawOrders(admission, 1)
+	}
+	return orderID, err
+}
+
+// checkTradingControls refuses new orders covered by a kill switch
+func (s *Service) checkTradingControls(actorID string, accountIDs []string, orders int, correlationID string) error {
+	if s.tradingControls == nil {
+		return nil
+	}
+	return s.tradingControls.CheckSubmission(tradingcontrol.Submission{
+		ActorID:    actorID,
+		AccountIDs: accountIDs,
+		Orders:     orders,
+	}, correlationID)
+}
 
-	orderID := uuid.New().String()
+// admitOrders counts new orders against the submitting actor's rate limit,
+// refusing them if it would go over. It runs once every other check has
+// passed, so refused orders do not use up the limit.
+func (s *Service) admitOrders(actorID string, accountIDs []string, orders int, correlationID string) (*tradingcontrol.Admission, error) {
+	if s.tradingControls == nil {
+		return nil, nil
+	}
+	return s.tradingControls.Admit(tradingcontrol.Submission{
+		ActorID:    actorID,
+		AccountIDs: accountIDs,
+		Orders:     orders,
+	}, correlationID)
+}
 
+// withdrawOrders stops admitted orders that were refused after all from
+// counting against the rate limit
+func (s *Service) withdrawOrders(admission *tradingcontrol.Admission, orders int) {
+	if s.tradingControls == nil {
+		return
+	}
+	if err := s.tradingControls.Withdraw(admission, orders); err != nil {
+		fmt.Printf("Failed to withdraw rate limit admission: %v\n", err)
+	}
+}
+
+// checkRiskLimits measures new orders against the submitting trader's desk
+// risk limits and returns the exposure each order adds, in order
+func (s *Service) checkRiskLimits(actorID string, orders []CreateOrderRequest, replacing bool, correlationID string) ([]*risk.Exposure, error) {
+	exposures := make([]*risk.Exposure, len(orders))
+	if s.riskLimits == nil {
+		return exposures, nil
+	}
+
+	submission := risk.Submission{ActorID: actorID, Replacing: replacing}
+	for _, order := range orders {
+		submission.Orders = append(submission.Orders, risk.OrderContext{
+			AccountID:    order.AccountID,
+			InstrumentID: order.InstrumentID,
+			OrderType:    string(order.OrderType),
+			Quantity:     order.Quantity,
+			LimitPrice:   order.LimitPrice,
+		})
+	}
+
+	assessment, err := s.riskLimits.Check(submission, correlationID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range assessment.Exposures {
+		exposures[i] = &assessment.Exposures[i]
+	}
+	return exposures, nil
+}
+
+// createOrder records a validated order under the given ID, then runs
+// pre-trade compliance and approval policies against it
+func (s *Service) createOrder(orderID string, req CreateOrderRequest, correlationID string) (string, error) {
+	event := newOrderCreatedEvent(orderID, req, correlationID)
+
+	// Append to event store
+	if err := s.eventStore.Append(event); err != nil {
+		return "", fmt.Errorf("failed to append OrderCreated event: %w", err)
+	}
+
+	// Publish to event bus
+	s.eventBus.Publish(event)
+
+	// Run compliance check (pre-trade)
+	complianceResult, err := s.runComplianceCheck(orderID, req, correlationID, req.CreatedBy)
+	if err != nil {
+		// Log error but don't fail order creation
+		fmt.Printf("Compliance check failed: %v\n", err)
+	} else if complianceResult != nil {
+		// Store compliance result
+		if err := s.storeComplianceResult(orderID, complianceResult, correlationID, req.CreatedBy); err != nil {
+			fmt.Printf("Failed to store compliance result: %v\n", err)
+		}
+
+		// If blocked, emit OrderBlockedByCompliance event
+		if complianceResult.Status == ComplianceStatusBlock {
+			s.emitComplianceBlockedEvent(orderID, complianceResult, correlationID, req.CreatedBy)
+			return orderID, ErrComplianceBlocked
+		}
+
+	}
+
+	s.requestApproval(orderID, req, complianceResult, correlationID)
+	return orderID, nil
+}
+
+// requestApproval sends a new order for approval when its approval policies
+// require it; a compliance warning always needs at least one approver. It
+// returns the requirement the order was sent with, nil if none.
+func (s *Service) requestApproval(orderID string, req CreateOrderRequest, complianceResult *ComplianceResult, correlationID string) *approval.Requirement {
+	requirement := s.approvalRequirement(req)
+	if complianceResult != nil && complianceResult.Status == ComplianceStatusWarn && requirement.RequiredApprovals < 1 {
+		requirement.RequiredApprovals = 1
+	}
+	if requirement.RequiredApprovals == 0 {
+		return nil
+	}
+	s.emitApprovalRequestedEvent(orderID, requirement, correlationID, req.CreatedBy)
+	return requirement
+}
+
+// newOrderCreatedEvent builds the OrderCreated event for an order's terms
+func newOrderCreatedEvent(orderID string, req CreateOrderRequest, correlationID string) *events.Event {
 	// Build payload
 	payload := map[string]interface{}{
 		"orderId":       orderID,
@@ -65,15 +241,41 @@ func (s *Service) CreateOrder(req CreateOrderRequest, correlationID string) (str
 	if req.LimitPrice != nil {
 		payload["limitPrice"] = *req.LimitPrice
 	}
+	if req.LimitYield != nil {
+		payload["limitYield"] = *req.LimitYield
+	}
+	if req.limitAsOf != nil {
+		payload["limitPricedAsOf"] = req.limitAsOf.UTC()
+	}
 	if req.CurveSpreadBp != nil {
 		payload["curveSpreadBp"] = *req.CurveSpreadBp
 	}
+	if req.ExpireAt != nil {
+		payload["expireAt"] = req.ExpireAt.UTC()
+	}
 	if req.BatchID != nil {
 		payload["batchId"] = *req.BatchID
 	}
+	if req.BlockID != nil {
+		payload["blockId"] = *req.BlockID
+	}
+	if req.riskExposure != nil {
+		payload["riskExposure"] = map[string]interface{}{
+			"tradeDate": req.riskExposure.TradeDate,
+			"notional":  req.riskExposure.Notional,
+			"dv01":      req.riskExposure.Dv01,
+		}
+	}
+	payload["chainId"] = orderID
+	payload["chainVersion"] = 1
+	if req.replaces != nil {
+		payload["chainId"] = req.replaces.ChainID
+		payload["chainVersion"] = req.replaces.Version
+		payload["replacesOrderId"] = req.replaces.OrderID
+	}
 
 	// Create event
-	event := events.NewEvent(
+	return events.NewEvent(
 		events.EventOrderCreated,
 		events.AggregateOrder,
 		orderID,
@@ -82,50 +284,49 @@ func (s *Service) CreateOrder(req CreateOrderRequest, correlationID string) (str
 		correlationID,
 		payload,
 	)
+}
 
-	// Append to event store
-	if err := s.eventStore.Append(event); err != nil {
-		return "", fmt.Errorf("failed to append OrderCreated event: %w", err)
-	}
-
-	// Publish to event bus
-	s.eventBus.Publish(event)
-
-	// Run compliance check (pre-trade)
-	complianceResult, err := s.runComplianceCheck(orderID, req, correlationID, req.CreatedBy)
+// AmendOrder amends an existing order. The amended order is checked against
+// pre-trade compliance before the amendment is recorded: a BLOCK refuses it,
+// and a WARN (or an approval policy the new terms now match) sends an order
+// that has not been sent yet back for approval. OrderAmended, the compliance
+// result and any approval request are written in one batch at the order's
+// version, so the new terms are never seen still approved.
+func (s *Service) AmendOrder(req AmendOrderRequest, correlationID string) (*AmendResult, error) {
+	order, err := s.loadOrder(req.OrderID)
 	if err != nil {
-		// Log error but don't fail order creation
-		fmt.Printf("Compliance check failed: %v\n", err)
-	} else if complianceResult != nil {
-		// Store compliance result
-		if err := s.storeComplianceResult(orderID, complianceResult, correlationID, req.CreatedBy); err != nil {
-			fmt.Printf("Failed to store compliance result: %v\n", err)
-		}
-
-		// If blocked, emit OrderBlockedByCompliance event
-		if complianceResult.Status == ComplianceStatusBlock {
-			s.emitComplianceBlockedEvent(orderID, complianceResult, correlationID, req.CreatedBy)
-			return orderID, ErrComplianceBlocked
-		}
+		return nil, err
+	}
+	if err := order.ValidateAmend(req); err != nil {
+		return nil, err
+	}
 
-		// If needs approval, emit OrderApprovalRequested event
-		if complianceResult.Status == ComplianceStatusWarn || s.needsApproval(req) {
-			s.emitApprovalRequestedEvent(orderID, correlationID, req.CreatedBy)
+	terms := order.AmendedTerms(req)
+	if err := validateOrderTypeTerms(terms); err != nil {
+		return nil, err
+	}
+	limitChanged := limitTermsChanged(order.Terms(), terms)
+	if limitChanged {
+		if terms, err = s.priceLimit(terms); err != nil {
+			return nil, err
 		}
 	}
 
-	return orderID, nil
-}
-
-// AmendOrder amends an existing order
-func (s *Service) AmendOrder(req AmendOrderRequest, correlationID string) error {
-	// Validate amendment is allowed based on current state
-	// This would query the projection to get current order state
-	// For now, we'll emit the event
+	complianceResult, recordCompliance, err := s.runAmendmentComplianceCheck(req.OrderID, terms, correlationID, req.UpdatedBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run compliance check on amendment: %w", err)
+	}
+	result, requirement, err := amendmentOutcome(order, complianceResult, func() *approval.Requirement {
+		return s.approvalRequirement(terms)
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	payload := map[string]interface{}{
 		"orderId":   req.OrderID,
 		"updatedBy": req.UpdatedBy,
+		"changes":   termChanges(order.Terms(), terms),
 	}
 
 	if req.Quantity != nil {
@@ -134,8 +335,17 @@ func (s *Service) AmendOrder(req AmendOrderRequest, correlationID string) error
 	if req.OrderType != nil {
 		payload["orderType"] = *req.OrderType
 	}
-	if req.LimitPrice != nil {
-		payload["limitPrice"] = *req.LimitPrice
+	if limitChanged {
+		// The limit is recorded in both forms, whichever one was amended
+		if terms.LimitPrice != nil {
+			payload["limitPrice"] = *terms.LimitPrice
+		}
+		if terms.LimitYield != nil {
+			payload["limitYield"] = *terms.LimitYield
+		}
+		if terms.limitAsOf != nil {
+			payload["limitPricedAsOf"] = terms.limitAsOf.UTC()
+		}
 	}
 	if req.CurveSpreadBp != nil {
 		payload["curveSpreadBp"] = *req.CurveSpreadBp
@@ -151,31 +361,101 @@ func (s *Service) AmendOrder(req AmendOrderRequest, correlationID string) error
 		payload,
 	)
 
-	if err := s.eventStore.Append(event); err != nil {
-		return fmt.Errorf("failed to append OrderAmended event: %w", err)
+	batch := amendmentBatch(event, complianceResult, requirement, correlationID, req.UpdatedBy)
+	if err := s.eventStore.AppendAll(batch, map[events.Aggregate]int{event.Aggregate: order.Version}); err != nil {
+		return nil, fmt.Errorf("failed to append amendment events: %w", err)
+	}
+	for _, written := range batch {
+		s.eventBus.Publish(written)
 	}
 
-	s.eventBus.Publish(event)
+	recordCompliance()
+	return result, nil
+}
 
-	// Re-run compliance check if needed
-	// ... (simplified for MVP)
+// amendmentBatch is what an accepted amendment writes: OrderAmended, the
+// compliance result on the new terms and, when the order has to be approved
+// again, the approval request, both caused by the amendment
+func amendmentBatch(amended *events.Event, checks *ComplianceResult, requirement *approval.Requirement, correlationID, actorID string) []*events.Event {
+	orderID := amended.Aggregate.ID
+	batch := []*events.Event{
+		amended,
+		newRuleEvaluatedEvent(orderID, checks, correlationID, actorID).WithCausation(amended.EventID),
+	}
+	if requirement != nil {
+		batch = append(batch, newApprovalRequestedEvent(orderID, requirement, correlationID, actorID).WithCausation(amended.EventID))
+	}
+	return batch
+}
 
-	return nil
+// amendmentOutcome decides what an amendment's compliance result means for
+// the order. A BLOCK refuses the amendment. Approval given on the old terms
+// does not carry over once the order has to be approved again, which its
+// approval policies or a WARN require; orders already with the EMS keep
+// working. requirement is only evaluated for an order that can go back for
+// approval, and is returned when it has to.
+func amendmentOutcome(order *OrderAggregate, checks *ComplianceResult, requirement func() *approval.Requirement) (*AmendResult, *approval.Requirement, error) {
+	if checks.Status == ComplianceStatusBlock {
+		return nil, nil, &ComplianceBlockError{OrderID: order.OrderID, Blocks: checks.Blocks}
+	}
+
+	result := &AmendResult{
+		ComplianceStatus: checks.Status,
+		Warnings:         checks.Warnings,
+	}
+	if !order.CanTransitionTo(OrderStateApprovalPending) {
+		return result, nil, nil
+	}
+
+	approvals := requirement()
+	if checks.Status == ComplianceStatusWarn && approvals.RequiredApprovals < 1 {
+		approvals.RequiredApprovals = 1
+	}
+	if approvals.RequiredApprovals == 0 {
+		return result, nil, nil
+	}
+	result.ApprovalRequested = true
+	result.RequiredApprovals = approvals.RequiredApprovals
+	return result, approvals, nil
 }
 
-// ApproveOrder approves an order that is pending approval
-func (s *Service) ApproveOrder(req ApproveOrderRequest, correlationID string) error {
-	// Validate state is APPROVAL_PENDING (would query projection)
-	// For MVP, we'll emit the event
+// ApproveOrder records an approval. Orders pending approval need the number of
+// distinct approvers their approval policies require, none of whom may be the
+// order's creator; the order is approved once the last one signs off.
+func (s *Service) ApproveOrder(req ApproveOrderRequest, correlationID string) (*ApprovalResult, error) {
+	order, err := s.loadOrder(req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := order.RequireTransition("approve", OrderStateApproved); err != nil {
+		return nil, err
+	}
+	if err := order.ValidateApprover(req.ApprovedBy); err != nil {
+		return nil, err
+	}
+
+	result := &ApprovalResult{
+		Approvals:         len(order.Approvers) + 1,
+		RequiredApprovals: order.ApprovalsNeeded(),
+	}
+	result.Approved = result.Approvals >= result.RequiredApprovals
 
 	payload := map[string]interface{}{
-		"orderId":    req.OrderID,
-		"approvedBy": req.ApprovedBy,
-		"approvedAt": time.Now().UTC(),
+		"orderId":           req.OrderID,
+		"approvedBy":        req.ApprovedBy,
+		"approvedAt":        time.Now().UTC(),
+		"approvalLevel":     result.Approvals,
+		"requiredApprovals": result.RequiredApprovals,
+	}
+
+	eventType := events.EventOrderApprovalRecorded
+	if result.Approved {
+		eventType = events.EventOrderApproved
+		payload["approvers"] = append(append([]string{}, order.Approvers...), req.ApprovedBy)
 	}
 
 	event := events.NewEvent(
-		events.EventOrderApproved,
+		eventType,
 		events.AggregateOrder,
 		req.OrderID,
 		req.ApprovedBy,
@@ -184,20 +464,68 @@ func (s *Service) ApproveOrder(req ApproveOrderRequest, correlationID string) er
 		payload,
 	)
 
-	if err := s.eventStore.Append(event); err != nil {
-		return fmt.Errorf("failed to append OrderApproved event: %w", err)
+	if err := s.eventStore.AppendExpected(event, order.Version); err != nil {
+		return nil, fmt.Errorf("failed to append %s event: %w", eventType, err)
 	}
 
 	s.eventBus.Publish(event)
 
-	// Run pre-execution compliance check
-	// ... (simplified for MVP)
+	return result, nil
+}
+
+// RejectOrder rejects an order that is pending approval
+func (s *Service) RejectOrder(req RejectOrderRequest, correlationID string) error {
+	if strings.TrimSpace(req.RejectedBy) == "" {
+		return ErrMissingRejectedBy
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return ErrMissingRejectReason
+	}
+
+	order, err := s.loadOrder(req.OrderID)
+	if err != nil {
+		return err
+	}
+	if order.State != OrderStateApprovalPending {
+		return &StateError{OrderID: order.OrderID, Action: "reject", CurrentState: order.State}
+	}
+
+	payload := map[string]interface{}{
+		"orderId":    req.OrderID,
+		"rejectedBy": req.RejectedBy,
+		"rejectedAt": time.Now().UTC(),
+		"reason":     req.Reason,
+	}
+
+	event := events.NewEvent(
+		events.EventOrderRejected,
+		events.AggregateOrder,
+		req.OrderID,
+		req.RejectedBy,
+		"user",
+		correlationID,
+		payload,
+	)
+
+	if err := s.eventStore.AppendExpected(event, order.Version); err != nil {
+		return fmt.Errorf("failed to append OrderRejected event: %w", err)
+	}
+
+	s.eventBus.Publish(event)
 
 	return nil
 }
 
 // CancelOrder cancels an order
 func (s *Service) CancelOrder(req CancelOrderRequest, correlationID string) error {
+	order, err := s.loadOrder(req.OrderID)
+	if err != nil {
+		return err
+	}
+	if err := order.RequireTransition("cancel", OrderStateCancelled); err != nil {
+		return err
+	}
+
 	payload := map[string]interface{}{
 		"orderId":     req.OrderID,
 		"cancelledBy": req.CancelledBy,
@@ -218,7 +546,7 @@ func (s *Service) CancelOrder(req CancelOrderRequest, correlationID string) erro
 		payload,
 	)
 
-	if err := s.eventStore.Append(event); err != nil {
+	if err := s.eventStore.AppendExpected(event, order.Version); err != nil {
 		return fmt.Errorf("failed to append OrderCancelled event: %w", err)
 	}
 
@@ -227,9 +555,57 @@ func (s *Service) CancelOrder(req CancelOrderRequest, correlationID string) erro
 	return nil
 }
 
+// ExpireOrder expires an order whose time in force has run out. Any unfilled
+// remainder is no longer working; fills already booked are kept.
+func (s *Service) ExpireOrder(orderID, reason, correlationID string) error {
+	order, err := s.loadOrder(orderID)
+	if err != nil {
+		return err
+	}
+	if err := order.RequireTransition("expire", OrderStateExpired); err != nil {
+		return err
+	}
+
+	payload := map[string]interface{}{
+		"orderId":           orderID,
+		"timeInForce":       order.TimeInForce,
+		"expiredAt":         time.Now().UTC(),
+		"reason":            reason,
+		"filledQuantity":    order.FilledQuantity,
+		"remainingQuantity": order.Quantity - order.FilledQuantity,
+	}
+
+	event := events.NewEvent(
+		events.EventOrderExpired,
+		events.AggregateOrder,
+		orderID,
+		expiryActorID,
+		"system",
+		correlationID,
+		payload,
+	)
+
+	if err := s.eventStore.AppendExpected(event, order.Version); err != nil {
+		return fmt.Errorf("failed to append OrderExpired event: %w", err)
+	}
+
+	s.eventBus.Publish(event)
+
+	return nil
+}
+
 // SendToEMS sends an approved order to the EMS
 func (s *Service) SendToEMS(req SendToEMSRequest, correlationID string) error {
-	// Validate order is APPROVED (would query projection)
+	order, err := s.loadOrder(req.OrderID)
+	if err != nil {
+		return err
+	}
+	if err := order.RequireTransition("send", OrderStateSent); err != nil {
+		return err
+	}
+	if order.BlockID != "" {
+		return ErrBlockChildOrder
+	}
 
 	payload := map[string]interface{}{
 		"orderId":     req.OrderID,
@@ -247,7 +623,7 @@ func (s *Service) SendToEMS(req SendToEMSRequest, correlationID string) error {
 		payload,
 	)
 
-	if err := s.eventStore.Append(event); err != nil {
+	if err := s.eventStore.AppendExpected(event, order.Version); err != nil {
 		return fmt.Errorf("failed to append OrderSentToEMS event: %w", err)
 	}
 
@@ -256,12 +632,54 @@ func (s *Service) SendToEMS(req SendToEMSRequest, correlationID string) error {
 	return nil
 }
 
+// loadOrder rehydrates the order aggregate from the event store. Commands
+// append with the version it was loaded at, so a command that raced another
+// on the same order fails with eventstore.ErrConcurrencyConflict.
+func (s *Service) loadOrder(orderID string) (*OrderAggregate, error) {
+	orderEvents, err := s.eventStore.GetByAggregate(events.AggregateOrder, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order events: %w", err)
+	}
+	return LoadOrderAggregate(orderEvents)
+}
+
+// ValidateCreateOrderRequest checks an order's terms the way CreateOrder
+// does, without creating it
+func (s *Service) ValidateCreateOrderRequest(req CreateOrderRequest) error {
+	if req.TimeInForce == "" {
+		req.TimeInForce = TimeInForceDay
+	}
+	if err := s.validateCreateOrderRequest(req); err != nil {
+		return err
+	}
+	_, err := s.priceLimit(req)
+	return err
+}
+
 // validateCreateOrderRequest validates the create order request
 func (s *Service) validateCreateOrderRequest(req CreateOrderRequest) error {
 	if req.Quantity <= 0 {
 		return ErrInvalidQuantity
 	}
 
+	switch req.TimeInForce {
+	case TimeInForceGTD:
+		if req.ExpireAt == nil || !req.ExpireAt.After(time.Now()) {
+			return ErrInvalidExpireAt
+		}
+	case TimeInForceDay, TimeInForceIOC, TimeInForceGTC:
+		if req.ExpireAt != nil {
+			return ErrInvalidExpireAt
+		}
+	default:
+		return ErrInvalidTimeInForce
+	}
+
+	return validateOrderTypeTerms(req)
+}
+
+// validateOrderTypeTerms checks that the order carries the price terms its order type needs
+func validateOrderTypeTerms(req CreateOrderRequest) error {
 	switch req.OrderType {
 	case OrderTypeLimit:
 		if req.LimitPrice == nil {
@@ -271,6 +689,10 @@ func (s *Service) validateCreateOrderRequest(req CreateOrderRequest) error {
 		if req.CurveSpreadBp == nil {
 			return ErrMissingCurveSpread
 		}
+	case OrderTypeYieldLimit:
+		if req.LimitYield == nil {
+			return ErrMissingLimitYield
+		}
 	case OrderTypeMarket:
 		// No additional validation needed
 	default:
@@ -280,6 +702,29 @@ func (s *Service) validateCreateOrderRequest(req CreateOrderRequest) error {
 	return nil
 }
 
+// runAmendmentComplianceCheck runs pre-trade compliance checks against the
+// amended terms without blocking the order itself. Nothing is recorded until
+// the returned record is called, once the amendment has been accepted.
+func (s *Service) runAmendmentComplianceCheck(orderID string, terms CreateOrderRequest, correlationID, actorID string) (*ComplianceResult, func(), error) {
+	if s.complianceService == nil {
+		return &ComplianceResult{
+			Status:      ComplianceStatusPass,
+			RulesPassed: []string{},
+			Warnings:    []ComplianceViolation{},
+			Blocks:      []ComplianceViolation{},
+			CheckedAt:   time.Now().UTC(),
+		}, func() {}, nil
+	}
+
+	result, err := s.complianceService.EvaluateAmendment(complianceOrderSnapshot(orderID, terms), actorID, correlationID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	record := func() { s.complianceService.RecordAmendment(result) }
+	return complianceResultFromService(result), record, nil
+}
+
 // runComplianceCheck runs pre-trade compliance checks
 // This is a stub - would integrate with actual compliance service
 func (s *Service) runComplianceCheck(orderID string, req CreateOrderRequest, correlationID, actorID string) (*ComplianceResult, error) {
@@ -304,23 +749,7 @@ func (s *Service) runComplianceCheck(orderID string, req CreateOrderRequest, cor
 
 // storeComplianceResult stores compliance result by emitting RuleEvaluated event
 func (s *Service) storeComplianceResult(orderID string, result *ComplianceResult, correlationID, actorID string) error {
-	resultJSON, _ := json.Marshal(result)
-	payload := map[string]interface{}{
-		"orderId":          orderID,
-		"complianceResult": json.RawMessage(resultJSON),
-		"status":           result.Status,
-	}
-
-	event := events.NewEvent(
-		events.EventRuleEvaluated,
-		events.AggregateOrder,
-		orderID,
-		actorID,
-		"system",
-		correlationID,
-		payload,
-	)
-
+	event := newRuleEvaluatedEvent(orderID, result, correlationID, actorID)
 	if err := s.eventStore.Append(event); err != nil {
 		return err
 	}
@@ -350,14 +779,18 @@ func (s *Service) emitComplianceBlockedEvent(orderID string, result *ComplianceR
 	s.eventBus.Publish(event)
 }
 
-// emitApprovalRequestedEvent emits OrderApprovalRequested event
-func (s *Service) emitApprovalRequestedEvent(orderID, correlationID, actorID string) {
+// newRuleEvaluatedEvent builds the RuleEvaluated event recording an order's
+// compliance result
+func newRuleEvaluatedEvent(orderID string, result *ComplianceResult, correlationID, actorID string) *events.Event {
+	resultJSON, _ := json.Marshal(result)
 	payload := map[string]interface{}{
-		"orderId": orderID,
+		"orderId":          orderID,
+		"complianceResult": json.RawMessage(resultJSON),
+		"status":           result.Status,
 	}
 
-	event := events.NewEvent(
-		events.EventOrderApprovalRequested,
+	return events.NewEvent(
+		events.EventRuleEvaluated,
 		events.AggregateOrder,
 		orderID,
 		actorID,
@@ -365,16 +798,51 @@ func (s *Service) emitApprovalRequestedEvent(orderID, correlationID, actorID str
 		correlationID,
 		payload,
 	)
+}
 
+// emitApprovalRequestedEvent emits OrderApprovalRequested event
+func (s *Service) emitApprovalRequestedEvent(orderID string, requirement *approval.Requirement, correlationID, actorID string) {
+	event := newApprovalRequestedEvent(orderID, requirement, correlationID, actorID)
 	s.eventStore.Append(event)
 	s.eventBus.Publish(event)
 }
 
-// needsApproval determines if an order needs manual approval
-// This is a stub - would have more complex logic in production
-func (s *Service) needsApproval(req CreateOrderRequest) bool {
-	// For MVP, require approval for large orders
-	return req.Quantity > 1000000
+// newApprovalRequestedEvent builds the OrderApprovalRequested event for an
+// approval requirement
+func newApprovalRequestedEvent(orderID string, requirement *approval.Requirement, correlationID, actorID string) *events.Event {
+	payload := map[string]interface{}{
+		"orderId":           orderID,
+		"requiredApprovals": requirement.RequiredApprovals,
+		"policies":          requirement.Policies,
+		"metrics":           requirement.Metrics,
+	}
+
+	return events.NewEvent(
+		events.EventOrderApprovalRequested,
+		events.AggregateOrder,
+		orderID,
+		actorID,
+		"system",
+		correlationID,
+		payload,
+	)
+}
+
+// approvalRequirement evaluates the order against the active approval
+// policies. If policies cannot be evaluated the order needs one approval.
+func (s *Service) approvalRequirement(req CreateOrderRequest) *approval.Requirement {
+	requirement, err := s.approvalService.Evaluate(approval.OrderContext{
+		AccountID:    req.AccountID,
+		InstrumentID: req.InstrumentID,
+		OrderType:    string(req.OrderType),
+		Quantity:     req.Quantity,
+		LimitPrice:   req.LimitPrice,
+	})
+	if err != nil {
+		fmt.Printf("Approval policy evaluation failed: %v\n", err)
+		return &approval.Requirement{RequiredApprovals: 1, Policies: []approval.MatchedPolicy{}}
+	}
+	return requirement
 }
 
 func complianceOrderSnapshot(orderID string, req CreateOrderRequest) compliance.OrderSnapshot {
@@ -387,7 +855,7 @@ func complianceOrderSnapshot(orderID string, req CreateOrderRequest) compliance.
 		curveSpread = sql.NullFloat64{Float64: *req.CurveSpreadBp, Valid: true}
 	}
 
-	return compliance.OrderSnapshot{
+	snapshot := compliance.OrderSnapshot{
 		OrderID:      orderID,
 		AccountID:    req.AccountID,
 		InstrumentID: req.InstrumentID,
@@ -397,6 +865,10 @@ func complianceOrderSnapshot(orderID string, req CreateOrderRequest) compliance.
 		LimitPrice:   limitPrice,
 		CurveSpread:  curveSpread,
 	}
+	if req.replaces != nil {
+		snapshot.ReplacesOrderID = req.replaces.OrderID
+	}
+	return snapshot
 }
 
 func complianceResultFromService(result *compliance.Result) *ComplianceResult {
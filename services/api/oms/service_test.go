@@ -0,0 +1,127 @@
+package oms
+
+import (
+	"errors"
+	"testing"
+
+	"instant/services/api/events"
+	"instant/services/api/services/approval"
+)
+
+func TestRejectOrderRequiresRejecterAndReason(t *testing.T) {
+	// Both are checked before the order is loaded
+	service := &Service{}
+
+	cases := []struct {
+		req  RejectOrderRequest
+		want error
+	}{
+		{RejectOrderRequest{OrderID: "order-1", Reason: "stale price"}, ErrMissingRejectedBy},
+		{RejectOrderRequest{OrderID: "order-1", RejectedBy: "  ", Reason: "stale price"}, ErrMissingRejectedBy},
+		{RejectOrderRequest{OrderID: "order-1", RejectedBy: "supervisor"}, ErrMissingRejectReason},
+		{RejectOrderRequest{OrderID: "order-1", RejectedBy: "supervisor", Reason: " "}, ErrMissingRejectReason},
+	}
+	for _, tc := range cases {
+		if err := service.RejectOrder(tc.req, "corr-1"); !errors.Is(err, tc.want) {
+			t.Errorf("RejectOrder(%+v) = %v, want %v", tc.req, err, tc.want)
+		}
+	}
+}
+
+func TestAmendmentOutcome(t *testing.T) {
+	approved, err := LoadOrderAggregate([]*events.Event{
+		orderEvent(events.EventOrderCreated, map[string]interface{}{"orderId": "order-1", "quantity": 1000.0}),
+		orderEvent(events.EventOrderApproved, map[string]interface{}{"orderId": "order-1"}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sent, err := LoadOrderAggregate([]*events.Event{
+		orderEvent(events.EventOrderCreated, map[string]interface{}{"orderId": "order-1", "quantity": 1000.0}),
+		orderEvent(events.EventOrderApproved, map[string]interface{}{"orderId": "order-1"}),
+		orderEvent(events.EventOrderSentToEMS, map[string]interface{}{"orderId": "order-1"}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	warning := []ComplianceViolation{{RuleID: "rule-1", Description: "concentration above 10%"}}
+	requires := func(approvals int) func() *approval.Requirement {
+		return func() *approval.Requirement {
+			return &approval.Requirement{RequiredApprovals: approvals}
+		}
+	}
+
+	t.Run("block refuses the amendment", func(t *testing.T) {
+		checks := &ComplianceResult{Status: ComplianceStatusBlock, Blocks: warning}
+		_, _, err := amendmentOutcome(approved, checks, requires(0))
+		var blockErr *ComplianceBlockError
+		if !errors.As(err, &blockErr) || blockErr.OrderID != "order-1" || len(blockErr.Blocks) != 1 {
+			t.Fatalf("expected a compliance block, got %v", err)
+		}
+	})
+
+	t.Run("warning sends an approved order back for approval", func(t *testing.T) {
+		checks := &ComplianceResult{Status: ComplianceStatusWarn, Warnings: warning}
+		result, requirement, err := amendmentOutcome(approved, checks, requires(0))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !result.ApprovalRequested || result.RequiredApprovals != 1 || requirement == nil {
+			t.Fatalf("expected one approval to be requested, got %+v", result)
+		}
+		if result.ComplianceStatus != ComplianceStatusWarn || len(result.Warnings) != 1 {
+			t.Fatalf("expected the warning to be reported, got %+v", result)
+		}
+	})
+
+	t.Run("policy requirement applies to the new terms", func(t *testing.T) {
+		checks := &ComplianceResult{Status: ComplianceStatusPass}
+		result, _, err := amendmentOutcome(approved, checks, requires(2))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !result.ApprovalRequested || result.RequiredApprovals != 2 {
+			t.Fatalf("expected two approvals to be requested, got %+v", result)
+		}
+
+		result, requirement, err := amendmentOutcome(approved, checks, requires(0))
+		if err != nil || result.ApprovalRequested || requirement != nil {
+			t.Fatalf("expected the approval to carry over, got %+v, %v", result, err)
+		}
+	})
+
+	t.Run("sent order keeps working", func(t *testing.T) {
+		checks := &ComplianceResult{Status: ComplianceStatusWarn, Warnings: warning}
+		result, requirement, err := amendmentOutcome(sent, checks, func() *approval.Requirement {
+			t.Fatal("approval policies evaluated for an order already with the EMS")
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.ApprovalRequested || requirement != nil || len(result.Warnings) != 1 {
+			t.Fatalf("expected the warning without re-approval, got %+v", result)
+		}
+	})
+}
+
+func TestAmendmentBatchWritesEverythingTheAmendmentCauses(t *testing.T) {
+	amended := events.NewEvent(events.EventOrderAmended, events.AggregateOrder, "order-1", "trader", "user", "corr-1", map[string]interface{}{})
+	checks := &ComplianceResult{Status: ComplianceStatusWarn}
+
+	batch := amendmentBatch(amended, checks, &approval.Requirement{RequiredApprovals: 1}, "corr-1", "trader")
+	if len(batch) != 3 || batch[0] != amended ||
+		batch[1].EventType != events.EventRuleEvaluated || batch[2].EventType != events.EventOrderApprovalRequested {
+		t.Fatalf("expected OrderAmended, RuleEvaluated and OrderApprovalRequested, got %d events", len(batch))
+	}
+	for _, event := range batch[1:] {
+		if event.Aggregate != amended.Aggregate || event.CausationID == nil || *event.CausationID != amended.EventID {
+			t.Fatalf("expected %s on the order, caused by the amendment, got %+v", event.EventType, event)
+		}
+	}
+
+	if batch := amendmentBatch(amended, &ComplianceResult{Status: ComplianceStatusPass}, nil, "corr-1", "trader"); len(batch) != 2 {
+		t.Fatalf("expected no approval request without a requirement, got %d events", len(batch))
+	}
+}
@@ -1,6 +1,7 @@
 package oms
 
 import (
+	"instant/services/api/services/risk"
 	"time"
 )
 
@@ -19,6 +20,7 @@ const (
 	OrderTypeMarket        OrderType = "MARKET"
 	OrderTypeLimit         OrderType = "LIMIT"
 	OrderTypeCurveRelative OrderType = "CURVE_RELATIVE"
+	OrderTypeYieldLimit    OrderType = "YIELD_LIMIT" // limit quoted as a yield to maturity, in percent
 )
 
 // TimeInForce represents how long an order remains active
@@ -27,6 +29,8 @@ type TimeInForce string
 const (
 	TimeInForceDay TimeInForce = "DAY"
 	TimeInForceIOC TimeInForce = "IOC"
+	TimeInForceGTC TimeInForce = "GTC"
+	TimeInForceGTD TimeInForce = "GTD"
 )
 
 // OrderState represents the current state of an order
@@ -42,6 +46,8 @@ const (
 	OrderStateCancelled       OrderState = "CANCELLED"
 	OrderStateRejected        OrderState = "REJECTED"
 	OrderStateSettled         OrderState = "SETTLED"
+	OrderStateExpired         OrderState = "EXPIRED"
+	OrderStateReplaced        OrderState = "REPLACED"
 )
 
 // ComplianceStatus represents the result of a compliance check
@@ -78,9 +84,15 @@ type CreateOrderRequest struct {
 	Quantity       float64      `json:"quantity"`
 	OrderType      OrderType    `json:"orderType"`
 	LimitPrice     *float64     `json:"limitPrice,omitempty"`
+	LimitYield     *float64     `json:"limitYield,omitempty"` // percent; required for YIELD_LIMIT
 	CurveSpreadBp  *float64     `json:"curveSpreadBp,omitempty"`
 	TimeInForce    TimeInForce  `json:"timeInForce"`
+	ExpireAt       *time.Time   `json:"expireAt,omitempty"` // required for GTD
 	BatchID        *string      `json:"batchId,omitempty"`
+	BlockID        *string      `json:"-"` // set by CreateBlockOrder on child orders
+	replaces       *chainLink   // set by ReplaceOrder on the new version
+	limitAsOf      *time.Time   // curve date the limit was priced on
+	riskExposure   *risk.Exposure // notional and DV01 the order adds to its trader's day
 	CreatedBy      string       `json:"createdBy"`
 }
 
@@ -90,16 +102,117 @@ type AmendOrderRequest struct {
 	Quantity       *float64    `json:"quantity,omitempty"`
 	OrderType      *OrderType  `json:"orderType,omitempty"`
 	LimitPrice     *float64    `json:"limitPrice,omitempty"`
+	LimitYield     *float64    `json:"limitYield,omitempty"`
 	CurveSpreadBp  *float64    `json:"curveSpreadBp,omitempty"`
 	UpdatedBy      string      `json:"updatedBy"`
 }
 
+// AmendResult reports the compliance outcome of an accepted amendment
+type AmendResult struct {
+	ComplianceStatus  ComplianceStatus      `json:"complianceStatus"`
+	Warnings          []ComplianceViolation `json:"warnings,omitempty"`
+	ApprovalRequested bool                  `json:"approvalRequested"`
+	RequiredApprovals int                   `json:"requiredApprovals,omitempty"`
+}
+
+// ReplaceOrderRequest represents a cancel/replace: the order is retired and a
+// new version with the changed terms takes its place. Terms left unset are
+// carried over; quantity defaults to what the old version left unfilled.
+type ReplaceOrderRequest struct {
+	OrderID       string       `json:"orderId"`
+	Quantity      *float64     `json:"quantity,omitempty"`
+	OrderType     *OrderType   `json:"orderType,omitempty"`
+	LimitPrice    *float64     `json:"limitPrice,omitempty"`
+	LimitYield    *float64     `json:"limitYield,omitempty"`
+	CurveSpreadBp *float64     `json:"curveSpreadBp,omitempty"`
+	TimeInForce   *TimeInForce `json:"timeInForce,omitempty"`
+	ExpireAt      *time.Time   `json:"expireAt,omitempty"`
+	Reason        string       `json:"reason,omitempty"`
+	ReplacedBy    string       `json:"replacedBy"`
+}
+
+// ReplaceResult reports the new order version created by a cancel/replace
+type ReplaceResult struct {
+	OrderID           string                `json:"orderId"`
+	ReplacedOrderID   string                `json:"replacedOrderId"`
+	ChainID           string                `json:"chainId"`
+	Version           int                   `json:"version"`
+	Changes           []FieldChange         `json:"changes"`
+	ComplianceStatus  ComplianceStatus      `json:"complianceStatus"`
+	Warnings          []ComplianceViolation `json:"warnings,omitempty"`
+	ApprovalRequested bool                  `json:"approvalRequested"`
+	RequiredApprovals int                   `json:"requiredApprovals,omitempty"`
+}
+
+// FieldChange is one order term changed by an amendment or a replace
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
 // ApproveOrderRequest represents a request to approve an order
 type ApproveOrderRequest struct {
 	OrderID    string `json:"orderId"`
 	ApprovedBy string `json:"approvedBy"`
 }
 
+// ApprovalResult reports progress towards the approvals an order needs
+type ApprovalResult struct {
+	Approved          bool `json:"approved"`
+	Approvals         int  `json:"approvals"`
+	RequiredApprovals int  `json:"requiredApprovals"`
+}
+
+// RejectOrderRequest represents a request to reject an order pending approval
+type RejectOrderRequest struct {
+	OrderID    string `json:"orderId"`
+	RejectedBy string `json:"rejectedBy"`
+	Reason     string `json:"reason"`
+}
+
+// BlockAllocationRequest is one account's share of a block order
+type BlockAllocationRequest struct {
+	AccountID string  `json:"accountId"`
+	Quantity  float64 `json:"quantity"`
+}
+
+// CreateBlockOrderRequest represents a request to trade one instrument for several accounts at once
+type CreateBlockOrderRequest struct {
+	InstrumentID  string                   `json:"instrumentId"` // CUSIP
+	Side          OrderSide                `json:"side"`
+	OrderType     OrderType                `json:"orderType"`
+	LimitPrice    *float64                 `json:"limitPrice,omitempty"`
+	LimitYield    *float64                 `json:"limitYield,omitempty"`
+	CurveSpreadBp *float64                 `json:"curveSpreadBp,omitempty"`
+	TimeInForce   TimeInForce              `json:"timeInForce"`
+	ExpireAt      *time.Time               `json:"expireAt,omitempty"`
+	LotSize       *float64                 `json:"lotSize,omitempty"`
+	Allocations   []BlockAllocationRequest `json:"allocations"`
+	CreatedBy     string                   `json:"createdBy"`
+}
+
+// BlockChildResult reports the child order created for one allocation
+type BlockChildResult struct {
+	AccountID string  `json:"accountId"`
+	OrderID   string  `json:"orderId"`
+	Quantity  float64 `json:"quantity"`
+	Status    string  `json:"status"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// CreateBlockOrderResult reports a new block order and its child orders
+type CreateBlockOrderResult struct {
+	BlockID string             `json:"blockId"`
+	Orders  []BlockChildResult `json:"orders"`
+}
+
+// SendBlockToEMSRequest represents a request to send a block order to the EMS
+type SendBlockToEMSRequest struct {
+	BlockID string `json:"blockId"`
+	SentBy  string `json:"sentBy"`
+}
+
 // CancelOrderRequest represents a request to cancel an order
 type CancelOrderRequest struct {
 	OrderID     string `json:"orderId"`
@@ -113,6 +226,39 @@ type SendToEMSRequest struct {
 	SentBy  string `json:"sentBy"`
 }
 
+// NetAndSendRequest represents a request to cross approved orders against each
+// other and send only what is left to the EMS
+type NetAndSendRequest struct {
+	OrderIDs []string `json:"orderIds"`
+	SentBy   string   `json:"sentBy"`
+}
+
+// CrossTrade is a buy and a sell for different accounts crossed internally at the evaluated price
+type CrossTrade struct {
+	CrossID       string    `json:"crossId"`
+	InstrumentID  string    `json:"instrumentId"`
+	BuyOrderID    string    `json:"buyOrderId"`
+	BuyAccountID  string    `json:"buyAccountId"`
+	SellOrderID   string    `json:"sellOrderId"`
+	SellAccountID string    `json:"sellAccountId"`
+	Quantity      float64   `json:"quantity"`
+	Price         float64   `json:"price"`
+	PriceAsOf     time.Time `json:"priceAsOf"`
+}
+
+// NetResidual is the part of an order left after crossing, which goes to the EMS
+type NetResidual struct {
+	OrderID  string  `json:"orderId"`
+	Quantity float64 `json:"quantity"`
+}
+
+// NetAndSendResult reports the crosses a netting run made and the residuals it sent
+type NetAndSendResult struct {
+	NettingID string        `json:"nettingId"`
+	Crosses   []CrossTrade  `json:"crosses"`
+	Residuals []NetResidual `json:"residuals"`
+}
+
 // Order represents an order in the system
 type Order struct {
 	OrderID           string            `json:"orderId"`
@@ -122,16 +268,25 @@ type Order struct {
 	Quantity          float64           `json:"quantity"`
 	OrderType         OrderType         `json:"orderType"`
 	LimitPrice        *float64          `json:"limitPrice,omitempty"`
+	LimitYield        *float64          `json:"limitYield,omitempty"`
 	CurveSpreadBp     *float64          `json:"curveSpreadBp,omitempty"`
 	TimeInForce       TimeInForce       `json:"timeInForce"`
+	ExpireAt          *time.Time        `json:"expireAt,omitempty"`
 	State             OrderState        `json:"state"`
 	BatchID           *string           `json:"batchId,omitempty"`
+	BlockID           *string           `json:"blockId,omitempty"`
 	ComplianceResult  *ComplianceResult `json:"complianceResult,omitempty"`
 	CreatedAt         time.Time         `json:"createdAt"`
 	CreatedBy         string            `json:"createdBy"`
 	UpdatedAt         time.Time         `json:"updatedAt"`
 	LastStateChangeAt time.Time         `json:"lastStateChangeAt"`
+	ApprovedBy        *string           `json:"approvedBy,omitempty"`
+	ApprovedAt        *time.Time        `json:"approvedAt,omitempty"`
+	RejectedBy        *string           `json:"rejectedBy,omitempty"`
+	RejectedAt        *time.Time        `json:"rejectedAt,omitempty"`
+	RejectionReason   *string           `json:"rejectionReason,omitempty"`
 	SentToEmsAt       *time.Time        `json:"sentToEmsAt,omitempty"`
 	FullyFilledAt     *time.Time        `json:"fullyFilledAt,omitempty"`
 	SettledAt         *time.Time        `json:"settledAt,omitempty"`
+	ExpiredAt         *time.Time        `json:"expiredAt,omitempty"`
 }
@@ -0,0 +1,96 @@
+package pms
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"instant/services/api/events"
+)
+
+// ErrAccountNotFound is returned when an account command names an unknown account
+var ErrAccountNotFound = errors.New("account not found")
+
+// AdjustCash credits (positive amount) or debits (negative amount) an
+// account's cash balance. The first adjustment starts tracking cash for the
+// account, which turns on the pre-trade cash check for its buy orders.
+func (s *Service) AdjustCash(req AdjustCashRequest, correlationID string) error {
+	if req.AccountID == "" {
+		return errors.New("accountId is required")
+	}
+	if req.AdjustedBy == "" {
+		return errors.New("adjustedBy is required")
+	}
+	if req.Amount == 0 {
+		return errors.New("amount must not be zero")
+	}
+	if err := s.requireAccount(req.AccountID); err != nil {
+		return err
+	}
+
+	payload := map[string]interface{}{
+		"accountId":  req.AccountID,
+		"amount":     req.Amount,
+		"adjustedBy": req.AdjustedBy,
+		"adjustedAt": time.Now().UTC(),
+	}
+	if req.Reason != "" {
+		payload["reason"] = req.Reason
+	}
+
+	event := events.NewEvent(
+		events.EventAccountCashAdjusted,
+		events.AggregateAccount,
+		req.AccountID,
+		req.AdjustedBy,
+		"user",
+		correlationID,
+		payload,
+	)
+
+	return s.appendAndPublish(event)
+}
+
+// SetShortSales allows or disallows sell orders beyond an account's
+// available holdings
+func (s *Service) SetShortSales(req SetShortSalesRequest, correlationID string) error {
+	if req.AccountID == "" {
+		return errors.New("accountId is required")
+	}
+	if req.UpdatedBy == "" {
+		return errors.New("updatedBy is required")
+	}
+	if err := s.requireAccount(req.AccountID); err != nil {
+		return err
+	}
+
+	event := events.NewEvent(
+		events.EventAccountShortSalesUpdated,
+		events.AggregateAccount,
+		req.AccountID,
+		req.UpdatedBy,
+		"user",
+		correlationID,
+		map[string]interface{}{
+			"accountId":       req.AccountID,
+			"allowShortSales": req.AllowShortSales,
+			"updatedBy":       req.UpdatedBy,
+			"updatedAt":       time.Now().UTC(),
+		},
+	)
+
+	return s.appendAndPublish(event)
+}
+
+func (s *Service) requireAccount(accountID string) error {
+	var exists string
+	err := s.db.QueryRow(`SELECT "accountId" FROM accounts WHERE "accountId" = $1`, accountID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAccountNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch account: %w", err)
+	}
+	return nil
+}
@@ -86,3 +86,16 @@ type SendProposalToOMSRequest struct {
 	ProposalID string `json:"proposalId"`
 	SentBy     string `json:"sentBy"`
 }
+
+type AdjustCashRequest struct {
+	AccountID  string  `json:"accountId"`
+	Amount     float64 `json:"amount"`
+	Reason     string  `json:"reason,omitempty"`
+	AdjustedBy string  `json:"adjustedBy"`
+}
+
+type SetShortSalesRequest struct {
+	AccountID       string `json:"accountId"`
+	AllowShortSales bool   `json:"allowShortSales"`
+	UpdatedBy       string `json:"updatedBy"`
+}
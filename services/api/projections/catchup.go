@@ -0,0 +1,100 @@
+package projections
+
+import (
+	"fmt"
+	"instant/services/api/eventbus"
+	"instant/services/api/events"
+	"time"
+)
+
+const (
+	// catchUpBatchSize is how many events a catch-up pass reads at a time
+	catchUpBatchSize = 500
+	// catchUpRetryInterval is how long a projection waits to retry a failed catch-up
+	catchUpRetryInterval = 5 * time.Second
+)
+
+// catchUp replays the events appended after a projection's checkpoint before
+// it goes live, so events appended while no instance ran the projection still
+// reach its read model. Events queued on the live subscription meanwhile are
+// discarded: they are already committed, so the next pass reads them from the
+// store. Passes repeat until one finds nothing new. It returns false if the
+// projection is stopped first.
+func catchUp(projection string, subscriber eventbus.Subscriber, loader eventbus.EventLoader, checkpoints *CheckpointStore, apply func(*events.Event), stop <-chan struct{}) bool {
+	from, err := checkpoints.Cursor(projection)
+	for err != nil {
+		if !waitToRetry(projection, err, stop) {
+			return false
+		}
+		from, err = checkpoints.Cursor(projection)
+	}
+
+	for {
+		discardQueued(subscriber)
+
+		applied, err := catchUpPass(loader, &from, apply)
+		if err != nil {
+			if !waitToRetry(projection, err, stop) {
+				return false
+			}
+			continue
+		}
+		if applied == 0 {
+			return true
+		}
+		fmt.Printf("%s projection caught up on %d event(s)\n", projection, applied)
+	}
+}
+
+// CatchUp is catchUp for workers outside this package that act on events,
+// such as the EMS and compliance listeners, so they too handle the events
+// appended while no instance ran them. name is the worker's checkpoint.
+func CatchUp(name string, subscriber eventbus.Subscriber, loader eventbus.EventLoader, checkpoints *CheckpointStore, apply func(*events.Event), stop <-chan struct{}) bool {
+	return catchUp(name, subscriber, loader, checkpoints, apply, stop)
+}
+
+// catchUpPass applies every event after *from in position order, moving *from
+// along, and returns how many it applied
+func catchUpPass(loader eventbus.EventLoader, from *int64, apply func(*events.Event)) (int, error) {
+	applied := 0
+	for {
+		batch, err := loader.GetAfterPosition(*from, catchUpBatchSize)
+		if err != nil {
+			return applied, err
+		}
+		for _, event := range batch {
+			apply(event)
+			*from = event.Position
+			applied++
+		}
+		if len(batch) < catchUpBatchSize {
+			return applied, nil
+		}
+	}
+}
+
+// waitToRetry logs a failed catch-up and waits to retry it. It returns false
+// if the projection is stopped first.
+func waitToRetry(projection string, err error, stop <-chan struct{}) bool {
+	fmt.Printf("%s projection failed to catch up: %v\n", projection, err)
+	select {
+	case <-stop:
+		return false
+	case <-time.After(catchUpRetryInterval):
+		return true
+	}
+}
+
+// discardQueued empties a subscription without blocking
+func discardQueued(subscriber eventbus.Subscriber) {
+	for {
+		select {
+		case _, ok := <-subscriber:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
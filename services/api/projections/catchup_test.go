@@ -0,0 +1,76 @@
+package projections
+
+import (
+	"errors"
+	"testing"
+
+	"instant/services/api/eventbus"
+	"instant/services/api/events"
+)
+
+type fakeLoader struct {
+	events []*events.Event
+	err    error
+}
+
+func (l *fakeLoader) GetByPosition(position int64) (*events.Event, error) {
+	return nil, errors.New("not used")
+}
+
+func (l *fakeLoader) GetAfterPosition(position int64, limit int) ([]*events.Event, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	batch := []*events.Event{}
+	for _, event := range l.events {
+		if event.Position > position && len(batch) < limit {
+			batch = append(batch, event)
+		}
+	}
+	return batch, nil
+}
+
+func TestCatchUpPassReplaysFromTheCheckpoint(t *testing.T) {
+	loader := &fakeLoader{}
+	for position := int64(1); position <= catchUpBatchSize+5; position++ {
+		loader.events = append(loader.events, &events.Event{Position: position})
+	}
+
+	applied := []int64{}
+	from := int64(3)
+	count, err := catchUpPass(loader, &from, func(event *events.Event) {
+		applied = append(applied, event.Position)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != catchUpBatchSize+2 || applied[0] != 4 || from != catchUpBatchSize+5 {
+		t.Fatalf("expected positions 4 to %d, got %d events ending at %d", catchUpBatchSize+5, count, from)
+	}
+
+	// Nothing new: the projection can go live
+	if count, _ := catchUpPass(loader, &from, func(*events.Event) {}); count != 0 {
+		t.Fatalf("expected nothing left to replay, got %d", count)
+	}
+}
+
+func TestCatchUpPassKeepsItsPlaceOnFailure(t *testing.T) {
+	loader := &fakeLoader{events: []*events.Event{{Position: 1}}, err: errors.New("connection reset")}
+	from := int64(0)
+	if _, err := catchUpPass(loader, &from, func(*events.Event) {}); err == nil || from != 0 {
+		t.Fatalf("expected the error and no progress, got %v at %d", err, from)
+	}
+}
+
+func TestDiscardQueuedEmptiesTheSubscription(t *testing.T) {
+	subscriber := make(eventbus.Subscriber, 3)
+	subscriber <- &events.Event{Position: 1}
+	subscriber <- &events.Event{Position: 2}
+	discardQueued(subscriber)
+	if len(subscriber) != 0 {
+		t.Fatalf("expected the subscription emptied, %d left", len(subscriber))
+	}
+
+	close(subscriber)
+	discardQueued(subscriber)
+}
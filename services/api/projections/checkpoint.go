@@ -0,0 +1,114 @@
+package projections
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// checkpointPollInterval is how often WaitFor re-reads a checkpoint
+const checkpointPollInterval = 25 * time.Millisecond
+
+// CheckpointStore tracks the highest event position each projection has applied.
+// Checkpoints live in the database so a reader on any instance can wait for
+// the projection running on the leader.
+type CheckpointStore struct {
+	db *sql.DB
+}
+
+// NewCheckpointStore creates a new checkpoint store
+func NewCheckpointStore(db *sql.DB) *CheckpointStore {
+	return &CheckpointStore{db: db}
+}
+
+// Advance records that a projection has handled the event at position.
+// Checkpoints never move backwards, so out-of-order delivery is harmless.
+func (s *CheckpointStore) Advance(projection string, position int64) error {
+	if position <= 0 {
+		return nil
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO projection_checkpoints (projection, position, "updatedAt")
+		VALUES ($1, $2, $3)
+		ON CONFLICT (projection) DO UPDATE SET
+			position = GREATEST(projection_checkpoints.position, EXCLUDED.position),
+			"updatedAt" = EXCLUDED."updatedAt"
+	`, projection, position, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to advance checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Position returns the position up to which a projection's read model is
+// complete: its checkpoint, held below the lowest event still waiting in its
+// dead letter queue. Events after a dead letter may already be applied, but a
+// reader waiting on the projection must not see it as caught up until the
+// dead letter is retried or skipped.
+func (s *CheckpointStore) Position(projection string) (int64, error) {
+	var position int64
+	err := s.db.QueryRow(`
+		SELECT LEAST(
+			c.position,
+			COALESCE((
+				SELECT MIN(d.position) - 1
+				FROM projection_dead_letters d
+				WHERE d.projection = c.projection AND d.status IN ($2, $3)
+			), c.position)
+		)
+		FROM projection_checkpoints c
+		WHERE c.projection = $1
+	`, projection, DeadLetterPending, DeadLetterFailed).Scan(&position)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	return position, nil
+}
+
+// Cursor returns the last position a projection has applied, ignoring dead
+// letters. Catch-up resumes from here: dead-lettered events are retried from
+// the queue, not replayed.
+func (s *CheckpointStore) Cursor(projection string) (int64, error) {
+	var position int64
+	err := s.db.QueryRow(`SELECT position FROM projection_checkpoints WHERE projection = $1`, projection).Scan(&position)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	return position, nil
+}
+
+// WaitFor blocks until the projection's position reaches minPosition or ctx
+// is done. It returns the last position observed.
+func (s *CheckpointStore) WaitFor(ctx context.Context, projection string, minPosition int64) (int64, error) {
+	return waitFor(ctx, func() (int64, error) { return s.Position(projection) }, minPosition)
+}
+
+// waitFor polls read until it reaches minPosition or ctx is done
+func waitFor(ctx context.Context, read func() (int64, error), minPosition int64) (int64, error) {
+	ticker := time.NewTicker(checkpointPollInterval)
+	defer ticker.Stop()
+
+	for {
+		position, err := read()
+		if err != nil {
+			return 0, err
+		}
+		if position >= minPosition {
+			return position, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return position, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package projections
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitForReturnsOnceThePositionIsReached(t *testing.T) {
+	reads := 0
+	position, err := waitFor(context.Background(), func() (int64, error) {
+		reads++
+		return int64(reads * 10), nil
+	}, 25)
+	if err != nil || position != 30 || reads != 3 {
+		t.Fatalf("expected position 30 on the third read, got %d after %d reads: %v", position, reads, err)
+	}
+}
+
+func TestWaitForTimesOutWithTheLastPosition(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*checkpointPollInterval)
+	defer cancel()
+
+	started := time.Now()
+	position, err := waitFor(ctx, func() (int64, error) { return 7, nil }, 8)
+	if !errors.Is(err, context.DeadlineExceeded) || position != 7 {
+		t.Fatalf("expected a timeout at position 7, got %d: %v", position, err)
+	}
+	if time.Since(started) > time.Second {
+		t.Fatalf("expected waitFor to give up at the deadline")
+	}
+}
+
+func TestWaitForStopsOnAReadError(t *testing.T) {
+	_, err := waitFor(context.Background(), func() (int64, error) {
+		return 0, errors.New("connection reset")
+	}, 1)
+	if err == nil {
+		t.Fatalf("expected the read error")
+	}
+}
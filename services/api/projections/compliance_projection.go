@@ -8,21 +8,28 @@ import (
 	"instant/services/api/events"
 	"strconv"
 	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
 )
 
 type ComplianceProjection struct {
-	db       *sql.DB
-	eventBus *eventbus.EventBus
-	stopChan chan struct{}
+	db          *sql.DB
+	eventBus    *eventbus.EventBus
+	loader      eventbus.EventLoader
+	deadLetters *DeadLetterQueue
+	checkpoints *CheckpointStore
+	stopChan    chan struct{}
 }
 
-func NewComplianceProjection(db *sql.DB, eb *eventbus.EventBus) (*ComplianceProjection, error) {
+func NewComplianceProjection(db *sql.DB, eb *eventbus.EventBus, loader eventbus.EventLoader) (*ComplianceProjection, error) {
 	return &ComplianceProjection{
-		db:       db,
-		eventBus: eb,
-		stopChan: make(chan struct{}),
+		db:          db,
+		eventBus:    eb,
+		loader:      loader,
+		deadLetters: NewDeadLetterQueue(db),
+		checkpoints: NewCheckpointStore(db),
+		stopChan:    make(chan struct{}),
 	}, nil
 }
 
@@ -30,17 +37,26 @@ func (p *ComplianceProjection) Start() {
 	subscriber, cleanup := p.eventBus.Subscribe("*", 1000)
 	defer cleanup()
 
+	retryTicker := time.NewTicker(deadLetterSweepInterval)
+	defer retryTicker.Stop()
+
 	fmt.Println("Compliance Projection worker started")
 
+	if !catchUp(ProjectionCompliance, subscriber, p.loader, p.checkpoints, p.apply, p.stopChan) {
+		fmt.Println("Compliance Projection worker stopped")
+		return
+	}
+
 	for {
 		select {
-		case event := <-subscriber:
-			if event == nil {
-				continue
-			}
-			if err := p.handleEvent(event); err != nil {
-				fmt.Printf("Compliance projection error handling %s: %v\n", event.EventType, err)
+		case event, ok := <-subscriber:
+			if !ok {
+				// The bus has closed the subscription
+				return
 			}
+			p.apply(event)
+		case <-retryTicker.C:
+			p.deadLetters.RetryDue(ProjectionCompliance, p.handleEvent)
 		case <-p.stopChan:
 			fmt.Println("Compliance Projection worker stopped")
 			return
@@ -48,6 +64,16 @@ func (p *ComplianceProjection) Start() {
 	}
 }
 
+// apply handles an event and advances the projection's checkpoint if it was applied
+func (p *ComplianceProjection) apply(event *events.Event) {
+	if err := p.deadLetters.Handle(ProjectionCompliance, "ComplianceProjection."+event.EventType, event, p.handleEvent); err != nil {
+		return
+	}
+	if err := p.checkpoints.Advance(ProjectionCompliance, event.Position); err != nil {
+		fmt.Printf("%s projection checkpoint error: %v\n", ProjectionCompliance, err)
+	}
+}
+
 func (p *ComplianceProjection) Stop() {
 	close(p.stopChan)
 }
@@ -0,0 +1,365 @@
+package projections
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"instant/services/api/events"
+	"runtime/debug"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Projection names used to scope dead letters
+const (
+	ProjectionOMS        = "oms"
+	ProjectionEMS        = "ems"
+	ProjectionPMS        = "pms"
+	ProjectionCompliance = "compliance"
+)
+
+// ProjectionNames lists every projection that records dead letters
+var ProjectionNames = []string{ProjectionOMS, ProjectionEMS, ProjectionPMS, ProjectionCompliance}
+
+// Dead letter statuses
+const (
+	DeadLetterPending  = "PENDING"  // waiting for an automatic or requested retry
+	DeadLetterFailed   = "FAILED"   // automatic retries exhausted
+	DeadLetterResolved = "RESOLVED" // a retry succeeded
+	DeadLetterSkipped  = "SKIPPED"  // an operator chose not to apply the event
+)
+
+const (
+	// inlineAttempts is how many times an event is tried before it is dead-lettered
+	inlineAttempts = 3
+	inlineBackoff  = 100 * time.Millisecond
+	// maxDeadLetterAttempts stops automatic retries; operators can still requeue
+	maxDeadLetterAttempts = 10
+	retryBaseBackoff      = 30 * time.Second
+	retryMaxBackoff       = time.Hour
+	// deadLetterSweepInterval is how often a running projection retries due dead letters
+	deadLetterSweepInterval = 5 * time.Second
+)
+
+// operatorMoves lists, for each status an operator can move a dead letter to,
+// the statuses it may be moved from: resolved and skipped letters are closed
+var operatorMoves = map[string][]string{
+	DeadLetterPending: {DeadLetterPending, DeadLetterFailed},
+	DeadLetterSkipped: {DeadLetterPending, DeadLetterFailed},
+}
+
+var (
+	ErrUnknownProjection  = errors.New("unknown projection")
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	ErrDeadLetterClosed   = errors.New("dead letter already resolved or skipped")
+)
+
+// DeadLetter is an event a projection could not apply
+type DeadLetter struct {
+	ID            string     `json:"id"`
+	Projection    string     `json:"projection"`
+	Handler       string     `json:"handler"`
+	EventID       string     `json:"eventId"`
+	EventType     string     `json:"eventType"`
+	Position      int64      `json:"position"`
+	Error         string     `json:"error"`
+	Attempts      int        `json:"attempts"`
+	Status        string     `json:"status"`
+	NextRetryAt   *time.Time `json:"nextRetryAt,omitempty"`
+	FirstFailedAt time.Time  `json:"firstFailedAt"`
+	LastFailedAt  time.Time  `json:"lastFailedAt"`
+	ResolvedAt    *time.Time `json:"resolvedAt,omitempty"`
+	ResolvedBy    *string    `json:"resolvedBy,omitempty"`
+}
+
+// DeadLetterQueue applies events with retries and records the ones that keep failing
+type DeadLetterQueue struct {
+	db *sql.DB
+}
+
+// NewDeadLetterQueue creates a new dead letter queue
+func NewDeadLetterQueue(db *sql.DB) *DeadLetterQueue {
+	return &DeadLetterQueue{db: db}
+}
+
+// IsProjection reports whether name is a known projection
+func IsProjection(name string) bool {
+	for _, projection := range ProjectionNames {
+		if projection == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle applies an event, retrying with backoff, and dead-letters it when
+// every attempt fails. Panics in the handler are recovered and treated as errors.
+// It returns the handler's last error if the event was not applied.
+func (q *DeadLetterQueue) Handle(projection, handler string, event *events.Event, apply func(*events.Event) error) error {
+	var err error
+	for attempt := 1; attempt <= inlineAttempts; attempt++ {
+		if err = safeApply(apply, event); err == nil {
+			return nil
+		}
+		if attempt < inlineAttempts {
+			time.Sleep(inlineBackoff << (attempt - 1))
+		}
+	}
+
+	fmt.Printf("%s projection dead-lettering %s (%s): %v\n", projection, event.EventType, event.EventID, err)
+	if recordErr := q.record(projection, handler, event, err, inlineAttempts); recordErr != nil {
+		fmt.Printf("%s projection failed to record dead letter for %s: %v\n", projection, event.EventID, recordErr)
+	}
+	return err
+}
+
+// RetryDue re-applies pending dead letters whose backoff has elapsed.
+// It runs inside the projection's own worker loop so retries never race
+// the live handler.
+func (q *DeadLetterQueue) RetryDue(projection string, apply func(*events.Event) error) {
+	rows, err := q.db.Query(`
+		SELECT id, event, attempts
+		FROM projection_dead_letters
+		WHERE projection = $1 AND status = $2 AND "nextRetryAt" <= $3
+		ORDER BY position ASC
+		LIMIT 50
+	`, projection, DeadLetterPending, time.Now().UTC())
+	if err != nil {
+		fmt.Printf("%s projection failed to load dead letters: %v\n", projection, err)
+		return
+	}
+
+	type dueLetter struct {
+		id       string
+		event    []byte
+		attempts int
+	}
+	var due []dueLetter
+	for rows.Next() {
+		var letter dueLetter
+		if err := rows.Scan(&letter.id, &letter.event, &letter.attempts); err != nil {
+			rows.Close()
+			fmt.Printf("%s projection failed to scan dead letter: %v\n", projection, err)
+			return
+		}
+		due = append(due, letter)
+	}
+	rows.Close()
+
+	for _, letter := range due {
+		var event events.Event
+		if err := json.Unmarshal(letter.event, &event); err != nil {
+			fmt.Printf("%s projection cannot decode dead letter %s: %v\n", projection, letter.id, err)
+			continue
+		}
+
+		now := time.Now().UTC()
+		if applyErr := safeApply(apply, &event); applyErr != nil {
+			attempts := letter.attempts + 1
+			status, nextRetryAt := retryOutcome(attempts, now)
+			_, err = q.db.Exec(`
+				UPDATE projection_dead_letters
+				SET error = $2, attempts = $3, status = $4, "nextRetryAt" = $5, "lastFailedAt" = $6, "updatedAt" = $6
+				WHERE id = $1
+			`, letter.id, applyErr.Error(), attempts, status, nextRetryAt, now)
+		} else {
+			_, err = q.db.Exec(`
+				UPDATE projection_dead_letters
+				SET status = $2, "nextRetryAt" = NULL, "resolvedAt" = $3, "updatedAt" = $3
+				WHERE id = $1
+			`, letter.id, DeadLetterResolved, now)
+		}
+		if err != nil {
+			fmt.Printf("%s projection failed to update dead letter %s: %v\n", projection, letter.id, err)
+		}
+	}
+}
+
+// List returns dead letters for a projection, optionally filtered by status
+func (q *DeadLetterQueue) List(projection, status string, limit, offset int) ([]DeadLetter, error) {
+	if !IsProjection(projection) {
+		return nil, ErrUnknownProjection
+	}
+
+	query := `
+		SELECT id, projection, handler, "eventId", "eventType", position, error, attempts,
+		       status, "nextRetryAt", "firstFailedAt", "lastFailedAt", "resolvedAt", "resolvedBy"
+		FROM projection_dead_letters
+		WHERE projection = $1
+	`
+	args := []interface{}{projection}
+	if status != "" {
+		query += ` AND status = $2`
+		args = append(args, status)
+	}
+	query += fmt.Sprintf(` ORDER BY position ASC LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	rows, err := q.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	letters := []DeadLetter{}
+	for rows.Next() {
+		var (
+			letter      DeadLetter
+			nextRetryAt sql.NullTime
+			resolvedAt  sql.NullTime
+			resolvedBy  sql.NullString
+		)
+		if err := rows.Scan(
+			&letter.ID, &letter.Projection, &letter.Handler, &letter.EventID, &letter.EventType,
+			&letter.Position, &letter.Error, &letter.Attempts, &letter.Status, &nextRetryAt,
+			&letter.FirstFailedAt, &letter.LastFailedAt, &resolvedAt, &resolvedBy,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+		if nextRetryAt.Valid {
+			letter.NextRetryAt = &nextRetryAt.Time
+		}
+		if resolvedAt.Valid {
+			letter.ResolvedAt = &resolvedAt.Time
+		}
+		if resolvedBy.Valid {
+			letter.ResolvedBy = &resolvedBy.String
+		}
+		letters = append(letters, letter)
+	}
+
+	return letters, rows.Err()
+}
+
+// Requeue schedules a dead letter for immediate retry by the running projection
+func (q *DeadLetterQueue) Requeue(projection, id string) error {
+	return q.transition(projection, id, DeadLetterPending, `
+		UPDATE projection_dead_letters
+		SET status = $3, "nextRetryAt" = $4, "updatedAt" = $4
+		WHERE projection = $1 AND id = $2
+	`, DeadLetterPending, time.Now().UTC())
+}
+
+// Skip marks a dead letter as intentionally not applied
+func (q *DeadLetterQueue) Skip(projection, id, actorID string) error {
+	return q.transition(projection, id, DeadLetterSkipped, `
+		UPDATE projection_dead_letters
+		SET status = $3, "nextRetryAt" = NULL, "resolvedAt" = $4, "resolvedBy" = $5, "updatedAt" = $4
+		WHERE projection = $1 AND id = $2
+	`, DeadLetterSkipped, time.Now().UTC(), actorID)
+}
+
+// transition moves a dead letter to a new status with update, if an operator
+// may move it there from its current status
+func (q *DeadLetterQueue) transition(projection, id, to, update string, args ...interface{}) error {
+	if !IsProjection(projection) {
+		return ErrUnknownProjection
+	}
+
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin dead letter update: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow(`SELECT status FROM projection_dead_letters WHERE projection = $1 AND id = $2 FOR UPDATE`, projection, id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load dead letter: %w", err)
+	}
+	if !canMove(status, to) {
+		return ErrDeadLetterClosed
+	}
+
+	if _, err := tx.Exec(update, append([]interface{}{projection, id}, args...)...); err != nil {
+		return fmt.Errorf("failed to update dead letter: %w", err)
+	}
+	return tx.Commit()
+}
+
+// canMove reports whether an operator may move a dead letter between statuses
+func canMove(from, to string) bool {
+	for _, status := range operatorMoves[to] {
+		if status == from {
+			return true
+		}
+	}
+	return false
+}
+
+// record stores a failed event, or bumps the attempt count if it was already dead-lettered
+func (q *DeadLetterQueue) record(projection, handler string, event *events.Event, cause error, attempts int) error {
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	now := time.Now().UTC()
+	_, err = q.db.Exec(`
+		INSERT INTO projection_dead_letters (
+			id, projection, handler, "eventId", "eventType", position, event, error,
+			attempts, status, "nextRetryAt", "firstFailedAt", "lastFailedAt", "createdAt", "updatedAt"
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $12, $12, $12)
+		ON CONFLICT (projection, "eventId") DO UPDATE SET
+			error = EXCLUDED.error,
+			attempts = projection_dead_letters.attempts + EXCLUDED.attempts,
+			status = EXCLUDED.status,
+			"nextRetryAt" = EXCLUDED."nextRetryAt",
+			"lastFailedAt" = EXCLUDED."lastFailedAt",
+			"updatedAt" = EXCLUDED."updatedAt"
+	`,
+		uuid.New().String(),
+		projection,
+		handler,
+		event.EventID,
+		event.EventType,
+		event.Position,
+		eventJSON,
+		cause.Error(),
+		attempts,
+		DeadLetterPending,
+		now.Add(retryBackoff(attempts)),
+		now,
+	)
+	return err
+}
+
+// safeApply runs a handler, converting a panic into an error
+func safeApply(apply func(*events.Event) error, event *events.Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return apply(event)
+}
+
+// retryOutcome is the status a dead letter is left in after a failed retry,
+// and when it is retried next: never, once automatic attempts are exhausted
+func retryOutcome(attempts int, now time.Time) (string, interface{}) {
+	if attempts >= maxDeadLetterAttempts {
+		return DeadLetterFailed, nil
+	}
+	return DeadLetterPending, now.Add(retryBackoff(attempts))
+}
+
+// retryBackoff doubles the delay for every attempt beyond the inline ones
+func retryBackoff(attempts int) time.Duration {
+	exponent := attempts - inlineAttempts
+	if exponent < 0 {
+		exponent = 0
+	}
+	if exponent > 7 {
+		return retryMaxBackoff
+	}
+	backoff := retryBaseBackoff << exponent
+	if backoff > retryMaxBackoff {
+		return retryMaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,91 @@
+package projections
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"instant/services/api/events"
+)
+
+func TestRetryBackoffDoublesUpToTheCap(t *testing.T) {
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, retryBaseBackoff},
+		{inlineAttempts, retryBaseBackoff},
+		{inlineAttempts + 1, 2 * retryBaseBackoff},
+		{inlineAttempts + 2, 4 * retryBaseBackoff},
+		{inlineAttempts + 6, 64 * retryBaseBackoff},
+		{inlineAttempts + 7, retryMaxBackoff},
+		{inlineAttempts + 20, retryMaxBackoff},
+	}
+	for _, tc := range cases {
+		if got := retryBackoff(tc.attempts); got != tc.want {
+			t.Errorf("retryBackoff(%d) = %s, want %s", tc.attempts, got, tc.want)
+		}
+	}
+}
+
+func TestRetryOutcomeFailsOnceAttemptsAreExhausted(t *testing.T) {
+	now := time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)
+
+	status, next := retryOutcome(inlineAttempts+1, now)
+	if status != DeadLetterPending || next != now.Add(2*retryBaseBackoff) {
+		t.Fatalf("expected a pending retry after the backoff, got %s at %v", status, next)
+	}
+
+	status, next = retryOutcome(maxDeadLetterAttempts, now)
+	if status != DeadLetterFailed || next != nil {
+		t.Fatalf("expected a failed letter with no retry, got %s at %v", status, next)
+	}
+}
+
+func TestOperatorsCanOnlyRequeueOrSkipOpenLetters(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     bool
+	}{
+		{DeadLetterPending, DeadLetterPending, true},
+		{DeadLetterFailed, DeadLetterPending, true},
+		{DeadLetterPending, DeadLetterSkipped, true},
+		{DeadLetterFailed, DeadLetterSkipped, true},
+		{DeadLetterResolved, DeadLetterPending, false},
+		{DeadLetterSkipped, DeadLetterPending, false},
+		{DeadLetterResolved, DeadLetterSkipped, false},
+		{DeadLetterSkipped, DeadLetterSkipped, false},
+		{DeadLetterPending, DeadLetterResolved, false},
+	}
+	for _, tc := range cases {
+		if got := canMove(tc.from, tc.to); got != tc.want {
+			t.Errorf("canMove(%s, %s) = %v, want %v", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestHandleRetriesInlineBeforeDeadLettering(t *testing.T) {
+	// No database: an event that succeeds within the inline attempts is never recorded
+	queue := NewDeadLetterQueue(nil)
+	calls := 0
+	err := queue.Handle(ProjectionOMS, "OMSProjection.OrderCreated", &events.Event{EventType: "OrderCreated"}, func(*events.Event) error {
+		calls++
+		if calls < inlineAttempts {
+			return errors.New("deadlock detected")
+		}
+		return nil
+	})
+	if err != nil || calls != inlineAttempts {
+		t.Fatalf("expected success after %d attempts, got %d: %v", inlineAttempts, calls, err)
+	}
+}
+
+func TestSafeApplyTurnsPanicsIntoErrors(t *testing.T) {
+	err := safeApply(func(*events.Event) error {
+		panic("nil map")
+	}, &events.Event{})
+	if err == nil || !strings.Contains(err.Error(), "panic: nil map") {
+		t.Fatalf("expected the panic as an error, got %v", err)
+	}
+}
@@ -6,23 +6,30 @@ import (
 	"fmt"
 	"instant/services/api/eventbus"
 	"instant/services/api/events"
+	"time"
 
 	_ "github.com/lib/pq"
 )
 
 // EMSProjection handles building the Execution and Fill read models from events.
 type EMSProjection struct {
-	db       *sql.DB
-	eventBus *eventbus.EventBus
-	stopChan chan struct{}
+	db          *sql.DB
+	eventBus    *eventbus.EventBus
+	loader      eventbus.EventLoader
+	deadLetters *DeadLetterQueue
+	checkpoints *CheckpointStore
+	stopChan    chan struct{}
 }
 
 // NewEMSProjection creates a new EMS projection worker.
-func NewEMSProjection(db *sql.DB, eb *eventbus.EventBus) (*EMSProjection, error) {
+func NewEMSProjection(db *sql.DB, eb *eventbus.EventBus, loader eventbus.EventLoader) (*EMSProjection, error) {
 	return &EMSProjection{
-		db:       db,
-		eventBus: eb,
-		stopChan: make(chan struct{}),
+		db:          db,
+		eventBus:    eb,
+		loader:      loader,
+		deadLetters: NewDeadLetterQueue(db),
+		checkpoints: NewCheckpointStore(db),
+		stopChan:    make(chan struct{}),
 	}, nil
 }
 
@@ -31,14 +38,26 @@ func (p *EMSProjection) Start() {
 	subscriber, cleanup := p.eventBus.Subscribe("*", 1000)
 	defer cleanup()
 
+	retryTicker := time.NewTicker(deadLetterSweepInterval)
+	defer retryTicker.Stop()
+
 	fmt.Println("EMS Projection worker started")
 
+	if !catchUp(ProjectionEMS, subscriber, p.loader, p.checkpoints, p.apply, p.stopChan) {
+		fmt.Println("EMS Projection worker stopped")
+		return
+	}
+
 	for {
 		select {
-		case event := <-subscriber:
-			if err := p.handleEvent(event); err != nil {
-				fmt.Printf("EMS projection error handling %s: %v\n", event.EventType, err)
+		case event, ok := <-subscriber:
+			if !ok {
+				// The bus has closed the subscription
+				return
 			}
+			p.apply(event)
+		case <-retryTicker.C:
+			p.deadLetters.RetryDue(ProjectionEMS, p.handleEvent)
 		case <-p.stopChan:
 			fmt.Println("EMS Projection worker stopped")
 			return
@@ -46,6 +65,16 @@ func (p *EMSProjection) Start() {
 	}
 }
 
+// apply handles an event and advances the projection's checkpoint if it was applied
+func (p *EMSProjection) apply(event *events.Event) {
+	if err := p.deadLetters.Handle(ProjectionEMS, "EMSProjection."+event.EventType, event, p.handleEvent); err != nil {
+		return
+	}
+	if err := p.checkpoints.Advance(ProjectionEMS, event.Position); err != nil {
+		fmt.Printf("%s projection checkpoint error: %v\n", ProjectionEMS, err)
+	}
+}
+
 // Stop stops the projection worker.
 func (p *EMSProjection) Stop() {
 	close(p.stopChan)
@@ -65,6 +94,26 @@ func (p *EMSProjection) handleEvent(event *events.Event) error {
 		return p.handleOrderFullyFilled(event)
 	case events.EventSettlementBooked:
 		return p.handleSettlementBooked(event)
+	case events.EventAllocationBooked:
+		return p.handleAllocationBooked(event)
+	case events.EventSettlementSettled, events.EventSettlementPartiallySettled:
+		return p.handleSettlementSettled(event)
+	case events.EventSettlementFailed:
+		return p.handleSettlementFailed(event)
+	case events.EventBlockOrderAllocated:
+		return p.handleBlockOrderAllocated(event)
+	case events.EventFeeScheduleCreated:
+		return p.handleFeeScheduleCreated(event)
+	case events.EventFeeScheduleUpdated:
+		return p.handleFeeScheduleUpdated(event)
+	case events.EventFeeScheduleDeleted:
+		return p.handleFeeScheduleDeleted(event)
+	case events.EventRoutingPolicyCreated:
+		return p.handleRoutingPolicyCreated(event)
+	case events.EventRoutingPolicyUpdated:
+		return p.handleRoutingPolicyUpdated(event)
+	case events.EventRoutingPolicyDeleted:
+		return p.handleRoutingPolicyDeleted(event)
 	}
 
 	return nil
@@ -86,8 +135,8 @@ func (p *EMSProjection) handleExecutionRequested(event *events.Event) error {
 		INSERT INTO executions (
 			"executionId", "orderId", "accountId", "instrumentId", side,
 			"totalQuantity", "filledQuantity", status, "asOfDate",
-			"createdAt", "updatedAt"
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			"createdAt", "updatedAt", "blockId", venue, "routingPolicyId"
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 
 	_, err = p.db.Exec(
@@ -103,6 +152,9 @@ func (p *EMSProjection) handleExecutionRequested(event *events.Event) error {
 		asOfDate,
 		event.OccurredAt,
 		event.OccurredAt,
+		payload["blockId"],
+		nullableString(payload["venue"]),
+		nullableString(payload["routingPolicyId"]),
 	)
 
 	return err
@@ -193,8 +245,9 @@ func (p *EMSProjection) handleFillGenerated(event *events.Event) error {
 
 	query := `
 		INSERT INTO fills (
-			"fillId", "executionId", "clipIndex", quantity, price, timestamp, slippage, "createdAt"
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			"fillId", "executionId", "clipIndex", quantity, price, timestamp, slippage, "createdAt",
+			commission, fees, markup
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
 	timestamp, err := parseTime(payload["timestamp"])
@@ -212,6 +265,9 @@ func (p *EMSProjection) handleFillGenerated(event *events.Event) error {
 		timestamp,
 		payload["slippage"],
 		event.OccurredAt,
+		parseFloat(payload["commission"]),
+		parseFloat(payload["fees"]),
+		parseFloat(payload["markup"]),
 	)
 
 	return err
@@ -223,6 +279,10 @@ func (p *EMSProjection) handleOrderPartiallyFilled(event *events.Event) error {
 	if !ok {
 		return nil
 	}
+	// Child fills of a block are recorded on the execution by BlockOrderAllocated
+	if _, ok := payload["blockId"]; ok {
+		return nil
+	}
 
 	query := `
 		UPDATE executions
@@ -240,6 +300,10 @@ func (p *EMSProjection) handleOrderFullyFilled(event *events.Event) error {
 	if !ok {
 		return nil
 	}
+	// Child fills of a block are recorded on the execution by BlockOrderAllocated
+	if _, ok := payload["blockId"]; ok {
+		return nil
+	}
 
 	updates := []string{"status = 'FILLED'", `"updatedAt" = $1`}
 	args := []interface{}{event.OccurredAt}
@@ -263,6 +327,10 @@ func (p *EMSProjection) handleOrderFullyFilled(event *events.Event) error {
 	return err
 }
 
+// handleSettlementBooked records what an execution settles for and when.
+// Settlements booked before the settlement lifecycle settled the execution
+// straight away; now the execution settles once all its settlements have, and
+// a single account's execution or cross leg opens a PENDING settlement.
 func (p *EMSProjection) handleSettlementBooked(event *events.Event) error {
 	payload := event.Payload
 	executionID, ok := payload["executionId"].(string)
@@ -275,12 +343,294 @@ func (p *EMSProjection) handleSettlementBooked(event *events.Event) error {
 		return err
 	}
 
+	legacy := settledOnBooking(payload)
 	query := `
 		UPDATE executions
-		SET status = 'SETTLED', "settlementDate" = $1, "settledDate" = $2, "updatedAt" = $3
-		WHERE "executionId" = $4
+		SET status = CASE WHEN $11 THEN 'SETTLED'::execution_status ELSE status END,
+			"settledDate" = CASE WHEN $11 THEN $2 ELSE "settledDate" END,
+			"settlementDate" = $1, "updatedAt" = $3,
+			commission = $4, fees = $5, markup = $6,
+			principal = $7, "accruedInterest" = $8, "netSettlementAmount" = $9
+		WHERE "executionId" = $10
 	`
 
-	_, err = p.db.Exec(query, settlementDate, event.OccurredAt, event.OccurredAt, executionID)
+	_, err = p.db.Exec(query, settlementDate, event.OccurredAt, event.OccurredAt,
+		parseFloat(payload["commission"]), parseFloat(payload["fees"]), parseFloat(payload["markup"]),
+		payload["principal"], payload["accruedInterest"], payload["netSettlementAmount"], executionID, legacy)
+	if err != nil || legacy || stringify(payload["blockId"]) != "" {
+		return err
+	}
+
+	return p.insertSettlement(event, parseFloat(payload["filledQuantity"]), parseFloat(payload["avgFillPrice"]))
+}
+
+// handleAllocationBooked opens a PENDING settlement for one account's share of
+// a block execution
+func (p *EMSProjection) handleAllocationBooked(event *events.Event) error {
+	if settledOnBooking(event.Payload) {
+		return nil
+	}
+	return p.insertSettlement(event, parseFloat(event.Payload["quantity"]), parseFloat(event.Payload["price"]))
+}
+
+// insertSettlement records a PENDING settlement from a SettlementBooked or
+// AllocationBooked payload
+func (p *EMSProjection) insertSettlement(event *events.Event, quantity, price float64) error {
+	payload := event.Payload
+
+	settlementDate, err := parseTime(payload["settlementDate"])
+	if err != nil {
+		return err
+	}
+	tradeDate, err := parseTime(payload["tradeDate"])
+	if err != nil {
+		return err
+	}
+	if tradeDate.IsZero() {
+		tradeDate = event.OccurredAt
+	}
+
+	_, err = p.db.Exec(`
+		INSERT INTO settlements (
+			"settlementId", "executionId", "orderId", "blockId", "crossId", "accountId", "instrumentId",
+			side, quantity, price, "netSettlementAmount", "tradeDate", "settlementDate", status,
+			"createdAt", "updatedAt"
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, 'PENDING', $14, $15)
+		ON CONFLICT ("settlementId") DO NOTHING
+	`,
+		payload["settlementId"],
+		payload["executionId"],
+		nullableString(payload["orderId"]),
+		nullableString(payload["blockId"]),
+		nullableString(payload["crossId"]),
+		payload["accountId"],
+		payload["instrumentId"],
+		stringify(payload["side"]),
+		quantity,
+		price,
+		payload["netSettlementAmount"],
+		tradeDate,
+		settlementDate,
+		event.OccurredAt,
+		event.OccurredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert settlement: %w", err)
+	}
+	return nil
+}
+
+// handleSettlementSettled records a full or partial settlement. An execution
+// is SETTLED once none of its settlements is left open.
+func (p *EMSProjection) handleSettlementSettled(event *events.Event) error {
+	payload := event.Payload
+	settlementID := stringify(payload["settlementId"])
+	if settlementID == "" {
+		return nil
+	}
+
+	settledAt, err := parseTime(payload["settledAt"])
+	if err != nil {
+		return err
+	}
+	if settledAt.IsZero() {
+		settledAt = event.OccurredAt
+	}
+
+	_, err = p.db.Exec(`
+		UPDATE settlements
+		SET status = $1, "settledQuantity" = $2, reason = COALESCE($3, reason), "settledAt" = $4, "updatedAt" = $5
+		WHERE "settlementId" = $6
+	`, payload["status"], parseFloat(payload["settledQuantity"]), nullableString(payload["reason"]), settledAt, event.OccurredAt, settlementID)
+	if err != nil || event.EventType != events.EventSettlementSettled {
+		return err
+	}
+
+	_, err = p.db.Exec(`
+		UPDATE executions
+		SET status = 'SETTLED', "settledDate" = $1, "updatedAt" = $2
+		WHERE "executionId" = $3
+		  AND NOT EXISTS (
+			SELECT 1 FROM settlements
+			WHERE "executionId" = $3 AND status <> 'SETTLED'
+		  )
+	`, settledAt, event.OccurredAt, stringify(payload["executionId"]))
+	return err
+}
+
+// handleSettlementFailed records why a settlement failed
+func (p *EMSProjection) handleSettlementFailed(event *events.Event) error {
+	payload := event.Payload
+	settlementID := stringify(payload["settlementId"])
+	if settlementID == "" {
+		return nil
+	}
+
+	failedAt, err := parseTime(payload["failedAt"])
+	if err != nil {
+		return err
+	}
+	if failedAt.IsZero() {
+		failedAt = event.OccurredAt
+	}
+
+	_, err = p.db.Exec(`
+		UPDATE settlements
+		SET status = 'FAILED', reason = $1, "failedAt" = $2, "updatedAt" = $3
+		WHERE "settlementId" = $4
+	`, payload["reason"], failedAt, event.OccurredAt, settlementID)
 	return err
 }
+
+func (p *EMSProjection) handleBlockOrderAllocated(event *events.Event) error {
+	payload := event.Payload
+	executionID, ok := payload["executionId"].(string)
+	if !ok {
+		return nil
+	}
+
+	query := `
+		UPDATE executions
+		SET status = $1, "filledQuantity" = $2, "avgFillPrice" = $3, "updatedAt" = $4
+		WHERE "executionId" = $5
+	`
+
+	_, err := p.db.Exec(query, payload["state"], payload["filledQuantity"], payload["avgFillPrice"], event.OccurredAt, executionID)
+	return err
+}
+
+// handleFeeScheduleCreated records a new fee schedule
+func (p *EMSProjection) handleFeeScheduleCreated(event *events.Event) error {
+	payload := event.Payload
+	scheduleID, ok := payload["scheduleId"].(string)
+	if !ok || scheduleID == "" {
+		return nil
+	}
+
+	_, err := p.db.Exec(`
+		INSERT INTO fee_schedules (
+			"scheduleId", name, scope, "scopeId", "instrumentType", "commissionPerBond", "commissionBps",
+			"minCommission", "feeBps", "markupBps", status, version, "createdAt", "createdBy", "updatedAt", "updatedBy"
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $13, $14)
+		ON CONFLICT ("scheduleId") DO NOTHING
+	`, scheduleID, payload["name"], payload["scope"], nullableString(payload["scopeId"]), nullableString(payload["instrumentType"]),
+		parseFloat(payload["commissionPerBond"]), parseFloat(payload["commissionBps"]), parseFloat(payload["minCommission"]),
+		parseFloat(payload["feeBps"]), parseFloat(payload["markupBps"]), payload["status"], payload["version"],
+		event.OccurredAt, payload["updatedBy"])
+	if err != nil {
+		return fmt.Errorf("failed to insert fee schedule: %w", err)
+	}
+
+	return nil
+}
+
+// handleFeeScheduleUpdated stores a fee schedule's new name and rates
+func (p *EMSProjection) handleFeeScheduleUpdated(event *events.Event) error {
+	payload := event.Payload
+	scheduleID, ok := payload["scheduleId"].(string)
+	if !ok || scheduleID == "" {
+		return nil
+	}
+
+	_, err := p.db.Exec(`
+		UPDATE fee_schedules
+		SET name = $1, "commissionPerBond" = $2, "commissionBps" = $3, "minCommission" = $4,
+			"feeBps" = $5, "markupBps" = $6, version = $7, "updatedAt" = $8, "updatedBy" = $9
+		WHERE "scheduleId" = $10
+	`, payload["name"], parseFloat(payload["commissionPerBond"]), parseFloat(payload["commissionBps"]),
+		parseFloat(payload["minCommission"]), parseFloat(payload["feeBps"]), parseFloat(payload["markupBps"]),
+		payload["version"], event.OccurredAt, payload["updatedBy"], scheduleID)
+	if err != nil {
+		return fmt.Errorf("failed to update fee schedule: %w", err)
+	}
+
+	return nil
+}
+
+// handleFeeScheduleDeleted marks a fee schedule as deleted
+func (p *EMSProjection) handleFeeScheduleDeleted(event *events.Event) error {
+	payload := event.Payload
+	scheduleID, ok := payload["scheduleId"].(string)
+	if !ok || scheduleID == "" {
+		return nil
+	}
+
+	_, err := p.db.Exec(`
+		UPDATE fee_schedules
+		SET status = 'DELETED', version = $1, "updatedAt" = $2, "updatedBy" = $3
+		WHERE "scheduleId" = $4
+	`, payload["version"], event.OccurredAt, payload["deletedBy"], scheduleID)
+	if err != nil {
+		return fmt.Errorf("failed to delete fee schedule: %w", err)
+	}
+
+	return nil
+}
+
+// handleRoutingPolicyCreated records a new routing policy
+func (p *EMSProjection) handleRoutingPolicyCreated(event *events.Event) error {
+	payload := event.Payload
+	policyID, ok := payload["policyId"].(string)
+	if !ok || policyID == "" {
+		return nil
+	}
+
+	_, err := p.db.Exec(`
+		INSERT INTO routing_policies (
+			"policyId", name, priority, venue, "accountId", "instrumentType", "orderType",
+			"minQuantity", "maxQuantity", status, version, "createdAt", "createdBy", "updatedAt", "updatedBy"
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $12, $13)
+		ON CONFLICT ("policyId") DO NOTHING
+	`, policyID, payload["name"], int(parseFloat(payload["priority"])), payload["venue"],
+		nullableString(payload["accountId"]), nullableString(payload["instrumentType"]), nullableString(payload["orderType"]),
+		nullableFloat(payload["minQuantity"]), nullableFloat(payload["maxQuantity"]), payload["status"], payload["version"],
+		event.OccurredAt, payload["updatedBy"])
+	if err != nil {
+		return fmt.Errorf("failed to insert routing policy: %w", err)
+	}
+
+	return nil
+}
+
+// handleRoutingPolicyUpdated stores a routing policy's new criteria and venue
+func (p *EMSProjection) handleRoutingPolicyUpdated(event *events.Event) error {
+	payload := event.Payload
+	policyID, ok := payload["policyId"].(string)
+	if !ok || policyID == "" {
+		return nil
+	}
+
+	_, err := p.db.Exec(`
+		UPDATE routing_policies
+		SET name = $1, priority = $2, venue = $3, "accountId" = $4, "instrumentType" = $5, "orderType" = $6,
+			"minQuantity" = $7, "maxQuantity" = $8, version = $9, "updatedAt" = $10, "updatedBy" = $11
+		WHERE "policyId" = $12
+	`, payload["name"], int(parseFloat(payload["priority"])), payload["venue"], nullableString(payload["accountId"]),
+		nullableString(payload["instrumentType"]), nullableString(payload["orderType"]), nullableFloat(payload["minQuantity"]),
+		nullableFloat(payload["maxQuantity"]), payload["version"], event.OccurredAt, payload["updatedBy"], policyID)
+	if err != nil {
+		return fmt.Errorf("failed to update routing policy: %w", err)
+	}
+
+	return nil
+}
+
+// handleRoutingPolicyDeleted marks a routing policy as deleted
+func (p *EMSProjection) handleRoutingPolicyDeleted(event *events.Event) error {
+	payload := event.Payload
+	policyID, ok := payload["policyId"].(string)
+	if !ok || policyID == "" {
+		return nil
+	}
+
+	_, err := p.db.Exec(`
+		UPDATE routing_policies
+		SET status = 'DELETED', version = $1, "updatedAt" = $2, "updatedBy" = $3
+		WHERE "policyId" = $4
+	`, payload["version"], event.OccurredAt, payload["deletedBy"], policyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete routing policy: %w", err)
+	}
+
+	return nil
+}
@@ -33,3 +33,12 @@ func nullableString(value interface{}) interface{} {
 	}
 	return value
 }
+
+// settledOnBooking reports whether a SettlementBooked or AllocationBooked
+// payload predates the settlement lifecycle, when trades settled as soon as
+// they were booked
+func settledOnBooking(payload map[string]interface{}) bool {
+	_, single := payload["settlementId"]
+	_, block := payload["settlementIds"]
+	return !single && !block
+}
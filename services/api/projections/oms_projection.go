@@ -6,23 +6,30 @@ import (
 	"fmt"
 	"instant/services/api/eventbus"
 	"instant/services/api/events"
+	"time"
 
 	_ "github.com/lib/pq"
 )
 
 // OMSProjection handles building the Order read model from events
 type OMSProjection struct {
-	db       *sql.DB
-	eventBus *eventbus.EventBus
-	stopChan chan struct{}
+	db          *sql.DB
+	eventBus    *eventbus.EventBus
+	loader      eventbus.EventLoader
+	deadLetters *DeadLetterQueue
+	checkpoints *CheckpointStore
+	stopChan    chan struct{}
 }
 
 // NewOMSProjection creates a new OMS projection worker
-func NewOMSProjection(db *sql.DB, eb *eventbus.EventBus) (*OMSProjection, error) {
+func NewOMSProjection(db *sql.DB, eb *eventbus.EventBus, loader eventbus.EventLoader) (*OMSProjection, error) {
 	return &OMSProjection{
-		db:       db,
-		eventBus: eb,
-		stopChan: make(chan struct{}),
+		db:          db,
+		eventBus:    eb,
+		loader:      loader,
+		deadLetters: NewDeadLetterQueue(db),
+		checkpoints: NewCheckpointStore(db),
+		stopChan:    make(chan struct{}),
 	}, nil
 }
 
@@ -32,14 +39,26 @@ func (p *OMSProjection) Start() {
 	subscriber, cleanup := p.eventBus.Subscribe("*", 1000)
 	defer cleanup()
 
+	retryTicker := time.NewTicker(deadLetterSweepInterval)
+	defer retryTicker.Stop()
+
 	fmt.Println("OMS Projection worker started")
 
+	if !catchUp(ProjectionOMS, subscriber, p.loader, p.checkpoints, p.apply, p.stopChan) {
+		fmt.Println("OMS Projection worker stopped")
+		return
+	}
+
 	for {
 		select {
-		case event := <-subscriber:
-			if err := p.handleEvent(event); err != nil {
-				fmt.Printf("Error handling event %s: %v\n", event.EventType, err)
+		case event, ok := <-subscriber:
+			if !ok {
+				// The bus has closed the subscription
+				return
 			}
+			p.apply(event)
+		case <-retryTicker.C:
+			p.deadLetters.RetryDue(ProjectionOMS, p.handleEvent)
 		case <-p.stopChan:
 			fmt.Println("OMS Projection worker stopped")
 			return
@@ -47,6 +66,16 @@ func (p *OMSProjection) Start() {
 	}
 }
 
+// apply handles an event and advances the projection's checkpoint if it was applied
+func (p *OMSProjection) apply(event *events.Event) {
+	if err := p.deadLetters.Handle(ProjectionOMS, "OMSProjection."+event.EventType, event, p.handleEvent); err != nil {
+		return
+	}
+	if err := p.checkpoints.Advance(ProjectionOMS, event.Position); err != nil {
+		fmt.Printf("%s projection checkpoint error: %v\n", ProjectionOMS, err)
+	}
+}
+
 // Stop stops the projection worker
 func (p *OMSProjection) Stop() {
 	close(p.stopChan)
@@ -54,13 +83,21 @@ func (p *OMSProjection) Stop() {
 
 // handleEvent routes events to appropriate handlers
 func (p *OMSProjection) handleEvent(event *events.Event) error {
+	if err := p.trackRiskExposure(event); err != nil {
+		return err
+	}
+
 	switch event.EventType {
 	case events.EventOrderCreated:
 		return p.handleOrderCreated(event)
 	case events.EventOrderAmended:
 		return p.handleOrderAmended(event)
+	case events.EventOrderReplaced:
+		return p.handleOrderReplaced(event)
 	case events.EventOrderApprovalRequested:
 		return p.handleOrderApprovalRequested(event)
+	case events.EventOrderApprovalRecorded:
+		return p.handleOrderApprovalRecorded(event)
 	case events.EventOrderApproved:
 		return p.handleOrderApproved(event)
 	case events.EventOrderRejected:
@@ -73,12 +110,52 @@ func (p *OMSProjection) handleEvent(event *events.Event) error {
 		return p.handleOrderFullyFilled(event)
 	case events.EventSettlementBooked:
 		return p.handleSettlementBooked(event)
+	case events.EventSettlementSettled:
+		return p.handleSettlementSettled(event)
 	case events.EventOrderCancelled:
 		return p.handleOrderCancelled(event)
+	case events.EventOrderExpired:
+		return p.handleOrderExpired(event)
 	case events.EventRuleEvaluated:
 		return p.handleRuleEvaluated(event)
 	case events.EventOrderBlockedByCompliance:
 		return p.handleOrderBlockedByCompliance(event)
+	case events.EventApprovalPolicyCreated, events.EventApprovalPolicyUpdated:
+		return p.handleApprovalPolicyUpserted(event)
+	case events.EventApprovalPolicyDeleted:
+		return p.handleApprovalPolicyDeleted(event)
+	case events.EventRiskLimitCreated:
+		return p.handleRiskLimitCreated(event)
+	case events.EventRiskLimitUpdated:
+		return p.handleRiskLimitUpdated(event)
+	case events.EventRiskLimitDeleted:
+		return p.handleRiskLimitDeleted(event)
+	case events.EventTradingHaltActivated:
+		return p.handleTradingHaltActivated(event)
+	case events.EventTradingHaltReleased:
+		return p.handleTradingHaltReleased(event)
+	case events.EventOrderRateLimitSet:
+		return p.handleOrderRateLimitSet(event)
+	case events.EventOrderSubmissionRefused:
+		return p.handleOrderSubmissionRefused(event)
+	case events.EventBlockOrderCreated:
+		return p.handleBlockOrderCreated(event)
+	case events.EventBlockOrderSentToEMS:
+		return p.handleBlockOrderSentToEMS(event)
+	case events.EventBlockOrderAllocated:
+		return p.handleBlockOrderAllocated(event)
+	case events.EventAllocationBooked:
+		return p.handleAllocationBooked(event)
+	case events.EventCrossTradeExecuted:
+		return p.handleCrossTradeExecuted(event)
+	case events.EventUploadBatchReceived:
+		return p.handleUploadBatchReceived(event)
+	case events.EventUploadBatchValidated:
+		return p.handleUploadBatchValidated(event)
+	case events.EventUploadBatchConfirming:
+		return p.handleUploadBatchConfirming(event)
+	case events.EventUploadBatchConfirmed:
+		return p.handleUploadBatchConfirmed(event)
 	}
 
 	return nil
@@ -91,9 +168,11 @@ func (p *OMSProjection) handleOrderCreated(event *events.Event) error {
 	query := `
 		INSERT INTO orders (
 			"orderId", "accountId", "instrumentId", side, quantity, "orderType",
-			"limitPrice", "curveSpreadBp", "timeInForce", state,
-			"batchId", "createdAt", "createdBy", "updatedAt", "lastStateChangeAt"
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+			"limitPrice", "curveSpreadBp", "timeInForce", "expireAt", state,
+			"batchId", "blockId", "chainId", "chainVersion", "replacesOrderId",
+			"createdAt", "createdBy", "updatedAt", "lastStateChangeAt",
+			"limitYield", "limitPricedAsOf"
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10::timestamp, $11, $12, $13, COALESCE($14, $1), COALESCE($15, 1), $16, $17, $18, $19, $20, $21, $22::timestamp)
 	`
 
 	_, err := p.db.Exec(
@@ -107,19 +186,30 @@ func (p *OMSProjection) handleOrderCreated(event *events.Event) error {
 		payload["limitPrice"],
 		payload["curveSpreadBp"],
 		payload["timeInForce"],
+		payload["expireAt"],
 		payload["state"],
 		payload["batchId"],
+		payload["blockId"],
+		payload["chainId"],
+		payload["chainVersion"],
+		payload["replacesOrderId"],
 		event.OccurredAt,
 		payload["createdBy"],
 		event.OccurredAt,
 		event.OccurredAt,
+		payload["limitYield"],
+		payload["limitPricedAsOf"],
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to insert order: %w", err)
 	}
 
-	return nil
+	changeType := "CREATED"
+	if _, ok := payload["replacesOrderId"]; ok {
+		changeType = "REPLACEMENT"
+	}
+	return p.recordOrderHistory(event, changeType, nil)
 }
 
 // handleOrderAmended updates an order
@@ -149,6 +239,18 @@ func (p *OMSProjection) handleOrderAmended(event *events.Event) error {
 		argPos++
 	}
 
+	if limitYield, ok := payload["limitYield"]; ok {
+		updates = append(updates, fmt.Sprintf(`"limitYield" = $%d`, argPos))
+		args = append(args, limitYield)
+		argPos++
+	}
+
+	if limitPricedAsOf, ok := payload["limitPricedAsOf"]; ok {
+		updates = append(updates, fmt.Sprintf(`"limitPricedAsOf" = $%d::timestamp`, argPos))
+		args = append(args, limitPricedAsOf)
+		argPos++
+	}
+
 	if curveSpreadBp, ok := payload["curveSpreadBp"]; ok {
 		updates = append(updates, fmt.Sprintf(`"curveSpreadBp" = $%d`, argPos))
 		args = append(args, curveSpreadBp)
@@ -174,6 +276,84 @@ func (p *OMSProjection) handleOrderAmended(event *events.Event) error {
 		return fmt.Errorf("failed to update order: %w", err)
 	}
 
+	changes := payload["changes"]
+	if changes == nil {
+		// Amendments recorded before field-level changes were captured only
+		// carry the new values
+		amended := []map[string]interface{}{}
+		for _, field := range []string{"quantity", "orderType", "limitPrice", "curveSpreadBp"} {
+			if value, ok := payload[field]; ok {
+				amended = append(amended, map[string]interface{}{"field": field, "from": nil, "to": value})
+			}
+		}
+		changes = amended
+	}
+
+	return p.recordOrderHistory(event, "AMENDED", changes)
+}
+
+// handleOrderReplaced retires an order that a new version has replaced
+func (p *OMSProjection) handleOrderReplaced(event *events.Event) error {
+	payload := event.Payload
+	orderID := payload["orderId"].(string)
+
+	query := `
+		UPDATE orders
+		SET state = 'REPLACED', "replacedByOrderId" = $1, "replacedAt" = $2,
+			"lastStateChangeAt" = $3, "updatedAt" = $4
+		WHERE "orderId" = $5
+	`
+
+	_, err := p.db.Exec(query, payload["replacedByOrderId"], event.OccurredAt, event.OccurredAt, event.OccurredAt, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to mark order replaced: %w", err)
+	}
+
+	return p.recordOrderHistory(event, "REPLACED", payload["changes"])
+}
+
+// recordOrderHistory appends an entry to an order's version history. The
+// entry is keyed by event ID so a redelivered event is recorded once.
+func (p *OMSProjection) recordOrderHistory(event *events.Event, changeType string, changes interface{}) error {
+	if changes == nil {
+		changes = []interface{}{}
+	}
+	changesJSON, err := jsonFromPayload(changes)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO order_history (
+			"entryId", "orderId", "chainId", "chainVersion", "changeType", changes,
+			"relatedOrderId", reason, actor, "occurredAt"
+		)
+		SELECT $1, "orderId", "chainId", "chainVersion", $2, $3, $4, $5, $6, $7
+		FROM orders
+		WHERE "orderId" = $8
+		ON CONFLICT ("entryId") DO NOTHING
+	`
+
+	relatedOrderID := event.Payload["replacedByOrderId"]
+	if changeType == "REPLACEMENT" {
+		relatedOrderID = event.Payload["replacesOrderId"]
+	}
+
+	_, err = p.db.Exec(
+		query,
+		event.EventID,
+		changeType,
+		changesJSON,
+		relatedOrderID,
+		event.Payload["reason"],
+		event.Actor.ActorID,
+		event.OccurredAt,
+		event.Aggregate.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record order history: %w", err)
+	}
+
 	return nil
 }
 
@@ -184,11 +364,28 @@ func (p *OMSProjection) handleOrderApprovalRequested(event *events.Event) error
 
 	query := `
 		UPDATE orders
-		SET state = 'APPROVAL_PENDING', "lastStateChangeAt" = $1, "updatedAt" = $2
+		SET state = 'APPROVAL_PENDING', "lastStateChangeAt" = $1, "updatedAt" = $2,
+			"requiredApprovals" = COALESCE($3::int, 1), approvals = '[]'::jsonb
+		WHERE "orderId" = $4
+	`
+
+	_, err := p.db.Exec(query, event.OccurredAt, event.OccurredAt, payload["requiredApprovals"], orderID)
+	return err
+}
+
+// handleOrderApprovalRecorded records one approval of an order that needs several
+func (p *OMSProjection) handleOrderApprovalRecorded(event *events.Event) error {
+	payload := event.Payload
+	orderID := payload["orderId"].(string)
+
+	query := `
+		UPDATE orders
+		SET approvals = approvals || jsonb_build_array(jsonb_build_object('approvedBy', $1::text, 'approvedAt', $2::timestamp)),
+			"updatedAt" = $2
 		WHERE "orderId" = $3
 	`
 
-	_, err := p.db.Exec(query, event.OccurredAt, event.OccurredAt, orderID)
+	_, err := p.db.Exec(query, payload["approvedBy"], event.OccurredAt, orderID)
 	return err
 }
 
@@ -199,11 +396,13 @@ func (p *OMSProjection) handleOrderApproved(event *events.Event) error {
 
 	query := `
 		UPDATE orders
-		SET state = 'APPROVED', "lastStateChangeAt" = $1, "updatedAt" = $2
-		WHERE "orderId" = $3
+		SET state = 'APPROVED', "lastStateChangeAt" = $1, "updatedAt" = $2,
+			"approvedBy" = $3::text, "approvedAt" = $4::timestamp,
+			approvals = approvals || jsonb_build_array(jsonb_build_object('approvedBy', $3::text, 'approvedAt', $4::timestamp))
+		WHERE "orderId" = $5
 	`
 
-	_, err := p.db.Exec(query, event.OccurredAt, event.OccurredAt, orderID)
+	_, err := p.db.Exec(query, event.OccurredAt, event.OccurredAt, payload["approvedBy"], event.OccurredAt, orderID)
 	return err
 }
 
@@ -214,26 +413,28 @@ func (p *OMSProjection) handleOrderRejected(event *events.Event) error {
 
 	query := `
 		UPDATE orders
-		SET state = 'REJECTED', "lastStateChangeAt" = $1, "updatedAt" = $2
-		WHERE "orderId" = $3
+		SET state = 'REJECTED', "lastStateChangeAt" = $1, "updatedAt" = $2,
+			"rejectedBy" = $3, "rejectedAt" = $4, "rejectionReason" = $5
+		WHERE "orderId" = $6
 	`
 
-	_, err := p.db.Exec(query, event.OccurredAt, event.OccurredAt, orderID)
+	_, err := p.db.Exec(query, event.OccurredAt, event.OccurredAt, payload["rejectedBy"], event.OccurredAt, payload["reason"], orderID)
 	return err
 }
 
-// handleOrderSentToEMS updates order state to SENT
+// handleOrderSentToEMS updates order state to SENT, recording the netting
+// run the order was sent through, if any
 func (p *OMSProjection) handleOrderSentToEMS(event *events.Event) error {
 	payload := event.Payload
 	orderID := payload["orderId"].(string)
 
 	query := `
 		UPDATE orders
-		SET state = 'SENT', "lastStateChangeAt" = $1, "updatedAt" = $2, "sentToEmsAt" = $3
-		WHERE "orderId" = $4
+		SET state = 'SENT', "lastStateChangeAt" = $1, "updatedAt" = $2, "sentToEmsAt" = $3, "nettingId" = $4
+		WHERE "orderId" = $5
 	`
 
-	_, err := p.db.Exec(query, event.OccurredAt, event.OccurredAt, event.OccurredAt, orderID)
+	_, err := p.db.Exec(query, event.OccurredAt, event.OccurredAt, event.OccurredAt, payload["nettingId"], orderID)
 	return err
 }
 
@@ -267,22 +468,66 @@ func (p *OMSProjection) handleOrderFullyFilled(event *events.Event) error {
 	return err
 }
 
-// handleSettlementBooked updates order state to SETTLED
+// handleSettlementBooked settles orders booked before the settlement
+// lifecycle, which settled as soon as they were booked. Orders now stay
+// FILLED until their settlement settles.
 func (p *OMSProjection) handleSettlementBooked(event *events.Event) error {
-	payload := event.Payload
-	orderID, ok := payload["orderId"].(string)
-	if !ok {
+	if !settledOnBooking(event.Payload) {
 		return nil
 	}
+	return p.settleOrders(event)
+}
+
+// handleSettlementSettled settles the order, or the block's child order, a
+// settlement was for. Partial settlements and failures leave it FILLED.
+func (p *OMSProjection) handleSettlementSettled(event *events.Event) error {
+	return p.settleOrders(event)
+}
+
+// settleOrders updates order state to SETTLED. Orders whose remainder was
+// cancelled or expired keep that state and only record settledAt. A legacy
+// block settlement settles every child order that received an allocation.
+func (p *OMSProjection) settleOrders(event *events.Event) error {
+	payload := event.Payload
+	orderIDs := []string{}
+	if orderID, ok := payload["orderId"].(string); ok {
+		orderIDs = append(orderIDs, orderID)
+	}
+	switch ids := payload["orderIds"].(type) {
+	case []string:
+		orderIDs = append(orderIDs, ids...)
+	case []interface{}:
+		for _, id := range ids {
+			if orderID, ok := id.(string); ok {
+				orderIDs = append(orderIDs, orderID)
+			}
+		}
+	}
 
 	query := `
 		UPDATE orders
-		SET state = 'SETTLED', "lastStateChangeAt" = $1, "updatedAt" = $2, "settledAt" = $3
+		SET state = CASE WHEN state = 'FILLED' THEN 'SETTLED'::order_state ELSE state END,
+		    "lastStateChangeAt" = CASE WHEN state = 'FILLED' THEN $1 ELSE "lastStateChangeAt" END,
+		    "updatedAt" = $2, "settledAt" = $3
 		WHERE "orderId" = $4
 	`
 
-	_, err := p.db.Exec(query, event.OccurredAt, event.OccurredAt, event.OccurredAt, orderID)
-	return err
+	for _, orderID := range orderIDs {
+		if _, err := p.db.Exec(query, event.OccurredAt, event.OccurredAt, event.OccurredAt, orderID); err != nil {
+			return err
+		}
+	}
+
+	if blockID, ok := payload["blockId"].(string); ok {
+		_, err := p.db.Exec(`
+			UPDATE block_orders
+			SET "settledAt" = $1, "updatedAt" = $2
+			WHERE "blockId" = $3
+		`, event.OccurredAt, event.OccurredAt, blockID)
+		return err
+	}
+
+	return nil
 }
 
 // handleOrderCancelled updates order state to CANCELLED
@@ -300,6 +545,21 @@ func (p *OMSProjection) handleOrderCancelled(event *events.Event) error {
 	return err
 }
 
+// handleOrderExpired updates order state to EXPIRED
+func (p *OMSProjection) handleOrderExpired(event *events.Event) error {
+	payload := event.Payload
+	orderID := payload["orderId"].(string)
+
+	query := `
+		UPDATE orders
+		SET state = 'EXPIRED', "lastStateChangeAt" = $1, "updatedAt" = $2, "expiredAt" = $3
+		WHERE "orderId" = $4
+	`
+
+	_, err := p.db.Exec(query, event.OccurredAt, event.OccurredAt, event.OccurredAt, orderID)
+	return err
+}
+
 // handleRuleEvaluated stores compliance result
 func (p *OMSProjection) handleRuleEvaluated(event *events.Event) error {
 	payload := event.Payload
@@ -354,3 +614,528 @@ func join(strs []string, sep string) string {
 	}
 	return result
 }
+
+// handleApprovalPolicyUpserted stores the latest definition of an approval policy
+func (p *OMSProjection) handleApprovalPolicyUpserted(event *events.Event) error {
+	payload := event.Payload
+	policyID, ok := payload["policyId"].(string)
+	if !ok || policyID == "" {
+		return nil
+	}
+
+	criteriaJSON, err := jsonFromPayload(payload["criteria"])
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO approval_policies (
+			"policyId", name, description, status, scope, "scopeId", criteria,
+			"requiredApprovals", version, "createdAt", "createdBy", "updatedAt", "updatedBy"
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $10, $11)
+		ON CONFLICT ("policyId") DO UPDATE SET
+			name = EXCLUDED.name,
+			description = EXCLUDED.description,
+			status = EXCLUDED.status,
+			scope = EXCLUDED.scope,
+			"scopeId" = EXCLUDED."scopeId",
+			criteria = EXCLUDED.criteria,
+			"requiredApprovals" = EXCLUDED."requiredApprovals",
+			version = EXCLUDED.version,
+			"updatedAt" = EXCLUDED."updatedAt",
+			"updatedBy" = EXCLUDED."updatedBy"
+	`
+
+	_, err = p.db.Exec(
+		query,
+		policyID,
+		payload["name"],
+		payload["description"],
+		payload["status"],
+		payload["scope"],
+		payload["scopeId"],
+		criteriaJSON,
+		payload["requiredApprovals"],
+		payload["version"],
+		event.OccurredAt,
+		payload["updatedBy"],
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert approval policy: %w", err)
+	}
+
+	return nil
+}
+
+// handleApprovalPolicyDeleted marks an approval policy as deleted
+func (p *OMSProjection) handleApprovalPolicyDeleted(event *events.Event) error {
+	payload := event.Payload
+	policyID, ok := payload["policyId"].(string)
+	if !ok || policyID == "" {
+		return nil
+	}
+
+	_, err := p.db.Exec(`
+		UPDATE approval_policies
+		SET status = 'DELETED', version = $1, "updatedAt" = $2, "updatedBy" = $3
+		WHERE "policyId" = $4
+	`, payload["version"], event.OccurredAt, payload["deletedBy"], policyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete approval policy: %w", err)
+	}
+
+	return nil
+}
+
+// trackRiskExposure keeps the running risk utilization: an order's exposure
+// is recorded against its trader when it is created, and the order stops
+// counting as open once it is filled or leaves the book
+func (p *OMSProjection) trackRiskExposure(event *events.Event) error {
+	payload := event.Payload
+	orderID, _ := payload["orderId"].(string)
+	if orderID == "" {
+		return nil
+	}
+
+	switch event.EventType {
+	case events.EventOrderCreated:
+		exposure, ok := payload["riskExposure"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		_, err := p.db.Exec(`
+			INSERT INTO risk_order_exposures ("orderId", "actorId", "tradeDate", notional, dv01, open, "createdAt")
+			VALUES ($1, $2, $3, $4, $5, true, $6)
+			ON CONFLICT ("orderId") DO NOTHING
+		`, orderID, payload["createdBy"], exposure["tradeDate"], exposure["notional"], exposure["dv01"], event.OccurredAt)
+		if err != nil {
+			return fmt.Errorf("failed to record risk exposure: %w", err)
+		}
+	case events.EventOrderFullyFilled, events.EventOrderCancelled, events.EventOrderRejected,
+		events.EventOrderExpired, events.EventOrderReplaced, events.EventOrderBlockedByCompliance:
+		_, err := p.db.Exec(`
+			UPDATE risk_order_exposures SET open = false, "closedAt" = $1
+			WHERE "orderId" = $2 AND open
+		`, event.OccurredAt, orderID)
+		if err != nil {
+			return fmt.Errorf("failed to close risk exposure: %w", err)
+		}
+	}
+	return nil
+}
+
+// handleRiskLimitCreated stores a new risk limit
+func (p *OMSProjection) handleRiskLimitCreated(event *events.Event) error {
+	payload := event.Payload
+	limitID, ok := payload["limitId"].(string)
+	if !ok || limitID == "" {
+		return nil
+	}
+
+	_, err := p.db.Exec(`
+		INSERT INTO risk_limits (
+			"limitId", "limitType", scope, "scopeId", "maxValue", status, version,
+			"createdAt", "createdBy", "updatedAt", "updatedBy"
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $8, $9)
+		ON CONFLICT ("limitId") DO NOTHING
+	`, limitID, payload["limitType"], payload["scope"], nullableString(payload["scopeId"]), payload["maxValue"],
+		payload["status"], payload["version"], event.OccurredAt, payload["updatedBy"])
+	if err != nil {
+		return fmt.Errorf("failed to insert risk limit: %w", err)
+	}
+
+	return nil
+}
+
+// handleRiskLimitUpdated stores a risk limit's new maximum
+func (p *OMSProjection) handleRiskLimitUpdated(event *events.Event) error {
+	payload := event.Payload
+	limitID, ok := payload["limitId"].(string)
+	if !ok || limitID == "" {
+		return nil
+	}
+
+	_, err := p.db.Exec(`
+		UPDATE risk_limits
+		SET "maxValue" = $1, version = $2, "updatedAt" = $3, "updatedBy" = $4
+		WHERE "limitId" = $5
+	`, payload["maxValue"], payload["version"], event.OccurredAt, payload["updatedBy"], limitID)
+	if err != nil {
+		return fmt.Errorf("failed to update risk limit: %w", err)
+	}
+
+	return nil
+}
+
+// handleRiskLimitDeleted marks a risk limit as deleted
+func (p *OMSProjection) handleRiskLimitDeleted(event *events.Event) error {
+	payload := event.Payload
+	limitID, ok := payload["limitId"].(string)
+	if !ok || limitID == "" {
+		return nil
+	}
+
+	_, err := p.db.Exec(`
+		UPDATE risk_limits
+		SET status = 'DELETED', version = $1, "updatedAt" = $2, "updatedBy" = $3
+		WHERE "limitId" = $4
+	`, payload["version"], event.OccurredAt, payload["deletedBy"], limitID)
+	if err != nil {
+		return fmt.Errorf("failed to delete risk limit: %w", err)
+	}
+
+	return nil
+}
+
+// handleTradingHaltActivated records a new kill switch
+func (p *OMSProjection) handleTradingHaltActivated(event *events.Event) error {
+	payload := event.Payload
+	haltID, ok := payload["haltId"].(string)
+	if !ok || haltID == "" {
+		return nil
+	}
+
+	activatedAt, err := parseTime(payload["activatedAt"])
+	if err != nil || activatedAt.IsZero() {
+		activatedAt = event.OccurredAt
+	}
+
+	_, err = p.db.Exec(`
+		INSERT INTO trading_halts (
+			"haltId", scope, "scopeId", reason, status, "activatedBy", "activatedAt"
+		) VALUES ($1, $2, $3, $4, 'ACTIVE', $5, $6)
+		ON CONFLICT ("haltId") DO NOTHING
+	`, haltID, payload["scope"], nullableString(payload["scopeId"]), payload["reason"], payload["activatedBy"], activatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert trading halt: %w", err)
+	}
+
+	return nil
+}
+
+// handleTradingHaltReleased marks a kill switch as released
+func (p *OMSProjection) handleTradingHaltReleased(event *events.Event) error {
+	payload := event.Payload
+	haltID, ok := payload["haltId"].(string)
+	if !ok || haltID == "" {
+		return nil
+	}
+
+	releasedAt, err := parseTime(payload["releasedAt"])
+	if err != nil || releasedAt.IsZero() {
+		releasedAt = event.OccurredAt
+	}
+
+	_, err = p.db.Exec(`
+		UPDATE trading_halts
+		SET status = 'RELEASED', "releasedBy" = $1, "releasedAt" = $2, "releaseReason" = $3
+		WHERE "haltId" = $4
+	`, payload["releasedBy"], releasedAt, payload["releaseReason"], haltID)
+	if err != nil {
+		return fmt.Errorf("failed to release trading halt: %w", err)
+	}
+
+	return nil
+}
+
+// handleOrderRateLimitSet stores an actor's order rate limit
+func (p *OMSProjection) handleOrderRateLimitSet(event *events.Event) error {
+	payload := event.Payload
+	actorID, ok := payload["actorId"].(string)
+	if !ok || actorID == "" {
+		return nil
+	}
+
+	_, err := p.db.Exec(`
+		INSERT INTO order_rate_limits ("actorId", "maxOrders", "windowSeconds", "setBy", "updatedAt")
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT ("actorId") DO UPDATE SET
+			"maxOrders" = EXCLUDED."maxOrders",
+			"windowSeconds" = EXCLUDED."windowSeconds",
+			"setBy" = EXCLUDED."setBy",
+			"updatedAt" = EXCLUDED."updatedAt"
+	`, actorID, payload["maxOrders"], payload["windowSeconds"], payload["setBy"], event.OccurredAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert order rate limit: %w", err)
+	}
+
+	return nil
+}
+
+// handleOrderSubmissionRefused records an order refused by a halt or a rate limit
+func (p *OMSProjection) handleOrderSubmissionRefused(event *events.Event) error {
+	payload := event.Payload
+
+	refusedAt, err := parseTime(payload["refusedAt"])
+	if err != nil || refusedAt.IsZero() {
+		refusedAt = event.OccurredAt
+	}
+	accountIDsJSON, err := jsonFromPayload(payload["accountIds"])
+	if err != nil {
+		return err
+	}
+
+	_, err = p.db.Exec(`
+		INSERT INTO order_submission_refusals (
+			"refusalId", "actorId", "accountIds", reason, "haltId", detail, "refusedAt"
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT ("refusalId") DO NOTHING
+	`, event.EventID, payload["actorId"], accountIDsJSON,
+		payload["reason"], nullableString(payload["haltId"]), payload["detail"], refusedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert order submission refusal: %w", err)
+	}
+
+	return nil
+}
+
+// handleBlockOrderCreated creates a new block order record
+func (p *OMSProjection) handleBlockOrderCreated(event *events.Event) error {
+	payload := event.Payload
+
+	allocationsJSON, err := jsonFromPayload(payload["allocations"])
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO block_orders (
+			"blockId", "instrumentId", side, quantity, "orderType", "limitPrice",
+			"curveSpreadBp", "timeInForce", "expireAt", "lotSize", allocations, state,
+			"createdAt", "createdBy", "updatedAt", "limitYield"
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::timestamp, $10, $11, $12, $13, $14, $15, $16)
+	`
+
+	_, err = p.db.Exec(
+		query,
+		payload["blockId"],
+		payload["instrumentId"],
+		payload["side"],
+		payload["quantity"],
+		payload["orderType"],
+		payload["limitPrice"],
+		payload["curveSpreadBp"],
+		payload["timeInForce"],
+		payload["expireAt"],
+		payload["lotSize"],
+		allocationsJSON,
+		payload["state"],
+		event.OccurredAt,
+		payload["createdBy"],
+		event.OccurredAt,
+		payload["limitYield"],
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert block order: %w", err)
+	}
+
+	return nil
+}
+
+// handleBlockOrderSentToEMS updates block state to SENT with the quantity actually sent
+func (p *OMSProjection) handleBlockOrderSentToEMS(event *events.Event) error {
+	payload := event.Payload
+	blockID := payload["blockId"].(string)
+
+	query := `
+		UPDATE block_orders
+		SET state = 'SENT', quantity = $1, "sentToEmsAt" = $2, "updatedAt" = $3
+		WHERE "blockId" = $4
+	`
+
+	_, err := p.db.Exec(query, payload["quantity"], event.OccurredAt, event.OccurredAt, blockID)
+	return err
+}
+
+// handleBlockOrderAllocated records the block's fill once it is allocated
+func (p *OMSProjection) handleBlockOrderAllocated(event *events.Event) error {
+	payload := event.Payload
+	blockID := payload["blockId"].(string)
+
+	query := `
+		UPDATE block_orders
+		SET state = $1, "filledQuantity" = $2, "avgFillPrice" = $3, "updatedAt" = $4
+		WHERE "blockId" = $5
+	`
+
+	_, err := p.db.Exec(query, payload["state"], payload["filledQuantity"], payload["avgFillPrice"], event.OccurredAt, blockID)
+	return err
+}
+
+// handleAllocationBooked stores one account's share of a block execution
+func (p *OMSProjection) handleAllocationBooked(event *events.Event) error {
+	payload := event.Payload
+
+	settlementDate, err := parseTime(payload["settlementDate"])
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO allocations (
+			"allocationId", "blockId", "executionId", "orderId", "accountId", "instrumentId",
+			side, quantity, "targetQuantity", price, "settlementDate", "createdAt",
+			commission, fees, markup
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		ON CONFLICT ("allocationId") DO NOTHING
+	`
+
+	_, err = p.db.Exec(
+		query,
+		payload["allocationId"],
+		payload["blockId"],
+		payload["executionId"],
+		payload["orderId"],
+		payload["accountId"],
+		payload["instrumentId"],
+		payload["side"],
+		payload["quantity"],
+		payload["targetQuantity"],
+		payload["price"],
+		settlementDate,
+		event.OccurredAt,
+		parseFloat(payload["commission"]),
+		parseFloat(payload["fees"]),
+		parseFloat(payload["markup"]),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert allocation: %w", err)
+	}
+
+	return nil
+}
+
+// handleUploadBatchReceived records an uploaded order file
+func (p *OMSProjection) handleUploadBatchReceived(event *events.Event) error {
+	payload := event.Payload
+
+	query := `
+		INSERT INTO upload_batches (
+			"batchId", "fileName", format, status, "rowCount", "uploadedBy", "createdAt", "updatedAt"
+		) VALUES ($1, $2, $3, 'RECEIVED', $4, $5, $6, $7)
+		ON CONFLICT ("batchId") DO NOTHING
+	`
+
+	_, err := p.db.Exec(
+		query,
+		event.Aggregate.ID,
+		payload["fileName"],
+		payload["format"],
+		payload["rowCount"],
+		payload["uploadedBy"],
+		event.OccurredAt,
+		event.OccurredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert upload batch: %w", err)
+	}
+
+	return nil
+}
+
+// handleUploadBatchValidated stores each uploaded row with its validation errors
+func (p *OMSProjection) handleUploadBatchValidated(event *events.Event) error {
+	payload := event.Payload
+
+	rowsJSON, err := jsonFromPayload(payload["rows"])
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE upload_batches
+		SET status = 'VALIDATED', rows = $1, "validCount" = $2, "errorCount" = $3, "updatedAt" = $4
+		WHERE "batchId" = $5
+	`
+
+	_, err = p.db.Exec(query, rowsJSON, payload["validCount"], payload["errorCount"], event.OccurredAt, event.Aggregate.ID)
+	return err
+}
+
+// handleUploadBatchConfirming marks a batch whose orders are being created
+func (p *OMSProjection) handleUploadBatchConfirming(event *events.Event) error {
+	query := `
+		UPDATE upload_batches
+		SET status = 'CONFIRMING', "confirmedBy" = $1, "updatedAt" = $2
+		WHERE "batchId" = $3
+	`
+
+	_, err := p.db.Exec(query, event.Payload["confirmedBy"], event.OccurredAt, event.Aggregate.ID)
+	return err
+}
+
+// handleUploadBatchConfirmed records the orders created from an upload batch
+func (p *OMSProjection) handleUploadBatchConfirmed(event *events.Event) error {
+	payload := event.Payload
+
+	ordersJSON, err := jsonFromPayload(payload["orders"])
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE upload_batches
+		SET status = 'CONFIRMED', orders = $1, "createdCount" = $2, "failedCount" = $3,
+			"confirmedBy" = $4, "confirmedAt" = $5, "updatedAt" = $6
+		WHERE "batchId" = $7
+	`
+
+	_, err = p.db.Exec(
+		query,
+		ordersJSON,
+		payload["createdCount"],
+		payload["failedCount"],
+		payload["confirmedBy"],
+		event.OccurredAt,
+		event.OccurredAt,
+		event.Aggregate.ID,
+	)
+	return err
+}
+
+// handleCrossTradeExecuted stores both legs of a buy and a sell crossed
+// internally by a netting run, one row per order
+func (p *OMSProjection) handleCrossTradeExecuted(event *events.Event) error {
+	payload := event.Payload
+
+	priceAsOf, err := parseTime(payload["priceAsOf"])
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO cross_trades (
+			"crossId", "nettingId", "orderId", "accountId", "instrumentId", side,
+			"counterpartyOrderId", "counterpartyAccountId", quantity, price, "priceAsOf", "executedAt"
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT ("crossId", "orderId") DO NOTHING
+	`
+
+	legs := []struct {
+		side, orderID, accountID, counterpartyOrderID, counterpartyAccountID interface{}
+	}{
+		{"BUY", payload["buyOrderId"], payload["buyAccountId"], payload["sellOrderId"], payload["sellAccountId"]},
+		{"SELL", payload["sellOrderId"], payload["sellAccountId"], payload["buyOrderId"], payload["buyAccountId"]},
+	}
+	for _, leg := range legs {
+		_, err := p.db.Exec(
+			query,
+			payload["crossId"],
+			payload["nettingId"],
+			leg.orderID,
+			leg.accountID,
+			payload["instrumentId"],
+			leg.side,
+			leg.counterpartyOrderID,
+			leg.counterpartyAccountID,
+			payload["quantity"],
+			payload["price"],
+			priceAsOf,
+			event.OccurredAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -17,17 +17,23 @@ import (
 
 // PMSProjection handles building PMS read models from events.
 type PMSProjection struct {
-	db       *sql.DB
-	eventBus *eventbus.EventBus
-	stopChan chan struct{}
+	db          *sql.DB
+	eventBus    *eventbus.EventBus
+	loader      eventbus.EventLoader
+	deadLetters *DeadLetterQueue
+	checkpoints *CheckpointStore
+	stopChan    chan struct{}
 }
 
 // NewPMSProjection creates a new PMS projection worker.
-func NewPMSProjection(db *sql.DB, eb *eventbus.EventBus) (*PMSProjection, error) {
+func NewPMSProjection(db *sql.DB, eb *eventbus.EventBus, loader eventbus.EventLoader) (*PMSProjection, error) {
 	return &PMSProjection{
-		db:       db,
-		eventBus: eb,
-		stopChan: make(chan struct{}),
+		db:          db,
+		eventBus:    eb,
+		loader:      loader,
+		deadLetters: NewDeadLetterQueue(db),
+		checkpoints: NewCheckpointStore(db),
+		stopChan:    make(chan struct{}),
 	}, nil
 }
 
@@ -36,17 +42,26 @@ func (p *PMSProjection) Start() {
 	subscriber, cleanup := p.eventBus.Subscribe("*", 1000)
 	defer cleanup()
 
+	retryTicker := time.NewTicker(deadLetterSweepInterval)
+	defer retryTicker.Stop()
+
 	fmt.Println("PMS Projection worker started")
 
+	if !catchUp(ProjectionPMS, subscriber, p.loader, p.checkpoints, p.apply, p.stopChan) {
+		fmt.Println("PMS Projection worker stopped")
+		return
+	}
+
 	for {
 		select {
-		case event := <-subscriber:
-			if event == nil {
-				continue
-			}
-			if err := p.handleEvent(event); err != nil {
-				fmt.Printf("Error handling PMS event %s: %v\n", event.EventType, err)
+		case event, ok := <-subscriber:
+			if !ok {
+				// The bus has closed the subscription
+				return
 			}
+			p.apply(event)
+		case <-retryTicker.C:
+			p.deadLetters.RetryDue(ProjectionPMS, p.handleEvent)
 		case <-p.stopChan:
 			fmt.Println("PMS Projection worker stopped")
 			return
@@ -54,6 +69,16 @@ func (p *PMSProjection) Start() {
 	}
 }
 
+// apply handles an event and advances the projection's checkpoint if it was applied
+func (p *PMSProjection) apply(event *events.Event) {
+	if err := p.deadLetters.Handle(ProjectionPMS, "PMSProjection."+event.EventType, event, p.handleEvent); err != nil {
+		return
+	}
+	if err := p.checkpoints.Advance(ProjectionPMS, event.Position); err != nil {
+		fmt.Printf("%s projection checkpoint error: %v\n", ProjectionPMS, err)
+	}
+}
+
 // Stop stops the projection worker.
 func (p *PMSProjection) Stop() {
 	close(p.stopChan)
@@ -63,6 +88,10 @@ func (p *PMSProjection) handleEvent(event *events.Event) error {
 	switch event.EventType {
 	case events.EventSettlementBooked:
 		return p.handleSettlementBooked(event)
+	case events.EventAllocationBooked:
+		return p.handleAllocationBooked(event)
+	case events.EventSettlementSettled, events.EventSettlementPartiallySettled:
+		return p.handleSettlementSettled(event)
 	case events.EventTargetSet:
 		return p.handleTargetSet(event)
 	case events.EventProposalGenerated:
@@ -71,6 +100,10 @@ func (p *PMSProjection) handleEvent(event *events.Event) error {
 		return p.handleProposalApproved(event)
 	case events.EventProposalSentToOMS:
 		return p.handleProposalSentToOMS(event)
+	case events.EventAccountCashAdjusted:
+		return p.handleAccountCashAdjusted(event)
+	case events.EventAccountShortSalesUpdated:
+		return p.handleAccountShortSalesUpdated(event)
 	}
 
 	return nil
@@ -81,6 +114,18 @@ func (p *PMSProjection) handleSettlementBooked(event *events.Event) error {
 	if !ok || executionID == "" {
 		return nil
 	}
+	// Block executions book positions per account from AllocationBooked
+	if blockID := stringify(event.Payload["blockId"]); blockID != "" {
+		return nil
+	}
+	// Cross trades never reach the executions table, so book from the payload
+	if crossID := stringify(event.Payload["crossId"]); crossID != "" {
+		execution, err := executionFromPayload(event.Payload)
+		if err != nil {
+			return err
+		}
+		return p.applyExecution(event, executionID, execution)
+	}
 
 	execution, err := p.fetchExecutionWithRetry(executionID)
 	if err != nil {
@@ -90,7 +135,77 @@ func (p *PMSProjection) handleSettlementBooked(event *events.Event) error {
 		}
 		execution = payloadExecution
 	}
+	// The settlement carries the charges and amounts; the execution row may
+	// not have them yet
+	execution.charges = parseFloat(event.Payload["totalCharges"])
+	execution.netAmount = parseFloat(event.Payload["netSettlementAmount"])
+
+	return p.applyExecution(event, executionID, execution)
+}
+
+// handleAllocationBooked books one account's share of a block execution
+func (p *PMSProjection) handleAllocationBooked(event *events.Event) error {
+	payload := event.Payload
+	executionID := stringify(payload["executionId"])
+	execution := executionRecord{
+		accountID:      stringify(payload["accountId"]),
+		instrumentID:   stringify(payload["instrumentId"]),
+		side:           stringify(payload["side"]),
+		filledQuantity: parseFloat(payload["quantity"]),
+		avgFillPrice:   parseFloat(payload["price"]),
+		charges:        parseFloat(payload["totalCharges"]),
+		netAmount:      parseFloat(payload["netSettlementAmount"]),
+	}
+	if execution.accountID == "" || execution.instrumentID == "" || execution.filledQuantity == 0 {
+		return nil
+	}
+
+	return p.applyExecution(event, executionID, execution)
+}
+
+// handleSettlementSettled moves the quantity a settlement settled into the
+// position's settled quantity
+func (p *PMSProjection) handleSettlementSettled(event *events.Event) error {
+	payload := event.Payload
+	accountID := stringify(payload["accountId"])
+	instrumentID := stringify(payload["instrumentId"])
+	quantity := parseFloat(payload["quantity"])
+	if accountID == "" || instrumentID == "" || quantity == 0 {
+		return nil
+	}
+	if stringify(payload["side"]) == "SELL" {
+		quantity = -quantity
+	}
+
+	_, err := p.db.Exec(`
+		INSERT INTO positions ("accountId", "instrumentId", quantity, "avgCost", "marketValue", duration, dv01, "settledQuantity", "updatedAt")
+		VALUES ($1, $2, 0, 0, 0, 0, 0, $3, $4)
+		ON CONFLICT ("accountId", "instrumentId")
+		DO UPDATE SET "settledQuantity" = positions."settledQuantity" + EXCLUDED."settledQuantity", "updatedAt" = EXCLUDED."updatedAt"
+	`, accountID, instrumentID, quantity, event.OccurredAt)
+	if err != nil {
+		return err
+	}
+	return p.deleteFlatPosition(accountID, instrumentID)
+}
+
+// deleteFlatPosition removes a position once nothing is held on trade date
+// and nothing is left to settle
+func (p *PMSProjection) deleteFlatPosition(accountID, instrumentID string) error {
+	_, err := p.db.Exec(`
+		DELETE FROM positions
+		WHERE "accountId" = $1 AND "instrumentId" = $2
+		  AND ABS(quantity) < 0.000001 AND ABS("settledQuantity") < 0.000001
+	`, accountID, instrumentID)
+	return err
+}
 
+// applyExecution folds a filled quantity into the account's trade-date
+// position and moves the account's cash for it, in one transaction so a
+// retried event never moves cash twice. The commission, fees and markup on a
+// BUY are part of its cost. Trades booked before the settlement lifecycle
+// were settled on booking, so they move the settled quantity too.
+func (p *PMSProjection) applyExecution(event *events.Event, executionID string, execution executionRecord) error {
 	instrument, err := p.fetchInstrument(execution.instrumentID)
 	if err != nil {
 		instrument = instrumentSnapshot{}
@@ -105,7 +220,8 @@ func (p *PMSProjection) handleSettlementBooked(event *events.Event) error {
 	}
 
 	log.Printf(
-		"PMS projection: settlement booked executionId=%s accountId=%s instrumentId=%s side=%s filled=%0.2f avgFill=%0.6f",
+		"PMS projection: %s executionId=%s accountId=%s instrumentId=%s side=%s filled=%0.2f avgFill=%0.6f",
+		event.EventType,
 		executionID,
 		execution.accountID,
 		execution.instrumentID,
@@ -114,7 +230,13 @@ func (p *PMSProjection) handleSettlementBooked(event *events.Event) error {
 		execution.avgFillPrice,
 	)
 
-	existing, err := p.fetchPosition(execution.accountID, execution.instrumentID)
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin position update: %w", err)
+	}
+	defer tx.Rollback()
+
+	existing, err := fetchPosition(tx, execution.accountID, execution.instrumentID)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return err
 	}
@@ -125,10 +247,15 @@ func (p *PMSProjection) handleSettlementBooked(event *events.Event) error {
 	}
 
 	newQuantity := existing.quantity + quantity
+	newSettledQuantity := existing.settledQuantity
+	if settledOnBooking(event.Payload) {
+		newSettledQuantity += quantity
+	}
 
 	newAvgCost := existing.avgCost
 	if quantity > 0 {
-		totalCost := existing.avgCost*existing.quantity + quantity*price
+		// avgCost is per 100 par, so dollar charges are scaled up to it
+		totalCost := existing.avgCost*existing.quantity + quantity*price + execution.charges*100
 		if newQuantity > 0 {
 			newAvgCost = totalCost / newQuantity
 		} else {
@@ -139,25 +266,31 @@ func (p *PMSProjection) handleSettlementBooked(event *events.Event) error {
 		newAvgCost = 0
 	}
 
-	if math.Abs(newQuantity) < 0.000001 {
-		_, err := p.db.Exec(`DELETE FROM positions WHERE "accountId" = $1 AND "instrumentId" = $2`, execution.accountID, execution.instrumentID)
+	if err := applyCashMovement(tx, execution); err != nil {
 		return err
 	}
 
+	if math.Abs(newQuantity) < 0.000001 && math.Abs(newSettledQuantity) < 0.000001 {
+		if _, err := tx.Exec(`DELETE FROM positions WHERE "accountId" = $1 AND "instrumentId" = $2`, execution.accountID, execution.instrumentID); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
 	marketValue := newQuantity * price
 	duration := instrument.askDuration
 	dv01 := marketValue * duration * 0.0001
 
 	query := `
-		INSERT INTO positions ("accountId", "instrumentId", quantity, "avgCost", "marketValue", duration, dv01, "updatedAt")
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO positions ("accountId", "instrumentId", quantity, "avgCost", "marketValue", duration, dv01, "settledQuantity", "updatedAt")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		ON CONFLICT ("accountId", "instrumentId")
 		DO UPDATE SET quantity = EXCLUDED.quantity, "avgCost" = EXCLUDED."avgCost",
 			"marketValue" = EXCLUDED."marketValue", duration = EXCLUDED.duration,
-			dv01 = EXCLUDED.dv01, "updatedAt" = EXCLUDED."updatedAt"
+			dv01 = EXCLUDED.dv01, "settledQuantity" = EXCLUDED."settledQuantity", "updatedAt" = EXCLUDED."updatedAt"
 	`
 
-	_, err = p.db.Exec(
+	_, err = tx.Exec(
 		query,
 		execution.accountID,
 		execution.instrumentID,
@@ -166,8 +299,12 @@ func (p *PMSProjection) handleSettlementBooked(event *events.Event) error {
 		marketValue,
 		duration,
 		dv01,
+		newSettledQuantity,
 		event.OccurredAt,
 	)
+	if err == nil {
+		err = tx.Commit()
+	}
 	if err == nil {
 		log.Printf(
 			"PMS projection: position upserted accountId=%s instrumentId=%s quantity=%0.2f avgCost=%0.6f mv=%0.2f duration=%0.4f",
@@ -183,6 +320,66 @@ func (p *PMSProjection) handleSettlementBooked(event *events.Event) error {
 	return err
 }
 
+// applyCashMovement pays for a BUY and credits a SELL with the net
+// settlement amount: principal and accrued interest, with the charges added
+// to a BUY and taken off a SELL. Settlements booked before net amounts were
+// recorded move the principal at the fill price, par times the per-100 price
+// over 100, with the charges. Accounts that do not track cash keep a NULL
+// balance.
+func applyCashMovement(tx *sql.Tx, execution executionRecord) error {
+	amount := execution.netAmount
+	if amount == 0 {
+		amount = execution.filledQuantity * execution.avgFillPrice / 100
+		if amount == 0 {
+			return nil
+		}
+		if execution.side == "BUY" {
+			amount += execution.charges
+		} else {
+			amount -= execution.charges
+		}
+	}
+	if execution.side == "BUY" {
+		amount = -amount
+	}
+
+	_, err := tx.Exec(
+		`UPDATE accounts SET "cashBalance" = "cashBalance" + $2 WHERE "accountId" = $1 AND "cashBalance" IS NOT NULL`,
+		execution.accountID,
+		amount,
+	)
+	return err
+}
+
+func (p *PMSProjection) handleAccountCashAdjusted(event *events.Event) error {
+	accountID := stringify(event.Payload["accountId"])
+	if accountID == "" {
+		return nil
+	}
+
+	_, err := p.db.Exec(
+		`UPDATE accounts SET "cashBalance" = COALESCE("cashBalance", 0) + $2 WHERE "accountId" = $1`,
+		accountID,
+		parseFloat(event.Payload["amount"]),
+	)
+	return err
+}
+
+func (p *PMSProjection) handleAccountShortSalesUpdated(event *events.Event) error {
+	accountID := stringify(event.Payload["accountId"])
+	if accountID == "" {
+		return nil
+	}
+	allowShortSales, _ := event.Payload["allowShortSales"].(bool)
+
+	_, err := p.db.Exec(
+		`UPDATE accounts SET "allowShortSales" = $2 WHERE "accountId" = $1`,
+		accountID,
+		allowShortSales,
+	)
+	return err
+}
+
 func (p *PMSProjection) handleTargetSet(event *events.Event) error {
 	payload := event.Payload
 
@@ -334,6 +531,8 @@ type executionRecord struct {
 	side           string
 	filledQuantity float64
 	avgFillPrice   float64
+	charges        float64
+	netAmount      float64
 }
 
 type instrumentSnapshot struct {
@@ -342,8 +541,9 @@ type instrumentSnapshot struct {
 }
 
 type positionRecord struct {
-	quantity float64
-	avgCost  float64
+	quantity        float64
+	avgCost         float64
+	settledQuantity float64
 }
 
 func (p *PMSProjection) fetchExecution(executionID string) (executionRecord, error) {
@@ -420,15 +620,18 @@ func (p *PMSProjection) fetchInstrument(cusip string) (instrumentSnapshot, error
 	return record, nil
 }
 
-func (p *PMSProjection) fetchPosition(accountID, instrumentID string) (positionRecord, error) {
+// fetchPosition reads a position in a transaction, locking it until the
+// transaction ends
+func fetchPosition(tx *sql.Tx, accountID, instrumentID string) (positionRecord, error) {
 	query := `
-		SELECT quantity, "avgCost"
+		SELECT quantity, "avgCost", "settledQuantity"
 		FROM positions
 		WHERE "accountId" = $1 AND "instrumentId" = $2
+		FOR UPDATE
 	`
 
 	var record positionRecord
-	if err := p.db.QueryRow(query, accountID, instrumentID).Scan(&record.quantity, &record.avgCost); err != nil {
+	if err := tx.QueryRow(query, accountID, instrumentID).Scan(&record.quantity, &record.avgCost, &record.settledQuantity); err != nil {
 		return positionRecord{}, err
 	}
 
@@ -452,6 +655,8 @@ func executionFromPayload(payload map[string]interface{}) (executionRecord, erro
 		side:           side,
 		filledQuantity: filledQuantity,
 		avgFillPrice:   avgFillPrice,
+		charges:        parseFloat(payload["totalCharges"]),
+		netAmount:      parseFloat(payload["netSettlementAmount"]),
 	}, nil
 }
 
@@ -0,0 +1,55 @@
+package reconciliation
+
+import (
+	"log"
+	"time"
+)
+
+// Job runs reconciliation on a fixed interval. It is a singleton worker so
+// auto-repair never runs concurrently on two instances.
+type Job struct {
+	service    *Service
+	interval   time.Duration
+	autoRepair bool
+	stopChan   chan struct{}
+}
+
+// NewJob creates a new scheduled reconciliation job
+func NewJob(service *Service, interval time.Duration, autoRepair bool) *Job {
+	return &Job{
+		service:    service,
+		interval:   interval,
+		autoRepair: autoRepair,
+		stopChan:   make(chan struct{}),
+	}
+}
+
+// Start runs reconciliation every interval until stopped
+func (j *Job) Start() {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	log.Printf("Reconciliation job started (every %s, autoRepair=%t)", j.interval, j.autoRepair)
+
+	for {
+		select {
+		case <-ticker.C:
+			report, err := j.service.Run(j.autoRepair, "system:reconciliation-job")
+			if err != nil {
+				log.Printf("Reconciliation job failed: %v", err)
+				continue
+			}
+			if report.BreakCount > 0 {
+				log.Printf("Reconciliation run %s found %d breaks (%d repaired)", report.RunID, report.BreakCount, report.RepairedCount)
+			}
+		case <-j.stopChan:
+			log.Println("Reconciliation job stopped")
+			return
+		}
+	}
+}
+
+// Stop stops the job
+func (j *Job) Stop() {
+	close(j.stopChan)
+}
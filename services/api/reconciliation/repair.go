@@ -0,0 +1,239 @@
+package reconciliation
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// repair overwrites a broken projection value with the event-sourced one.
+// Missing or unexpected orders and executions are only reported: they point
+// at a dropped event, which is recovered through the projection dead letters.
+func (s *Service) repair(b *Break) error {
+	if b.Field == "exists" && b.Kind != BreakPosition {
+		return nil
+	}
+
+	var err error
+	switch b.Kind {
+	case BreakOrder:
+		err = s.repairOrder(b)
+	case BreakExecution:
+		err = s.repairExecution(b)
+	case BreakPosition:
+		err = s.repairPosition(b)
+	}
+	if err != nil {
+		return err
+	}
+
+	b.Repaired = true
+	return nil
+}
+
+func (s *Service) repairOrder(b *Break) error {
+	set, value, err := orderRepair(b)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(
+		fmt.Sprintf(`UPDATE orders SET %s, "updatedAt" = $2 WHERE "orderId" = $3`, set),
+		value, time.Now().UTC(), b.Key,
+	)
+	return err
+}
+
+// orderRepair returns the SET clause that repairs an order break, casting
+// enums, and the value to bind to it
+func orderRepair(b *Break) (string, interface{}, error) {
+	columns := map[string]string{
+		"state":         "state",
+		"quantity":      "quantity",
+		"orderType":     `"orderType"`,
+		"limitPrice":    `"limitPrice"`,
+		"curveSpreadBp": `"curveSpreadBp"`,
+	}
+	column, ok := columns[b.Field]
+	if !ok {
+		return "", nil, fmt.Errorf("cannot repair order field %s", b.Field)
+	}
+
+	value := b.Expected
+	if pointer, ok := value.(*float64); ok {
+		value = nil
+		if pointer != nil {
+			value = *pointer
+		}
+	}
+
+	switch b.Field {
+	case "state":
+		return column + " = $1::order_state", value, nil
+	case "orderType":
+		return column + " = $1::order_type", value, nil
+	}
+	return column + " = $1", value, nil
+}
+
+func (s *Service) repairExecution(b *Break) error {
+	switch b.Field {
+	case "filledQuantity", "avgFillPrice":
+	default:
+		// Fill rows cannot be rebuilt from a count; the fill event must be replayed
+		return fmt.Errorf("cannot repair execution field %s", b.Field)
+	}
+
+	_, err := s.db.Exec(
+		fmt.Sprintf(`UPDATE executions SET "%s" = $1, "updatedAt" = $2 WHERE "executionId" = $3`, b.Field),
+		b.Expected, time.Now().UTC(), b.Key,
+	)
+	return err
+}
+
+func (s *Service) repairPosition(b *Break) error {
+	quantity, _ := b.Expected.(float64)
+	if b.Field == "settledQuantity" {
+		_, err := s.db.Exec(
+			`UPDATE positions SET "settledQuantity" = $1, "updatedAt" = $2 WHERE "accountId" = $3 AND "instrumentId" = $4`,
+			quantity, time.Now().UTC(), b.AccountID, b.InstrumentID,
+		)
+		return err
+	}
+	if math.Abs(quantity) <= quantityTolerance {
+		// A position with quantity still to settle is kept, flat
+		_, err := s.db.Exec(
+			`DELETE FROM positions WHERE "accountId" = $1 AND "instrumentId" = $2 AND ABS("settledQuantity") <= $3`,
+			b.AccountID, b.InstrumentID, quantityTolerance,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = s.db.Exec(
+			`UPDATE positions SET quantity = 0, "marketValue" = 0, dv01 = 0, "updatedAt" = $1 WHERE "accountId" = $2 AND "instrumentId" = $3`,
+			time.Now().UTC(), b.AccountID, b.InstrumentID,
+		)
+		return err
+	}
+
+	var (
+		askPrice sql.NullFloat64
+		duration sql.NullFloat64
+	)
+	err := s.db.QueryRow(
+		`SELECT "askPrice", "askModifiedDuration" FROM instruments WHERE cusip = $1`,
+		b.InstrumentID,
+	).Scan(&askPrice, &duration)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	avgCost, err := s.rebuiltAvgCost(b.AccountID, b.InstrumentID)
+	if err != nil {
+		return err
+	}
+
+	price := askPrice.Float64
+	if price <= 0 {
+		price = avgCost
+	}
+	if price <= 0 {
+		price = 100
+	}
+	marketValue := quantity * price
+	dv01 := marketValue * duration.Float64 * 0.0001
+
+	_, err = s.db.Exec(`
+		INSERT INTO positions ("accountId", "instrumentId", quantity, "avgCost", "marketValue", duration, dv01, "updatedAt")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT ("accountId", "instrumentId")
+		DO UPDATE SET quantity = EXCLUDED.quantity, "marketValue" = EXCLUDED."marketValue",
+			dv01 = EXCLUDED.dv01, "updatedAt" = EXCLUDED."updatedAt"
+	`, b.AccountID, b.InstrumentID, quantity, avgCost, marketValue, duration.Float64, dv01, time.Now().UTC())
+	return err
+}
+
+// rebuiltAvgCost is the average fill price of everything bought, including
+// its charges, used only when a position row has to be recreated
+func (s *Service) rebuiltAvgCost(accountID, instrumentID string) (float64, error) {
+	var avgCost sql.NullFloat64
+	err := s.db.QueryRow(`
+		SELECT SUM(quantity * price + charges) / NULLIF(SUM(quantity), 0)
+		FROM (
+			SELECT "filledQuantity" AS quantity, COALESCE("avgFillPrice", 0) AS price,
+				commission + fees + markup AS charges
+			FROM executions
+			WHERE "accountId" = $1 AND "instrumentId" = $2 AND side = 'BUY'
+			UNION ALL
+			SELECT quantity, price, commission + fees + markup
+			FROM allocations
+			WHERE "accountId" = $1 AND "instrumentId" = $2 AND side = 'BUY'
+			UNION ALL
+			SELECT quantity, price, 0
+			FROM cross_trades
+			WHERE "accountId" = $1 AND "instrumentId" = $2 AND side = 'BUY'
+		) bought
+	`, accountID, instrumentID).Scan(&avgCost)
+	if err != nil {
+		return 0, err
+	}
+	return avgCost.Float64, nil
+}
+
+func stringValue(value interface{}) string {
+	s, _ := value.(string)
+	return strings.TrimSpace(s)
+}
+
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s := stringValue(item); s != "" {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+func floatValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func optionalFloat(value interface{}) *float64 {
+	v, ok := floatValue(value)
+	if !ok {
+		return nil
+	}
+	return &v
+}
+
+func optionalEqual(want *float64, got sql.NullFloat64) bool {
+	if want == nil || !got.Valid {
+		return want == nil && !got.Valid
+	}
+	return math.Abs(*want-got.Float64) <= priceTolerance
+}
+
+func nullFloatValue(value sql.NullFloat64) interface{} {
+	if !value.Valid {
+		return nil
+	}
+	return value.Float64
+}
@@ -0,0 +1,656 @@
+package reconciliation
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"instant/services/api/events"
+	"instant/services/api/eventstore"
+	"instant/services/api/projections"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	quantityTolerance = 0.005
+	priceTolerance    = 0.0001
+	// scanBatchSize is how many events a run reads from the store at a time
+	scanBatchSize = 1000
+)
+
+var ErrRunNotFound = errors.New("reconciliation run not found")
+
+// orderStateByEvent mirrors the state transitions applied by OMSProjection
+var orderStateByEvent = map[string]string{
+	events.EventOrderApprovalRequested:   "APPROVAL_PENDING",
+	events.EventOrderApproved:            "APPROVED",
+	events.EventOrderRejected:            "REJECTED",
+	events.EventOrderSentToEMS:           "SENT",
+	events.EventOrderPartiallyFilled:     "PARTIALLY_FILLED",
+	events.EventOrderFullyFilled:         "FILLED",
+	events.EventSettlementBooked:         "SETTLED",
+	events.EventSettlementSettled:        "SETTLED",
+	events.EventOrderCancelled:           "CANCELLED",
+	events.EventOrderExpired:             "EXPIRED",
+	events.EventOrderReplaced:            "REPLACED",
+	events.EventOrderBlockedByCompliance: "REJECTED",
+}
+
+// eventReader reads the event log in position order
+type eventReader interface {
+	GetAfterPosition(position int64, limit int) ([]*events.Event, error)
+	LastPosition() (int64, error)
+}
+
+// Service rebuilds orders, executions and positions from the event log and
+// compares them with the projection tables.
+type Service struct {
+	db          *sql.DB
+	eventStore  eventReader
+	checkpoints *projections.CheckpointStore
+}
+
+// rebuilt holds the read models rebuilt from the event log, each up to its
+// projection's cutoff
+type rebuilt struct {
+	orders     map[string]*expectedOrder
+	executions map[string]*expectedExecution
+	positions  map[positionKey]*expectedPosition
+	scanned    int
+}
+
+// NewService creates a new reconciliation service
+func NewService(db *sql.DB, es *eventstore.EventStore) (*Service, error) {
+	return &Service{
+		db:          db,
+		eventStore:  es,
+		checkpoints: projections.NewCheckpointStore(db),
+	}, nil
+}
+
+// Run reconciles every projection table and, when repair is set, overwrites
+// broken projection values with the ones rebuilt from events. Each table is
+// only compared against events its projection has already applied, so
+// in-flight events do not show up as breaks.
+func (s *Service) Run(repair bool, triggeredBy string) (*Report, error) {
+	report := &Report{
+		RunID:       uuid.New().String(),
+		StartedAt:   time.Now().UTC(),
+		TriggeredBy: triggeredBy,
+		Repair:      repair,
+		Breaks:      []Break{},
+	}
+
+	latest, err := s.eventStore.LastPosition()
+	if err != nil {
+		return nil, err
+	}
+	omsCutoff, err := s.cutoff(projections.ProjectionOMS, latest)
+	if err != nil {
+		return nil, err
+	}
+	emsCutoff, err := s.cutoff(projections.ProjectionEMS, latest)
+	if err != nil {
+		return nil, err
+	}
+	pmsCutoff, err := s.cutoff(projections.ProjectionPMS, latest)
+	if err != nil {
+		return nil, err
+	}
+
+	models, err := s.rebuild(omsCutoff, emsCutoff, pmsCutoff)
+	if err != nil {
+		return nil, err
+	}
+	report.EventsScanned = models.scanned
+
+	orderBreaks, checked, err := s.reconcileOrders(models.orders)
+	if err != nil {
+		return nil, err
+	}
+	report.OrdersChecked = checked
+	report.Breaks = append(report.Breaks, orderBreaks...)
+
+	executionBreaks, checked, err := s.reconcileExecutions(models.executions)
+	if err != nil {
+		return nil, err
+	}
+	report.ExecutionsChecked = checked
+	report.Breaks = append(report.Breaks, executionBreaks...)
+
+	positionBreaks, checked, err := s.reconcilePositions(models.positions)
+	if err != nil {
+		return nil, err
+	}
+	report.PositionsChecked = checked
+	report.Breaks = append(report.Breaks, positionBreaks...)
+
+	if repair {
+		for i := range report.Breaks {
+			if err := s.repair(&report.Breaks[i]); err != nil {
+				report.Breaks[i].RepairError = err.Error()
+				continue
+			}
+			if report.Breaks[i].Repaired {
+				report.RepairedCount++
+			}
+		}
+	}
+
+	report.BreakCount = len(report.Breaks)
+	report.CompletedAt = time.Now().UTC()
+
+	if err := s.storeReport(report); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// cutoff returns the last position a projection has applied. A projection
+// without a checkpoint predates checkpointing and is treated as caught up.
+func (s *Service) cutoff(projection string, latest int64) (int64, error) {
+	position, err := s.checkpoints.Cursor(projection)
+	if err != nil {
+		return 0, err
+	}
+	if position == 0 {
+		return latest, nil
+	}
+	return position, nil
+}
+
+// rebuild reads the event log in batches up to the highest cutoff, folding
+// each event into the read models whose projection has already applied it
+func (s *Service) rebuild(omsCutoff, emsCutoff, pmsCutoff int64) (*rebuilt, error) {
+	models := &rebuilt{
+		orders:     map[string]*expectedOrder{},
+		executions: map[string]*expectedExecution{},
+		positions:  map[positionKey]*expectedPosition{},
+	}
+	last := omsCutoff
+	if emsCutoff > last {
+		last = emsCutoff
+	}
+	if pmsCutoff > last {
+		last = pmsCutoff
+	}
+
+	from := int64(0)
+	for from < last {
+		batch, err := s.eventStore.GetAfterPosition(from, scanBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range batch {
+			if event.Position > last {
+				return models, nil
+			}
+			if event.Position <= omsCutoff {
+				foldOrders(models.orders, event)
+			}
+			if event.Position <= emsCutoff {
+				foldExecutions(models.executions, event)
+			}
+			if event.Position <= pmsCutoff {
+				foldPositions(models.positions, event)
+			}
+			models.scanned++
+			from = event.Position
+		}
+		if len(batch) < scanBatchSize {
+			break
+		}
+	}
+	return models, nil
+}
+
+// ListRuns returns recent runs without their break details
+func (s *Service) ListRuns(limit int) ([]Report, error) {
+	rows, err := s.db.Query(`
+		SELECT "runId", "startedAt", "completedAt", "triggeredBy", repair, "breakCount", "repairedCount"
+		FROM reconciliation_runs
+		ORDER BY "startedAt" DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reconciliation runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []Report{}
+	for rows.Next() {
+		var run Report
+		if err := rows.Scan(&run.RunID, &run.StartedAt, &run.CompletedAt, &run.TriggeredBy, &run.Repair, &run.BreakCount, &run.RepairedCount); err != nil {
+			return nil, fmt.Errorf("failed to scan reconciliation run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// GetRun returns a stored run including its breaks
+func (s *Service) GetRun(runID string) (*Report, error) {
+	var reportJSON []byte
+	err := s.db.QueryRow(`SELECT report FROM reconciliation_runs WHERE "runId" = $1`, runID).Scan(&reportJSON)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRunNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reconciliation run: %w", err)
+	}
+
+	var report Report
+	if err := json.Unmarshal(reportJSON, &report); err != nil {
+		return nil, fmt.Errorf("failed to decode reconciliation run: %w", err)
+	}
+	return &report, nil
+}
+
+func (s *Service) storeReport(report *Report) error {
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO reconciliation_runs (
+			"runId", "startedAt", "completedAt", "triggeredBy", repair,
+			"breakCount", "repairedCount", report
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		report.RunID,
+		report.StartedAt,
+		report.CompletedAt,
+		report.TriggeredBy,
+		report.Repair,
+		report.BreakCount,
+		report.RepairedCount,
+		reportJSON,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store reconciliation run: %w", err)
+	}
+	return nil
+}
+
+// foldOrders applies an order event to the orders rebuilt so far
+func foldOrders(orders map[string]*expectedOrder, event *events.Event) {
+	// A block settlement booked before the settlement lifecycle settled
+	// every child order that received an allocation
+	if event.EventType == events.EventSettlementBooked && settledOnBooking(event.Payload) {
+		for _, childID := range stringList(event.Payload["orderIds"]) {
+			if order, ok := orders[childID]; ok && order.state == "FILLED" {
+				order.state = "SETTLED"
+			}
+		}
+	}
+
+	orderID, _ := event.Payload["orderId"].(string)
+	if orderID == "" {
+		return
+	}
+
+	if event.EventType == events.EventOrderCreated {
+		order := &expectedOrder{
+			orderID:       orderID,
+			accountID:     stringValue(event.Payload["accountId"]),
+			instrumentID:  stringValue(event.Payload["instrumentId"]),
+			state:         stringValue(event.Payload["state"]),
+			orderType:     stringValue(event.Payload["orderType"]),
+			limitPrice:    optionalFloat(event.Payload["limitPrice"]),
+			curveSpreadBp: optionalFloat(event.Payload["curveSpreadBp"]),
+		}
+		order.quantity, _ = floatValue(event.Payload["quantity"])
+		orders[orderID] = order
+		return
+	}
+
+	order, ok := orders[orderID]
+	if !ok {
+		return
+	}
+
+	if event.EventType == events.EventOrderAmended {
+		if value, ok := event.Payload["quantity"]; ok {
+			order.quantity, _ = floatValue(value)
+		}
+		if value, ok := event.Payload["orderType"]; ok {
+			order.orderType = stringValue(value)
+		}
+		if value, ok := event.Payload["limitPrice"]; ok {
+			order.limitPrice = optionalFloat(value)
+		}
+		if value, ok := event.Payload["curveSpreadBp"]; ok {
+			order.curveSpreadBp = optionalFloat(value)
+		}
+		return
+	}
+
+	// Settlement only moves a fully filled order; a cancelled or expired
+	// order keeps its state when its partial fill settles. Orders booked
+	// since the settlement lifecycle settle when their settlement does.
+	switch event.EventType {
+	case events.EventSettlementBooked:
+		if !settledOnBooking(event.Payload) || order.state != "FILLED" {
+			return
+		}
+	case events.EventSettlementSettled:
+		if order.state != "FILLED" {
+			return
+		}
+	}
+	if state, ok := orderStateByEvent[event.EventType]; ok {
+		order.state = state
+	}
+}
+
+// foldExecutions applies an execution or fill event to the execution totals
+// rebuilt so far
+func foldExecutions(executions map[string]*expectedExecution, event *events.Event) {
+	switch event.EventType {
+	case events.EventExecutionRequested:
+		executionID := stringValue(event.Payload["executionId"])
+		executions[executionID] = &expectedExecution{
+			executionID:  executionID,
+			orderID:      stringValue(event.Payload["orderId"]),
+			accountID:    stringValue(event.Payload["accountId"]),
+			instrumentID: stringValue(event.Payload["instrumentId"]),
+		}
+	case events.EventFillGenerated:
+		execution, ok := executions[stringValue(event.Payload["executionId"])]
+		if !ok {
+			return
+		}
+		quantity, _ := floatValue(event.Payload["quantity"])
+		price, _ := floatValue(event.Payload["price"])
+		execution.filledQuantity += quantity
+		execution.notional += quantity * price
+		execution.fillCount++
+	}
+}
+
+// foldPositions applies an event to the holdings rebuilt so far. Holdings
+// come from settlement events and, for block executions, from the allocation
+// booked to each account. The settled quantity moves when a settlement
+// settles, or on booking for trades booked before the settlement lifecycle.
+func foldPositions(positions map[positionKey]*expectedPosition, event *events.Event) {
+	var quantity, price float64
+	settled := settledOnBooking(event.Payload)
+	tradeDate := true
+	switch event.EventType {
+	case events.EventSettlementBooked:
+		quantity, _ = floatValue(event.Payload["filledQuantity"])
+		price, _ = floatValue(event.Payload["avgFillPrice"])
+	case events.EventAllocationBooked:
+		quantity, _ = floatValue(event.Payload["quantity"])
+		price, _ = floatValue(event.Payload["price"])
+	case events.EventSettlementSettled, events.EventSettlementPartiallySettled:
+		quantity, _ = floatValue(event.Payload["quantity"])
+		settled, tradeDate = true, false
+	default:
+		return
+	}
+
+	key := positionKey{
+		accountID:    stringValue(event.Payload["accountId"]),
+		instrumentID: stringValue(event.Payload["instrumentId"]),
+	}
+	if key.accountID == "" || key.instrumentID == "" {
+		return
+	}
+
+	position, ok := positions[key]
+	if !ok {
+		position = &expectedPosition{}
+		positions[key] = position
+	}
+	if stringValue(event.Payload["side"]) == "SELL" {
+		quantity = -quantity
+	}
+	if settled {
+		position.settledQuantity += quantity
+	}
+	if !tradeDate {
+		return
+	}
+	position.quantity += quantity
+	if quantity > 0 {
+		position.boughtQuantity += quantity
+		position.boughtCost += quantity * price
+	}
+}
+
+// settledOnBooking reports whether a SettlementBooked or AllocationBooked
+// payload predates the settlement lifecycle, when trades settled as soon as
+// they were booked
+func settledOnBooking(payload map[string]interface{}) bool {
+	_, single := payload["settlementId"]
+	_, block := payload["settlementIds"]
+	return !single && !block
+}
+
+func (s *Service) reconcileOrders(expected map[string]*expectedOrder) ([]Break, int, error) {
+	rows, err := s.db.Query(`
+		SELECT "orderId", "accountId", "instrumentId", state, quantity, "orderType", "limitPrice", "curveSpreadBp"
+		FROM orders
+	`)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query orders: %w", err)
+	}
+	defer rows.Close()
+
+	actual := []orderRow{}
+	for rows.Next() {
+		var row orderRow
+		if err := rows.Scan(&row.orderID, &row.accountID, &row.instrumentID, &row.state, &row.quantity, &row.orderType, &row.limitPrice, &row.curveSpreadBp); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan order: %w", err)
+		}
+		actual = append(actual, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return diffOrders(expected, actual), len(expected), nil
+}
+
+// diffOrders compares rebuilt orders with the rows in the orders table
+func diffOrders(expected map[string]*expectedOrder, actual []orderRow) []Break {
+	breaks := []Break{}
+	seen := map[string]bool{}
+	for _, row := range actual {
+		seen[row.orderID] = true
+
+		newBreak := func(field string, want, got interface{}) Break {
+			return Break{Kind: BreakOrder, Key: row.orderID, AccountID: row.accountID, InstrumentID: row.instrumentID, Field: field, Expected: want, Actual: got}
+		}
+
+		order, ok := expected[row.orderID]
+		if !ok {
+			breaks = append(breaks, newBreak("exists", false, true))
+			continue
+		}
+		if order.state != row.state {
+			breaks = append(breaks, newBreak("state", order.state, row.state))
+		}
+		if math.Abs(order.quantity-row.quantity) > quantityTolerance {
+			breaks = append(breaks, newBreak("quantity", order.quantity, row.quantity))
+		}
+		if order.orderType != row.orderType {
+			breaks = append(breaks, newBreak("orderType", order.orderType, row.orderType))
+		}
+		if !optionalEqual(order.limitPrice, row.limitPrice) {
+			breaks = append(breaks, newBreak("limitPrice", order.limitPrice, nullFloatValue(row.limitPrice)))
+		}
+		if !optionalEqual(order.curveSpreadBp, row.curveSpreadBp) {
+			breaks = append(breaks, newBreak("curveSpreadBp", order.curveSpreadBp, nullFloatValue(row.curveSpreadBp)))
+		}
+	}
+
+	for _, orderID := range sortedKeys(expected) {
+		if seen[orderID] {
+			continue
+		}
+		order := expected[orderID]
+		breaks = append(breaks, Break{
+			Kind: BreakOrder, Key: orderID, AccountID: order.accountID, InstrumentID: order.instrumentID,
+			Field: "exists", Expected: true, Actual: false,
+		})
+	}
+	return breaks
+}
+
+func (s *Service) reconcileExecutions(expected map[string]*expectedExecution) ([]Break, int, error) {
+	rows, err := s.db.Query(`
+		SELECT e."executionId", COALESCE(e."accountId", ''), e."instrumentId", e."filledQuantity",
+		       COALESCE(e."avgFillPrice", 0), COUNT(f."fillId")
+		FROM executions e
+		LEFT JOIN fills f ON f."executionId" = e."executionId"
+		GROUP BY e."executionId", e."accountId", e."instrumentId", e."filledQuantity", e."avgFillPrice"
+	`)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query executions: %w", err)
+	}
+	defer rows.Close()
+
+	actual := []executionRow{}
+	for rows.Next() {
+		var row executionRow
+		if err := rows.Scan(&row.executionID, &row.accountID, &row.instrumentID, &row.filledQuantity, &row.avgFillPrice, &row.fillCount); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan execution: %w", err)
+		}
+		actual = append(actual, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return diffExecutions(expected, actual), len(expected), nil
+}
+
+// diffExecutions compares rebuilt executions with the executions table and its fills
+func diffExecutions(expected map[string]*expectedExecution, actual []executionRow) []Break {
+	breaks := []Break{}
+	seen := map[string]bool{}
+	for _, row := range actual {
+		seen[row.executionID] = true
+
+		newBreak := func(field string, want, got interface{}) Break {
+			return Break{Kind: BreakExecution, Key: row.executionID, AccountID: row.accountID, InstrumentID: row.instrumentID, Field: field, Expected: want, Actual: got}
+		}
+
+		execution, ok := expected[row.executionID]
+		if !ok {
+			breaks = append(breaks, newBreak("exists", false, true))
+			continue
+		}
+		if math.Abs(execution.filledQuantity-row.filledQuantity) > quantityTolerance {
+			breaks = append(breaks, newBreak("filledQuantity", execution.filledQuantity, row.filledQuantity))
+		}
+		if execution.filledQuantity > 0 && math.Abs(execution.avgFillPrice()-row.avgFillPrice) > priceTolerance {
+			breaks = append(breaks, newBreak("avgFillPrice", execution.avgFillPrice(), row.avgFillPrice))
+		}
+		if execution.fillCount != row.fillCount {
+			breaks = append(breaks, newBreak("fillCount", execution.fillCount, row.fillCount))
+		}
+	}
+
+	for _, executionID := range sortedKeys(expected) {
+		if seen[executionID] {
+			continue
+		}
+		execution := expected[executionID]
+		breaks = append(breaks, Break{
+			Kind: BreakExecution, Key: executionID, AccountID: execution.accountID, InstrumentID: execution.instrumentID,
+			Field: "exists", Expected: true, Actual: false,
+		})
+	}
+	return breaks
+}
+
+func (s *Service) reconcilePositions(expected map[positionKey]*expectedPosition) ([]Break, int, error) {
+	rows, err := s.db.Query(`SELECT "accountId", "instrumentId", quantity, "settledQuantity" FROM positions`)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query positions: %w", err)
+	}
+	defer rows.Close()
+
+	actual := []positionRow{}
+	for rows.Next() {
+		var row positionRow
+		if err := rows.Scan(&row.key.accountID, &row.key.instrumentID, &row.quantity, &row.settledQuantity); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan position: %w", err)
+		}
+		actual = append(actual, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return diffPositions(expected, actual), len(expected), nil
+}
+
+// diffPositions compares rebuilt holdings with the positions table. A flat
+// holding needs no row.
+func diffPositions(expected map[positionKey]*expectedPosition, actual []positionRow) []Break {
+	breaks := []Break{}
+	seen := map[positionKey]bool{}
+	for _, row := range actual {
+		seen[row.key] = true
+
+		want := &expectedPosition{}
+		if position, ok := expected[row.key]; ok {
+			want = position
+		}
+		if math.Abs(want.quantity-row.quantity) > quantityTolerance {
+			breaks = append(breaks, positionBreak(row.key, "quantity", want.quantity, row.quantity))
+		}
+		if math.Abs(want.settledQuantity-row.settledQuantity) > quantityTolerance {
+			breaks = append(breaks, positionBreak(row.key, "settledQuantity", want.settledQuantity, row.settledQuantity))
+		}
+	}
+
+	keys := make([]positionKey, 0, len(expected))
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].accountID != keys[j].accountID {
+			return keys[i].accountID < keys[j].accountID
+		}
+		return keys[i].instrumentID < keys[j].instrumentID
+	})
+	for _, key := range keys {
+		if seen[key] || math.Abs(expected[key].quantity) <= quantityTolerance {
+			continue
+		}
+		breaks = append(breaks, positionBreak(key, "quantity", expected[key].quantity, 0.0))
+	}
+	return breaks
+}
+
+func positionBreak(key positionKey, field string, want, got float64) Break {
+	return Break{
+		Kind:         BreakPosition,
+		Key:          key.accountID + "/" + key.instrumentID,
+		AccountID:    key.accountID,
+		InstrumentID: key.instrumentID,
+		Field:        field,
+		Expected:     want,
+		Actual:       got,
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
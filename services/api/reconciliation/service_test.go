@@ -0,0 +1,278 @@
+package reconciliation
+
+import (
+	"database/sql"
+	"testing"
+
+	"instant/services/api/events"
+)
+
+// fakeReader serves events from memory in position order
+type fakeReader struct {
+	events []*events.Event
+	reads  int
+}
+
+func (r *fakeReader) GetAfterPosition(position int64, limit int) ([]*events.Event, error) {
+	r.reads++
+	batch := []*events.Event{}
+	for _, event := range r.events {
+		if event.Position > position && len(batch) < limit {
+			batch = append(batch, event)
+		}
+	}
+	return batch, nil
+}
+
+func (r *fakeReader) LastPosition() (int64, error) {
+	if len(r.events) == 0 {
+		return 0, nil
+	}
+	return r.events[len(r.events)-1].Position, nil
+}
+
+// eventLog builds an event log, numbering positions from 1
+func eventLog(entries ...*events.Event) []*events.Event {
+	for i, event := range entries {
+		event.Position = int64(i + 1)
+	}
+	return entries
+}
+
+func event(eventType string, payload map[string]interface{}) *events.Event {
+	return &events.Event{EventType: eventType, Payload: payload}
+}
+
+func fold(all []*events.Event) (map[string]*expectedOrder, map[string]*expectedExecution, map[positionKey]*expectedPosition) {
+	orders := map[string]*expectedOrder{}
+	executions := map[string]*expectedExecution{}
+	positions := map[positionKey]*expectedPosition{}
+	for _, event := range all {
+		foldOrders(orders, event)
+		foldExecutions(executions, event)
+		foldPositions(positions, event)
+	}
+	return orders, executions, positions
+}
+
+func TestFoldOrdersFollowsTheOrderLifecycle(t *testing.T) {
+	orders, _, _ := fold(eventLog(
+		event(events.EventOrderCreated, map[string]interface{}{
+			"orderId": "o1", "accountId": "A", "instrumentId": "C1", "state": "CREATED",
+			"orderType": "LIMIT", "quantity": 100.0, "limitPrice": 99.5,
+		}),
+		event(events.EventOrderAmended, map[string]interface{}{"orderId": "o1", "quantity": 80.0, "limitPrice": nil}),
+		event(events.EventOrderSentToEMS, map[string]interface{}{"orderId": "o1"}),
+		event(events.EventOrderFullyFilled, map[string]interface{}{"orderId": "o1"}),
+		event(events.EventSettlementSettled, map[string]interface{}{"orderId": "o1"}),
+		event(events.EventOrderCreated, map[string]interface{}{"orderId": "o2", "state": "CREATED", "quantity": 50.0}),
+		event(events.EventOrderCancelled, map[string]interface{}{"orderId": "o2"}),
+		event(events.EventSettlementSettled, map[string]interface{}{"orderId": "o2"}),
+		event(events.EventOrderApproved, map[string]interface{}{"orderId": "unknown"}),
+	))
+
+	if len(orders) != 2 {
+		t.Fatalf("expected two orders, got %d", len(orders))
+	}
+	o1 := orders["o1"]
+	if o1.state != "SETTLED" || o1.quantity != 80 || o1.limitPrice != nil || o1.orderType != "LIMIT" {
+		t.Fatalf("unexpected o1: %+v", o1)
+	}
+	// A partial fill settling does not move a cancelled order
+	if orders["o2"].state != "CANCELLED" {
+		t.Fatalf("expected o2 to stay cancelled, got %s", orders["o2"].state)
+	}
+}
+
+func TestFoldOrdersSettlesLegacyBlockChildrenOnBooking(t *testing.T) {
+	orders, _, _ := fold(eventLog(
+		event(events.EventOrderCreated, map[string]interface{}{"orderId": "c1", "state": "CREATED"}),
+		event(events.EventOrderFullyFilled, map[string]interface{}{"orderId": "c1"}),
+		event(events.EventOrderCreated, map[string]interface{}{"orderId": "c2", "state": "CREATED"}),
+		event(events.EventSettlementBooked, map[string]interface{}{"orderIds": []interface{}{"c1", "c2"}}),
+	))
+	if orders["c1"].state != "SETTLED" || orders["c2"].state != "CREATED" {
+		t.Fatalf("expected only the filled child settled, got %s and %s", orders["c1"].state, orders["c2"].state)
+	}
+
+	// Since the settlement lifecycle, booking leaves the child filled
+	orders, _, _ = fold(eventLog(
+		event(events.EventOrderCreated, map[string]interface{}{"orderId": "c1", "state": "CREATED"}),
+		event(events.EventOrderFullyFilled, map[string]interface{}{"orderId": "c1"}),
+		event(events.EventSettlementBooked, map[string]interface{}{"orderIds": []interface{}{"c1"}, "settlementIds": []interface{}{"s1"}}),
+	))
+	if orders["c1"].state != "FILLED" {
+		t.Fatalf("expected the child to wait for settlement, got %s", orders["c1"].state)
+	}
+}
+
+func TestFoldExecutionsTotalsFills(t *testing.T) {
+	_, executions, _ := fold(eventLog(
+		event(events.EventExecutionRequested, map[string]interface{}{"executionId": "e1", "orderId": "o1", "accountId": "A", "instrumentId": "C1"}),
+		event(events.EventFillGenerated, map[string]interface{}{"executionId": "e1", "quantity": 60.0, "price": 100.0}),
+		event(events.EventFillGenerated, map[string]interface{}{"executionId": "e1", "quantity": 40.0, "price": 101.0}),
+		event(events.EventFillGenerated, map[string]interface{}{"executionId": "missing", "quantity": 10.0, "price": 100.0}),
+	))
+
+	if len(executions) != 1 {
+		t.Fatalf("expected one execution, got %d", len(executions))
+	}
+	e1 := executions["e1"]
+	if e1.filledQuantity != 100 || e1.fillCount != 2 || e1.avgFillPrice() != 100.4 {
+		t.Fatalf("unexpected e1: %+v (avg %v)", e1, e1.avgFillPrice())
+	}
+}
+
+func TestFoldPositionsMovesSettledQuantityOnSettlement(t *testing.T) {
+	key := positionKey{accountID: "A", instrumentID: "C1"}
+	_, _, positions := fold(eventLog(
+		event(events.EventSettlementBooked, map[string]interface{}{
+			"accountId": "A", "instrumentId": "C1", "side": "BUY", "filledQuantity": 100.0, "avgFillPrice": 99.0, "settlementId": "s1",
+		}),
+		event(events.EventSettlementBooked, map[string]interface{}{
+			"accountId": "A", "instrumentId": "C1", "side": "SELL", "filledQuantity": 30.0, "avgFillPrice": 101.0, "settlementId": "s2",
+		}),
+		event(events.EventSettlementSettled, map[string]interface{}{"accountId": "A", "instrumentId": "C1", "side": "BUY", "quantity": 100.0}),
+		// A legacy booking settles as soon as it is booked
+		event(events.EventAllocationBooked, map[string]interface{}{"accountId": "B", "instrumentId": "C1", "side": "BUY", "quantity": 25.0, "price": 98.0}),
+		event(events.EventSettlementBooked, map[string]interface{}{"instrumentId": "C1", "filledQuantity": 5.0}),
+	))
+
+	a := positions[key]
+	if a.quantity != 70 || a.settledQuantity != 100 || a.boughtQuantity != 100 || a.boughtCost != 9900 {
+		t.Fatalf("unexpected A: %+v", a)
+	}
+	b := positions[positionKey{accountID: "B", instrumentID: "C1"}]
+	if b.quantity != 25 || b.settledQuantity != 25 {
+		t.Fatalf("unexpected B: %+v", b)
+	}
+	if len(positions) != 2 {
+		t.Fatalf("expected an event without an account to be ignored, got %d positions", len(positions))
+	}
+}
+
+func TestRebuildReadsEachProjectionUpToItsCutoff(t *testing.T) {
+	reader := &fakeReader{}
+	for i := 0; i < scanBatchSize+4; i++ {
+		reader.events = append(reader.events, event("Heartbeat", map[string]interface{}{}))
+	}
+	reader.events = append(reader.events,
+		event(events.EventOrderCreated, map[string]interface{}{"orderId": "o1", "state": "CREATED"}),
+		event(events.EventExecutionRequested, map[string]interface{}{"executionId": "e1"}),
+		event(events.EventOrderCreated, map[string]interface{}{"orderId": "o2", "state": "CREATED"}),
+	)
+	reader.events = eventLog(reader.events...)
+	orderAt := int64(scanBatchSize + 5)
+
+	service := &Service{eventStore: reader}
+	models, err := service.rebuild(orderAt, orderAt+1, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// o2 is beyond every cutoff, and the OMS projection has not applied e1's position
+	if len(models.orders) != 1 || models.orders["o1"] == nil || len(models.executions) != 1 {
+		t.Fatalf("expected o1 and e1 only, got %d orders and %d executions", len(models.orders), len(models.executions))
+	}
+	if models.scanned != int(orderAt+1) || reader.reads != 2 {
+		t.Fatalf("expected %d events over two reads, got %d over %d", orderAt+1, models.scanned, reader.reads)
+	}
+}
+
+func TestDiffOrdersReportsMissingExtraAndChangedOrders(t *testing.T) {
+	price := 99.5
+	expected := map[string]*expectedOrder{
+		"o1": {orderID: "o1", state: "FILLED", quantity: 100, orderType: "LIMIT", limitPrice: &price},
+		"o2": {orderID: "o2", accountID: "A", state: "CREATED", quantity: 10},
+	}
+	breaks := diffOrders(expected, []orderRow{
+		{orderID: "o1", state: "SENT", quantity: 100.001, orderType: "LIMIT", limitPrice: sql.NullFloat64{Float64: 99.5, Valid: true}},
+		{orderID: "o3", state: "CREATED"},
+	})
+
+	want := map[string]string{"o1": "state", "o3": "exists", "o2": "exists"}
+	if len(breaks) != len(want) {
+		t.Fatalf("expected %d breaks, got %+v", len(want), breaks)
+	}
+	for _, b := range breaks {
+		if want[b.Key] != b.Field {
+			t.Fatalf("unexpected break %+v", b)
+		}
+	}
+	if breaks[0].Expected != "FILLED" || breaks[0].Actual != "SENT" || breaks[2].Expected != true || breaks[2].AccountID != "A" {
+		t.Fatalf("unexpected break values %+v", breaks)
+	}
+}
+
+func TestDiffExecutionsComparesTotalsAndFillCount(t *testing.T) {
+	expected := map[string]*expectedExecution{
+		"e1": {executionID: "e1", filledQuantity: 100, notional: 10040, fillCount: 2},
+	}
+	breaks := diffExecutions(expected, []executionRow{
+		{executionID: "e1", filledQuantity: 100, avgFillPrice: 100.5, fillCount: 1},
+	})
+	if len(breaks) != 2 || breaks[0].Field != "avgFillPrice" || breaks[1].Field != "fillCount" {
+		t.Fatalf("expected avgFillPrice and fillCount breaks, got %+v", breaks)
+	}
+}
+
+func TestDiffPositionsIgnoresFlatHoldingsWithoutARow(t *testing.T) {
+	expected := map[positionKey]*expectedPosition{
+		{accountID: "A", instrumentID: "C1"}: {quantity: 50, settledQuantity: 50},
+		{accountID: "A", instrumentID: "C2"}: {},
+		{accountID: "B", instrumentID: "C1"}: {quantity: 20},
+	}
+	breaks := diffPositions(expected, []positionRow{
+		{key: positionKey{accountID: "A", instrumentID: "C1"}, quantity: 50, settledQuantity: 0},
+		{key: positionKey{accountID: "C", instrumentID: "C1"}, quantity: 5},
+	})
+
+	got := []string{}
+	for _, b := range breaks {
+		got = append(got, b.Key+" "+b.Field)
+	}
+	want := []string{"A/C1 settledQuantity", "C/C1 quantity", "B/C1 quantity"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestRepairOnlyReportsMissingOrdersAndExecutions(t *testing.T) {
+	service := &Service{}
+	for _, kind := range []string{BreakOrder, BreakExecution} {
+		b := Break{Kind: kind, Field: "exists", Expected: true, Actual: false}
+		if err := service.repair(&b); err != nil || b.Repaired {
+			t.Fatalf("expected a missing %s to be left for the dead letters, got %v", kind, err)
+		}
+	}
+
+	b := Break{Kind: BreakExecution, Field: "fillCount", Expected: 2, Actual: 1}
+	if err := service.repair(&b); err == nil || b.Repaired {
+		t.Fatalf("expected a fill count break to be unrepairable")
+	}
+}
+
+func TestOrderRepairCastsEnumsAndUnwrapsPrices(t *testing.T) {
+	set, value, err := orderRepair(&Break{Field: "state", Expected: "FILLED"})
+	if err != nil || set != "state = $1::order_state" || value != "FILLED" {
+		t.Fatalf("unexpected state repair %q %v %v", set, value, err)
+	}
+
+	price := 99.5
+	set, value, _ = orderRepair(&Break{Field: "limitPrice", Expected: &price})
+	if set != `"limitPrice" = $1` || value != 99.5 {
+		t.Fatalf("unexpected limit price repair %q %v", set, value)
+	}
+	var cleared *float64
+	if _, value, _ := orderRepair(&Break{Field: "limitPrice", Expected: cleared}); value != nil {
+		t.Fatalf("expected a cleared limit price to be written as NULL, got %v", value)
+	}
+
+	if _, _, err := orderRepair(&Break{Field: "accountId"}); err == nil {
+		t.Fatalf("expected an unknown field to be refused")
+	}
+}
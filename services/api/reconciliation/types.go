@@ -0,0 +1,116 @@
+package reconciliation
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Break categories
+const (
+	BreakOrder     = "ORDER"
+	BreakExecution = "EXECUTION"
+	BreakPosition  = "POSITION"
+)
+
+// Break is a difference between a projection row and the value rebuilt from the event log
+type Break struct {
+	Kind         string      `json:"kind"`
+	Key          string      `json:"key"`
+	AccountID    string      `json:"accountId,omitempty"`
+	InstrumentID string      `json:"instrumentId,omitempty"`
+	Field        string      `json:"field"`
+	Expected     interface{} `json:"expected"`
+	Actual       interface{} `json:"actual"`
+	Repaired     bool        `json:"repaired"`
+	RepairError  string      `json:"repairError,omitempty"`
+}
+
+// Report summarizes one reconciliation run
+type Report struct {
+	RunID             string    `json:"runId"`
+	StartedAt         time.Time `json:"startedAt"`
+	CompletedAt       time.Time `json:"completedAt"`
+	TriggeredBy       string    `json:"triggeredBy"`
+	Repair            bool      `json:"repair"`
+	EventsScanned     int       `json:"eventsScanned"`
+	OrdersChecked     int       `json:"ordersChecked"`
+	ExecutionsChecked int       `json:"executionsChecked"`
+	PositionsChecked  int       `json:"positionsChecked"`
+	BreakCount        int       `json:"breakCount"`
+	RepairedCount     int       `json:"repairedCount"`
+	Breaks            []Break   `json:"breaks"`
+}
+
+// expectedOrder is an order as rebuilt from order events
+type expectedOrder struct {
+	orderID       string
+	accountID     string
+	instrumentID  string
+	state         string
+	quantity      float64
+	orderType     string
+	limitPrice    *float64
+	curveSpreadBp *float64
+}
+
+// expectedExecution is an execution as rebuilt from fill events
+type expectedExecution struct {
+	executionID    string
+	orderID        string
+	accountID      string
+	instrumentID   string
+	filledQuantity float64
+	notional       float64
+	fillCount      int
+}
+
+func (e expectedExecution) avgFillPrice() float64 {
+	if e.filledQuantity == 0 {
+		return 0
+	}
+	return e.notional / e.filledQuantity
+}
+
+// positionKey identifies a holding
+type positionKey struct {
+	accountID    string
+	instrumentID string
+}
+
+// expectedPosition is a holding as rebuilt from settlement events
+type expectedPosition struct {
+	quantity        float64
+	settledQuantity float64
+	// cost of quantity bought, used to seed avgCost when a missing row is repaired
+	boughtQuantity float64
+	boughtCost     float64
+}
+
+// orderRow is an order as stored in the orders table
+type orderRow struct {
+	orderID       string
+	accountID     string
+	instrumentID  string
+	state         string
+	quantity      float64
+	orderType     string
+	limitPrice    sql.NullFloat64
+	curveSpreadBp sql.NullFloat64
+}
+
+// executionRow is an execution as stored, with the number of fills recorded for it
+type executionRow struct {
+	executionID    string
+	accountID      string
+	instrumentID   string
+	filledQuantity float64
+	avgFillPrice   float64
+	fillCount      int
+}
+
+// positionRow is a holding as stored in the positions table
+type positionRow struct {
+	key             positionKey
+	quantity        float64
+	settledQuantity float64
+}
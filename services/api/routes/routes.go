@@ -4,6 +4,7 @@ import (
 	"instant/services/api/events"
 	"instant/services/api/eventstore"
 	"instant/services/api/handlers"
+	"instant/services/api/projections"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,8 +19,28 @@ func SetupRoutes(
 	pmsQueryHandler *handlers.PMSQueryHandler,
 	complianceCommandHandler *handlers.ComplianceCommandHandler,
 	complianceQueryHandler *handlers.ComplianceQueryHandler,
+	approvalCommandHandler *handlers.ApprovalCommandHandler,
+	approvalQueryHandler *handlers.ApprovalQueryHandler,
+	tradingControlCommandHandler *handlers.TradingControlCommandHandler,
+	tradingControlQueryHandler *handlers.TradingControlQueryHandler,
+	riskCommandHandler *handlers.RiskCommandHandler,
+	riskQueryHandler *handlers.RiskQueryHandler,
+	feeCommandHandler *handlers.FeeCommandHandler,
+	feeQueryHandler *handlers.FeeQueryHandler,
+	settlementCommandHandler *handlers.SettlementCommandHandler,
+	settlementQueryHandler *handlers.SettlementQueryHandler,
+	routingCommandHandler *handlers.RoutingCommandHandler,
+	routingQueryHandler *handlers.RoutingQueryHandler,
+	uploadCommandHandler *handlers.UploadCommandHandler,
 	marketDataQueryHandler *handlers.MarketDataQueryHandler,
+	calendarQueryHandler *handlers.CalendarQueryHandler,
 	copilotCommandHandler *handlers.CopilotCommandHandler,
+	workerQueryHandler *handlers.WorkerQueryHandler,
+	projectionCommandHandler *handlers.ProjectionCommandHandler,
+	projectionQueryHandler *handlers.ProjectionQueryHandler,
+	reconciliationCommandHandler *handlers.ReconciliationCommandHandler,
+	reconciliationQueryHandler *handlers.ReconciliationQueryHandler,
+	consistencyWaiter *handlers.ConsistencyWaiter,
 	eventStore *eventstore.EventStore,
 ) {
 	// Health check
@@ -34,9 +55,16 @@ func SetupRoutes(
 			oms.POST("/orders", omsCommandHandler.HandleCreateOrder)
 			oms.POST("/orders/bulk", omsCommandHandler.HandleBulkCreateOrders)
 			oms.PATCH("/orders/:id/amend", omsCommandHandler.HandleAmendOrder)
+			oms.POST("/orders/:id/replace", omsCommandHandler.HandleReplaceOrder)
 			oms.POST("/orders/:id/approve", omsCommandHandler.HandleApproveOrder)
+			oms.POST("/orders/:id/reject", omsCommandHandler.HandleRejectOrder)
 			oms.POST("/orders/:id/cancel", omsCommandHandler.HandleCancelOrder)
 			oms.POST("/orders/:id/send-to-ems", omsCommandHandler.HandleSendToEMS)
+			oms.POST("/orders/net-and-send", omsCommandHandler.HandleNetAndSend)
+			oms.POST("/uploads", uploadCommandHandler.HandleUploadOrders)
+			oms.POST("/uploads/:id/confirm", uploadCommandHandler.HandleConfirmUpload)
+			oms.POST("/blocks", omsCommandHandler.HandleCreateBlockOrder)
+			oms.POST("/blocks/:id/send-to-ems", omsCommandHandler.HandleSendBlockToEMS)
 		}
 
 		ems := api.Group("/ems")
@@ -51,6 +79,8 @@ func SetupRoutes(
 			pms.POST("/optimization", pmsCommandHandler.HandleRunOptimization)
 			pms.POST("/proposals/:id/approve", pmsCommandHandler.HandleApproveProposal)
 			pms.POST("/proposals/:id/send-to-oms", pmsCommandHandler.HandleSendProposalToOMS)
+			pms.POST("/accounts/:id/cash", pmsCommandHandler.HandleAdjustCash)
+			pms.PUT("/accounts/:id/short-sales", pmsCommandHandler.HandleSetShortSales)
 		}
 
 		compliance := api.Group("/compliance")
@@ -64,6 +94,52 @@ func SetupRoutes(
 		}
 
 		// Copilot endpoints (AI Draft management)
+		approval := api.Group("/approval")
+		{
+			approval.POST("/policies", approvalCommandHandler.CreatePolicy)
+			approval.PATCH("/policies/:id", approvalCommandHandler.UpdatePolicy)
+			approval.DELETE("/policies/:id", approvalCommandHandler.DeletePolicy)
+		}
+
+		// Kill switches and order-flow throttles
+		tradingControls := api.Group("/trading-controls")
+		{
+			tradingControls.POST("/halts", tradingControlCommandHandler.ActivateHalt)
+			tradingControls.POST("/halts/:id/release", tradingControlCommandHandler.ReleaseHalt)
+			tradingControls.PUT("/rate-limits/:actorId", tradingControlCommandHandler.SetRateLimit)
+		}
+
+		// Desk risk limits
+		risk := api.Group("/risk")
+		{
+			risk.POST("/limits", riskCommandHandler.CreateLimit)
+			risk.PATCH("/limits/:id", riskCommandHandler.UpdateLimit)
+			risk.DELETE("/limits/:id", riskCommandHandler.DeleteLimit)
+		}
+
+		// Commission, fee and markup schedules
+		fees := api.Group("/fees")
+		{
+			fees.POST("/schedules", feeCommandHandler.CreateSchedule)
+			fees.PUT("/schedules/:id", feeCommandHandler.UpdateSchedule)
+			fees.DELETE("/schedules/:id", feeCommandHandler.DeleteSchedule)
+		}
+
+		// Execution venue routing policies
+		routingPolicies := api.Group("/routing")
+		{
+			routingPolicies.POST("/policies", routingCommandHandler.CreatePolicy)
+			routingPolicies.PUT("/policies/:id", routingCommandHandler.UpdatePolicy)
+			routingPolicies.DELETE("/policies/:id", routingCommandHandler.DeletePolicy)
+		}
+
+		// Settlement lifecycle
+		settlements := api.Group("/settlements")
+		{
+			settlements.POST("/:id/settle", settlementCommandHandler.SettleSettlement)
+			settlements.POST("/:id/fail", settlementCommandHandler.FailSettlement)
+		}
+
 		copilot := api.Group("/copilot")
 		{
 			copilot.POST("/drafts", copilotCommandHandler.HandleCreateDraft)
@@ -71,19 +147,54 @@ func SetupRoutes(
 			copilot.POST("/drafts/:id/reject", copilotCommandHandler.HandleRejectDraft)
 		}
 
+		// Projection dead-letter operations
+		projection := api.Group("/projections")
+		{
+			projection.POST("/:name/dead-letters/:id/retry", projectionCommandHandler.HandleRetryDeadLetter)
+			projection.POST("/:name/dead-letters/:id/skip", projectionCommandHandler.HandleSkipDeadLetter)
+		}
+
+		// Reconciliation of projections against the event log
+		api.POST("/reconciliation/runs", reconciliationCommandHandler.HandleRunReconciliation)
+
 		// Generic command endpoint (for event-driven architecture)
 		api.POST("/commands", omsCommandHandler.HandleCommandRouter)
 	}
 
 	// Views (read operations - projections)
+	// Views wait for their projection when the client passes minPosition
+	omsView := consistencyWaiter.Require(projections.ProjectionOMS)
+	emsView := consistencyWaiter.Require(projections.ProjectionEMS)
+	pmsView := consistencyWaiter.Require(projections.ProjectionPMS)
+	complianceView := consistencyWaiter.Require(projections.ProjectionCompliance)
+
 	views := router.Group("/api/views")
 	{
 		// OMS Views
-		views.GET("/blotter", omsQueryHandler.GetBlotter)
-		views.GET("/orders/:id", omsQueryHandler.GetOrderByID)
-		views.GET("/orders/batch/:batchId", omsQueryHandler.GetOrdersByBatchID)
-		views.GET("/executions", emsQueryHandler.GetExecutions)
-		views.GET("/executions/:id", emsQueryHandler.GetExecutionByID)
+		views.GET("/blotter", omsView, omsQueryHandler.GetBlotter)
+		views.GET("/orders/:id", omsView, omsQueryHandler.GetOrderByID)
+		views.GET("/orders/:id/history", omsView, omsQueryHandler.GetOrderHistory)
+		views.GET("/orders/batch/:batchId", omsView, omsQueryHandler.GetOrdersByBatchID)
+		views.GET("/uploads/:id", omsView, omsQueryHandler.GetUploadBatch)
+		views.GET("/blocks", omsView, omsQueryHandler.GetBlockOrders)
+		views.GET("/blocks/:id", omsView, omsQueryHandler.GetBlockOrderByID)
+		views.GET("/netting/:id", omsView, omsQueryHandler.GetNettingRun)
+		views.GET("/approvals", omsView, omsQueryHandler.GetApprovalQueue)
+		views.GET("/approval/policies", omsView, approvalQueryHandler.GetPolicies)
+		views.GET("/approval/policies/:id", omsView, approvalQueryHandler.GetPolicyByID)
+		views.GET("/trading-controls", omsView, tradingControlQueryHandler.GetStatus)
+		views.GET("/trading-controls/halts/:id", omsView, tradingControlQueryHandler.GetHaltByID)
+		views.GET("/risk/limits", omsView, riskQueryHandler.GetLimits)
+		views.GET("/risk/limits/:id", omsView, riskQueryHandler.GetLimitByID)
+		views.GET("/risk/utilization", omsView, riskQueryHandler.GetUtilization)
+		views.GET("/executions", emsView, emsQueryHandler.GetExecutions)
+		views.GET("/executions/:id", emsView, emsQueryHandler.GetExecutionByID)
+		views.GET("/fees/schedules", emsView, feeQueryHandler.GetSchedules)
+		views.GET("/fees/schedules/:id", emsView, feeQueryHandler.GetScheduleByID)
+		views.GET("/routing/policies", emsView, routingQueryHandler.GetPolicies)
+		views.GET("/routing/policies/:id", emsView, routingQueryHandler.GetPolicyByID)
+		views.GET("/settlements", emsView, settlementQueryHandler.GetSettlements)
+		views.GET("/settlements/:id", emsView, settlementQueryHandler.GetSettlementByID)
 
 		// Market data views
 		views.GET("/instruments", marketDataQueryHandler.GetInstruments)
@@ -94,19 +205,27 @@ func SetupRoutes(
 		views.GET("/pricing/:cusip", marketDataQueryHandler.GetEvaluatedPricing)
 		views.GET("/pricing/:cusip/history", marketDataQueryHandler.GetPricingHistory)
 		views.GET("/marketdata/summary", marketDataQueryHandler.GetMarketDataSummary)
+		views.GET("/calendar", calendarQueryHandler.GetCalendar)
+		views.GET("/calendar/settlement-date", calendarQueryHandler.GetSettlementDate)
 
 		// Other views (to be implemented)
-		views.GET("/accounts", pmsQueryHandler.GetAccounts)
-		views.GET("/accounts/:id", pmsQueryHandler.GetAccountView)
-		views.GET("/households", pmsQueryHandler.GetHouseholds)
-		views.GET("/households/:id", pmsQueryHandler.GetHouseholdView)
-		views.GET("/proposals", pmsQueryHandler.GetProposals)
-		views.GET("/proposals/:id", pmsQueryHandler.GetProposalByID)
-		views.GET("/drift", pmsQueryHandler.GetDriftView)
+		views.GET("/accounts", pmsView, pmsQueryHandler.GetAccounts)
+		views.GET("/accounts/:id", pmsView, pmsQueryHandler.GetAccountView)
+		views.GET("/households", pmsView, pmsQueryHandler.GetHouseholds)
+		views.GET("/households/:id", pmsView, pmsQueryHandler.GetHouseholdView)
+		views.GET("/proposals", pmsView, pmsQueryHandler.GetProposals)
+		views.GET("/proposals/:id", pmsView, pmsQueryHandler.GetProposalByID)
+		views.GET("/drift", pmsView, pmsQueryHandler.GetDriftView)
 		views.GET("/compliance", getComplianceStatus)
-		views.GET("/compliance/rules", complianceQueryHandler.GetRules)
-		views.GET("/compliance/rules/:id", complianceQueryHandler.GetRuleDetail)
-		views.GET("/compliance/violations", complianceQueryHandler.GetViolations)
+		views.GET("/compliance/rules", complianceView, complianceQueryHandler.GetRules)
+		views.GET("/compliance/rules/:id", complianceView, complianceQueryHandler.GetRuleDetail)
+		views.GET("/compliance/violations", complianceView, complianceQueryHandler.GetViolations)
+
+		// System views
+		views.GET("/workers", workerQueryHandler.GetWorkers)
+		views.GET("/projections/:name/dead-letters", projectionQueryHandler.GetDeadLetters)
+		views.GET("/reconciliation/runs", reconciliationQueryHandler.GetRuns)
+		views.GET("/reconciliation/runs/:id", reconciliationQueryHandler.GetRunByID)
 	}
 
 	// Events (event store queries)
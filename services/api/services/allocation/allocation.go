@@ -0,0 +1,117 @@
+package allocation
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// DefaultLotSize is the par amount allocations are rounded to when a block
+// does not specify one
+const DefaultLotSize = 1000.0
+
+// quantityEpsilon absorbs floating point noise when comparing quantities
+const quantityEpsilon = 0.000001
+
+var (
+	ErrNoTargets     = errors.New("at least one allocation is required")
+	ErrInvalidTarget = errors.New("allocation quantity must be greater than 0")
+	ErrInvalidLot    = errors.New("lot size must be greater than 0")
+)
+
+// Target is the quantity an account was meant to receive from a block
+type Target struct {
+	AccountID string  `json:"accountId"`
+	OrderID   string  `json:"orderId"`
+	Quantity  float64 `json:"quantity"`
+}
+
+// Result is the quantity an account actually receives
+type Result struct {
+	AccountID string  `json:"accountId"`
+	OrderID   string  `json:"orderId"`
+	Target    float64 `json:"target"`
+	Quantity  float64 `json:"quantity"`
+}
+
+// ProRata splits a block's filled quantity across its targets in proportion
+// to their target quantities.
+//
+// Each account first gets its pro-rata share rounded down to whole lots. The
+// lots left over are handed out one at a time, largest rounding remainder
+// first, with ties going to the earlier target, so the same fill always
+// allocates the same way. Any odd lot (a fill that is not a whole number of
+// lots) goes to the next account in that order. No account receives more
+// than its target.
+func ProRata(filled float64, targets []Target, lotSize float64) ([]Result, error) {
+	if len(targets) == 0 {
+		return nil, ErrNoTargets
+	}
+	if lotSize <= 0 {
+		return nil, ErrInvalidLot
+	}
+
+	total := 0.0
+	for _, target := range targets {
+		if target.Quantity <= 0 {
+			return nil, ErrInvalidTarget
+		}
+		total += target.Quantity
+	}
+
+	results := make([]Result, len(targets))
+	for i, target := range targets {
+		results[i] = Result{AccountID: target.AccountID, OrderID: target.OrderID, Target: target.Quantity}
+	}
+
+	if filled <= 0 {
+		return results, nil
+	}
+	if filled >= total-quantityEpsilon {
+		for i := range results {
+			results[i].Quantity = results[i].Target
+		}
+		return results, nil
+	}
+
+	remainders := make([]float64, len(targets))
+	allocated := 0.0
+	for i, target := range targets {
+		ideal := filled * target.Quantity / total
+		lots := math.Floor(ideal/lotSize + quantityEpsilon)
+		results[i].Quantity = math.Min(lots*lotSize, target.Quantity)
+		remainders[i] = ideal - results[i].Quantity
+		allocated += results[i].Quantity
+	}
+
+	order := make([]int, len(targets))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]+quantityEpsilon
+	})
+
+	leftover := filled - allocated
+	for leftover > quantityEpsilon {
+		progressed := false
+		for _, i := range order {
+			if leftover <= quantityEpsilon {
+				break
+			}
+			room := results[i].Target - results[i].Quantity
+			give := math.Min(math.Min(lotSize, leftover), room)
+			if give <= quantityEpsilon {
+				continue
+			}
+			results[i].Quantity += give
+			leftover -= give
+			progressed = true
+		}
+		if !progressed {
+			break
+		}
+	}
+
+	return results, nil
+}
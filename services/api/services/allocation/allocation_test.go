@@ -0,0 +1,91 @@
+package allocation
+
+import (
+	"math"
+	"testing"
+)
+
+func allocatedQuantities(t *testing.T, filled float64, targets []Target, lotSize float64) []float64 {
+	t.Helper()
+	results, err := ProRata(filled, targets, lotSize)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	quantities := make([]float64, len(results))
+	total := 0.0
+	for i, result := range results {
+		quantities[i] = result.Quantity
+		total += result.Quantity
+		if result.Quantity > result.Target+quantityEpsilon {
+			t.Fatalf("allocation %d exceeds target: %v > %v", i, result.Quantity, result.Target)
+		}
+	}
+	if math.Abs(total-filled) > quantityEpsilon {
+		t.Fatalf("expected allocations to sum to %v, got %v", filled, total)
+	}
+	return quantities
+}
+
+func TestProRataRoundsToLotsAndHandsOutRemainderDeterministically(t *testing.T) {
+	targets := []Target{
+		{AccountID: "acct-a", Quantity: 500000},
+		{AccountID: "acct-b", Quantity: 300000},
+		{AccountID: "acct-c", Quantity: 200000},
+	}
+
+	// 70.1% filled: ideal shares are 350,500 / 210,300 / 140,200
+	got := allocatedQuantities(t, 701000, targets, 1000)
+	want := []float64{351000, 210000, 140000}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("allocation %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+
+	again := allocatedQuantities(t, 701000, targets, 1000)
+	for i := range got {
+		if again[i] != got[i] {
+			t.Fatalf("allocation is not deterministic: %v vs %v", got, again)
+		}
+	}
+}
+
+func TestProRataTiesGoToEarlierTargets(t *testing.T) {
+	targets := []Target{
+		{AccountID: "acct-a", Quantity: 100000},
+		{AccountID: "acct-b", Quantity: 100000},
+		{AccountID: "acct-c", Quantity: 100000},
+	}
+
+	got := allocatedQuantities(t, 100000, targets, 1000)
+	want := []float64{34000, 33000, 33000}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("allocation %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestProRataOddLotAndFullFill(t *testing.T) {
+	targets := []Target{
+		{AccountID: "acct-a", Quantity: 60000},
+		{AccountID: "acct-b", Quantity: 40000},
+	}
+
+	got := allocatedQuantities(t, 50500, targets, 1000)
+	want := []float64{30500, 20000}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("allocation %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+
+	full := allocatedQuantities(t, 100000, targets, 1000)
+	if full[0] != 60000 || full[1] != 40000 {
+		t.Fatalf("expected a full fill to allocate every target, got %v", full)
+	}
+
+	if _, err := ProRata(1000, nil, 1000); err != ErrNoTargets {
+		t.Fatalf("expected ErrNoTargets, got %v", err)
+	}
+}
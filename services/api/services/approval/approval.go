@@ -0,0 +1,354 @@
+package approval
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"instant/services/api/eventbus"
+	"instant/services/api/eventstore"
+	"sort"
+
+	_ "github.com/lib/pq"
+)
+
+// Policy scopes
+const (
+	ScopeGlobal    = "GLOBAL"
+	ScopeHousehold = "HOUSEHOLD"
+	ScopeAccount   = "ACCOUNT"
+)
+
+// Policy statuses
+const (
+	StatusActive   = "ACTIVE"
+	StatusInactive = "INACTIVE"
+	StatusDeleted  = "DELETED"
+)
+
+// maxRequiredApprovals caps how many distinct approvers a policy may demand
+const maxRequiredApprovals = 5
+
+var (
+	ErrPolicyNotFound           = errors.New("approval policy not found")
+	ErrInvalidScope             = errors.New("scope must be GLOBAL, HOUSEHOLD or ACCOUNT")
+	ErrMissingScopeID           = errors.New("scopeId is required for HOUSEHOLD and ACCOUNT policies")
+	ErrInvalidRequiredApprovals = fmt.Errorf("requiredApprovals must be between 1 and %d", maxRequiredApprovals)
+	ErrInvalidStatus            = errors.New("status must be ACTIVE or INACTIVE")
+)
+
+// Criteria are the thresholds and filters an order must meet for a policy to apply.
+// Unset criteria are ignored; set criteria must all match.
+type Criteria struct {
+	MinNotional     *float64 `json:"minNotional,omitempty"`
+	MinQuantity     *float64 `json:"minQuantity,omitempty"`
+	MinDv01         *float64 `json:"minDv01,omitempty"`
+	InstrumentTypes []string `json:"instrumentTypes,omitempty"`
+	OrderTypes      []string `json:"orderTypes,omitempty"`
+}
+
+// Policy is an approval policy as stored in the approval_policies projection
+type Policy struct {
+	PolicyID          string   `json:"policyId"`
+	Name              string   `json:"name"`
+	Description       *string  `json:"description,omitempty"`
+	Status            string   `json:"status"`
+	Scope             string   `json:"scope"`
+	ScopeID           *string  `json:"scopeId,omitempty"`
+	Criteria          Criteria `json:"criteria"`
+	RequiredApprovals int      `json:"requiredApprovals"`
+	Version           int      `json:"version"`
+}
+
+// OrderContext is the order being checked against approval policies
+type OrderContext struct {
+	AccountID    string
+	InstrumentID string
+	OrderType    string
+	Quantity     float64
+	LimitPrice   *float64
+}
+
+// orderMetrics are the values policy criteria are compared with
+type orderMetrics struct {
+	accountID      string
+	householdID    string
+	instrumentType string
+	orderType      string
+	quantity       float64
+	notional       float64
+	dv01           float64
+}
+
+// MatchedPolicy records a policy that applied to an order
+type MatchedPolicy struct {
+	PolicyID          string `json:"policyId"`
+	Name              string `json:"name"`
+	Version           int    `json:"version"`
+	RequiredApprovals int    `json:"requiredApprovals"`
+}
+
+// Requirement is the approval an order needs before it can be released
+type Requirement struct {
+	RequiredApprovals int                    `json:"requiredApprovals"`
+	Policies          []MatchedPolicy        `json:"policies"`
+	Metrics           map[string]interface{} `json:"metrics"`
+}
+
+// Service stores approval policies and evaluates orders against them
+type Service struct {
+	eventStore *eventstore.EventStore
+	eventBus   *eventbus.EventBus
+	db         *sql.DB
+}
+
+// NewService creates a new approval policy service
+func NewService(db *sql.DB, es *eventstore.EventStore, eb *eventbus.EventBus) (*Service, error) {
+	return &Service{
+		eventStore: es,
+		eventBus:   eb,
+		db:         db,
+	}, nil
+}
+
+// Evaluate returns how many approvals an order needs under the active policies.
+// When several policies match, the strictest one wins.
+func (s *Service) Evaluate(order OrderContext) (*Requirement, error) {
+	metrics, err := s.computeMetrics(order)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := s.activePolicies(metrics.accountID, metrics.householdID)
+	if err != nil {
+		return nil, err
+	}
+
+	return evaluatePolicies(policies, metrics), nil
+}
+
+func evaluatePolicies(policies []Policy, metrics orderMetrics) *Requirement {
+	requirement := &Requirement{
+		Policies: []MatchedPolicy{},
+		Metrics: map[string]interface{}{
+			"order.quantity":      metrics.quantity,
+			"order.notional":      metrics.notional,
+			"order.dv01":          metrics.dv01,
+			"order.orderType":     metrics.orderType,
+			"instrument.type":     metrics.instrumentType,
+			"account.householdId": metrics.householdID,
+		},
+	}
+
+	for _, policy := range policies {
+		if !policy.matches(metrics) {
+			continue
+		}
+		requirement.Policies = append(requirement.Policies, MatchedPolicy{
+			PolicyID:          policy.PolicyID,
+			Name:              policy.Name,
+			Version:           policy.Version,
+			RequiredApprovals: policy.RequiredApprovals,
+		})
+		if policy.RequiredApprovals > requirement.RequiredApprovals {
+			requirement.RequiredApprovals = policy.RequiredApprovals
+		}
+	}
+
+	sort.Slice(requirement.Policies, func(i, j int) bool {
+		return requirement.Policies[i].RequiredApprovals > requirement.Policies[j].RequiredApprovals
+	})
+
+	return requirement
+}
+
+// matches reports whether an order falls under the policy. Thresholds are
+// exclusive: a policy with minNotional 50,000,000 applies above $50M.
+func (p Policy) matches(metrics orderMetrics) bool {
+	switch p.Scope {
+	case ScopeAccount:
+		if p.ScopeID == nil || *p.ScopeID != metrics.accountID {
+			return false
+		}
+	case ScopeHousehold:
+		if p.ScopeID == nil || *p.ScopeID != metrics.householdID {
+			return false
+		}
+	}
+
+	criteria := p.Criteria
+	if criteria.MinNotional != nil && metrics.notional <= *criteria.MinNotional {
+		return false
+	}
+	if criteria.MinQuantity != nil && metrics.quantity <= *criteria.MinQuantity {
+		return false
+	}
+	if criteria.MinDv01 != nil && metrics.dv01 <= *criteria.MinDv01 {
+		return false
+	}
+	if len(criteria.InstrumentTypes) > 0 && !contains(criteria.InstrumentTypes, metrics.instrumentType) {
+		return false
+	}
+	if len(criteria.OrderTypes) > 0 && !contains(criteria.OrderTypes, metrics.orderType) {
+		return false
+	}
+	return true
+}
+
+// computeMetrics prices the order the same way compliance does: limit price
+// for LIMIT and YIELD_LIMIT orders, otherwise the instrument's ask. Notional
+// and DV01 are in dollars, so policy thresholds are too.
+func (s *Service) computeMetrics(order OrderContext) (orderMetrics, error) {
+	metrics := orderMetrics{
+		accountID: order.AccountID,
+		orderType: order.OrderType,
+		quantity:  order.Quantity,
+	}
+
+	var householdID sql.NullString
+	err := s.db.QueryRow(`SELECT "householdId" FROM accounts WHERE "accountId" = $1`, order.AccountID).Scan(&householdID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return metrics, fmt.Errorf("failed to fetch account: %w", err)
+	}
+	metrics.householdID = householdID.String
+
+	price := 100.0
+	modifiedDuration := 0.0
+	var (
+		instrumentType sql.NullString
+		askPrice       sql.NullFloat64
+		duration       sql.NullFloat64
+	)
+	err = s.db.QueryRow(`
+		SELECT type::text, "askPrice", "askModifiedDuration"
+		FROM instruments
+		WHERE cusip = $1
+	`, order.InstrumentID).Scan(&instrumentType, &askPrice, &duration)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return metrics, fmt.Errorf("failed to fetch instrument: %w", err)
+	}
+	metrics.instrumentType = instrumentType.String
+	if askPrice.Valid {
+		price = askPrice.Float64
+	}
+	if duration.Valid {
+		modifiedDuration = duration.Float64
+	}
+	if (order.OrderType == "LIMIT" || order.OrderType == "YIELD_LIMIT") && order.LimitPrice != nil {
+		price = *order.LimitPrice
+	}
+
+	metrics.notional = notional(order.Quantity, price)
+	metrics.dv01 = metrics.notional * modifiedDuration * 0.0001
+
+	return metrics, nil
+}
+
+// notional is the dollar value of a par quantity at a price per 100 par
+func notional(quantity, price float64) float64 {
+	return quantity * price / 100
+}
+
+func (s *Service) activePolicies(accountID, householdID string) ([]Policy, error) {
+	rows, err := s.db.Query(`
+		SELECT "policyId", name, description, status, scope, "scopeId", criteria, "requiredApprovals", version
+		FROM approval_policies
+		WHERE status = $1
+		  AND (
+		    scope = 'GLOBAL'
+		    OR (scope = 'HOUSEHOLD' AND "scopeId" = $2)
+		    OR (scope = 'ACCOUNT' AND "scopeId" = $3)
+		  )
+	`, StatusActive, householdID, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query approval policies: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPolicies(rows)
+}
+
+// ListPolicies returns every policy that has not been deleted
+func (s *Service) ListPolicies() ([]Policy, error) {
+	rows, err := s.db.Query(`
+		SELECT "policyId", name, description, status, scope, "scopeId", criteria, "requiredApprovals", version
+		FROM approval_policies
+		WHERE status <> $1
+		ORDER BY "requiredApprovals" DESC, name ASC
+	`, StatusDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query approval policies: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPolicies(rows)
+}
+
+// GetPolicy returns a single policy
+func (s *Service) GetPolicy(policyID string) (*Policy, error) {
+	rows, err := s.db.Query(`
+		SELECT "policyId", name, description, status, scope, "scopeId", criteria, "requiredApprovals", version
+		FROM approval_policies
+		WHERE "policyId" = $1
+	`, policyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query approval policy: %w", err)
+	}
+	defer rows.Close()
+
+	policies, err := scanPolicies(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		return nil, ErrPolicyNotFound
+	}
+	return &policies[0], nil
+}
+
+func scanPolicies(rows *sql.Rows) ([]Policy, error) {
+	policies := []Policy{}
+	for rows.Next() {
+		var (
+			policy       Policy
+			description  sql.NullString
+			scopeID      sql.NullString
+			criteriaJSON []byte
+		)
+		if err := rows.Scan(
+			&policy.PolicyID,
+			&policy.Name,
+			&description,
+			&policy.Status,
+			&policy.Scope,
+			&scopeID,
+			&criteriaJSON,
+			&policy.RequiredApprovals,
+			&policy.Version,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan approval policy: %w", err)
+		}
+		if description.Valid {
+			policy.Description = &description.String
+		}
+		if scopeID.Valid {
+			policy.ScopeID = &scopeID.String
+		}
+		if len(criteriaJSON) > 0 {
+			if err := json.Unmarshal(criteriaJSON, &policy.Criteria); err != nil {
+				return nil, fmt.Errorf("invalid criteria for policy %s: %w", policy.PolicyID, err)
+			}
+		}
+		policies = append(policies, policy)
+	}
+	return policies, rows.Err()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package approval
+
+import "testing"
+
+func floatPtr(v float64) *float64 { return &v }
+
+func stringPtr(v string) *string { return &v }
+
+func TestEvaluatePoliciesUsesStrictestMatch(t *testing.T) {
+	policies := []Policy{
+		{PolicyID: "large", Name: "Large orders", Scope: ScopeGlobal, RequiredApprovals: 1,
+			Criteria: Criteria{MinNotional: floatPtr(10_000_000)}},
+		{PolicyID: "very-large", Name: "Very large orders", Scope: ScopeGlobal, RequiredApprovals: 2,
+			Criteria: Criteria{MinNotional: floatPtr(50_000_000)}},
+		{PolicyID: "bonds", Name: "Long bonds", Scope: ScopeGlobal, RequiredApprovals: 1,
+			Criteria: Criteria{InstrumentTypes: []string{"bond"}}},
+	}
+
+	requirement := evaluatePolicies(policies, orderMetrics{notional: 60_000_000, instrumentType: "note"})
+	if requirement.RequiredApprovals != 2 {
+		t.Fatalf("expected 2 approvals, got %d", requirement.RequiredApprovals)
+	}
+	if len(requirement.Policies) != 2 || requirement.Policies[0].PolicyID != "very-large" {
+		t.Fatalf("unexpected matched policies: %+v", requirement.Policies)
+	}
+
+	requirement = evaluatePolicies(policies, orderMetrics{notional: 50_000_000, instrumentType: "note"})
+	if requirement.RequiredApprovals != 1 {
+		t.Fatalf("expected threshold to be exclusive, got %d approvals", requirement.RequiredApprovals)
+	}
+
+	requirement = evaluatePolicies(policies, orderMetrics{notional: 1_000, instrumentType: "note"})
+	if requirement.RequiredApprovals != 0 || len(requirement.Policies) != 0 {
+		t.Fatalf("expected no approval, got %+v", requirement)
+	}
+}
+
+func TestNotionalIsInDollars(t *testing.T) {
+	policies := []Policy{
+		{PolicyID: "very-large", Name: "Above $50M", Scope: ScopeGlobal, RequiredApprovals: 2,
+			Criteria: Criteria{MinNotional: floatPtr(50_000_000)}},
+	}
+
+	// 500,000 par at 99.5 is a $497,500 order
+	small := notional(500_000, 99.5)
+	if small != 497_500 {
+		t.Fatalf("expected 497,500, got %v", small)
+	}
+	if requirement := evaluatePolicies(policies, orderMetrics{notional: small}); requirement.RequiredApprovals != 0 {
+		t.Fatalf("expected a $497,500 order to need no approval, got %+v", requirement)
+	}
+	if requirement := evaluatePolicies(policies, orderMetrics{notional: notional(60_000_000, 99.5)}); requirement.RequiredApprovals != 2 {
+		t.Fatalf("expected a $59.7M order to need 2 approvals, got %+v", requirement)
+	}
+}
+
+func TestPolicyMatchesScopeAndFilters(t *testing.T) {
+	policy := Policy{
+		Scope:             ScopeHousehold,
+		ScopeID:           stringPtr("hh-1"),
+		RequiredApprovals: 1,
+		Criteria: Criteria{
+			MinDv01:    floatPtr(500),
+			OrderTypes: []string{"MARKET"},
+		},
+	}
+
+	metrics := orderMetrics{householdID: "hh-1", orderType: "MARKET", dv01: 750}
+	if !policy.matches(metrics) {
+		t.Fatal("expected policy to match")
+	}
+
+	metrics.householdID = "hh-2"
+	if policy.matches(metrics) {
+		t.Fatal("expected household scope to exclude other households")
+	}
+
+	metrics.householdID = "hh-1"
+	metrics.orderType = "LIMIT"
+	if policy.matches(metrics) {
+		t.Fatal("expected order type filter to exclude LIMIT orders")
+	}
+}
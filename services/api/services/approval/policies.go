@@ -0,0 +1,180 @@
+package approval
+
+import (
+	"errors"
+	"instant/services/api/events"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// PolicyInput is the editable part of an approval policy
+type PolicyInput struct {
+	Name              string
+	Description       *string
+	Status            string
+	Scope             string
+	ScopeID           *string
+	Criteria          Criteria
+	RequiredApprovals int
+	ActorID           string
+}
+
+// CreatePolicy validates a new policy and emits ApprovalPolicyCreated
+func (s *Service) CreatePolicy(input PolicyInput, correlationID string) (string, error) {
+	if input.Status == "" {
+		input.Status = StatusActive
+	}
+	if input.Scope == "" {
+		input.Scope = ScopeGlobal
+	}
+	if err := validatePolicyInput(input); err != nil {
+		return "", err
+	}
+
+	policyID := uuid.New().String()
+	event := events.NewEvent(
+		events.EventApprovalPolicyCreated,
+		events.AggregateApprovalPolicy,
+		policyID,
+		input.ActorID,
+		"user",
+		correlationID,
+		buildPolicyPayload(policyID, input, 1),
+	)
+
+	if err := s.eventStore.Append(event); err != nil {
+		return "", err
+	}
+	s.eventBus.Publish(event)
+
+	return policyID, nil
+}
+
+// UpdatePolicy replaces a policy's definition and emits ApprovalPolicyUpdated
+// carrying both the new definition and the one it replaced
+func (s *Service) UpdatePolicy(policyID string, input PolicyInput, correlationID string) (int, error) {
+	existing, err := s.GetPolicy(policyID)
+	if err != nil {
+		return 0, err
+	}
+	if existing.Status == StatusDeleted {
+		return 0, ErrPolicyNotFound
+	}
+
+	if input.Status == "" {
+		input.Status = existing.Status
+	}
+	if input.Scope == "" {
+		input.Scope = existing.Scope
+		input.ScopeID = existing.ScopeID
+	}
+	if err := validatePolicyInput(input); err != nil {
+		return 0, err
+	}
+
+	version := existing.Version + 1
+	payload := buildPolicyPayload(policyID, input, version)
+	payload["previous"] = existing
+
+	event := events.NewEvent(
+		events.EventApprovalPolicyUpdated,
+		events.AggregateApprovalPolicy,
+		policyID,
+		input.ActorID,
+		"user",
+		correlationID,
+		payload,
+	)
+
+	if err := s.eventStore.Append(event); err != nil {
+		return 0, err
+	}
+	s.eventBus.Publish(event)
+
+	return version, nil
+}
+
+// DeletePolicy retires a policy and emits ApprovalPolicyDeleted. The row is
+// kept so past approval requests can still be traced to it.
+func (s *Service) DeletePolicy(policyID, actorID, correlationID string) error {
+	if actorID == "" {
+		return errors.New("deletedBy is required")
+	}
+
+	existing, err := s.GetPolicy(policyID)
+	if err != nil {
+		return err
+	}
+	if existing.Status == StatusDeleted {
+		return ErrPolicyNotFound
+	}
+
+	event := events.NewEvent(
+		events.EventApprovalPolicyDeleted,
+		events.AggregateApprovalPolicy,
+		policyID,
+		actorID,
+		"user",
+		correlationID,
+		map[string]interface{}{
+			"policyId":  policyID,
+			"version":   existing.Version + 1,
+			"status":    StatusDeleted,
+			"deletedBy": actorID,
+			"previous":  existing,
+		},
+	)
+
+	if err := s.eventStore.Append(event); err != nil {
+		return err
+	}
+	s.eventBus.Publish(event)
+
+	return nil
+}
+
+func validatePolicyInput(input PolicyInput) error {
+	if strings.TrimSpace(input.Name) == "" {
+		return errors.New("name is required")
+	}
+	if input.ActorID == "" {
+		return errors.New("actorId is required")
+	}
+	if input.Status != StatusActive && input.Status != StatusInactive {
+		return ErrInvalidStatus
+	}
+	switch input.Scope {
+	case ScopeGlobal:
+	case ScopeHousehold, ScopeAccount:
+		if input.ScopeID == nil || *input.ScopeID == "" {
+			return ErrMissingScopeID
+		}
+	default:
+		return ErrInvalidScope
+	}
+	if input.RequiredApprovals < 1 || input.RequiredApprovals > maxRequiredApprovals {
+		return ErrInvalidRequiredApprovals
+	}
+	return nil
+}
+
+func buildPolicyPayload(policyID string, input PolicyInput, version int) map[string]interface{} {
+	payload := map[string]interface{}{
+		"policyId":          policyID,
+		"name":              input.Name,
+		"status":            input.Status,
+		"scope":             input.Scope,
+		"criteria":          input.Criteria,
+		"requiredApprovals": input.RequiredApprovals,
+		"version":           version,
+		"updatedBy":         input.ActorID,
+	}
+	if input.Description != nil {
+		payload["description"] = *input.Description
+	}
+	if input.Scope != ScopeGlobal && input.ScopeID != nil {
+		payload["scopeId"] = *input.ScopeID
+	}
+	return payload
+}
@@ -0,0 +1,205 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSettlementCycle is T+1, the US Treasury standard
+const DefaultSettlementCycle = 1
+
+// earlyCloseHour is when the bond market closes on an early close day, in
+// the calendar's time zone
+const earlyCloseHour = 14
+
+// ErrInvalidSettlementCycle is returned for a settlement cycle that is not
+// "type=days" with a non-negative number of days
+var ErrInvalidSettlementCycle = errors.New("settlement cycles must be instrumentType=days")
+
+// Calendar answers business-day questions for the US bond market in a time
+// zone, and settles trades on each instrument type's settlement cycle. The
+// dates it returns are calendar dates at midnight UTC, the way settlement
+// dates are stored.
+type Calendar struct {
+	location     *time.Location
+	defaultCycle int
+	cycles       map[string]int
+
+	mu    sync.Mutex
+	years map[int]map[string]SpecialDay
+}
+
+// New creates a calendar. cycles overrides defaultCycle, in business days,
+// per instrument type.
+func New(location *time.Location, defaultCycle int, cycles map[string]int) *Calendar {
+	if location == nil {
+		location = time.UTC
+	}
+	if defaultCycle < 0 {
+		defaultCycle = DefaultSettlementCycle
+	}
+	if cycles == nil {
+		cycles = map[string]int{}
+	}
+	return &Calendar{
+		location:     location,
+		defaultCycle: defaultCycle,
+		cycles:       cycles,
+		years:        map[int]map[string]SpecialDay{},
+	}
+}
+
+// ParseSettlementCycles parses entries such as "bill=0" into settlement
+// cycles per instrument type
+func ParseSettlementCycles(entries []string) (map[string]int, error) {
+	cycles := map[string]int{}
+	for _, entry := range entries {
+		instrumentType, days, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSettlementCycle, entry)
+		}
+		parsed, err := strconv.Atoi(strings.TrimSpace(days))
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSettlementCycle, entry)
+		}
+		cycles[strings.TrimSpace(instrumentType)] = parsed
+	}
+	return cycles, nil
+}
+
+// Location is the calendar's time zone
+func (c *Calendar) Location() *time.Location {
+	return c.location
+}
+
+// SettlementCycle is the number of business days an instrument type settles after trade date
+func (c *Calendar) SettlementCycle(instrumentType string) int {
+	if cycle, ok := c.cycles[instrumentType]; ok {
+		return cycle
+	}
+	return c.defaultCycle
+}
+
+// SettlementCycles lists the default cycle and the per-type overrides
+func (c *Calendar) SettlementCycles() map[string]int {
+	cycles := map[string]int{"default": c.defaultCycle}
+	for instrumentType, cycle := range c.cycles {
+		cycles[instrumentType] = cycle
+	}
+	return cycles
+}
+
+// Special returns the holiday or early close on the date of t in the
+// calendar's time zone
+func (c *Calendar) Special(t time.Time) (SpecialDay, bool) {
+	local := t.In(c.location)
+	day, ok := c.year(local.Year())[local.Format(dateLayout)]
+	return day, ok
+}
+
+// IsBusinessDay reports whether the bond market is open on the date of t
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	return c.isBusinessDate(c.date(t))
+}
+
+// TradeDate is the date of t, rolled forward to the next business day when
+// the market is closed. It is returned as midnight UTC.
+func (c *Calendar) TradeDate(t time.Time) time.Time {
+	date := c.date(t)
+	for !c.isBusinessDate(date) {
+		date = date.AddDate(0, 0, 1)
+	}
+	return date
+}
+
+// LatestBusinessDay is the date of t, rolled back to the previous business
+// day when the market is closed. It is returned as midnight UTC.
+func (c *Calendar) LatestBusinessDay(t time.Time) time.Time {
+	date := c.date(t)
+	for !c.isBusinessDate(date) {
+		date = date.AddDate(0, 0, -1)
+	}
+	return date
+}
+
+// AddBusinessDays moves n business days from the date of t. It is returned
+// as midnight UTC.
+func (c *Calendar) AddBusinessDays(t time.Time, n int) time.Time {
+	return c.addBusinessDays(c.date(t), n)
+}
+
+// SettlementDate is when a trade at t in an instrument type settles: its
+// settlement cycle in business days after the trade date
+func (c *Calendar) SettlementDate(t time.Time, instrumentType string) time.Time {
+	return c.addBusinessDays(c.TradeDate(t), c.SettlementCycle(instrumentType))
+}
+
+// EarlyClose returns when the market closes on the date of t if it closes early
+func (c *Calendar) EarlyClose(t time.Time) (time.Time, bool) {
+	day, ok := c.Special(t)
+	if !ok || day.Kind != KindEarlyClose {
+		return time.Time{}, false
+	}
+	local := t.In(c.location)
+	return time.Date(local.Year(), local.Month(), local.Day(), earlyCloseHour, 0, 0, 0, c.location), true
+}
+
+// Year lists a year's holidays and early closes in date order
+func (c *Calendar) Year(year int) []SpecialDay {
+	days := append(SIFMAHolidays(year), SIFMAEarlyCloses(year)...)
+	sortDays(days)
+	return days
+}
+
+// date is the calendar date of t in the calendar's time zone, as midnight UTC
+func (c *Calendar) date(t time.Time) time.Time {
+	local := t.In(c.location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// addBusinessDays moves n business days from a date returned by date
+func (c *Calendar) addBusinessDays(date time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		date = date.AddDate(0, 0, step)
+		if c.isBusinessDate(date) {
+			n--
+		}
+	}
+	return date
+}
+
+// isBusinessDate reports whether the market is open on a date returned by date
+func (c *Calendar) isBusinessDate(date time.Time) bool {
+	if isWeekend(date) {
+		return false
+	}
+	day, ok := c.year(date.Year())[date.Format(dateLayout)]
+	return !ok || day.Kind != KindHoliday
+}
+
+// year returns a year's special days by date, generating them once
+func (c *Calendar) year(year int) map[string]SpecialDay {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if days, ok := c.years[year]; ok {
+		return days
+	}
+	days := map[string]SpecialDay{}
+	for _, day := range SIFMAEarlyCloses(year) {
+		days[day.Date] = day
+	}
+	for _, day := range SIFMAHolidays(year) {
+		days[day.Date] = day
+	}
+	c.years[year] = days
+	return days
+}
@@ -0,0 +1,110 @@
+package calendar
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newYorkCalendar(t *testing.T, cycles map[string]int) *Calendar {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return New(location, DefaultSettlementCycle, cycles)
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestSIFMAHolidaysObserveWeekendRules(t *testing.T) {
+	holidays := map[string]string{}
+	for _, day := range SIFMAHolidays(2026) {
+		holidays[day.Date] = day.Name
+	}
+	for dateValue, name := range map[string]string{
+		"2026-04-03": "Good Friday",
+		"2026-05-25": "Memorial Day",
+		"2026-07-03": "Independence Day",
+		"2026-11-26": "Thanksgiving Day",
+	} {
+		if holidays[dateValue] != name {
+			t.Fatalf("expected %s on %s, got %q", name, dateValue, holidays[dateValue])
+		}
+	}
+
+	// New Year's Day 2022 fell on a Saturday and was not observed
+	for _, day := range SIFMAHolidays(2022) {
+		if day.Name == "New Year's Day" {
+			t.Fatalf("expected no observed New Year's Day in 2022, got %s", day.Date)
+		}
+	}
+}
+
+func TestSIFMAEarlyCloses(t *testing.T) {
+	closes := map[string]bool{}
+	for _, day := range SIFMAEarlyCloses(2026) {
+		closes[day.Date] = true
+	}
+	for _, dateValue := range []string{"2026-04-02", "2026-05-22", "2026-07-02", "2026-11-27", "2026-12-24", "2026-12-31"} {
+		if !closes[dateValue] {
+			t.Fatalf("expected an early close on %s", dateValue)
+		}
+	}
+}
+
+func TestSettlementDateSkipsWeekendsAndHolidays(t *testing.T) {
+	cal := newYorkCalendar(t, map[string]int{"bill": 0})
+
+	// Friday trades settle on Monday
+	if got := cal.SettlementDate(time.Date(2026, 3, 6, 15, 0, 0, 0, time.UTC), "note"); !got.Equal(date(2026, 3, 9)) {
+		t.Fatalf("expected 2026-03-09, got %s", got)
+	}
+	// Thursday before the observed Independence Day settles on Monday
+	if got := cal.SettlementDate(time.Date(2026, 7, 2, 15, 0, 0, 0, time.UTC), "bond"); !got.Equal(date(2026, 7, 6)) {
+		t.Fatalf("expected 2026-07-06, got %s", got)
+	}
+	// A Saturday trade is dated Monday and settles same day for bills
+	if got := cal.SettlementDate(time.Date(2026, 3, 7, 15, 0, 0, 0, time.UTC), "bill"); !got.Equal(date(2026, 3, 9)) {
+		t.Fatalf("expected 2026-03-09, got %s", got)
+	}
+	// 22:00 New York on Wednesday 25 November is still the 25th there
+	if got := cal.SettlementDate(time.Date(2026, 11, 26, 3, 0, 0, 0, time.UTC), "note"); !got.Equal(date(2026, 11, 27)) {
+		t.Fatalf("expected 2026-11-27, got %s", got)
+	}
+}
+
+func TestLatestBusinessDayRollsBack(t *testing.T) {
+	cal := newYorkCalendar(t, nil)
+	if got := cal.LatestBusinessDay(time.Date(2026, 4, 5, 15, 0, 0, 0, time.UTC)); !got.Equal(date(2026, 4, 2)) {
+		t.Fatalf("expected the Thursday before Good Friday, got %s", got)
+	}
+}
+
+func TestEarlyClose(t *testing.T) {
+	cal := newYorkCalendar(t, nil)
+	earlyClose, ok := cal.EarlyClose(time.Date(2026, 11, 27, 12, 0, 0, 0, time.UTC))
+	if !ok || !earlyClose.Equal(time.Date(2026, 11, 27, 19, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected a 14:00 New York close, got %s (%v)", earlyClose, ok)
+	}
+	if _, ok := cal.EarlyClose(time.Date(2026, 11, 30, 12, 0, 0, 0, time.UTC)); ok {
+		t.Fatalf("expected no early close on an ordinary Monday")
+	}
+}
+
+func TestParseSettlementCycles(t *testing.T) {
+	cycles, err := ParseSettlementCycles([]string{"bill=0", " tips = 2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cycles["bill"] != 0 || cycles["tips"] != 2 {
+		t.Fatalf("unexpected cycles: %v", cycles)
+	}
+
+	for _, entry := range []string{"bill", "note=-1", "bond=x"} {
+		if _, err := ParseSettlementCycles([]string{entry}); !errors.Is(err, ErrInvalidSettlementCycle) {
+			t.Fatalf("expected ErrInvalidSettlementCycle for %q, got %v", entry, err)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package calendar
+
+import (
+	"sort"
+	"time"
+)
+
+// Kinds of special day
+const (
+	KindHoliday    = "HOLIDAY"
+	KindEarlyClose = "EARLY_CLOSE"
+)
+
+// dateLayout formats calendar dates
+const dateLayout = "2006-01-02"
+
+// SpecialDay is a day the US bond market is closed or closes early
+type SpecialDay struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// holidayRule places a holiday in a year. observed is the day the market
+// closes; ok is false when the holiday is not observed that year.
+type holidayRule struct {
+	name string
+	date func(year int) (observed time.Time, ok bool)
+	// earlyCloseBefore is true when the business day before the holiday
+	// closes early
+	earlyCloseBefore bool
+}
+
+// sifmaRules are SIFMA's recommended US bond market full closes. Fixed-date
+// holidays falling on a Sunday are observed on the Monday. Independence Day,
+// Juneteenth and Christmas falling on a Saturday are observed on the Friday;
+// New Year's Day and Veterans Day falling on a Saturday are not observed.
+var sifmaRules = []holidayRule{
+	{name: "New Year's Day", date: func(year int) (time.Time, bool) {
+		return fixedHoliday(year, time.January, 1, false)
+	}, earlyCloseBefore: true},
+	{name: "Martin Luther King Jr. Day", date: func(year int) (time.Time, bool) {
+		return nthWeekday(year, time.January, time.Monday, 3), true
+	}},
+	{name: "Presidents' Day", date: func(year int) (time.Time, bool) {
+		return nthWeekday(year, time.February, time.Monday, 3), true
+	}},
+	{name: "Good Friday", date: func(year int) (time.Time, bool) {
+		return easterSunday(year).AddDate(0, 0, -2), true
+	}, earlyCloseBefore: true},
+	{name: "Memorial Day", date: func(year int) (time.Time, bool) {
+		return lastWeekday(year, time.May, time.Monday), true
+	}, earlyCloseBefore: true},
+	{name: "Juneteenth", date: func(year int) (time.Time, bool) {
+		if year < 2022 {
+			return time.Time{}, false
+		}
+		return fixedHoliday(year, time.June, 19, true)
+	}},
+	{name: "Independence Day", date: func(year int) (time.Time, bool) {
+		return fixedHoliday(year, time.July, 4, true)
+	}, earlyCloseBefore: true},
+	{name: "Labor Day", date: func(year int) (time.Time, bool) {
+		return nthWeekday(year, time.September, time.Monday, 1), true
+	}},
+	{name: "Columbus Day", date: func(year int) (time.Time, bool) {
+		return nthWeekday(year, time.October, time.Monday, 2), true
+	}},
+	{name: "Veterans Day", date: func(year int) (time.Time, bool) {
+		return fixedHoliday(year, time.November, 11, false)
+	}},
+	{name: "Thanksgiving Day", date: func(year int) (time.Time, bool) {
+		return nthWeekday(year, time.November, time.Thursday, 4), true
+	}},
+	{name: "Christmas Day", date: func(year int) (time.Time, bool) {
+		return fixedHoliday(year, time.December, 25, true)
+	}, earlyCloseBefore: true},
+}
+
+// SIFMAHolidays returns the US bond market full closes in a year
+func SIFMAHolidays(year int) []SpecialDay {
+	days := []SpecialDay{}
+	for _, rule := range sifmaRules {
+		if date, ok := rule.date(year); ok && date.Year() == year {
+			days = append(days, SpecialDay{Date: date.Format(dateLayout), Name: rule.name, Kind: KindHoliday})
+		}
+	}
+	sortDays(days)
+	return days
+}
+
+// SIFMAEarlyCloses returns the days in a year the US bond market closes
+// early: the business day before New Year's Day, Good Friday, Memorial Day,
+// Independence Day and Christmas, and the day after Thanksgiving
+func SIFMAEarlyCloses(year int) []SpecialDay {
+	closed := map[string]bool{}
+	for _, y := range []int{year, year + 1} {
+		for _, day := range SIFMAHolidays(y) {
+			closed[day.Date] = true
+		}
+	}
+
+	days := []SpecialDay{}
+	add := func(date time.Time, name string) {
+		if date.Year() == year {
+			days = append(days, SpecialDay{Date: date.Format(dateLayout), Name: name, Kind: KindEarlyClose})
+		}
+	}
+	for _, y := range []int{year, year + 1} {
+		for _, rule := range sifmaRules {
+			if !rule.earlyCloseBefore {
+				continue
+			}
+			date, ok := rule.date(y)
+			if !ok {
+				// An unobserved New Year's Day still closes the day before early
+				date = time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC)
+			}
+			add(previousOpenDay(date, closed), "Before "+rule.name)
+		}
+	}
+	thanksgiving := nthWeekday(year, time.November, time.Thursday, 4)
+	add(thanksgiving.AddDate(0, 0, 1), "Day after Thanksgiving Day")
+
+	sortDays(days)
+	return dedupe(days)
+}
+
+func sortDays(days []SpecialDay) {
+	sort.SliceStable(days, func(i, j int) bool { return days[i].Date < days[j].Date })
+}
+
+func dedupe(days []SpecialDay) []SpecialDay {
+	unique := days[:0]
+	for i, day := range days {
+		if i > 0 && day.Date == days[i-1].Date {
+			continue
+		}
+		unique = append(unique, day)
+	}
+	return unique
+}
+
+// previousOpenDay is the weekday before date that is not a full close
+func previousOpenDay(date time.Time, closed map[string]bool) time.Time {
+	day := date.AddDate(0, 0, -1)
+	for isWeekend(day) || closed[day.Format(dateLayout)] {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// fixedHoliday observes a fixed-date holiday on the Monday when it falls on a
+// Sunday, and on the Friday when it falls on a Saturday if saturdayToFriday
+func fixedHoliday(year int, month time.Month, day int, saturdayToFriday bool) (time.Time, bool) {
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	switch date.Weekday() {
+	case time.Sunday:
+		return date.AddDate(0, 0, 1), true
+	case time.Saturday:
+		if !saturdayToFriday {
+			return time.Time{}, false
+		}
+		return date.AddDate(0, 0, -1), true
+	}
+	return date, true
+}
+
+// nthWeekday is the nth given weekday of a month
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday is the last given weekday of a month
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+	offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+	return last.AddDate(0, 0, -offset)
+}
+
+// easterSunday is Western Easter, by the anonymous Gregorian algorithm
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+func isWeekend(date time.Time) bool {
+	return date.Weekday() == time.Saturday || date.Weekday() == time.Sunday
+}
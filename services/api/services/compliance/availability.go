@@ -0,0 +1,334 @@
+package compliance
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Severities of the built-in availability checks
+const (
+	AvailabilityOff   = "OFF"
+	AvailabilityWarn  = "WARN"
+	AvailabilityBlock = "BLOCK"
+)
+
+// AvailabilityPolicy sets how the built-in pre-trade holdings and cash checks
+// treat a shortfall: BLOCK rejects the order, WARN sends it for approval and
+// OFF skips the check
+type AvailabilityPolicy struct {
+	Holdings string
+	Cash     string
+}
+
+// DefaultAvailabilityPolicy blocks sells the account cannot deliver and buys
+// it cannot pay for
+var DefaultAvailabilityPolicy = AvailabilityPolicy{
+	Holdings: AvailabilityBlock,
+	Cash:     AvailabilityBlock,
+}
+
+// ParseAvailabilitySeverity reads a check severity from configuration
+func ParseAvailabilitySeverity(value string) (string, error) {
+	switch severity := strings.ToUpper(strings.TrimSpace(value)); severity {
+	case AvailabilityOff, AvailabilityWarn, AvailabilityBlock:
+		return severity, nil
+	}
+	return "", fmt.Errorf("invalid availability check severity %q (expected BLOCK, WARN or OFF)", value)
+}
+
+// The availability checks are not stored in compliance_rules; their
+// evaluations and violations are recorded under these fixed rule IDs
+var (
+	holdingsAvailableRule = ruleRecord{
+		ruleID:  "builtin:holdings-available",
+		ruleKey: "HOLDINGS_AVAILABLE",
+		name:    "Holdings available",
+		version: 1,
+		scope:   "ACCOUNT",
+	}
+	cashAvailableRule = ruleRecord{
+		ruleID:  "builtin:cash-available",
+		ruleKey: "CASH_AVAILABLE",
+		name:    "Cash available",
+		version: 1,
+		scope:   "ACCOUNT",
+	}
+)
+
+// workingOrderStates are the order states that still commit holdings or cash
+const workingOrderStates = `('DRAFT', 'APPROVAL_PENDING', 'APPROVED', 'SENT', 'PARTIALLY_FILLED')`
+
+// SetAvailabilityPolicy changes the severity of the holdings and cash checks
+func (s *Service) SetAvailabilityPolicy(policy AvailabilityPolicy) {
+	s.availability = policy
+}
+
+// checkAvailability runs the built-in pre-trade checks: a SELL may not exceed
+// the account's position less what open sell orders already commit, unless
+// the account allows short sales, and a BUY may not cost more than the
+// account's cash less what open buy orders commit. Accounts without a cash
+// balance are not cash-checked.
+func (s *Service) checkAvailability(order OrderSnapshot, account *accountSnapshot, orderMetrics map[string]interface{}, result *Result, actorID, correlationID string) error {
+	switch order.Side {
+	case "SELL":
+		if s.availability.Holdings == AvailabilityOff {
+			return nil
+		}
+		return s.checkHoldings(order, account, result, actorID, correlationID)
+	case "BUY":
+		if s.availability.Cash == AvailabilityOff || !account.cashBalance.Valid {
+			return nil
+		}
+		return s.checkCash(order, account, orderMetrics, result, actorID, correlationID)
+	}
+	return nil
+}
+
+func (s *Service) checkHoldings(order OrderSnapshot, account *accountSnapshot, result *Result, actorID, correlationID string) error {
+	position, err := s.availabilityData.position(order.AccountID, order.InstrumentID)
+	if err != nil {
+		return err
+	}
+	openSells, err := s.openOrderCommitment(order, "quantity", order.InstrumentID)
+	if err != nil {
+		return err
+	}
+	quantity, err := s.unfilledQuantity(order)
+	if err != nil {
+		return err
+	}
+
+	available := position - openSells
+	snapshot := map[string]interface{}{
+		"order.quantity":          quantity,
+		"position.quantity":       position,
+		"openOrders.sellQuantity": openSells,
+		"available.quantity":      available,
+		"account.allowShortSales": account.allowShortSales,
+	}
+
+	passes := quantity <= available+availabilityTolerance
+	explanation := fmt.Sprintf("SELL of %.2f is covered by available holdings of %.2f", quantity, available)
+	if !passes {
+		explanation = fmt.Sprintf(
+			"SELL of %.2f exceeds available holdings of %.2f (position %.2f less %.2f on open sell orders)",
+			quantity, available, position, openSells,
+		)
+		if account.allowShortSales {
+			passes = true
+			explanation += "; short sales are allowed on this account"
+		}
+	}
+
+	s.recordAvailabilityCheck(holdingsAvailableRule, s.availability.Holdings, passes, order, quantity, available, snapshot, explanation, result, actorID, correlationID)
+	return nil
+}
+
+func (s *Service) checkCash(order OrderSnapshot, account *accountSnapshot, orderMetrics map[string]interface{}, result *Result, actorID, correlationID string) error {
+	openBuys, err := s.openOrderCommitment(order, "notional", "")
+	if err != nil {
+		return err
+	}
+	quantity, err := s.unfilledQuantity(order)
+	if err != nil {
+		return err
+	}
+
+	// order.value is par times the per-100 price; cash is in dollars
+	cost := 0.0
+	if value, ok := toFloat(orderMetrics["order.value"]); ok && order.Quantity > 0 {
+		cost = value / 100 * quantity / order.Quantity
+	}
+
+	cash := account.cashBalance.Float64
+	available := cash - openBuys
+	snapshot := map[string]interface{}{
+		"order.value":            cost,
+		"account.cashBalance":    cash,
+		"openOrders.buyNotional": openBuys,
+		"available.cash":         available,
+	}
+
+	passes := cost <= available+availabilityTolerance
+	explanation := fmt.Sprintf("BUY costing %.2f is covered by available cash of %.2f", cost, available)
+	if !passes {
+		explanation = fmt.Sprintf(
+			"BUY costing %.2f exceeds available cash of %.2f (balance %.2f less %.2f on open buy orders)",
+			cost, available, cash, openBuys,
+		)
+	}
+
+	s.recordAvailabilityCheck(cashAvailableRule, s.availability.Cash, passes, order, cost, available, snapshot, explanation, result, actorID, correlationID)
+	return nil
+}
+
+// availabilityTolerance absorbs rounding in decimal columns
+const availabilityTolerance = 0.005
+
+// openOrder is another working order of the account, with what it has filled
+type openOrder struct {
+	orderID  string
+	quantity float64
+	filled   float64
+	// price is the limit price, or the instrument's current ask, per 100 face
+	price float64
+}
+
+// availabilityReader reads the positions and orders the availability checks
+// weigh an order against
+type availabilityReader interface {
+	position(accountID, instrumentID string) (float64, error)
+	// openOrders lists the account's working orders on a side, in one
+	// instrument or, when instrumentID is empty, in all of them
+	openOrders(accountID, side, instrumentID string) ([]openOrder, error)
+	filledQuantity(orderID string) (float64, error)
+}
+
+// openOrderCommitment sums what the account's other working orders on the
+// same side still commit, as unfilled quantity or as the dollar notional of
+// the unfilled par at the limit (or current ask) price. The order being checked and the version it
+// replaces commit nothing against it.
+func (s *Service) openOrderCommitment(order OrderSnapshot, measure, instrumentID string) (float64, error) {
+	orders, err := s.availabilityData.openOrders(order.AccountID, order.Side, instrumentID)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0.0
+	for _, open := range orders {
+		if open.orderID == order.OrderID || (order.ReplacesOrderID != "" && open.orderID == order.ReplacesOrderID) {
+			continue
+		}
+		amount := unfilled(open.quantity, open.filled)
+		if measure == "notional" {
+			amount *= open.price / 100
+		}
+		total += amount
+	}
+	return total, nil
+}
+
+// unfilledQuantity is the part of the order that is not yet reflected in the
+// account's positions and cash. It is the whole order unless an amendment is
+// being checked on an order that has already filled in part.
+func (s *Service) unfilledQuantity(order OrderSnapshot) (float64, error) {
+	filled, err := s.availabilityData.filledQuantity(order.OrderID)
+	if err != nil {
+		return 0, err
+	}
+	return unfilled(order.Quantity, filled), nil
+}
+
+// unfilled is what is left of a quantity once its fills are taken off
+func unfilled(quantity, filled float64) float64 {
+	if filled >= quantity {
+		return 0
+	}
+	return quantity - filled
+}
+
+// sqlAvailabilityReader reads availability from the projections. An order's
+// fills are its executions, block allocations and netted crosses.
+type sqlAvailabilityReader struct {
+	db *sql.DB
+}
+
+func (r sqlAvailabilityReader) position(accountID, instrumentID string) (float64, error) {
+	var position float64
+	err := r.db.QueryRow(`
+		SELECT quantity FROM positions WHERE "accountId" = $1 AND "instrumentId" = $2
+	`, accountID, instrumentID).Scan(&position)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to fetch position: %w", err)
+	}
+	return position, nil
+}
+
+func (r sqlAvailabilityReader) openOrders(accountID, side, instrumentID string) ([]openOrder, error) {
+	rows, err := r.db.Query(fmt.Sprintf(`
+		SELECT o."orderId", o.quantity,
+		       COALESCE(e.filled, 0) + COALESCE(al.filled, 0) + COALESCE(ct.filled, 0),
+		       COALESCE(CASE WHEN o."orderType" IN ('LIMIT', 'YIELD_LIMIT') THEN o."limitPrice" END, i."askPrice", 100)
+		FROM orders o
+		LEFT JOIN (
+			SELECT "orderId", SUM("filledQuantity") AS filled FROM executions WHERE "orderId" IS NOT NULL GROUP BY "orderId"
+		) e ON e."orderId" = o."orderId"
+		LEFT JOIN (
+			SELECT "orderId", SUM(quantity) AS filled FROM allocations GROUP BY "orderId"
+		) al ON al."orderId" = o."orderId"
+		LEFT JOIN (
+			SELECT "orderId", SUM(quantity) AS filled FROM cross_trades GROUP BY "orderId"
+		) ct ON ct."orderId" = o."orderId"
+		LEFT JOIN instruments i ON i.cusip = o."instrumentId"
+		WHERE o."accountId" = $1
+		  AND o.side = $2
+		  AND o.state IN %s
+		  AND ($3 = '' OR o."instrumentId" = $3)
+	`, workingOrderStates), accountID, side, instrumentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch open orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []openOrder{}
+	for rows.Next() {
+		var open openOrder
+		if err := rows.Scan(&open.orderID, &open.quantity, &open.filled, &open.price); err != nil {
+			return nil, fmt.Errorf("failed to scan open order: %w", err)
+		}
+		orders = append(orders, open)
+	}
+	return orders, rows.Err()
+}
+
+func (r sqlAvailabilityReader) filledQuantity(orderID string) (float64, error) {
+	var filled float64
+	err := r.db.QueryRow(`
+		SELECT
+			COALESCE((SELECT SUM("filledQuantity") FROM executions WHERE "orderId" = $1), 0) +
+			COALESCE((SELECT SUM(quantity) FROM allocations WHERE "orderId" = $1), 0) +
+			COALESCE((SELECT SUM(quantity) FROM cross_trades WHERE "orderId" = $1), 0)
+	`, orderID).Scan(&filled)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch filled quantity: %w", err)
+	}
+	return filled, nil
+}
+
+// recordAvailabilityCheck collects a built-in check like a rule evaluation and
+// adds a failure to the result at the configured severity
+func (s *Service) recordAvailabilityCheck(rule ruleRecord, severity string, passes bool, order OrderSnapshot, metricValue, threshold float64, snapshot map[string]interface{}, explanation string, result *Result, actorID, correlationID string) {
+	rule.severity = severity
+	evaluatedAt := time.Now().UTC()
+
+	resultValue := "PASS"
+	if !passes {
+		resultValue = severity
+	}
+
+	result.evaluations = append(result.evaluations, newRuleEvaluated(rule, uuid.New().String(), order, evaluationPointPreTrade, resultValue, metricValue, threshold, snapshot, explanation, evaluatedAt, actorID, correlationID))
+
+	if passes {
+		result.RulesPassed = append(result.RulesPassed, rule.ruleKey)
+		return
+	}
+
+	result.evaluations = append(result.evaluations, newRuleViolation(rule, order, evaluationPointPreTrade, metricValue, threshold, snapshot, explanation, evaluatedAt, actorID, correlationID))
+
+	violation := ViolationSummary{
+		RuleID:      rule.ruleID,
+		RuleName:    rule.name,
+		Description: explanation,
+		Metrics:     snapshot,
+	}
+	if severity == AvailabilityBlock {
+		result.Blocks = append(result.Blocks, violation)
+	} else {
+		result.Warnings = append(result.Warnings, violation)
+	}
+}
@@ -0,0 +1,206 @@
+package compliance
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"instant/services/api/events"
+)
+
+func TestParseAvailabilitySeverity(t *testing.T) {
+	for value, want := range map[string]string{"BLOCK": AvailabilityBlock, " warn ": AvailabilityWarn, "off": AvailabilityOff} {
+		got, err := ParseAvailabilitySeverity(value)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", value, err)
+		}
+		if got != want {
+			t.Fatalf("%q: expected %s, got %s", value, want, got)
+		}
+	}
+
+	if _, err := ParseAvailabilitySeverity("REJECT"); err == nil {
+		t.Fatal("expected an error for an unknown severity")
+	}
+}
+
+func TestAvailabilityChecksAreCollectedUntilRecorded(t *testing.T) {
+	// No event store: collecting a check must not store anything, so a
+	// refused amendment leaves no violation behind
+	service := &Service{}
+	result := &Result{}
+	order := OrderSnapshot{OrderID: "order-1", AccountID: "acct-1", Side: "SELL", Quantity: 500}
+
+	service.recordAvailabilityCheck(holdingsAvailableRule, AvailabilityBlock, false, order, 500, 200, map[string]interface{}{}, "short by 300", result, "trader", "corr-1")
+
+	if len(result.Blocks) != 1 || len(result.Warnings) != 0 {
+		t.Fatalf("expected one block, got %d blocks and %d warnings", len(result.Blocks), len(result.Warnings))
+	}
+	if len(result.evaluations) != 2 {
+		t.Fatalf("expected an evaluation and a violation, got %d events", len(result.evaluations))
+	}
+	if result.evaluations[0].EventType != events.EventRuleEvaluated || result.evaluations[1].EventType != events.EventRuleViolationDetected {
+		t.Fatalf("expected RuleEvaluated then RuleViolationDetected, got %s and %s", result.evaluations[0].EventType, result.evaluations[1].EventType)
+	}
+}
+
+// fakeAvailability serves positions, open orders and fills from memory
+type fakeAvailability struct {
+	positions map[string]float64
+	orders    map[string][]openOrder // keyed by side
+	filled    map[string]float64
+}
+
+func (f fakeAvailability) position(accountID, instrumentID string) (float64, error) {
+	return f.positions[instrumentID], nil
+}
+
+func (f fakeAvailability) openOrders(accountID, side, instrumentID string) ([]openOrder, error) {
+	return f.orders[side], nil
+}
+
+func (f fakeAvailability) filledQuantity(orderID string) (float64, error) {
+	return f.filled[orderID], nil
+}
+
+func availabilityService(data fakeAvailability) *Service {
+	return &Service{availability: DefaultAvailabilityPolicy, availabilityData: data}
+}
+
+func TestCheckHoldingsWeighsSellsAgainstPositionLessOpenSells(t *testing.T) {
+	service := availabilityService(fakeAvailability{
+		positions: map[string]float64{"912828YK0": 1000},
+		orders: map[string][]openOrder{
+			"SELL": {{orderID: "order-open", quantity: 500, filled: 200}},
+		},
+	})
+	account := &accountSnapshot{accountID: "acct-1"}
+
+	// 1000 held less the 300 still unfilled on the open sell leaves 700
+	covered := OrderSnapshot{OrderID: "order-1", AccountID: "acct-1", InstrumentID: "912828YK0", Side: "SELL", Quantity: 700}
+	result := &Result{}
+	if err := service.checkHoldings(covered, account, result, "trader", "corr-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Blocks) != 0 || len(result.RulesPassed) != 1 {
+		t.Fatalf("expected a SELL of 700 to pass, got %+v", result)
+	}
+
+	short := covered
+	short.Quantity = 800
+	result = &Result{}
+	if err := service.checkHoldings(short, account, result, "trader", "corr-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Blocks) != 1 || result.Blocks[0].Metrics["available.quantity"] != 700.0 {
+		t.Fatalf("expected a SELL of 800 to be blocked against 700 available, got %+v", result)
+	}
+}
+
+func TestCheckHoldingsAllowsShortSalesWhenTheAccountPermitsThem(t *testing.T) {
+	service := availabilityService(fakeAvailability{})
+	order := OrderSnapshot{OrderID: "order-1", AccountID: "acct-1", InstrumentID: "912828YK0", Side: "SELL", Quantity: 500}
+
+	result := &Result{}
+	if err := service.checkHoldings(order, &accountSnapshot{allowShortSales: true}, result, "trader", "corr-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Blocks) != 0 || len(result.RulesPassed) != 1 {
+		t.Fatalf("expected the short sale to pass, got %+v", result)
+	}
+	if len(result.evaluations) != 1 || !strings.Contains(result.evaluations[0].Payload["explanation"].(string), "short sales are allowed") {
+		t.Fatalf("expected a passing evaluation that notes the override, got %d events", len(result.evaluations))
+	}
+
+	result = &Result{}
+	if err := service.checkHoldings(order, &accountSnapshot{}, result, "trader", "corr-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Blocks) != 1 {
+		t.Fatalf("expected the short sale to be blocked without the override, got %+v", result)
+	}
+}
+
+func TestCheckCashWeighsBuysAgainstBalanceLessOpenBuys(t *testing.T) {
+	service := availabilityService(fakeAvailability{
+		orders: map[string][]openOrder{
+			// 400,000 par unfilled at 99.5 commits 398,000
+			"BUY": {{orderID: "order-open", quantity: 1000000, filled: 600000, price: 99.5}},
+		},
+	})
+	account := &accountSnapshot{cashBalance: sql.NullFloat64{Float64: 1000000, Valid: true}}
+	order := OrderSnapshot{OrderID: "order-1", AccountID: "acct-1", Side: "BUY", Quantity: 600000}
+
+	// order.value is par times the per-100 price: 600,000 par at 100.3333 costs 602,000
+	result := &Result{}
+	if err := service.checkCash(order, account, map[string]interface{}{"order.value": 60200000.0}, result, "trader", "corr-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Blocks) != 0 {
+		t.Fatalf("expected a BUY costing 602,000 to fit in 602,000 available, got %+v", result.Blocks)
+	}
+
+	result = &Result{}
+	if err := service.checkCash(order, account, map[string]interface{}{"order.value": 60300000.0}, result, "trader", "corr-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Blocks) != 1 || result.Blocks[0].Metrics["openOrders.buyNotional"] != 398000.0 {
+		t.Fatalf("expected a BUY costing 603,000 to be blocked, got %+v", result)
+	}
+
+	// A WARN policy reports the shortfall without blocking
+	service.availability.Cash = AvailabilityWarn
+	result = &Result{}
+	if err := service.checkCash(order, account, map[string]interface{}{"order.value": 60300000.0}, result, "trader", "corr-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Blocks) != 0 || len(result.Warnings) != 1 {
+		t.Fatalf("expected a warning, got %+v", result)
+	}
+}
+
+func TestCheckCashCostsOnlyTheUnfilledPartOfAnAmendedOrder(t *testing.T) {
+	// An amendment to 100,000 par of an order that has filled 75,000 only
+	// needs cash for 25,000 par
+	service := availabilityService(fakeAvailability{filled: map[string]float64{"order-1": 75000}})
+	account := &accountSnapshot{cashBalance: sql.NullFloat64{Float64: 30000, Valid: true}}
+	order := OrderSnapshot{OrderID: "order-1", AccountID: "acct-1", Side: "BUY", Quantity: 100000}
+
+	result := &Result{}
+	if err := service.checkCash(order, account, map[string]interface{}{"order.value": 10000000.0}, result, "trader", "corr-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Blocks) != 0 || result.evaluations[0].Payload["metricValue"] != 25000.0 {
+		t.Fatalf("expected the unfilled 25,000 par at 100 to cost 25,000 and pass, got %+v", result)
+	}
+}
+
+func TestOpenOrderCommitmentExcludesTheOrderAndTheVersionItReplaces(t *testing.T) {
+	service := availabilityService(fakeAvailability{
+		orders: map[string][]openOrder{
+			"SELL": {
+				{orderID: "order-2", quantity: 900},
+				{orderID: "order-1", quantity: 500},
+				{orderID: "order-other", quantity: 300, filled: 100},
+				{orderID: "order-overfilled", quantity: 100, filled: 150},
+			},
+		},
+	})
+
+	// order-2 replaces order-1: neither counts, and fills only reduce what
+	// the others commit down to nothing
+	order := OrderSnapshot{OrderID: "order-2", AccountID: "acct-1", Side: "SELL", Quantity: 900, ReplacesOrderID: "order-1"}
+	got, err := service.openOrderCommitment(order, "quantity", "912828YK0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != 200 {
+		t.Fatalf("expected 200 committed by other open sells, got %.2f", got)
+	}
+
+	// A new order replaces nothing, so only the order itself is left out
+	order = OrderSnapshot{OrderID: "order-2", AccountID: "acct-1", Side: "SELL", Quantity: 900}
+	if got, _ = service.openOrderCommitment(order, "quantity", "912828YK0"); got != 700 {
+		t.Fatalf("expected 700 committed by other open sells, got %.2f", got)
+	}
+}
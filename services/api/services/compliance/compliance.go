@@ -8,6 +8,7 @@ import (
 	"instant/services/api/eventbus"
 	"instant/services/api/events"
 	"instant/services/api/eventstore"
+	"instant/services/api/projections"
 	"math"
 	"strconv"
 	"strings"
@@ -24,18 +25,28 @@ const (
 )
 
 type Service struct {
-	eventStore *eventstore.EventStore
-	eventBus   *eventbus.EventBus
-	db         *sql.DB
-	stopChan   chan struct{}
+	eventStore       *eventstore.EventStore
+	eventBus         *eventbus.EventBus
+	db               *sql.DB
+	availability     AvailabilityPolicy
+	availabilityData availabilityReader
+	checkpoints      *projections.CheckpointStore
+	stopChan         chan struct{}
 }
 
+// listenerCheckpoint is the checkpoint the compliance listener resumes from
+const listenerCheckpoint = "compliance-listener"
+
 type Result struct {
 	Status      string
 	RulesPassed []string
 	Warnings    []ViolationSummary
 	Blocks      []ViolationSummary
 	CheckedAt   time.Time
+
+	// evaluations are the RuleEvaluated and RuleViolationDetected events
+	// for the checks, stored once the result is recorded
+	evaluations []*events.Event
 }
 
 type ViolationSummary struct {
@@ -54,11 +65,16 @@ type OrderSnapshot struct {
 	OrderType    string
 	LimitPrice   sql.NullFloat64
 	CurveSpread  sql.NullFloat64
+	// ReplacesOrderID is set when the order replaces another one, which then
+	// no longer counts towards the account's open orders
+	ReplacesOrderID string
 }
 
 type accountSnapshot struct {
-	accountID   string
-	householdID sql.NullString
+	accountID       string
+	householdID     sql.NullString
+	cashBalance     sql.NullFloat64
+	allowShortSales bool
 }
 
 type ruleRecord struct {
@@ -88,10 +104,13 @@ type predicate struct {
 // NewService creates a new Compliance service.
 func NewService(db *sql.DB, es *eventstore.EventStore, eb *eventbus.EventBus) (*Service, error) {
 	return &Service{
-		eventStore: es,
-		eventBus:   eb,
-		db:         db,
-		stopChan:   make(chan struct{}),
+		eventStore:       es,
+		eventBus:         eb,
+		db:               db,
+		availability:     DefaultAvailabilityPolicy,
+		availabilityData: sqlAvailabilityReader{db: db},
+		checkpoints:      projections.NewCheckpointStore(db),
+		stopChan:         make(chan struct{}),
 	}, nil
 }
 
@@ -100,13 +119,19 @@ func (s *Service) Start() {
 	subscriber, cleanup := s.eventBus.Subscribe("*", 1000)
 	defer cleanup()
 
+	// Evaluate what was appended while no instance ran the listener
+	if !projections.CatchUp(listenerCheckpoint, subscriber, s.eventStore, s.checkpoints, s.apply, s.stopChan) {
+		return
+	}
+
 	for {
 		select {
-		case event := <-subscriber:
-			if event == nil {
-				continue
+		case event, ok := <-subscriber:
+			if !ok {
+				// The bus has closed the subscription
+				return
 			}
-			s.handleEvent(event)
+			s.apply(event)
 		case <-s.stopChan:
 			return
 		}
@@ -118,17 +143,30 @@ func (s *Service) Stop() {
 	close(s.stopChan)
 }
 
-func (s *Service) handleEvent(event *events.Event) {
+// apply handles an event and advances the listener's checkpoint past it
+func (s *Service) apply(event *events.Event) {
+	if !s.handleEvent(event) {
+		return
+	}
+	if err := s.checkpoints.Advance(listenerCheckpoint, event.Position); err != nil {
+		fmt.Printf("%s checkpoint error: %v\n", listenerCheckpoint, err)
+	}
+}
+
+// handleEvent evaluates the orders an event affects and reports whether the
+// listener acts on events of its type
+func (s *Service) handleEvent(event *events.Event) bool {
 	switch event.EventType {
-	case events.EventOrderAmended:
-		s.evaluateOrderByID(event, evaluationPointPreTrade)
 	case events.EventOrderApproved:
 		s.evaluateOrderByID(event, evaluationPointPreExecution)
 	case events.EventExecutionRequested:
 		s.evaluateOrderByID(event, evaluationPointPreExecution)
 	case events.EventSettlementBooked:
 		s.evaluateOrderByID(event, evaluationPointPostTrade)
+	default:
+		return false
 	}
+	return true
 }
 
 // EvaluatePreTrade runs pre-trade compliance checks using provided order snapshot.
@@ -136,6 +174,20 @@ func (s *Service) EvaluatePreTrade(order OrderSnapshot, actorID, correlationID s
 	return s.evaluate(order, evaluationPointPreTrade, actorID, correlationID)
 }
 
+// EvaluateAmendment runs pre-trade checks against an order as it would look
+// after an amendment. Nothing is recorded and the order itself is not blocked
+// or warned: the OMS refuses a blocked amendment, and records the evaluations
+// of an accepted one with RecordAmendment.
+func (s *Service) EvaluateAmendment(order OrderSnapshot, actorID, correlationID string) (*Result, error) {
+	return s.evaluateRules(order, evaluationPointPreTrade, actorID, correlationID)
+}
+
+// RecordAmendment records the rule evaluations and violations of an
+// amendment the OMS accepted
+func (s *Service) RecordAmendment(result *Result) {
+	s.record(result)
+}
+
 func (s *Service) evaluateOrderByID(event *events.Event, evaluationPoint string) {
 	orderID, ok := event.Payload["orderId"].(string)
 	if !ok || orderID == "" {
@@ -152,24 +204,39 @@ func (s *Service) evaluateOrderByID(event *events.Event, evaluationPoint string)
 }
 
 func (s *Service) evaluate(order OrderSnapshot, evaluationPoint, actorID, correlationID string) (*Result, error) {
-	account, err := s.fetchAccount(order.AccountID)
+	result, err := s.evaluateRules(order, evaluationPoint, actorID, correlationID)
 	if err != nil {
 		return nil, err
 	}
+	s.record(result)
 
-	rules, err := s.fetchApplicableRules(order.AccountID, account.householdID, evaluationPoint)
+	if evaluationPoint == evaluationPointPreTrade {
+		if result.Status == "BLOCK" {
+			s.emitOrderBlocked(order.OrderID, result.Blocks, actorID, correlationID)
+		}
+		if result.Status == "WARN" {
+			s.emitOrderWarned(order.OrderID, result.Warnings, actorID, correlationID)
+		}
+	}
+
+	if evaluationPoint == evaluationPointPreExecution && result.Status == "BLOCK" {
+		s.emitExecutionBlocked(order.OrderID, result.Blocks, actorID, correlationID)
+	}
+
+	return result, nil
+}
+
+// evaluateRules evaluates every applicable rule, collecting each evaluation
+// and violation on the result without recording them or acting on the order
+func (s *Service) evaluateRules(order OrderSnapshot, evaluationPoint, actorID, correlationID string) (*Result, error) {
+	account, err := s.fetchAccount(order.AccountID)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(rules) == 0 {
-		return &Result{
-			Status:      "PASS",
-			RulesPassed: []string{},
-			Warnings:    []ViolationSummary{},
-			Blocks:      []ViolationSummary{},
-			CheckedAt:   time.Now().UTC(),
-		}, nil
+	rules, err := s.fetchApplicableRules(order.AccountID, account.householdID, evaluationPoint)
+	if err != nil {
+		return nil, err
 	}
 
 	portfolioMetrics, err := s.computePortfolioMetrics(order.AccountID)
@@ -214,7 +281,7 @@ func (s *Service) evaluate(order OrderSnapshot, evaluationPoint, actorID, correl
 
 		explanation := buildExplanation(rule.explanationTemplate, metricValue, pred.Value)
 
-		s.emitRuleEvaluated(rule, evalID, order, evaluationPoint, resultValue, metricValue, pred.Value, metricSnapshot, explanation, evaluatedAt, actorID, correlationID)
+		result.evaluations = append(result.evaluations, newRuleEvaluated(rule, evalID, order, evaluationPoint, resultValue, metricValue, pred.Value, metricSnapshot, explanation, evaluatedAt, actorID, correlationID))
 
 		if passes {
 			result.RulesPassed = append(result.RulesPassed, rule.ruleKey)
@@ -228,7 +295,7 @@ func (s *Service) evaluate(order OrderSnapshot, evaluationPoint, actorID, correl
 			Metrics:     metricSnapshot,
 		}
 
-		s.emitRuleViolation(rule, order, evaluationPoint, metricValue, pred.Value, metricSnapshot, explanation, evaluatedAt, actorID, correlationID)
+		result.evaluations = append(result.evaluations, newRuleViolation(rule, order, evaluationPoint, metricValue, pred.Value, metricSnapshot, explanation, evaluatedAt, actorID, correlationID))
 
 		if rule.severity == "BLOCK" {
 			result.Blocks = append(result.Blocks, violation)
@@ -237,33 +304,26 @@ func (s *Service) evaluate(order OrderSnapshot, evaluationPoint, actorID, correl
 		}
 	}
 
-	if len(result.Blocks) > 0 {
-		result.Status = "BLOCK"
-	} else if len(result.Warnings) > 0 {
-		result.Status = "WARN"
-	}
-
 	if evaluationPoint == evaluationPointPreTrade {
-		if result.Status == "BLOCK" {
-			s.emitOrderBlocked(order.OrderID, result.Blocks, actorID, correlationID)
-		}
-		if result.Status == "WARN" {
-			s.emitOrderWarned(order.OrderID, result.Warnings, actorID, correlationID)
+		if err := s.checkAvailability(order, account, orderMetrics, result, actorID, correlationID); err != nil {
+			return nil, err
 		}
 	}
 
-	if evaluationPoint == evaluationPointPreExecution && result.Status == "BLOCK" {
-		s.emitExecutionBlocked(order.OrderID, result.Blocks, actorID, correlationID)
+	if len(result.Blocks) > 0 {
+		result.Status = "BLOCK"
+	} else if len(result.Warnings) > 0 {
+		result.Status = "WARN"
 	}
 
 	return result, nil
 }
 
 func (s *Service) fetchAccount(accountID string) (*accountSnapshot, error) {
-	query := `SELECT "accountId", "householdId" FROM accounts WHERE "accountId" = $1`
+	query := `SELECT "accountId", "householdId", "cashBalance", "allowShortSales" FROM accounts WHERE "accountId" = $1`
 
 	var snapshot accountSnapshot
-	if err := s.db.QueryRow(query, accountID).Scan(&snapshot.accountID, &snapshot.householdID); err != nil {
+	if err := s.db.QueryRow(query, accountID).Scan(&snapshot.accountID, &snapshot.householdID, &snapshot.cashBalance, &snapshot.allowShortSales); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("account not found")
 		}
@@ -406,7 +466,7 @@ func (s *Service) computeOrderMetrics(order OrderSnapshot) (map[string]interface
 		}
 	}
 
-	if order.OrderType == "LIMIT" && order.LimitPrice.Valid {
+	if (order.OrderType == "LIMIT" || order.OrderType == "YIELD_LIMIT") && order.LimitPrice.Valid {
 		price = order.LimitPrice.Float64
 	}
 
@@ -496,7 +556,17 @@ func (s *Service) resolveMetric(pred predicate, portfolioMetrics map[string]floa
 	return nil, map[string]interface{}{}, fmt.Errorf("unsupported metric: %s", metric)
 }
 
-func (s *Service) emitRuleEvaluated(rule ruleRecord, evaluationID string, order OrderSnapshot, evaluationPoint, result string, metricValue interface{}, threshold interface{}, metricSnapshot map[string]interface{}, explanation string, evaluatedAt time.Time, actorID, correlationID string) {
+// record stores and publishes the evaluations collected on a result
+func (s *Service) record(result *Result) {
+	for _, event := range result.evaluations {
+		if err := s.eventStore.Append(event); err == nil {
+			s.eventBus.Publish(event)
+		}
+	}
+	result.evaluations = nil
+}
+
+func newRuleEvaluated(rule ruleRecord, evaluationID string, order OrderSnapshot, evaluationPoint, result string, metricValue interface{}, threshold interface{}, metricSnapshot map[string]interface{}, explanation string, evaluatedAt time.Time, actorID, correlationID string) *events.Event {
 	payload := map[string]interface{}{
 		"evaluationId":    evaluationID,
 		"ruleId":          rule.ruleID,
@@ -512,7 +582,7 @@ func (s *Service) emitRuleEvaluated(rule ruleRecord, evaluationID string, order
 		"evaluatedAt":     evaluatedAt,
 	}
 
-	event := events.NewEvent(
+	return events.NewEvent(
 		events.EventRuleEvaluated,
 		events.AggregateRule,
 		rule.ruleID,
@@ -521,13 +591,9 @@ func (s *Service) emitRuleEvaluated(rule ruleRecord, evaluationID string, order
 		correlationID,
 		payload,
 	)
-
-	if err := s.eventStore.Append(event); err == nil {
-		s.eventBus.Publish(event)
-	}
 }
 
-func (s *Service) emitRuleViolation(rule ruleRecord, order OrderSnapshot, evaluationPoint string, metricValue interface{}, threshold interface{}, metricSnapshot map[string]interface{}, explanation string, evaluatedAt time.Time, actorID, correlationID string) {
+func newRuleViolation(rule ruleRecord, order OrderSnapshot, evaluationPoint string, metricValue interface{}, threshold interface{}, metricSnapshot map[string]interface{}, explanation string, evaluatedAt time.Time, actorID, correlationID string) *events.Event {
 	payload := map[string]interface{}{
 		"violationId":     uuid.New().String(),
 		"ruleId":          rule.ruleID,
@@ -547,7 +613,7 @@ func (s *Service) emitRuleViolation(rule ruleRecord, order OrderSnapshot, evalua
 		"evaluatedAt":     evaluatedAt,
 	}
 
-	event := events.NewEvent(
+	return events.NewEvent(
 		events.EventRuleViolationDetected,
 		events.AggregateRule,
 		rule.ruleID,
@@ -556,10 +622,6 @@ func (s *Service) emitRuleViolation(rule ruleRecord, order OrderSnapshot, evalua
 		correlationID,
 		payload,
 	)
-
-	if err := s.eventStore.Append(event); err == nil {
-		s.eventBus.Publish(event)
-	}
 }
 
 func (s *Service) emitOrderBlocked(orderID string, blocks []ViolationSummary, actorID, correlationID string) {
@@ -0,0 +1,231 @@
+package fees
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"instant/services/api/eventbus"
+	"instant/services/api/eventstore"
+	"math"
+
+	_ "github.com/lib/pq"
+)
+
+// Schedule scopes. An ACCOUNT schedule replaces the GLOBAL schedule for one
+// account.
+const (
+	ScopeGlobal  = "GLOBAL"
+	ScopeAccount = "ACCOUNT"
+)
+
+// Schedule statuses
+const (
+	StatusActive  = "ACTIVE"
+	StatusDeleted = "DELETED"
+)
+
+// parPerBond is the par amount a per-bond commission is charged on
+const parPerBond = 1000.0
+
+var (
+	ErrScheduleNotFound      = errors.New("fee schedule not found")
+	ErrDuplicateSchedule     = errors.New("an active fee schedule already exists for this scope and instrument type")
+	ErrInvalidScope          = errors.New("scope must be GLOBAL or ACCOUNT")
+	ErrMissingScopeID        = errors.New("scopeId is required for ACCOUNT schedules")
+	ErrInvalidInstrumentType = errors.New("instrumentType must be bill, note, bond or tips")
+	ErrInvalidRate           = errors.New("fee rates and minimums cannot be negative")
+	ErrMissingName           = errors.New("name is required")
+)
+
+// Schedule is a fee schedule as stored in the fee_schedules projection.
+// Commission is charged per bond ($1,000 par) and in basis points of
+// principal, and never less than MinCommission on a fill. Fees and markup are
+// basis points of principal.
+type Schedule struct {
+	ScheduleID        string  `json:"scheduleId"`
+	Name              string  `json:"name"`
+	Scope             string  `json:"scope"`
+	ScopeID           *string `json:"scopeId,omitempty"`
+	InstrumentType    *string `json:"instrumentType,omitempty"`
+	CommissionPerBond float64 `json:"commissionPerBond"`
+	CommissionBps     float64 `json:"commissionBps"`
+	MinCommission     float64 `json:"minCommission"`
+	FeeBps            float64 `json:"feeBps"`
+	MarkupBps         float64 `json:"markupBps"`
+	Status            string  `json:"status"`
+	Version           int     `json:"version"`
+	UpdatedBy         string  `json:"updatedBy"`
+}
+
+// Charges are the commission, fees and markup on a fill, allocation or
+// settlement, in currency. ScheduleID is empty when no schedule applied.
+type Charges struct {
+	ScheduleID string  `json:"scheduleId,omitempty"`
+	Commission float64 `json:"commission"`
+	Fees       float64 `json:"fees"`
+	Markup     float64 `json:"markup"`
+}
+
+// Total is everything charged
+func (c Charges) Total() float64 {
+	return roundCents(c.Commission + c.Fees + c.Markup)
+}
+
+// Add sums two sets of charges, keeping the first schedule seen
+func (c Charges) Add(other Charges) Charges {
+	if c.ScheduleID == "" {
+		c.ScheduleID = other.ScheduleID
+	}
+	c.Commission = roundCents(c.Commission + other.Commission)
+	c.Fees = roundCents(c.Fees + other.Fees)
+	c.Markup = roundCents(c.Markup + other.Markup)
+	return c
+}
+
+// Payload writes the charges into an event payload
+func (c Charges) Payload(payload map[string]interface{}) {
+	payload["commission"] = c.Commission
+	payload["fees"] = c.Fees
+	payload["markup"] = c.Markup
+	payload["totalCharges"] = c.Total()
+	if c.ScheduleID != "" {
+		payload["feeScheduleId"] = c.ScheduleID
+	}
+}
+
+// Compute prices a fill of quantity (par) at price (per 100 par) against a
+// schedule. A nil schedule charges nothing.
+func Compute(schedule *Schedule, quantity, price float64) Charges {
+	if schedule == nil || quantity <= 0 {
+		return Charges{}
+	}
+
+	principal := quantity * price / 100
+	commission := schedule.CommissionPerBond*quantity/parPerBond + principal*schedule.CommissionBps/10000
+	if commission < schedule.MinCommission {
+		commission = schedule.MinCommission
+	}
+
+	return Charges{
+		ScheduleID: schedule.ScheduleID,
+		Commission: roundCents(commission),
+		Fees:       roundCents(principal * schedule.FeeBps / 10000),
+		Markup:     roundCents(principal * schedule.MarkupBps / 10000),
+	}
+}
+
+// selectSchedule picks the most specific active schedule for an account and
+// instrument type: the account's schedule for the type, then the account's
+// schedule for any type, then the GLOBAL equivalents.
+func selectSchedule(schedules []Schedule, accountID, instrumentType string) *Schedule {
+	best, bestRank := -1, 0
+	for i, schedule := range schedules {
+		if schedule.Status != StatusActive {
+			continue
+		}
+		rank := 0
+		switch schedule.Scope {
+		case ScopeAccount:
+			if schedule.ScopeID == nil || *schedule.ScopeID != accountID {
+				continue
+			}
+			rank = 2
+		case ScopeGlobal:
+		default:
+			continue
+		}
+		if schedule.InstrumentType != nil {
+			if *schedule.InstrumentType != instrumentType {
+				continue
+			}
+			rank++
+		}
+		if best < 0 || rank > bestRank {
+			best, bestRank = i, rank
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	return &schedules[best]
+}
+
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// Service stores fee schedules and prices fills against them
+type Service struct {
+	eventStore *eventstore.EventStore
+	eventBus   *eventbus.EventBus
+	db         *sql.DB
+}
+
+// NewService creates a new fee schedule service
+func NewService(db *sql.DB, es *eventstore.EventStore, eb *eventbus.EventBus) (*Service, error) {
+	return &Service{
+		eventStore: es,
+		eventBus:   eb,
+		db:         db,
+	}, nil
+}
+
+// ScheduleFor returns the schedule that prices an account's fills in an
+// instrument type, or nil when none applies
+func (s *Service) ScheduleFor(accountID, instrumentType string) (*Schedule, error) {
+	schedules, err := s.querySchedules(`
+		WHERE status = $1 AND (scope = 'GLOBAL' OR (scope = 'ACCOUNT' AND "scopeId" = $2))
+	`, StatusActive, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return selectSchedule(schedules, accountID, instrumentType), nil
+}
+
+// ListSchedules returns every schedule that has not been deleted
+func (s *Service) ListSchedules() ([]Schedule, error) {
+	return s.querySchedules(`WHERE status = $1 ORDER BY scope, "scopeId", "instrumentType", name`, StatusActive)
+}
+
+// GetSchedule returns a single schedule
+func (s *Service) GetSchedule(scheduleID string) (*Schedule, error) {
+	schedules, err := s.querySchedules(`WHERE "scheduleId" = $1`, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if len(schedules) == 0 {
+		return nil, ErrScheduleNotFound
+	}
+	return &schedules[0], nil
+}
+
+func (s *Service) querySchedules(where string, args ...interface{}) ([]Schedule, error) {
+	rows, err := s.db.Query(`
+		SELECT "scheduleId", name, scope, "scopeId", "instrumentType", "commissionPerBond",
+		       "commissionBps", "minCommission", "feeBps", "markupBps", status, version, "updatedBy"
+		FROM fee_schedules
+		`+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fee schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := []Schedule{}
+	for rows.Next() {
+		var schedule Schedule
+		var scopeID, instrumentType sql.NullString
+		if err := rows.Scan(&schedule.ScheduleID, &schedule.Name, &schedule.Scope, &scopeID, &instrumentType,
+			&schedule.CommissionPerBond, &schedule.CommissionBps, &schedule.MinCommission, &schedule.FeeBps,
+			&schedule.MarkupBps, &schedule.Status, &schedule.Version, &schedule.UpdatedBy); err != nil {
+			return nil, fmt.Errorf("failed to scan fee schedule: %w", err)
+		}
+		if scopeID.Valid {
+			schedule.ScopeID = &scopeID.String
+		}
+		if instrumentType.Valid {
+			schedule.InstrumentType = &instrumentType.String
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
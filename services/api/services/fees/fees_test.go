@@ -0,0 +1,95 @@
+package fees
+
+import (
+	"errors"
+	"testing"
+)
+
+func stringPtr(v string) *string { return &v }
+
+func TestComputeCharges(t *testing.T) {
+	schedule := &Schedule{
+		ScheduleID:        "sched-1",
+		CommissionPerBond: 1.5,
+		CommissionBps:     0.5,
+		FeeBps:            0.1,
+		MarkupBps:         2,
+	}
+
+	// 50,000 par at 99.5: 50 bonds and 49,750 of principal
+	charges := Compute(schedule, 50000, 99.5)
+	want := Charges{ScheduleID: "sched-1", Commission: 77.49, Fees: 0.5, Markup: 9.95}
+	if charges != want {
+		t.Fatalf("got %+v, want %+v", charges, want)
+	}
+	if charges.Total() != 87.94 {
+		t.Fatalf("expected total 87.94, got %v", charges.Total())
+	}
+
+	if charges := Compute(nil, 50000, 99.5); charges != (Charges{}) {
+		t.Fatalf("expected no charges without a schedule, got %+v", charges)
+	}
+}
+
+func TestComputeAppliesMinimumCommission(t *testing.T) {
+	schedule := &Schedule{ScheduleID: "sched-1", CommissionPerBond: 1, MinCommission: 25}
+
+	if charges := Compute(schedule, 5000, 100); charges.Commission != 25 {
+		t.Fatalf("expected the minimum commission, got %v", charges.Commission)
+	}
+	if charges := Compute(schedule, 100000, 100); charges.Commission != 100 {
+		t.Fatalf("expected 100 bonds at 1.00, got %v", charges.Commission)
+	}
+}
+
+func TestSelectSchedulePrefersMostSpecific(t *testing.T) {
+	schedules := []Schedule{
+		{ScheduleID: "global", Scope: ScopeGlobal, Status: StatusActive},
+		{ScheduleID: "global-bill", Scope: ScopeGlobal, InstrumentType: stringPtr("bill"), Status: StatusActive},
+		{ScheduleID: "acct", Scope: ScopeAccount, ScopeID: stringPtr("acct-1"), Status: StatusActive},
+		{ScheduleID: "acct-bond", Scope: ScopeAccount, ScopeID: stringPtr("acct-1"), InstrumentType: stringPtr("bond"), Status: StatusActive},
+		{ScheduleID: "deleted", Scope: ScopeAccount, ScopeID: stringPtr("acct-2"), Status: StatusDeleted},
+	}
+
+	cases := []struct {
+		accountID      string
+		instrumentType string
+		want           string
+	}{
+		{"acct-1", "bond", "acct-bond"},
+		{"acct-1", "bill", "acct"},
+		{"acct-2", "bill", "global-bill"},
+		{"acct-2", "note", "global"},
+	}
+	for _, tc := range cases {
+		schedule := selectSchedule(schedules, tc.accountID, tc.instrumentType)
+		if schedule == nil || schedule.ScheduleID != tc.want {
+			t.Errorf("selectSchedule(%s, %s) = %+v, want %s", tc.accountID, tc.instrumentType, schedule, tc.want)
+		}
+	}
+
+	if schedule := selectSchedule(schedules[2:], "acct-3", "note"); schedule != nil {
+		t.Fatalf("expected no schedule, got %s", schedule.ScheduleID)
+	}
+}
+
+func TestValidateScheduleInput(t *testing.T) {
+	cases := []struct {
+		input ScheduleInput
+		want  error
+	}{
+		{ScheduleInput{Name: "Retail", Scope: ScopeGlobal, CommissionPerBond: 1, ActorID: "ops"}, nil},
+		{ScheduleInput{Name: "Retail", Scope: ScopeAccount, ScopeID: stringPtr("acct-1"), ActorID: "ops"}, nil},
+		{ScheduleInput{Name: "Retail", Scope: ScopeAccount, ActorID: "ops"}, ErrMissingScopeID},
+		{ScheduleInput{Name: "Retail", Scope: "DESK", ActorID: "ops"}, ErrInvalidScope},
+		{ScheduleInput{Name: "Retail", Scope: ScopeGlobal, InstrumentType: stringPtr("equity"), ActorID: "ops"}, ErrInvalidInstrumentType},
+		{ScheduleInput{Name: "Retail", Scope: ScopeGlobal, FeeBps: -1, ActorID: "ops"}, ErrInvalidRate},
+		{ScheduleInput{Name: " ", Scope: ScopeGlobal, ActorID: "ops"}, ErrMissingName},
+	}
+
+	for _, tc := range cases {
+		if err := validateScheduleInput(tc.input); !errors.Is(err, tc.want) {
+			t.Errorf("validateScheduleInput(%+v) = %v, want %v", tc.input, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package fees
+
+import (
+	"errors"
+	"instant/services/api/events"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ScheduleInput is the editable part of a fee schedule
+type ScheduleInput struct {
+	Name              string
+	Scope             string
+	ScopeID           *string
+	InstrumentType    *string
+	CommissionPerBond float64
+	CommissionBps     float64
+	MinCommission     float64
+	FeeBps            float64
+	MarkupBps         float64
+	ActorID           string
+}
+
+// CreateSchedule validates a new schedule and emits FeeScheduleCreated. Each
+// scope may have one active schedule per instrument type, plus one for any type.
+func (s *Service) CreateSchedule(input ScheduleInput, correlationID string) (string, error) {
+	if input.Scope == "" {
+		input.Scope = ScopeGlobal
+	}
+	if err := validateScheduleInput(input); err != nil {
+		return "", err
+	}
+	if input.Scope == ScopeGlobal {
+		input.ScopeID = nil
+	}
+
+	existing, err := s.ListSchedules()
+	if err != nil {
+		return "", err
+	}
+	for _, schedule := range existing {
+		if sameTarget(schedule, input) {
+			return "", ErrDuplicateSchedule
+		}
+	}
+
+	scheduleID := uuid.New().String()
+	payload := schedulePayload(input)
+	payload["scheduleId"] = scheduleID
+	payload["status"] = StatusActive
+	payload["version"] = 1
+
+	event := events.NewEvent(
+		events.EventFeeScheduleCreated,
+		events.AggregateFeeSchedule,
+		scheduleID,
+		input.ActorID,
+		"user",
+		correlationID,
+		payload,
+	)
+
+	if err := s.eventStore.Append(event); err != nil {
+		return "", err
+	}
+	s.eventBus.Publish(event)
+
+	return scheduleID, nil
+}
+
+// UpdateSchedule replaces a schedule's name and rates and emits
+// FeeScheduleUpdated. Scope and instrument type cannot change; fills already
+// priced keep the charges they were booked with.
+func (s *Service) UpdateSchedule(scheduleID string, input ScheduleInput, correlationID string) (int, error) {
+	if input.ActorID == "" {
+		return 0, errors.New("updatedBy is required")
+	}
+
+	existing, err := s.GetSchedule(scheduleID)
+	if err != nil {
+		return 0, err
+	}
+	if existing.Status == StatusDeleted {
+		return 0, ErrScheduleNotFound
+	}
+
+	input.Scope = existing.Scope
+	input.ScopeID = existing.ScopeID
+	input.InstrumentType = existing.InstrumentType
+	if err := validateScheduleInput(input); err != nil {
+		return 0, err
+	}
+
+	version := existing.Version + 1
+	payload := schedulePayload(input)
+	payload["scheduleId"] = scheduleID
+	payload["version"] = version
+	payload["previous"] = existing
+
+	event := events.NewEvent(
+		events.EventFeeScheduleUpdated,
+		events.AggregateFeeSchedule,
+		scheduleID,
+		input.ActorID,
+		"user",
+		correlationID,
+		payload,
+	)
+
+	if err := s.eventStore.Append(event); err != nil {
+		return 0, err
+	}
+	s.eventBus.Publish(event)
+
+	return version, nil
+}
+
+// DeleteSchedule retires a schedule and emits FeeScheduleDeleted. The row is
+// kept so booked charges can still be traced to it.
+func (s *Service) DeleteSchedule(scheduleID, actorID, correlationID string) error {
+	if actorID == "" {
+		return errors.New("deletedBy is required")
+	}
+
+	existing, err := s.GetSchedule(scheduleID)
+	if err != nil {
+		return err
+	}
+	if existing.Status == StatusDeleted {
+		return ErrScheduleNotFound
+	}
+
+	event := events.NewEvent(
+		events.EventFeeScheduleDeleted,
+		events.AggregateFeeSchedule,
+		scheduleID,
+		actorID,
+		"user",
+		correlationID,
+		map[string]interface{}{
+			"scheduleId": scheduleID,
+			"version":    existing.Version + 1,
+			"status":     StatusDeleted,
+			"deletedBy":  actorID,
+			"previous":   existing,
+		},
+	)
+
+	if err := s.eventStore.Append(event); err != nil {
+		return err
+	}
+	s.eventBus.Publish(event)
+
+	return nil
+}
+
+func schedulePayload(input ScheduleInput) map[string]interface{} {
+	payload := map[string]interface{}{
+		"name":              strings.TrimSpace(input.Name),
+		"scope":             input.Scope,
+		"commissionPerBond": input.CommissionPerBond,
+		"commissionBps":     input.CommissionBps,
+		"minCommission":     input.MinCommission,
+		"feeBps":            input.FeeBps,
+		"markupBps":         input.MarkupBps,
+		"updatedBy":         input.ActorID,
+	}
+	if input.ScopeID != nil {
+		payload["scopeId"] = *input.ScopeID
+	}
+	if input.InstrumentType != nil {
+		payload["instrumentType"] = *input.InstrumentType
+	}
+	return payload
+}
+
+func sameTarget(schedule Schedule, input ScheduleInput) bool {
+	if schedule.Scope != input.Scope {
+		return false
+	}
+	if input.Scope == ScopeAccount && (schedule.ScopeID == nil || *schedule.ScopeID != *input.ScopeID) {
+		return false
+	}
+	if (schedule.InstrumentType == nil) != (input.InstrumentType == nil) {
+		return false
+	}
+	return schedule.InstrumentType == nil || *schedule.InstrumentType == *input.InstrumentType
+}
+
+func validateScheduleInput(input ScheduleInput) error {
+	if input.ActorID == "" {
+		return errors.New("createdBy is required")
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		return ErrMissingName
+	}
+	switch input.Scope {
+	case ScopeGlobal:
+		if input.ScopeID != nil && *input.ScopeID != "" {
+			return errors.New("scopeId is not allowed for GLOBAL schedules")
+		}
+	case ScopeAccount:
+		if input.ScopeID == nil || *input.ScopeID == "" {
+			return ErrMissingScopeID
+		}
+	default:
+		return ErrInvalidScope
+	}
+	if input.InstrumentType != nil {
+		switch *input.InstrumentType {
+		case "bill", "note", "bond", "tips":
+		default:
+			return ErrInvalidInstrumentType
+		}
+	}
+	for _, rate := range []float64{input.CommissionPerBond, input.CommissionBps, input.MinCommission, input.FeeBps, input.MarkupBps} {
+		if rate < 0 {
+			return ErrInvalidRate
+		}
+	}
+	return nil
+}
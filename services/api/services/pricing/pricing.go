@@ -104,6 +104,73 @@ func (s *Service) Evaluate(instrument InstrumentInput, curve CurveData, asOfDate
 	}, nil
 }
 
+// PriceAtYield prices the instrument at a yield to maturity, in percent,
+// using the same compounding Evaluate reports yields in
+func (s *Service) PriceAtYield(instrument InstrumentInput, yieldPercent float64, asOfDate time.Time) (*EvaluatedPrice, error) {
+	cashflows, err := futureCashflows(instrument, asOfDate)
+	if err != nil {
+		return nil, err
+	}
+
+	yield := yieldPercent / 100
+	dirtyPrice := priceFromYield(cashflows, yield)
+	accrued := accruedInterest(instrument, asOfDate)
+	modifiedDuration := modifiedDuration(cashflows, dirtyPrice, yield)
+
+	return &EvaluatedPrice{
+		Cusip:               instrument.Cusip,
+		AsOfDate:            asOfDate,
+		CleanPrice:          dirtyPrice - accrued,
+		DirtyPrice:          dirtyPrice,
+		AccruedInterest:     accrued,
+		YieldToMaturity:     yieldPercent,
+		ModifiedDuration:    modifiedDuration,
+		DV01:                modifiedDuration * dirtyPrice * 0.0001,
+		PricingModelVersion: ModelVersion,
+		ComputedAt:          time.Now().UTC(),
+	}, nil
+}
+
+// YieldAtPrice returns the yield to maturity, in percent, implied by a clean price
+func (s *Service) YieldAtPrice(instrument InstrumentInput, cleanPrice float64, asOfDate time.Time) (float64, error) {
+	if cleanPrice <= 0 {
+		return 0, errors.New("price must be positive")
+	}
+	cashflows, err := futureCashflows(instrument, asOfDate)
+	if err != nil {
+		return 0, err
+	}
+
+	dirtyPrice := cleanPrice + accruedInterest(instrument, asOfDate)
+	return solveYield(cashflows, dirtyPrice, 0.05) * 100, nil
+}
+
+// AccruedInterest returns the coupon accrued per 100 par from the last coupon
+// date up to the settlement date, under the instrument's day count
+func (s *Service) AccruedInterest(instrument InstrumentInput, settlementDate time.Time) float64 {
+	return accruedInterest(instrument, settlementDate)
+}
+
+// DefaultDayCount is the day count convention instruments are priced with:
+// ACT/360 for bills and zero-coupon instruments, ACT/ACT otherwise
+func DefaultDayCount(instrumentType string, coupon float64, couponFrequency int) string {
+	if instrumentType == "bill" || couponFrequency == 0 || coupon == 0 {
+		return "ACT/360"
+	}
+	return "ACT/ACT"
+}
+
+func futureCashflows(instrument InstrumentInput, asOfDate time.Time) ([]cashflow, error) {
+	if !instrument.MaturityDate.After(asOfDate) {
+		return nil, errors.New("instrument matured before as-of date")
+	}
+	cashflows := buildCashflows(instrument, asOfDate)
+	if len(cashflows) == 0 {
+		return nil, errors.New("no future cashflows")
+	}
+	return cashflows, nil
+}
+
 type cashflow struct {
 	timeYears float64
 	amount    float64
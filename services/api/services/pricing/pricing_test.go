@@ -121,9 +121,77 @@ func TestEvaluateCouponAccruedInterest(t *testing.T) {
 	}
 }
 
+func TestAccruedInterestAtSettlementDate(t *testing.T) {
+	instrument := InstrumentInput{
+		Cusip:           "NOTE123",
+		Type:            "note",
+		Coupon:          4.0,
+		IssueDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		MaturityDate:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		CouponFrequency: 2,
+		DayCount:        "ACT/ACT",
+	}
+	service := NewService()
+
+	// 93 of the 184 days from the July coupon to the January coupon
+	assertNear(t, service.AccruedInterest(instrument, time.Date(2024, 10, 2, 0, 0, 0, 0, time.UTC)), 2.0*93/184, 1e-9)
+	assertNear(t, service.AccruedInterest(instrument, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)), 0, 1e-9)
+
+	instrument.Coupon = 0
+	assertNear(t, service.AccruedInterest(instrument, time.Date(2024, 10, 2, 0, 0, 0, 0, time.UTC)), 0, 1e-9)
+}
+
 func assertNear(t *testing.T, actual float64, expected float64, tolerance float64) {
 	t.Helper()
 	if math.Abs(actual-expected) > tolerance {
 		t.Fatalf("expected %.8f +/- %.8f, got %.8f", expected, tolerance, actual)
 	}
 }
+
+func TestPriceAtYieldRoundTrip(t *testing.T) {
+	asOfDate := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	curve := CurveData{
+		AsOfDate: asOfDate,
+		Points: []CurvePoint{
+			{TenorYears: 5, ParYield: 4.2},
+			{TenorYears: 10, ParYield: 4.4},
+		},
+	}
+	instrument := InstrumentInput{
+		Cusip:           "NOTE123",
+		Type:            "note",
+		Coupon:          4,
+		IssueDate:       time.Date(2023, 11, 15, 0, 0, 0, 0, time.UTC),
+		MaturityDate:    time.Date(2030, 11, 15, 0, 0, 0, 0, time.UTC),
+		CouponFrequency: 2,
+		DayCount:        DefaultDayCount("note", 4, 2),
+	}
+
+	service := NewService()
+	evaluated, err := service.Evaluate(instrument, curve, asOfDate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Pricing at the evaluated yield reproduces the evaluated price
+	atYield, err := service.PriceAtYield(instrument, evaluated.YieldToMaturity, asOfDate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertNear(t, atYield.CleanPrice, evaluated.CleanPrice, 1e-6)
+	assertNear(t, atYield.AccruedInterest, evaluated.AccruedInterest, 1e-9)
+
+	// A higher yield is a lower price, and converts back to the same yield
+	higher, err := service.PriceAtYield(instrument, evaluated.YieldToMaturity+0.25, asOfDate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if higher.CleanPrice >= evaluated.CleanPrice {
+		t.Fatalf("expected a lower price at a higher yield, got %.4f vs %.4f", higher.CleanPrice, evaluated.CleanPrice)
+	}
+	yield, err := service.YieldAtPrice(instrument, higher.CleanPrice, asOfDate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertNear(t, yield, evaluated.YieldToMaturity+0.25, 1e-6)
+}
@@ -0,0 +1,180 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"instant/services/api/events"
+
+	"github.com/google/uuid"
+)
+
+// LimitInput is the editable part of a risk limit
+type LimitInput struct {
+	LimitType string
+	Scope     string
+	ScopeID   *string
+	MaxValue  float64
+	ActorID   string
+}
+
+// CreateLimit validates a new limit and emits RiskLimitCreated. Each scope
+// may have one active limit of each type.
+func (s *Service) CreateLimit(input LimitInput, correlationID string) (string, error) {
+	if input.Scope == "" {
+		input.Scope = ScopeGlobal
+	}
+	if err := validateLimitInput(input); err != nil {
+		return "", err
+	}
+
+	existing, err := s.ListLimits()
+	if err != nil {
+		return "", err
+	}
+	for _, limit := range existing {
+		if limit.LimitType == input.LimitType && limit.Scope == input.Scope &&
+			(input.Scope == ScopeGlobal || (limit.ScopeID != nil && *limit.ScopeID == *input.ScopeID)) {
+			return "", ErrDuplicateLimit
+		}
+	}
+
+	limitID := uuid.New().String()
+	payload := map[string]interface{}{
+		"limitId":   limitID,
+		"limitType": input.LimitType,
+		"scope":     input.Scope,
+		"maxValue":  input.MaxValue,
+		"status":    StatusActive,
+		"version":   1,
+		"updatedBy": input.ActorID,
+	}
+	if input.Scope == ScopeActor {
+		payload["scopeId"] = *input.ScopeID
+	}
+
+	event := events.NewEvent(
+		events.EventRiskLimitCreated,
+		events.AggregateRiskLimit,
+		limitID,
+		input.ActorID,
+		"user",
+		correlationID,
+		payload,
+	)
+
+	if err := s.eventStore.Append(event); err != nil {
+		return "", err
+	}
+	s.eventBus.Publish(event)
+
+	return limitID, nil
+}
+
+// UpdateLimit changes a limit's maximum and emits RiskLimitUpdated carrying
+// the value it replaced
+func (s *Service) UpdateLimit(limitID string, maxValue float64, actorID, correlationID string) (int, error) {
+	if actorID == "" {
+		return 0, errors.New("updatedBy is required")
+	}
+	if maxValue <= 0 {
+		return 0, ErrInvalidMaxValue
+	}
+
+	existing, err := s.GetLimit(limitID)
+	if err != nil {
+		return 0, err
+	}
+	if existing.Status == StatusDeleted {
+		return 0, ErrLimitNotFound
+	}
+
+	version := existing.Version + 1
+	event := events.NewEvent(
+		events.EventRiskLimitUpdated,
+		events.AggregateRiskLimit,
+		limitID,
+		actorID,
+		"user",
+		correlationID,
+		map[string]interface{}{
+			"limitId":          limitID,
+			"maxValue":         maxValue,
+			"previousMaxValue": existing.MaxValue,
+			"version":          version,
+			"updatedBy":        actorID,
+		},
+	)
+
+	if err := s.eventStore.Append(event); err != nil {
+		return 0, err
+	}
+	s.eventBus.Publish(event)
+
+	return version, nil
+}
+
+// DeleteLimit retires a limit and emits RiskLimitDeleted. The row is kept so
+// past breaches can still be traced to it.
+func (s *Service) DeleteLimit(limitID, actorID, correlationID string) error {
+	if actorID == "" {
+		return errors.New("deletedBy is required")
+	}
+
+	existing, err := s.GetLimit(limitID)
+	if err != nil {
+		return err
+	}
+	if existing.Status == StatusDeleted {
+		return ErrLimitNotFound
+	}
+
+	event := events.NewEvent(
+		events.EventRiskLimitDeleted,
+		events.AggregateRiskLimit,
+		limitID,
+		actorID,
+		"user",
+		correlationID,
+		map[string]interface{}{
+			"limitId":   limitID,
+			"version":   existing.Version + 1,
+			"status":    StatusDeleted,
+			"deletedBy": actorID,
+			"previous":  existing,
+		},
+	)
+
+	if err := s.eventStore.Append(event); err != nil {
+		return err
+	}
+	s.eventBus.Publish(event)
+
+	return nil
+}
+
+func validateLimitInput(input LimitInput) error {
+	if input.ActorID == "" {
+		return errors.New("createdBy is required")
+	}
+	switch input.LimitType {
+	case LimitGrossNotionalDaily, LimitDv01Daily, LimitOrderNotional, LimitOpenOrders:
+	default:
+		return ErrInvalidLimitType
+	}
+	switch input.Scope {
+	case ScopeGlobal:
+	case ScopeActor:
+		if input.ScopeID == nil || *input.ScopeID == "" {
+			return ErrMissingScopeID
+		}
+	default:
+		return ErrInvalidScope
+	}
+	if input.MaxValue <= 0 {
+		return ErrInvalidMaxValue
+	}
+	if input.LimitType == LimitOpenOrders && input.MaxValue != float64(int(input.MaxValue)) {
+		return fmt.Errorf("%w: OPEN_ORDERS must be a whole number", ErrInvalidMaxValue)
+	}
+	return nil
+}
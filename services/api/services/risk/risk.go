@@ -0,0 +1,402 @@
+package risk
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"instant/services/api/eventbus"
+	"instant/services/api/events"
+	"instant/services/api/eventstore"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// Limit types
+const (
+	LimitGrossNotionalDaily = "GROSS_NOTIONAL_DAILY"
+	LimitDv01Daily          = "DV01_DAILY"
+	LimitOrderNotional      = "ORDER_NOTIONAL"
+	LimitOpenOrders         = "OPEN_ORDERS"
+)
+
+// Limit scopes. A GLOBAL limit applies to every trader; an ACTOR limit
+// replaces the GLOBAL limit of the same type for one trader.
+const (
+	ScopeGlobal = "GLOBAL"
+	ScopeActor  = "ACTOR"
+)
+
+// Limit statuses
+const (
+	StatusActive  = "ACTIVE"
+	StatusDeleted = "DELETED"
+)
+
+// tradeDateLayout formats the trading day utilization is tracked against
+const tradeDateLayout = "2006-01-02"
+
+var (
+	// ErrRiskLimitBreached is returned when an order would take a trader over a risk limit
+	ErrRiskLimitBreached = errors.New("order breaches a risk limit")
+	ErrLimitNotFound     = errors.New("risk limit not found")
+	ErrDuplicateLimit    = errors.New("an active risk limit of this type already exists for the scope")
+	ErrInvalidLimitType  = errors.New("limitType must be GROSS_NOTIONAL_DAILY, DV01_DAILY, ORDER_NOTIONAL or OPEN_ORDERS")
+	ErrInvalidScope      = errors.New("scope must be GLOBAL or ACTOR")
+	ErrMissingScopeID    = errors.New("scopeId is required for ACTOR limits")
+	ErrInvalidMaxValue   = errors.New("maxValue must be greater than 0")
+	ErrInvalidTradeDate  = errors.New("tradeDate must be YYYY-MM-DD")
+)
+
+// Limit is a desk risk limit as stored in the risk_limits projection
+type Limit struct {
+	LimitID   string  `json:"limitId"`
+	LimitType string  `json:"limitType"`
+	Scope     string  `json:"scope"`
+	ScopeID   *string `json:"scopeId,omitempty"`
+	MaxValue  float64 `json:"maxValue"`
+	Status    string  `json:"status"`
+	Version   int     `json:"version"`
+	UpdatedBy string  `json:"updatedBy"`
+}
+
+// OrderContext is an order being checked against risk limits
+type OrderContext struct {
+	AccountID    string
+	InstrumentID string
+	OrderType    string
+	Quantity     float64
+	LimitPrice   *float64
+}
+
+// Submission is one or more orders a trader is about to create. Replacing
+// an order does not change how many orders the trader has open.
+type Submission struct {
+	ActorID   string
+	Orders    []OrderContext
+	Replacing bool
+}
+
+// Exposure is the notional and DV01 an order adds to its trader's day
+type Exposure struct {
+	TradeDate string  `json:"tradeDate"`
+	Notional  float64 `json:"notional"`
+	Dv01      float64 `json:"dv01"`
+}
+
+// Usage is a trader's utilization before the order being checked
+type Usage struct {
+	GrossNotional float64 `json:"grossNotional"`
+	Dv01          float64 `json:"dv01"`
+	OpenOrders    int     `json:"openOrders"`
+}
+
+// LimitCheck is one limit measured against a submission or a trader's usage
+type LimitCheck struct {
+	LimitID     string  `json:"limitId"`
+	LimitType   string  `json:"limitType"`
+	Scope       string  `json:"scope"`
+	MaxValue    float64 `json:"maxValue"`
+	Used        float64 `json:"used"`
+	Adding      float64 `json:"adding"`
+	Utilization float64 `json:"utilizationPct"`
+	Breached    bool    `json:"breached"`
+}
+
+// Assessment is the outcome of a pre-trade risk check. Exposures are in the
+// order of the submission's orders.
+type Assessment struct {
+	TradeDate string       `json:"tradeDate"`
+	Exposures []Exposure   `json:"exposures"`
+	Checks    []LimitCheck `json:"checks"`
+}
+
+// BreachError reports the limits a submission would breach. It wraps
+// ErrRiskLimitBreached so callers can match it with errors.Is.
+type BreachError struct {
+	ActorID  string
+	Breaches []LimitCheck
+}
+
+func (e *BreachError) Error() string {
+	types := make([]string, 0, len(e.Breaches))
+	for _, breach := range e.Breaches {
+		types = append(types, breach.LimitType)
+	}
+	return fmt.Sprintf("%s: %s would exceed %s", ErrRiskLimitBreached, e.ActorID, strings.Join(types, ", "))
+}
+
+func (e *BreachError) Unwrap() error {
+	return ErrRiskLimitBreached
+}
+
+// Service stores desk risk limits and checks orders against them
+type Service struct {
+	eventStore *eventstore.EventStore
+	eventBus   *eventbus.EventBus
+	db         *sql.DB
+	location   *time.Location
+}
+
+// NewService creates a new risk limit service. Daily limits reset at
+// midnight in the given location.
+func NewService(db *sql.DB, es *eventstore.EventStore, eb *eventbus.EventBus, location *time.Location) (*Service, error) {
+	if location == nil {
+		location = time.UTC
+	}
+	return &Service{
+		eventStore: es,
+		eventBus:   eb,
+		db:         db,
+		location:   location,
+	}, nil
+}
+
+// Check measures a submission against the trader's risk limits. A breach is
+// recorded with RiskLimitBreached and returned as a *BreachError; otherwise
+// the assessment carries each order's exposure for the OrderCreated event.
+func (s *Service) Check(submission Submission, correlationID string) (*Assessment, error) {
+	tradeDate := s.TradeDate(time.Now())
+
+	exposures := make([]Exposure, 0, len(submission.Orders))
+	for _, order := range submission.Orders {
+		exposure, err := s.computeExposure(order)
+		if err != nil {
+			return nil, err
+		}
+		exposure.TradeDate = tradeDate
+		exposures = append(exposures, exposure)
+	}
+
+	limits, err := s.limitsFor(submission.ActorID)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.usage(submission.ActorID, tradeDate)
+	if err != nil {
+		return nil, err
+	}
+
+	openingOrders := len(submission.Orders)
+	if submission.Replacing {
+		openingOrders = 0
+	}
+
+	assessment := &Assessment{
+		TradeDate: tradeDate,
+		Exposures: exposures,
+		Checks:    evaluateLimits(effectiveLimits(limits, submission.ActorID), usage, exposures, openingOrders),
+	}
+
+	breaches := []LimitCheck{}
+	for _, check := range assessment.Checks {
+		if check.Breached {
+			breaches = append(breaches, check)
+		}
+	}
+	if len(breaches) > 0 {
+		return assessment, s.recordBreach(submission, assessment, breaches, correlationID)
+	}
+	return assessment, nil
+}
+
+// TradeDate is the trading day a moment falls on
+func (s *Service) TradeDate(at time.Time) string {
+	return at.In(s.location).Format(tradeDateLayout)
+}
+
+// effectiveLimits keeps, for each limit type, the trader's own limit when
+// there is one and the GLOBAL limit otherwise
+func effectiveLimits(limits []Limit, actorID string) []Limit {
+	byType := map[string]Limit{}
+	order := []string{}
+	for _, limit := range limits {
+		if limit.Status != StatusActive {
+			continue
+		}
+		if limit.Scope == ScopeActor && (limit.ScopeID == nil || *limit.ScopeID != actorID) {
+			continue
+		}
+		existing, seen := byType[limit.LimitType]
+		if !seen {
+			order = append(order, limit.LimitType)
+		}
+		if !seen || (existing.Scope == ScopeGlobal && limit.Scope == ScopeActor) {
+			byType[limit.LimitType] = limit
+		}
+	}
+
+	effective := make([]Limit, 0, len(order))
+	for _, limitType := range order {
+		effective = append(effective, byType[limitType])
+	}
+	return effective
+}
+
+// evaluateLimits measures usage plus the new orders against each limit. Limits
+// are inclusive: a trader may use exactly the limit.
+func evaluateLimits(limits []Limit, usage Usage, exposures []Exposure, openingOrders int) []LimitCheck {
+	notional, dv01, largest := 0.0, 0.0, 0.0
+	for _, exposure := range exposures {
+		notional += exposure.Notional
+		dv01 += exposure.Dv01
+		if exposure.Notional > largest {
+			largest = exposure.Notional
+		}
+	}
+
+	checks := make([]LimitCheck, 0, len(limits))
+	for _, limit := range limits {
+		check := LimitCheck{
+			LimitID:   limit.LimitID,
+			LimitType: limit.LimitType,
+			Scope:     limit.Scope,
+			MaxValue:  limit.MaxValue,
+		}
+		switch limit.LimitType {
+		case LimitGrossNotionalDaily:
+			check.Used, check.Adding = usage.GrossNotional, notional
+		case LimitDv01Daily:
+			check.Used, check.Adding = usage.Dv01, dv01
+		case LimitOrderNotional:
+			check.Adding = largest
+		case LimitOpenOrders:
+			check.Used, check.Adding = float64(usage.OpenOrders), float64(openingOrders)
+		default:
+			continue
+		}
+		check.Breached = check.Adding > 0 && check.Used+check.Adding > limit.MaxValue
+		if limit.MaxValue > 0 {
+			check.Utilization = (check.Used + check.Adding) / limit.MaxValue * 100
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+func (s *Service) recordBreach(submission Submission, assessment *Assessment, breaches []LimitCheck, correlationID string) error {
+	accountIDs := make([]string, 0, len(submission.Orders))
+	for _, order := range submission.Orders {
+		accountIDs = append(accountIDs, order.AccountID)
+	}
+
+	event := events.NewEvent(
+		events.EventRiskLimitBreached,
+		events.AggregateRiskLimit,
+		breaches[0].LimitID,
+		submission.ActorID,
+		"user",
+		correlationID,
+		map[string]interface{}{
+			"actorId":    submission.ActorID,
+			"accountIds": accountIDs,
+			"tradeDate":  assessment.TradeDate,
+			"exposures":  assessment.Exposures,
+			"breaches":   breaches,
+		},
+	)
+	if err := s.eventStore.Append(event); err != nil {
+		return fmt.Errorf("failed to append RiskLimitBreached event: %w", err)
+	}
+	s.eventBus.Publish(event)
+
+	return &BreachError{ActorID: submission.ActorID, Breaches: breaches}
+}
+
+// computeExposure prices the order the same way compliance and approval
+// policies do: limit price for LIMIT and YIELD_LIMIT orders, otherwise the
+// instrument's ask
+func (s *Service) computeExposure(order OrderContext) (Exposure, error) {
+	price := 100.0
+	modifiedDuration := 0.0
+	var askPrice, duration sql.NullFloat64
+	err := s.db.QueryRow(`
+		SELECT "askPrice", "askModifiedDuration" FROM instruments WHERE cusip = $1
+	`, order.InstrumentID).Scan(&askPrice, &duration)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Exposure{}, fmt.Errorf("failed to fetch instrument: %w", err)
+	}
+	if askPrice.Valid {
+		price = askPrice.Float64
+	}
+	if duration.Valid {
+		modifiedDuration = duration.Float64
+	}
+	if (order.OrderType == "LIMIT" || order.OrderType == "YIELD_LIMIT") && order.LimitPrice != nil {
+		price = *order.LimitPrice
+	}
+
+	notional := order.Quantity * price
+	return Exposure{
+		Notional: notional,
+		Dv01:     notional * modifiedDuration * 0.0001,
+	}, nil
+}
+
+// usage sums the trader's exposure on the trade date and counts their open orders
+func (s *Service) usage(actorID, tradeDate string) (Usage, error) {
+	var usage Usage
+	err := s.db.QueryRow(`
+		SELECT
+			COALESCE(SUM(notional) FILTER (WHERE "tradeDate" = $2), 0),
+			COALESCE(SUM(dv01) FILTER (WHERE "tradeDate" = $2), 0),
+			COUNT(*) FILTER (WHERE open)
+		FROM risk_order_exposures
+		WHERE "actorId" = $1
+	`, actorID, tradeDate).Scan(&usage.GrossNotional, &usage.Dv01, &usage.OpenOrders)
+	if err != nil {
+		return usage, fmt.Errorf("failed to fetch risk utilization: %w", err)
+	}
+	return usage, nil
+}
+
+func (s *Service) limitsFor(actorID string) ([]Limit, error) {
+	return s.queryLimits(`
+		WHERE status = $1 AND (scope = 'GLOBAL' OR (scope = 'ACTOR' AND "scopeId" = $2))
+		ORDER BY "limitType"
+	`, StatusActive, actorID)
+}
+
+// ListLimits returns every limit that has not been deleted
+func (s *Service) ListLimits() ([]Limit, error) {
+	return s.queryLimits(`WHERE status = $1 ORDER BY "limitType", scope, "scopeId"`, StatusActive)
+}
+
+// GetLimit returns a single limit
+func (s *Service) GetLimit(limitID string) (*Limit, error) {
+	limits, err := s.queryLimits(`WHERE "limitId" = $1`, limitID)
+	if err != nil {
+		return nil, err
+	}
+	if len(limits) == 0 {
+		return nil, ErrLimitNotFound
+	}
+	return &limits[0], nil
+}
+
+func (s *Service) queryLimits(where string, args ...interface{}) ([]Limit, error) {
+	rows, err := s.db.Query(`
+		SELECT "limitId", "limitType", scope, "scopeId", "maxValue", status, version, "updatedBy"
+		FROM risk_limits
+		`+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query risk limits: %w", err)
+	}
+	defer rows.Close()
+
+	limits := []Limit{}
+	for rows.Next() {
+		var limit Limit
+		var scopeID sql.NullString
+		if err := rows.Scan(&limit.LimitID, &limit.LimitType, &limit.Scope, &scopeID, &limit.MaxValue,
+			&limit.Status, &limit.Version, &limit.UpdatedBy); err != nil {
+			return nil, fmt.Errorf("failed to scan risk limit: %w", err)
+		}
+		if scopeID.Valid {
+			limit.ScopeID = &scopeID.String
+		}
+		limits = append(limits, limit)
+	}
+	return limits, rows.Err()
+}
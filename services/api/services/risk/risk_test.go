@@ -0,0 +1,89 @@
+package risk
+
+import (
+	"errors"
+	"testing"
+)
+
+func stringPtr(v string) *string { return &v }
+
+func TestEffectiveLimitsPreferActorLimit(t *testing.T) {
+	limits := []Limit{
+		{LimitID: "global-notional", LimitType: LimitGrossNotionalDaily, Scope: ScopeGlobal, MaxValue: 50_000_000, Status: StatusActive},
+		{LimitID: "trader-notional", LimitType: LimitGrossNotionalDaily, Scope: ScopeActor, ScopeID: stringPtr("trader-1"), MaxValue: 100_000_000, Status: StatusActive},
+		{LimitID: "other-open", LimitType: LimitOpenOrders, Scope: ScopeActor, ScopeID: stringPtr("trader-2"), MaxValue: 5, Status: StatusActive},
+		{LimitID: "deleted-dv01", LimitType: LimitDv01Daily, Scope: ScopeGlobal, MaxValue: 10_000, Status: StatusDeleted},
+	}
+
+	effective := effectiveLimits(limits, "trader-1")
+	if len(effective) != 1 || effective[0].LimitID != "trader-notional" {
+		t.Fatalf("expected only trader-1's own notional limit, got %+v", effective)
+	}
+
+	effective = effectiveLimits(limits, "trader-3")
+	if len(effective) != 1 || effective[0].LimitID != "global-notional" {
+		t.Fatalf("expected the global notional limit, got %+v", effective)
+	}
+}
+
+func TestEvaluateLimits(t *testing.T) {
+	limits := []Limit{
+		{LimitID: "notional", LimitType: LimitGrossNotionalDaily, MaxValue: 10_000_000},
+		{LimitID: "dv01", LimitType: LimitDv01Daily, MaxValue: 5_000},
+		{LimitID: "single", LimitType: LimitOrderNotional, MaxValue: 4_000_000},
+		{LimitID: "open", LimitType: LimitOpenOrders, MaxValue: 3},
+	}
+	usage := Usage{GrossNotional: 6_000_000, Dv01: 3_000, OpenOrders: 2}
+	exposures := []Exposure{{Notional: 4_000_000, Dv01: 2_000}}
+
+	checks := evaluateLimits(limits, usage, exposures, 1)
+	for _, check := range checks {
+		if check.Breached {
+			t.Fatalf("expected every limit to be met exactly, %s breached: %+v", check.LimitType, check)
+		}
+	}
+	if checks[0].Utilization != 100 {
+		t.Fatalf("expected notional utilization 100%%, got %v", checks[0].Utilization)
+	}
+
+	exposures = append(exposures, Exposure{Notional: 1, Dv01: 0})
+	breached := map[string]bool{}
+	for _, check := range evaluateLimits(limits, usage, exposures, 2) {
+		breached[check.LimitType] = check.Breached
+	}
+	if !breached[LimitGrossNotionalDaily] || !breached[LimitOpenOrders] {
+		t.Fatalf("expected notional and open order limits to be breached: %+v", breached)
+	}
+	if breached[LimitDv01Daily] || breached[LimitOrderNotional] {
+		t.Fatalf("expected DV01 and single order limits to hold: %+v", breached)
+	}
+}
+
+func TestReplacementDoesNotOpenAnotherOrder(t *testing.T) {
+	limits := []Limit{{LimitID: "open", LimitType: LimitOpenOrders, MaxValue: 2}}
+	checks := evaluateLimits(limits, Usage{OpenOrders: 2}, []Exposure{{Notional: 1_000}}, 0)
+	if checks[0].Breached {
+		t.Fatal("expected a replacement at the open order limit to be allowed")
+	}
+}
+
+func TestValidateLimitInput(t *testing.T) {
+	cases := []struct {
+		input LimitInput
+		want  error
+	}{
+		{LimitInput{LimitType: LimitDv01Daily, Scope: ScopeGlobal, MaxValue: 25_000, ActorID: "risk"}, nil},
+		{LimitInput{LimitType: LimitOpenOrders, Scope: ScopeActor, ScopeID: stringPtr("trader-1"), MaxValue: 20, ActorID: "risk"}, nil},
+		{LimitInput{LimitType: "VAR", Scope: ScopeGlobal, MaxValue: 1, ActorID: "risk"}, ErrInvalidLimitType},
+		{LimitInput{LimitType: LimitOpenOrders, Scope: ScopeActor, MaxValue: 1, ActorID: "risk"}, ErrMissingScopeID},
+		{LimitInput{LimitType: LimitOpenOrders, Scope: "ACCOUNT", MaxValue: 1, ActorID: "risk"}, ErrInvalidScope},
+		{LimitInput{LimitType: LimitOrderNotional, Scope: ScopeGlobal, MaxValue: 0, ActorID: "risk"}, ErrInvalidMaxValue},
+		{LimitInput{LimitType: LimitOpenOrders, Scope: ScopeGlobal, MaxValue: 2.5, ActorID: "risk"}, ErrInvalidMaxValue},
+	}
+
+	for _, tc := range cases {
+		if err := validateLimitInput(tc.input); !errors.Is(err, tc.want) {
+			t.Errorf("validateLimitInput(%+v) = %v, want %v", tc.input, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package risk
+
+import (
+	"fmt"
+	"time"
+)
+
+// TraderUtilization is one trader's usage on a trade date measured against
+// their effective limits
+type TraderUtilization struct {
+	ActorID string       `json:"actorId"`
+	Usage   Usage        `json:"usage"`
+	Limits  []LimitCheck `json:"limits"`
+}
+
+// Utilization is the risk utilization view for a trade date
+type Utilization struct {
+	TradeDate string              `json:"tradeDate"`
+	Traders   []TraderUtilization `json:"traders"`
+}
+
+// GetUtilization returns every trader with exposure on the trade date or
+// open orders, or just one trader when actorID is set. An empty tradeDate
+// means today.
+func (s *Service) GetUtilization(tradeDate, actorID string) (*Utilization, error) {
+	if tradeDate == "" {
+		tradeDate = s.TradeDate(time.Now())
+	} else if _, err := time.Parse(tradeDateLayout, tradeDate); err != nil {
+		return nil, ErrInvalidTradeDate
+	}
+
+	actorIDs := []string{actorID}
+	if actorID == "" {
+		var err error
+		if actorIDs, err = s.activeTraders(tradeDate); err != nil {
+			return nil, err
+		}
+	}
+
+	utilization := &Utilization{TradeDate: tradeDate, Traders: []TraderUtilization{}}
+	for _, id := range actorIDs {
+		usage, err := s.usage(id, tradeDate)
+		if err != nil {
+			return nil, err
+		}
+		limits, err := s.limitsFor(id)
+		if err != nil {
+			return nil, err
+		}
+		utilization.Traders = append(utilization.Traders, TraderUtilization{
+			ActorID: id,
+			Usage:   usage,
+			Limits:  evaluateLimits(effectiveLimits(limits, id), usage, nil, 0),
+		})
+	}
+	return utilization, nil
+}
+
+func (s *Service) activeTraders(tradeDate string) ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT DISTINCT "actorId" FROM risk_order_exposures
+		WHERE "tradeDate" = $1 OR open
+		ORDER BY "actorId"
+	`, tradeDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query traders: %w", err)
+	}
+	defer rows.Close()
+
+	actorIDs := []string{}
+	for rows.Next() {
+		var actorID string
+		if err := rows.Scan(&actorID); err != nil {
+			return nil, err
+		}
+		actorIDs = append(actorIDs, actorID)
+	}
+	return actorIDs, rows.Err()
+}
@@ -0,0 +1,194 @@
+package routing
+
+import (
+	"errors"
+	"instant/services/api/events"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// PolicyInput is the editable part of a routing policy
+type PolicyInput struct {
+	Name           string
+	Priority       int
+	Venue          string
+	AccountID      *string
+	InstrumentType *string
+	OrderType      *string
+	MinQuantity    *float64
+	MaxQuantity    *float64
+	ActorID        string
+}
+
+// CreatePolicy validates a new policy and emits RoutingPolicyCreated
+func (s *Service) CreatePolicy(input PolicyInput, correlationID string) (string, error) {
+	if input.ActorID == "" {
+		return "", errors.New("createdBy is required")
+	}
+	if err := s.validatePolicyInput(input); err != nil {
+		return "", err
+	}
+
+	policyID := uuid.New().String()
+	payload := policyPayload(input)
+	payload["policyId"] = policyID
+	payload["status"] = StatusActive
+	payload["version"] = 1
+
+	event := events.NewEvent(
+		events.EventRoutingPolicyCreated,
+		events.AggregateRoutingPolicy,
+		policyID,
+		input.ActorID,
+		"user",
+		correlationID,
+		payload,
+	)
+
+	if err := s.eventStore.Append(event); err != nil {
+		return "", err
+	}
+	s.eventBus.Publish(event)
+
+	return policyID, nil
+}
+
+// UpdatePolicy replaces a policy's criteria and venue and emits
+// RoutingPolicyUpdated. Executions already routed keep their venue.
+func (s *Service) UpdatePolicy(policyID string, input PolicyInput, correlationID string) (int, error) {
+	if input.ActorID == "" {
+		return 0, errors.New("updatedBy is required")
+	}
+
+	existing, err := s.GetPolicy(policyID)
+	if err != nil {
+		return 0, err
+	}
+	if existing.Status == StatusDeleted {
+		return 0, ErrPolicyNotFound
+	}
+	if err := s.validatePolicyInput(input); err != nil {
+		return 0, err
+	}
+
+	version := existing.Version + 1
+	payload := policyPayload(input)
+	payload["policyId"] = policyID
+	payload["version"] = version
+	payload["previous"] = existing
+
+	event := events.NewEvent(
+		events.EventRoutingPolicyUpdated,
+		events.AggregateRoutingPolicy,
+		policyID,
+		input.ActorID,
+		"user",
+		correlationID,
+		payload,
+	)
+
+	if err := s.eventStore.Append(event); err != nil {
+		return 0, err
+	}
+	s.eventBus.Publish(event)
+
+	return version, nil
+}
+
+// DeletePolicy retires a policy and emits RoutingPolicyDeleted. The row is
+// kept so routed executions can still be traced to it.
+func (s *Service) DeletePolicy(policyID, actorID, correlationID string) error {
+	if actorID == "" {
+		return errors.New("deletedBy is required")
+	}
+
+	existing, err := s.GetPolicy(policyID)
+	if err != nil {
+		return err
+	}
+	if existing.Status == StatusDeleted {
+		return ErrPolicyNotFound
+	}
+
+	event := events.NewEvent(
+		events.EventRoutingPolicyDeleted,
+		events.AggregateRoutingPolicy,
+		policyID,
+		actorID,
+		"user",
+		correlationID,
+		map[string]interface{}{
+			"policyId":  policyID,
+			"version":   existing.Version + 1,
+			"status":    StatusDeleted,
+			"deletedBy": actorID,
+			"previous":  existing,
+		},
+	)
+
+	if err := s.eventStore.Append(event); err != nil {
+		return err
+	}
+	s.eventBus.Publish(event)
+
+	return nil
+}
+
+func policyPayload(input PolicyInput) map[string]interface{} {
+	payload := map[string]interface{}{
+		"name":      strings.TrimSpace(input.Name),
+		"priority":  input.Priority,
+		"venue":     input.Venue,
+		"updatedBy": input.ActorID,
+	}
+	if input.AccountID != nil {
+		payload["accountId"] = *input.AccountID
+	}
+	if input.InstrumentType != nil {
+		payload["instrumentType"] = *input.InstrumentType
+	}
+	if input.OrderType != nil {
+		payload["orderType"] = *input.OrderType
+	}
+	if input.MinQuantity != nil {
+		payload["minQuantity"] = *input.MinQuantity
+	}
+	if input.MaxQuantity != nil {
+		payload["maxQuantity"] = *input.MaxQuantity
+	}
+	return payload
+}
+
+func (s *Service) validatePolicyInput(input PolicyInput) error {
+	if strings.TrimSpace(input.Name) == "" {
+		return ErrMissingName
+	}
+	if !s.venues[input.Venue] {
+		return ErrUnknownVenue
+	}
+	if input.Priority < 0 {
+		return ErrInvalidPriority
+	}
+	if input.InstrumentType != nil {
+		switch *input.InstrumentType {
+		case "bill", "note", "bond", "tips":
+		default:
+			return ErrInvalidInstrumentType
+		}
+	}
+	if input.OrderType != nil {
+		switch *input.OrderType {
+		case "MARKET", "LIMIT", "CURVE_RELATIVE", "YIELD_LIMIT":
+		default:
+			return ErrInvalidOrderType
+		}
+	}
+	if (input.MinQuantity != nil && *input.MinQuantity <= 0) || (input.MaxQuantity != nil && *input.MaxQuantity <= 0) {
+		return ErrInvalidQuantityRange
+	}
+	if input.MinQuantity != nil && input.MaxQuantity != nil && *input.MinQuantity > *input.MaxQuantity {
+		return ErrInvalidQuantityRange
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package routing
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"instant/services/api/eventbus"
+	"instant/services/api/eventstore"
+
+	_ "github.com/lib/pq"
+)
+
+// Policy statuses
+const (
+	StatusActive  = "ACTIVE"
+	StatusDeleted = "DELETED"
+)
+
+var (
+	ErrPolicyNotFound        = errors.New("routing policy not found")
+	ErrMissingName           = errors.New("name is required")
+	ErrUnknownVenue          = errors.New("venue is not a configured execution venue")
+	ErrInvalidPriority       = errors.New("priority cannot be negative")
+	ErrInvalidInstrumentType = errors.New("instrumentType must be bill, note, bond or tips")
+	ErrInvalidOrderType      = errors.New("orderType must be MARKET, LIMIT, CURVE_RELATIVE or YIELD_LIMIT")
+	ErrInvalidQuantityRange  = errors.New("minQuantity and maxQuantity must be positive and minQuantity no more than maxQuantity")
+)
+
+// Policy is a routing policy as stored in the routing_policies projection. An
+// order goes to the venue of the first active policy, lowest priority first,
+// whose criteria it meets; a criterion left empty matches every order.
+type Policy struct {
+	PolicyID       string   `json:"policyId"`
+	Name           string   `json:"name"`
+	Priority       int      `json:"priority"`
+	Venue          string   `json:"venue"`
+	AccountID      *string  `json:"accountId,omitempty"`
+	InstrumentType *string  `json:"instrumentType,omitempty"`
+	OrderType      *string  `json:"orderType,omitempty"`
+	MinQuantity    *float64 `json:"minQuantity,omitempty"`
+	MaxQuantity    *float64 `json:"maxQuantity,omitempty"`
+	Status         string   `json:"status"`
+	Version        int      `json:"version"`
+	UpdatedBy      string   `json:"updatedBy"`
+}
+
+// Order is what a routing policy matches on. Block orders have no account.
+type Order struct {
+	AccountID      string
+	InstrumentType string
+	OrderType      string
+	Quantity       float64
+}
+
+// matches reports whether an order meets every criterion the policy sets
+func (p Policy) matches(order Order) bool {
+	if p.AccountID != nil && *p.AccountID != order.AccountID {
+		return false
+	}
+	if p.InstrumentType != nil && *p.InstrumentType != order.InstrumentType {
+		return false
+	}
+	if p.OrderType != nil && *p.OrderType != order.OrderType {
+		return false
+	}
+	if p.MinQuantity != nil && order.Quantity < *p.MinQuantity {
+		return false
+	}
+	if p.MaxQuantity != nil && order.Quantity > *p.MaxQuantity {
+		return false
+	}
+	return true
+}
+
+// selectPolicy picks the first active policy an order matches. Policies must
+// be sorted by priority.
+func selectPolicy(policies []Policy, order Order) *Policy {
+	for i, policy := range policies {
+		if policy.Status == StatusActive && policy.matches(order) {
+			return &policies[i]
+		}
+	}
+	return nil
+}
+
+// Service stores routing policies and routes orders to execution venues
+type Service struct {
+	eventStore *eventstore.EventStore
+	eventBus   *eventbus.EventBus
+	db         *sql.DB
+	venues     map[string]bool
+}
+
+// NewService creates a new routing policy service. Policies may only route
+// to the named venues.
+func NewService(db *sql.DB, es *eventstore.EventStore, eb *eventbus.EventBus, venues []string) (*Service, error) {
+	known := make(map[string]bool, len(venues))
+	for _, venue := range venues {
+		known[venue] = true
+	}
+	return &Service{
+		eventStore: es,
+		eventBus:   eb,
+		db:         db,
+		venues:     known,
+	}, nil
+}
+
+// Route returns the policy that routes an order, or nil when none matches
+// and the order goes to the default venue
+func (s *Service) Route(order Order) (*Policy, error) {
+	policies, err := s.ListPolicies()
+	if err != nil {
+		return nil, err
+	}
+	return selectPolicy(policies, order), nil
+}
+
+// ListPolicies returns every policy that has not been deleted, in the order
+// they are tried
+func (s *Service) ListPolicies() ([]Policy, error) {
+	return s.queryPolicies(`WHERE status = $1 ORDER BY priority, "createdAt", "policyId"`, StatusActive)
+}
+
+// GetPolicy returns a single policy
+func (s *Service) GetPolicy(policyID string) (*Policy, error) {
+	policies, err := s.queryPolicies(`WHERE "policyId" = $1`, policyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		return nil, ErrPolicyNotFound
+	}
+	return &policies[0], nil
+}
+
+func (s *Service) queryPolicies(where string, args ...interface{}) ([]Policy, error) {
+	rows, err := s.db.Query(`
+		SELECT "policyId", name, priority, venue, "accountId", "instrumentType", "orderType",
+		       "minQuantity", "maxQuantity", status, version, "updatedBy"
+		FROM routing_policies
+		`+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query routing policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []Policy{}
+	for rows.Next() {
+		var policy Policy
+		var accountID, instrumentType, orderType sql.NullString
+		var minQuantity, maxQuantity sql.NullFloat64
+		if err := rows.Scan(&policy.PolicyID, &policy.Name, &policy.Priority, &policy.Venue, &accountID,
+			&instrumentType, &orderType, &minQuantity, &maxQuantity, &policy.Status, &policy.Version,
+			&policy.UpdatedBy); err != nil {
+			return nil, fmt.Errorf("failed to scan routing policy: %w", err)
+		}
+		if accountID.Valid {
+			policy.AccountID = &accountID.String
+		}
+		if instrumentType.Valid {
+			policy.InstrumentType = &instrumentType.String
+		}
+		if orderType.Valid {
+			policy.OrderType = &orderType.String
+		}
+		if minQuantity.Valid {
+			policy.MinQuantity = &minQuantity.Float64
+		}
+		if maxQuantity.Valid {
+			policy.MaxQuantity = &maxQuantity.Float64
+		}
+		policies = append(policies, policy)
+	}
+	return policies, rows.Err()
+}
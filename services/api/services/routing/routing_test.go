@@ -0,0 +1,68 @@
+package routing
+
+import (
+	"errors"
+	"testing"
+)
+
+func stringPtr(v string) *string { return &v }
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestSelectPolicyTakesFirstMatchByPriority(t *testing.T) {
+	policies := []Policy{
+		{PolicyID: "acct-bills", Venue: "mock-broker", AccountID: stringPtr("acct-1"), InstrumentType: stringPtr("bill"), Status: StatusActive},
+		{PolicyID: "large", Venue: "mock-broker", MinQuantity: floatPtr(1000000), Status: StatusActive},
+		{PolicyID: "deleted-limits", Venue: "mock-broker", OrderType: stringPtr("LIMIT"), Status: StatusDeleted},
+		{PolicyID: "small-limits", Venue: "simulator", OrderType: stringPtr("LIMIT"), MaxQuantity: floatPtr(50000), Status: StatusActive},
+	}
+
+	cases := []struct {
+		order Order
+		want  string
+	}{
+		{Order{AccountID: "acct-1", InstrumentType: "bill", OrderType: "MARKET", Quantity: 2000000}, "acct-bills"},
+		{Order{AccountID: "acct-2", InstrumentType: "bill", OrderType: "MARKET", Quantity: 2000000}, "large"},
+		{Order{AccountID: "acct-2", InstrumentType: "note", OrderType: "LIMIT", Quantity: 50000}, "small-limits"},
+		{Order{AccountID: "acct-2", InstrumentType: "note", OrderType: "LIMIT", Quantity: 60000}, ""},
+		{Order{InstrumentType: "bill", OrderType: "MARKET", Quantity: 10000}, ""},
+	}
+	for _, tc := range cases {
+		policy := selectPolicy(policies, tc.order)
+		got := ""
+		if policy != nil {
+			got = policy.PolicyID
+		}
+		if got != tc.want {
+			t.Errorf("selectPolicy(%+v) = %q, want %q", tc.order, got, tc.want)
+		}
+	}
+}
+
+func TestValidatePolicyInput(t *testing.T) {
+	service := &Service{venues: map[string]bool{"simulator": true, "mock-broker": true}}
+	valid := PolicyInput{Name: "Bills to broker", Venue: "mock-broker", InstrumentType: stringPtr("bill"), ActorID: "trader-1"}
+	if err := service.validatePolicyInput(valid); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		modify func(*PolicyInput)
+		want   error
+	}{
+		{func(input *PolicyInput) { input.Name = " " }, ErrMissingName},
+		{func(input *PolicyInput) { input.Venue = "dark-pool" }, ErrUnknownVenue},
+		{func(input *PolicyInput) { input.Priority = -1 }, ErrInvalidPriority},
+		{func(input *PolicyInput) { input.InstrumentType = stringPtr("strip") }, ErrInvalidInstrumentType},
+		{func(input *PolicyInput) { input.OrderType = stringPtr("STOP") }, ErrInvalidOrderType},
+		{func(input *PolicyInput) { input.MinQuantity = floatPtr(0) }, ErrInvalidQuantityRange},
+		{func(input *PolicyInput) { input.MinQuantity, input.MaxQuantity = floatPtr(500000), floatPtr(100000) }, ErrInvalidQuantityRange},
+	}
+	for _, tc := range cases {
+		input := valid
+		tc.modify(&input)
+		if err := service.validatePolicyInput(input); !errors.Is(err, tc.want) {
+			t.Errorf("expected %v, got %v", tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package settlement
+
+import "math"
+
+// Amounts is the cash a trade settles for, in dollars. Quantities are par and
+// prices are per 100 par, so principal is quantity times the clean price over
+// 100, and accrued interest quantity times the accrued interest per 100 par
+// over 100.
+type Amounts struct {
+	Principal       float64 `json:"principal"`
+	AccruedInterest float64 `json:"accruedInterest"`
+	Charges         float64 `json:"charges"`
+	NetAmount       float64 `json:"netSettlementAmount"`
+}
+
+// Compute works out what a trade settles for. A buyer pays principal,
+// accrued interest and charges; a seller receives principal and accrued
+// interest less charges.
+func Compute(side string, quantity, cleanPrice, accruedPer100, charges float64) Amounts {
+	amounts := Amounts{
+		Principal:       roundCents(quantity * cleanPrice / 100),
+		AccruedInterest: roundCents(quantity * accruedPer100 / 100),
+		Charges:         roundCents(charges),
+	}
+	if side == "SELL" {
+		amounts.NetAmount = roundCents(amounts.Principal + amounts.AccruedInterest - amounts.Charges)
+	} else {
+		amounts.NetAmount = roundCents(amounts.Principal + amounts.AccruedInterest + amounts.Charges)
+	}
+	return amounts
+}
+
+// Add sums the amounts of trades on the same side
+func (a Amounts) Add(other Amounts) Amounts {
+	return Amounts{
+		Principal:       roundCents(a.Principal + other.Principal),
+		AccruedInterest: roundCents(a.AccruedInterest + other.AccruedInterest),
+		Charges:         roundCents(a.Charges + other.Charges),
+		NetAmount:       roundCents(a.NetAmount + other.NetAmount),
+	}
+}
+
+// Payload writes the amounts into a settlement event payload. Charges are
+// written separately, itemised, by the fee schedule that priced them.
+func (a Amounts) Payload(payload map[string]interface{}) {
+	payload["principal"] = a.Principal
+	payload["accruedInterest"] = a.AccruedInterest
+	payload["netSettlementAmount"] = a.NetAmount
+}
+
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,35 @@
+package settlement
+
+import "testing"
+
+func TestComputeBuyPaysAccruedAndCharges(t *testing.T) {
+	amounts := Compute("BUY", 1000000, 99.5, 1.25, 42.5)
+	want := Amounts{Principal: 995000, AccruedInterest: 12500, Charges: 42.5, NetAmount: 1007542.5}
+	if amounts != want {
+		t.Fatalf("got %+v, want %+v", amounts, want)
+	}
+}
+
+func TestComputeSellReceivesAccruedLessCharges(t *testing.T) {
+	amounts := Compute("SELL", 1000000, 99.5, 1.25, 42.5)
+	if amounts.NetAmount != 1007457.5 {
+		t.Fatalf("expected 1007457.5, got %v", amounts.NetAmount)
+	}
+}
+
+func TestComputeIsInDollars(t *testing.T) {
+	// 250,000 par at 101-01 (101.03125) with 0.6875 accrued per 100 par
+	amounts := Compute("BUY", 250000, 101.03125, 0.6875, 0)
+	want := Amounts{Principal: 252578.13, AccruedInterest: 1718.75, NetAmount: 254296.88}
+	if amounts != want {
+		t.Fatalf("got %+v, want %+v", amounts, want)
+	}
+}
+
+func TestAddSumsAmounts(t *testing.T) {
+	total := Compute("BUY", 600000, 100, 0.5, 10).Add(Compute("BUY", 400000, 100, 0.5, 5))
+	want := Amounts{Principal: 1000000, AccruedInterest: 5000, Charges: 15, NetAmount: 1005015}
+	if total != want {
+		t.Fatalf("got %+v, want %+v", total, want)
+	}
+}
@@ -0,0 +1,85 @@
+package settlement
+
+import (
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// jobActorID is recorded as the actor on settlements the job settles
+const jobActorID = "system:settlement-job"
+
+// Job settles pending settlements on their settlement date. It runs as a
+// singleton worker; a settlement settled by hand at the same time is kept
+// from settling twice by the settlement's expected version.
+type Job struct {
+	service  *Service
+	interval time.Duration
+	stopChan chan struct{}
+}
+
+// NewJob creates a new scheduled settlement job
+func NewJob(service *Service, interval time.Duration) *Job {
+	return &Job{
+		service:  service,
+		interval: interval,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start settles due settlements every interval until stopped
+func (j *Job) Start() {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	log.Printf("Settlement job started (every %s)", j.interval)
+
+	for {
+		select {
+		case <-ticker.C:
+			settled, err := j.service.SettleDue(time.Now().UTC())
+			if err != nil {
+				log.Printf("Settlement job failed: %v", err)
+				continue
+			}
+			if settled > 0 {
+				log.Printf("Settlement job settled %d settlements", settled)
+			}
+		case <-j.stopChan:
+			log.Println("Settlement job stopped")
+			return
+		}
+	}
+}
+
+// Stop stops the job
+func (j *Job) Stop() {
+	close(j.stopChan)
+}
+
+// SettleDue settles in full every pending settlement whose settlement date is
+// on or before the market's latest business day at now. Failed and partially
+// settled settlements are left for someone to resolve. The projection only
+// picks the candidates; each is settled from its events.
+func (s *Service) SettleDue(now time.Time) (int, error) {
+	asOf := now.UTC()
+	if s.calendar != nil {
+		asOf = s.calendar.LatestBusinessDay(now)
+	}
+	due, err := s.dueSettlements(asOf)
+	if err != nil {
+		return 0, err
+	}
+
+	correlationID := uuid.New().String()
+	settled := 0
+	for _, settlement := range due {
+		if _, err := s.Settle(settlement.SettlementID, 0, "", jobActorID, correlationID); err != nil {
+			log.Printf("Settlement job: failed to settle %s: %v", settlement.SettlementID, err)
+			continue
+		}
+		settled++
+	}
+	return settled, nil
+}
@@ -0,0 +1,406 @@
+package settlement
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"instant/services/api/eventbus"
+	"instant/services/api/events"
+	"instant/services/api/eventstore"
+	"instant/services/api/services/calendar"
+	"math"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// Settlement statuses. A settlement is PENDING from its trade date until it
+// settles in full, or in part, on or after its settlement date. A FAILED
+// settlement stays open until it is settled by hand.
+const (
+	StatusPending          = "PENDING"
+	StatusPartiallySettled = "PARTIALLY_SETTLED"
+	StatusSettled          = "SETTLED"
+	StatusFailed           = "FAILED"
+)
+
+// quantityTolerance absorbs float noise when comparing par quantities
+const quantityTolerance = 0.000001
+
+var (
+	ErrSettlementNotFound   = errors.New("settlement not found")
+	ErrSettlementClosed     = errors.New("settlement is already settled")
+	ErrSettlementNotPending = errors.New("only pending or partially settled settlements can fail")
+	ErrInvalidQuantity      = errors.New("settled quantity must be positive and no more than the quantity left to settle")
+	ErrReasonRequired       = errors.New("a reason is required to fail or partially settle a settlement")
+	// ErrSettlementChanged is returned when another settle or fail was written
+	// between loading the settlement and recording this one
+	ErrSettlementChanged = errors.New("settlement changed while it was being updated")
+)
+
+// Settlement is one account's obligation to deliver or receive bonds and cash
+// for a trade, as stored in the settlements projection. Executions and cross
+// legs settle one account each; a block execution settles per allocation.
+// Version counts the settlement's lifecycle events when it was loaded to be
+// settled or failed.
+type Settlement struct {
+	SettlementID      string     `json:"settlementId"`
+	ExecutionID       string     `json:"executionId"`
+	OrderID           *string    `json:"orderId,omitempty"`
+	BlockID           *string    `json:"blockId,omitempty"`
+	CrossID           *string    `json:"crossId,omitempty"`
+	AccountID         string     `json:"accountId"`
+	InstrumentID      string     `json:"instrumentId"`
+	Side              string     `json:"side"`
+	Quantity          float64    `json:"quantity"`
+	SettledQuantity   float64    `json:"settledQuantity"`
+	Price             float64    `json:"price"`
+	NetAmount         *float64   `json:"netSettlementAmount,omitempty"`
+	TradeDate         time.Time  `json:"tradeDate"`
+	SettlementDate    time.Time  `json:"settlementDate"`
+	Status            string     `json:"status"`
+	Reason            *string    `json:"reason,omitempty"`
+	SettledAt         *time.Time `json:"settledAt,omitempty"`
+	FailedAt          *time.Time `json:"failedAt,omitempty"`
+	RemainingQuantity float64    `json:"remainingQuantity"`
+	Version           int        `json:"-"`
+}
+
+// Remaining is the quantity still to settle
+func (s Settlement) Remaining() float64 {
+	return math.Max(s.Quantity-s.SettledQuantity, 0)
+}
+
+// settle works out the event that settles quantity of the settlement, all of
+// what remains when quantity is zero. Settling less than what remains needs a
+// reason.
+func (s Settlement) settle(quantity float64, reason string) (string, float64, error) {
+	switch s.Status {
+	case StatusPending, StatusPartiallySettled, StatusFailed:
+	default:
+		return "", 0, ErrSettlementClosed
+	}
+
+	remaining := s.Remaining()
+	if quantity == 0 {
+		quantity = remaining
+	}
+	if quantity <= 0 || quantity > remaining+quantityTolerance {
+		return "", 0, ErrInvalidQuantity
+	}
+	if remaining-quantity > quantityTolerance {
+		if reason == "" {
+			return "", 0, ErrReasonRequired
+		}
+		return events.EventSettlementPartiallySettled, quantity, nil
+	}
+	return events.EventSettlementSettled, remaining, nil
+}
+
+// fail checks a settlement can be failed
+func (s Settlement) fail(reason string) error {
+	if s.Status != StatusPending && s.Status != StatusPartiallySettled {
+		if s.Status == StatusSettled {
+			return ErrSettlementClosed
+		}
+		return ErrSettlementNotPending
+	}
+	if reason == "" {
+		return ErrReasonRequired
+	}
+	return nil
+}
+
+// replay rebuilds the settlement's progress from its lifecycle events, on top
+// of the booked terms
+func (s Settlement) replay(lifecycle []*events.Event) Settlement {
+	s.SettledQuantity = 0
+	s.Status = StatusPending
+	s.Reason = nil
+	s.SettledAt = nil
+	s.FailedAt = nil
+
+	for _, event := range lifecycle {
+		payload := event.Payload
+		reason, hasReason := payload["reason"].(string)
+		switch event.EventType {
+		case events.EventSettlementSettled, events.EventSettlementPartiallySettled:
+			quantity, _ := payload["quantity"].(float64)
+			s.SettledQuantity += quantity
+			s.Status = StatusSettled
+			if event.EventType == events.EventSettlementPartiallySettled {
+				s.Status = StatusPartiallySettled
+			}
+			settledAt := payloadTime(payload["settledAt"], event.OccurredAt)
+			s.SettledAt = &settledAt
+		case events.EventSettlementFailed:
+			s.Status = StatusFailed
+			failedAt := payloadTime(payload["failedAt"], event.OccurredAt)
+			s.FailedAt = &failedAt
+		default:
+			continue
+		}
+		if hasReason && reason != "" {
+			s.Reason = &reason
+		}
+	}
+	s.RemainingQuantity = s.Remaining()
+	s.Version = len(lifecycle)
+	return s
+}
+
+// payloadTime reads a timestamp from a payload, which holds a time.Time when
+// the event was just built and a string once it has been stored
+func payloadTime(value interface{}, fallback time.Time) time.Time {
+	switch v := value.(type) {
+	case time.Time:
+		return v
+	case string:
+		if parsed, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+// payload describes the settlement in a lifecycle event
+func (s Settlement) payload() map[string]interface{} {
+	payload := map[string]interface{}{
+		"settlementId":   s.SettlementID,
+		"executionId":    s.ExecutionID,
+		"accountId":      s.AccountID,
+		"instrumentId":   s.InstrumentID,
+		"side":           s.Side,
+		"settlementDate": s.SettlementDate,
+	}
+	if s.OrderID != nil {
+		payload["orderId"] = *s.OrderID
+	}
+	if s.BlockID != nil {
+		payload["blockId"] = *s.BlockID
+	}
+	if s.CrossID != nil {
+		payload["crossId"] = *s.CrossID
+	}
+	return payload
+}
+
+// Service moves settlements through their lifecycle on the bond market calendar
+type Service struct {
+	eventStore *eventstore.EventStore
+	eventBus   *eventbus.EventBus
+	db         *sql.DB
+	calendar   *calendar.Calendar
+}
+
+// NewService creates a new settlement service
+func NewService(db *sql.DB, es *eventstore.EventStore, eb *eventbus.EventBus, marketCalendar *calendar.Calendar) (*Service, error) {
+	return &Service{
+		eventStore: es,
+		eventBus:   eb,
+		db:         db,
+		calendar:   marketCalendar,
+	}, nil
+}
+
+// Settle settles quantity of a settlement, or all that remains when quantity
+// is zero, and emits SettlementSettled, or SettlementPartiallySettled with the
+// reason the rest did not settle
+func (s *Service) Settle(settlementID string, quantity float64, reason, actorID, correlationID string) (*Settlement, error) {
+	if actorID == "" {
+		return nil, errors.New("settledBy is required")
+	}
+
+	existing, err := s.loadSettlement(settlementID)
+	if err != nil {
+		return nil, err
+	}
+	eventType, settled, err := existing.settle(quantity, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	existing.SettledQuantity += settled
+	existing.Status = StatusSettled
+	if eventType == events.EventSettlementPartiallySettled {
+		existing.Status = StatusPartiallySettled
+	}
+	settledAt := time.Now().UTC()
+	existing.SettledAt = &settledAt
+	existing.RemainingQuantity = existing.Remaining()
+
+	payload := existing.payload()
+	payload["quantity"] = settled
+	payload["settledQuantity"] = existing.SettledQuantity
+	payload["remainingQuantity"] = existing.RemainingQuantity
+	payload["status"] = existing.Status
+	payload["settledAt"] = settledAt
+	payload["settledBy"] = actorID
+	if reason != "" {
+		payload["reason"] = reason
+		existing.Reason = &reason
+	}
+
+	if err := s.appendAndPublish(eventType, settlementID, existing.Version, actorID, correlationID, payload); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// Fail marks a settlement FAILED with the reason it did not settle and emits
+// SettlementFailed. Whatever already settled stays settled.
+func (s *Service) Fail(settlementID, reason, actorID, correlationID string) (*Settlement, error) {
+	if actorID == "" {
+		return nil, errors.New("failedBy is required")
+	}
+
+	existing, err := s.loadSettlement(settlementID)
+	if err != nil {
+		return nil, err
+	}
+	if err := existing.fail(reason); err != nil {
+		return nil, err
+	}
+
+	failedAt := time.Now().UTC()
+	existing.Status = StatusFailed
+	existing.Reason = &reason
+	existing.FailedAt = &failedAt
+
+	payload := existing.payload()
+	payload["settledQuantity"] = existing.SettledQuantity
+	payload["remainingQuantity"] = existing.RemainingQuantity
+	payload["status"] = StatusFailed
+	payload["reason"] = reason
+	payload["failedAt"] = failedAt
+	payload["failedBy"] = actorID
+
+	if err := s.appendAndPublish(events.EventSettlementFailed, settlementID, existing.Version, actorID, correlationID, payload); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// ListSettlements returns settlements, most recent trade date first,
+// optionally filtered by status and account
+func (s *Service) ListSettlements(status, accountID string, limit int) ([]Settlement, error) {
+	return s.querySettlements(`
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR "accountId" = $2)
+		ORDER BY "tradeDate" DESC, "createdAt" DESC
+		LIMIT $3
+	`, status, accountID, limit)
+}
+
+// GetSettlement returns a single settlement
+func (s *Service) GetSettlement(settlementID string) (*Settlement, error) {
+	settlements, err := s.querySettlements(`WHERE "settlementId" = $1`, settlementID)
+	if err != nil {
+		return nil, err
+	}
+	if len(settlements) == 0 {
+		return nil, ErrSettlementNotFound
+	}
+	return &settlements[0], nil
+}
+
+// loadSettlement returns a settlement's booked terms from the projection and
+// rebuilds its progress from its events, so a settle or fail never acts on a
+// projection that has not caught up
+func (s *Service) loadSettlement(settlementID string) (*Settlement, error) {
+	booked, err := s.GetSettlement(settlementID)
+	if err != nil {
+		return nil, err
+	}
+	lifecycle, err := s.eventStore.GetByAggregate(events.AggregateSettlement, settlementID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settlement events: %w", err)
+	}
+	settlement := booked.replay(lifecycle)
+	return &settlement, nil
+}
+
+// dueSettlements returns the pending settlements whose settlement date is on
+// or before asOf
+func (s *Service) dueSettlements(asOf time.Time) ([]Settlement, error) {
+	return s.querySettlements(`
+		WHERE status = $1 AND "settlementDate" <= $2
+		ORDER BY "settlementDate", "createdAt"
+	`, StatusPending, asOf)
+}
+
+func (s *Service) querySettlements(where string, args ...interface{}) ([]Settlement, error) {
+	rows, err := s.db.Query(`
+		SELECT "settlementId", "executionId", "orderId", "blockId", "crossId", "accountId", "instrumentId",
+		       side::text, quantity, "settledQuantity", price, "netSettlementAmount", "tradeDate",
+		       "settlementDate", status, reason, "settledAt", "failedAt"
+		FROM settlements
+		`+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query settlements: %w", err)
+	}
+	defer rows.Close()
+
+	settlements := []Settlement{}
+	for rows.Next() {
+		var settlement Settlement
+		var orderID, blockID, crossID, reason sql.NullString
+		var netAmount sql.NullFloat64
+		var settledAt, failedAt sql.NullTime
+		if err := rows.Scan(&settlement.SettlementID, &settlement.ExecutionID, &orderID, &blockID, &crossID,
+			&settlement.AccountID, &settlement.InstrumentID, &settlement.Side, &settlement.Quantity,
+			&settlement.SettledQuantity, &settlement.Price, &netAmount, &settlement.TradeDate,
+			&settlement.SettlementDate, &settlement.Status, &reason, &settledAt, &failedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan settlement: %w", err)
+		}
+		if orderID.Valid {
+			settlement.OrderID = &orderID.String
+		}
+		if blockID.Valid {
+			settlement.BlockID = &blockID.String
+		}
+		if crossID.Valid {
+			settlement.CrossID = &crossID.String
+		}
+		if reason.Valid {
+			settlement.Reason = &reason.String
+		}
+		if netAmount.Valid {
+			settlement.NetAmount = &netAmount.Float64
+		}
+		if settledAt.Valid {
+			settlement.SettledAt = &settledAt.Time
+		}
+		if failedAt.Valid {
+			settlement.FailedAt = &failedAt.Time
+		}
+		settlement.RemainingQuantity = settlement.Remaining()
+		settlements = append(settlements, settlement)
+	}
+	return settlements, rows.Err()
+}
+
+// appendAndPublish writes a lifecycle event only if the settlement still has
+// the events it was loaded with
+func (s *Service) appendAndPublish(eventType, settlementID string, expectedVersion int, actorID, correlationID string, payload map[string]interface{}) error {
+	role := "user"
+	if actorID == jobActorID {
+		role = "system"
+	}
+	event := events.NewEvent(
+		eventType,
+		events.AggregateSettlement,
+		settlementID,
+		actorID,
+		role,
+		correlationID,
+		payload,
+	)
+	if err := s.eventStore.AppendExpected(event, expectedVersion); err != nil {
+		if errors.Is(err, eventstore.ErrConcurrencyConflict) {
+			return fmt.Errorf("%w: %s", ErrSettlementChanged, settlementID)
+		}
+		return fmt.Errorf("failed to append %s event: %w", eventType, err)
+	}
+	s.eventBus.Publish(event)
+	return nil
+}
@@ -0,0 +1,90 @@
+package settlement
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"instant/services/api/events"
+)
+
+func TestSettleDefaultsToEverythingRemaining(t *testing.T) {
+	pending := Settlement{Quantity: 10000, SettledQuantity: 4000, Status: StatusPartiallySettled}
+	eventType, settled, err := pending.settle(0, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if eventType != events.EventSettlementSettled || settled != 6000 {
+		t.Fatalf("expected %s of 6000, got %s of %v", events.EventSettlementSettled, eventType, settled)
+	}
+}
+
+func TestPartialSettleNeedsAReason(t *testing.T) {
+	pending := Settlement{Quantity: 10000, Status: StatusPending}
+	if _, _, err := pending.settle(4000, ""); !errors.Is(err, ErrReasonRequired) {
+		t.Fatalf("expected ErrReasonRequired, got %v", err)
+	}
+	eventType, settled, err := pending.settle(4000, "counterparty short")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if eventType != events.EventSettlementPartiallySettled || settled != 4000 {
+		t.Fatalf("expected %s of 4000, got %s of %v", events.EventSettlementPartiallySettled, eventType, settled)
+	}
+	if _, _, err := pending.settle(12000, ""); !errors.Is(err, ErrInvalidQuantity) {
+		t.Fatalf("expected ErrInvalidQuantity, got %v", err)
+	}
+}
+
+func TestFailedSettlementCanStillSettle(t *testing.T) {
+	failed := Settlement{Quantity: 10000, Status: StatusFailed}
+	if _, _, err := failed.settle(0, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := failed.fail("again"); !errors.Is(err, ErrSettlementNotPending) {
+		t.Fatalf("expected ErrSettlementNotPending, got %v", err)
+	}
+
+	settled := Settlement{Quantity: 10000, SettledQuantity: 10000, Status: StatusSettled}
+	if _, _, err := settled.settle(0, ""); !errors.Is(err, ErrSettlementClosed) {
+		t.Fatalf("expected ErrSettlementClosed, got %v", err)
+	}
+	if err := settled.fail("late"); !errors.Is(err, ErrSettlementClosed) {
+		t.Fatalf("expected ErrSettlementClosed, got %v", err)
+	}
+}
+
+func TestFailNeedsAReason(t *testing.T) {
+	pending := Settlement{Quantity: 10000, Status: StatusPending}
+	if err := pending.fail(""); !errors.Is(err, ErrReasonRequired) {
+		t.Fatalf("expected ErrReasonRequired, got %v", err)
+	}
+}
+
+func TestReplayRebuildsProgressFromEvents(t *testing.T) {
+	settledAt := time.Date(2026, 3, 3, 15, 0, 0, 0, time.UTC)
+	event := func(eventType string, payload map[string]interface{}) *events.Event {
+		return events.NewEvent(eventType, events.AggregateSettlement, "stl-1", "ops", "user", "corr", payload)
+	}
+
+	// The projection may not have caught up with either event yet
+	booked := Settlement{SettlementID: "stl-1", Quantity: 10000, Status: StatusPending}
+	replayed := booked.replay([]*events.Event{
+		event(events.EventSettlementPartiallySettled, map[string]interface{}{
+			"quantity": 4000.0, "reason": "counterparty short", "settledAt": settledAt.Format(time.RFC3339Nano),
+		}),
+		event(events.EventSettlementFailed, map[string]interface{}{"reason": "still short"}),
+	})
+	if replayed.Status != StatusFailed || replayed.SettledQuantity != 4000 || replayed.RemainingQuantity != 6000 ||
+		replayed.Version != 2 || *replayed.Reason != "still short" || !replayed.SettledAt.Equal(settledAt) || replayed.FailedAt == nil {
+		t.Fatalf("unexpected replayed settlement: %+v", replayed)
+	}
+
+	settled := replayed.replay([]*events.Event{event(events.EventSettlementSettled, map[string]interface{}{"quantity": 10000.0})})
+	if settled.Status != StatusSettled || settled.Version != 1 {
+		t.Fatalf("unexpected settled settlement: %+v", settled)
+	}
+	if _, _, err := settled.settle(0, ""); !errors.Is(err, ErrSettlementClosed) {
+		t.Fatalf("expected a settled settlement to refuse a second settle, got %v", err)
+	}
+}
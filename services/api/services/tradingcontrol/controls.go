@@ -0,0 +1,161 @@
+package tradingcontrol
+
+import (
+	"errors"
+	"instant/services/api/events"
+	"instant/services/api/eventstore"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HaltInput is a kill switch being activated
+type HaltInput struct {
+	Scope   string
+	ScopeID *string
+	Reason  string
+	ActorID string
+}
+
+// ActivateHalt validates a kill switch and emits TradingHaltActivated. From
+// then on the OMS refuses new orders it covers and the EMS holds their executions.
+func (s *Service) ActivateHalt(input HaltInput, correlationID string) (string, error) {
+	if input.Scope == "" {
+		input.Scope = ScopeGlobal
+	}
+	if err := validateHaltInput(input); err != nil {
+		return "", err
+	}
+
+	haltID := uuid.New().String()
+	payload := map[string]interface{}{
+		"haltId":      haltID,
+		"scope":       input.Scope,
+		"reason":      input.Reason,
+		"status":      StatusActive,
+		"activatedBy": input.ActorID,
+		"activatedAt": time.Now().UTC(),
+	}
+	if input.Scope != ScopeGlobal {
+		payload["scopeId"] = *input.ScopeID
+	}
+
+	event := events.NewEvent(
+		events.EventTradingHaltActivated,
+		events.AggregateTradingControl,
+		haltID,
+		input.ActorID,
+		"user",
+		correlationID,
+		payload,
+	)
+
+	if err := s.eventStore.Append(event); err != nil {
+		return "", err
+	}
+	s.eventBus.Publish(event)
+
+	return haltID, nil
+}
+
+// ReleaseHalt lifts a kill switch and emits TradingHaltReleased. Orders the
+// EMS held while it was active stay SENT until they are re-requested.
+func (s *Service) ReleaseHalt(haltID, actorID, reason, correlationID string) error {
+	if actorID == "" {
+		return errors.New("releasedBy is required")
+	}
+	if strings.TrimSpace(reason) == "" {
+		return ErrMissingReason
+	}
+
+	halt, version, err := s.loadHalt(haltID)
+	if err != nil {
+		return err
+	}
+	if halt.Status != StatusActive {
+		return ErrHaltReleased
+	}
+
+	event := events.NewEvent(
+		events.EventTradingHaltReleased,
+		events.AggregateTradingControl,
+		haltID,
+		actorID,
+		"user",
+		correlationID,
+		map[string]interface{}{
+			"haltId":        haltID,
+			"status":        StatusReleased,
+			"releasedBy":    actorID,
+			"releasedAt":    time.Now().UTC(),
+			"releaseReason": reason,
+		},
+	)
+
+	// A release racing this one finds the halt already moved on
+	if err := s.eventStore.AppendExpected(event, version); err != nil {
+		if errors.Is(err, eventstore.ErrConcurrencyConflict) {
+			return ErrHaltReleased
+		}
+		return err
+	}
+	s.eventBus.Publish(event)
+
+	return nil
+}
+
+// SetRateLimit sets an actor's own order rate limit and emits OrderRateLimitSet.
+// A MaxOrders of 0 exempts the actor from the default limit.
+func (s *Service) SetRateLimit(limit RateLimit, correlationID string) error {
+	if limit.ActorID == "" {
+		return errors.New("actorId is required")
+	}
+	if limit.SetBy == "" {
+		return errors.New("setBy is required")
+	}
+	if limit.MaxOrders < 0 || limit.Window <= 0 {
+		return ErrInvalidRateLimit
+	}
+
+	event := events.NewEvent(
+		events.EventOrderRateLimitSet,
+		events.AggregateTradingControl,
+		limit.ActorID,
+		limit.SetBy,
+		"user",
+		correlationID,
+		map[string]interface{}{
+			"actorId":       limit.ActorID,
+			"maxOrders":     limit.MaxOrders,
+			"windowSeconds": int(limit.Window / time.Second),
+			"setBy":         limit.SetBy,
+		},
+	)
+
+	if err := s.eventStore.Append(event); err != nil {
+		return err
+	}
+	s.eventBus.Publish(event)
+
+	return nil
+}
+
+func validateHaltInput(input HaltInput) error {
+	if input.ActorID == "" {
+		return errors.New("activatedBy is required")
+	}
+	if strings.TrimSpace(input.Reason) == "" {
+		return ErrMissingReason
+	}
+	switch input.Scope {
+	case ScopeGlobal:
+	case ScopeHousehold, ScopeAccount, ScopeActor:
+		if input.ScopeID == nil || *input.ScopeID == "" {
+			return ErrMissingScopeID
+		}
+	default:
+		return ErrInvalidScope
+	}
+	return nil
+}
@@ -0,0 +1,683 @@
+package tradingcontrol
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"instant/services/api/eventbus"
+	"instant/services/api/events"
+	"instant/services/api/eventstore"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Halt scopes
+const (
+	ScopeGlobal    = "GLOBAL"
+	ScopeHousehold = "HOUSEHOLD"
+	ScopeAccount   = "ACCOUNT"
+	ScopeActor     = "ACTOR"
+)
+
+// Halt statuses
+const (
+	StatusActive   = "ACTIVE"
+	StatusReleased = "RELEASED"
+)
+
+// Reasons a submission is refused
+const (
+	ReasonHalted    = "HALTED"
+	ReasonThrottled = "THROTTLED"
+)
+
+// recentRefusalLimit caps how many refusals the status view lists
+const recentRefusalLimit = 50
+
+// recentReleaseLimit caps how many released halts the status view lists
+const recentReleaseLimit = 20
+
+// haltProjection is the projection that keeps trading_halts, and whose
+// checkpoint says which halt events the table already reflects
+const haltProjection = "oms"
+
+var (
+	// ErrTradingHalted is returned when a kill switch covers the order
+	ErrTradingHalted = errors.New("trading is halted")
+	// ErrRateLimited is returned when the actor has submitted too many orders recently
+	ErrRateLimited      = errors.New("order submission rate limit exceeded")
+	ErrHaltNotFound     = errors.New("trading halt not found")
+	ErrHaltReleased     = errors.New("trading halt has already been released")
+	ErrInvalidScope     = errors.New("scope must be GLOBAL, HOUSEHOLD, ACCOUNT or ACTOR")
+	ErrMissingScopeID   = errors.New("scopeId is required for HOUSEHOLD, ACCOUNT and ACTOR halts")
+	ErrMissingReason    = errors.New("reason is required")
+	ErrInvalidRateLimit = errors.New("maxOrders must not be negative and windowSeconds must be positive")
+)
+
+// Halt is a kill switch, folded from its TradingHaltActivated and
+// TradingHaltReleased events
+type Halt struct {
+	HaltID        string     `json:"haltId"`
+	Scope         string     `json:"scope"`
+	ScopeID       *string    `json:"scopeId,omitempty"`
+	Reason        string     `json:"reason"`
+	Status        string     `json:"status"`
+	ActivatedBy   string     `json:"activatedBy"`
+	ActivatedAt   time.Time  `json:"activatedAt"`
+	ReleasedBy    *string    `json:"releasedBy,omitempty"`
+	ReleasedAt    *time.Time `json:"releasedAt,omitempty"`
+	ReleaseReason *string    `json:"releaseReason,omitempty"`
+}
+
+// RateLimit caps how many orders an actor may submit within a rolling window.
+// MaxOrders 0 means no limit.
+type RateLimit struct {
+	ActorID   string        `json:"actorId,omitempty"`
+	MaxOrders int           `json:"maxOrders"`
+	Window    time.Duration `json:"-"`
+	SetBy     string        `json:"setBy,omitempty"`
+}
+
+// MarshalJSON reports the window in seconds
+func (l RateLimit) MarshalJSON() ([]byte, error) {
+	type rateLimit RateLimit
+	return json.Marshal(struct {
+		rateLimit
+		WindowSeconds int `json:"windowSeconds"`
+	}{rateLimit(l), int(l.Window / time.Second)})
+}
+
+// Refusal is an order submission refused by a halt or a rate limit
+type Refusal struct {
+	RefusalID  string    `json:"refusalId"`
+	ActorID    string    `json:"actorId"`
+	AccountIDs []string  `json:"accountIds"`
+	Reason     string    `json:"reason"`
+	HaltID     *string   `json:"haltId,omitempty"`
+	Detail     string    `json:"detail"`
+	RefusedAt  time.Time `json:"refusedAt"`
+}
+
+// Status is the current state of the kill switches and throttles
+type Status struct {
+	ActiveHalts      []Halt      `json:"activeHalts"`
+	RecentReleases   []Halt      `json:"recentReleases"`
+	DefaultRateLimit RateLimit   `json:"defaultRateLimit"`
+	RateLimits       []RateLimit `json:"rateLimits"`
+	RecentRefusals   []Refusal   `json:"recentRefusals"`
+}
+
+// Submission is an order, or a block of orders, about to be created
+type Submission struct {
+	ActorID    string
+	AccountIDs []string
+	Orders     int
+}
+
+// Admission is a submission counted against its actor's rate limit
+type Admission struct {
+	AdmissionID string
+	Orders      int
+}
+
+// RefusedError reports a submission refused by a halt or a rate limit. It
+// wraps ErrTradingHalted or ErrRateLimited so callers can match it with errors.Is.
+type RefusedError struct {
+	Reason string
+	Halt   *Halt
+	Detail string
+}
+
+func (e *RefusedError) Error() string {
+	if e.Reason == ReasonHalted {
+		return fmt.Sprintf("%s: %s", ErrTradingHalted, e.Detail)
+	}
+	return fmt.Sprintf("%s: %s", ErrRateLimited, e.Detail)
+}
+
+func (e *RefusedError) Unwrap() error {
+	if e.Reason == ReasonHalted {
+		return ErrTradingHalted
+	}
+	return ErrRateLimited
+}
+
+// Service records kill switches and order rate limits and checks orders
+// against them
+type Service struct {
+	eventStore   *eventstore.EventStore
+	eventBus     *eventbus.EventBus
+	db           *sql.DB
+	defaultLimit RateLimit
+}
+
+// NewService creates a trading control service. The default rate limit
+// applies to actors without one of their own.
+func NewService(db *sql.DB, es *eventstore.EventStore, eb *eventbus.EventBus, defaultLimit RateLimit) (*Service, error) {
+	return &Service{
+		eventStore:   es,
+		eventBus:     eb,
+		db:           db,
+		defaultLimit: defaultLimit,
+	}, nil
+}
+
+// CheckSubmission refuses an order submission that a kill switch covers. The
+// refusal is recorded with OrderSubmissionRefused.
+func (s *Service) CheckSubmission(submission Submission, correlationID string) error {
+	halt, err := s.ActiveHalt(submission.AccountIDs, submission.ActorID)
+	if err != nil {
+		return err
+	}
+	if halt != nil {
+		refused := &RefusedError{Reason: ReasonHalted, Halt: halt, Detail: describeHalt(halt)}
+		return s.recordRefusal(submission, refused, correlationID)
+	}
+	return nil
+}
+
+// Admit counts a submission against its actor's rate limit, refusing it
+// with OrderSubmissionRefused when it would go over. It is the last check a
+// submission passes, so only orders that are otherwise accepted count. The
+// admission is nil when the actor has no limit.
+func (s *Service) Admit(submission Submission, correlationID string) (*Admission, error) {
+	limit, err := s.rateLimit(submission.ActorID)
+	if err != nil {
+		return nil, err
+	}
+	if limit.MaxOrders <= 0 {
+		return nil, nil
+	}
+	recent, admission, err := s.admit(submission, limit)
+	if err != nil {
+		return nil, err
+	}
+	if admission == nil {
+		refused := &RefusedError{
+			Reason: ReasonThrottled,
+			Detail: fmt.Sprintf("%s has submitted %d orders in the last %s, limit %d", submission.ActorID, recent, limit.Window, limit.MaxOrders),
+		}
+		return nil, s.recordRefusal(submission, refused, correlationID)
+	}
+	return admission, nil
+}
+
+// Withdraw stops orders of an admission counting against the rate limit,
+// for orders refused or not recorded after they were admitted
+func (s *Service) Withdraw(admission *Admission, orders int) error {
+	if admission == nil || orders <= 0 {
+		return nil
+	}
+	if orders >= admission.Orders {
+		_, err := s.db.Exec(`DELETE FROM order_rate_admissions WHERE "admissionId" = $1`, admission.AdmissionID)
+		if err != nil {
+			return fmt.Errorf("failed to withdraw rate limit admission: %w", err)
+		}
+		return nil
+	}
+	_, err := s.db.Exec(`
+		UPDATE order_rate_admissions SET orders = orders - $2 WHERE "admissionId" = $1
+	`, admission.AdmissionID, orders)
+	if err != nil {
+		return fmt.Errorf("failed to withdraw rate limit admission: %w", err)
+	}
+	return nil
+}
+
+// ActiveHalt returns the oldest active halt covering any of the accounts,
+// their households or the actor, or nil when trading may proceed.
+func (s *Service) ActiveHalt(accountIDs []string, actorID string) (*Halt, error) {
+	halts, err := s.activeHalts()
+	if err != nil {
+		return nil, err
+	}
+	if len(halts) == 0 {
+		return nil, nil
+	}
+
+	householdIDs, err := s.households(accountIDs)
+	if err != nil {
+		return nil, err
+	}
+	return matchHalt(halts, accountIDs, householdIDs, actorID), nil
+}
+
+// matchHalt returns the first halt that covers the accounts, households or actor
+func matchHalt(halts []Halt, accountIDs, householdIDs []string, actorID string) *Halt {
+	for i := range halts {
+		halt := &halts[i]
+		if halt.Status != StatusActive {
+			continue
+		}
+		switch halt.Scope {
+		case ScopeGlobal:
+			return halt
+		case ScopeAccount:
+			if halt.ScopeID != nil && contains(accountIDs, *halt.ScopeID) {
+				return halt
+			}
+		case ScopeHousehold:
+			if halt.ScopeID != nil && contains(householdIDs, *halt.ScopeID) {
+				return halt
+			}
+		case ScopeActor:
+			if halt.ScopeID != nil && *halt.ScopeID == actorID {
+				return halt
+			}
+		}
+	}
+	return nil
+}
+
+// exceedsRateLimit reports whether adding orders to the recent count goes over the limit
+func exceedsRateLimit(limit RateLimit, recent, adding int) bool {
+	if limit.MaxOrders <= 0 {
+		return false
+	}
+	if adding < 1 {
+		adding = 1
+	}
+	return recent+adding > limit.MaxOrders
+}
+
+func describeHalt(halt *Halt) string {
+	scope := halt.Scope
+	if halt.ScopeID != nil {
+		scope += " " + *halt.ScopeID
+	}
+	return fmt.Sprintf("%s halted by %s: %s", scope, halt.ActivatedBy, halt.Reason)
+}
+
+func (s *Service) recordRefusal(submission Submission, refused *RefusedError, correlationID string) error {
+	payload := map[string]interface{}{
+		"actorId":    submission.ActorID,
+		"accountIds": submission.AccountIDs,
+		"reason":     refused.Reason,
+		"detail":     refused.Detail,
+		"refusedAt":  time.Now().UTC(),
+	}
+	if refused.Halt != nil {
+		payload["haltId"] = refused.Halt.HaltID
+	}
+
+	event := events.NewEvent(
+		events.EventOrderSubmissionRefused,
+		events.AggregateTradingControl,
+		submission.ActorID,
+		submission.ActorID,
+		"user",
+		correlationID,
+		payload,
+	)
+	if err := s.eventStore.Append(event); err != nil {
+		return fmt.Errorf("failed to append OrderSubmissionRefused event: %w", err)
+	}
+	s.eventBus.Publish(event)
+
+	return refused
+}
+
+// rateLimit returns the actor's own rate limit, or the default
+func (s *Service) rateLimit(actorID string) (RateLimit, error) {
+	limit := RateLimit{ActorID: actorID}
+	var windowSeconds int
+	err := s.db.QueryRow(`
+		SELECT "maxOrders", "windowSeconds", "setBy" FROM order_rate_limits WHERE "actorId" = $1
+	`, actorID).Scan(&limit.MaxOrders, &windowSeconds, &limit.SetBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.defaultLimit, nil
+	}
+	if err != nil {
+		return limit, fmt.Errorf("failed to fetch rate limit: %w", err)
+	}
+	limit.Window = time.Duration(windowSeconds) * time.Second
+	return limit, nil
+}
+
+// admit counts the orders the actor has been admitted within the limit's
+// window and, if the submission fits, admits it. Each actor's admissions are
+// serialised with an advisory lock, so concurrent submissions cannot all fit
+// under the same count. It returns the count the decision was made on and
+// the admission, nil if the submission does not fit.
+func (s *Service) admit(submission Submission, limit RateLimit) (int, *Admission, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin rate limit check: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('rate-limit'), hashtext($1))`, submission.ActorID); err != nil {
+		return 0, nil, fmt.Errorf("failed to lock rate limit: %w", err)
+	}
+
+	now := time.Now().UTC()
+	since := now.Add(-limit.Window)
+	if _, err := tx.Exec(`
+		DELETE FROM order_rate_admissions WHERE "actorId" = $1 AND "admittedAt" <= $2
+	`, submission.ActorID, since); err != nil {
+		return 0, nil, fmt.Errorf("failed to expire rate limit admissions: %w", err)
+	}
+
+	var recent int
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(orders), 0) FROM order_rate_admissions
+		WHERE "actorId" = $1 AND "admittedAt" > $2
+	`, submission.ActorID, since).Scan(&recent)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to count recent orders: %w", err)
+	}
+	if exceedsRateLimit(limit, recent, submission.Orders) {
+		return recent, nil, nil
+	}
+
+	admission := &Admission{AdmissionID: uuid.New().String(), Orders: submission.Orders}
+	if admission.Orders < 1 {
+		admission.Orders = 1
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO order_rate_admissions ("admissionId", "actorId", orders, "admittedAt")
+		VALUES ($1, $2, $3, $4)
+	`, admission.AdmissionID, submission.ActorID, admission.Orders, now); err != nil {
+		return 0, nil, fmt.Errorf("failed to record rate limit admission: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, nil, fmt.Errorf("failed to commit rate limit admission: %w", err)
+	}
+	return recent, admission, nil
+}
+
+func (s *Service) households(accountIDs []string) ([]string, error) {
+	if len(accountIDs) == 0 {
+		return nil, nil
+	}
+	rows, err := s.db.Query(`
+		SELECT DISTINCT "householdId" FROM accounts WHERE "accountId" = ANY($1) AND "householdId" IS NOT NULL
+	`, pq.Array(accountIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch households: %w", err)
+	}
+	defer rows.Close()
+
+	householdIDs := []string{}
+	for rows.Next() {
+		var householdID string
+		if err := rows.Scan(&householdID); err != nil {
+			return nil, err
+		}
+		householdIDs = append(householdIDs, householdID)
+	}
+	return householdIDs, rows.Err()
+}
+
+// GetStatus returns the active halts, recently released halts, rate limits
+// and recent refusals
+func (s *Service) GetStatus() (*Status, error) {
+	halts, err := s.loadHalts()
+	if err != nil {
+		return nil, err
+	}
+	active := haltsWithStatus(halts, StatusActive)
+	released := haltsWithStatus(halts, StatusReleased)
+	sort.SliceStable(released, func(i, j int) bool {
+		return released[i].ReleasedAt.After(*released[j].ReleasedAt)
+	})
+	if len(released) > recentReleaseLimit {
+		released = released[:recentReleaseLimit]
+	}
+	limits, err := s.rateLimits()
+	if err != nil {
+		return nil, err
+	}
+	refusals, err := s.recentRefusals()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Status{
+		ActiveHalts:      active,
+		RecentReleases:   released,
+		DefaultRateLimit: s.defaultLimit,
+		RateLimits:       limits,
+		RecentRefusals:   refusals,
+	}, nil
+}
+
+// GetHalt returns a single halt
+func (s *Service) GetHalt(haltID string) (*Halt, error) {
+	halt, _, err := s.loadHalt(haltID)
+	return halt, err
+}
+
+// loadHalt folds a halt from its events and returns how many there are, the
+// version a release must expect
+func (s *Service) loadHalt(haltID string) (*Halt, int, error) {
+	haltEvents, err := s.eventStore.GetByAggregate(events.AggregateTradingControl, haltID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load trading halt: %w", err)
+	}
+	halts := foldHalts(haltEvents)
+	if len(halts) == 0 {
+		return nil, 0, ErrHaltNotFound
+	}
+	return &halts[0], len(haltEvents), nil
+}
+
+// activeHalts returns the halts active now. The trading_halts projection
+// holds the active halts as of its checkpoint; the halt events written since
+// are read from the event store and applied on top, so a halt applies as soon
+// as it is activated without every check re-reading the whole halt history.
+func (s *Service) activeHalts() ([]Halt, error) {
+	// The checkpoint is read first: the table is then at least that current,
+	// and an event applied twice changes nothing
+	var position int64
+	err := s.db.QueryRow(`SELECT position FROM projection_checkpoints WHERE projection = $1`, haltProjection).Scan(&position)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to read trading halt checkpoint: %w", err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT "haltId", scope, "scopeId", reason, "activatedBy", "activatedAt"
+		FROM trading_halts
+		WHERE status = $1
+	`, StatusActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trading halts: %w", err)
+	}
+	defer rows.Close()
+
+	projected := []Halt{}
+	for rows.Next() {
+		halt := Halt{Status: StatusActive}
+		var scopeID sql.NullString
+		if err := rows.Scan(&halt.HaltID, &halt.Scope, &scopeID, &halt.Reason, &halt.ActivatedBy, &halt.ActivatedAt); err != nil {
+			return nil, err
+		}
+		if scopeID.Valid {
+			halt.ScopeID = &scopeID.String
+		}
+		projected = append(projected, halt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	recent, err := s.eventStore.GetByEventTypesAfterPosition(position, events.EventTradingHaltActivated, events.EventTradingHaltReleased)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trading halt events: %w", err)
+	}
+	return haltsWithStatus(applyHaltEvents(projected, recent), StatusActive), nil
+}
+
+// loadHalts folds every halt from the event store, oldest first
+func (s *Service) loadHalts() ([]Halt, error) {
+	activated, err := s.eventStore.GetByEventType(events.EventTradingHaltActivated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trading halts: %w", err)
+	}
+	released, err := s.eventStore.GetByEventType(events.EventTradingHaltReleased)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trading halts: %w", err)
+	}
+	return foldHalts(append(activated, released...)), nil
+}
+
+// foldHalts builds halts from their activation and release events, ordered
+// by when they were activated
+func foldHalts(haltEvents []*events.Event) []Halt {
+	return applyHaltEvents(nil, haltEvents)
+}
+
+// applyHaltEvents applies activation and release events to known halts and
+// returns them all, ordered by when they were activated
+func applyHaltEvents(known []Halt, haltEvents []*events.Event) []Halt {
+	byID := map[string]*Halt{}
+	for i := range known {
+		halt := known[i]
+		byID[halt.HaltID] = &halt
+	}
+	for _, event := range haltEvents {
+		if event.EventType != events.EventTradingHaltActivated {
+			continue
+		}
+		payload := event.Payload
+		halt := &Halt{
+			HaltID:      payloadString(payload["haltId"]),
+			Scope:       payloadString(payload["scope"]),
+			Reason:      payloadString(payload["reason"]),
+			Status:      StatusActive,
+			ActivatedBy: payloadString(payload["activatedBy"]),
+			ActivatedAt: payloadTime(payload["activatedAt"], event.OccurredAt),
+		}
+		if scopeID := payloadString(payload["scopeId"]); scopeID != "" {
+			halt.ScopeID = &scopeID
+		}
+		if _, ok := byID[halt.HaltID]; halt.HaltID != "" && !ok {
+			byID[halt.HaltID] = halt
+		}
+	}
+	for _, event := range haltEvents {
+		if event.EventType != events.EventTradingHaltReleased {
+			continue
+		}
+		payload := event.Payload
+		halt, ok := byID[payloadString(payload["haltId"])]
+		if !ok {
+			continue
+		}
+		releasedBy := payloadString(payload["releasedBy"])
+		releasedAt := payloadTime(payload["releasedAt"], event.OccurredAt)
+		releaseReason := payloadString(payload["releaseReason"])
+		halt.Status = StatusReleased
+		halt.ReleasedBy = &releasedBy
+		halt.ReleasedAt = &releasedAt
+		halt.ReleaseReason = &releaseReason
+	}
+
+	halts := make([]Halt, 0, len(byID))
+	for _, halt := range byID {
+		halts = append(halts, *halt)
+	}
+	sort.Slice(halts, func(i, j int) bool {
+		if !halts[i].ActivatedAt.Equal(halts[j].ActivatedAt) {
+			return halts[i].ActivatedAt.Before(halts[j].ActivatedAt)
+		}
+		return halts[i].HaltID < halts[j].HaltID
+	})
+	return halts
+}
+
+func haltsWithStatus(halts []Halt, status string) []Halt {
+	matching := []Halt{}
+	for _, halt := range halts {
+		if halt.Status == status {
+			matching = append(matching, halt)
+		}
+	}
+	return matching
+}
+
+func payloadString(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+// payloadTime reads a timestamp from a payload, which holds a time.Time when
+// the event was just built and a string once it has been stored
+func payloadTime(value interface{}, fallback time.Time) time.Time {
+	switch v := value.(type) {
+	case time.Time:
+		return v
+	case string:
+		if parsed, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+func (s *Service) rateLimits() ([]RateLimit, error) {
+	rows, err := s.db.Query(`
+		SELECT "actorId", "maxOrders", "windowSeconds", "setBy" FROM order_rate_limits ORDER BY "actorId"
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rate limits: %w", err)
+	}
+	defer rows.Close()
+
+	limits := []RateLimit{}
+	for rows.Next() {
+		var limit RateLimit
+		var windowSeconds int
+		if err := rows.Scan(&limit.ActorID, &limit.MaxOrders, &windowSeconds, &limit.SetBy); err != nil {
+			return nil, err
+		}
+		limit.Window = time.Duration(windowSeconds) * time.Second
+		limits = append(limits, limit)
+	}
+	return limits, rows.Err()
+}
+
+func (s *Service) recentRefusals() ([]Refusal, error) {
+	rows, err := s.db.Query(`
+		SELECT "refusalId", "actorId", "accountIds", reason, "haltId", detail, "refusedAt"
+		FROM order_submission_refusals
+		ORDER BY "refusedAt" DESC
+		LIMIT $1
+	`, recentRefusalLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query refusals: %w", err)
+	}
+	defer rows.Close()
+
+	refusals := []Refusal{}
+	for rows.Next() {
+		var refusal Refusal
+		var accountIDsJSON []byte
+		var haltID sql.NullString
+		if err := rows.Scan(&refusal.RefusalID, &refusal.ActorID, &accountIDsJSON, &refusal.Reason,
+			&haltID, &refusal.Detail, &refusal.RefusedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(accountIDsJSON, &refusal.AccountIDs); err != nil {
+			return nil, fmt.Errorf("failed to decode refusal accounts: %w", err)
+		}
+		if haltID.Valid {
+			refusal.HaltID = &haltID.String
+		}
+		refusals = append(refusals, refusal)
+	}
+	return refusals, rows.Err()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package tradingcontrol
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"instant/services/api/events"
+)
+
+func stringPtr(v string) *string { return &v }
+
+func TestMatchHaltByScope(t *testing.T) {
+	halts := []Halt{
+		{HaltID: "released", Scope: ScopeGlobal, Status: StatusReleased},
+		{HaltID: "account", Scope: ScopeAccount, ScopeID: stringPtr("acct-1"), Status: StatusActive},
+		{HaltID: "household", Scope: ScopeHousehold, ScopeID: stringPtr("hh-1"), Status: StatusActive},
+		{HaltID: "actor", Scope: ScopeActor, ScopeID: stringPtr("trader-1"), Status: StatusActive},
+	}
+
+	if halt := matchHalt(halts, []string{"acct-2"}, []string{"hh-2"}, "trader-2"); halt != nil {
+		t.Fatalf("expected no halt, got %s", halt.HaltID)
+	}
+	if halt := matchHalt(halts, []string{"acct-2", "acct-1"}, nil, "trader-2"); halt == nil || halt.HaltID != "account" {
+		t.Fatalf("expected account halt, got %+v", halt)
+	}
+	if halt := matchHalt(halts, []string{"acct-3"}, []string{"hh-1"}, "trader-2"); halt == nil || halt.HaltID != "household" {
+		t.Fatalf("expected household halt, got %+v", halt)
+	}
+	if halt := matchHalt(halts, []string{"acct-3"}, nil, "trader-1"); halt == nil || halt.HaltID != "actor" {
+		t.Fatalf("expected actor halt, got %+v", halt)
+	}
+
+	halts = append(halts, Halt{HaltID: "global", Scope: ScopeGlobal, Status: StatusActive})
+	if halt := matchHalt(halts, nil, nil, "anyone"); halt == nil || halt.HaltID != "global" {
+		t.Fatalf("expected global halt, got %+v", halt)
+	}
+}
+
+func TestFoldHaltsFromEvents(t *testing.T) {
+	activatedAt := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	event := func(eventType, haltID string, payload map[string]interface{}) *events.Event {
+		payload["haltId"] = haltID
+		return events.NewEvent(eventType, events.AggregateTradingControl, haltID, "ops", "user", "corr", payload)
+	}
+
+	halts := foldHalts([]*events.Event{
+		event(events.EventTradingHaltActivated, "later", map[string]interface{}{
+			"scope": ScopeAccount, "scopeId": "acct-1", "reason": "bad fills", "activatedBy": "ops",
+			"activatedAt": activatedAt.Add(time.Minute).Format(time.RFC3339Nano),
+		}),
+		event(events.EventTradingHaltActivated, "earlier", map[string]interface{}{
+			"scope": ScopeGlobal, "reason": "incident", "activatedBy": "ops", "activatedAt": activatedAt,
+		}),
+		event(events.EventTradingHaltReleased, "earlier", map[string]interface{}{
+			"releasedBy": "risk", "releaseReason": "resolved", "releasedAt": activatedAt.Add(time.Hour).Format(time.RFC3339Nano),
+		}),
+		event(events.EventTradingHaltReleased, "unknown", map[string]interface{}{"releasedBy": "risk"}),
+	})
+
+	if len(halts) != 2 || halts[0].HaltID != "earlier" || halts[1].HaltID != "later" {
+		t.Fatalf("expected both halts oldest first, got %+v", halts)
+	}
+	earlier := halts[0]
+	if earlier.Status != StatusReleased || earlier.ScopeID != nil || *earlier.ReleasedBy != "risk" ||
+		!earlier.ReleasedAt.Equal(activatedAt.Add(time.Hour)) || *earlier.ReleaseReason != "resolved" {
+		t.Fatalf("unexpected released halt: %+v", earlier)
+	}
+	later := halts[1]
+	if later.Status != StatusActive || later.ScopeID == nil || *later.ScopeID != "acct-1" ||
+		!later.ActivatedAt.Equal(activatedAt.Add(time.Minute)) || later.ReleasedAt != nil {
+		t.Fatalf("unexpected active halt: %+v", later)
+	}
+
+	if active := haltsWithStatus(halts, StatusActive); len(active) != 1 || matchHalt(active, []string{"acct-1"}, nil, "trader-1").HaltID != "later" {
+		t.Fatalf("expected only the account halt to be active, got %+v", active)
+	}
+}
+
+func TestApplyHaltEventsBringsProjectedHaltsUpToDate(t *testing.T) {
+	event := func(eventType, haltID string, payload map[string]interface{}) *events.Event {
+		payload["haltId"] = haltID
+		return events.NewEvent(eventType, events.AggregateTradingControl, haltID, "ops", "user", "corr", payload)
+	}
+	activatedAt := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	projected := []Halt{
+		{HaltID: "released-since", Scope: ScopeGlobal, Status: StatusActive, ActivatedAt: activatedAt},
+		{HaltID: "still-active", Scope: ScopeGlobal, Reason: "incident", Status: StatusActive, ActivatedAt: activatedAt.Add(time.Minute)},
+	}
+
+	// The projection's checkpoint can trail the halts it has already
+	// applied, so an activation it holds may be read again
+	halts := haltsWithStatus(applyHaltEvents(projected, []*events.Event{
+		event(events.EventTradingHaltActivated, "still-active", map[string]interface{}{"scope": ScopeGlobal, "reason": "replayed"}),
+		event(events.EventTradingHaltReleased, "released-since", map[string]interface{}{"releasedBy": "risk"}),
+		event(events.EventTradingHaltActivated, "new", map[string]interface{}{
+			"scope": ScopeActor, "scopeId": "trader-1", "activatedAt": activatedAt.Add(time.Hour),
+		}),
+	}), StatusActive)
+
+	if len(halts) != 2 || halts[0].HaltID != "still-active" || halts[0].Reason != "incident" || halts[1].HaltID != "new" {
+		t.Fatalf("expected the projected halt still active and the new one, got %+v", halts)
+	}
+}
+
+func TestExceedsRateLimit(t *testing.T) {
+	limit := RateLimit{MaxOrders: 10, Window: time.Minute}
+
+	if exceedsRateLimit(limit, 9, 1) {
+		t.Fatal("expected the tenth order to be allowed")
+	}
+	if !exceedsRateLimit(limit, 10, 1) {
+		t.Fatal("expected the eleventh order to be refused")
+	}
+	if !exceedsRateLimit(limit, 5, 6) {
+		t.Fatal("expected a block that crosses the limit to be refused")
+	}
+	if exceedsRateLimit(RateLimit{MaxOrders: 0, Window: time.Minute}, 1000, 1) {
+		t.Fatal("expected a zero limit to mean no limit")
+	}
+}
+
+func TestValidateHaltInput(t *testing.T) {
+	cases := []struct {
+		input HaltInput
+		want  error
+	}{
+		{HaltInput{Scope: ScopeGlobal, Reason: "incident", ActorID: "ops"}, nil},
+		{HaltInput{Scope: ScopeAccount, ScopeID: stringPtr("acct-1"), Reason: "incident", ActorID: "ops"}, nil},
+		{HaltInput{Scope: ScopeAccount, Reason: "incident", ActorID: "ops"}, ErrMissingScopeID},
+		{HaltInput{Scope: "DESK", ScopeID: stringPtr("d"), Reason: "incident", ActorID: "ops"}, ErrInvalidScope},
+		{HaltInput{Scope: ScopeGlobal, Reason: " ", ActorID: "ops"}, ErrMissingReason},
+	}
+
+	for _, tc := range cases {
+		if err := validateHaltInput(tc.input); !errors.Is(err, tc.want) {
+			t.Errorf("validateHaltInput(%+v) = %v, want %v", tc.input, err, tc.want)
+		}
+	}
+}
+
+func TestRefusedErrorWrapsSentinel(t *testing.T) {
+	halted := &RefusedError{Reason: ReasonHalted, Detail: "GLOBAL halted by ops: incident"}
+	if !errors.Is(halted, ErrTradingHalted) || errors.Is(halted, ErrRateLimited) {
+		t.Fatalf("expected halted refusal to wrap ErrTradingHalted only: %v", halted)
+	}
+
+	throttled := &RefusedError{Reason: ReasonThrottled, Detail: "too many"}
+	if !errors.Is(throttled, ErrRateLimited) || errors.Is(throttled, ErrTradingHalted) {
+		t.Fatalf("expected throttled refusal to wrap ErrRateLimited only: %v", throttled)
+	}
+}
+
+func TestRateLimitJSONReportsWindowSeconds(t *testing.T) {
+	data, err := json.Marshal(RateLimit{ActorID: "trader-1", MaxOrders: 20, Window: 2 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"actorId":"trader-1","maxOrders":20,"windowSeconds":120}`
+	if string(data) != want {
+		t.Fatalf("got %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,351 @@
+package upload
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedFormat is returned for files that are neither CSV nor XLSX
+	ErrUnsupportedFormat = errors.New("unsupported file format: upload a .csv or .xlsx file")
+	// ErrEmptyFile is returned when a file has no header row
+	ErrEmptyFile = errors.New("file has no header row")
+	// ErrUnreadableFile is returned when a file is not valid CSV or XLSX
+	ErrUnreadableFile = errors.New("file could not be read")
+)
+
+// File formats accepted by ParseFile
+const (
+	FormatCSV  = "CSV"
+	FormatXLSX = "XLSX"
+)
+
+// Limits on what an XLSX upload may unpack to, so a small compressed file
+// cannot exhaust memory
+const (
+	// maxSheetRows is the last worksheet row read, counting the header
+	maxSheetRows = 10001
+	// maxSheetColumns is the number of columns in a worksheet, A to XFD
+	maxSheetColumns = 16384
+	// maxSheetCells caps the cells of all rows, counting the empty ones
+	// before the last cell of each row
+	maxSheetCells = 1 << 20
+	// maxPartBytes caps the uncompressed size of each part read from the package
+	maxPartBytes = 64 << 20
+)
+
+// Row is one data row of an uploaded file, keyed by normalized column name
+type Row struct {
+	Number int               `json:"rowNumber"` // 1-based, counting the header as row 1
+	Fields map[string]string `json:"fields"`
+}
+
+// Sheet is the parsed content of an uploaded file
+type Sheet struct {
+	Format  string
+	Columns []string
+	Rows    []Row
+}
+
+// ParseFile parses a CSV or XLSX upload, picking the format from the file
+// name and falling back to the zip signature XLSX files start with
+func ParseFile(fileName string, data []byte) (*Sheet, error) {
+	switch {
+	case strings.EqualFold(path.Ext(fileName), ".xlsx"), bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		records, err := readXLSX(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w as xlsx: %v", ErrUnreadableFile, err)
+		}
+		return sheetFromRecords(FormatXLSX, records)
+	case strings.EqualFold(path.Ext(fileName), ".csv"), strings.EqualFold(path.Ext(fileName), ".txt"), path.Ext(fileName) == "":
+		records, err := readCSV(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w as csv: %v", ErrUnreadableFile, err)
+		}
+		return sheetFromRecords(FormatCSV, records)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// NormalizeColumn maps a header such as "Account ID" or "limit_price" to the
+// lower-case key rows are stored under ("accountid", "limitprice")
+func NormalizeColumn(name string) string {
+	name = strings.TrimPrefix(name, "\ufeff")
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r == ' ' || r == '_' || r == '-' || r == '\t' {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func sheetFromRecords(format string, records [][]string) (*Sheet, error) {
+	if len(records) == 0 {
+		return nil, ErrEmptyFile
+	}
+
+	columns := make([]string, len(records[0]))
+	for i, name := range records[0] {
+		columns[i] = NormalizeColumn(name)
+	}
+
+	sheet := &Sheet{Format: format, Columns: columns}
+	for i, record := range records[1:] {
+		fields := map[string]string{}
+		blank := true
+		for j, value := range record {
+			if j >= len(columns) || columns[j] == "" {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			if value != "" {
+				blank = false
+			}
+			fields[columns[j]] = value
+		}
+		if blank {
+			continue
+		}
+		sheet.Rows = append(sheet.Rows, Row{Number: i + 2, Fields: fields})
+	}
+	return sheet, nil
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return reader.ReadAll()
+}
+
+// readXLSX returns the cell text of the workbook's first worksheet. It reads
+// the parts of the Office Open XML package directly: the workbook to find the
+// first sheet, the shared string table, and the sheet's cells.
+func readXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]*zip.File{}
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var sharedStrings []string
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst xlsxSharedStrings
+		if err := decodeZipXML(file, &sst); err != nil {
+			return nil, err
+		}
+		for _, item := range sst.Items {
+			sharedStrings = append(sharedStrings, item.text())
+		}
+	}
+
+	file, ok := files[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("worksheet %s not found", sheetPath)
+	}
+	var worksheet xlsxWorksheet
+	if err := decodeZipXML(file, &worksheet); err != nil {
+		return nil, err
+	}
+
+	records := [][]string{}
+	cells := 0
+	for _, row := range worksheet.Rows {
+		if row.Number > maxSheetRows || len(records) >= maxSheetRows {
+			return nil, fmt.Errorf("worksheet has more than %d rows", maxSheetRows)
+		}
+
+		record := []string{}
+		for i, cell := range row.Cells {
+			column := i
+			if cell.Ref != "" {
+				if column, err = columnIndex(cell.Ref); err != nil {
+					return nil, err
+				}
+			}
+			if column >= maxSheetColumns {
+				return nil, fmt.Errorf("row %d has more than %d columns", row.Number, maxSheetColumns)
+			}
+			if cells+column >= maxSheetCells {
+				return nil, fmt.Errorf("worksheet has more than %d cells", maxSheetCells)
+			}
+			for len(record) <= column {
+				record = append(record, "")
+			}
+			value, err := cell.value(sharedStrings)
+			if err != nil {
+				return nil, err
+			}
+			record[column] = value
+		}
+		cells += len(record)
+
+		// Skipped row numbers are empty rows
+		for row.Number > 0 && len(records) < row.Number-1 {
+			records = append(records, []string{})
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// firstSheetPath resolves the first worksheet in workbook order to its part name
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	workbookFile, ok := files["xl/workbook.xml"]
+	if !ok {
+		return "", errors.New("xl/workbook.xml not found")
+	}
+	var workbook xlsxWorkbook
+	if err := decodeZipXML(workbookFile, &workbook); err != nil {
+		return "", err
+	}
+	relsFile, ok := files["xl/_rels/workbook.xml.rels"]
+	if len(workbook.Sheets) == 0 || !ok {
+		return fallback, nil
+	}
+
+	var rels xlsxRelationships
+	if err := decodeZipXML(relsFile, &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RelID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return fallback, nil
+}
+
+func decodeZipXML(file *zip.File, v interface{}) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// Read one byte past the limit to tell a part that fits from one that does not
+	limited := &io.LimitedReader{R: reader, N: maxPartBytes + 1}
+	if err := xml.NewDecoder(limited).Decode(v); err != nil && err != io.EOF {
+		if limited.N <= 0 {
+			return fmt.Errorf("%s is larger than %d bytes", file.Name, maxPartBytes)
+		}
+		return fmt.Errorf("failed to parse %s: %w", file.Name, err)
+	}
+	if limited.N <= 0 {
+		return fmt.Errorf("%s is larger than %d bytes", file.Name, maxPartBytes)
+	}
+	return nil
+}
+
+// columnIndex converts a cell reference such as "C7" to its 0-based column
+func columnIndex(ref string) (int, error) {
+	index := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A'+1)
+		if index > maxSheetColumns {
+			return 0, fmt.Errorf("cell %s is beyond column XFD", ref)
+		}
+	}
+	if index == 0 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return index - 1, nil
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name  string `xml:"name,attr"`
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+// xlsxRichText is a string that is either plain (<t>) or a series of runs (<r><t>)
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxRichText) text() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Number int        `xml:"r,attr"`
+		Cells  []xlsxCell `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type xlsxCell struct {
+	Ref    string       `xml:"r,attr"`
+	Type   string       `xml:"t,attr"`
+	Value  string       `xml:"v"`
+	Inline xlsxRichText `xml:"is"`
+}
+
+func (c xlsxCell) value(sharedStrings []string) (string, error) {
+	switch c.Type {
+	case "s":
+		index, err := strconv.Atoi(strings.TrimSpace(c.Value))
+		if err != nil || index < 0 || index >= len(sharedStrings) {
+			return "", fmt.Errorf("cell %s refers to missing shared string %q", c.Ref, c.Value)
+		}
+		return sharedStrings[index], nil
+	case "inlineStr":
+		return c.Inline.text(), nil
+	case "b":
+		if c.Value == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	default:
+		return c.Value, nil
+	}
+}
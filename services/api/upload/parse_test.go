@@ -0,0 +1,178 @@
+package upload
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func buildXLSX(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range parts {
+		part, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+		if _, err := part.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close workbook: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseFileCSVNormalizesHeadersAndSkipsBlankRows(t *testing.T) {
+	data := []byte("Account ID,CUSIP,side,Quantity,order_type,limitPrice\n" +
+		"acct-1,912828YK0,BUY,100000,LIMIT,99.5\n" +
+		",,,,,\n" +
+		"acct-2,912828YK0,SELL,50000,MARKET,\n")
+
+	sheet, err := ParseFile("orders.csv", data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sheet.Format != FormatCSV {
+		t.Fatalf("expected CSV format, got %s", sheet.Format)
+	}
+	if len(sheet.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(sheet.Rows))
+	}
+
+	first := sheet.Rows[0]
+	if first.Number != 2 || first.Fields["accountid"] != "acct-1" || first.Fields["ordertype"] != "LIMIT" || first.Fields["limitprice"] != "99.5" {
+		t.Fatalf("unexpected first row: %+v", first)
+	}
+	if second := sheet.Rows[1]; second.Number != 4 || second.Fields["side"] != "SELL" {
+		t.Fatalf("expected the blank row to be skipped but counted, got %+v", second)
+	}
+	if missing := missingColumns(sheet.Columns); len(missing) != 0 {
+		t.Fatalf("expected no missing columns, got %v", missing)
+	}
+}
+
+func TestParseFileXLSXReadsFirstSheet(t *testing.T) {
+	data := buildXLSX(t, map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets><sheet name="Orders" sheetId="1" r:id="rId3"/><sheet name="Notes" sheetId="2" r:id="rId4"/></sheets>
+</workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/orders.xml"/>
+  <Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/notes.xml"/>
+</Relationships>`,
+		"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <si><t>accountName</t></si><si><t>cusip</t></si><si><t>side</t></si><si><t>quantity</t></si>
+  <si><t>orderType</t></si><si><t>expireAt</t></si><si><r><t>Smith </t></r><r><t>Family</t></r></si>
+</sst>`,
+		"xl/worksheets/orders.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+  <row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c><c r="D1" t="s"><v>3</v></c><c r="E1" t="s"><v>4</v></c><c r="F1" t="s"><v>5</v></c></row>
+  <row r="3"><c r="A3" t="s"><v>6</v></c><c r="B3" t="inlineStr"><is><t>912828YK0</t></is></c><c r="C3" t="str"><v>BUY</v></c><c r="D3"><v>250000</v></c><c r="E3" t="inlineStr"><is><t>MARKET</t></is></c><c r="F3"><v>46100</v></c></row>
+</sheetData></worksheet>`,
+		"xl/worksheets/notes.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+  <row r="1"><c r="A1" t="inlineStr"><is><t>not an order sheet</t></is></c></row>
+</sheetData></worksheet>`,
+	})
+
+	sheet, err := ParseFile("orders.xlsx", data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sheet.Format != FormatXLSX {
+		t.Fatalf("expected XLSX format, got %s", sheet.Format)
+	}
+	if len(sheet.Rows) != 1 {
+		t.Fatalf("expected 1 row, got %d: %+v", len(sheet.Rows), sheet.Rows)
+	}
+
+	row := sheet.Rows[0]
+	if row.Number != 3 {
+		t.Fatalf("expected the row to keep its sheet row number 3, got %d", row.Number)
+	}
+	want := map[string]string{
+		"accountname": "Smith Family",
+		"cusip":       "912828YK0",
+		"side":        "BUY",
+		"quantity":    "250000",
+		"ordertype":   "MARKET",
+	}
+	for column, value := range want {
+		if row.Fields[column] != value {
+			t.Fatalf("column %s: expected %q, got %q", column, value, row.Fields[column])
+		}
+	}
+
+	expireAt, err := parseUploadTime(row.Fields["expireat"])
+	if err != nil {
+		t.Fatalf("unexpected error parsing date serial: %v", err)
+	}
+	if !expireAt.Equal(time.Date(2026, 3, 19, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected serial 46100 to be 2026-03-19, got %s", expireAt)
+	}
+}
+
+func TestParseFileRejectsUnknownFormatsAndMissingColumns(t *testing.T) {
+	if _, err := ParseFile("orders.pdf", []byte("%PDF-1.4")); err != ErrUnsupportedFormat {
+		t.Fatalf("expected ErrUnsupportedFormat, got %v", err)
+	}
+
+	sheet, err := ParseFile("orders.csv", []byte("accountId,side,quantity\nacct-1,BUY,1000\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	missing := missingColumns(sheet.Columns)
+	if len(missing) != 2 || missing[0] != "cusip or instrumentid" || missing[1] != "ordertype" {
+		t.Fatalf("unexpected missing columns: %v", missing)
+	}
+}
+
+func TestParseFileXLSXRejectsSheetsBeyondTheLimits(t *testing.T) {
+	sheetWith := func(rows string) []byte {
+		return buildXLSX(t, map[string]string{
+			"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheets/></workbook>`,
+			"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+				`<row r="1"><c r="A1" t="inlineStr"><is><t>cusip</t></is></c></row>` + rows +
+				`</sheetData></worksheet>`,
+		})
+	}
+
+	cases := map[string]string{
+		"column beyond XFD":      `<row r="2"><c r="XFE2" t="inlineStr"><is><t>x</t></is></c></row>`,
+		"overflowing column":     `<row r="2"><c r="ZZZZZZZZZZZZZZZZ2" t="inlineStr"><is><t>x</t></is></c></row>`,
+		"row number past limit":  `<row r="2000000000"><c r="A2000000000" t="inlineStr"><is><t>x</t></is></c></row>`,
+		"too many padded cells":  strings.Repeat(`<row><c r="XFD1" t="inlineStr"><is><t>x</t></is></c></row>`, maxSheetCells/maxSheetColumns+1),
+		"invalid cell reference": `<row r="2"><c r="12" t="inlineStr"><is><t>x</t></is></c></row>`,
+	}
+	for name, rows := range cases {
+		if _, err := ParseFile("orders.xlsx", sheetWith(rows)); !errors.Is(err, ErrUnreadableFile) {
+			t.Errorf("%s: expected ErrUnreadableFile, got %v", name, err)
+		}
+	}
+
+	if _, err := ParseFile("orders.xlsx", sheetWith(`<row r="2"><c r="XFD2" t="inlineStr"><is><t>x</t></is></c></row>`)); err != nil {
+		t.Fatalf("expected column XFD to be read, got %v", err)
+	}
+}
+
+func TestParseFileXLSXRejectsOversizedParts(t *testing.T) {
+	data := buildXLSX(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheets/></workbook>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="inlineStr"><is><t>` + strings.Repeat("x", maxPartBytes) + `</t></is></c></row>` +
+			`</sheetData></worksheet>`,
+	})
+	_, err := ParseFile("orders.xlsx", data)
+	if !errors.Is(err, ErrUnreadableFile) || !strings.Contains(err.Error(), "larger than") {
+		t.Fatalf("expected the oversized worksheet to be refused, got %v", err)
+	}
+}
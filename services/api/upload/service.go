@@ -0,0 +1,526 @@
+package upload
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"instant/services/api/eventbus"
+	"instant/services/api/events"
+	"instant/services/api/eventstore"
+	"instant/services/api/oms"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrMissingColumns is returned when a file lacks a required column
+	ErrMissingColumns = errors.New("missing required columns")
+	// ErrBatchNotFound is returned when no upload batch exists with the given ID
+	ErrBatchNotFound = errors.New("upload batch not found")
+	// ErrBatchNotConfirmable is returned when a batch is not validated or was already confirmed
+	ErrBatchNotConfirmable = errors.New("upload batch must be validated and not yet confirmed")
+	// ErrNoValidRows is returned when confirming a batch without a single valid row
+	ErrNoValidRows = errors.New("upload batch has no valid rows")
+)
+
+// requiredColumns lists the columns every upload needs; each entry is a set
+// of alternatives, any one of which satisfies it
+var requiredColumns = [][]string{
+	{"accountid", "accountname"},
+	{"cusip", "instrumentid"},
+	{"side"},
+	{"quantity"},
+	{"ordertype"},
+}
+
+// Service handles order file uploads
+type Service struct {
+	db         *sql.DB
+	eventStore *eventstore.EventStore
+	eventBus   *eventbus.EventBus
+	orders     *oms.Service
+}
+
+// NewService creates a new upload service
+func NewService(db *sql.DB, es *eventstore.EventStore, eb *eventbus.EventBus, orders *oms.Service) (*Service, error) {
+	return &Service{
+		db:         db,
+		eventStore: es,
+		eventBus:   eb,
+		orders:     orders,
+	}, nil
+}
+
+// Receive parses an uploaded file and validates every row against reference
+// data and the order rules. It records UploadBatchReceived and
+// UploadBatchValidated; no orders are created until the batch is confirmed.
+func (s *Service) Receive(req ReceiveRequest, correlationID string) (*Batch, error) {
+	sheet, err := ParseFile(req.FileName, req.Data)
+	if err != nil {
+		return nil, err
+	}
+	if missing := missingColumns(sheet.Columns); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingColumns, strings.Join(missing, ", "))
+	}
+
+	batch := &Batch{
+		BatchID:    uuid.New().String(),
+		FileName:   req.FileName,
+		Format:     sheet.Format,
+		Status:     BatchStatusReceived,
+		RowCount:   len(sheet.Rows),
+		UploadedBy: req.UploadedBy,
+		ReceivedAt: time.Now().UTC(),
+	}
+
+	received := events.NewEvent(
+		events.EventUploadBatchReceived,
+		events.AggregateUploadBatch,
+		batch.BatchID,
+		req.UploadedBy,
+		"user",
+		correlationID,
+		map[string]interface{}{
+			"batchId":    batch.BatchID,
+			"fileName":   batch.FileName,
+			"format":     batch.Format,
+			"columns":    sheet.Columns,
+			"rowCount":   batch.RowCount,
+			"uploadedBy": req.UploadedBy,
+			"receivedAt": batch.ReceivedAt,
+		},
+	)
+	if err := s.appendAndPublish(received); err != nil {
+		return nil, err
+	}
+
+	validator := newRowValidator(s.db, s.orders)
+	batch.Rows = make([]RowResult, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		result, err := validator.validate(row)
+		if err != nil {
+			return nil, err
+		}
+		if result.Valid {
+			batch.ValidCount++
+		} else {
+			batch.ErrorCount++
+		}
+		batch.Rows = append(batch.Rows, result)
+	}
+	batch.Status = BatchStatusValidated
+
+	validated := events.NewEvent(
+		events.EventUploadBatchValidated,
+		events.AggregateUploadBatch,
+		batch.BatchID,
+		req.UploadedBy,
+		"user",
+		correlationID,
+		map[string]interface{}{
+			"batchId":    batch.BatchID,
+			"rows":       batch.Rows,
+			"validCount": batch.ValidCount,
+			"errorCount": batch.ErrorCount,
+		},
+	).WithCausation(received.EventID)
+	if err := s.appendAndPublish(validated); err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// Confirm creates an order for every valid row of a validated batch. The
+// orders carry the batch ID, so the batch can be viewed with its orders.
+// Rows that fail when created are reported and do not stop the others.
+//
+// The batch is claimed with UploadBatchConfirming at the version it was loaded
+// at before any order is created, so a second confirmation of the same batch
+// fails instead of creating its orders again. A batch left CONFIRMING by a
+// failure is not confirmed twice; its orders are found by batch ID.
+func (s *Service) Confirm(req ConfirmRequest, correlationID string) (*ConfirmResult, error) {
+	batch, err := s.loadBatch(req.BatchID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != BatchStatusValidated {
+		return nil, ErrBatchNotConfirmable
+	}
+	if batch.ValidCount == 0 {
+		return nil, ErrNoValidRows
+	}
+
+	confirming := events.NewEvent(
+		events.EventUploadBatchConfirming,
+		events.AggregateUploadBatch,
+		batch.BatchID,
+		req.ConfirmedBy,
+		"user",
+		correlationID,
+		map[string]interface{}{
+			"batchId":     batch.BatchID,
+			"confirmedBy": req.ConfirmedBy,
+		},
+	)
+	if err := s.eventStore.AppendExpected(confirming, batch.Version); err != nil {
+		if errors.Is(err, eventstore.ErrConcurrencyConflict) {
+			return nil, ErrBatchNotConfirmable
+		}
+		return nil, fmt.Errorf("failed to append %s event: %w", confirming.EventType, err)
+	}
+	s.eventBus.Publish(confirming)
+
+	result := &ConfirmResult{BatchID: batch.BatchID, Orders: []CreatedOrder{}}
+	for _, row := range batch.Rows {
+		if !row.Valid || row.Order == nil {
+			continue
+		}
+
+		orderReq := *row.Order
+		orderReq.BatchID = &batch.BatchID
+		orderReq.CreatedBy = req.ConfirmedBy
+
+		created := CreatedOrder{RowNumber: row.RowNumber, Status: "created"}
+		orderID, err := s.orders.CreateOrder(orderReq, correlationID)
+		created.OrderID = orderID
+		switch {
+		case err == nil:
+			result.CreatedCount++
+		case errors.Is(err, oms.ErrComplianceBlocked):
+			created.Status = "blocked"
+			created.Error = err.Error()
+			result.CreatedCount++
+		default:
+			created.Status = "failed"
+			created.Error = err.Error()
+			result.FailedCount++
+		}
+		result.Orders = append(result.Orders, created)
+	}
+
+	confirmed := events.NewEvent(
+		events.EventUploadBatchConfirmed,
+		events.AggregateUploadBatch,
+		batch.BatchID,
+		req.ConfirmedBy,
+		"user",
+		correlationID,
+		map[string]interface{}{
+			"batchId":      batch.BatchID,
+			"confirmedBy":  req.ConfirmedBy,
+			"orders":       result.Orders,
+			"createdCount": result.CreatedCount,
+			"failedCount":  result.FailedCount,
+		},
+	).WithCausation(confirming.EventID)
+	if err := s.eventStore.AppendExpected(confirmed, batch.Version+1); err != nil {
+		return nil, fmt.Errorf("failed to append %s event: %w", confirmed.EventType, err)
+	}
+	s.eventBus.Publish(confirmed)
+
+	return result, nil
+}
+
+// LoadBatch folds an upload batch's events into its current state
+func LoadBatch(batchEvents []*events.Event) (*Batch, error) {
+	batch := &Batch{Version: len(batchEvents)}
+	for _, event := range batchEvents {
+		payload := event.Payload
+		switch event.EventType {
+		case events.EventUploadBatchReceived:
+			batch.BatchID = event.Aggregate.ID
+			batch.FileName, _ = payload["fileName"].(string)
+			batch.Format, _ = payload["format"].(string)
+			batch.UploadedBy, _ = payload["uploadedBy"].(string)
+			batch.ReceivedAt = event.OccurredAt
+			batch.Status = BatchStatusReceived
+		case events.EventUploadBatchValidated:
+			if err := decodePayload(payload["rows"], &batch.Rows); err != nil {
+				return nil, fmt.Errorf("failed to decode upload rows: %w", err)
+			}
+			batch.RowCount = len(batch.Rows)
+			batch.ValidCount, batch.ErrorCount = 0, 0
+			for _, row := range batch.Rows {
+				if row.Valid {
+					batch.ValidCount++
+				} else {
+					batch.ErrorCount++
+				}
+			}
+			batch.Status = BatchStatusValidated
+		case events.EventUploadBatchConfirming:
+			batch.ConfirmedBy, _ = payload["confirmedBy"].(string)
+			batch.Status = BatchStatusConfirming
+		case events.EventUploadBatchConfirmed:
+			batch.ConfirmedBy, _ = payload["confirmedBy"].(string)
+			batch.Status = BatchStatusConfirmed
+		}
+	}
+	if batch.BatchID == "" {
+		return nil, ErrBatchNotFound
+	}
+	return batch, nil
+}
+
+func (s *Service) loadBatch(batchID string) (*Batch, error) {
+	batchEvents, err := s.eventStore.GetByAggregate(events.AggregateUploadBatch, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load upload batch events: %w", err)
+	}
+	return LoadBatch(batchEvents)
+}
+
+func (s *Service) appendAndPublish(event *events.Event) error {
+	if err := s.eventStore.Append(event); err != nil {
+		return fmt.Errorf("failed to append %s event: %w", event.EventType, err)
+	}
+	s.eventBus.Publish(event)
+	return nil
+}
+
+// rowValidator checks uploaded rows, caching reference data lookups so a
+// file that repeats an account or CUSIP queries it once
+type rowValidator struct {
+	db          *sql.DB
+	orders      *oms.Service
+	accounts    map[string]bool
+	accountIDs  map[string][]string
+	instruments map[string]bool
+}
+
+func newRowValidator(db *sql.DB, orders *oms.Service) *rowValidator {
+	return &rowValidator{
+		db:          db,
+		orders:      orders,
+		accounts:    map[string]bool{},
+		accountIDs:  map[string][]string{},
+		instruments: map[string]bool{},
+	}
+}
+
+// validate turns a row into an order request, collecting every problem with
+// the row rather than stopping at the first
+func (v *rowValidator) validate(row Row) (RowResult, error) {
+	result := RowResult{RowNumber: row.Number, Fields: row.Fields, Errors: []string{}}
+	fields := row.Fields
+	order := oms.CreateOrderRequest{}
+	termsParsed := true
+
+	accountID, err := v.resolveAccount(fields["accountid"], fields["accountname"])
+	if err != nil {
+		return result, err
+	}
+	switch {
+	case accountID != "":
+		order.AccountID = accountID
+	case fields["accountid"] != "":
+		result.Errors = append(result.Errors, fmt.Sprintf("account %q not found", fields["accountid"]))
+	case fields["accountname"] != "":
+		matches := v.accountIDs[fields["accountname"]]
+		if len(matches) > 1 {
+			result.Errors = append(result.Errors, fmt.Sprintf("account name %q matches %d accounts; use accountId", fields["accountname"], len(matches)))
+		} else {
+			result.Errors = append(result.Errors, fmt.Sprintf("account %q not found", fields["accountname"]))
+		}
+	default:
+		result.Errors = append(result.Errors, "accountId or accountName is required")
+	}
+
+	cusip := fields["cusip"]
+	if cusip == "" {
+		cusip = fields["instrumentid"]
+	}
+	if cusip == "" {
+		result.Errors = append(result.Errors, "cusip is required")
+	} else if exists, err := v.instrumentExists(cusip); err != nil {
+		return result, err
+	} else if !exists {
+		result.Errors = append(result.Errors, fmt.Sprintf("CUSIP %q not found", cusip))
+	} else {
+		order.InstrumentID = cusip
+	}
+
+	side := oms.OrderSide(strings.ToUpper(fields["side"]))
+	if side != oms.OrderSideBuy && side != oms.OrderSideSell {
+		result.Errors = append(result.Errors, "side must be BUY or SELL")
+	}
+	order.Side = side
+
+	quantity, err := strconv.ParseFloat(strings.ReplaceAll(fields["quantity"], ",", ""), 64)
+	if err != nil || math.IsNaN(quantity) {
+		result.Errors = append(result.Errors, fmt.Sprintf("quantity %q is not a number", fields["quantity"]))
+		termsParsed = false
+	}
+	order.Quantity = quantity
+
+	order.OrderType = oms.OrderType(strings.ToUpper(strings.ReplaceAll(fields["ordertype"], " ", "_")))
+	order.TimeInForce = oms.TimeInForce(strings.ToUpper(fields["timeinforce"]))
+
+	if value := fields["limitprice"]; value != "" {
+		price, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("limitPrice %q is not a number", value))
+			termsParsed = false
+		} else {
+			order.LimitPrice = &price
+		}
+	}
+	if value := fields["limityield"]; value != "" {
+		yield, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("limitYield %q is not a number", value))
+			termsParsed = false
+		} else {
+			order.LimitYield = &yield
+		}
+	}
+	if value := fields["curvespreadbp"]; value != "" {
+		spread, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("curveSpreadBp %q is not a number", value))
+			termsParsed = false
+		} else {
+			order.CurveSpreadBp = &spread
+		}
+	}
+	if value := fields["expireat"]; value != "" {
+		expireAt, err := parseUploadTime(value)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("expireAt %q is not a date", value))
+			termsParsed = false
+		} else {
+			order.ExpireAt = &expireAt
+		}
+	}
+
+	if termsParsed {
+		if err := v.orders.ValidateCreateOrderRequest(order); err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+	}
+
+	result.Valid = len(result.Errors) == 0
+	if result.Valid {
+		if order.TimeInForce == "" {
+			order.TimeInForce = oms.TimeInForceDay
+		}
+		result.Order = &order
+	}
+	return result, nil
+}
+
+// resolveAccount returns the account ID a row refers to, by ID or by a
+// unique account name, or "" if there is no such account
+func (v *rowValidator) resolveAccount(accountID, accountName string) (string, error) {
+	if accountID != "" {
+		exists, ok := v.accounts[accountID]
+		if !ok {
+			err := v.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM accounts WHERE "accountId" = $1)`, accountID).Scan(&exists)
+			if err != nil {
+				return "", fmt.Errorf("failed to look up account: %w", err)
+			}
+			v.accounts[accountID] = exists
+		}
+		if exists {
+			return accountID, nil
+		}
+		return "", nil
+	}
+	if accountName == "" {
+		return "", nil
+	}
+
+	matches, ok := v.accountIDs[accountName]
+	if !ok {
+		rows, err := v.db.Query(`SELECT "accountId" FROM accounts WHERE name = $1`, accountName)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up account: %w", err)
+		}
+		defer rows.Close()
+		matches = []string{}
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				return "", fmt.Errorf("failed to scan account: %w", err)
+			}
+			matches = append(matches, id)
+		}
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+		v.accountIDs[accountName] = matches
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	return "", nil
+}
+
+func (v *rowValidator) instrumentExists(cusip string) (bool, error) {
+	if exists, ok := v.instruments[cusip]; ok {
+		return exists, nil
+	}
+	var exists bool
+	if err := v.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM instruments WHERE cusip = $1)`, cusip).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to look up instrument: %w", err)
+	}
+	v.instruments[cusip] = exists
+	return exists, nil
+}
+
+// missingColumns lists the required columns a header row does not have
+func missingColumns(columns []string) []string {
+	present := map[string]bool{}
+	for _, column := range columns {
+		present[column] = true
+	}
+	missing := []string{}
+	for _, alternatives := range requiredColumns {
+		found := false
+		for _, column := range alternatives {
+			if present[column] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, strings.Join(alternatives, " or "))
+		}
+	}
+	return missing
+}
+
+// excelEpoch is day zero of the spreadsheet date serial numbers XLSX files
+// store dates as
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// parseUploadTime accepts RFC 3339 timestamps, plain dates and spreadsheet
+// date serial numbers
+func parseUploadTime(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.UTC(), nil
+		}
+	}
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return excelEpoch.Add(time.Duration(serial * float64(24*time.Hour))).Round(time.Second), nil
+}
+
+// decodePayload converts a payload value into a typed value. Payloads hold Go
+// values when published in-process and decoded JSON once loaded from the store.
+func decodePayload(value interface{}, target interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, target)
+}
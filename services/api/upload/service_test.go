@@ -0,0 +1,39 @@
+package upload
+
+import (
+	"testing"
+
+	"instant/services/api/events"
+)
+
+func batchEvent(eventType string, payload map[string]interface{}) *events.Event {
+	return events.NewEvent(eventType, events.AggregateUploadBatch, "batch-1", "trader", "user", "corr-1", payload)
+}
+
+func TestLoadBatchTracksTheConfirmationClaim(t *testing.T) {
+	batchEvents := []*events.Event{
+		batchEvent(events.EventUploadBatchReceived, map[string]interface{}{"batchId": "batch-1", "fileName": "orders.csv", "uploadedBy": "trader"}),
+		batchEvent(events.EventUploadBatchValidated, map[string]interface{}{"batchId": "batch-1", "rows": []interface{}{
+			map[string]interface{}{"rowNumber": 2.0, "valid": true},
+			map[string]interface{}{"rowNumber": 3.0, "valid": false, "errors": []interface{}{"unknown CUSIP"}},
+		}}),
+	}
+
+	batch, err := LoadBatch(batchEvents)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if batch.Status != BatchStatusValidated || batch.Version != 2 || batch.ValidCount != 1 {
+		t.Fatalf("expected a validated batch at version 2 with 1 valid row, got %s at %d with %d", batch.Status, batch.Version, batch.ValidCount)
+	}
+
+	// Once claimed the batch is no longer VALIDATED, so Confirm refuses it
+	batchEvents = append(batchEvents, batchEvent(events.EventUploadBatchConfirming, map[string]interface{}{"batchId": "batch-1", "confirmedBy": "pm-1"}))
+	batch, err = LoadBatch(batchEvents)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if batch.Status != BatchStatusConfirming || batch.ConfirmedBy != "pm-1" || batch.Version != 3 {
+		t.Fatalf("expected a batch being confirmed by pm-1 at version 3, got %s by %s at %d", batch.Status, batch.ConfirmedBy, batch.Version)
+	}
+}
@@ -0,0 +1,72 @@
+package upload
+
+import (
+	"instant/services/api/oms"
+	"time"
+)
+
+// BatchStatus is the lifecycle state of an upload batch
+type BatchStatus string
+
+const (
+	BatchStatusReceived   BatchStatus = "RECEIVED"
+	BatchStatusValidated  BatchStatus = "VALIDATED"
+	BatchStatusConfirming BatchStatus = "CONFIRMING"
+	BatchStatusConfirmed  BatchStatus = "CONFIRMED"
+)
+
+// ReceiveRequest is an uploaded order file
+type ReceiveRequest struct {
+	FileName   string
+	Data       []byte
+	UploadedBy string
+}
+
+// ConfirmRequest asks for the valid rows of a validated batch to be created as orders
+type ConfirmRequest struct {
+	BatchID     string `json:"batchId"`
+	ConfirmedBy string `json:"confirmedBy"`
+}
+
+// RowResult is the validation outcome of one uploaded row
+type RowResult struct {
+	RowNumber int                     `json:"rowNumber"`
+	Valid     bool                    `json:"valid"`
+	Errors    []string                `json:"errors"`
+	Fields    map[string]string       `json:"fields"`
+	Order     *oms.CreateOrderRequest `json:"order,omitempty"`
+}
+
+// Batch is an uploaded file and the validation of each of its rows
+type Batch struct {
+	BatchID     string      `json:"batchId"`
+	FileName    string      `json:"fileName"`
+	Format      string      `json:"format"`
+	Status      BatchStatus `json:"status"`
+	RowCount    int         `json:"rowCount"`
+	ValidCount  int         `json:"validCount"`
+	ErrorCount  int         `json:"errorCount"`
+	Rows        []RowResult `json:"rows"`
+	UploadedBy  string      `json:"uploadedBy"`
+	ReceivedAt  time.Time   `json:"receivedAt"`
+	ConfirmedBy string      `json:"confirmedBy,omitempty"`
+
+	// Version is the number of events the batch was loaded from
+	Version int `json:"-"`
+}
+
+// CreatedOrder reports the order created for one confirmed row
+type CreatedOrder struct {
+	RowNumber int    `json:"rowNumber"`
+	OrderID   string `json:"orderId,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ConfirmResult reports the orders created when a batch is confirmed
+type ConfirmResult struct {
+	BatchID      string         `json:"batchId"`
+	Orders       []CreatedOrder `json:"orders"`
+	CreatedCount int            `json:"createdCount"`
+	FailedCount  int            `json:"failedCount"`
+}
@@ -0,0 +1,280 @@
+// Package mockbroker is a local stand-in for an external execution venue. It
+// accepts orders over HTTP and fills them in clips at a fixed spread from the
+// reference price the EMS sends. The fills so far are returned in the
+// response, and an order still working can be polled for the rest.
+package mockbroker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Order statuses
+const (
+	StatusFilled          = "FILLED"
+	StatusPartiallyFilled = "PARTIALLY_FILLED"
+	StatusCancelled       = "CANCELLED"
+)
+
+// OrderRequest is an order as the EMS submits it. ReferencePrice is the
+// EMS's baseline clean price; LimitPrice is a clean price.
+type OrderRequest struct {
+	ClientOrderID  string   `json:"clientOrderId"`
+	InstrumentID   string   `json:"instrumentId"`
+	Side           string   `json:"side"`
+	Quantity       float64  `json:"quantity"`
+	OrderType      string   `json:"orderType"`
+	LimitPrice     *float64 `json:"limitPrice,omitempty"`
+	TimeInForce    string   `json:"timeInForce"`
+	ReferencePrice float64  `json:"referencePrice"`
+}
+
+// Fill is one fill of an order
+type Fill struct {
+	FillID    string    `json:"fillId"`
+	Quantity  float64   `json:"quantity"`
+	Price     float64   `json:"price"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// OrderResponse is an order's state at the broker. Fills lists every fill
+// the order has had.
+type OrderResponse struct {
+	OrderID           string  `json:"orderId"`
+	ClientOrderID     string  `json:"clientOrderId"`
+	Status            string  `json:"status"`
+	FilledQuantity    float64 `json:"filledQuantity"`
+	LeavesQuantity    float64 `json:"leavesQuantity"`
+	CancelledQuantity float64 `json:"cancelledQuantity"`
+	Fills             []Fill  `json:"fills"`
+}
+
+// Broker fills orders in clips of ClipSize, each SpreadBps through the
+// reference price. A limit order whose clip price breaches the limit fills at
+// the limit, unless it is IOC, when nothing fills. IOC orders take one clip
+// and the broker cancels the rest. With a ClipInterval, an order fills one
+// clip on submission and another each interval after, until it fills or is
+// cancelled; without one it fills straight away.
+type Broker struct {
+	SpreadBps    float64
+	ClipSize     float64
+	ClipInterval time.Duration
+
+	mu     sync.Mutex
+	orders map[string]*workingOrder
+}
+
+// workingOrder is an order as the broker works it
+type workingOrder struct {
+	request     OrderRequest
+	price       float64
+	submittedAt time.Time
+	state       *OrderResponse
+}
+
+// New creates a mock broker
+func New(spreadBps, clipSize float64) *Broker {
+	return &Broker{
+		SpreadBps: spreadBps,
+		ClipSize:  clipSize,
+		orders:    make(map[string]*workingOrder),
+	}
+}
+
+// Handler serves the broker's API:
+//
+//	POST /orders                        submit an order
+//	GET  /orders/{clientOrderId}        an order's state
+//	POST /orders/{clientOrderId}/cancel cancel what is left of an order
+func (b *Broker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /orders", b.handleSubmit)
+	mux.HandleFunc("GET /orders/{id}", b.handleGet)
+	mux.HandleFunc("POST /orders/{id}/cancel", b.handleCancel)
+	return mux
+}
+
+// Submit works an order and returns its state
+func (b *Broker) Submit(req OrderRequest) (*OrderResponse, error) {
+	if req.ClientOrderID == "" {
+		return nil, errors.New("clientOrderId is required")
+	}
+	if req.Side != "BUY" && req.Side != "SELL" {
+		return nil, errors.New("side must be BUY or SELL")
+	}
+	if req.Quantity <= 0 {
+		return nil, errors.New("quantity must be positive")
+	}
+	if req.ReferencePrice <= 0 {
+		return nil, errors.New("referencePrice must be positive")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, exists := b.orders[req.ClientOrderID]; exists {
+		return nil, fmt.Errorf("order %s already submitted", req.ClientOrderID)
+	}
+
+	sideMultiplier := 1.0
+	if req.Side == "SELL" {
+		sideMultiplier = -1.0
+	}
+	price := req.ReferencePrice * (1 + b.SpreadBps*sideMultiplier/10000)
+	fillable := true
+	if req.LimitPrice != nil {
+		breachesLimit := (req.Side == "BUY" && price > *req.LimitPrice) ||
+			(req.Side == "SELL" && price < *req.LimitPrice)
+		if breachesLimit {
+			price = *req.LimitPrice
+			fillable = req.TimeInForce != "IOC"
+		}
+	}
+
+	order := &workingOrder{
+		request:     req,
+		price:       price,
+		submittedAt: time.Now().UTC(),
+		state: &OrderResponse{
+			OrderID:        uuid.New().String(),
+			ClientOrderID:  req.ClientOrderID,
+			Status:         StatusPartiallyFilled,
+			LeavesQuantity: req.Quantity,
+			Fills:          []Fill{},
+		},
+	}
+	if fillable {
+		b.work(order, order.submittedAt)
+	}
+	if req.TimeInForce == "IOC" && order.state.LeavesQuantity > 0 {
+		order.state.cancel()
+	}
+
+	b.orders[req.ClientOrderID] = order
+	return order.state.copy(), nil
+}
+
+// work fills the clips an open order is due by now. IOC orders only ever get
+// their first clip.
+func (b *Broker) work(order *workingOrder, now time.Time) {
+	state := order.state
+	if state.Status != StatusPartiallyFilled || state.LeavesQuantity <= 0 {
+		return
+	}
+
+	clipSize := b.ClipSize
+	if clipSize <= 0 {
+		clipSize = order.request.Quantity
+	}
+	clipsDue := math.MaxInt
+	if b.ClipInterval > 0 {
+		clipsDue = 1 + int(now.Sub(order.submittedAt)/b.ClipInterval)
+	}
+	if order.request.TimeInForce == "IOC" {
+		clipsDue = 1
+	}
+
+	for state.LeavesQuantity > 0 && len(state.Fills) < clipsDue {
+		quantity := math.Min(clipSize, state.LeavesQuantity)
+		timestamp := now
+		if b.ClipInterval > 0 {
+			timestamp = order.submittedAt.Add(time.Duration(len(state.Fills)) * b.ClipInterval)
+		}
+		state.Fills = append(state.Fills, Fill{
+			FillID:    uuid.New().String(),
+			Quantity:  quantity,
+			Price:     order.price,
+			Timestamp: timestamp,
+		})
+		state.FilledQuantity += quantity
+		state.LeavesQuantity -= quantity
+	}
+	if state.LeavesQuantity <= 0 {
+		state.LeavesQuantity = 0
+		state.Status = StatusFilled
+	}
+}
+
+// Cancel cancels what is left of an order. Cancelling an order with nothing
+// left returns it unchanged.
+func (b *Broker) Cancel(clientOrderID string) (*OrderResponse, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	order, ok := b.orders[clientOrderID]
+	if !ok {
+		return nil, false
+	}
+	b.work(order, time.Now().UTC())
+	if order.state.LeavesQuantity > 0 {
+		order.state.cancel()
+	}
+	return order.state.copy(), true
+}
+
+// Order returns an order's state, with the clips it has filled by now
+func (b *Broker) Order(clientOrderID string) (*OrderResponse, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	order, ok := b.orders[clientOrderID]
+	if !ok {
+		return nil, false
+	}
+	b.work(order, time.Now().UTC())
+	return order.state.copy(), true
+}
+
+func (o *OrderResponse) cancel() {
+	o.CancelledQuantity += o.LeavesQuantity
+	o.LeavesQuantity = 0
+	o.Status = StatusCancelled
+}
+
+func (o *OrderResponse) copy() *OrderResponse {
+	copied := *o
+	copied.Fills = append([]Fill{}, o.Fills...)
+	return &copied
+}
+
+func (b *Broker) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	var req OrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	order, err := b.Submit(req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, order)
+}
+
+func (b *Broker) handleGet(w http.ResponseWriter, r *http.Request) {
+	order, ok := b.Order(r.PathValue("id"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "order not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, order)
+}
+
+func (b *Broker) handleCancel(w http.ResponseWriter, r *http.Request) {
+	order, ok := b.Cancel(r.PathValue("id"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "order not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, order)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"instant/services/mockbroker"
+)
+
+func main() {
+	address := getEnv("MOCK_BROKER_ADDRESS", ":8090")
+	broker := mockbroker.New(getFloat("MOCK_BROKER_SPREAD_BPS", 1.0), getFloat("MOCK_BROKER_CLIP_SIZE", 50000))
+	broker.ClipInterval = getDuration("MOCK_BROKER_CLIP_INTERVAL", 0)
+
+	log.Printf("Mock broker listening on %s (spread %.2fbp, clips of %.0f every %s)", address, broker.SpreadBps, broker.ClipSize, broker.ClipInterval)
+	if err := http.ListenAndServe(address, broker.Handler()); err != nil {
+		log.Fatalln("Mock broker stopped", err)
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}